@@ -0,0 +1,304 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package backfill splits a time range into partition sized ranges and loads them with bounded
+// parallelism, retrying failed ranges and reporting per-range progress.
+//
+// A typical use re-populates a table from a source with INSERT SELECT:
+//
+//	results, err := backfill.Run(ctx, conn, backfill.Config{
+//		Query: "INSERT INTO events SELECT * FROM events_raw WHERE ts >= @from AND ts < @to",
+//		From:  from,
+//		To:    to,
+//	})
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+var ErrInvalidConf = errors.New("clickhouse [backfill]: invalid configuration")
+
+// Range is the half-open time range [From, To) of one partition.
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+func (r Range) String() string {
+	return fmt.Sprintf("[%s, %s)", r.From.Format(time.RFC3339), r.To.Format(time.RFC3339))
+}
+
+// Split divides [from, to) into ranges.
+type Split func(from, to time.Time) []Range
+
+// Monthly splits by calendar month, matching PARTITION BY toYYYYMM(...).
+func Monthly(from, to time.Time) []Range {
+	return split(from, to, func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).AddDate(0, 1, 0)
+	})
+}
+
+// Daily splits by calendar day, matching PARTITION BY toDate(...).
+func Daily(from, to time.Time) []Range {
+	return split(from, to, func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).AddDate(0, 0, 1)
+	})
+}
+
+// Every splits into ranges of d, aligned to multiples of d since the zero time.
+func Every(d time.Duration) Split {
+	return func(from, to time.Time) []Range {
+		return split(from, to, func(t time.Time) time.Time {
+			return t.Truncate(d).Add(d)
+		})
+	}
+}
+
+func split(from, to time.Time, next func(time.Time) time.Time) (ranges []Range) {
+	for start := from; start.Before(to); {
+		end := next(start)
+		if end.After(to) {
+			end = to
+		}
+		ranges = append(ranges, Range{From: start, To: end})
+		start = end
+	}
+	return ranges
+}
+
+type Config struct {
+	// Query is executed for every range with the named parameters @from and @to bound to its bounds.
+	Query string
+	// Load, if set, is called for every range instead of executing Query.
+	Load func(ctx context.Context, conn driver.Conn, r Range) error
+	// From and To delimit the backfilled time range [From, To).
+	From time.Time
+	To   time.Time
+	// Split divides the time range into ranges, it should match the partition key of the table. Default Monthly.
+	Split Split
+	// Parallelism is the number of ranges loaded at once. Default 1.
+	Parallelism int
+	// MaxRetries is the number of times a failed range is retried. Default 3, a negative value disables retries.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, doubled on each attempt. Default 1 second.
+	RetryBackoff time.Duration
+	// OnProgress is called when a range starts, fails an attempt, completes or is skipped. It may be called concurrently.
+	OnProgress func(Progress)
+	// Completed are ranges loaded by a previous, interrupted run, e.g. persisted from the StateDone
+	// progress reports. They are skipped to resume the backfill.
+	Completed []Range
+}
+
+func (c Config) setDefaults() Config {
+	if c.Split == nil {
+		c.Split = Monthly
+	}
+	if c.Parallelism <= 0 {
+		c.Parallelism = 1
+	}
+	if c.MaxRetries < 0 {
+		c.MaxRetries = 0
+	} else if c.MaxRetries == 0 {
+		c.MaxRetries = 3
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = time.Second
+	}
+	return c
+}
+
+func (c Config) completed(r Range) bool {
+	for _, done := range c.Completed {
+		if done.From.Equal(r.From) && done.To.Equal(r.To) {
+			return true
+		}
+	}
+	return false
+}
+
+type State uint8
+
+const (
+	StateRunning State = iota
+	StateRetrying
+	StateDone
+	StateFailed
+	StateSkipped
+)
+
+func (s State) String() string {
+	switch s {
+	case StateRunning:
+		return "running"
+	case StateRetrying:
+		return "retrying"
+	case StateDone:
+		return "done"
+	case StateFailed:
+		return "failed"
+	case StateSkipped:
+		return "skipped"
+	}
+	return ""
+}
+
+// Progress reports the state of one range.
+type Progress struct {
+	Range   Range
+	State   State
+	Attempt int
+	// WrittenRows is the number of rows written by Query, as reported by the server.
+	WrittenRows uint64
+	Elapsed     time.Duration
+	Err         error
+}
+
+// Result is the outcome of one range.
+type Result struct {
+	Range       Range
+	Attempts    int
+	WrittenRows uint64
+	Elapsed     time.Duration
+	Err         error
+}
+
+// Run loads every range of the configured time range. It returns the results in range order and,
+// if any range failed after its retries, an error wrapping the first failure.
+func Run(ctx context.Context, conn driver.Conn, config Config) ([]Result, error) {
+	if len(config.Query) == 0 && config.Load == nil {
+		return nil, fmt.Errorf("%w: query and load are empty", ErrInvalidConf)
+	}
+	if !config.From.Before(config.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidConf)
+	}
+	config = config.setDefaults()
+	var (
+		ranges  = config.Split(config.From, config.To)
+		results = make([]Result, len(ranges))
+		jobs    = make(chan int)
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < config.Parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if config.completed(ranges[i]) {
+					results[i] = Result{Range: ranges[i]}
+					if config.OnProgress != nil {
+						config.OnProgress(Progress{Range: ranges[i], State: StateSkipped})
+					}
+					continue
+				}
+				results[i] = load(ctx, conn, config, ranges[i])
+			}
+		}()
+	}
+	for i := range ranges {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			for ; i < len(ranges); i++ {
+				results[i] = Result{Range: ranges[i], Err: ctx.Err()}
+			}
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	var (
+		failed int
+		first  error
+	)
+	for _, result := range results {
+		if result.Err != nil {
+			if failed++; first == nil {
+				first = fmt.Errorf("range %s: %w", result.Range, result.Err)
+			}
+		}
+	}
+	if failed != 0 {
+		return results, fmt.Errorf("clickhouse [backfill]: %d of %d ranges failed: %w", failed, len(results), first)
+	}
+	return results, nil
+}
+
+func load(ctx context.Context, conn driver.Conn, config Config, r Range) Result {
+	var (
+		start   = time.Now()
+		result  = Result{Range: r}
+		backoff = config.RetryBackoff
+	)
+	report := func(state State) {
+		if config.OnProgress != nil {
+			config.OnProgress(Progress{
+				Range:       r,
+				State:       state,
+				Attempt:     result.Attempts,
+				WrittenRows: result.WrittenRows,
+				Elapsed:     time.Since(start),
+				Err:         result.Err,
+			})
+		}
+	}
+	for {
+		result.Attempts++
+		report(StateRunning)
+		var written uint64
+		if result.Err = exec(ctx, conn, config, r, &written); result.Err == nil {
+			result.WrittenRows = written
+			break
+		}
+		if result.Attempts > config.MaxRetries || ctx.Err() != nil {
+			result.Elapsed = time.Since(start)
+			report(StateFailed)
+			return result
+		}
+		report(StateRetrying)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			result.Err, result.Elapsed = ctx.Err(), time.Since(start)
+			report(StateFailed)
+			return result
+		}
+		backoff *= 2
+	}
+	result.Elapsed = time.Since(start)
+	report(StateDone)
+	return result
+}
+
+func exec(ctx context.Context, conn driver.Conn, config Config, r Range, written *uint64) error {
+	if config.Load != nil {
+		return config.Load(ctx, conn, r)
+	}
+	ctx = clickhouse.Context(ctx, clickhouse.WithProgress(func(p *clickhouse.Progress) {
+		*written += p.WroteRows
+	}))
+	return conn.Exec(ctx, config.Query, clickhouse.Named("from", r.From), clickhouse.Named("to", r.To))
+}
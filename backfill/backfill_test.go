@@ -0,0 +1,133 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package backfill
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	var (
+		from = time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)
+		to   = time.Date(2023, 3, 10, 0, 0, 0, 0, time.UTC)
+	)
+	assert.Equal(t, []Range{
+		{From: from, To: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{From: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{From: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC), To: to},
+	}, Monthly(from, to))
+	assert.Len(t, Daily(from, to), 54)
+	assert.Equal(t, []Range{
+		{From: from, To: from.Add(12 * time.Hour)},
+		{From: from.Add(12 * time.Hour), To: from.Add(24 * time.Hour)},
+	}, Every(12*time.Hour)(from, from.Add(24*time.Hour)))
+	assert.Empty(t, Monthly(to, from))
+}
+
+func TestRun(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts = make(map[time.Time]int)
+		states   []State
+	)
+	results, err := Run(context.Background(), nil, Config{
+		From:         time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:           time.Date(2023, 1, 5, 0, 0, 0, 0, time.UTC),
+		Split:        Daily,
+		Parallelism:  2,
+		RetryBackoff: time.Millisecond,
+		Load: func(ctx context.Context, conn driver.Conn, r Range) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if attempts[r.From]++; r.From.Day() == 2 && attempts[r.From] == 1 {
+				return errors.New("transient")
+			}
+			return nil
+		},
+		OnProgress: func(p Progress) {
+			mu.Lock()
+			defer mu.Unlock()
+			states = append(states, p.State)
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	for i, result := range results {
+		assert.Equal(t, i+1, result.Range.From.Day())
+		assert.NoError(t, result.Err)
+	}
+	assert.Equal(t, 2, results[1].Attempts)
+	assert.Contains(t, states, StateRetrying)
+	assert.Contains(t, states, StateDone)
+}
+
+func TestRunFailure(t *testing.T) {
+	failure := errors.New("failure")
+	results, err := Run(context.Background(), nil, Config{
+		From:       time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:         time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+		MaxRetries: -1,
+		Load: func(ctx context.Context, conn driver.Conn, r Range) error {
+			if r.From.Month() == time.February {
+				return failure
+			}
+			return nil
+		},
+	})
+	require.ErrorIs(t, err, failure)
+	require.Len(t, results, 2)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, 1, results[1].Attempts)
+
+	_, err = Run(context.Background(), nil, Config{})
+	assert.ErrorIs(t, err, ErrInvalidConf)
+}
+
+func TestRunCompleted(t *testing.T) {
+	var (
+		loaded  []int
+		skipped []int
+	)
+	results, err := Run(context.Background(), nil, Config{
+		From:      time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:        time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC),
+		Completed: []Range{{From: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)}},
+		Load: func(ctx context.Context, conn driver.Conn, r Range) error {
+			loaded = append(loaded, int(r.From.Month()))
+			return nil
+		},
+		OnProgress: func(p Progress) {
+			if p.State == StateSkipped {
+				skipped = append(skipped, int(p.Range.From.Month()))
+			}
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, []int{1, 3}, loaded)
+	assert.Equal(t, []int{2}, skipped)
+	assert.Equal(t, 0, results[1].Attempts)
+}
@@ -0,0 +1,109 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// SourcePlaceholder stands for the source table in View.Select.
+const SourcePlaceholder = "{source}"
+
+// View is a materialized view populated by RunView.
+type View struct {
+	// Name of the materialized view, optionally qualified with its database.
+	Name string
+	// Target is the table the view writes to. TargetDDL, e.g. a CREATE TABLE IF NOT EXISTS statement,
+	// creates it when set.
+	Target    string
+	TargetDDL string
+	// Source is the table the view reads from, optionally qualified with its database.
+	Source string
+	// Select is the SELECT of the view, reading FROM SourcePlaceholder.
+	Select string
+	// TimeColumn is the time column of Source splitting the rows between the view and the backfill.
+	TimeColumn string
+}
+
+func (v View) validate() error {
+	switch {
+	case len(v.Name) == 0 || len(v.Target) == 0 || len(v.Source) == 0 || len(v.TimeColumn) == 0:
+		return fmt.Errorf("%w: view name, target, source and time column are required", ErrInvalidConf)
+	case !strings.Contains(v.Select, SourcePlaceholder):
+		return fmt.Errorf("%w: view select must read from %s", ErrInvalidConf, SourcePlaceholder)
+	}
+	return nil
+}
+
+// source replaces the source table of the SELECT with a subquery filtered by where.
+func (v View) source(where string) string {
+	table := v.Source
+	if i := strings.LastIndex(table, "."); i != -1 {
+		table = table[i+1:]
+	}
+	subquery := fmt.Sprintf("(SELECT * FROM %s WHERE %s) AS `%s`", v.Source, where, strings.Trim(table, "`"))
+	return strings.ReplaceAll(v.Select, SourcePlaceholder, subquery)
+}
+
+func (v View) ddl() string {
+	return fmt.Sprintf("CREATE MATERIALIZED VIEW IF NOT EXISTS %s TO %s AS %s", v.Name, v.Target, v.source(v.TimeColumn+" >= @to"))
+}
+
+func (v View) query() string {
+	return fmt.Sprintf("INSERT INTO %s %s", v.Target, v.source(fmt.Sprintf("%[1]s >= @from AND %[1]s < @to", v.TimeColumn)))
+}
+
+// RunView populates a materialized view without downtime, unlike POPULATE which misses the rows inserted
+// while it runs. It creates the target table and attaches the view for the rows with TimeColumn at or
+// after config.To, then waits until config.To has passed and loads the rows before it with Run, split
+// into partition sized ranges. config.To should therefore be shortly in the future and rows should not
+// arrive later than their TimeColumn. An interrupted backfill is resumed by calling RunView again with
+// the loaded ranges as config.Completed.
+func RunView(ctx context.Context, conn driver.Conn, view View, config Config) ([]Result, error) {
+	if err := view.validate(); err != nil {
+		return nil, err
+	}
+	if !config.From.Before(config.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidConf)
+	}
+	if len(view.TargetDDL) != 0 {
+		if err := conn.Exec(ctx, view.TargetDDL); err != nil {
+			return nil, err
+		}
+	}
+	if err := conn.Exec(ctx, view.ddl(), clickhouse.Named("to", config.To)); err != nil {
+		return nil, err
+	}
+	if wait := time.Until(config.To); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	config.Query, config.Load = view.query(), nil
+	return Run(ctx, conn, config)
+}
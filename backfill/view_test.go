@@ -0,0 +1,66 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package backfill
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type viewConn struct {
+	driver.Conn
+	exec []string
+}
+
+func (c *viewConn) Exec(ctx context.Context, query string, args ...interface{}) error {
+	c.exec = append(c.exec, query)
+	return nil
+}
+
+func TestRunView(t *testing.T) {
+	var (
+		conn = &viewConn{}
+		view = View{
+			Name:       "db.daily_mv",
+			Target:     "db.daily",
+			TargetDDL:  "CREATE TABLE IF NOT EXISTS db.daily (day Date, n UInt64) Engine SummingMergeTree ORDER BY day",
+			Source:     "db.events",
+			Select:     "SELECT toDate(ts) AS day, count() AS n FROM {source} GROUP BY day",
+			TimeColumn: "ts",
+		}
+	)
+	results, err := RunView(context.Background(), conn, view, Config{
+		From: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	require.Len(t, conn.exec, 4)
+	assert.Equal(t, view.TargetDDL, conn.exec[0])
+	assert.Equal(t, "CREATE MATERIALIZED VIEW IF NOT EXISTS db.daily_mv TO db.daily AS SELECT toDate(ts) AS day, count() AS n FROM (SELECT * FROM db.events WHERE ts >= @to) AS `events` GROUP BY day", conn.exec[1])
+	assert.Equal(t, "INSERT INTO db.daily SELECT toDate(ts) AS day, count() AS n FROM (SELECT * FROM db.events WHERE ts >= @from AND ts < @to) AS `events` GROUP BY day", conn.exec[2])
+
+	view.Select = "SELECT * FROM db.events"
+	_, err = RunView(context.Background(), conn, view, Config{})
+	assert.ErrorIs(t, err, ErrInvalidConf)
+}
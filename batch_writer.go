@@ -0,0 +1,197 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+)
+
+var ErrBatchWriterUnsupported = errors.New("clickhouse [batch writer]: only native protocol connections opened with clickhouse.Open are supported")
+
+// BatchWriter is a goroutine-safe alternative to driver.Batch. Rows are appended to one of several
+// column buffers (shards) so concurrent writers rarely contend, and each shard is sent as its own data block.
+type BatchWriter struct {
+	mu       sync.RWMutex
+	next     uint32
+	batch    *batch
+	prepared driver.Batch // returned by PrepareBatch: batch or its wrapper, e.g. of an interceptor
+	shards   []*batchShard
+	mapping  *ColumnMapping
+}
+
+type batchShard struct {
+	sync.Mutex
+	err   error
+	block *proto.Block
+}
+
+// NewBatchWriter prepares an insert on conn and returns a BatchWriter with the given number of shards.
+// If shards <= 0 one shard is created, which serializes all appends.
+func NewBatchWriter(ctx context.Context, conn driver.Conn, query string, shards int) (*BatchWriter, error) {
+	ch, ok := conn.(*clickhouse)
+	if !ok {
+		return nil, ErrBatchWriterUnsupported
+	}
+	if shards <= 0 {
+		shards = 1
+	}
+	b, err := ch.PrepareBatch(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	nb, ok := nativeBatch(b)
+	if !ok {
+		b.Abort()
+		return nil, ErrBatchWriterUnsupported
+	}
+	w := &BatchWriter{
+		batch:    nb,
+		prepared: b,
+		shards:   make([]*batchShard, 0, shards),
+		mapping:  queryOptions(ctx).columnMapping,
+	}
+	for i := 0; i < shards; i++ {
+		block := &proto.Block{Timezone: nb.block.Timezone, Conversion: nb.block.Conversion, FixedString: nb.block.FixedString, BoolMapping: nb.block.BoolMapping}
+		for _, col := range nb.block.Columns {
+			if err := block.AddColumn(col.Name(), col.Type()); err != nil {
+				b.Abort()
+				return nil, err
+			}
+		}
+		w.shards = append(w.shards, &batchShard{block: block})
+	}
+	return w, nil
+}
+
+// shard returns a locked shard, preferring one that is not in use by another goroutine.
+func (w *BatchWriter) shard() *batchShard {
+	start := int(atomic.AddUint32(&w.next, 1))
+	for i := range w.shards {
+		if s := w.shards[(start+i)%len(w.shards)]; s.TryLock() {
+			return s
+		}
+	}
+	s := w.shards[start%len(w.shards)]
+	s.Lock()
+	return s
+}
+
+func (w *BatchWriter) Append(v ...interface{}) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.batch.sent {
+		return ErrBatchAlreadySent
+	}
+	v, err := convertRow("batch.Append", w.batch.converters, w.batch.block.ColumnsNames(), v)
+	if err != nil {
+		return err
+	}
+	s := w.shard()
+	defer s.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	if err := w.batch.appendBlock(s.block, v); err != nil {
+		// a partially appended row leaves the shard columns misaligned
+		s.err = fmt.Errorf("%w: %s", ErrBatchInvalid, err)
+		return err
+	}
+	return nil
+}
+
+func (w *BatchWriter) AppendStruct(v interface{}) error {
+	values, err := w.batch.conn.structMap.MapColumns("AppendStruct", w.batch.block.ColumnsNames(), v, false, w.mapping)
+	if err != nil {
+		return err
+	}
+	return w.Append(values...)
+}
+
+// Rows returns the number of rows buffered and not yet flushed.
+func (w *BatchWriter) Rows() (rows int) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, s := range w.shards {
+		s.Lock()
+		rows += s.block.Rows()
+		s.Unlock()
+	}
+	return rows
+}
+
+// Flush sends all buffered rows to the server without finishing the insert.
+func (w *BatchWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.batch.sent {
+		return ErrBatchAlreadySent
+	}
+	return w.flush()
+}
+
+func (w *BatchWriter) flush() error {
+	for _, s := range w.shards {
+		if s.err != nil {
+			return s.err
+		}
+	}
+	for _, s := range w.shards {
+		if s.block.Rows() == 0 {
+			continue
+		}
+		if err := w.batch.sendBlock(s.block); err != nil {
+			w.batch.err = err
+			return err
+		}
+		s.block.Reset()
+	}
+	return nil
+}
+
+// Send flushes every shard and completes the insert.
+func (w *BatchWriter) Send() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.batch.sent {
+		return ErrBatchAlreadySent
+	}
+	if err := w.flush(); err != nil {
+		w.prepared.Abort()
+		return err
+	}
+	return w.prepared.Send()
+}
+
+func (w *BatchWriter) Abort() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.prepared.Abort()
+}
+
+func (w *BatchWriter) IsSent() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.batch.sent
+}
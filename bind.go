@@ -66,12 +66,11 @@ func bind(tz *time.Location, query string, args ...interface{}) (string, error)
 		return query, nil
 	}
 	var (
+		template       = bindTemplates.get(query)
 		haveNamed      bool
-		haveNumeric    bool
-		havePositional bool
+		haveNumeric    = len(template.numeric) != 0
+		havePositional = len(template.positional) != 0
 	)
-	haveNumeric = bindNumericRe.MatchString(query)
-	havePositional = bindPositionalRe.MatchString(query)
 	if haveNumeric && havePositional {
 		return "", ErrBindMixedParamsFormats
 	}
@@ -86,17 +85,15 @@ func bind(tz *time.Location, query string, args ...interface{}) (string, error)
 		}
 	}
 	if haveNamed {
-		return bindNamed(tz, query, args...)
+		return bindNamed(tz, template, query, args...)
 	}
 	if haveNumeric {
-		return bindNumeric(tz, query, args...)
+		return bindNumeric(tz, template, query, args...)
 	}
-	return bindPositional(tz, query, args...)
+	return bindPositional(tz, template, query, args...)
 }
 
-var bindPositionCharRe = regexp.MustCompile(`[?]`)
-
-func bindPositional(tz *time.Location, query string, args ...interface{}) (_ string, err error) {
+func bindPositional(tz *time.Location, template *bindTemplate, query string, args ...interface{}) (_ string, err error) {
 	var (
 		unbind = make(map[int]struct{})
 		params = make([]string, len(args))
@@ -113,16 +110,14 @@ func bindPositional(tz *time.Location, query string, args ...interface{}) (_ str
 		}
 	}
 	i := 0
-	query = bindPositionalRe.ReplaceAllStringFunc(query, func(n string) string {
+	query = template.replace(query, template.positional, func(n string) string {
 		if i >= len(params) {
 			unbind[i] = struct{}{}
 			return ""
 		}
 		val := params[i]
 		i++
-		return bindPositionCharRe.ReplaceAllStringFunc(n, func(m string) string {
-			return val
-		})
+		return strings.ReplaceAll(n, "?", val)
 	})
 	for param := range unbind {
 		return "", fmt.Errorf("have no arg for param ? at position %d", param)
@@ -131,7 +126,7 @@ func bindPositional(tz *time.Location, query string, args ...interface{}) (_ str
 	return strings.ReplaceAll(query, "\\?", "?"), nil
 }
 
-func bindNumeric(tz *time.Location, query string, args ...interface{}) (_ string, err error) {
+func bindNumeric(tz *time.Location, template *bindTemplate, query string, args ...interface{}) (_ string, err error) {
 	var (
 		unbind = make(map[string]struct{})
 		params = make(map[string]string)
@@ -148,7 +143,7 @@ func bindNumeric(tz *time.Location, query string, args ...interface{}) (_ string
 		}
 		params[fmt.Sprintf("$%d", i+1)] = val
 	}
-	query = bindNumericRe.ReplaceAllStringFunc(query, func(n string) string {
+	query = template.replace(query, template.numeric, func(n string) string {
 		if _, found := params[n]; !found {
 			unbind[n] = struct{}{}
 			return ""
@@ -163,7 +158,7 @@ func bindNumeric(tz *time.Location, query string, args ...interface{}) (_ string
 
 var bindNamedRe = regexp.MustCompile(`@[a-zA-Z0-9\_]+`)
 
-func bindNamed(tz *time.Location, query string, args ...interface{}) (_ string, err error) {
+func bindNamed(tz *time.Location, template *bindTemplate, query string, args ...interface{}) (_ string, err error) {
 	var (
 		unbind = make(map[string]struct{})
 		params = make(map[string]string)
@@ -190,7 +185,7 @@ func bindNamed(tz *time.Location, query string, args ...interface{}) (_ string,
 			params["@"+v.Name] = val
 		}
 	}
-	query = bindNamedRe.ReplaceAllStringFunc(query, func(n string) string {
+	query = template.replace(query, template.named, func(n string) string {
 		if _, found := params[n]; !found {
 			unbind[n] = struct{}{}
 			return ""
@@ -257,6 +252,8 @@ func format(tz *time.Location, scale TimeUnit, v interface{}) (string, error) {
 			return "", err
 		}
 		return fmt.Sprintf("[%s]", val), nil
+	case IdentifierValue:
+		return v.quote()
 	case fmt.Stringer:
 		return quote(v.String()), nil
 	}
@@ -0,0 +1,116 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+const (
+	bindCacheSize = 1024
+	// queries longer than bindCacheMaxQuery, e.g. inserts with inline values, are not worth caching
+	bindCacheMaxQuery = 16 << 10
+)
+
+// bindTemplate is the placeholder structure of a query: the positions of its positional, numeric
+// and named placeholders, and whether it uses server side {name:Type} parameters. Templates are cached
+// by query text so services repeating the same statements skip the regular expression scans.
+type bindTemplate struct {
+	queryParams bool
+	positional  [][]int
+	numeric     [][]int
+	named       [][]int
+}
+
+func newBindTemplate(query string) *bindTemplate {
+	return &bindTemplate{
+		queryParams: hasQueryParamsRe.MatchString(query),
+		positional:  bindPositionalRe.FindAllStringIndex(query, -1),
+		numeric:     bindNumericRe.FindAllStringIndex(query, -1),
+		named:       bindNamedRe.FindAllStringIndex(query, -1),
+	}
+}
+
+// replace replaces the matches of query, like regexp.ReplaceAllStringFunc.
+func (t *bindTemplate) replace(query string, matches [][]int, fn func(string) string) string {
+	if len(matches) == 0 {
+		return query
+	}
+	var (
+		last int
+		b    strings.Builder
+	)
+	b.Grow(len(query))
+	for _, m := range matches {
+		b.WriteString(query[last:m[0]])
+		b.WriteString(fn(query[m[0]:m[1]]))
+		last = m[1]
+	}
+	b.WriteString(query[last:])
+	return b.String()
+}
+
+type bindCacheEntry struct {
+	query    string
+	template *bindTemplate
+}
+
+// bindCache is a least recently used cache of bind templates.
+type bindCache struct {
+	mu      sync.Mutex
+	size    int
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+func newBindCache(size int) *bindCache {
+	return &bindCache{
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+var bindTemplates = newBindCache(bindCacheSize)
+
+func (c *bindCache) get(query string) *bindTemplate {
+	if len(query) > bindCacheMaxQuery {
+		return newBindTemplate(query)
+	}
+	c.mu.Lock()
+	if e, found := c.entries[query]; found {
+		c.lru.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*bindCacheEntry).template
+	}
+	c.mu.Unlock()
+	template := newBindTemplate(query)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, found := c.entries[query]; !found {
+		c.entries[query] = c.lru.PushFront(&bindCacheEntry{query: query, template: template})
+		if c.lru.Len() > c.size {
+			oldest := c.lru.Back()
+			c.lru.Remove(oldest)
+			delete(c.entries, oldest.Value.(*bindCacheEntry).query)
+		}
+	}
+	return template
+}
@@ -0,0 +1,66 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindTemplate(t *testing.T) {
+	template := newBindTemplate("SELECT ?, $1, @name, {p:String} WHERE x = \\?")
+	assert.True(t, template.queryParams)
+	assert.Len(t, template.positional, 1)
+	assert.Len(t, template.numeric, 1)
+	assert.Len(t, template.named, 1)
+	const query = "SELECT @a, @bb, @a"
+	template = newBindTemplate(query)
+	assert.Equal(t, "SELECT <@a>, <@bb>, <@a>", template.replace(query, template.named, func(n string) string {
+		return "<" + n + ">"
+	}))
+	assert.Equal(t, query, template.replace(query, template.numeric, strings.ToUpper))
+}
+
+func TestBindCache(t *testing.T) {
+	cache := newBindCache(2)
+	first := cache.get("SELECT ?")
+	assert.Same(t, first, cache.get("SELECT ?"))
+	cache.get("SELECT $1")
+	cache.get("SELECT ?") // most recently used
+	cache.get("SELECT @a")
+	assert.Equal(t, 2, cache.lru.Len())
+	assert.Contains(t, cache.entries, "SELECT ?")
+	assert.NotContains(t, cache.entries, "SELECT $1")
+	cache.get(strings.Repeat(" ", bindCacheMaxQuery+1))
+	assert.Equal(t, 2, cache.lru.Len())
+}
+
+func TestBindCachedQuery(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		query, err := bind(nil, "SELECT $1, $2", "a", 1)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "SELECT 'a', 1", query)
+		}
+		query, err = bind(nil, "SELECT @a", Named("a", "b"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, "SELECT 'b'", query)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+)
+
+// CaptureOptions configures a Capture.
+type CaptureOptions struct {
+	// Payloads records the bytes received from the server, which replaying a trace requires. They may contain
+	// the data of the queries: review a trace before sharing it. The bytes sent to the server, which contain
+	// the credentials and the queries, are never recorded.
+	Payloads bool
+}
+
+// CaptureRecord is a record of a trace written by a Capture, one JSON object per line.
+type CaptureRecord struct {
+	Conn   int           `json:"conn"`             // the number of the connection in the trace
+	Time   time.Duration `json:"time"`             // since the connection was dialed
+	Op     string        `json:"op"`               // dial, read, write, send, receive or close
+	Packet string        `json:"packet,omitempty"` // the packet sent (send) or received (receive)
+	Size   int           `json:"size,omitempty"`   // the bytes read or written
+	Data   []byte        `json:"data,omitempty"`   // the bytes read, with CaptureOptions.Payloads
+	Err    string        `json:"err,omitempty"`
+}
+
+// Capture records the native protocol traffic of the connections to a trace: the packets exchanged with
+// the server and the sizes and timings of the reads and writes, see CaptureRecord. With payloads, a trace
+// can be replayed with NewReplayDialer, so that protocol desync issues can be reproduced without a server.
+// It applies to native connections, see Options.Capture, and is safe for concurrent use.
+type Capture struct {
+	opts  CaptureOptions
+	mu    sync.Mutex
+	enc   *json.Encoder
+	conns int
+	err   error
+}
+
+func NewCapture(w io.Writer, opts CaptureOptions) *Capture {
+	return &Capture{
+		opts: opts,
+		enc:  json.NewEncoder(w),
+	}
+}
+
+// Err returns the first error writing the trace, after which nothing is recorded.
+func (c *Capture) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *Capture) wrap(conn net.Conn) *captureConn {
+	c.mu.Lock()
+	c.conns++
+	captured := &captureConn{
+		Conn:    conn,
+		capture: c,
+		num:     c.conns,
+		start:   time.Now(),
+	}
+	c.mu.Unlock()
+	captured.record(CaptureRecord{Op: "dial"})
+	return captured
+}
+
+func (c *Capture) write(record CaptureRecord) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = c.enc.Encode(record)
+	}
+}
+
+type captureConn struct {
+	net.Conn
+	capture *Capture
+	num     int
+	start   time.Time
+}
+
+func (c *captureConn) record(record CaptureRecord) {
+	record.Conn, record.Time = c.num, time.Since(c.start)
+	c.capture.write(record)
+}
+
+func (c *captureConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	record := CaptureRecord{Op: "read", Size: n, Err: captureErr(err)}
+	if c.capture.opts.Payloads && n > 0 {
+		record.Data = append([]byte(nil), p[:n]...)
+	}
+	c.record(record)
+	return n, err
+}
+
+func (c *captureConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.record(CaptureRecord{Op: "write", Size: n, Err: captureErr(err)})
+	return n, err
+}
+
+func (c *captureConn) Close() error {
+	err := c.Conn.Close()
+	c.record(CaptureRecord{Op: "close", Err: captureErr(err)})
+	return err
+}
+
+// send records the client packet starting buf.
+func (c *captureConn) send(buf []byte) {
+	if c == nil || len(buf) == 0 {
+		return
+	}
+	c.record(CaptureRecord{Op: "send", Packet: packetName(clientPackets, buf[0]), Size: len(buf)})
+}
+
+func (c *captureConn) receive(packet byte) {
+	if c == nil {
+		return
+	}
+	c.record(CaptureRecord{Op: "receive", Packet: packetName(serverPackets, packet)})
+}
+
+func captureErr(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+var clientPackets = []string{
+	proto.ClientHello:  "Hello",
+	proto.ClientQuery:  "Query",
+	proto.ClientData:   "Data",
+	proto.ClientCancel: "Cancel",
+	proto.ClientPing:   "Ping",
+}
+
+var serverPackets = []string{
+	proto.ServerHello:               "Hello",
+	proto.ServerData:                "Data",
+	proto.ServerException:           "Exception",
+	proto.ServerProgress:            "Progress",
+	proto.ServerPong:                "Pong",
+	proto.ServerEndOfStream:         "EndOfStream",
+	proto.ServerProfileInfo:         "ProfileInfo",
+	proto.ServerTotals:              "Totals",
+	proto.ServerExtremes:            "Extremes",
+	proto.ServerTablesStatus:        "TablesStatus",
+	proto.ServerLog:                 "Log",
+	proto.ServerTableColumns:        "TableColumns",
+	proto.ServerPartUUIDs:           "PartUUIDs",
+	proto.ServerReadTaskRequest:     "ReadTaskRequest",
+	proto.ServerProfileEvents:       "ProfileEvents",
+	proto.ServerTreeReadTaskRequest: "TreeReadTaskRequest",
+}
+
+func packetName(names []string, packet byte) string {
+	if int(packet) < len(names) {
+		return names[packet]
+	}
+	return fmt.Sprintf("Unknown(%d)", packet)
+}
+
+// NewReplayDialer returns an Options.DialContext replaying a trace recorded with CaptureOptions.Payloads:
+// each dial returns the next connection of the trace, which serves the bytes the server sent in order and
+// discards the writes. Timings are not reproduced.
+func NewReplayDialer(trace io.Reader) (func(ctx context.Context, addr string) (net.Conn, error), error) {
+	var (
+		conns   = make(map[int]*replayConn)
+		order   []*replayConn
+		scanner = bufio.NewScanner(trace)
+	)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		var record CaptureRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("clickhouse [replay]: %w", err)
+		}
+		conn, found := conns[record.Conn]
+		if !found {
+			conn = &replayConn{}
+			conns[record.Conn] = conn
+			order = append(order, conn)
+		}
+		if record.Op == "read" {
+			if record.Size != len(record.Data) {
+				return nil, fmt.Errorf("clickhouse [replay]: connection %d: the trace has no payloads", record.Conn)
+			}
+			conn.data = append(conn.data, record.Data...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("clickhouse [replay]: %w", err)
+	}
+	var mu sync.Mutex
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(order) == 0 {
+			return nil, fmt.Errorf("clickhouse [replay]: no more connections in the trace")
+		}
+		conn := order[0]
+		order = order[1:]
+		return conn, nil
+	}, nil
+}
+
+type replayConn struct {
+	data   []byte
+	closed bool
+}
+
+func (c *replayConn) Read(p []byte) (int, error) {
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	if len(c.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, c.data)
+	c.data = c.data[n:]
+	return n, nil
+}
+
+func (c *replayConn) Write(p []byte) (int, error) {
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	return len(p), nil
+}
+
+func (c *replayConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func (c *replayConn) LocalAddr() net.Addr                { return replayAddr{} }
+func (c *replayConn) RemoteAddr() net.Addr               { return replayAddr{} }
+func (c *replayConn) SetDeadline(t time.Time) error      { return nil }
+func (c *replayConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *replayConn) SetWriteDeadline(t time.Time) error { return nil }
+
+type replayAddr struct{}
+
+func (replayAddr) Network() string { return "replay" }
+func (replayAddr) String() string  { return "replay" }
@@ -0,0 +1,97 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer returns the bytes a server sends to a connection: its handshake, followed by packets.
+func fakeServer(packets ...byte) []byte {
+	var server chproto.Buffer
+	server.PutByte(proto.ServerHello)
+	server.PutString("ClickHouse")
+	server.PutUVarInt(23)
+	server.PutUVarInt(8)
+	server.PutUVarInt(proto.DBMS_MIN_REVISION_WITH_QUOTA_KEY_IN_CLIENT_INFO)
+	server.PutString("UTC")
+	server.PutRaw(packets)
+	return server.Buf
+}
+
+func TestCaptureReplay(t *testing.T) {
+	server := fakeServer(proto.ServerPong)
+
+	connect := func(dialer func(ctx context.Context, addr string) (net.Conn, error), capture *Capture) {
+		opt := &Options{
+			Auth:        Auth{Username: "default", Password: "secret"},
+			DialContext: dialer,
+			Capture:     capture,
+		}
+		opt.setDefaults()
+		conn, err := dial(context.Background(), "127.0.0.1:9000", 1, opt)
+		require.NoError(t, err)
+		assert.Equal(t, "ClickHouse", conn.server.Name)
+		require.NoError(t, conn.ping(context.Background()))
+		require.NoError(t, conn.close())
+	}
+
+	var trace bytes.Buffer
+	capture := NewCapture(&trace, CaptureOptions{Payloads: true})
+	connect(func(context.Context, string) (net.Conn, error) {
+		return &replayConn{data: server}, nil
+	}, capture)
+	require.NoError(t, capture.Err())
+	assert.NotContains(t, trace.String(), "secret")
+
+	var packets []string
+	scanner := bufio.NewScanner(bytes.NewReader(trace.Bytes()))
+	for scanner.Scan() {
+		var record CaptureRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		assert.Equal(t, 1, record.Conn)
+		if record.Packet != "" {
+			packets = append(packets, record.Op+" "+record.Packet)
+		}
+	}
+	assert.Equal(t, []string{"send Hello", "receive Hello", "send Ping", "receive Pong"}, packets)
+
+	dialer, err := NewReplayDialer(bytes.NewReader(trace.Bytes()))
+	require.NoError(t, err)
+	connect(dialer, nil)
+	_, err = dialer(context.Background(), "127.0.0.1:9000")
+	assert.Error(t, err)
+
+	trace.Reset()
+	capture = NewCapture(&trace, CaptureOptions{})
+	connect(func(context.Context, string) (net.Conn, error) {
+		return &replayConn{data: server}, nil
+	}, capture)
+	_, err = NewReplayDialer(bytes.NewReader(trace.Bytes()))
+	assert.Error(t, err)
+}
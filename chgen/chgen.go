@@ -0,0 +1,257 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package chgen generates Go code for ClickHouse tables: a Row struct per table with Append and Scan
+// methods and batch helpers appending column by column. The generated code uses the concrete Go types
+// of the driver columns, so values are appended and scanned without reflection.
+//
+//	tables, err := chgen.ParseSchema(schemaSQL) // or chgen.Introspect(ctx, conn, "events")
+//	src, err := chgen.Generate(chgen.Config{Package: "models"}, tables...)
+//
+// The chgen command in cmd/chgen writes the generated code to a file, e.g. from go:generate:
+//
+//	//go:generate go run github.com/ClickHouse/clickhouse-go/v2/chgen/cmd/chgen -schema schema.sql -package models -out models_gen.go
+//
+// For a table events with columns id UInt64 and name String the generated code is used as:
+//
+//	err := models.InsertEventsRows(ctx, conn, []models.EventsRow{{ID: 1, Name: "a"}})
+//	rows, err := models.SelectEventsRows(ctx, conn, "WHERE id > ?", 0)
+package chgen
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+var ErrUnsupportedType = errors.New("clickhouse [chgen]: unsupported type")
+
+//go:embed table.tpl
+var tableSrc string
+
+var tableTemplate = template.Must(template.New("table").Parse(tableSrc))
+
+// Table is the name of a table, optionally qualified with its database, and its insertable columns.
+type Table struct {
+	Name    string
+	Columns []Column
+}
+
+// Column is a column name and its ClickHouse type.
+type Column struct {
+	Name string
+	Type string
+}
+
+// Config configures the generated code.
+type Config struct {
+	// Package is the package name of the generated file. Default "models".
+	Package string
+	// Source describes where the schema was read from, mentioned in the header of the file.
+	Source string
+}
+
+func (c *Config) setDefaults() {
+	if len(c.Package) == 0 {
+		c.Package = "models"
+	}
+}
+
+// Introspect reads the insertable columns of table, skipping MATERIALIZED and ALIAS columns.
+func Introspect(ctx context.Context, conn driver.Conn, table string) (Table, error) {
+	columns, err := clickhouse.DescribeTable(ctx, conn, table)
+	if err != nil {
+		return Table{}, err
+	}
+	t := Table{Name: table}
+	for _, c := range columns {
+		switch c.DefaultType {
+		case "MATERIALIZED", "ALIAS":
+			continue
+		}
+		t.Columns = append(t.Columns, Column{Name: c.Name, Type: c.Type})
+	}
+	return t, nil
+}
+
+// Generate returns the formatted source of the code for tables.
+func Generate(config Config, tables ...Table) ([]byte, error) {
+	config.setDefaults()
+	var (
+		imports = map[string]bool{
+			"context": true,
+			"github.com/ClickHouse/clickhouse-go/v2/lib/driver": true,
+		}
+		data = struct {
+			Config
+			StdImports []string
+			Imports    []string
+			Tables     []tableData
+		}{Config: config}
+	)
+	for _, t := range tables {
+		td, err := newTableData(t, imports)
+		if err != nil {
+			return nil, err
+		}
+		data.Tables = append(data.Tables, td)
+	}
+	for path := range imports {
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			data.Imports = append(data.Imports, path)
+		} else {
+			data.StdImports = append(data.StdImports, path)
+		}
+	}
+	sort.Strings(data.StdImports)
+	sort.Strings(data.Imports)
+	var out bytes.Buffer
+	if err := tableTemplate.Execute(&out, data); err != nil {
+		return nil, err
+	}
+	return format.Source(out.Bytes())
+}
+
+type tableData struct {
+	Name    string
+	Type    string
+	Table   string // quoted name
+	Columns string // quoted column list
+	Fields  []fieldData
+}
+
+type fieldData struct {
+	Name   string
+	Column string
+	GoType string
+}
+
+func newTableData(t Table, imports map[string]bool) (tableData, error) {
+	if len(t.Columns) == 0 {
+		return tableData{}, fmt.Errorf("clickhouse [chgen]: table %s has no columns", t.Name)
+	}
+	var (
+		parts = strings.Split(t.Name, ".")
+		td    = tableData{
+			Name: t.Name,
+			Type: GoName(parts[len(parts)-1]),
+		}
+		quoted = make([]string, 0, len(t.Columns))
+		names  = make(map[string]int, len(t.Columns))
+	)
+	for i, part := range parts {
+		parts[i] = clickhouse.QuoteIdentifier(part)
+	}
+	td.Table = strings.Join(parts, ".")
+	for _, c := range t.Columns {
+		goType, err := GoType(c.Type, imports)
+		if err != nil {
+			return tableData{}, fmt.Errorf("%w: column %s of table %s: %s", ErrUnsupportedType, c.Name, t.Name, err)
+		}
+		name := GoName(c.Name)
+		if n := names[name]; n != 0 {
+			name = fmt.Sprintf("%s%d", name, n+1)
+		}
+		names[name]++
+		td.Fields = append(td.Fields, fieldData{
+			Name:   name,
+			Column: c.Name,
+			GoType: goType,
+		})
+		quoted = append(quoted, clickhouse.QuoteIdentifier(c.Name))
+	}
+	td.Columns = strings.Join(quoted, ", ")
+	return td, nil
+}
+
+// GoType returns the Go type the driver scans chType into, adding the import paths it needs to imports.
+func GoType(chType string, imports map[string]bool) (string, error) {
+	col, err := column.Type(chType).Column("", nil)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := col.(*column.Nothing); ok {
+		return "", fmt.Errorf("%s values can't be stored in tables", chType)
+	}
+	return typeString(col.ScanType(), imports), nil
+}
+
+func typeString(t reflect.Type, imports map[string]bool) string {
+	if len(t.Name()) != 0 {
+		if len(t.PkgPath()) != 0 {
+			imports[t.PkgPath()] = true
+		}
+		return t.String()
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + typeString(t.Elem(), imports)
+	case reflect.Slice:
+		return "[]" + typeString(t.Elem(), imports)
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), typeString(t.Elem(), imports))
+	case reflect.Map:
+		return "map[" + typeString(t.Key(), imports) + "]" + typeString(t.Elem(), imports)
+	case reflect.Interface:
+		return "interface{}"
+	}
+	return t.String()
+}
+
+// initialisms are written in upper case in Go names, following the Go naming conventions.
+var initialisms = map[string]bool{
+	"API": true, "CPU": true, "DNS": true, "HTML": true, "HTTP": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "TTL": true, "URI": true, "URL": true, "UTC": true, "UUID": true,
+}
+
+// GoName converts a column or table name such as "user_id" or "nested.value" to an exported Go name,
+// "UserID" and "NestedValue".
+func GoName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, word := range words {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	switch s := b.String(); {
+	case len(s) == 0:
+		return "X"
+	case unicode.IsDigit(rune(s[0])):
+		return "X" + s
+	default:
+		return s
+	}
+}
@@ -0,0 +1,154 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package chgen
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const schema = `
+-- events; with comments
+CREATE TABLE IF NOT EXISTS db.events ON CLUSTER default (
+    ` + "`id`" + ` UInt64,
+    user_id UInt32 CODEC(Delta, ZSTD),
+    name LowCardinality(String) DEFAULT 'a, b',
+    note String NULL COMMENT 'free; text',
+    score Int32 NOT NULL,
+    amount Decimal(18, 4),
+    ts DateTime64(3, 'UTC'),
+    day Date MATERIALIZED toDate(ts),
+    INDEX idx_name name TYPE bloom_filter GRANULARITY 4,
+    PRIMARY KEY (id)
+) ENGINE = MergeTree ORDER BY id;
+CREATE VIEW v AS SELECT 1;
+CREATE TABLE "users" ("id" UUID, "ip" IPv6)
+`
+
+func TestParseSchema(t *testing.T) {
+	tables, err := ParseSchema(schema)
+	require.NoError(t, err)
+	assert.Equal(t, []Table{
+		{
+			Name: "db.events",
+			Columns: []Column{
+				{Name: "id", Type: "UInt64"},
+				{Name: "user_id", Type: "UInt32"},
+				{Name: "name", Type: "LowCardinality(String)"},
+				{Name: "note", Type: "Nullable(String)"},
+				{Name: "score", Type: "Int32"},
+				{Name: "amount", Type: "Decimal(18, 4)"},
+				{Name: "ts", Type: "DateTime64(3, 'UTC')"},
+			},
+		},
+		{
+			Name:    "users",
+			Columns: []Column{{Name: "id", Type: "UUID"}, {Name: "ip", Type: "IPv6"}},
+		},
+	}, tables)
+
+	_, err = ParseSchema("CREATE TABLE t (id UInt64")
+	assert.Error(t, err)
+}
+
+func TestGoName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"user_id":      "UserID",
+		"nested.value": "NestedValue",
+		"URL":          "URL",
+		"createdAt":    "CreatedAt",
+		"1st":          "X1st",
+		"__":           "X",
+	} {
+		assert.Equal(t, expected, GoName(name), name)
+	}
+}
+
+func TestGoType(t *testing.T) {
+	imports := make(map[string]bool)
+	for chType, expected := range map[string]string{
+		"UInt8":                            "uint8",
+		"Nullable(String)":                 "*string",
+		"Array(Nullable(Int32))":           "[]*int32",
+		"Map(String, Array(UInt64))":       "map[string][]uint64",
+		"LowCardinality(Nullable(String))": "*string",
+		"DateTime64(3)":                    "time.Time",
+		"Decimal(10, 2)":                   "decimal.Decimal",
+		"UUID":                             "uuid.UUID",
+		"Int128":                           "*big.Int",
+		"Tuple(String, Int64)":             "[]interface{}",
+	} {
+		goType, err := GoType(chType, imports)
+		require.NoError(t, err, chType)
+		assert.Equal(t, expected, goType, chType)
+	}
+	assert.Equal(t, map[string]bool{
+		"time":                          true,
+		"math/big":                      true,
+		"github.com/google/uuid":        true,
+		"github.com/shopspring/decimal": true,
+	}, imports)
+
+	_, err := GoType("Nothing", imports)
+	assert.Error(t, err)
+	_, err = GoType("Unknown", imports)
+	assert.Error(t, err)
+}
+
+func TestGenerate(t *testing.T) {
+	tables, err := ParseSchema(schema)
+	require.NoError(t, err)
+	src, err := Generate(Config{Source: "schema.sql"}, tables...)
+	require.NoError(t, err)
+	file, err := parser.ParseFile(token.NewFileSet(), "models_gen.go", src, parser.ImportsOnly)
+	require.NoError(t, err)
+	assert.Equal(t, "models", file.Name.Name)
+	var imports []string
+	for _, spec := range file.Imports {
+		imports = append(imports, spec.Path.Value)
+	}
+	assert.Equal(t, []string{
+		`"context"`, `"net"`, `"time"`,
+		`"github.com/ClickHouse/clickhouse-go/v2/lib/driver"`, `"github.com/google/uuid"`, `"github.com/shopspring/decimal"`,
+	}, imports)
+	for _, expected := range []string{
+		"// source: schema.sql",
+		"type EventsRow struct {",
+		"\tUserID uint32          `ch:\"user_id\"`",
+		"EventsInsert = \"INSERT INTO `db`.`events` (\" + EventsColumns + \")\"",
+		"func (r *EventsRow) Scan(rows driver.Rows) error {",
+		"c5 := make([]decimal.Decimal, len(rows))",
+		"func SelectUsersRows(ctx context.Context, conn driver.Conn, clauses string, args ...interface{}) ([]UsersRow, error) {",
+	} {
+		assert.Contains(t, string(src), expected)
+	}
+
+	src, err = Generate(Config{}, Table{Name: "db.t\\", Columns: []Column{{Name: "c\\", Type: "String"}}})
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "TColumns = \"`c\\\\\\\\`\"")
+	assert.Contains(t, string(src), "TInsert = \"INSERT INTO `db`.`t\\\\\\\\` (\"")
+
+	_, err = Generate(Config{}, Table{Name: "t", Columns: []Column{{Name: "c", Type: "Unknown"}}})
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	_, err = Generate(Config{}, Table{Name: "t"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Command chgen generates Go code for ClickHouse tables, read from a file of CREATE TABLE statements or
+// from a server:
+//
+//	chgen -schema schema.sql -package models -out models_gen.go
+//	chgen -dsn clickhouse://localhost:9000/db -tables events,users -package models -out models_gen.go
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/chgen"
+)
+
+func main() {
+	var (
+		schema = flag.String("schema", "", "file of CREATE TABLE statements")
+		dsn    = flag.String("dsn", "", "server to introspect the tables from, instead of -schema")
+		tables = flag.String("tables", "", "comma separated tables to generate, default all tables of -schema")
+		pkg    = flag.String("package", "models", "package name of the generated code")
+		out    = flag.String("out", "", "output file, default stdout")
+	)
+	flag.Parse()
+	var (
+		selected []chgen.Table
+		config   = chgen.Config{Package: *pkg}
+		err      error
+	)
+	switch {
+	case len(*schema) != 0:
+		selected, err = fromSchema(*schema, *tables)
+		config.Source = *schema
+	case len(*dsn) != 0:
+		selected, err = fromServer(*dsn, *tables)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := chgen.Generate(config, selected...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(*out) == 0 {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = os.WriteFile(*out, src, 0o644)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func fromSchema(path, names string) ([]chgen.Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := chgen.ParseSchema(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return tables, nil
+	}
+	var selected []chgen.Table
+	for _, name := range strings.Split(names, ",") {
+		found := false
+		for _, t := range tables {
+			if t.Name == strings.TrimSpace(name) {
+				selected, found = append(selected, t), true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("table %s not found in %s", name, path)
+		}
+	}
+	return selected, nil
+}
+
+func fromServer(dsn, names string) ([]chgen.Table, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("-tables is required with -dsn")
+	}
+	options, err := clickhouse.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := clickhouse.Open(options)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var tables []chgen.Table
+	for _, name := range strings.Split(names, ",") {
+		t, err := chgen.Introspect(context.Background(), conn, strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
@@ -0,0 +1,319 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package querycheck
+
+import (
+	"strconv"
+	"strings"
+)
+
+type tokenKind uint8
+
+const (
+	tokenWord        tokenKind = iota // identifier or keyword
+	tokenQuoted                       // backquoted or double quoted identifier, unquoted
+	tokenString                       // string literal
+	tokenNumber                       // numeric literal
+	tokenPlaceholder                  // ?, $1 or @name
+	tokenParameter                    // server side parameter {name:Type}
+	tokenPunct
+)
+
+type lexeme struct {
+	kind tokenKind
+	text string
+}
+
+func (t lexeme) is(kind tokenKind, text string) bool {
+	return t.kind == kind && strings.EqualFold(t.text, text)
+}
+
+func (t lexeme) keyword(text string) bool {
+	return t.is(tokenWord, text)
+}
+
+func (t lexeme) identifier() bool {
+	return t.kind == tokenQuoted || t.kind == tokenWord && !keywords[strings.ToUpper(t.text)]
+}
+
+// keywords are not identifiers, for the expressions recognized by the parser.
+var keywords = map[string]bool{
+	"SELECT": true, "DISTINCT": true, "FROM": true, "WHERE": true, "PREWHERE": true, "AND": true, "OR": true,
+	"NOT": true, "AS": true, "JOIN": true, "ON": true, "USING": true, "GROUP": true, "BY": true, "ORDER": true,
+	"HAVING": true, "LIMIT": true, "OFFSET": true, "FINAL": true, "SAMPLE": true, "ARRAY": true, "INSERT": true,
+	"INTO": true, "VALUES": true, "FORMAT": true, "SETTINGS": true, "UNION": true, "ALL": true, "WITH": true,
+	"IN": true, "LIKE": true, "ILIKE": true, "BETWEEN": true, "IS": true, "NULL": true, "CASE": true,
+	"WHEN": true, "THEN": true, "ELSE": true, "END": true, "INTERVAL": true, "ASC": true, "DESC": true,
+	"LEFT": true, "RIGHT": true, "INNER": true, "OUTER": true, "FULL": true, "CROSS": true, "ANY": true,
+	"GLOBAL": true, "TRUE": true, "FALSE": true,
+}
+
+var comparisons = map[string]bool{
+	"=": true, "==": true, "!=": true, "<>": true, "<": true, ">": true, "<=": true, ">=": true,
+}
+
+func tokenize(sql string) []lexeme {
+	var tokens []lexeme
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+		case c == '\'' || c == '`' || c == '"':
+			end := i + 1
+			for end < len(sql) && sql[end] != c {
+				if sql[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			kind := tokenQuoted
+			if c == '\'' {
+				kind = tokenString
+			}
+			if end > len(sql) {
+				end = len(sql)
+			}
+			text := sql[i+1 : end]
+			tokens = append(tokens, lexeme{kind: kind, text: text})
+			i = end + 1
+		case c == '?':
+			tokens = append(tokens, lexeme{kind: tokenPlaceholder, text: "?"})
+			i++
+		case (c == '$' || c == '@') && i+1 < len(sql) && isWord(sql[i+1]):
+			end := i + 1
+			for end < len(sql) && isWord(sql[end]) {
+				end++
+			}
+			tokens = append(tokens, lexeme{kind: tokenPlaceholder, text: sql[i:end]})
+			i = end
+		case c == '{':
+			end := strings.IndexByte(sql[i:], '}')
+			if end == -1 {
+				end = len(sql) - i - 1
+			}
+			tokens = append(tokens, lexeme{kind: tokenParameter, text: sql[i : i+end+1]})
+			i += end + 1
+		case c >= '0' && c <= '9':
+			end := i
+			for end < len(sql) && (isWord(sql[end]) || sql[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, lexeme{kind: tokenNumber, text: sql[i:end]})
+			i = end
+		case isWord(c):
+			end := i
+			for end < len(sql) && isWord(sql[end]) {
+				end++
+			}
+			tokens = append(tokens, lexeme{kind: tokenWord, text: sql[i:end]})
+			i = end
+		default:
+			text := sql[i : i+1]
+			if i+1 < len(sql) {
+				switch two := sql[i : i+2]; two {
+				case "<=", ">=", "!=", "<>", "==", "::", "||", "->":
+					text = two
+				}
+			}
+			tokens = append(tokens, lexeme{kind: tokenPunct, text: text})
+			i += len(text)
+		}
+	}
+	return tokens
+}
+
+func isWord(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// query is what the checks know about a query string.
+type query struct {
+	// table is the table selected from or inserted into, empty if unknown.
+	table string
+	// single is set when table is the only table of the query, so all columns are its columns.
+	single bool
+	insert bool
+	// columns are the selected or inserted columns, star is set for * in the select list.
+	columns []selected
+	star    bool
+	// positional is the number of ? placeholders, numeric the highest $N placeholder.
+	positional  int
+	numeric     int
+	named       bool
+	comparisons []comparison
+}
+
+// selected is an element of the select list or of the insert column list.
+type selected struct {
+	column string // the column if the element is a column reference
+	alias  string
+}
+
+// comparison is a column compared to a bound argument, e.g. id = ?.
+type comparison struct {
+	column string
+	arg    int // index of the bound argument
+}
+
+func parseQuery(sql string) *query {
+	var (
+		tokens = tokenize(sql)
+		q      query
+	)
+	for i, t := range tokens {
+		if t.kind != tokenPlaceholder {
+			continue
+		}
+		arg := -1
+		switch t.text[0] {
+		case '?':
+			arg = q.positional
+			q.positional++
+		case '$':
+			if n, err := strconv.Atoi(t.text[1:]); err == nil {
+				if n > q.numeric {
+					q.numeric = n
+				}
+				arg = n - 1
+			}
+		case '@':
+			q.named = true
+		}
+		if arg >= 0 && i > 1 && tokens[i-1].kind == tokenPunct && comparisons[tokens[i-1].text] && tokens[i-2].identifier() {
+			q.comparisons = append(q.comparisons, comparison{column: tokens[i-2].text, arg: arg})
+		}
+	}
+	switch {
+	case len(tokens) > 0 && tokens[0].keyword("SELECT"):
+		q.parseSelect(tokens[1:])
+	case len(tokens) > 1 && tokens[0].keyword("INSERT") && tokens[1].keyword("INTO"):
+		q.parseInsert(tokens[2:])
+	}
+	return &q
+}
+
+func (q *query) parseSelect(tokens []lexeme) {
+	if len(tokens) > 0 && tokens[0].keyword("DISTINCT") {
+		tokens = tokens[1:]
+	}
+	var (
+		depth, start int
+		from         = len(tokens)
+	)
+	for i, t := range tokens {
+		if t.kind == tokenPunct {
+			switch t.text {
+			case "(", "[":
+				depth++
+			case ")", "]":
+				depth--
+			case ",":
+				if depth == 0 {
+					q.addSelected(tokens[start:i])
+					start = i + 1
+				}
+			}
+		}
+		if depth == 0 && t.keyword("FROM") {
+			from = i
+			break
+		}
+	}
+	q.addSelected(tokens[start:from])
+	if from+1 >= len(tokens) {
+		return
+	}
+	table, rest := tableName(tokens[from+1:])
+	if len(table) == 0 {
+		return
+	}
+	q.table, q.single = table, true
+	depth = 0
+	for _, t := range rest {
+		switch {
+		case t.is(tokenPunct, "("):
+			depth++
+		case t.is(tokenPunct, ")"):
+			depth--
+		case depth == 0 && (t.keyword("JOIN") || t.keyword("ARRAY") || t.keyword("UNION") || t.is(tokenPunct, ",")):
+			q.single = false
+		}
+	}
+}
+
+func (q *query) addSelected(tokens []lexeme) {
+	switch {
+	case len(tokens) == 0:
+		return
+	case len(tokens) == 1 && tokens[0].is(tokenPunct, "*"),
+		len(tokens) == 3 && tokens[0].identifier() && tokens[1].is(tokenPunct, ".") && tokens[2].is(tokenPunct, "*"):
+		q.star = true
+		return
+	}
+	var s selected
+	if n := len(tokens); n > 2 && tokens[n-2].keyword("AS") && tokens[n-1].identifier() {
+		s.alias, tokens = tokens[n-1].text, tokens[:n-2]
+	} else if n == 2 && tokens[0].identifier() && tokens[1].identifier() {
+		s.alias, tokens = tokens[1].text, tokens[:1]
+	}
+	switch {
+	case len(tokens) == 1 && tokens[0].identifier():
+		s.column = tokens[0].text
+	case len(tokens) == 3 && tokens[0].identifier() && tokens[1].is(tokenPunct, ".") && tokens[2].identifier():
+		s.column = tokens[2].text
+	}
+	q.columns = append(q.columns, s)
+}
+
+func (q *query) parseInsert(tokens []lexeme) {
+	if len(tokens) > 0 && tokens[0].keyword("TABLE") {
+		tokens = tokens[1:]
+	}
+	table, rest := tableName(tokens)
+	if len(table) == 0 {
+		return
+	}
+	q.table, q.single, q.insert = table, true, true
+	if len(rest) == 0 || !rest[0].is(tokenPunct, "(") {
+		return
+	}
+	for _, t := range rest[1:] {
+		switch {
+		case t.is(tokenPunct, ")"):
+			return
+		case t.identifier():
+			q.columns = append(q.columns, selected{column: t.text})
+		}
+	}
+}
+
+// tableName reads a, possibly database qualified, table name.
+func tableName(tokens []lexeme) (string, []lexeme) {
+	if len(tokens) == 0 || !tokens[0].identifier() {
+		return "", tokens
+	}
+	if len(tokens) > 2 && tokens[1].is(tokenPunct, ".") && tokens[2].identifier() {
+		return tokens[0].text + "." + tokens[2].text, tokens[3:]
+	}
+	return tokens[0].text, tokens[1:]
+}
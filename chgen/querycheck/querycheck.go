@@ -0,0 +1,453 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package querycheck is an analyzer checking the constant query strings passed to the driver against a
+// snapshot of the schema, so mistakes are reported by go vet rather than by the server at runtime:
+//
+//   - selected, inserted and compared columns must exist in the table
+//   - the number of bound arguments must match the placeholders of the query
+//   - arguments compared to a column, e.g. id = ?, must have a compatible Go type
+//   - Scan must have a target per selected column and batch Append a value per inserted column
+//
+// Queries are recognized on the methods of driver.Conn and of database/sql. The schema is read from a
+// file of CREATE TABLE statements, see chgen.ParseSchema, and the analyzer is run by go vet with the
+// chvet command:
+//
+//	go install github.com/ClickHouse/clickhouse-go/v2/chgen/querycheck/cmd/chvet
+//	go vet -vettool=$(which chvet) -schema=$PWD/schema.sql ./...
+//
+// Only simple SELECT queries of a single table and INSERT statements are checked for columns, anything
+// the analyzer does not understand is skipped rather than reported.
+//
+// The package is a separate module so that golang.org/x/tools is not required by the driver.
+package querycheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ClickHouse/clickhouse-go/v2/chgen"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const driverPath = "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+
+const doc = `check ClickHouse queries against a schema
+
+The querycheck analyzer reports columns missing from the schema, bound arguments not matching the
+placeholders or the column types, and Scan or batch Append calls not matching the selected or
+inserted columns.`
+
+// Analyzer reads the schema from the file of the -schema flag.
+var Analyzer = newAnalyzer(&checker{}, true)
+
+// NewAnalyzer returns an analyzer checking queries against tables.
+func NewAnalyzer(tables []chgen.Table) *analysis.Analyzer {
+	c := &checker{}
+	c.once.Do(func() {
+		c.tables = tables
+	})
+	return newAnalyzer(c, false)
+}
+
+func newAnalyzer(c *checker, flag bool) *analysis.Analyzer {
+	a := &analysis.Analyzer{
+		Name:     "querycheck",
+		Doc:      doc,
+		Requires: []*analysis.Analyzer{inspect.Analyzer},
+		Run:      c.run,
+	}
+	if flag {
+		a.Flags.StringVar(&c.schema, "schema", "", "file of CREATE TABLE statements")
+	}
+	return a
+}
+
+// queryMethods are the methods taking a query, in the driver and in database/sql.
+var queryMethods = map[string]map[string]bool{
+	driverPath: {
+		"Query": true, "QueryRow": true, "Select": true, "Exec": true, "PrepareBatch": true, "AsyncInsert": true,
+	},
+	"database/sql": {
+		"Query": true, "QueryContext": true, "QueryRow": true, "QueryRowContext": true,
+		"Exec": true, "ExecContext": true, "Prepare": true, "PrepareContext": true,
+	},
+}
+
+type checker struct {
+	schema string
+	once   sync.Once
+	tables []chgen.Table
+	err    error
+}
+
+func (c *checker) load() ([]chgen.Table, error) {
+	c.once.Do(func() {
+		if len(c.schema) == 0 {
+			c.err = fmt.Errorf("querycheck: the -schema flag is required")
+			return
+		}
+		data, err := os.ReadFile(c.schema)
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.tables, c.err = chgen.ParseSchema(string(data))
+	})
+	return c.tables, c.err
+}
+
+// call is a call of a query method with a constant query.
+type call struct {
+	query *query
+	expr  ast.Expr // the query argument
+	args  []ast.Expr
+	// bound is set when args are the bound arguments, rather than spread from a slice.
+	bound bool
+}
+
+type checkPass struct {
+	*analysis.Pass
+	tables []chgen.Table
+	calls  map[*ast.CallExpr]*call
+	// results are the variables holding the rows, row or batch of a query.
+	results map[types.Object]*call
+}
+
+func (c *checker) run(p *analysis.Pass) (interface{}, error) {
+	tables, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		pass = &checkPass{
+			Pass:    p,
+			tables:  tables,
+			calls:   make(map[*ast.CallExpr]*call),
+			results: make(map[types.Object]*call),
+		}
+		nodes = []ast.Node{(*ast.AssignStmt)(nil), (*ast.ValueSpec)(nil), (*ast.CallExpr)(nil)}
+	)
+	p.ResultOf[inspect.Analyzer].(*inspector.Inspector).Preorder(nodes, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Rhs) == 1 && len(n.Lhs) > 0 {
+				pass.assign(n.Lhs[0], n.Rhs[0])
+			}
+		case *ast.ValueSpec:
+			if len(n.Values) == 1 && len(n.Names) > 0 {
+				pass.assign(n.Names[0], n.Values[0])
+			}
+		case *ast.CallExpr:
+			if qc := pass.queryCall(n); qc != nil {
+				pass.checkQuery(qc)
+				return
+			}
+			pass.checkResult(n)
+		}
+	})
+	return nil, nil
+}
+
+// assign records the variable holding the result of a query.
+func (p *checkPass) assign(lhs, rhs ast.Expr) {
+	id, ok := lhs.(*ast.Ident)
+	if !ok {
+		return
+	}
+	obj := p.TypesInfo.ObjectOf(id)
+	if obj == nil {
+		return
+	}
+	if expr, ok := rhs.(*ast.CallExpr); ok {
+		if qc := p.queryCall(expr); qc != nil {
+			p.results[obj] = qc
+			return
+		}
+	}
+	delete(p.results, obj)
+}
+
+// queryCall returns the query of a call of a query method, nil if it is not one or the query is not constant.
+func (p *checkPass) queryCall(expr *ast.CallExpr) *call {
+	if qc, ok := p.calls[expr]; ok {
+		return qc
+	}
+	qc := p.parseCall(expr)
+	p.calls[expr] = qc
+	return qc
+}
+
+func (p *checkPass) parseCall(expr *ast.CallExpr) *call {
+	fn := p.method(expr)
+	if fn == nil || !queryMethods[fn.Pkg().Path()][fn.Name()] {
+		return nil
+	}
+	var (
+		sig    = fn.Type().(*types.Signature)
+		params = sig.Params()
+		index  = -1
+	)
+	for i := 0; i < params.Len(); i++ {
+		if basic, ok := params.At(i).Type().(*types.Basic); ok && basic.Kind() == types.String {
+			index = i
+			break
+		}
+	}
+	if index == -1 || index >= len(expr.Args) {
+		return nil
+	}
+	value := p.TypesInfo.Types[expr.Args[index]].Value
+	if value == nil || value.Kind() != constant.String {
+		return nil
+	}
+	qc := &call{
+		query: parseQuery(constant.StringVal(value)),
+		expr:  expr.Args[index],
+	}
+	if sig.Variadic() && index == params.Len()-2 && expr.Ellipsis == token.NoPos {
+		qc.args, qc.bound = expr.Args[index+1:], true
+	}
+	return qc
+}
+
+// method returns the method called by expr, nil for other calls.
+func (p *checkPass) method(expr *ast.CallExpr) *types.Func {
+	sel, ok := expr.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	fn, ok := p.TypesInfo.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Type().(*types.Signature).Recv() == nil {
+		return nil
+	}
+	return fn
+}
+
+func (p *checkPass) checkQuery(qc *call) {
+	q := qc.query
+	if qc.bound && !q.named && (q.positional == 0 || q.numeric == 0) {
+		if placeholders := q.positional + q.numeric; placeholders != 0 && placeholders != len(qc.args) {
+			p.Reportf(qc.expr.Pos(), "query has %d placeholders but %d arguments are bound", placeholders, len(qc.args))
+		}
+	}
+	table := p.table(q)
+	if table == nil {
+		return
+	}
+	known := make(map[string]bool, len(table.Columns)+len(q.columns))
+	for _, c := range table.Columns {
+		known[c.Name] = true
+	}
+	for _, s := range q.columns {
+		if len(s.alias) != 0 {
+			known[s.alias] = true
+		}
+	}
+	for _, s := range q.columns {
+		if len(s.column) != 0 && !known[s.column] && !strings.HasPrefix(s.column, "_") {
+			p.Reportf(qc.expr.Pos(), "column %s does not exist in table %s", s.column, table.Name)
+		}
+	}
+	for _, cmp := range q.comparisons {
+		if !known[cmp.column] && !strings.HasPrefix(cmp.column, "_") {
+			p.Reportf(qc.expr.Pos(), "column %s does not exist in table %s", cmp.column, table.Name)
+			continue
+		}
+		if qc.bound && cmp.arg < len(qc.args) {
+			p.checkArg(qc.args[cmp.arg], table, cmp.column)
+		}
+	}
+}
+
+// table returns the schema of the single table of q, nil if the query reads several tables or one
+// missing from the schema.
+func (p *checkPass) table(q *query) *chgen.Table {
+	if !q.single {
+		return nil
+	}
+	for i, t := range p.tables {
+		if t.Name == q.table {
+			return &p.tables[i]
+		}
+	}
+	for i, t := range p.tables {
+		if unqualified(t.Name) == unqualified(q.table) {
+			return &p.tables[i]
+		}
+	}
+	return nil
+}
+
+func unqualified(name string) string {
+	return name[strings.LastIndexByte(name, '.')+1:]
+}
+
+func (p *checkPass) checkArg(arg ast.Expr, table *chgen.Table, name string) {
+	for _, c := range table.Columns {
+		if c.Name != name {
+			continue
+		}
+		var (
+			argType = p.TypesInfo.TypeOf(arg)
+			want    = columnCategory(c.Type)
+			got     = goCategory(argType)
+		)
+		if !compatible(want, got) {
+			p.Reportf(arg.Pos(), "argument of type %s is not compatible with column %s %s", argType, c.Name, c.Type)
+		}
+		return
+	}
+}
+
+// checkResult checks the Scan of rows and the Append to a batch of a query.
+func (p *checkPass) checkResult(expr *ast.CallExpr) {
+	fn := p.method(expr)
+	if fn == nil || expr.Ellipsis != token.NoPos {
+		return
+	}
+	switch path := fn.Pkg().Path(); {
+	case fn.Name() == "Scan" && (path == driverPath || path == "database/sql"),
+		fn.Name() == "Append" && path == driverPath:
+	default:
+		return
+	}
+	var qc *call
+	switch recv := expr.Fun.(*ast.SelectorExpr).X.(type) {
+	case *ast.CallExpr:
+		qc = p.queryCall(recv)
+	case *ast.Ident:
+		qc = p.results[p.TypesInfo.ObjectOf(recv)]
+	}
+	if qc == nil {
+		return
+	}
+	columns, ok := p.columns(qc.query)
+	if !ok || columns == len(expr.Args) {
+		return
+	}
+	switch {
+	case fn.Name() == "Append" && qc.query.insert:
+		p.Reportf(expr.Pos(), "Append of %d values but the query inserts %d columns", len(expr.Args), columns)
+	case fn.Name() == "Scan" && !qc.query.insert:
+		p.Reportf(expr.Pos(), "Scan into %d targets but the query selects %d columns", len(expr.Args), columns)
+	}
+}
+
+// columns returns the number of columns selected or inserted by q, false if unknown.
+func (p *checkPass) columns(q *query) (int, bool) {
+	if !q.star && (len(q.columns) != 0 || !q.insert) {
+		return len(q.columns), len(q.columns) != 0
+	}
+	table := p.table(q)
+	if table == nil {
+		return 0, false
+	}
+	return len(table.Columns) + len(q.columns), true
+}
+
+type category uint8
+
+const (
+	categoryUnknown category = iota
+	categoryNumber
+	categoryString
+	categoryTime
+	categoryBool
+	categoryUUID
+)
+
+func columnCategory(chType string) category {
+	for _, wrapper := range []string{"LowCardinality(", "Nullable("} {
+		if strings.HasPrefix(chType, wrapper) {
+			chType = strings.TrimSuffix(strings.TrimPrefix(chType, wrapper), ")")
+		}
+	}
+	switch {
+	case strings.HasPrefix(chType, "Int"), strings.HasPrefix(chType, "UInt"),
+		strings.HasPrefix(chType, "Float"), strings.HasPrefix(chType, "Decimal"):
+		return categoryNumber
+	case strings.HasPrefix(chType, "String"), strings.HasPrefix(chType, "FixedString"),
+		strings.HasPrefix(chType, "Enum"):
+		return categoryString
+	case strings.HasPrefix(chType, "Date"):
+		return categoryTime
+	case chType == "Bool":
+		return categoryBool
+	case chType == "UUID":
+		return categoryUUID
+	}
+	return categoryUnknown
+}
+
+func goCategory(t types.Type) category {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil {
+		switch named.Obj().Pkg().Path() + "." + named.Obj().Name() {
+		case "time.Time":
+			return categoryTime
+		case "github.com/google/uuid.UUID":
+			return categoryUUID
+		case "github.com/shopspring/decimal.Decimal", "math/big.Int":
+			return categoryNumber
+		}
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch info := u.Info(); {
+		case info&types.IsNumeric != 0:
+			return categoryNumber
+		case info&types.IsString != 0:
+			return categoryString
+		case info&types.IsBoolean != 0:
+			return categoryBool
+		}
+	case *types.Slice:
+		if basic, ok := u.Elem().(*types.Basic); ok && basic.Kind() == types.Byte {
+			return categoryString
+		}
+	}
+	return categoryUnknown
+}
+
+// compatible reports whether an argument of category got can be compared to a column of category want.
+func compatible(want, got category) bool {
+	if want == categoryUnknown || got == categoryUnknown || want == got {
+		return true
+	}
+	switch want {
+	case categoryNumber:
+		return got == categoryBool
+	case categoryTime:
+		return got == categoryString || got == categoryNumber
+	case categoryBool:
+		return got == categoryNumber || got == categoryString
+	case categoryUUID:
+		return got == categoryString
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package querycheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/chgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+func TestAnalyzer(t *testing.T) {
+	tables, err := chgen.ParseSchema(`
+		CREATE TABLE db.events (id UInt64, name LowCardinality(String), ts DateTime64(3), note Nullable(String));
+		CREATE TABLE users (id UInt64, email String);
+	`)
+	require.NoError(t, err)
+	fset := token.NewFileSet()
+	files, err := parsePackage(fset, "a", parser.ParseComments)
+	require.NoError(t, err)
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	imports := &testImporter{fset: fset, std: importer.Default(), pkgs: make(map[string]*types.Package)}
+	pkg, err := (&types.Config{Importer: imports}).Check("a", fset, files, info)
+	require.NoError(t, err)
+	var reported []string
+	pass := &analysis.Pass{
+		Analyzer:  NewAnalyzer(tables),
+		Fset:      fset,
+		Files:     files,
+		Pkg:       pkg,
+		TypesInfo: info,
+		ResultOf:  map[*analysis.Analyzer]interface{}{inspect.Analyzer: inspector.New(files)},
+		Report: func(d analysis.Diagnostic) {
+			reported = append(reported, fmt.Sprintf("%d: %s", fset.Position(d.Pos).Line, d.Message))
+		},
+	}
+	_, err = pass.Analyzer.Run(pass)
+	require.NoError(t, err)
+	assert.Equal(t, wanted(fset, files), reported)
+}
+
+var wantRe = regexp.MustCompile("// want `([^`]+)`")
+
+// wanted returns the diagnostics expected by the // want comments of files, as used by analysistest.
+func wanted(fset *token.FileSet, files []*ast.File) []string {
+	var want []string
+	for _, f := range files {
+		for _, group := range f.Comments {
+			for _, c := range group.List {
+				if m := wantRe.FindStringSubmatch(c.Text); m != nil {
+					want = append(want, fmt.Sprintf("%d: %s", fset.Position(c.Pos()).Line, m[1]))
+				}
+			}
+		}
+	}
+	return want
+}
+
+// parsePackage parses the package path of testdata/src.
+func parsePackage(fset *token.FileSet, path string, mode parser.Mode) ([]*ast.File, error) {
+	dir := filepath.Join("testdata", "src", filepath.FromSlash(path))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, e.Name()), nil, mode)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// testImporter imports the packages of testdata/src, and the standard library. The analysistest
+// package is not used as it loads packages in GOPATH mode.
+type testImporter struct {
+	fset *token.FileSet
+	std  types.Importer
+	pkgs map[string]*types.Package
+}
+
+func (i *testImporter) Import(path string) (*types.Package, error) {
+	if pkg, ok := i.pkgs[path]; ok {
+		return pkg, nil
+	}
+	if _, err := os.Stat(filepath.Join("testdata", "src", filepath.FromSlash(path))); err != nil {
+		return i.std.Import(path)
+	}
+	files, err := parsePackage(i.fset, path, 0)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := (&types.Config{Importer: i}).Check(path, i.fset, files, nil)
+	if err != nil {
+		return nil, err
+	}
+	i.pkgs[path] = pkg
+	return pkg, nil
+}
+
+func TestParseQuery(t *testing.T) {
+	q := parseQuery("SELECT DISTINCT `id`, e.name AS n, count() c, * FROM db.`events` AS e FINAL WHERE id = ? AND ts > $2 -- ?")
+	assert.Equal(t, &query{
+		table:  "db.events",
+		single: true,
+		columns: []selected{
+			{column: "id"},
+			{column: "name", alias: "n"},
+			{},
+		},
+		star:       true,
+		positional: 1,
+		numeric:    2,
+		comparisons: []comparison{
+			{column: "id", arg: 0},
+			{column: "ts", arg: 1},
+		},
+	}, q)
+
+	q = parseQuery("INSERT INTO events (id, `name`) VALUES")
+	assert.Equal(t, &query{
+		table:   "events",
+		single:  true,
+		insert:  true,
+		columns: []selected{{column: "id"}, {column: "name"}},
+	}, q)
+
+	q = parseQuery("SELECT a FROM (SELECT 1 AS a) WHERE a = @a AND b = '?'")
+	assert.Empty(t, q.table)
+	assert.True(t, q.named)
+	assert.Equal(t, 0, q.positional)
+}
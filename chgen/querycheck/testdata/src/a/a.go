@@ -0,0 +1,48 @@
+package a
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+const selectEvents = "SELECT id, name FROM events"
+
+func queries(ctx context.Context, conn driver.Conn, db *sql.DB, id uint64, name string, args []interface{}) {
+	var count uint64
+	conn.QueryRow(ctx, "SELECT count() FROM events WHERE id > ?", id).Scan(&count)
+	conn.QueryRow(ctx, "SELECT count() FROM events WHERE id > ? AND name = ?", id).Scan(&count) // want `query has 2 placeholders but 1 arguments are bound`
+	conn.QueryRow(ctx, "SELECT count() FROM events WHERE id > ?", args...).Scan(&count)
+	conn.QueryRow(ctx, "SELECT count(), max(ts) FROM events").Scan(&count) // want `Scan into 1 targets but the query selects 2 columns`
+	conn.Exec(ctx, "ALTER TABLE events DELETE WHERE id = $1 AND name = $2", id, name)
+	conn.Exec(ctx, "ALTER TABLE events DELETE WHERE id = $1 AND name = $2", id) // want `query has 2 placeholders but 1 arguments are bound`
+
+	rows, _ := conn.Query(ctx, selectEvents+" WHERE name = ?", name)
+	for rows.Next() {
+		rows.Scan(&id, &name)
+		rows.Scan(&id) // want `Scan into 1 targets but the query selects 2 columns`
+	}
+	rows, _ = conn.Query(ctx, "SELECT * FROM db.events")
+	rows.Scan(&id, &name, &count) // want `Scan into 3 targets but the query selects 4 columns`
+
+	conn.Query(ctx, "SELECT id, nme FROM events") // want `column nme does not exist in table db.events`
+	conn.Query(ctx, "SELECT id AS x, upper(name) n FROM events WHERE x = ?", 1)
+	conn.Query(ctx, "SELECT e.id, u.nme FROM events e JOIN users u ON e.id = u.id")
+	conn.Query(ctx, "SELECT id FROM events WHERE nme = ?", name) // want `column nme does not exist in table db.events`
+	conn.Query(ctx, "SELECT id FROM events WHERE id = ?", name)  // want `argument of type string is not compatible with column id UInt64`
+	conn.Query(ctx, "SELECT id FROM events WHERE ts > ? AND name = ?", time.Now(), []byte("a"))
+	conn.Query(ctx, "SELECT id FROM events WHERE name = ?", time.Now()) // want `argument of type time.Time is not compatible with column name LowCardinality(String)`
+	conn.Query(ctx, "SELECT _part, id FROM unknown WHERE x = ?", 1)
+
+	var batch driver.Batch
+	batch, _ = conn.PrepareBatch(ctx, "INSERT INTO events")
+	batch.Append(id, name, time.Now(), nil)
+	batch.Append(id, name)                                            // want `Append of 2 values but the query inserts 4 columns`
+	batch, _ = conn.PrepareBatch(ctx, "INSERT INTO events (id, nam)") // want `column nam does not exist in table db.events`
+	batch.Append(id)                                                  // want `Append of 1 values but the query inserts 2 columns`
+
+	db.QueryRowContext(ctx, "SELECT name FROM events WHERE id = ?", id, name).Scan(&name) // want `query has 1 placeholders but 2 arguments are bound`
+	db.QueryRow("SELECT name, id FROM events").Scan(&name)                                // want `Scan into 1 targets but the query selects 2 columns`
+}
@@ -0,0 +1,26 @@
+// Package driver is the subset of the driver interfaces used by the querycheck tests.
+package driver
+
+import "context"
+
+type (
+	Conn interface {
+		Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+		Query(ctx context.Context, query string, args ...interface{}) (Rows, error)
+		QueryRow(ctx context.Context, query string, args ...interface{}) Row
+		PrepareBatch(ctx context.Context, query string) (Batch, error)
+		Exec(ctx context.Context, query string, args ...interface{}) error
+	}
+	Row interface {
+		Scan(dest ...interface{}) error
+	}
+	Rows interface {
+		Next() bool
+		Scan(dest ...interface{}) error
+		Close() error
+	}
+	Batch interface {
+		Append(v ...interface{}) error
+		Send() error
+	}
+)
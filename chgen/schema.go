@@ -0,0 +1,218 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package chgen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var createTableRe = regexp.MustCompile(`(?is)^CREATE\s+(?:OR\s+REPLACE\s+)?(?:TEMPORARY\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?((?:` + "`[^`]+`" + `|"[^"]+"|[\w]+)(?:\.(?:` + "`[^`]+`" + `|"[^"]+"|[\w]+))?)\s*(?:ON\s+CLUSTER\s+\S+\s*)?\(`)
+
+// ParseSchema reads the tables of the CREATE TABLE statements in sql, separated by semicolons. Other
+// statements are ignored, as are MATERIALIZED, ALIAS and EPHEMERAL columns, indexes, projections and
+// constraints.
+func ParseSchema(sql string) ([]Table, error) {
+	var tables []Table
+	for _, stmt := range splitTopLevel(stripComments(sql), ';') {
+		m := createTableRe.FindStringSubmatchIndex(strings.TrimSpace(stmt))
+		if m == nil {
+			continue
+		}
+		stmt = strings.TrimSpace(stmt)
+		body, ok := enclosed(stmt[m[1]-1:])
+		if !ok {
+			return nil, fmt.Errorf("clickhouse [chgen]: unbalanced parentheses in %.40q", stmt)
+		}
+		parts := strings.Split(stmt[m[2]:m[3]], ".")
+		for i, part := range parts {
+			parts[i] = unquote(part)
+		}
+		t := Table{Name: strings.Join(parts, ".")}
+		for _, def := range splitTopLevel(body, ',') {
+			c, ok, err := parseColumn(strings.TrimSpace(def))
+			if err != nil {
+				return nil, fmt.Errorf("clickhouse [chgen]: table %s: %w", t.Name, err)
+			}
+			if ok {
+				t.Columns = append(t.Columns, c)
+			}
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// modifiers end the type of a column definition.
+var modifiers = map[string]bool{
+	"DEFAULT": true, "MATERIALIZED": true, "ALIAS": true, "EPHEMERAL": true, "CODEC": true,
+	"TTL": true, "COMMENT": true, "NULL": true, "NOT": true, "PRIMARY": true, "SETTINGS": true,
+}
+
+// parseColumn parses a column definition, reporting false for other elements and non-insertable columns.
+func parseColumn(def string) (Column, bool, error) {
+	if len(def) == 0 {
+		return Column{}, false, nil
+	}
+	name, rest := identifier(def)
+	switch strings.ToUpper(name) {
+	case "INDEX", "PROJECTION", "CONSTRAINT", "PRIMARY":
+		if !strings.HasPrefix(def, "`") && !strings.HasPrefix(def, `"`) {
+			return Column{}, false, nil
+		}
+	}
+	words := splitTopLevel(strings.TrimSpace(rest), ' ')
+	var typ []string
+	for i, word := range words {
+		if len(word) == 0 {
+			continue
+		}
+		upper := strings.ToUpper(word)
+		if !modifiers[upper] && !strings.HasPrefix(upper, "CODEC(") {
+			typ = append(typ, word)
+			continue
+		}
+		for j, modifier := range words[i:] {
+			switch strings.ToUpper(modifier) {
+			case "MATERIALIZED", "ALIAS", "EPHEMERAL":
+				return Column{}, false, nil
+			case "NULL":
+				if i+j == 0 || !strings.EqualFold(words[i+j-1], "NOT") {
+					typ = []string{"Nullable(" + strings.Join(typ, " ") + ")"}
+				}
+			}
+		}
+		break
+	}
+	if len(typ) == 0 {
+		return Column{}, false, fmt.Errorf("column %s has no type", name)
+	}
+	return Column{Name: name, Type: strings.Join(typ, " ")}, true, nil
+}
+
+// identifier splits a leading, possibly quoted, identifier from s.
+func identifier(s string) (string, string) {
+	if len(s) != 0 && (s[0] == '`' || s[0] == '"') {
+		if end := strings.IndexByte(s[1:], s[0]); end != -1 {
+			return s[1 : end+1], s[end+2:]
+		}
+	}
+	end := strings.IndexAny(s, " \t\r\n")
+	if end == -1 {
+		return s, ""
+	}
+	return s[:end], s[end:]
+}
+
+func unquote(s string) string {
+	if len(s) > 1 && (s[0] == '`' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// enclosed returns the contents of the parentheses s starts with.
+func enclosed(s string) (string, bool) {
+	depth, quote := 0, byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '`' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth == 0 {
+				return s[1:i], true
+			}
+		}
+	}
+	return "", false
+}
+
+// splitTopLevel splits s at sep outside of quotes and parentheses. A whitespace separator matches any
+// whitespace.
+func splitTopLevel(s string, sep byte) []string {
+	var (
+		parts []string
+		depth int
+		quote byte
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '`' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && (c == sep || sep == ' ' && (c == '\t' || c == '\n' || c == '\r')):
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// stripComments removes -- line comments outside of quotes.
+func stripComments(s string) string {
+	var (
+		b     strings.Builder
+		quote byte
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && i+1 < len(s) {
+				b.WriteByte(c)
+				i++
+				c = s[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '`' || c == '"':
+			quote = c
+		case c == '-' && i+1 < len(s) && s[i+1] == '-':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			if i == len(s) {
+				return b.String()
+			}
+			c = '\n'
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -21,6 +21,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"os"
 	"sync/atomic"
 	"time"
 
@@ -38,15 +40,28 @@ type (
 	Exception     = proto.Exception
 	ProfileInfo   = proto.ProfileInfo
 	ServerVersion = proto.ServerHandshake
+
+	ConversionPolicy   = column.ConversionPolicy
+	FixedStringOptions = column.FixedStringOptions
+)
+
+const (
+	ConversionLenient = column.ConversionLenient
+	ConversionStrict  = column.ConversionStrict
 )
 
 var (
 	ErrBatchInvalid              = errors.New("clickhouse: batch is invalid. check appended data is correct")
 	ErrBatchAlreadySent          = errors.New("clickhouse: batch has already been sent")
+	ErrBatchNotSent              = errors.New("clickhouse: batch has not been sent")
+	ErrBatchRetryUnsupported     = errors.New("clickhouse: batch retry is not supported by this connection")
+	ErrBatchResetUnsupported     = errors.New("clickhouse: batch reset is not supported by this batch")
 	ErrAcquireConnTimeout        = errors.New("clickhouse: acquire conn timeout. you can increase the number of max open conn or the dial timeout")
 	ErrUnsupportedServerRevision = errors.New("clickhouse: unsupported server revision")
 	ErrBindMixedParamsFormats    = errors.New("clickhouse [bind]: mixed named, numeric or positional parameters")
+	ErrInvalidIdentifier         = errors.New("clickhouse [bind]: invalid identifier")
 	ErrAcquireConnNoAddress      = errors.New("clickhouse: no valid address supplied")
+	ErrMaxOpenConnsPerHost       = errors.New("clickhouse: max open conns per host reached")
 )
 
 type OpError struct {
@@ -60,6 +75,9 @@ func (e *OpError) Error() string {
 	case *column.Error:
 		return fmt.Sprintf("clickhouse [%s]: (%s %s) %s", e.Op, e.ColumnName, err.ColumnType, err.Err)
 	case *column.ColumnConverterError:
+		if len(err.Column) != 0 {
+			return err.Error()
+		}
 		var hint string
 		if len(err.Hint) != 0 {
 			hint += ". " + err.Hint
@@ -73,23 +91,70 @@ func (e *OpError) Error() string {
 	return fmt.Sprintf("clickhouse [%s]: %s", e.Op, e.Err)
 }
 
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
 func Open(opt *Options) (driver.Conn, error) {
 	if opt == nil {
 		opt = &Options{}
 	}
 	o := opt.setDefaults()
+	ch := newPool(o, &samplingSupport{})
+	if len(o.ReadAddr) != 0 {
+		readOpt := *o
+		readOpt.Addr, readOpt.ReadAddr = o.ReadAddr, nil
+		// the replicas of ReadAddr serve the same tables, so they share what is known of their sampling keys
+		ch.reader = newPool(&readOpt, ch.sampling)
+	}
+	return ch, nil
+}
+
+func newPool(o *Options, sampling *samplingSupport) *clickhouse {
 	return &clickhouse{
-		opt:  o,
-		idle: make(chan *connect, o.MaxIdleConns),
-		open: make(chan struct{}, o.MaxOpenConns),
-	}, nil
+		opt:       o,
+		idle:      make(chan *connect, o.MaxIdleConns),
+		open:      make(chan struct{}, o.MaxOpenConns),
+		hosts:     newHostLimiter(o.MaxOpenConnsPerHost, o.HostLimit),
+		resolver:  newAddrResolver(o),
+		shutdowns: &shutdownTracker{},
+		sampling:  sampling,
+	}
 }
 
 type clickhouse struct {
 	opt    *Options
 	idle   chan *connect
 	open   chan struct{}
+	hosts  *hostLimiter
 	connID int64
+	// resolver expands Addr at dial time, if SRV names or ResolveTTL are used
+	resolver *addrResolver
+	// reader is the pool of the ReadAddr endpoints, if configured
+	reader *clickhouse
+	// shutdowns are the hosts whose server signaled a shutdown, see serverShutdown
+	shutdowns *shutdownTracker
+	// sampling remembers the tables without a sampling key, see WithSampling
+	sampling *samplingSupport
+}
+
+// pool returns the connection pool of a query - the read pool for reads when ReadAddr is configured,
+// unless the route is set explicitly with WithRoute or the reads must see recent writes, see ConsistencyToken.
+func (ch *clickhouse) pool(ctx context.Context, query string) *clickhouse {
+	if ch.reader == nil {
+		return ch
+	}
+	options := queryOptions(ctx)
+	switch options.route {
+	case RouteRead:
+		return ch.reader
+	case RouteWrite:
+		return ch
+	}
+	if StatementKind(query) == StatementRead && !options.consistency.fresh() {
+		return ch.reader
+	}
+	return ch
 }
 
 func (clickhouse) Contributors() []string {
@@ -114,90 +179,163 @@ func (ch *clickhouse) ServerVersion() (*driver.ServerVersion, error) {
 }
 
 func (ch *clickhouse) Query(ctx context.Context, query string, args ...interface{}) (rows driver.Rows, err error) {
-	conn, err := ch.acquire(ctx)
+	op := &Operation{Kind: OperationQuery, Query: query, Args: args}
+	err = ch.intercept(ctx, op, func(ctx context.Context, op *Operation) error {
+		pool := ch.pool(ctx, op.Query)
+		conn, err := pool.acquire(ctx)
+		if err != nil {
+			return err
+		}
+		conn.debugf("[acquired] connection [%d]", conn.id)
+		rows, err := conn.query(ctx, pool.release, op.Query, op.Args...)
+		if err != nil {
+			return err
+		}
+		op.Rows = rows
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	conn.debugf("[acquired] connection [%d]", conn.id)
-	return conn.query(ctx, ch.release, query, args...)
+	return op.Rows, nil
 }
 
 func (ch *clickhouse) QueryRow(ctx context.Context, query string, args ...interface{}) (rows driver.Row) {
-	conn, err := ch.acquire(ctx)
-	if err != nil {
+	op := &Operation{Kind: OperationQueryRow, Query: query, Args: args}
+	err := ch.intercept(ctx, op, func(ctx context.Context, op *Operation) error {
+		pool := ch.pool(ctx, op.Query)
+		conn, err := pool.acquire(ctx)
+		if err != nil {
+			return err
+		}
+		conn.debugf("[acquired] connection [%d]", conn.id)
+		op.Row = conn.queryRow(ctx, pool.release, op.Query, op.Args...)
+		return op.Row.Err()
+	})
+	if op.Row == nil || (err != nil && op.Row.Err() == nil) {
 		return &row{
 			err: err,
 		}
 	}
-	conn.debugf("[acquired] connection [%d]", conn.id)
-	return conn.queryRow(ctx, ch.release, query, args...)
+	return op.Row
 }
 
 func (ch *clickhouse) Exec(ctx context.Context, query string, args ...interface{}) error {
-	conn, err := ch.acquire(ctx)
-	if err != nil {
-		return err
-	}
-	if err := conn.exec(ctx, query, args...); err != nil {
-		ch.release(conn, err)
-		return err
-	}
-	ch.release(conn, nil)
-	return nil
+	op := &Operation{Kind: OperationExec, Query: query, Args: args}
+	return ch.intercept(ctx, op, func(ctx context.Context, op *Operation) error {
+		pool := ch.pool(ctx, op.Query)
+		conn, err := pool.acquire(ctx)
+		if err != nil {
+			return err
+		}
+		if err := conn.exec(ctx, op.Query, op.Args...); err != nil {
+			pool.release(conn, err)
+			return err
+		}
+		pool.release(conn, nil)
+		return nil
+	})
 }
 
 func (ch *clickhouse) PrepareBatch(ctx context.Context, query string) (driver.Batch, error) {
-	conn, err := ch.acquire(ctx)
-	if err != nil {
-		return nil, err
-	}
-	batch, err := conn.prepareBatch(ctx, query, ch.release)
+	op := &Operation{Kind: OperationPrepareBatch, Query: query}
+	err := ch.intercept(ctx, op, func(ctx context.Context, op *Operation) error {
+		if err := ch.opt.InsertThrottle.wait(ctx, op.Query); err != nil {
+			return err
+		}
+		conn, err := ch.acquire(ctx)
+		if err != nil {
+			return err
+		}
+		batch, err := conn.prepareBatch(ctx, op.Query, ch.release, ch.acquire)
+		if err != nil {
+			return err
+		}
+		op.Batch = batch
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return batch, nil
+	return op.Batch, nil
 }
 
 func (ch *clickhouse) AsyncInsert(ctx context.Context, query string, wait bool) error {
-	conn, err := ch.acquire(ctx)
-	if err != nil {
-		return err
-	}
-	if err := conn.asyncInsert(ctx, query, wait); err != nil {
-		ch.release(conn, err)
-		return err
-	}
-	ch.release(conn, nil)
-	return nil
+	op := &Operation{Kind: OperationAsyncInsert, Query: query}
+	return ch.intercept(ctx, op, func(ctx context.Context, op *Operation) error {
+		conn, err := ch.acquire(ctx)
+		if err != nil {
+			return err
+		}
+		if err := conn.asyncInsert(ctx, op.Query, wait); err != nil {
+			ch.release(conn, err)
+			return err
+		}
+		ch.release(conn, nil)
+		return nil
+	})
 }
 
 func (ch *clickhouse) Ping(ctx context.Context) (err error) {
-	conn, err := ch.acquire(ctx)
-	if err != nil {
-		return err
-	}
-	if err := conn.ping(ctx); err != nil {
-		ch.release(conn, err)
-		return err
-	}
-	ch.release(conn, nil)
-	return nil
+	op := &Operation{Kind: OperationPing}
+	return ch.intercept(ctx, op, func(ctx context.Context, op *Operation) error {
+		conn, err := ch.acquire(ctx)
+		if err != nil {
+			return err
+		}
+		if err := conn.ping(ctx); err != nil {
+			ch.release(conn, err)
+			return err
+		}
+		ch.release(conn, nil)
+		return nil
+	})
 }
 
+// Stats returns the connections of the pool, including those of the ReadAddr pool if configured.
 func (ch *clickhouse) Stats() driver.Stats {
-	return driver.Stats{
+	stats := driver.Stats{
 		Open:         len(ch.open),
 		Idle:         len(ch.idle),
 		MaxOpenConns: cap(ch.open),
 		MaxIdleConns: cap(ch.idle),
 	}
+	if ch.reader != nil {
+		reader := ch.reader.Stats()
+		stats.Open += reader.Open
+		stats.Idle += reader.Idle
+		stats.MaxOpenConns += reader.MaxOpenConns
+		stats.MaxIdleConns += reader.MaxIdleConns
+	}
+	return stats
 }
 
 func (ch *clickhouse) dial(ctx context.Context) (conn *connect, err error) {
+	return ch.dialObserved(ctx, nil)
+}
+
+// dialObserved dials a connection, calling observe, if set, with the outcome of every address tried.
+func (ch *clickhouse) dialObserved(ctx context.Context, observe func(addr string, err error)) (conn *connect, err error) {
 	connID := int(atomic.AddInt64(&ch.connID, 1))
 
 	dialFunc := func(ctx context.Context, addr string, opt *Options) (DialResult, error) {
+		if host := ch.resolver.host(addr); len(host) != 0 && opt.TLS != nil && len(opt.TLS.ServerName) == 0 {
+			// verify the certificate against the hostname rather than the resolved IP
+			withServerName := *opt
+			withServerName.TLS = opt.TLS.Clone()
+			withServerName.TLS.ServerName = host
+			opt = &withServerName
+		}
+		if err := ch.hosts.acquire(ctx, addr, opt.DialTimeout); err != nil {
+			return DialResult{}, err
+		}
 		conn, err := dial(ctx, addr, connID, opt)
+		if err != nil {
+			ch.hosts.release(addr)
+		}
+		if observe != nil {
+			observe(addr, err)
+		}
 
 		return DialResult{conn}, err
 	}
@@ -207,7 +345,18 @@ func (ch *clickhouse) dial(ctx context.Context) (conn *connect, err error) {
 		dialStrategy = ch.opt.DialStrategy
 	}
 
-	result, err := dialStrategy(ctx, connID, ch.opt, dialFunc)
+	opt := ch.opt
+	if ch.resolver != nil {
+		addrs, err := ch.resolver.resolve(ctx, ch.opt.Addr)
+		if err != nil {
+			return nil, err
+		}
+		resolved := *ch.opt
+		resolved.Addr = addrs
+		opt = &resolved
+	}
+
+	result, err := dialStrategy(ctx, connID, opt, dialFunc)
 	if err != nil {
 		return nil, err
 	}
@@ -238,6 +387,13 @@ func DefaultDialStrategy(ctx context.Context, connID int, opt *Options, dial Dia
 }
 
 func (ch *clickhouse) acquire(ctx context.Context) (conn *connect, err error) {
+	var (
+		start  = time.Now()
+		dialed bool
+	)
+	defer func() {
+		ch.acquired(ctx, start, conn, dialed, err)
+	}()
 	timer := time.NewTimer(ch.opt.DialTimeout)
 	defer timer.Stop()
 	select {
@@ -254,20 +410,23 @@ func (ch *clickhouse) acquire(ctx context.Context) (conn *connect, err error) {
 	case <-timer.C:
 		return nil, ErrAcquireConnTimeout
 	case conn := <-ch.idle:
-		if conn.isBad() {
+		switch {
+		case conn.isBad(), ch.shutdowns.stale(conn.addr, conn.connectedAt):
 			conn.close()
-			if conn, err = ch.dial(ctx); err != nil {
-				select {
-				case <-ch.open:
-				default:
-				}
-				return nil, err
+		case ch.hosts.tryAcquire(conn.addr):
+			conn.released = false
+			return conn, nil
+		default:
+			// the address of the idle connection is at MaxOpenConnsPerHost, leave it idle and dial
+			select {
+			case ch.idle <- conn:
+			default:
+				conn.close()
 			}
 		}
-		conn.released = false
-		return conn, nil
 	default:
 	}
+	dialed = true
 	if conn, err = ch.dial(ctx); err != nil {
 		select {
 		case <-ch.open:
@@ -278,16 +437,57 @@ func (ch *clickhouse) acquire(ctx context.Context) (conn *connect, err error) {
 	return conn, nil
 }
 
+// acquired reports an acquisition to the statistics of the statement and to Options.OnAcquire.
+func (ch *clickhouse) acquired(ctx context.Context, start time.Time, conn *connect, dialed bool, err error) {
+	var (
+		stats  *Statistics
+		labels Labels
+	)
+	if o, ok := ctx.Value(_contextOptionKey).(QueryOptions); ok {
+		stats, labels = o.statistics, o.labels
+	}
+	if stats == nil && ch.opt.OnAcquire == nil {
+		return
+	}
+	info := AcquireInfo{
+		Wait:   time.Since(start),
+		Dialed: dialed,
+		Err:    err,
+		Labels: labels,
+	}
+	if conn != nil {
+		info.Addr, info.ConnID = conn.addr, conn.id
+		info.Local, info.Compression = conn.local, conn.compression.Method
+	}
+	if stats != nil {
+		stats.Acquire = info
+	}
+	if ch.opt.OnAcquire != nil {
+		ch.opt.OnAcquire(ctx, info)
+	}
+}
+
 func (ch *clickhouse) release(conn *connect, err error) {
 	if conn.released {
 		return
 	}
 	conn.released = true
+	conn.resetSession()
 	select {
 	case <-ch.open:
 	default:
 	}
-	if err != nil || time.Since(conn.connectedAt) >= ch.opt.ConnMaxLifetime {
+	ch.hosts.release(conn.addr)
+	if err != nil && conn.isDrainedCancel(err) {
+		conn.debugf("[release] reusing drained connection after cancel")
+		err = nil
+	}
+	conn.drained = false
+	if err != nil && isServerShutdown(err) {
+		conn.debugf("[release] server shutdown: %v", err)
+		ch.serverShutdown(conn.addr, err)
+	}
+	if err != nil || time.Since(conn.connectedAt) >= ch.opt.ConnMaxLifetime || ch.shutdowns.stale(conn.addr, conn.connectedAt) {
 		conn.close()
 		return
 	}
@@ -298,7 +498,21 @@ func (ch *clickhouse) release(conn *connect, err error) {
 	}
 }
 
+// debugf logs the messages of the client which are not tied to a connection, when Debug is set.
+func (ch *clickhouse) debugf(format string, v ...interface{}) {
+	switch {
+	case !ch.opt.Debug:
+	case ch.opt.Debugf != nil:
+		ch.opt.Debugf(format, v...)
+	default:
+		log.New(os.Stdout, "[clickhouse]", 0).Printf(format, v...)
+	}
+}
+
 func (ch *clickhouse) Close() error {
+	if ch.reader != nil {
+		ch.reader.Close()
+	}
 	for {
 		select {
 		case c := <-ch.idle:
@@ -0,0 +1,58 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcquired(t *testing.T) {
+	var (
+		infos []AcquireInfo
+		ch    = &clickhouse{opt: &Options{
+			OnAcquire: func(ctx context.Context, info AcquireInfo) {
+				infos = append(infos, info)
+			},
+		}}
+		stats Statistics
+		ctx   = Context(context.Background(), WithStatistics(&stats), WithLabel("report", "daily_revenue"))
+		start = time.Now().Add(-time.Second)
+	)
+	ch.acquired(ctx, start, &connect{id: 3, addr: "host:9000"}, true, nil)
+	assert.Equal(t, "host:9000", stats.Acquire.Addr)
+	assert.Equal(t, 3, stats.Acquire.ConnID)
+	assert.True(t, stats.Acquire.Dialed)
+	assert.GreaterOrEqual(t, stats.Acquire.Wait, time.Second)
+	assert.Equal(t, Labels{"report": "daily_revenue"}, stats.Acquire.Labels)
+	stats.ReadRows = 10
+	stats.reset()
+	assert.Equal(t, uint64(0), stats.ReadRows)
+	assert.Equal(t, "host:9000", stats.Acquire.Addr, "reset keeps the acquisition of the statement")
+
+	ch.acquired(context.Background(), start, nil, false, ErrAcquireConnTimeout)
+	if assert.Len(t, infos, 2) {
+		assert.True(t, errors.Is(infos[1].Err, ErrAcquireConnTimeout))
+		assert.Empty(t, infos[1].Addr)
+		assert.Nil(t, infos[1].Labels)
+	}
+}
@@ -0,0 +1,108 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type HostLimitPolicy uint8
+
+const (
+	// HostLimitSpill dials the next address of the dial strategy when an address has
+	// MaxOpenConnsPerHost connections in use.
+	HostLimitSpill HostLimitPolicy = iota
+	// HostLimitQueue waits until a connection of the address chosen by the dial strategy is released.
+	HostLimitQueue
+)
+
+// hostLimiter limits the connections in use per address, so a slow replica cannot take the whole
+// MaxOpenConns budget. A nil hostLimiter does not limit anything.
+type hostLimiter struct {
+	max    int
+	policy HostLimitPolicy
+	mu     sync.Mutex
+	slots  map[string]chan struct{}
+}
+
+func newHostLimiter(max int, policy HostLimitPolicy) *hostLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &hostLimiter{
+		max:    max,
+		policy: policy,
+		slots:  make(map[string]chan struct{}),
+	}
+}
+
+func (h *hostLimiter) slot(addr string) chan struct{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	slot, found := h.slots[addr]
+	if !found {
+		slot = make(chan struct{}, h.max)
+		h.slots[addr] = slot
+	}
+	return slot
+}
+
+// tryAcquire takes a connection slot of addr if one is free.
+func (h *hostLimiter) tryAcquire(addr string) bool {
+	if h == nil {
+		return true
+	}
+	select {
+	case h.slot(addr) <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// acquire takes a connection slot of addr, waiting up to timeout for one with HostLimitQueue.
+func (h *hostLimiter) acquire(ctx context.Context, addr string, timeout time.Duration) error {
+	if h.tryAcquire(addr) {
+		return nil
+	}
+	if h.policy != HostLimitQueue {
+		return ErrMaxOpenConnsPerHost
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case h.slot(addr) <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrAcquireConnTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *hostLimiter) release(addr string) {
+	if h == nil {
+		return
+	}
+	select {
+	case <-h.slot(addr):
+	default:
+	}
+}
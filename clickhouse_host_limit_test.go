@@ -0,0 +1,50 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostLimiter(t *testing.T) {
+	var unlimited *hostLimiter
+	assert.Nil(t, newHostLimiter(0, HostLimitSpill))
+	assert.True(t, unlimited.tryAcquire("a:9000"))
+	unlimited.release("a:9000")
+
+	ctx := context.Background()
+	spill := newHostLimiter(1, HostLimitSpill)
+	assert.NoError(t, spill.acquire(ctx, "a:9000", time.Second))
+	assert.ErrorIs(t, spill.acquire(ctx, "a:9000", time.Second), ErrMaxOpenConnsPerHost)
+	assert.True(t, spill.tryAcquire("b:9000"))
+	spill.release("a:9000")
+	assert.True(t, spill.tryAcquire("a:9000"))
+
+	queue := newHostLimiter(1, HostLimitQueue)
+	assert.NoError(t, queue.acquire(ctx, "a:9000", time.Second))
+	assert.ErrorIs(t, queue.acquire(ctx, "a:9000", 10*time.Millisecond), ErrAcquireConnTimeout)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		queue.release("a:9000")
+	}()
+	assert.NoError(t, queue.acquire(ctx, "a:9000", time.Second))
+}
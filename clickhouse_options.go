@@ -41,6 +41,8 @@ func (c CompressionMethod) String() string {
 		return "zstd"
 	case CompressionLZ4:
 		return "lz4"
+	case CompressionLZ4HC:
+		return "lz4hc"
 	case CompressionGZIP:
 		return "gzip"
 	case CompressionDeflate:
@@ -59,12 +61,15 @@ const (
 	CompressionGZIP    = CompressionMethod(0x95)
 	CompressionDeflate = CompressionMethod(0x96)
 	CompressionBrotli  = CompressionMethod(0x97)
+	// CompressionLZ4HC is the high compression LZ4 encoder. Blocks are decoded as LZ4.
+	CompressionLZ4HC = CompressionMethod(0x98)
 )
 
 var compressionMap = map[string]CompressionMethod{
 	"none":    CompressionNone,
 	"zstd":    CompressionZSTD,
 	"lz4":     CompressionLZ4,
+	"lz4hc":   CompressionLZ4HC,
 	"gzip":    CompressionGZIP,
 	"deflate": CompressionDeflate,
 	"br":      CompressionBrotli,
@@ -74,12 +79,33 @@ type Auth struct { // has_control_character
 	Database string
 	Username string
 	Password string
+	// Credentials, if set, is called on every connection attempt (and every HTTP request) for the
+	// username and password, e.g. to read rotated secrets from a secret manager instead of keeping
+	// Password in memory. An empty username falls back to Username.
+	Credentials func(ctx context.Context) (username, password string, err error)
+}
+
+// credentials returns the username and password of a connection attempt.
+func (a *Auth) credentials(ctx context.Context) (username, password string, err error) {
+	if a.Credentials == nil {
+		return a.Username, a.Password, nil
+	}
+	if username, password, err = a.Credentials(ctx); err != nil {
+		return "", "", errors.Wrap(err, "clickhouse [credentials]")
+	}
+	if len(username) == 0 {
+		username = a.Username
+	}
+	return username, password, nil
 }
 
 type Compression struct {
 	Method CompressionMethod
-	// this only applies to zlib and brotli compression algorithms
+	// this only applies to zlib and brotli compression algorithms, and to LZ4HC and ZSTD over the native protocol
 	Level int
+	// SkipLocal disables the compression of native connections to loopback addresses and unix sockets, where
+	// compressing costs more CPU than the bytes it saves. WithCompression still applies to single queries.
+	SkipLocal bool
 }
 
 type ConnOpenStrategy uint8
@@ -89,11 +115,24 @@ const (
 	ConnOpenRoundRobin
 )
 
+type UnexpectedPacketPolicy uint8
+
+const (
+	// UnexpectedPacketError fails the query when the server sends a packet the client does not handle.
+	UnexpectedPacketError UnexpectedPacketPolicy = iota
+	// UnexpectedPacketSkip discards unhandled packets whose framing is known, e.g. part UUIDs or
+	// read task requests sent by newer servers, and only fails on packets it cannot skip.
+	UnexpectedPacketSkip
+)
+
 type Protocol int
 
 const (
 	Native Protocol = iota
 	HTTP
+	// GRPC is the gRPC interface of the server, enabled by its grpc_port setting. Like HTTP it is used by OpenDB.
+	// Only the gzip and deflate compression methods are supported, and query parameters are not.
+	GRPC
 )
 
 func (p Protocol) String() string {
@@ -102,6 +141,8 @@ func (p Protocol) String() string {
 		return "native"
 	case HTTP:
 		return "http"
+	case GRPC:
+		return "grpc"
 	default:
 		return ""
 	}
@@ -125,7 +166,9 @@ type Options struct {
 	ClientInfo ClientInfo
 
 	TLS                  *tls.Config
-	Addr                 []string
+	TLSVerifyNames       []string // if set, the server certificate must be valid for one of these names rather than the dialed host
+	Addr                 []string // entries such as _clickhouse._tcp.example.com are looked up as DNS SRV records
+	ReadAddr             []string // if set, reads are sent to these addresses and everything else to Addr, see WithRoute
 	Auth                 Auth
 	DialContext          func(ctx context.Context, addr string) (net.Conn, error)
 	DialStrategy         func(ctx context.Context, connID int, options *Options, dial Dial) (DialResult, error)
@@ -140,11 +183,60 @@ type Options struct {
 	ConnOpenStrategy     ConnOpenStrategy
 	HttpHeaders          map[string]string // set additional headers on HTTP requests
 	HttpUrlPath          string            // set additional URL path for HTTP requests
+	Http2                bool              // negotiate HTTP/2 over TLS, multiplexing the HTTP connections of the pool over shared sockets
+	HttpMaxConnsPerHost  int               // if set, limits the sockets per host shared by the HTTP connections of the pool
 	BlockBufferSize      uint8             // default 2 - can be overwritten on query
 	MaxCompressionBuffer int               // default 10485760 - measured in bytes  i.e. 10MiB
+	Interceptors         []Interceptor     // applied in order, the first interceptor is the outermost
+	CancelDrainTimeout   time.Duration     // if set, a cancelled query is drained for up to this long so the connection can be reused
+	UnexpectedPackets    UnexpectedPacketPolicy
+	ProtocolRevision     uint64 // pin the native protocol revision, default ClientTCPProtocolVersion
+	BandwidthLimit       int    // if set, limits the bytes per second written by each native connection
+	ReadOnly             bool   // reject statements other than reads (SELECT, SHOW, DESCRIBE...) and set readonly = 2
+	DisallowDDL          bool   // reject DDL statements (CREATE, ALTER, DROP...)
+	MaxOpenConnsPerHost  int    // if set, limits the connections in use per address, see HostLimit
+	HostLimit            HostLimitPolicy
+	ResolveTTL           time.Duration // if set, hostnames of Addr are resolved to all their IPs at dial time, at most once per ResolveTTL
+	DialFamily           DialFamily    // IP address families of the default dialer, see DialFamilyAny
+	DialFallbackDelay    time.Duration // delay before racing the other address family, default 300ms, negative to disable racing
+	Socket               SocketOptions // buffer sizes, TCP_NODELAY and DSCP of the connections of the default dialer
+
+	// OnAcquire is called after every acquisition of a native pool connection, e.g. to record info.Wait in a histogram.
+	OnAcquire func(ctx context.Context, info AcquireInfo)
+	// InsertThrottle, if set, delays the batches of tables the server signals too many parts for.
+	InsertThrottle *InsertThrottle
+	// ConversionPolicy governs the implicit conversions of appended and scanned values, see ConversionStrict.
+	ConversionPolicy ConversionPolicy
+	// FixedString controls the padding and truncation of values appended to FixedString columns, and the
+	// trimming of the padding when scanning them into strings.
+	FixedString FixedStringOptions
+	// BoolMapping scans legacy UInt8 and Int8 "boolean" columns, also when Nullable, into Go bool values and
+	// accepts bool values when appending to them. Such columns can still be used with their integer types.
+	BoolMapping bool
+	// SessionSettings is what the client does with the settings of SET statements, see SessionSettingsReset.
+	SessionSettings SessionSettingsPolicy
+	// SkipChecksumVerification skips the verification of the checksums of the compressed blocks received over
+	// the native protocol. Blocks which fail to decompress are still reported as a CorruptedBlockError.
+	SkipChecksumVerification bool
+	// OnCorruptedBlock, if set, is called with the compressed blocks received over the native protocol which
+	// fail their checksum or to decompress, as received, e.g. to log hex.Dump(block) while debugging middleboxes.
+	OnCorruptedBlock func(err *CorruptedBlockError, block []byte)
+	// Capture, if set, records the traffic of the native connections to a trace to attach to bug reports.
+	Capture *Capture
+	// DecorateContext, if set, is applied to the context of every query, batch and ping of the client, including the
+	// queries the client issues itself such as the timezone and version queries of the HTTP and gRPC protocols, e.g.
+	// to add the tenant to the quota key or the log_comment setting for auditing.
+	DecorateContext func(ctx context.Context) context.Context
+	// MemoryFallback, if set, re-runs the reads which exceeded the memory limit of the query once with GROUP BY and
+	// ORDER BY spilling to disk.
+	MemoryFallback *MemoryFallback
 
 	scheme      string
 	ReadTimeout time.Duration
+	// replay are the settings of SET statements replayed on the connections of the pool
+	replay *sessionSettings
+	// httpTransport is shared by the HTTP connections of the pool, see Http2
+	httpTransport *httpTransport
 }
 
 func (o *Options) fromDSN(in string) error {
@@ -169,6 +261,11 @@ func (o *Options) fromDSN(in string) error {
 		secure     bool
 		params     = dsn.Query()
 		skipVerify bool
+		serverName string
+		minVersion uint16
+		maxVersion uint16
+		ciphers    []uint16
+		skipLocal  bool
 	)
 	o.Auth.Database = strings.TrimPrefix(dsn.Path, "/")
 
@@ -211,6 +308,11 @@ func (o *Options) fromDSN(in string) error {
 			}
 
 			o.Compression.Level = int(level)
+		case "compress_skip_local":
+			var err error
+			if skipLocal, err = strconv.ParseBool(params.Get(v)); err != nil {
+				return errors.Wrap(err, "compress_skip_local invalid value")
+			}
 		case "max_compression_buffer":
 			max, err := strconv.Atoi(params.Get(v))
 			if err != nil {
@@ -223,6 +325,55 @@ func (o *Options) fromDSN(in string) error {
 				return fmt.Errorf("clickhouse [dsn parse]: dial timeout: %s", err)
 			}
 			o.DialTimeout = duration
+		case "resolve_ttl":
+			duration, err := time.ParseDuration(params.Get(v))
+			if err != nil {
+				return fmt.Errorf("clickhouse [dsn parse]: resolve ttl: %s", err)
+			}
+			o.ResolveTTL = duration
+		case "dial_family":
+			switch params.Get(v) {
+			case "any":
+				o.DialFamily = DialFamilyAny
+			case "prefer_ipv4":
+				o.DialFamily = DialPreferIPv4
+			case "prefer_ipv6":
+				o.DialFamily = DialPreferIPv6
+			case "ipv4":
+				o.DialFamily = DialIPv4Only
+			case "ipv6":
+				o.DialFamily = DialIPv6Only
+			default:
+				return fmt.Errorf("clickhouse [dsn parse]: dial_family must be any, prefer_ipv4, prefer_ipv6, ipv4 or ipv6")
+			}
+		case "dial_fallback_delay":
+			duration, err := time.ParseDuration(params.Get(v))
+			if err != nil {
+				return fmt.Errorf("clickhouse [dsn parse]: dial fallback delay: %s", err)
+			}
+			o.DialFallbackDelay = duration
+		case "socket_read_buffer", "socket_write_buffer":
+			size, err := strconv.Atoi(params.Get(v))
+			if err != nil {
+				return errors.Wrap(err, v+" invalid value")
+			}
+			if v == "socket_read_buffer" {
+				o.Socket.ReadBuffer = size
+			} else {
+				o.Socket.WriteBuffer = size
+			}
+		case "tcp_nodelay":
+			noDelay, err := strconv.ParseBool(params.Get(v))
+			if err != nil {
+				return errors.Wrap(err, "tcp_nodelay invalid value")
+			}
+			o.Socket.Nagle = !noDelay
+		case "dscp":
+			dscp, err := strconv.ParseUint(params.Get(v), 10, 8)
+			if err != nil || dscp > 63 {
+				return fmt.Errorf("clickhouse [dsn parse]: dscp must be between 0 and 63")
+			}
+			o.Socket.DSCP = uint8(dscp)
 		case "block_buffer_size":
 			if blockBufferSize, err := strconv.ParseUint(params.Get(v), 10, 8); err == nil {
 				if blockBufferSize <= 0 {
@@ -238,6 +389,66 @@ func (o *Options) fromDSN(in string) error {
 				return fmt.Errorf("clickhouse [dsn parse]:read timeout: %s", err)
 			}
 			o.ReadTimeout = duration
+		case "cancel_drain_timeout":
+			duration, err := time.ParseDuration(params.Get(v))
+			if err != nil {
+				return fmt.Errorf("clickhouse [dsn parse]: cancel drain timeout: %s", err)
+			}
+			o.CancelDrainTimeout = duration
+		case "read_addr":
+			for _, addr := range strings.Split(params.Get(v), ",") {
+				if addr = strings.TrimSpace(addr); len(addr) != 0 {
+					o.ReadAddr = append(o.ReadAddr, addr)
+				}
+			}
+		case "read_only":
+			readOnly, err := strconv.ParseBool(params.Get(v))
+			if err != nil {
+				return errors.Wrap(err, "read_only invalid value")
+			}
+			o.ReadOnly = readOnly
+		case "disallow_ddl":
+			disallowDDL, err := strconv.ParseBool(params.Get(v))
+			if err != nil {
+				return errors.Wrap(err, "disallow_ddl invalid value")
+			}
+			o.DisallowDDL = disallowDDL
+		case "bandwidth_limit":
+			limit, err := strconv.Atoi(params.Get(v))
+			if err != nil {
+				return errors.Wrap(err, "bandwidth_limit invalid value")
+			}
+			o.BandwidthLimit = limit
+		case "max_open_conns_per_host":
+			max, err := strconv.Atoi(params.Get(v))
+			if err != nil {
+				return errors.Wrap(err, "max_open_conns_per_host invalid value")
+			}
+			o.MaxOpenConnsPerHost = max
+		case "host_limit":
+			switch params.Get(v) {
+			case "spill":
+				o.HostLimit = HostLimitSpill
+			case "queue":
+				o.HostLimit = HostLimitQueue
+			default:
+				return fmt.Errorf("clickhouse [dsn parse]: host_limit must be spill or queue")
+			}
+		case "protocol_revision":
+			revision, err := strconv.ParseUint(params.Get(v), 10, 64)
+			if err != nil {
+				return errors.Wrap(err, "protocol_revision invalid value")
+			}
+			o.ProtocolRevision = revision
+		case "unexpected_packets":
+			switch params.Get(v) {
+			case "error":
+				o.UnexpectedPackets = UnexpectedPacketError
+			case "skip":
+				o.UnexpectedPackets = UnexpectedPacketSkip
+			default:
+				return fmt.Errorf("clickhouse [dsn parse]: unexpected_packets must be error or skip")
+			}
 		case "secure":
 			secureParam := params.Get(v)
 			if secureParam == "" {
@@ -248,6 +459,22 @@ func (o *Options) fromDSN(in string) error {
 					return fmt.Errorf("clickhouse [dsn parse]:secure: %s", err)
 				}
 			}
+		case "tls_server_name":
+			serverName = params.Get(v)
+		case "tls_min_version":
+			if minVersion, err = parseTLSVersion(params.Get(v)); err != nil {
+				return err
+			}
+		case "tls_max_version":
+			if maxVersion, err = parseTLSVersion(params.Get(v)); err != nil {
+				return err
+			}
+		case "tls_cipher_suites":
+			if ciphers, err = parseCipherSuites(params.Get(v)); err != nil {
+				return err
+			}
+		case "tls_verify_names":
+			o.TLSVerifyNames = strings.Split(params.Get(v), ",")
 		case "skip_verify":
 			skipVerifyParam := params.Get(v)
 			if skipVerifyParam == "" {
@@ -265,6 +492,63 @@ func (o *Options) fromDSN(in string) error {
 			case "round_robin":
 				o.ConnOpenStrategy = ConnOpenRoundRobin
 			}
+		case "conversion_policy":
+			switch params.Get(v) {
+			case "lenient":
+				o.ConversionPolicy = ConversionLenient
+			case "strict":
+				o.ConversionPolicy = ConversionStrict
+			default:
+				return fmt.Errorf("clickhouse [dsn parse]: unknown conversion_policy %q", params.Get(v))
+			}
+		case "fixed_string_truncate", "fixed_string_padding", "fixed_string_trim_nulls":
+			on, err := strconv.ParseBool(params.Get(v))
+			if err != nil {
+				return errors.Wrap(err, v+" invalid value")
+			}
+			switch v {
+			case "fixed_string_truncate":
+				o.FixedString.Truncate = on
+			case "fixed_string_padding":
+				o.FixedString.NoPadding = !on
+			case "fixed_string_trim_nulls":
+				o.FixedString.TrimNulls = on
+			}
+		case "session_settings":
+			switch params.Get(v) {
+			case "server":
+				o.SessionSettings = SessionSettingsServer
+			case "reset":
+				o.SessionSettings = SessionSettingsReset
+			case "replay":
+				o.SessionSettings = SessionSettingsReplay
+			default:
+				return fmt.Errorf("clickhouse [dsn parse]: unknown session_settings %q", params.Get(v))
+			}
+		case "bool_mapping":
+			on, err := strconv.ParseBool(params.Get(v))
+			if err != nil {
+				return errors.Wrap(err, v+" invalid value")
+			}
+			o.BoolMapping = on
+		case "skip_checksum_verification":
+			on, err := strconv.ParseBool(params.Get(v))
+			if err != nil {
+				return errors.Wrap(err, v+" invalid value")
+			}
+			o.SkipChecksumVerification = on
+		case "http2":
+			on, err := strconv.ParseBool(params.Get(v))
+			if err != nil {
+				return errors.Wrap(err, v+" invalid value")
+			}
+			o.Http2 = on
+		case "http_max_conns_per_host":
+			max, err := strconv.Atoi(params.Get(v))
+			if err != nil {
+				return errors.Wrap(err, v+" invalid value")
+			}
+			o.HttpMaxConnsPerHost = max
 		case "username":
 			o.Auth.Username = params.Get(v)
 		case "password":
@@ -295,9 +579,20 @@ func (o *Options) fromDSN(in string) error {
 			}
 		}
 	}
+	// applied after the compress parameters so the default level does not depend on the order of the parameters
+	if skipLocal {
+		if o.Compression == nil {
+			o.Compression = &Compression{Method: CompressionNone}
+		}
+		o.Compression.SkipLocal = true
+	}
 	if secure {
 		o.TLS = &tls.Config{
 			InsecureSkipVerify: skipVerify,
+			ServerName:         serverName,
+			MinVersion:         minVersion,
+			MaxVersion:         maxVersion,
+			CipherSuites:       ciphers,
 		}
 	}
 	o.scheme = dsn.Scheme
@@ -312,6 +607,9 @@ func (o *Options) fromDSN(in string) error {
 			return fmt.Errorf("clickhouse [dsn parse]: https without TLS")
 		}
 		o.Protocol = HTTP
+	case "grpc":
+		// TLS is enabled by the secure parameter
+		o.Protocol = GRPC
 	default:
 		o.Protocol = Native
 	}
@@ -320,9 +618,32 @@ func (o *Options) fromDSN(in string) error {
 
 // receive copy of Options, so we don't modify original - so its reusable
 func (o Options) setDefaults() *Options {
+	if o.TLS != nil && fipsMode {
+		o.TLS = fipsTLSConfig(o.TLS)
+	}
+	if o.TLS != nil && len(o.TLSVerifyNames) != 0 {
+		o.TLS = withVerifyNames(o.TLS, o.TLSVerifyNames)
+	}
+	if o.ReadOnly {
+		// readonly = 2 still allows the query settings sent by the client
+		settings := make(Settings, len(o.Settings)+1)
+		for k, v := range o.Settings {
+			settings[k] = v
+		}
+		if _, ok := settings["readonly"]; !ok {
+			settings["readonly"] = 2
+		}
+		o.Settings = settings
+	}
 	if len(o.Auth.Username) == 0 {
 		o.Auth.Username = "default"
 	}
+	if o.SessionSettings == SessionSettingsReplay {
+		o.replay = &sessionSettings{settings: make(Settings), shared: true}
+	}
+	if o.Http2 || o.HttpMaxConnsPerHost > 0 {
+		o.httpTransport = &httpTransport{}
+	}
 	if o.DialTimeout == 0 {
 		o.DialTimeout = time.Second * 30
 	}
@@ -350,7 +671,17 @@ func (o Options) setDefaults() *Options {
 			o.Addr = []string{"localhost:9000"}
 		case HTTP:
 			o.Addr = []string{"localhost:8123"}
+		case GRPC:
+			o.Addr = []string{"localhost:9100"}
 		}
 	}
 	return &o
 }
+
+// protocolRevision returns the revision the client announces in the handshake.
+func (o *Options) protocolRevision() uint64 {
+	if o.ProtocolRevision == 0 || o.ProtocolRevision > ClientTCPProtocolVersion {
+		return ClientTCPProtocolVersion
+	}
+	return o.ProtocolRevision
+}
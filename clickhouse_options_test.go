@@ -18,10 +18,14 @@
 package clickhouse
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestParseDSN does not implement all use cases yet
@@ -270,6 +274,26 @@ func TestParseDSN(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"native protocol with lz4 compression skipped for local connections",
+			"clickhouse://127.0.0.1/test_database?compress=lz4&compress_skip_local=true",
+			&Options{
+				Protocol: Native,
+				TLS:      nil,
+				Addr:     []string{"127.0.0.1"},
+				Settings: Settings{},
+				Compression: &Compression{
+					Method:    CompressionLZ4,
+					Level:     3,
+					SkipLocal: true,
+				},
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
 		{
 			"native protocol with zstd compression",
 			"clickhouse://127.0.0.1/test_database?compress=zstd",
@@ -449,6 +473,380 @@ func TestParseDSN(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"native protocol with cancel drain timeout",
+			"clickhouse://127.0.0.1/test_database?cancel_drain_timeout=2s",
+			&Options{
+				Protocol:           Native,
+				TLS:                nil,
+				Addr:               []string{"127.0.0.1"},
+				Settings:           Settings{},
+				CancelDrainTimeout: 2 * time.Second,
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol skipping unexpected packets",
+			"clickhouse://127.0.0.1/test_database?unexpected_packets=skip",
+			&Options{
+				Protocol:          Native,
+				TLS:               nil,
+				Addr:              []string{"127.0.0.1"},
+				Settings:          Settings{},
+				UnexpectedPackets: UnexpectedPacketSkip,
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol with invalid unexpected packets policy",
+			"clickhouse://127.0.0.1/test_database?unexpected_packets=ignore",
+			nil,
+			"clickhouse [dsn parse]: unexpected_packets must be error or skip",
+		},
+		{
+			"native protocol with pinned protocol revision",
+			"clickhouse://127.0.0.1/test_database?protocol_revision=54451",
+			&Options{
+				Protocol:         Native,
+				TLS:              nil,
+				Addr:             []string{"127.0.0.1"},
+				Settings:         Settings{},
+				ProtocolRevision: 54451,
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol with lz4hc compression and compression level 12",
+			"clickhouse://127.0.0.1/test_database?compress=lz4hc&compress_level=12",
+			&Options{
+				Protocol: Native,
+				TLS:      nil,
+				Addr:     []string{"127.0.0.1"},
+				Settings: Settings{},
+				Compression: &Compression{
+					Method: CompressionLZ4HC,
+					Level:  12,
+				},
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol with bandwidth limit",
+			"clickhouse://127.0.0.1/test_database?bandwidth_limit=1048576",
+			&Options{
+				Protocol:       Native,
+				TLS:            nil,
+				Addr:           []string{"127.0.0.1"},
+				Settings:       Settings{},
+				BandwidthLimit: 1048576,
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol with read only and disallow ddl",
+			"clickhouse://127.0.0.1/test_database?read_only=true&disallow_ddl=1",
+			&Options{
+				Protocol:    Native,
+				TLS:         nil,
+				Addr:        []string{"127.0.0.1"},
+				Settings:    Settings{},
+				ReadOnly:    true,
+				DisallowDDL: true,
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol with invalid read only option",
+			"clickhouse://127.0.0.1/test_database?read_only=yes",
+			nil,
+			"read_only invalid value: strconv.ParseBool: parsing \"yes\": invalid syntax",
+		},
+		{
+			"native protocol with invalid disallow ddl option",
+			"clickhouse://127.0.0.1/test_database?disallow_ddl=never",
+			nil,
+			"disallow_ddl invalid value: strconv.ParseBool: parsing \"never\": invalid syntax",
+		},
+		{
+			"native protocol with read addresses",
+			"clickhouse://127.0.0.1/test_database?read_addr=replica-1:9000,replica-2:9000",
+			&Options{
+				Protocol: Native,
+				TLS:      nil,
+				Addr:     []string{"127.0.0.1"},
+				ReadAddr: []string{"replica-1:9000", "replica-2:9000"},
+				Settings: Settings{},
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol with a per host connection limit",
+			"clickhouse://127.0.0.1/test_database?max_open_conns_per_host=4&host_limit=queue",
+			&Options{
+				Protocol:            Native,
+				TLS:                 nil,
+				Addr:                []string{"127.0.0.1"},
+				Settings:            Settings{},
+				MaxOpenConnsPerHost: 4,
+				HostLimit:           HostLimitQueue,
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol with address resolution",
+			"clickhouse://127.0.0.1/test_database?resolve_ttl=30s",
+			&Options{
+				Protocol:   Native,
+				TLS:        nil,
+				Addr:       []string{"127.0.0.1"},
+				Settings:   Settings{},
+				ResolveTTL: 30 * time.Second,
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol with dial family",
+			"clickhouse://127.0.0.1/test_database?dial_family=prefer_ipv4&dial_fallback_delay=100ms",
+			&Options{
+				Protocol:          Native,
+				TLS:               nil,
+				Addr:              []string{"127.0.0.1"},
+				Settings:          Settings{},
+				DialFamily:        DialPreferIPv4,
+				DialFallbackDelay: 100 * time.Millisecond,
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol with socket options",
+			"clickhouse://127.0.0.1/test_database?socket_read_buffer=1048576&socket_write_buffer=4194304&tcp_nodelay=false&dscp=10",
+			&Options{
+				Protocol: Native,
+				TLS:      nil,
+				Addr:     []string{"127.0.0.1"},
+				Settings: Settings{},
+				Socket: SocketOptions{
+					ReadBuffer:  1048576,
+					WriteBuffer: 4194304,
+					Nagle:       true,
+					DSCP:        10,
+				},
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol with TLS server name and verify names",
+			"clickhouse://127.0.0.1/test_database?secure=true&tls_server_name=clickhouse.internal&tls_verify_names=a.example.com,b.example.com",
+			&Options{
+				Protocol: Native,
+				TLS: &tls.Config{
+					InsecureSkipVerify: false,
+					ServerName:         "clickhouse.internal",
+				},
+				TLSVerifyNames: []string{"a.example.com", "b.example.com"},
+				Addr:           []string{"127.0.0.1"},
+				Settings:       Settings{},
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol with TLS versions and cipher suites",
+			"clickhouse://127.0.0.1/test_database?secure=true&tls_min_version=1.2&tls_max_version=1.2&tls_cipher_suites=TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+			&Options{
+				Protocol: Native,
+				TLS: &tls.Config{
+					MinVersion: tls.VersionTLS12,
+					MaxVersion: tls.VersionTLS12,
+					CipherSuites: []uint16{
+						tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+						tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+					},
+				},
+				Addr:     []string{"127.0.0.1"},
+				Settings: Settings{},
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol with strict conversion policy",
+			"clickhouse://127.0.0.1/test_database?conversion_policy=strict",
+			&Options{
+				Protocol:         Native,
+				TLS:              nil,
+				Addr:             []string{"127.0.0.1"},
+				Settings:         Settings{},
+				ConversionPolicy: ConversionStrict,
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol with unknown conversion policy",
+			"clickhouse://127.0.0.1/test_database?conversion_policy=loose",
+			nil,
+			"clickhouse [dsn parse]: unknown conversion_policy \"loose\"",
+		},
+		{
+			"native protocol with fixed string options",
+			"clickhouse://127.0.0.1/test_database?fixed_string_truncate=true&fixed_string_padding=false&fixed_string_trim_nulls=1",
+			&Options{
+				Protocol: Native,
+				TLS:      nil,
+				Addr:     []string{"127.0.0.1"},
+				Settings: Settings{},
+				FixedString: FixedStringOptions{
+					Truncate:  true,
+					NoPadding: true,
+					TrimNulls: true,
+				},
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol with invalid fixed string option",
+			"clickhouse://127.0.0.1/test_database?fixed_string_truncate=maybe",
+			nil,
+			"fixed_string_truncate invalid value: strconv.ParseBool: parsing \"maybe\": invalid syntax",
+		},
+		{
+			"native protocol with bool mapping",
+			"clickhouse://127.0.0.1/test_database?bool_mapping=true",
+			&Options{
+				Protocol:    Native,
+				TLS:         nil,
+				Addr:        []string{"127.0.0.1"},
+				Settings:    Settings{},
+				BoolMapping: true,
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol with session settings policy",
+			"clickhouse://127.0.0.1/test_database?session_settings=reset",
+			&Options{
+				Protocol:        Native,
+				TLS:             nil,
+				Addr:            []string{"127.0.0.1"},
+				Settings:        Settings{},
+				SessionSettings: SessionSettingsReset,
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "clickhouse",
+			},
+			"",
+		},
+		{
+			"native protocol with unknown session settings policy",
+			"clickhouse://127.0.0.1/test_database?session_settings=forget",
+			nil,
+			"clickhouse [dsn parse]: unknown session_settings \"forget\"",
+		},
+		{
+			"grpc protocol",
+			"grpc://127.0.0.1:9100/test_database",
+			&Options{
+				Protocol: GRPC,
+				TLS:      nil,
+				Addr:     []string{"127.0.0.1:9100"},
+				Settings: Settings{},
+				Auth: Auth{
+					Database: "test_database",
+				},
+				scheme: "grpc",
+			},
+			"",
+		},
+		{
+			"http2 with max conns per host",
+			"https://127.0.0.1:8443?secure=true&http2=true&http_max_conns_per_host=4",
+			&Options{
+				Protocol:            HTTP,
+				TLS:                 &tls.Config{InsecureSkipVerify: false},
+				Addr:                []string{"127.0.0.1:8443"},
+				Settings:            Settings{},
+				Http2:               true,
+				HttpMaxConnsPerHost: 4,
+				scheme:              "https",
+			},
+			"",
+		},
+		{
+			"skip checksum verification",
+			"clickhouse://127.0.0.1:9000?skip_checksum_verification=true",
+			&Options{
+				Protocol:                 Native,
+				TLS:                      nil,
+				Addr:                     []string{"127.0.0.1:9000"},
+				Settings:                 Settings{},
+				SkipChecksumVerification: true,
+				scheme:                   "clickhouse",
+			},
+			"",
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -466,3 +864,33 @@ func TestParseDSN(t *testing.T) {
 		})
 	}
 }
+
+func TestProtocolRevision(t *testing.T) {
+	assert.Equal(t, uint64(ClientTCPProtocolVersion), (&Options{}).protocolRevision())
+	assert.Equal(t, uint64(54451), (&Options{ProtocolRevision: 54451}).protocolRevision())
+	assert.Equal(t, uint64(ClientTCPProtocolVersion), (&Options{ProtocolRevision: ClientTCPProtocolVersion + 1}).protocolRevision())
+}
+
+func TestAuthCredentials(t *testing.T) {
+	ctx := context.Background()
+	auth := Auth{Username: "default", Password: "static"}
+	username, password, err := auth.credentials(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "default", username)
+	assert.Equal(t, "static", password)
+
+	auth.Credentials = func(context.Context) (string, string, error) {
+		return "", "rotated", nil
+	}
+	username, password, err = auth.credentials(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "default", username)
+	assert.Equal(t, "rotated", password)
+
+	failed := errors.New("secret manager unavailable")
+	auth.Credentials = func(context.Context) (string, string, error) {
+		return "", "", failed
+	}
+	_, _, err = auth.credentials(ctx)
+	assert.ErrorIs(t, err, failed)
+}
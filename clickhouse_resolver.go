@@ -0,0 +1,132 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// isSRVAddr reports whether addr is a DNS SRV name such as _clickhouse._tcp.example.com.
+func isSRVAddr(addr string) bool {
+	return strings.HasPrefix(addr, "_") && !strings.Contains(addr, ":")
+}
+
+// addrResolver expands the configured addresses at dial time: SRV names to their targets and, if
+// ttl is set, hostnames to all their IPs. Results are cached for ttl, so endpoints of e.g. a
+// Kubernetes headless service are picked up as pods come and go.
+type addrResolver struct {
+	ttl        time.Duration
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+	lookupSRV  func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+
+	mu       sync.Mutex
+	addrs    []string
+	hosts    map[string]string // resolved address -> hostname, for TLS verification
+	resolved time.Time
+}
+
+func newAddrResolver(opt *Options) *addrResolver {
+	resolve := opt.ResolveTTL > 0
+	for _, addr := range opt.Addr {
+		resolve = resolve || isSRVAddr(addr)
+	}
+	if !resolve {
+		return nil
+	}
+	return &addrResolver{
+		ttl:        opt.ResolveTTL,
+		lookupHost: net.DefaultResolver.LookupHost,
+		lookupSRV:  net.DefaultResolver.LookupSRV,
+	}
+}
+
+// resolve returns the addresses to dial. If a lookup fails, the previously resolved addresses are kept.
+func (r *addrResolver) resolve(ctx context.Context, configured []string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.addrs != nil && time.Since(r.resolved) < r.ttl {
+		return r.addrs, nil
+	}
+	var (
+		addrs []string
+		hosts = make(map[string]string)
+	)
+	for _, addr := range configured {
+		resolved, err := r.lookup(ctx, addr)
+		if err != nil {
+			if r.addrs != nil {
+				return r.addrs, nil
+			}
+			return nil, err
+		}
+		for _, a := range resolved {
+			if _, found := hosts[a.addr]; !found {
+				addrs = append(addrs, a.addr)
+				hosts[a.addr] = a.host
+			}
+		}
+	}
+	r.addrs, r.hosts, r.resolved = addrs, hosts, time.Now()
+	return addrs, nil
+}
+
+type resolvedAddr struct {
+	addr string
+	host string
+}
+
+func (r *addrResolver) lookup(ctx context.Context, addr string) (resolved []resolvedAddr, err error) {
+	if isSRVAddr(addr) {
+		_, records, err := r.lookupSRV(ctx, "", "", addr)
+		if err != nil {
+			return nil, err
+		}
+		for _, srv := range records {
+			host := strings.TrimSuffix(srv.Target, ".")
+			resolved = append(resolved, resolvedAddr{addr: net.JoinHostPort(host, strconv.Itoa(int(srv.Port))), host: host})
+		}
+		return resolved, nil
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || r.ttl <= 0 || net.ParseIP(host) != nil {
+		return []resolvedAddr{{addr: addr}}, nil
+	}
+	ips, err := r.lookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		resolved = append(resolved, resolvedAddr{addr: net.JoinHostPort(ip, port), host: host})
+	}
+	return resolved, nil
+}
+
+// host returns the hostname addr was resolved from, if any.
+func (r *addrResolver) host(addr string) string {
+	if r == nil {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.hosts[addr]
+}
@@ -0,0 +1,82 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddrResolver(t *testing.T) {
+	assert.Nil(t, newAddrResolver(&Options{Addr: []string{"clickhouse:9000"}}))
+	require.NotNil(t, newAddrResolver(&Options{Addr: []string{"_clickhouse._tcp.example.com"}}))
+
+	var (
+		lookups int
+		ips     = []string{"10.0.0.1", "10.0.0.2"}
+		ctx     = context.Background()
+	)
+	resolver := newAddrResolver(&Options{ResolveTTL: time.Hour})
+	resolver.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		lookups++
+		if host != "clickhouse" {
+			return nil, errors.New("no such host")
+		}
+		return ips, nil
+	}
+	resolver.lookupSRV = func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		return "", []*net.SRV{
+			{Target: "replica-1.example.com.", Port: 9440},
+			{Target: "replica-2.example.com.", Port: 9440},
+		}, nil
+	}
+	addrs, err := resolver.resolve(ctx, []string{"clickhouse:9000", "127.0.0.1:9000", "_clickhouse._tcp.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"10.0.0.1:9000",
+		"10.0.0.2:9000",
+		"127.0.0.1:9000",
+		"replica-1.example.com:9440",
+		"replica-2.example.com:9440",
+	}, addrs)
+	assert.Equal(t, "clickhouse", resolver.host("10.0.0.2:9000"))
+	assert.Equal(t, "", resolver.host("127.0.0.1:9000"))
+	assert.Equal(t, "replica-1.example.com", resolver.host("replica-1.example.com:9440"))
+
+	ips = []string{"10.0.0.3"}
+	addrs, err = resolver.resolve(ctx, []string{"clickhouse:9000"})
+	require.NoError(t, err)
+	assert.Len(t, addrs, 5, "cached until the TTL expired")
+	assert.Equal(t, 1, lookups)
+
+	resolver.resolved = time.Now().Add(-2 * time.Hour)
+	addrs, err = resolver.resolve(ctx, []string{"clickhouse:9000"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.3:9000"}, addrs)
+
+	resolver.resolved = time.Now().Add(-2 * time.Hour)
+	addrs, err = resolver.resolve(ctx, []string{"unknown:9000"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.3:9000"}, addrs, "failed lookups keep the previous addresses")
+}
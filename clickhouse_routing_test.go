@@ -0,0 +1,60 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadWriteRouting(t *testing.T) {
+	conn, err := Open(&Options{
+		Addr:     []string{"writer:9000"},
+		ReadAddr: []string{"reader-1:9000", "reader-2:9000"},
+	})
+	require.NoError(t, err)
+	var (
+		ch  = conn.(*clickhouse)
+		ctx = context.Background()
+	)
+	require.NotNil(t, ch.reader)
+	assert.Equal(t, []string{"reader-1:9000", "reader-2:9000"}, ch.reader.opt.Addr)
+	assert.Equal(t, []string{"writer:9000"}, ch.opt.Addr)
+
+	assert.Same(t, ch.reader, ch.pool(ctx, "SELECT 1"))
+	assert.Same(t, ch, ch.pool(ctx, "INSERT INTO t VALUES"))
+	assert.Same(t, ch, ch.pool(ctx, "ALTER TABLE t DELETE WHERE 1"))
+	assert.Same(t, ch, ch.pool(Context(ctx, WithRoute(RouteWrite)), "SELECT 1"))
+	assert.Same(t, ch.reader, ch.pool(Context(ctx, WithRoute(RouteRead)), "SYSTEM FLUSH LOGS"))
+
+	assert.Same(t, ch.sampling, ch.reader.sampling)
+
+	ch.reader.open <- struct{}{}
+	stats := conn.Stats()
+	assert.Equal(t, 1, stats.Open)
+	assert.Equal(t, 2*ch.opt.MaxOpenConns, stats.MaxOpenConns)
+	assert.Equal(t, 2*ch.opt.MaxIdleConns, stats.MaxIdleConns)
+
+	single, err := Open(&Options{Addr: []string{"writer:9000"}})
+	require.NoError(t, err)
+	assert.Same(t, single, single.(*clickhouse).pool(ctx, "SELECT 1"))
+	assert.Equal(t, ch.opt.MaxOpenConns, single.Stats().MaxOpenConns)
+}
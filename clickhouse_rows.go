@@ -33,9 +33,32 @@ type rows struct {
 	stream    chan *proto.Block
 	columns   []string
 	structMap *structMap
+	mapping   *ColumnMapping
+	limits    *ResultLimits
+	spool     *spool
+	returned  uint64 // rows returned by Next, counted only with limits
 }
 
-func (r *rows) Next() (result bool) {
+func (r *rows) Next() bool {
+	if r.limits == nil {
+		return r.next()
+	}
+	if !r.next() {
+		r.err = r.limits.wrap(r.err, r.returned)
+		return false
+	}
+	if r.limits.KeepPartial && r.limits.MaxRows != 0 && r.returned >= r.limits.MaxRows {
+		// the server breaks at a block boundary, drop the rows past the limit
+		r.err = &ResultLimitError{Limits: *r.limits, Rows: r.returned}
+		r.Close()
+		r.block = nil
+		return false
+	}
+	r.returned++
+	return true
+}
+
+func (r *rows) next() (result bool) {
 	defer func() {
 		if !result {
 			r.Close()
@@ -55,6 +78,9 @@ next:
 			goto next
 		case block := <-r.stream:
 			if block == nil {
+				if err := r.spool.failure(); err != nil {
+					r.err = err
+				}
 				return false
 			}
 			if block.Packet == proto.ServerTotals {
@@ -76,7 +102,7 @@ func (r *rows) Scan(dest ...interface{}) error {
 }
 
 func (r *rows) ScanStruct(dest interface{}) error {
-	values, err := r.structMap.Map("ScanStruct", r.columns, dest, true)
+	values, err := r.structMap.MapColumns("ScanStruct", r.columns, dest, true, r.mapping)
 	if err != nil {
 		return err
 	}
@@ -95,6 +121,7 @@ func (r *rows) Columns() []string {
 }
 
 func (r *rows) Close() error {
+	r.spool.discard()
 	active := 2
 	for {
 		select {
@@ -136,7 +163,7 @@ func (r *row) ScanStruct(dest interface{}) error {
 	if r.err != nil {
 		return r.err
 	}
-	values, err := r.rows.structMap.Map("ScanStruct", r.rows.columns, dest, true)
+	values, err := r.rows.structMap.MapColumns("ScanStruct", r.rows.columns, dest, true, r.rows.mapping)
 	if err != nil {
 		return err
 	}
@@ -67,6 +67,10 @@ func (o *stdConnOpener) Connect(ctx context.Context) (_ driver.Conn, err error)
 		dialFunc = func(ctx context.Context, addr string, num int, opt *Options) (stdConnect, error) {
 			return dialHttp(ctx, addr, num, opt)
 		}
+	case GRPC:
+		dialFunc = func(ctx context.Context, addr string, num int, opt *Options) (stdConnect, error) {
+			return dialGRPC(ctx, addr, num, opt)
+		}
 	default:
 		dialFunc = func(ctx context.Context, addr string, num int, opt *Options) (stdConnect, error) {
 			return dial(ctx, addr, num, opt)
@@ -91,6 +95,7 @@ func (o *stdConnOpener) Connect(ctx context.Context) (_ driver.Conn, err error)
 				debugf = log.New(os.Stdout, fmt.Sprintf("[clickhouse-std][conn=%d][%s] ", num, o.opt.Addr[num]), 0).Printf
 			}
 			return &stdDriver{
+				opt:    o.opt,
 				conn:   conn,
 				debugf: debugf,
 			}, nil
@@ -170,14 +175,35 @@ type stdConnect interface {
 	query(ctx context.Context, release func(*connect, error), query string, args ...interface{}) (*rows, error)
 	exec(ctx context.Context, query string, args ...interface{}) error
 	ping(ctx context.Context) (err error)
-	prepareBatch(ctx context.Context, query string, release func(*connect, error)) (ldriver.Batch, error)
+	prepareBatch(ctx context.Context, query string, release func(*connect, error), acquire func(context.Context) (*connect, error)) (ldriver.Batch, error)
 	asyncInsert(ctx context.Context, query string, wait bool) error
+	serverVersion() (*ServerVersion, error)
+	resetSession()
+}
+
+// NativeConn is the stable interface of a database/sql connection to native driver features.
+// Pooling stays with database/sql - obtain it for the lifetime of a single sql.Conn via Raw:
+//
+//	conn.Raw(func(driverConn interface{}) error {
+//		batch, err := driverConn.(clickhouse.NativeConn).PrepareBatch(ctx, "INSERT INTO example")
+//		...
+//	})
+//
+// Profile events, progress and logs are available through the query options of ctx (see WithProfileEvents).
+type NativeConn interface {
+	ServerVersion() (*ServerVersion, error)
+	PrepareBatch(ctx context.Context, query string) (ldriver.Batch, error)
+	Query(ctx context.Context, query string, args ...interface{}) (ldriver.Rows, error)
+	Exec(ctx context.Context, query string, args ...interface{}) error
 }
 
 type stdDriver struct {
+	opt    *Options
 	conn   stdConnect
 	commit func() error
 	debugf func(format string, v ...interface{})
+	// shutdown is set once the server signaled a shutdown, see recycle
+	shutdown bool
 }
 
 func (std *stdDriver) Open(dsn string) (_ driver.Conn, err error) {
@@ -196,14 +222,28 @@ func (std *stdDriver) Open(dsn string) (_ driver.Conn, err error) {
 }
 
 func (std *stdDriver) ResetSession(ctx context.Context) error {
-	if std.conn.isBad() {
+	if std.shutdown || std.conn.isBad() {
 		std.debugf("Resetting session because connection is bad")
 		return driver.ErrBadConn
 	}
+	std.conn.resetSession()
 	return nil
 }
 
-func (std *stdDriver) Ping(ctx context.Context) error { return std.conn.ping(ctx) }
+// context decorates ctx with Options.DecorateContext and returns the error of an invalid
+// query option passed to Context.
+func (std *stdDriver) context(ctx context.Context) (context.Context, error) {
+	ctx = decorateContext(std.opt.DecorateContext, ctx)
+	return ctx, contextError(ctx)
+}
+
+func (std *stdDriver) Ping(ctx context.Context) error {
+	ctx, err := std.context(ctx)
+	if err != nil {
+		return err
+	}
+	return std.conn.ping(ctx)
+}
 
 func (std *stdDriver) Begin() (driver.Tx, error) { return std, nil }
 
@@ -234,25 +274,112 @@ func (std *stdDriver) Rollback() error {
 
 func (std *stdDriver) CheckNamedValue(nv *driver.NamedValue) error { return nil }
 
+// BulkRows can be passed as the only argument of an INSERT executed through database/sql.
+// All rows are sent as a single native batch instead of one round trip per row.
+//
+//	db.ExecContext(ctx, "INSERT INTO example", clickhouse.BulkRows{{1, "a"}, {2, "b"}})
+type BulkRows [][]interface{}
+
+func bulkRows(args []driver.NamedValue) (BulkRows, bool) {
+	if len(args) != 1 {
+		return nil, false
+	}
+	rows, ok := args[0].Value.(BulkRows)
+	return rows, ok
+}
+
+func (std *stdDriver) execBulk(ctx context.Context, query string, rows BulkRows) (driver.Result, error) {
+	if err := std.opt.checkStatement(query); err != nil {
+		return nil, err
+	}
+	batch, err := std.conn.prepareBatch(ctx, query, func(*connect, error) {}, nil)
+	if err != nil {
+		if isConnBrokenError(err) {
+			std.debugf("ExecContext bulk got a fatal error, resetting connection: %v\n", err)
+			return nil, driver.ErrBadConn
+		}
+		std.debugf("ExecContext bulk prepare error: %v\n", err)
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := batch.Append(row...); err != nil {
+			std.debugf("ExecContext bulk append error: %v\n", err)
+			std.abortBulk(batch)
+			return nil, err
+		}
+	}
+	if err := batch.Send(); err != nil {
+		std.abortBulk(batch)
+		if isConnBrokenError(err) {
+			std.debugf("ExecContext bulk got a fatal error, resetting connection: %v\n", err)
+			return nil, driver.ErrBadConn
+		}
+		std.debugf("ExecContext bulk send error: %v\n", err)
+		return nil, err
+	}
+	return driver.RowsAffected(len(rows)), nil
+}
+
+// abortBulk aborts a failed bulk insert. A native connection is left in the middle of the INSERT, so it is
+// closed for ResetSession to report it as bad and database/sql to discard it rather than reuse it.
+func (std *stdDriver) abortBulk(batch ldriver.Batch) {
+	err := batch.Abort()
+	if _, native := std.conn.(*connect); native || err != nil {
+		std.debugf("ExecContext bulk aborted, closing connection")
+		std.conn.close()
+	}
+}
+
 func (std *stdDriver) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ctx, err := std.context(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if rows, ok := bulkRows(args); ok {
+		return std.execBulk(ctx, query, rows)
+	}
+	if err := std.opt.checkStatement(query); err != nil {
+		return nil, err
+	}
 	if options := queryOptions(ctx); options.async.ok {
 		if len(args) != 0 {
 			return nil, errors.New("clickhouse: you can't use parameters in an asynchronous insert")
 		}
 		return driver.RowsAffected(0), std.conn.asyncInsert(ctx, query, options.async.wait)
 	}
+	var (
+		written  int64
+		progress = queryOptions(ctx).events.progress
+	)
+	// the server reports written rows through progress packets - count them for RowsAffected
+	ctx = Context(ctx, WithProgress(func(p *Progress) {
+		written += int64(p.WroteRows)
+		if progress != nil {
+			progress(p)
+		}
+	}))
 	if err := std.conn.exec(ctx, query, rebind(args)...); err != nil {
 		if isConnBrokenError(err) {
 			std.debugf("ExecContext got a fatal error, resetting connection: %v\n", err)
 			return nil, driver.ErrBadConn
 		}
 		std.debugf("ExecContext error: %v\n", err)
+		std.recycle(err)
 		return nil, err
 	}
-	return driver.RowsAffected(0), nil
+	observeWrite(ctx, query, nil)
+	return driver.RowsAffected(written), nil
 }
 
 func (std *stdDriver) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, err := std.context(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := std.opt.checkStatement(query); err != nil {
+		return nil, err
+	}
+	ctx = consistentRead(ctx, query)
 	r, err := std.conn.query(ctx, func(*connect, error) {}, query, rebind(args)...)
 	if isConnBrokenError(err) {
 		std.debugf("QueryContext got a fatal error, resetting connection: %v\n", err)
@@ -260,6 +387,7 @@ func (std *stdDriver) QueryContext(ctx context.Context, query string, args []dri
 	}
 	if err != nil {
 		std.debugf("QueryContext error: %v\n", err)
+		std.recycle(err)
 		return nil, err
 	}
 	return &stdRows{
@@ -273,7 +401,14 @@ func (std *stdDriver) Prepare(query string) (driver.Stmt, error) {
 }
 
 func (std *stdDriver) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
-	batch, err := std.conn.prepareBatch(ctx, query, func(*connect, error) {})
+	ctx, err := std.context(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := std.opt.checkStatement(query); err != nil {
+		return nil, err
+	}
+	batch, err := std.conn.prepareBatch(ctx, query, func(*connect, error) {}, nil)
 	if err != nil {
 		if isConnBrokenError(err) {
 			std.debugf("PrepareContext got a fatal error, resetting connection: %v\n", err)
@@ -288,6 +423,59 @@ func (std *stdDriver) PrepareContext(ctx context.Context, query string) (driver.
 	}, nil
 }
 
+func (std *stdDriver) ServerVersion() (*ServerVersion, error) {
+	return std.conn.serverVersion()
+}
+
+func (std *stdDriver) PrepareBatch(ctx context.Context, query string) (ldriver.Batch, error) {
+	ctx, err := std.context(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := std.opt.checkStatement(query); err != nil {
+		return nil, err
+	}
+	return std.conn.prepareBatch(ctx, query, func(*connect, error) {}, nil)
+}
+
+func (std *stdDriver) Query(ctx context.Context, query string, args ...interface{}) (ldriver.Rows, error) {
+	ctx, err := std.context(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := std.opt.checkStatement(query); err != nil {
+		return nil, err
+	}
+	ctx = consistentRead(ctx, query)
+	r, err := std.conn.query(ctx, func(*connect, error) {}, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (std *stdDriver) Exec(ctx context.Context, query string, args ...interface{}) error {
+	ctx, err := std.context(ctx)
+	if err != nil {
+		return err
+	}
+	if err := std.opt.checkStatement(query); err != nil {
+		return err
+	}
+	err = std.conn.exec(ctx, query, args...)
+	observeWrite(ctx, query, err)
+	return err
+}
+
+// recycle marks the connection bad after its server signaled a shutdown, so that database/sql discards it
+// when resetting its session rather than failing the next query.
+func (std *stdDriver) recycle(err error) {
+	if isServerShutdown(err) {
+		std.debugf("server shutdown, discarding connection: %v\n", err)
+		std.shutdown = true
+	}
+}
+
 func (std *stdDriver) Close() error {
 	err := std.conn.close()
 	if err != nil {
@@ -309,6 +497,12 @@ func (s *stdBatch) NumInput() int { return -1 }
 func (s *stdBatch) Exec(args []driver.Value) (driver.Result, error) {
 	values := make([]interface{}, 0, len(args))
 	for _, v := range args {
+		if sv, ok := v.(stdValue); ok {
+			var err error
+			if v, err = sv.Value(); err != nil {
+				return nil, err
+			}
+		}
 		values = append(values, v)
 	}
 	if err := s.batch.Append(values...); err != nil {
@@ -319,6 +513,15 @@ func (s *stdBatch) Exec(args []driver.Value) (driver.Result, error) {
 }
 
 func (s *stdBatch) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if rows, ok := bulkRows(args); ok {
+		for _, row := range rows {
+			if err := s.batch.Append(row...); err != nil {
+				s.debugf("[batch][exec] append error: %v", err)
+				return nil, err
+			}
+		}
+		return driver.RowsAffected(len(rows)), nil
+	}
 	values := make([]driver.Value, 0, len(args))
 	for _, v := range args {
 		values = append(values, v.Value)
@@ -422,3 +625,5 @@ func (r *stdRows) Close() error {
 	}
 	return err
 }
+
+var _ NativeConn = (*stdDriver)(nil)
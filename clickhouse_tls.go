@@ -0,0 +1,142 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func parseTLSVersion(version string) (uint16, error) {
+	if v, found := tlsVersions[version]; found {
+		return v, nil
+	}
+	return 0, fmt.Errorf("clickhouse [dsn parse]: unknown TLS version %q, expected 1.0, 1.1, 1.2 or 1.3", version)
+}
+
+// parseCipherSuites returns the IDs of comma separated cipher suite names such as
+// TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Insecure cipher suites are rejected.
+func parseCipherSuites(names string) ([]uint16, error) {
+	var ids []uint16
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, suite := range tls.CipherSuites() {
+			if suite.Name == name {
+				ids, found = append(ids, suite.ID), true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("clickhouse [dsn parse]: unknown or insecure TLS cipher suite %q", name)
+		}
+	}
+	return ids, nil
+}
+
+// fipsCipherSuites are the FIPS 140-2 approved TLS 1.2 cipher suites.
+var fipsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// fipsTLSConfig returns a copy of config restricted to FIPS approved protocol versions, cipher
+// suites and curves. It is applied to Options.TLS in builds with the fips or boringcrypto tag.
+func fipsTLSConfig(config *tls.Config) *tls.Config {
+	fips := config.Clone()
+	if fips.MinVersion < tls.VersionTLS12 {
+		fips.MinVersion = tls.VersionTLS12
+	}
+	// the cipher suites of TLS 1.3 are not configurable and include ChaCha20-Poly1305
+	fips.MaxVersion = tls.VersionTLS12
+	var suites []uint16
+	for _, id := range config.CipherSuites {
+		for _, approved := range fipsCipherSuites {
+			if id == approved {
+				suites = append(suites, id)
+			}
+		}
+	}
+	if len(suites) == 0 {
+		suites = fipsCipherSuites
+	}
+	fips.CipherSuites = suites
+	fips.CurvePreferences = []tls.CurveID{tls.CurveP256, tls.CurveP384, tls.CurveP521}
+	return fips
+}
+
+// withVerifyNames returns a copy of config accepting a server certificate valid for any of names,
+// instead of the dialed host. This is needed behind TCP load balancers whose addresses are not in
+// the certificate. The certificate chain is still verified against config.RootCAs.
+func withVerifyNames(config *tls.Config, names []string) *tls.Config {
+	if config.InsecureSkipVerify || len(names) == 0 {
+		return config
+	}
+	var (
+		verify     = config.VerifyConnection
+		withNames  = config.Clone()
+		timeSource = config.Time
+	)
+	withNames.InsecureSkipVerify = true
+	withNames.VerifyConnection = func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("clickhouse [tls]: server sent no certificate")
+		}
+		options := x509.VerifyOptions{
+			Roots:         config.RootCAs,
+			Intermediates: x509.NewCertPool(),
+		}
+		if timeSource != nil {
+			options.CurrentTime = timeSource()
+		}
+		for _, cert := range state.PeerCertificates[1:] {
+			options.Intermediates.AddCert(cert)
+		}
+		leaf := state.PeerCertificates[0]
+		if _, err := leaf.Verify(options); err != nil {
+			return err
+		}
+		matched := false
+		for _, name := range names {
+			if leaf.VerifyHostname(name) == nil {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("clickhouse [tls]: certificate is not valid for any of %s", strings.Join(names, ", "))
+		}
+		if verify != nil {
+			return verify(state)
+		}
+		return nil
+	}
+	return withNames
+}
@@ -0,0 +1,132 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tlsTestServer listens on a local address with a certificate for dnsName, signed by the returned CA.
+func tlsTestServer(t *testing.T, dnsName string) (string, *x509.CertPool) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err = x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	leafDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{leafDER}, PrivateKey: key}},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ln.Close()
+	})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				conn.(*tls.Conn).Handshake()
+			}(conn)
+		}
+	}()
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	return ln.Addr().String(), roots
+}
+
+func TestTLSVerifyNames(t *testing.T) {
+	addr, roots := tlsTestServer(t, "clickhouse.internal")
+	dialTLS := func(config *tls.Config, names ...string) error {
+		opt := (&Options{TLS: config, TLSVerifyNames: names, DialTimeout: time.Second}).setDefaults()
+		conn, err := dialNetwork(context.Background(), addr, opt)
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+	assert.Error(t, dialTLS(&tls.Config{RootCAs: roots}), "the dialed IP is not in the certificate")
+	assert.NoError(t, dialTLS(&tls.Config{RootCAs: roots, ServerName: "clickhouse.internal"}))
+	assert.NoError(t, dialTLS(&tls.Config{RootCAs: roots}, "lb.example.com", "clickhouse.internal"))
+	assert.Error(t, dialTLS(&tls.Config{RootCAs: roots}, "lb.example.com"))
+	assert.Error(t, dialTLS(&tls.Config{}, "clickhouse.internal"), "the chain is still verified")
+}
+
+func TestFIPSTLSConfig(t *testing.T) {
+	config := fipsTLSConfig(&tls.Config{
+		MinVersion: tls.VersionTLS10,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		},
+	})
+	assert.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+	assert.Equal(t, uint16(tls.VersionTLS12), config.MaxVersion)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}, config.CipherSuites)
+	assert.Equal(t, fipsCipherSuites, fipsTLSConfig(&tls.Config{}).CipherSuites)
+}
+
+func TestParseTLSOptions(t *testing.T) {
+	version, err := parseTLSVersion("1.3")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), version)
+	_, err = parseTLSVersion("1.4")
+	assert.Error(t, err)
+	suites, err := parseCipherSuites("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_AES_128_GCM_SHA256")
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_AES_128_GCM_SHA256}, suites)
+	_, err = parseCipherSuites("TLS_RSA_WITH_RC4_128_SHA")
+	assert.Error(t, err, "insecure cipher suites are rejected")
+}
@@ -0,0 +1,70 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+// ColumnConverter converts a value before it is appended to a column, e.g. a custom Money type to
+// decimal.Decimal or a string trimmed to the length of a FixedString column. It is called with every
+// value appended to the column, including nil, and must be safe for concurrent use with a BatchWriter.
+type ColumnConverter func(v interface{}) (interface{}, error)
+
+// WithColumnConverters registers converters by column name for the batches prepared with the context.
+// They are applied by Append, AppendStruct and the AppendRow of a batch column, so rows don't need to be
+// transformed before they are appended. Converters of columns the insert does not have are ignored.
+func WithColumnConverters(converters map[string]ColumnConverter) QueryOption {
+	return func(o *QueryOptions) error {
+		o.columnConverters = converters
+		return nil
+	}
+}
+
+// columnConverters returns the converters of the columns names by index, nil if none applies.
+func columnConverters(converters map[string]ColumnConverter, names []string) []ColumnConverter {
+	var indexed []ColumnConverter
+	for i, name := range names {
+		convert, ok := converters[name]
+		if !ok {
+			continue
+		}
+		if indexed == nil {
+			indexed = make([]ColumnConverter, len(names))
+		}
+		indexed[i] = convert
+	}
+	return indexed
+}
+
+// convertRow applies the converters to the row v, returning a copy if any converter applies. The
+// caller's values are never modified.
+func convertRow(op string, converters []ColumnConverter, names []string, v []interface{}) ([]interface{}, error) {
+	if converters == nil {
+		return v, nil
+	}
+	converted := make([]interface{}, len(v))
+	copy(converted, v)
+	for i, convert := range converters {
+		if convert == nil || i >= len(v) {
+			continue
+		}
+		value, err := convert(v[i])
+		if err != nil {
+			return nil, &OpError{Op: op, ColumnName: names[i], Err: err}
+		}
+		converted[i] = value
+	}
+	return converted, nil
+}
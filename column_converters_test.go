@@ -0,0 +1,71 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchColumnConverters(t *testing.T) {
+	block := &proto.Block{}
+	require.NoError(t, block.AddColumn("id", "UInt64"))
+	require.NoError(t, block.AddColumn("code", "FixedString(2)"))
+	converters := map[string]ColumnConverter{
+		"code": func(v interface{}) (interface{}, error) {
+			s, ok := v.(string)
+			if !ok {
+				return nil, errors.New("not a string")
+			}
+			if len(s) > 2 {
+				s = s[:2]
+			}
+			return strings.ToUpper(s), nil
+		},
+		"missing": func(v interface{}) (interface{}, error) {
+			return v, nil
+		},
+	}
+	b := &batch{
+		block:      block,
+		converters: columnConverters(converters, block.ColumnsNames()),
+	}
+	row := []interface{}{uint64(1), "abc"}
+	require.NoError(t, b.Append(row...))
+	assert.Equal(t, "abc", row[1], "the values of the caller are not modified")
+	require.NoError(t, b.Column(1).AppendRow("de"))
+	require.NoError(t, b.Column(0).AppendRow(uint64(2)))
+
+	err := b.Append(uint64(3), 42)
+	var opErr *OpError
+	require.ErrorAs(t, err, &opErr)
+	assert.Equal(t, "code", opErr.ColumnName)
+	assert.Nil(t, b.err, "a failed conversion does not invalidate the batch")
+	assert.Error(t, b.Column(1).AppendRow(42))
+
+	require.Equal(t, 2, block.Rows())
+	assert.Equal(t, "AB", block.Columns[1].Row(0, false))
+	assert.Equal(t, "DE", block.Columns[1].Row(1, false))
+
+	assert.Nil(t, columnConverters(map[string]ColumnConverter{"missing": converters["missing"]}, block.ColumnsNames()))
+}
@@ -19,7 +19,6 @@ package clickhouse
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"io"
 	"log"
@@ -32,7 +31,6 @@ import (
 	"github.com/ClickHouse/clickhouse-go/v2/resources"
 	"github.com/pkg/errors"
 
-	"github.com/ClickHouse/ch-go/compress"
 	chproto "github.com/ClickHouse/ch-go/proto"
 	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
 )
@@ -43,20 +41,24 @@ func dial(ctx context.Context, addr string, num int, opt *Options) (*connect, er
 		conn   net.Conn
 		debugf = func(format string, v ...interface{}) {}
 	)
+	username, password, err := opt.Auth.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
 	switch {
 	case opt.DialContext != nil:
 		conn, err = opt.DialContext(ctx, addr)
 	default:
-		switch {
-		case opt.TLS != nil:
-			conn, err = tls.DialWithDialer(&net.Dialer{Timeout: opt.DialTimeout}, "tcp", addr, opt.TLS)
-		default:
-			conn, err = net.DialTimeout("tcp", addr, opt.DialTimeout)
-		}
+		conn, err = dialNetwork(ctx, addr, opt)
 	}
 	if err != nil {
 		return nil, err
 	}
+	var captured *captureConn
+	if opt.Capture != nil {
+		captured = opt.Capture.wrap(conn)
+		conn = captured
+	}
 	if opt.Debug {
 		if opt.Debugf != nil {
 			debugf = opt.Debugf
@@ -64,35 +66,55 @@ func dial(ctx context.Context, addr string, num int, opt *Options) (*connect, er
 			debugf = log.New(os.Stdout, fmt.Sprintf("[clickhouse][conn=%d][%s]", num, conn.RemoteAddr()), 0).Printf
 		}
 	}
-	compression := CompressionNone
+	compression := Compression{Method: CompressionNone}
 	if opt.Compression != nil {
 		switch opt.Compression.Method {
-		case CompressionLZ4, CompressionZSTD, CompressionNone:
-			compression = opt.Compression.Method
+		case CompressionLZ4, CompressionLZ4HC, CompressionZSTD, CompressionNone:
+			compression = *opt.Compression
 		default:
 			return nil, fmt.Errorf("unsupported compression method for native protocol")
 		}
 	}
+	local := isLocalAddr(conn.RemoteAddr())
+	if local && compression.SkipLocal && compression.Method != CompressionNone {
+		debugf("[dial] compression %q disabled for local connection", compression.Method)
+		compression.Method = CompressionNone
+	}
 
+	if revision := opt.ProtocolRevision; revision != 0 && revision < proto.DBMS_MIN_REVISION_WITH_CLIENT_INFO {
+		return nil, ErrUnsupportedServerRevision
+	}
 	var (
 		connect = &connect{
 			id:                   num,
+			addr:                 addr,
+			local:                local,
 			opt:                  opt,
 			conn:                 conn,
+			capture:              captured,
 			debugf:               debugf,
 			buffer:               new(chproto.Buffer),
 			reader:               chproto.NewReader(conn),
-			revision:             ClientTCPProtocolVersion,
+			revision:             opt.protocolRevision(),
 			structMap:            &structMap{},
 			compression:          compression,
+			blockCompression:     compression,
 			connectedAt:          time.Now(),
-			compressor:           compress.NewWriter(),
+			compressor:           newBlockCompressor(),
 			readTimeout:          opt.ReadTimeout,
 			blockBufferSize:      opt.BlockBufferSize,
 			maxCompressionBuffer: opt.MaxCompressionBuffer,
+			session:              newSessionSettings(opt),
 		}
 	)
-	if err := connect.handshake(opt.Auth.Database, opt.Auth.Username, opt.Auth.Password); err != nil {
+	connect.decompressed = chproto.NewReader(&blockDecompressor{
+		reader:       connect.reader,
+		skipChecksum: opt.SkipChecksumVerification,
+		onCorruption: opt.OnCorruptedBlock,
+	})
+	connect.bandwidth = newBandwidthLimiter(opt.BandwidthLimit)
+	connect.limiter = connect.bandwidth
+	if err := connect.handshake(opt.Auth.Database, username, password); err != nil {
 		return nil, err
 	}
 	if connect.revision >= proto.DBMS_MIN_PROTOCOL_VERSION_WITH_ADDENDUM {
@@ -113,22 +135,35 @@ func dial(ctx context.Context, addr string, num int, opt *Options) (*connect, er
 // https://github.com/ClickHouse/ClickHouse/blob/master/src/Client/Connection.cpp
 type connect struct {
 	id                   int
+	addr                 string
+	local                bool // connected to a loopback address or a unix socket
 	opt                  *Options
 	conn                 net.Conn
+	capture              *captureConn // records the packets, with Options.Capture
 	debugf               func(format string, v ...interface{})
 	server               ServerVersion
 	closed               bool
+	drained              bool
 	buffer               *chproto.Buffer
 	reader               *chproto.Reader
+	decompressed         *chproto.Reader // the data of the compressed blocks read from reader
+	written              uint64          // the bytes written to conn
+	database             string          // the database switched to by a query, see WithDatabase
+	defaultDatabase      string          // the database of the user, when Auth.Database is empty
 	released             bool
 	revision             uint64
 	structMap            *structMap
-	compression          CompressionMethod
+	compression          Compression
+	blockCompression     Compression // compression of the data blocks of the current query
+	bandwidth            *bandwidthLimiter
+	limiter              *bandwidthLimiter // bandwidth limit of the current query
 	connectedAt          time.Time
-	compressor           *compress.Writer
+	compressor           *blockCompressor
 	readTimeout          time.Duration
 	blockBufferSize      uint8
 	maxCompressionBuffer int
+	// session are the settings of the SET statements of the connection, if tracked by the client
+	session *sessionSettings
 
 	rwLock sync.Mutex
 }
@@ -141,6 +176,12 @@ func (c *connect) settings(querySettings Settings) []proto.Setting {
 			Value: v,
 		})
 	}
+	c.session.each(func(k string, v interface{}) {
+		settings = append(settings, proto.Setting{
+			Key:   k,
+			Value: v,
+		})
+	})
 	for k, v := range querySettings {
 		settings = append(settings, proto.Setting{
 			Key:   k,
@@ -150,6 +191,15 @@ func (c *connect) settings(querySettings Settings) []proto.Setting {
 	return settings
 }
 
+// resetSession drops the settings of the SET statements of the connection, unless they are replayed.
+func (c *connect) resetSession() {
+	c.session.reset()
+}
+
+func (c *connect) serverVersion() (*ServerVersion, error) {
+	return &c.server, nil
+}
+
 func (c *connect) isBad() bool {
 	if c.isClosed() {
 		return true
@@ -205,6 +255,7 @@ func (c *connect) close() error {
 	c.closed = true
 	c.buffer = nil
 	c.reader = nil
+	c.decompressed = nil
 	if err := c.conn.Close(); err != nil {
 		c.debugf("[close] %s", err)
 	}
@@ -226,13 +277,13 @@ func (c *connect) exception() error {
 		return err
 	}
 	c.debugf("[exception] %s", e.Error())
-	return &e
+	return memoryLimitError(&e)
 }
 
 func (c *connect) compressBuffer(start int) error {
-	if c.compression != CompressionNone && len(c.buffer.Buf) > 0 {
+	if c.blockCompression.Method != CompressionNone && len(c.buffer.Buf) > 0 {
 		data := c.buffer.Buf[start:]
-		if err := c.compressor.Compress(compress.Method(c.compression), data); err != nil {
+		if err := c.compressor.Compress(c.blockCompression, data); err != nil {
 			return errors.Wrap(err, "compress")
 		}
 		c.buffer.Buf = append(c.buffer.Buf[:start], c.compressor.Data...)
@@ -241,7 +292,7 @@ func (c *connect) compressBuffer(start int) error {
 }
 
 func (c *connect) sendData(block *proto.Block, name string) error {
-	c.debugf("[send data] compression=%q", c.compression)
+	c.debugf("[send data] compression=%q", c.blockCompression.Method)
 	c.buffer.PutByte(proto.ClientData)
 	c.buffer.PutString(name)
 
@@ -291,33 +342,38 @@ func (c *connect) readData(ctx context.Context, packet byte, compressible bool)
 		c.debugf("[read data] str error: %v", err)
 		return nil, err
 	}
-	if compressible && c.compression != CompressionNone {
-		c.reader.EnableCompression()
-		defer c.reader.DisableCompression()
-	}
-
-	opts := queryOptions(ctx)
-	location := c.server.Timezone
-	if opts.userLocation != nil {
-		location = opts.userLocation
+	reader := c.reader
+	if compressible && c.blockCompression.Method != CompressionNone {
+		reader = c.decompressed
 	}
 
-	block := proto.Block{Timezone: location}
-	if err := block.Decode(c.reader, c.revision); err != nil {
+	block := c.newBlock(ctx)
+	if err := block.Decode(reader, c.revision); err != nil {
 		c.debugf("[read data] decode error: %v", err)
 		return nil, err
 	}
 	block.Packet = packet
-	c.debugf("[read data] compression=%q. block: columns=%d, rows=%d", c.compression, len(block.Columns), block.Rows())
+	c.debugf("[read data] compression=%q. block: columns=%d, rows=%d", c.blockCompression.Method, len(block.Columns), block.Rows())
 	return &block, nil
 }
 
+// newBlock returns an empty block to decode the data of the query of ctx into.
+func (c *connect) newBlock(ctx context.Context) proto.Block {
+	location := c.server.Timezone
+	if opts := queryOptions(ctx); opts.userLocation != nil {
+		location = opts.userLocation
+	}
+	return proto.Block{Timezone: location, Conversion: c.opt.ConversionPolicy, FixedString: c.opt.FixedString, BoolMapping: c.opt.BoolMapping}
+}
+
 func (c *connect) flush() error {
 	if len(c.buffer.Buf) == 0 {
 		// Nothing to flush.
 		return nil
 	}
-	n, err := c.conn.Write(c.buffer.Buf)
+	c.capture.send(c.buffer.Buf)
+	n, err := c.write(c.buffer.Buf)
+	c.written += uint64(n)
 	if err != nil {
 		return errors.Wrap(err, "write")
 	}
@@ -328,3 +384,32 @@ func (c *connect) flush() error {
 	c.buffer.Reset()
 	return nil
 }
+
+// readPacket reads the type of the next packet from the server.
+func (c *connect) readPacket() (byte, error) {
+	packet, err := c.reader.ReadByte()
+	if err == nil {
+		c.capture.receive(packet)
+	}
+	return packet, err
+}
+
+func (c *connect) write(buf []byte) (int, error) {
+	if c.limiter == nil {
+		return c.conn.Write(buf)
+	}
+	var written int
+	for len(buf) > 0 {
+		size := c.limiter.chunk()
+		if size > len(buf) {
+			size = len(buf)
+		}
+		c.limiter.wait(size)
+		n, err := c.conn.Write(buf[:size])
+		if written += n; err != nil {
+			return written, err
+		}
+		buf = buf[size:]
+	}
+	return written, nil
+}
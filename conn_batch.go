@@ -34,7 +34,7 @@ import (
 var splitInsertRe = regexp.MustCompile(`(?i)\sVALUES\s*\(`)
 var columnMatch = regexp.MustCompile(`.*\((?P<Columns>.+)\)$`)
 
-func (c *connect) prepareBatch(ctx context.Context, query string, release func(*connect, error)) (driver.Batch, error) {
+func (c *connect) prepareBatch(ctx context.Context, query string, release func(*connect, error), acquire func(context.Context) (*connect, error)) (driver.Batch, error) {
 	//defer func() {
 	//	if err := recover(); err != nil {
 	//		fmt.Printf("panic occurred on %d:\n", c.num)
@@ -53,6 +53,10 @@ func (c *connect) prepareBatch(ctx context.Context, query string, release func(*
 		query += " VALUES"
 	}
 	options := queryOptions(ctx)
+	nulls := batchNullStrategy(c.opt.Settings, &options)
+	if options.nullStrategy == NullAsDefault {
+		options.settings = withNullAsDefault(options.settings)
+	}
 	if deadline, ok := ctx.Deadline(); ok {
 		c.conn.SetDeadline(deadline)
 		defer c.conn.SetDeadline(time.Time{})
@@ -73,25 +77,61 @@ func (c *connect) prepareBatch(ctx context.Context, query string, release func(*
 	if err = block.SortColumns(columns); err != nil {
 		return nil, err
 	}
-	return &batch{
+	b := &batch{
 		ctx:         ctx,
 		conn:        c,
+		query:       query,
+		nulls:       nulls,
+		converters:  columnConverters(options.columnConverters, block.ColumnsNames()),
 		block:       block,
 		released:    false,
 		connRelease: release,
+		connAcquire: acquire,
 		onProcess:   onProcess,
-	}, nil
+		throttle:    c.opt.InsertThrottle,
+	}
+	b.observe()
+	return b, nil
+}
+
+// observe hooks the batch into the packets received for the current insert.
+func (b *batch) observe() {
+	b.observeDelays()
+	b.observeProgress()
+}
+
+// observeDelays records whether the server delayed the insert for the InsertThrottle.
+func (b *batch) observeDelays() {
+	if b.throttle == nil {
+		return
+	}
+	profileEvents := b.onProcess.profileEvents
+	b.onProcess.profileEvents = func(events []ProfileEvent) {
+		if delayedInsert(events) {
+			b.delayed = true
+		}
+		profileEvents(events)
+	}
 }
 
 type batch struct {
 	err         error
 	ctx         context.Context
 	conn        *connect
+	query       string
 	sent        bool
+	sendErr     error
 	released    bool
 	block       *proto.Block
 	connRelease func(*connect, error)
+	connAcquire func(context.Context) (*connect, error)
 	onProcess   *onProcess
+	throttle    *InsertThrottle
+	delayed     bool // the server delayed the insert, see InsertThrottle
+	pending     bool // the INSERT query is sent with the data of the next Flush or Send, see Reset
+	progress    InsertProgress
+	nulls       NullStrategy // handling of nil for non-nullable columns, see NullStrategy
+	converters  []ColumnConverter
 }
 
 func (b *batch) release(err error) {
@@ -119,7 +159,16 @@ func (b *batch) Append(v ...interface{}) error {
 	if b.err != nil {
 		return b.err
 	}
-	if err := b.block.Append(v...); err != nil {
+	v, err := convertRow("batch.Append", b.converters, b.block.ColumnsNames(), v)
+	if err != nil {
+		return err
+	}
+	return b.appendRow(v)
+}
+
+// appendRow appends the row v, to which the column converters were applied.
+func (b *batch) appendRow(v []interface{}) error {
+	if err := b.appendBlock(b.block, v); err != nil {
 		b.err = errors.Wrap(ErrBatchInvalid, err.Error())
 		b.release(err)
 		return err
@@ -127,11 +176,20 @@ func (b *batch) Append(v ...interface{}) error {
 	return nil
 }
 
+// appendBlock appends the row v to block, the block of the batch or one with the same columns, applying
+// the null strategy of the batch.
+func (b *batch) appendBlock(block *proto.Block, v []interface{}) error {
+	v = applyNullStrategy(block, b.nulls, v, func(i int) {
+		b.conn.debugf("[batch] column %d promoted to Nullable to send NULL as default", i)
+	})
+	return block.Append(v...)
+}
+
 func (b *batch) AppendStruct(v interface{}) error {
 	if b.err != nil {
 		return b.err
 	}
-	values, err := b.conn.structMap.Map("AppendStruct", b.block.ColumnsNames(), v, false)
+	values, err := b.conn.structMap.MapColumns("AppendStruct", b.block.ColumnsNames(), v, false, queryOptions(b.ctx).columnMapping)
 	if err != nil {
 		return err
 	}
@@ -152,9 +210,15 @@ func (b *batch) Column(idx int) driver.BatchColumn {
 			},
 		}
 	}
+	var convert ColumnConverter
+	if b.converters != nil {
+		convert = b.converters[idx]
+	}
 	return &batchColumn{
-		batch:  b,
-		column: b.block.Columns[idx],
+		batch:      b,
+		column:     b.block.Columns[idx],
+		conversion: b.block.Conversion,
+		convert:    convert,
 		release: func(err error) {
 			b.err = err
 			b.release(err)
@@ -165,6 +229,7 @@ func (b *batch) Column(idx int) driver.BatchColumn {
 func (b *batch) Send() (err error) {
 	defer func() {
 		b.sent = true
+		b.sendErr = err
 		b.release(err)
 	}()
 	if b.sent {
@@ -173,8 +238,111 @@ func (b *batch) Send() (err error) {
 	if b.err != nil {
 		return b.err
 	}
+	if err = b.begin(); err != nil {
+		return err
+	}
+	err = b.send()
+	observeWrite(b.ctx, b.query, err)
+	return err
+}
+
+// Reset clears the appended rows so that the batch can be reused for another insert into the same table.
+// The resolved columns and the query options of ctx passed to PrepareBatch are kept: after a Send the
+// INSERT is sent along with the data of the next Flush or Send, instead of waiting for the server to
+// describe the table first. Rows flushed before Reset are already on the wire and are not discarded.
+func (b *batch) Reset() error {
+	if !b.sent && !b.released {
+		b.block.Reset()
+		return nil
+	}
+	if !b.sent && b.connAcquire == nil {
+		// the connection can't be replaced and may still be in the middle of the insert
+		return ErrBatchRetryUnsupported
+	}
+	b.block.Reset()
+	b.sent, b.sendErr, b.err, b.pending = false, nil, nil, true
+	return nil
+}
+
+// begin sends the INSERT query of a Reset batch. The header block the server replies with is
+// consumed by process after the data has been sent, which saves a round trip per insert.
+func (b *batch) begin() error {
+	if !b.pending {
+		return nil
+	}
+	conn := b.conn
+	if b.connAcquire != nil {
+		var err error
+		if conn, err = b.connAcquire(b.ctx); err != nil {
+			return err
+		}
+		conn.debugf("[batch reset] acquired connection [%d]", conn.id)
+	}
+	b.conn, b.released = conn, false
+	options := queryOptions(b.ctx)
+	if b.nulls == NullAsDefault {
+		options.settings = withNullAsDefault(options.settings)
+	}
+	if err := conn.sendQuery(b.query, &options); err != nil {
+		return err
+	}
+	b.onProcess, b.delayed, b.pending = options.onProcess(), false, false
+	b.observe()
+	return nil
+}
+
+// Retry re-sends the data of a batch whose Send failed on a new connection.
+// Only rows appended since the last Flush are kept - flushed blocks are already on the wire and are not retained.
+// Settings such as insert_deduplication_token can be passed via ctx to make the retry idempotent.
+func (b *batch) Retry(ctx context.Context) (err error) {
+	if !b.sent {
+		return ErrBatchNotSent
+	}
+	if b.sendErr == nil {
+		return ErrBatchAlreadySent
+	}
+	if b.err != nil {
+		return b.err
+	}
+	if b.connAcquire == nil {
+		return ErrBatchRetryUnsupported
+	}
+	conn, err := b.connAcquire(ctx)
+	if err != nil {
+		return err
+	}
+	conn.debugf("[batch retry] acquired connection [%d]", conn.id)
+	defer func() {
+		b.sendErr = err
+		b.release(err)
+	}()
+	b.ctx, b.conn, b.released = ctx, conn, false
+	options := queryOptions(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.conn.SetDeadline(deadline)
+		defer conn.conn.SetDeadline(time.Time{})
+	}
+	if err = conn.sendQuery(b.query, &options); err != nil {
+		return err
+	}
+	b.onProcess, b.delayed, b.pending = options.onProcess(), false, false
+	b.observe()
+	if _, err = conn.firstBlock(ctx, b.onProcess); err != nil {
+		return err
+	}
+	err = b.send()
+	observeWrite(b.ctx, b.query, err)
+	return err
+}
+
+func (b *batch) send() (err error) {
+	if b.throttle != nil {
+		defer func() {
+			b.throttle.observe(insertTable(b.query), backpressure(b.delayed, err))
+		}()
+	}
 	if b.block.Rows() != 0 {
-		if err = b.conn.sendData(b.block, ""); err != nil {
+		if err = b.sendBlock(b.block); err != nil {
 			return err
 		}
 	}
@@ -184,6 +352,8 @@ func (b *batch) Send() (err error) {
 	if err = b.conn.process(b.ctx, b.onProcess); err != nil {
 		return err
 	}
+	b.progress.Done = true
+	b.reportProgress()
 	return nil
 }
 
@@ -194,20 +364,26 @@ func (b *batch) Flush() error {
 	if b.err != nil {
 		return b.err
 	}
-	if b.block.Rows() != 0 {
-		if err := b.conn.sendData(b.block, ""); err != nil {
-			return err
-		}
+	if b.block.Rows() == 0 {
+		return nil
+	}
+	if err := b.begin(); err != nil {
+		return err
+	}
+	if err := b.sendBlock(b.block); err != nil {
+		return err
 	}
 	b.block.Reset()
 	return nil
 }
 
 type batchColumn struct {
-	err     error
-	batch   driver.Batch
-	column  column.Interface
-	release func(error)
+	err        error
+	batch      driver.Batch
+	column     column.Interface
+	conversion column.ConversionPolicy
+	convert    ColumnConverter
+	release    func(error)
 }
 
 func (b *batchColumn) Append(v interface{}) (err error) {
@@ -226,21 +402,33 @@ func (b *batchColumn) Append(v interface{}) (err error) {
 }
 
 func (b *batchColumn) AppendRow(v interface{}) (err error) {
-        if b.batch.IsSent() {
-                return ErrBatchAlreadySent
-        }
-        if b.err != nil {
-                b.release(b.err)
-                return b.err
-        }
-        if  err = b.column.AppendRow(v); err != nil {
-                b.release(err)
-                return err
-        }
-        return nil
+	if b.batch.IsSent() {
+		return ErrBatchAlreadySent
+	}
+	if b.err != nil {
+		b.release(b.err)
+		return b.err
+	}
+	if b.convert != nil {
+		if v, err = b.convert(v); err != nil {
+			return &OpError{Op: "AppendRow", ColumnName: b.column.Name(), Err: err}
+		}
+	}
+	row := b.column.Rows()
+	converted, err := column.ConvertAppend(b.column, v, b.conversion)
+	if err == nil {
+		err = b.column.AppendRow(converted)
+	}
+	if err != nil {
+		err = column.Locate(err, b.column, row, v)
+		b.release(err)
+		return err
+	}
+	return nil
 }
 
 var (
-	_ (driver.Batch)       = (*batch)(nil)
-	_ (driver.BatchColumn) = (*batchColumn)(nil)
+	_ (driver.RetryableBatch)  = (*batch)(nil)
+	_ (driver.ResettableBatch) = (*batch)(nil)
+	_ (driver.BatchColumn)     = (*batchColumn)(nil)
 )
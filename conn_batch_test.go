@@ -0,0 +1,107 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"testing"
+
+	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchReset(t *testing.T) {
+	newBatch := func(acquire func(context.Context) (*connect, error)) *batch {
+		block := &proto.Block{}
+		require.NoError(t, block.AddColumn("id", "UInt64"))
+		require.NoError(t, block.Append(uint64(1)))
+		return &batch{
+			block:       block,
+			connRelease: func(*connect, error) {},
+			connAcquire: acquire,
+		}
+	}
+	acquire := func(context.Context) (*connect, error) { return nil, nil }
+	t.Run("unsent", func(t *testing.T) {
+		b := newBatch(acquire)
+		require.NoError(t, b.Reset())
+		assert.Equal(t, 0, b.block.Rows())
+		assert.False(t, b.pending)
+	})
+	t.Run("sent", func(t *testing.T) {
+		b := newBatch(acquire)
+		b.sent, b.released, b.sendErr = true, true, assert.AnError
+		require.NoError(t, b.Reset())
+		assert.False(t, b.IsSent())
+		assert.NoError(t, b.sendErr)
+		assert.True(t, b.pending)
+		assert.Equal(t, 0, b.block.Rows())
+		assert.Equal(t, []string{"id"}, b.block.ColumnsNames())
+		require.NoError(t, b.Append(uint64(2)))
+		assert.Equal(t, 1, b.block.Rows())
+	})
+	t.Run("released without acquire", func(t *testing.T) {
+		b := newBatch(nil)
+		b.released = true
+		assert.ErrorIs(t, b.Reset(), ErrBatchRetryUnsupported)
+	})
+}
+
+func TestBatchInsertProgress(t *testing.T) {
+	var reported []InsertProgress
+	ctx := Context(context.Background(), WithInsertProgress(func(p *InsertProgress) {
+		reported = append(reported, *p)
+	}))
+	options := queryOptions(ctx)
+	block := &proto.Block{}
+	require.NoError(t, block.AddColumn("id", "UInt64"))
+	b := &batch{
+		ctx: ctx,
+		conn: &connect{
+			conn:                 &replayConn{},
+			buffer:               new(chproto.Buffer),
+			debugf:               func(string, ...interface{}) {},
+			revision:             proto.DBMS_TCP_PROTOCOL_VERSION,
+			compressor:           newBlockCompressor(),
+			maxCompressionBuffer: 1 << 20,
+		},
+		block:     block,
+		onProcess: options.onProcess(),
+	}
+	b.observe()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, b.Append(uint64(i)))
+	}
+	require.NoError(t, b.Flush())
+	require.NoError(t, b.Append(uint64(3)))
+	require.NoError(t, b.Flush())
+	b.onProcess.progress(&Progress{WroteRows: 4, WroteBytes: 32})
+
+	require.Len(t, reported, 3)
+	assert.Equal(t, 1, reported[0].Blocks)
+	assert.Equal(t, uint64(3), reported[0].Rows)
+	assert.NotZero(t, reported[0].Bytes)
+	assert.Equal(t, 2, reported[1].Blocks)
+	assert.Equal(t, uint64(4), reported[1].Rows)
+	assert.Greater(t, reported[1].Bytes, reported[0].Bytes)
+	assert.Equal(t, uint64(4), reported[2].WrittenRows)
+	assert.Equal(t, uint64(32), reported[2].WrittenBytes)
+	assert.False(t, reported[2].Done)
+}
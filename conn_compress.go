@@ -0,0 +1,120 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ClickHouse/ch-go/compress"
+	"github.com/go-faster/city"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/pkg/errors"
+)
+
+// native block compression header, see ch-go/compress
+const (
+	compressChecksumSize = 16
+	compressHeaderSize   = compressChecksumSize + 1 + 4 + 4
+)
+
+// blockCompressor compresses native protocol blocks. Plain LZ4 and default level ZSTD are delegated to
+// the ch-go writer, LZ4HC and leveled ZSTD are encoded here with the same framing.
+type blockCompressor struct {
+	Data []byte
+
+	writer *compress.Writer
+	lz4hc  lz4.CompressorHC
+	zstd   map[int]*zstd.Encoder
+}
+
+func newBlockCompressor() *blockCompressor {
+	return &blockCompressor{
+		writer: compress.NewWriter(),
+		zstd:   make(map[int]*zstd.Encoder),
+	}
+}
+
+func (w *blockCompressor) Compress(c Compression, buf []byte) error {
+	switch {
+	case c.Method == CompressionLZ4HC:
+		return w.compressLZ4HC(c.Level, buf)
+	case c.Method == CompressionZSTD && c.Level != 0:
+		return w.compressZSTD(c.Level, buf)
+	}
+	if err := w.writer.Compress(compress.Method(c.Method), buf); err != nil {
+		return err
+	}
+	w.Data = w.writer.Data
+	return nil
+}
+
+func (w *blockCompressor) compressLZ4HC(level int, buf []byte) error {
+	// ClickHouse levels go up to 12, lz4 search depth is capped at Level9 which is also the default
+	if level <= 0 || level > 9 {
+		level = 9
+	}
+	w.lz4hc.Level = lz4.CompressionLevel(1 << (8 + level))
+	w.Data = append(w.Data[:0], make([]byte, compressHeaderSize+lz4.CompressBlockBound(len(buf)))...)
+	n, err := w.lz4hc.CompressBlock(buf, w.Data[compressHeaderSize:])
+	if err != nil {
+		return errors.Wrap(err, "lz4hc")
+	}
+	// LZ4HC output is a regular LZ4 block
+	w.frame(compress.LZ4, n, len(buf))
+	return nil
+}
+
+func (w *blockCompressor) compressZSTD(level int, buf []byte) error {
+	encoder, ok := w.zstd[level]
+	if !ok {
+		var err error
+		if encoder, err = zstd.NewWriter(nil,
+			zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
+			zstd.WithEncoderConcurrency(1),
+			zstd.WithLowerEncoderMem(true),
+		); err != nil {
+			return errors.Wrap(err, "zstd")
+		}
+		w.zstd[level] = encoder
+	}
+	w.Data = encoder.EncodeAll(buf, append(w.Data[:0], make([]byte, compressHeaderSize)...))
+	w.frame(compress.ZSTD, len(w.Data)-compressHeaderSize, len(buf))
+	return nil
+}
+
+// frame writes the header of a block of n compressed bytes following it in Data.
+func (w *blockCompressor) frame(method compress.Method, n, size int) {
+	w.Data = w.Data[:compressHeaderSize+n]
+	w.Data[compressChecksumSize] = byte(method)
+	binary.LittleEndian.PutUint32(w.Data[compressChecksumSize+1:], uint32(n+compressHeaderSize-compressChecksumSize))
+	binary.LittleEndian.PutUint32(w.Data[compressChecksumSize+5:], uint32(size))
+	h := city.CH128(w.Data[compressChecksumSize:])
+	binary.LittleEndian.PutUint64(w.Data[0:8], h.Low)
+	binary.LittleEndian.PutUint64(w.Data[8:16], h.High)
+}
+
+// nativeCompression validates a compression method for the native protocol.
+func nativeCompression(c Compression) error {
+	switch c.Method {
+	case CompressionNone, CompressionLZ4, CompressionLZ4HC, CompressionZSTD:
+		return nil
+	}
+	return fmt.Errorf("unsupported compression method %s for native protocol", c.Method)
+}
@@ -0,0 +1,60 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/ClickHouse/ch-go/compress"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockCompressor(t *testing.T) {
+	data := bytes.Repeat([]byte("clickhouse block compression "), 1000)
+	for _, c := range []Compression{
+		{Method: CompressionNone},
+		{Method: CompressionLZ4},
+		{Method: CompressionLZ4HC},
+		{Method: CompressionLZ4HC, Level: 12},
+		{Method: CompressionZSTD},
+		{Method: CompressionZSTD, Level: 19},
+	} {
+		t.Run(c.Method.String(), func(t *testing.T) {
+			w := newBlockCompressor()
+			require.NoError(t, w.Compress(c, data))
+			if c.Method != CompressionNone {
+				assert.Less(t, len(w.Data), len(data))
+			}
+			out, err := io.ReadAll(io.LimitReader(compress.NewReader(bytes.NewReader(w.Data)), int64(len(data))))
+			require.NoError(t, err)
+			assert.Equal(t, data, out)
+		})
+	}
+}
+
+func TestWithCompression(t *testing.T) {
+	opts := queryOptions(Context(context.Background(), WithCompression(CompressionLZ4HC, 10)))
+	require.NotNil(t, opts.compression)
+	assert.Equal(t, Compression{Method: CompressionLZ4HC, Level: 10}, *opts.compression)
+	var o QueryOptions
+	assert.Error(t, WithCompression(CompressionGZIP, 0)(&o))
+}
@@ -0,0 +1,183 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/ClickHouse/ch-go/compress"
+	"github.com/go-faster/city"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/pkg/errors"
+)
+
+// bounds of the sizes of a compressed block, as in ch-go/compress
+const (
+	maxCompressedBlockSize   = 1024 * 1024 * 128
+	maxDecompressedBlockSize = 1024 * 1024 * 128
+)
+
+// CorruptedBlockError is a compressed block of the native protocol whose checksum does not match its data, or
+// which fails to decompress. The connection is closed, as the rest of the stream can't be trusted.
+type CorruptedBlockError struct {
+	// Offset is the position of the block among the compressed bytes read by the connection.
+	Offset           int64
+	Method           CompressionMethod
+	CompressedSize   int
+	DecompressedSize int
+	// Checksum is the CityHash128 sent with the block, Actual the one of the received data, as hex.
+	Checksum string
+	Actual   string
+	// Err is the decompression error, nil for a checksum mismatch.
+	Err error
+}
+
+func (e *CorruptedBlockError) Error() string {
+	problem := fmt.Sprintf("checksum mismatch: got %s, expected %s", e.Actual, e.Checksum)
+	if e.Err != nil {
+		problem = e.Err.Error()
+	}
+	return fmt.Sprintf("clickhouse: corrupted compressed block at offset %d (method %s, compressed size %d, decompressed size %d): %s",
+		e.Offset, e.Method, e.CompressedSize, e.DecompressedSize, problem)
+}
+
+func (e *CorruptedBlockError) Unwrap() error {
+	return e.Err
+}
+
+// blockDecompressor reads the data of the compressed blocks of the native protocol from reader, verifying
+// their checksums unless skipChecksum, see Options.SkipChecksumVerification.
+type blockDecompressor struct {
+	reader       io.Reader
+	skipChecksum bool
+	onCorruption func(err *CorruptedBlockError, block []byte)
+	offset       int64
+	header       [compressHeaderSize]byte
+	raw          []byte
+	data         []byte
+	pos          int
+	zstd         *zstd.Decoder
+}
+
+func (d *blockDecompressor) Read(p []byte) (int, error) {
+	if d.pos >= len(d.data) {
+		if err := d.readBlock(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.data[d.pos:])
+	d.pos += n
+	return n, nil
+}
+
+func (d *blockDecompressor) readBlock() error {
+	d.pos = 0
+	if _, err := io.ReadFull(d.reader, d.header[:]); err != nil {
+		return errors.Wrap(err, "read compressed block header")
+	}
+	var (
+		method   = CompressionMethod(d.header[compressChecksumSize])
+		rawSize  = int(binary.LittleEndian.Uint32(d.header[compressChecksumSize+1:])) - (compressHeaderSize - compressChecksumSize)
+		dataSize = int(binary.LittleEndian.Uint32(d.header[compressChecksumSize+5:]))
+		offset   = d.offset
+	)
+	if rawSize < 0 || rawSize > maxCompressedBlockSize || dataSize < 0 || dataSize > maxDecompressedBlockSize {
+		// the sizes can't be trusted to read the rest of the block
+		return d.corrupted(&CorruptedBlockError{
+			Offset:           offset,
+			Method:           method,
+			CompressedSize:   rawSize,
+			DecompressedSize: dataSize,
+			Err:              errors.New("invalid block size"),
+		})
+	}
+	d.raw = append(append(d.raw[:0], d.header[:]...), make([]byte, rawSize)...)
+	if _, err := io.ReadFull(d.reader, d.raw[compressHeaderSize:]); err != nil {
+		return errors.Wrap(err, "read compressed block")
+	}
+	d.offset += int64(len(d.raw))
+	checksum := city.U128{
+		Low:  binary.LittleEndian.Uint64(d.raw[0:8]),
+		High: binary.LittleEndian.Uint64(d.raw[8:16]),
+	}
+	corrupted := func(err error) error {
+		return d.corrupted(&CorruptedBlockError{
+			Offset:           offset,
+			Method:           method,
+			CompressedSize:   rawSize,
+			DecompressedSize: dataSize,
+			Checksum:         compress.FormatU128(checksum),
+			Actual:           compress.FormatU128(city.CH128(d.raw[compressChecksumSize:])),
+			Err:              err,
+		})
+	}
+	if !d.skipChecksum && city.CH128(d.raw[compressChecksumSize:]) != checksum {
+		return corrupted(nil)
+	}
+	d.data = append(d.data[:0], make([]byte, dataSize)...)
+	switch method {
+	case CompressionLZ4:
+		n, err := lz4.UncompressBlock(d.raw[compressHeaderSize:], d.data)
+		if err != nil {
+			return corrupted(errors.Wrap(err, "lz4"))
+		}
+		if n != dataSize {
+			return corrupted(fmt.Errorf("lz4: decompressed %d bytes", n))
+		}
+	case CompressionZSTD:
+		if d.zstd == nil {
+			decoder, err := zstd.NewReader(nil,
+				zstd.WithDecoderConcurrency(1),
+				zstd.WithDecoderLowmem(true),
+			)
+			if err != nil {
+				return errors.Wrap(err, "zstd")
+			}
+			d.zstd = decoder
+		}
+		data, err := d.zstd.DecodeAll(d.raw[compressHeaderSize:], d.data[:0])
+		if err != nil {
+			return corrupted(errors.Wrap(err, "zstd"))
+		}
+		if len(data) != dataSize {
+			return corrupted(fmt.Errorf("zstd: decompressed %d bytes", len(data)))
+		}
+		d.data = data
+	case CompressionNone:
+		copy(d.data, d.raw[compressHeaderSize:])
+	default:
+		return corrupted(fmt.Errorf("unknown compression method 0x%02x", byte(method)))
+	}
+	return nil
+}
+
+// corrupted reports err to the hook of Options.OnCorruptedBlock with the block as received.
+func (d *blockDecompressor) corrupted(err *CorruptedBlockError) error {
+	if d.onCorruption != nil {
+		block := d.raw
+		if err.Checksum == "" {
+			// the block could not be read past its header
+			block = d.header[:]
+		}
+		d.onCorruption(err, append([]byte(nil), block...))
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockDecompressor(t *testing.T) {
+	payload := bytes.Repeat([]byte("clickhouse"), 100)
+	frame := func(method CompressionMethod) []byte {
+		compressor := newBlockCompressor()
+		require.NoError(t, compressor.Compress(Compression{Method: method}, payload))
+		return append([]byte(nil), compressor.Data...)
+	}
+	t.Run("valid", func(t *testing.T) {
+		for _, method := range []CompressionMethod{CompressionLZ4, CompressionZSTD, CompressionLZ4HC} {
+			stream := append(frame(method), frame(method)...)
+			data, err := io.ReadAll(&blockDecompressor{reader: bytes.NewReader(stream)})
+			assert.ErrorIs(t, err, io.EOF)
+			assert.Equal(t, append(payload, payload...), data, method.String())
+		}
+	})
+	t.Run("checksum mismatch", func(t *testing.T) {
+		first, second := frame(CompressionLZ4), frame(CompressionLZ4)
+		second[compressHeaderSize+1] ^= 0xff
+		var (
+			reported *CorruptedBlockError
+			block    []byte
+		)
+		_, err := io.ReadAll(&blockDecompressor{
+			reader: bytes.NewReader(append(first, second...)),
+			onCorruption: func(err *CorruptedBlockError, b []byte) {
+				reported, block = err, b
+			},
+		})
+		var corrupted *CorruptedBlockError
+		require.ErrorAs(t, err, &corrupted)
+		assert.Same(t, corrupted, reported)
+		assert.Equal(t, second, block)
+		assert.Equal(t, int64(len(first)), corrupted.Offset)
+		assert.Equal(t, CompressionLZ4, corrupted.Method)
+		assert.Equal(t, len(second)-compressHeaderSize, corrupted.CompressedSize)
+		assert.Equal(t, len(payload), corrupted.DecompressedSize)
+		assert.NotEqual(t, corrupted.Checksum, corrupted.Actual)
+		assert.Nil(t, corrupted.Err)
+		assert.Contains(t, err.Error(), "checksum mismatch")
+	})
+	t.Run("skip checksum", func(t *testing.T) {
+		stream := frame(CompressionZSTD)
+		stream[0] ^= 0xff
+		data := make([]byte, len(payload))
+		_, err := io.ReadFull(&blockDecompressor{reader: bytes.NewReader(stream), skipChecksum: true}, data)
+		require.NoError(t, err)
+		assert.Equal(t, payload, data)
+	})
+	t.Run("unknown method", func(t *testing.T) {
+		stream := frame(CompressionLZ4)
+		stream[compressChecksumSize] = 0x42
+		_, err := io.ReadAll(&blockDecompressor{reader: bytes.NewReader(stream), skipChecksum: true})
+		var corrupted *CorruptedBlockError
+		require.ErrorAs(t, err, &corrupted)
+		assert.Error(t, corrupted.Err)
+	})
+}
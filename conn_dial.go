@@ -0,0 +1,226 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"time"
+)
+
+// DialFamily selects the IP address families used by the default dialer for hostnames with both
+// IPv4 and IPv6 addresses.
+type DialFamily uint8
+
+const (
+	// DialFamilyAny races both families as in RFC 8305, the resolver order deciding which one starts.
+	DialFamilyAny DialFamily = iota
+	// DialPreferIPv4 starts with the IPv4 addresses and races the IPv6 ones after DialFallbackDelay.
+	DialPreferIPv4
+	// DialPreferIPv6 starts with the IPv6 addresses and races the IPv4 ones after DialFallbackDelay.
+	DialPreferIPv6
+	DialIPv4Only
+	DialIPv6Only
+)
+
+const defaultFallbackDelay = 300 * time.Millisecond
+
+const unixAddrPrefix = "unix://"
+
+// unixSocket returns the socket path of an address such as unix:///var/run/clickhouse-server/clickhouse.sock.
+func unixSocket(addr string) (string, bool) {
+	if !strings.HasPrefix(addr, unixAddrPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(addr, unixAddrPrefix), true
+}
+
+// isLocalAddr reports whether addr is a unix socket or a loopback address.
+func isLocalAddr(addr net.Addr) bool {
+	switch addr := addr.(type) {
+	case *net.UnixAddr:
+		return true
+	case *net.TCPAddr:
+		return addr.IP.IsLoopback()
+	}
+	return false
+}
+
+// dialNetwork opens the TCP or unix socket connection of the default dialer, with TLS if configured.
+func dialNetwork(ctx context.Context, addr string, opt *Options) (net.Conn, error) {
+	if opt.DialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opt.DialTimeout)
+		defer cancel()
+	}
+	dialer := &net.Dialer{
+		Timeout:       opt.DialTimeout,
+		FallbackDelay: opt.DialFallbackDelay,
+		Control:       opt.Socket.control,
+	}
+	var (
+		conn net.Conn
+		err  error
+	)
+	path, isUnix := unixSocket(addr)
+	switch {
+	case isUnix:
+		conn, err = dialer.DialContext(ctx, "unix", path)
+	default:
+		conn, err = dialFamily(ctx, dialer, addr, opt.DialFamily)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		if err := opt.Socket.apply(tcpConn); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if opt.TLS == nil {
+		return conn, nil
+	}
+	config := opt.TLS
+	// a unix socket has no hostname to verify, TLS.ServerName must be set
+	if len(config.ServerName) == 0 && !isUnix {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		config = config.Clone()
+		config.ServerName = host
+	}
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+func dialFamily(ctx context.Context, dialer *net.Dialer, addr string, family DialFamily) (net.Conn, error) {
+	switch family {
+	case DialIPv4Only:
+		return dialer.DialContext(ctx, "tcp4", addr)
+	case DialIPv6Only:
+		return dialer.DialContext(ctx, "tcp6", addr)
+	case DialPreferIPv4, DialPreferIPv6:
+	default:
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var primaries, fallbacks []string
+	for _, ip := range ips {
+		if isIPv4 := ip.IP.To4() != nil; isIPv4 == (family == DialPreferIPv4) {
+			primaries = append(primaries, net.JoinHostPort(ip.String(), port))
+		} else {
+			fallbacks = append(fallbacks, net.JoinHostPort(ip.String(), port))
+		}
+	}
+	delay := dialer.FallbackDelay
+	if delay == 0 {
+		delay = defaultFallbackDelay
+	}
+	return dialRace(ctx, dialer, primaries, fallbacks, delay)
+}
+
+// dialRace dials the primary addresses one after another and, once delay passed or they failed, the
+// fallback addresses in parallel, returning the first established connection. A negative delay only
+// starts the fallbacks after the primaries failed.
+func dialRace(ctx context.Context, dialer *net.Dialer, primaries, fallbacks []string, delay time.Duration) (net.Conn, error) {
+	if len(primaries) == 0 {
+		primaries, fallbacks = fallbacks, nil
+	}
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		results  = make(chan result, 2)
+		pending  int
+		firstErr error
+	)
+	start := func(addrs []string) {
+		pending++
+		go func() {
+			conn, err := dialSerial(ctx, dialer, addrs)
+			results <- result{conn: conn, err: err}
+		}()
+	}
+	start(primaries)
+	var fallback <-chan time.Time
+	if len(fallbacks) != 0 && delay >= 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		fallback = timer.C
+	}
+	for {
+		select {
+		case <-fallback:
+			fallback = nil
+			start(fallbacks)
+			fallbacks = nil
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				// close the connection of a racing dial finishing afterwards
+				go func(pending int) {
+					for ; pending > 0; pending-- {
+						if r := <-results; r.conn != nil {
+							r.conn.Close()
+						}
+					}
+				}(pending)
+				return r.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			if len(fallbacks) != 0 {
+				fallback = nil
+				start(fallbacks)
+				fallbacks = nil
+				continue
+			}
+			if pending == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
+
+func dialSerial(ctx context.Context, dialer *net.Dialer, addrs []string) (conn net.Conn, err error) {
+	for _, addr := range addrs {
+		if conn, err = dialer.DialContext(ctx, "tcp", addr); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,119 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listenLocal(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ln.Close()
+	})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestDialRaceFallback(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		fallback = listenLocal(t)
+		refused  = "127.0.0.1:1"
+	)
+	conn, err := dialRace(ctx, &net.Dialer{}, []string{refused}, []string{fallback}, time.Hour)
+	require.NoError(t, err, "a failed primary starts the fallback immediately")
+	assert.Equal(t, fallback, conn.RemoteAddr().String())
+	conn.Close()
+
+	conn, err = dialRace(ctx, &net.Dialer{}, nil, []string{fallback}, time.Hour)
+	require.NoError(t, err)
+	conn.Close()
+
+	_, err = dialRace(ctx, &net.Dialer{}, []string{refused}, nil, time.Millisecond)
+	assert.Error(t, err)
+}
+
+func TestDialRaceSlowPrimary(t *testing.T) {
+	var (
+		primary  = listenLocal(t)
+		fallback = listenLocal(t)
+		dialer   = &net.Dialer{
+			Control: func(network, address string, c syscall.RawConn) error {
+				if address == primary {
+					// a black holed address family
+					time.Sleep(500 * time.Millisecond)
+				}
+				return nil
+			},
+		}
+		start = time.Now()
+	)
+	conn, err := dialRace(context.Background(), dialer, []string{primary}, []string{fallback}, 20*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, fallback, conn.RemoteAddr().String())
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+	conn.Close()
+}
+
+func TestDialUnixSocket(t *testing.T) {
+	path, ok := unixSocket("unix:///var/run/clickhouse-server/clickhouse.sock")
+	assert.True(t, ok)
+	assert.Equal(t, "/var/run/clickhouse-server/clickhouse.sock", path)
+	_, ok = unixSocket("127.0.0.1:9000")
+	assert.False(t, ok)
+
+	path = filepath.Join(t.TempDir(), "clickhouse.sock")
+	ln, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+	conn, err := dialNetwork(context.Background(), "unix://"+path, &Options{DialTimeout: time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, "unix", conn.RemoteAddr().Network())
+	conn.Close()
+}
+
+func TestIsLocalAddr(t *testing.T) {
+	assert.True(t, isLocalAddr(&net.UnixAddr{Name: "/var/run/clickhouse-server/clickhouse.sock", Net: "unix"}))
+	assert.True(t, isLocalAddr(&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 9000}))
+	assert.True(t, isLocalAddr(&net.TCPAddr{IP: net.IPv6loopback, Port: 9000}))
+	assert.False(t, isLocalAddr(&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 9000}))
+	assert.False(t, isLocalAddr(replayAddr{}))
+}
@@ -24,6 +24,11 @@ import (
 )
 
 func (c *connect) exec(ctx context.Context, query string, args ...interface{}) error {
+	if len(args) == 0 {
+		if ok, err := c.session.set(ctx, query, func(ctx context.Context, query string) error { return c.exec(ctx, query) }); ok {
+			return err
+		}
+	}
 	var (
 		options                    = queryOptions(ctx)
 		queryParamsProtocolSupport = c.revision >= proto.DBMS_MIN_PROTOCOL_VERSION_WITH_PARAMETERS
@@ -36,12 +41,12 @@ func (c *connect) exec(ctx context.Context, query string, args ...interface{}) e
 	c.conn.SetReadDeadline(time.Now().Add(c.readTimeout))
 	defer c.conn.SetReadDeadline(time.Time{})
 	// context level deadlines override any read deadline
-	if deadline, ok := ctx.Deadline(); ok {
+	if deadline, ok := options.deadline(ctx); ok {
 		c.conn.SetDeadline(deadline)
 		defer c.conn.SetDeadline(time.Time{})
 	}
 	if err := c.sendQuery(body, &options); err != nil {
 		return err
 	}
-	return c.process(ctx, options.onProcess())
+	return options.timeoutError(ctx, c.process(ctx, options.onProcess()))
 }
@@ -0,0 +1,456 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"time"
+
+	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/timezone"
+	"github.com/ClickHouse/clickhouse-go/v2/resources"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// dialGRPC connects to the gRPC interface of the server, enabled by the grpc_port of its configuration.
+func dialGRPC(ctx context.Context, addr string, num int, opt *Options) (*grpcConnect, error) {
+	creds := insecure.NewCredentials()
+	if opt.TLS != nil {
+		creds = credentials.NewTLS(opt.TLS)
+	}
+	callOptions := []grpc.CallOption{
+		grpc.ForceCodec(grpcCodec{}),
+		grpc.MaxCallRecvMsgSize(math.MaxInt32),
+	}
+	conn := &grpcConnect{
+		database:        opt.Auth.Database,
+		auth:            opt.Auth,
+		blockBufferSize: opt.BlockBufferSize,
+		conversion:      opt.ConversionPolicy,
+		fixedString:     opt.FixedString,
+		boolMapping:     opt.BoolMapping,
+		settings:        opt.Settings,
+		session:         newSessionSettings(opt),
+		buffer:          new(chproto.Buffer),
+		decorate:        opt.DecorateContext,
+	}
+	if opt.Compression != nil {
+		switch opt.Compression.Method {
+		case CompressionNone:
+		case CompressionGZIP:
+			// the requests are compressed by grpc, the results by the server
+			callOptions = append(callOptions, grpc.UseCompressor(gzip.Name))
+			conn.compression, conn.compressionLevel = "gzip", grpcCompressionLevel(opt.Compression.Level)
+		case CompressionDeflate:
+			conn.compression, conn.compressionLevel = "deflate", grpcCompressionLevel(opt.Compression.Level)
+		default:
+			return nil, fmt.Errorf("clickhouse [grpc]: unsupported compression method %s, use gzip or deflate", opt.Compression.Method)
+		}
+	}
+	dialer := &net.Dialer{
+		KeepAlive: opt.ConnMaxLifetime,
+	}
+	target := addr
+	dialContext := func(ctx context.Context, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+	if path, ok := unixSocket(addr); ok {
+		target = "localhost"
+		dialContext = func(ctx context.Context, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", path)
+		}
+	}
+	if opt.DialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opt.DialTimeout)
+		defer cancel()
+	}
+	var err error
+	conn.conn, err = grpc.DialContext(ctx, target,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithContextDialer(dialContext),
+		grpc.WithDefaultCallOptions(callOptions...),
+		grpc.WithUserAgent(opt.ClientInfo.String()),
+		grpc.WithBlock(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if conn.location, err = conn.readTimeZone(ctx); err != nil {
+		conn.close()
+		return nil, err
+	}
+	if num == 1 {
+		version, err := conn.readVersion(ctx)
+		if err != nil {
+			conn.close()
+			return nil, err
+		}
+		if !resources.ClientMeta.IsSupportedClickHouseVersion(version) {
+			fmt.Printf("WARNING: version %v of ClickHouse is not supported by this client\n", version)
+		}
+	}
+	return conn, nil
+}
+
+// grpcCompressionLevel maps the levels 1 to 9 of gzip and deflate to the CompressionLevel of the gRPC interface.
+func grpcCompressionLevel(level int) int32 {
+	switch {
+	case level <= 0:
+		return 2
+	case level <= 3:
+		return 1
+	case level <= 6:
+		return 2
+	default:
+		return 3
+	}
+}
+
+type grpcConnect struct {
+	conn             *grpc.ClientConn
+	database         string
+	auth             Auth
+	location         *time.Location
+	compression      string
+	compressionLevel int32
+	blockBufferSize  uint8
+	conversion       column.ConversionPolicy
+	fixedString      column.FixedStringOptions
+	boolMapping      bool
+	settings         Settings
+	session          *sessionSettings
+	buffer           *chproto.Buffer
+	decorate         func(context.Context) context.Context // see httpConnect.decorate
+}
+
+func (g *grpcConnect) isBad() bool {
+	return g.conn == nil
+}
+
+// resetSession drops the settings of the SET statements of the connection, unless they are replayed.
+func (g *grpcConnect) resetSession() {
+	g.session.reset()
+}
+
+func (g *grpcConnect) close() error {
+	if g.conn == nil {
+		return nil
+	}
+	err := g.conn.Close()
+	g.conn = nil
+	return err
+}
+
+func (g *grpcConnect) serverVersion() (*ServerVersion, error) {
+	version, err := g.readVersion(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &ServerVersion{
+		Name:     "ClickHouse",
+		Version:  version,
+		Timezone: g.location,
+	}, nil
+}
+
+func (g *grpcConnect) readTimeZone(ctx context.Context) (*time.Location, error) {
+	var name string
+	if err := g.queryRow(decorateContext(g.decorate, ctx), "SELECT timezone()", &name); err != nil {
+		return nil, err
+	}
+	return timezone.Load(name)
+}
+
+func (g *grpcConnect) readVersion(ctx context.Context) (proto.Version, error) {
+	var version string
+	if err := g.queryRow(decorateContext(g.decorate, ctx), "SELECT version()", &version); err != nil {
+		return proto.Version{}, err
+	}
+	return proto.ParseVersion(version)
+}
+
+func (g *grpcConnect) queryRow(ctx context.Context, query string, dest ...interface{}) error {
+	rows, err := g.query(ctx, nil, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return errors.New("clickhouse [grpc]: no rows in result set")
+	}
+	return rows.Scan(dest...)
+}
+
+func (g *grpcConnect) ping(ctx context.Context) error {
+	var one uint8
+	return g.queryRow(ctx, "SELECT 1", &one)
+}
+
+// queryInfo returns the QueryInfo of query, with the settings of the connection, the session and options.
+func (g *grpcConnect) queryInfo(ctx context.Context, options *QueryOptions, query string) (*grpcQueryInfo, error) {
+	if len(options.parameters) != 0 {
+		return nil, errors.New("clickhouse [grpc]: query parameters are not supported by the gRPC interface")
+	}
+	username, password, err := g.auth.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	settings := make(map[string]string, len(g.settings)+len(options.settings))
+	for key, value := range g.settings {
+		settings[key] = fmt.Sprint(value)
+	}
+	g.session.each(func(key string, value interface{}) {
+		settings[key] = fmt.Sprint(value)
+	})
+	for key, value := range options.settings {
+		settings[key] = fmt.Sprint(value)
+	}
+	if comment, ok := options.logComment(); ok {
+		settings["log_comment"] = comment
+	}
+	database := g.database
+	if len(options.database) != 0 {
+		database = options.database
+	}
+	return &grpcQueryInfo{
+		query:                     query,
+		queryID:                   options.queryID,
+		settings:                  settings,
+		database:                  database,
+		outputFormat:              "Native",
+		userName:                  username,
+		password:                  password,
+		quota:                     options.quotaKey,
+		transportCompressionType:  g.compression,
+		transportCompressionLevel: g.compressionLevel,
+	}, nil
+}
+
+func (g *grpcConnect) newStream(ctx context.Context, desc *grpc.StreamDesc, method string) (grpc.ClientStream, error) {
+	if g.conn == nil {
+		return nil, driver.ErrBadConn
+	}
+	return g.conn.NewStream(ctx, desc, method)
+}
+
+// execute sends info and returns the output of the results, read with the query events of options.
+// The stream of the results is canceled once the output ends or fails.
+func (g *grpcConnect) execute(ctx context.Context, options *QueryOptions, info *grpcQueryInfo) (*grpcOutput, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := g.newStream(ctx, &grpc.StreamDesc{ServerStreams: true}, grpcExecuteQueryWithStreamOutput)
+	if err == nil {
+		err = stream.SendMsg(info)
+	}
+	if err == nil {
+		err = stream.CloseSend()
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &grpcOutput{
+		stream: stream,
+		cancel: cancel,
+		on:     options.onProcess(),
+	}, nil
+}
+
+// release is ignored, because grpc is used by std with empty release function
+func (g *grpcConnect) query(ctx context.Context, release func(*connect, error), query string, args ...interface{}) (*rows, error) {
+	options := queryOptions(ctx)
+	query, err := bindQueryOrAppendParameters(false, &options, query, g.location, args...)
+	if err != nil {
+		return nil, err
+	}
+	info, err := g.queryInfo(ctx, &options, query)
+	if err != nil {
+		return nil, err
+	}
+	output, err := g.execute(ctx, &options, info)
+	if err != nil {
+		return nil, options.resultLimits.wrap(err, 0)
+	}
+	bufferSize := g.blockBufferSize
+	if options.blockBufferSize > 0 {
+		// allow block buffer sze to be overridden per query
+		bufferSize = options.blockBufferSize
+	}
+	var (
+		errCh  = make(chan error)
+		stream = make(chan *proto.Block, bufferSize)
+		reader = chproto.NewReader(output)
+	)
+	block, err := g.readData(ctx, reader)
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			return nil, options.resultLimits.wrap(err, 0)
+		}
+		// queries with no results get no output
+		close(stream)
+		close(errCh)
+		return &rows{
+			stream:    stream,
+			errors:    errCh,
+			block:     &proto.Block{},
+			columns:   []string{},
+			structMap: &structMap{},
+		}, nil
+	}
+	go func() {
+		defer func() {
+			close(stream)
+			close(errCh)
+		}()
+		for {
+			block, err := g.readData(ctx, reader)
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					errCh <- err
+				}
+				return
+			}
+			select {
+			case <-ctx.Done():
+				errCh <- ctx.Err()
+				return
+			case stream <- block:
+			}
+		}
+	}()
+	return &rows{
+		block:     block,
+		stream:    stream,
+		errors:    errCh,
+		columns:   block.ColumnsNames(),
+		structMap: &structMap{},
+		mapping:   options.columnMapping,
+		limits:    options.resultLimits,
+	}, nil
+}
+
+func (g *grpcConnect) readData(ctx context.Context, reader *chproto.Reader) (*proto.Block, error) {
+	location := g.location
+	if opts := queryOptions(ctx); opts.userLocation != nil {
+		location = opts.userLocation
+	}
+	block := proto.Block{Timezone: location, Conversion: g.conversion, FixedString: g.fixedString, BoolMapping: g.boolMapping}
+	if err := block.Decode(reader, 0); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+func (g *grpcConnect) exec(ctx context.Context, query string, args ...interface{}) error {
+	if len(args) == 0 {
+		if ok, err := g.session.set(ctx, query, func(ctx context.Context, query string) error { return g.exec(ctx, query) }); ok {
+			return err
+		}
+	}
+	options := queryOptions(ctx)
+	query, err := bindQueryOrAppendParameters(false, &options, query, g.location, args...)
+	if err != nil {
+		return err
+	}
+	return g.run(ctx, &options, query)
+}
+
+// run executes query and discards its output.
+func (g *grpcConnect) run(ctx context.Context, options *QueryOptions, query string) error {
+	info, err := g.queryInfo(ctx, options, query)
+	if err != nil {
+		return err
+	}
+	output, err := g.execute(ctx, options, info)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(io.Discard, output)
+	return err
+}
+
+func (g *grpcConnect) asyncInsert(ctx context.Context, query string, wait bool) error {
+	options := queryOptions(ctx)
+	options.settings["async_insert"] = 1
+	options.settings["wait_for_async_insert"] = 0
+	if wait {
+		options.settings["wait_for_async_insert"] = 1
+	}
+	return g.run(ctx, &options, query)
+}
+
+// grpcOutput reads the output of the results of a query as one stream, reporting their progress and logs.
+type grpcOutput struct {
+	stream grpc.ClientStream
+	cancel context.CancelFunc
+	on     *onProcess
+	buf    []byte
+	err    error
+}
+
+func (o *grpcOutput) Read(p []byte) (int, error) {
+	for len(o.buf) == 0 {
+		if o.err != nil {
+			return 0, o.err
+		}
+		if o.err = o.recv(); o.err != nil {
+			o.cancel()
+		}
+	}
+	n := copy(p, o.buf)
+	o.buf = o.buf[n:]
+	return n, nil
+}
+
+func (o *grpcOutput) recv() error {
+	var result grpcResult
+	if err := o.stream.RecvMsg(&result); err != nil {
+		if errors.Is(err, io.EOF) {
+			o.on.finish()
+		}
+		return err
+	}
+	if len(result.logs) != 0 {
+		o.on.logs(result.logs)
+	}
+	if result.progress != nil {
+		o.on.progress(result.progress)
+	}
+	if result.exception != nil {
+		return memoryLimitError(result.exception)
+	}
+	if result.cancelled {
+		return &Exception{Code: int32(ErrQueryWasCancelled), Name: "DB::Exception", Message: "query was cancelled"}
+	}
+	o.buf = result.output
+	return nil
+}
@@ -0,0 +1,262 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+
+	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"google.golang.org/grpc"
+)
+
+// release is ignored, because grpc is used by std with empty release function
+func (g *grpcConnect) prepareBatch(ctx context.Context, query string, release func(*connect, error), acquire func(context.Context) (*connect, error)) (driver.Batch, error) {
+	block := &proto.Block{Conversion: g.conversion, FixedString: g.fixedString, BoolMapping: g.boolMapping}
+	query, err := describeInsert(query, block, func(query string) (*rows, error) {
+		return g.query(ctx, release, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+	options := queryOptions(ctx)
+	return &grpcBatch{
+		ctx:        ctx,
+		conn:       g,
+		structMap:  &structMap{},
+		block:      block,
+		query:      query,
+		nulls:      batchNullStrategy(g.settings, &options),
+		converters: columnConverters(options.columnConverters, block.ColumnsNames()),
+	}, nil
+}
+
+// grpcBatch streams the blocks of the batch to the server: the first Flush starts the insert and every
+// Flush sends the rows appended since as the input data of the next QueryInfo.
+type grpcBatch struct {
+	query      string
+	err        error
+	ctx        context.Context
+	conn       *grpcConnect
+	structMap  *structMap
+	sent       bool
+	sendErr    error
+	block      *proto.Block
+	nulls      NullStrategy
+	converters []ColumnConverter
+	buffer     chproto.Buffer
+	// stream is the insert started by the first Flush, canceled by cancel
+	stream grpc.ClientStream
+	cancel context.CancelFunc
+	on     *onProcess
+}
+
+func (b *grpcBatch) Abort() error {
+	defer func() {
+		b.sent = true
+		b.close()
+	}()
+	if b.sent {
+		return ErrBatchAlreadySent
+	}
+	return nil
+}
+
+func (b *grpcBatch) Append(v ...interface{}) error {
+	if b.sent {
+		return ErrBatchAlreadySent
+	}
+	v, err := convertRow("batch.Append", b.converters, b.block.ColumnsNames(), v)
+	if err != nil {
+		return err
+	}
+	return b.block.Append(applyNullStrategy(b.block, b.nulls, v, nil)...)
+}
+
+func (b *grpcBatch) AppendStruct(v interface{}) error {
+	values, err := b.structMap.MapColumns("AppendStruct", b.block.ColumnsNames(), v, false, queryOptions(b.ctx).columnMapping)
+	if err != nil {
+		return err
+	}
+	return b.Append(values...)
+}
+
+func (b *grpcBatch) Column(idx int) driver.BatchColumn {
+	if len(b.block.Columns) <= idx {
+		return &batchColumn{
+			err: &OpError{
+				Op:  "batch.Column",
+				Err: fmt.Errorf("invalid column index %d", idx),
+			},
+		}
+	}
+	var convert ColumnConverter
+	if b.converters != nil {
+		convert = b.converters[idx]
+	}
+	return &batchColumn{
+		batch:      b,
+		column:     b.block.Columns[idx],
+		conversion: b.block.Conversion,
+		convert:    convert,
+		release: func(err error) {
+			b.err = err
+		},
+	}
+}
+
+func (b *grpcBatch) IsSent() bool {
+	return b.sent
+}
+
+// Flush sends the rows appended since the last Flush to the server.
+func (b *grpcBatch) Flush() error {
+	if b.sent {
+		return ErrBatchAlreadySent
+	}
+	if b.err != nil {
+		return b.err
+	}
+	if b.block.Rows() == 0 {
+		return nil
+	}
+	if err := b.write(true); err != nil {
+		b.err = err
+		b.close()
+		return err
+	}
+	b.block.Reset()
+	return nil
+}
+
+func (b *grpcBatch) Send() (err error) {
+	defer func() {
+		b.sent = true
+		b.sendErr = err
+		b.close()
+	}()
+	if b.sent {
+		return ErrBatchAlreadySent
+	}
+	if b.err != nil {
+		return b.err
+	}
+	err = b.send()
+	observeWrite(b.ctx, b.query, err)
+	return err
+}
+
+// Retry re-sends the data of a batch whose Send failed in a new insert.
+// Only rows appended since the last Flush are kept - flushed blocks were sent to the failed insert.
+func (b *grpcBatch) Retry(ctx context.Context) (err error) {
+	if !b.sent {
+		return ErrBatchNotSent
+	}
+	if b.sendErr == nil {
+		return ErrBatchAlreadySent
+	}
+	if b.err != nil {
+		return b.err
+	}
+	defer func() {
+		b.sendErr = err
+		b.close()
+	}()
+	b.ctx = ctx
+	err = b.send()
+	observeWrite(b.ctx, b.query, err)
+	return err
+}
+
+// Reset clears the appended rows so that the batch can be reused for another insert into the same table
+// without describing the table again.
+func (b *grpcBatch) Reset() error {
+	b.close()
+	b.block.Reset()
+	b.sent, b.sendErr, b.err = false, nil, nil
+	return nil
+}
+
+func (b *grpcBatch) send() error {
+	if err := b.write(false); err != nil {
+		return err
+	}
+	if err := b.stream.CloseSend(); err != nil {
+		return err
+	}
+	var result grpcResult
+	if err := b.stream.RecvMsg(&result); err != nil {
+		return err
+	}
+	if result.progress != nil {
+		b.on.progress(result.progress)
+	}
+	if result.exception != nil {
+		return result.exception
+	}
+	b.on.finish()
+	return nil
+}
+
+// write sends the appended rows, starting the insert if it is not started yet. next reports whether more
+// data follows. The rows are kept in the block, so that Retry can send them again.
+func (b *grpcBatch) write(next bool) error {
+	b.buffer.Reset()
+	if b.block.Rows() != 0 {
+		if err := b.block.Encode(&b.buffer, 0); err != nil {
+			return err
+		}
+	}
+	info := &grpcQueryInfo{
+		inputData:     b.buffer.Buf,
+		nextQueryInfo: next,
+	}
+	if b.stream == nil {
+		options := queryOptions(b.ctx)
+		if b.nulls == NullAsDefault {
+			options.settings = withNullAsDefault(options.settings)
+		}
+		var err error
+		if info, err = b.conn.queryInfo(b.ctx, &options, b.query); err != nil {
+			return err
+		}
+		info.inputData, info.nextQueryInfo = b.buffer.Buf, next
+		ctx, cancel := context.WithCancel(b.ctx)
+		if b.stream, err = b.conn.newStream(ctx, &grpc.StreamDesc{ClientStreams: true}, grpcExecuteQueryWithStreamInput); err != nil {
+			cancel()
+			return err
+		}
+		b.cancel, b.on = cancel, options.onProcess()
+	}
+	return b.stream.SendMsg(info)
+}
+
+// close ends the insert, if started.
+func (b *grpcBatch) close() {
+	if b.cancel != nil {
+		b.cancel()
+	}
+	b.stream, b.cancel = nil, nil
+}
+
+var (
+	_ driver.RetryableBatch  = (*grpcBatch)(nil)
+	_ driver.ResettableBatch = (*grpcBatch)(nil)
+)
@@ -0,0 +1,248 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The messages of the gRPC interface of ClickHouse, see src/Server/grpc_protos/clickhouse_grpc.proto in the
+// ClickHouse repository. Only the fields used by the client are encoded and decoded, unknown fields are skipped.
+
+const (
+	grpcExecuteQueryWithStreamInput  = "/clickhouse.grpc.ClickHouse/ExecuteQueryWithStreamInput"
+	grpcExecuteQueryWithStreamOutput = "/clickhouse.grpc.ClickHouse/ExecuteQueryWithStreamOutput"
+)
+
+type grpcQueryInfo struct {
+	query                     string
+	queryID                   string
+	settings                  map[string]string
+	database                  string
+	inputData                 []byte
+	outputFormat              string
+	userName                  string
+	password                  string
+	quota                     string
+	nextQueryInfo             bool
+	transportCompressionType  string
+	transportCompressionLevel int32
+}
+
+func (q *grpcQueryInfo) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, q.query)
+	b = appendString(b, 2, q.queryID)
+	keys := make([]string, 0, len(q.settings))
+	for k := range q.settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		// map entries are messages of key = 1 and value = 2
+		var entry []byte
+		entry = appendString(entry, 1, k)
+		entry = appendString(entry, 2, q.settings[k])
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+	b = appendString(b, 4, q.database)
+	if len(q.inputData) != 0 {
+		b = protowire.AppendTag(b, 5, protowire.BytesType)
+		b = protowire.AppendBytes(b, q.inputData)
+	}
+	b = appendString(b, 7, q.outputFormat)
+	b = appendString(b, 9, q.userName)
+	b = appendString(b, 10, q.password)
+	b = appendString(b, 11, q.quota)
+	if q.nextQueryInfo {
+		b = protowire.AppendTag(b, 16, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	b = appendString(b, 22, q.transportCompressionType)
+	if q.transportCompressionLevel != 0 {
+		b = protowire.AppendTag(b, 23, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(q.transportCompressionLevel))
+	}
+	return b
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+type grpcResult struct {
+	output    []byte
+	logs      []Log
+	progress  *Progress
+	exception *Exception
+	cancelled bool
+	timeZone  string
+}
+
+func (r *grpcResult) unmarshal(b []byte) error {
+	return grpcFields(b, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			r.output = append(r.output[:0], v...)
+		case num == 4 && typ == protowire.BytesType:
+			var log Log
+			if err := log.unmarshal(v); err != nil {
+				return err
+			}
+			r.logs = append(r.logs, log)
+		case num == 5 && typ == protowire.BytesType:
+			r.progress = &Progress{}
+			return grpcFields(v, func(num protowire.Number, typ protowire.Type, _ []byte, n uint64) error {
+				switch num {
+				case 1:
+					r.progress.Rows = n
+				case 2:
+					r.progress.Bytes = n
+				case 3:
+					r.progress.TotalRows = n
+				case 4:
+					r.progress.WroteRows = n
+				case 5:
+					r.progress.WroteBytes = n
+				}
+				return nil
+			})
+		case num == 7 && typ == protowire.BytesType:
+			exception := &Exception{}
+			err := grpcFields(v, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+				switch num {
+				case 1:
+					exception.Code = int32(n)
+				case 2:
+					exception.Name = string(v)
+				case 3:
+					exception.Message = string(v)
+				case 4:
+					exception.StackTrace = string(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if match := httpExceptionRe.FindStringSubmatch(exception.Message); match != nil {
+				exception.Message = match[2]
+			}
+			r.exception = exception
+		case num == 8 && typ == protowire.VarintType:
+			r.cancelled = n != 0
+		case num == 10 && typ == protowire.BytesType:
+			r.timeZone = string(v)
+		}
+		return nil
+	})
+}
+
+func (l *Log) unmarshal(b []byte) error {
+	var (
+		seconds int64
+		err     = grpcFields(b, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+			switch num {
+			case 1:
+				seconds = int64(n)
+			case 2:
+				l.TimeMicro = uint32(n)
+			case 3:
+				l.ThreadID = n
+			case 4:
+				l.QueryID = string(v)
+			case 5:
+				l.Priority = int8(n)
+			case 6:
+				l.Source = string(v)
+			case 7:
+				l.Text = string(v)
+			}
+			return nil
+		})
+	)
+	l.Time = time.Unix(seconds, int64(l.TimeMicro)*int64(time.Microsecond))
+	return err
+}
+
+// grpcFields calls fn with the number and type of every field of the message b, and with its value: v for
+// the length delimited fields and n for the varint ones. Fields of other wire types are skipped.
+func grpcFields(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error) error {
+	for len(b) > 0 {
+		num, typ, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			return fmt.Errorf("clickhouse [grpc]: %w", protowire.ParseError(l))
+		}
+		b = b[l:]
+		var (
+			v []byte
+			n uint64
+		)
+		switch typ {
+		case protowire.BytesType:
+			v, l = protowire.ConsumeBytes(b)
+		case protowire.VarintType:
+			n, l = protowire.ConsumeVarint(b)
+		default:
+			l = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if l < 0 {
+			return fmt.Errorf("clickhouse [grpc]: %w", protowire.ParseError(l))
+		}
+		b = b[l:]
+		if typ == protowire.BytesType || typ == protowire.VarintType {
+			if err := fn(num, typ, v, n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// grpcCodec encodes grpcQueryInfo and decodes grpcResult messages, registered under the name of the
+// protobuf codec so the content type of the requests is application/grpc+proto.
+type grpcCodec struct{}
+
+func (grpcCodec) Marshal(v interface{}) ([]byte, error) {
+	info, ok := v.(*grpcQueryInfo)
+	if !ok {
+		return nil, fmt.Errorf("clickhouse [grpc]: unexpected message %T", v)
+	}
+	return info.marshal(), nil
+}
+
+func (grpcCodec) Unmarshal(data []byte, v interface{}) error {
+	result, ok := v.(*grpcResult)
+	if !ok {
+		return fmt.Errorf("clickhouse [grpc]: unexpected message %T", v)
+	}
+	return result.unmarshal(data)
+}
+
+func (grpcCodec) Name() string {
+	return "proto"
+}
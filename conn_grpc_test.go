@@ -0,0 +1,263 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// grpcRawCodec passes the messages of the fake server as bytes.
+type grpcRawCodec struct{}
+
+func (grpcRawCodec) Marshal(v interface{}) ([]byte, error) { return *v.(*[]byte), nil }
+func (grpcRawCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
+func (grpcRawCodec) Name() string { return "proto" }
+
+// grpcServer is a fake of the gRPC interface of ClickHouse.
+type grpcServer struct {
+	mu       sync.Mutex
+	settings map[string]string
+	inserted []*proto.Block
+}
+
+func (s *grpcServer) handle(srv interface{}, stream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(stream)
+	var (
+		query string
+		input []byte
+		next  = true
+	)
+	for next {
+		var msg []byte
+		if err := stream.RecvMsg(&msg); err != nil {
+			return err
+		}
+		next = false
+		err := grpcFields(msg, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+			switch num {
+			case 1:
+				query = string(v)
+			case 3:
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				var key, value string
+				grpcFields(v, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) error {
+					if num == 1 {
+						key = string(v)
+					} else {
+						value = string(v)
+					}
+					return nil
+				})
+				s.settings[key] = value
+			case 5:
+				input = append(input, v...)
+			case 16:
+				next = n != 0
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if method == grpcExecuteQueryWithStreamInput {
+		reader := chproto.NewReader(bytes.NewReader(input))
+		for {
+			var block proto.Block
+			if err := block.Decode(reader, 0); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return err
+			}
+			s.mu.Lock()
+			s.inserted = append(s.inserted, &block)
+			s.mu.Unlock()
+		}
+		return s.send(stream, nil)
+	}
+	var block proto.Block
+	switch {
+	case query == "SELECT timezone()" || query == "SELECT version()":
+		value := "UTC"
+		if query == "SELECT version()" {
+			value = "23.8.1"
+		}
+		block.AddColumn("value", "String")
+		block.Append(value)
+	case query == "SELECT 1":
+		block.AddColumn("1", "UInt8")
+		block.Append(uint8(1))
+	case strings.HasPrefix(query, "DESCRIBE TABLE"):
+		for _, name := range []string{"name", "type", "default_type", "default_expression", "comment", "codec_expression", "ttl_expression"} {
+			block.AddColumn(name, "String")
+		}
+		block.Append("id", "UInt64", "", "", "", "", "")
+		block.Append("name", "String", "", "", "", "", "")
+	case strings.HasPrefix(query, "SELECT number"):
+		block.AddColumn("number", "UInt64")
+		for i := uint64(0); i < 5; i++ {
+			block.Append(i)
+		}
+	case strings.HasPrefix(query, "FAIL"):
+		var exception []byte
+		exception = protowire.AppendTag(exception, 1, protowire.VarintType)
+		exception = protowire.AppendVarint(exception, 60)
+		exception = appendString(exception, 2, "DB::Exception")
+		exception = appendString(exception, 3, "Code: 60. DB::Exception: Table default.x does not exist. (UNKNOWN_TABLE) (version 23.8.1)")
+		var result []byte
+		result = protowire.AppendTag(result, 7, protowire.BytesType)
+		result = protowire.AppendBytes(result, exception)
+		return stream.SendMsg(&result)
+	default:
+		return s.send(stream, nil)
+	}
+	var buf chproto.Buffer
+	if err := block.Encode(&buf, 0); err != nil {
+		return err
+	}
+	// the output of a block may be split over results
+	half := len(buf.Buf) / 2
+	if err := s.send(stream, buf.Buf[:half]); err != nil {
+		return err
+	}
+	return s.send(stream, buf.Buf[half:])
+}
+
+func (s *grpcServer) send(stream grpc.ServerStream, output []byte) error {
+	var progress []byte
+	progress = protowire.AppendTag(progress, 1, protowire.VarintType)
+	progress = protowire.AppendVarint(progress, 5)
+	var result []byte
+	if len(output) != 0 {
+		result = protowire.AppendTag(result, 1, protowire.BytesType)
+		result = protowire.AppendBytes(result, output)
+	}
+	result = protowire.AppendTag(result, 5, protowire.BytesType)
+	result = protowire.AppendBytes(result, progress)
+	return stream.SendMsg(&result)
+}
+
+func startGRPCServer(t *testing.T) (*grpcServer, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	fake := &grpcServer{settings: map[string]string{}}
+	server := grpc.NewServer(grpc.ForceServerCodec(grpcRawCodec{}), grpc.UnknownServiceHandler(fake.handle))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return fake, listener.Addr().String()
+}
+
+func TestGRPCQuery(t *testing.T) {
+	fake, addr := startGRPCServer(t)
+	ctx := context.Background()
+	conn, err := dialGRPC(ctx, addr, 2, (&Options{
+		Protocol: GRPC,
+		Addr:     []string{addr},
+		Settings: Settings{"max_execution_time": 60},
+	}).setDefaults())
+	require.NoError(t, err)
+	defer conn.close()
+	assert.Equal(t, "UTC", conn.location.String())
+	require.NoError(t, conn.ping(ctx))
+
+	var progress uint64
+	rows, err := conn.query(Context(ctx, WithProgress(func(p *Progress) {
+		progress += p.Rows
+	})), nil, "SELECT number FROM system.numbers LIMIT 5")
+	require.NoError(t, err)
+	var numbers []uint64
+	for rows.Next() {
+		var n uint64
+		require.NoError(t, rows.Scan(&n))
+		numbers = append(numbers, n)
+	}
+	require.NoError(t, rows.Close())
+	assert.Equal(t, []uint64{0, 1, 2, 3, 4}, numbers)
+	assert.Equal(t, uint64(10), progress)
+	fake.mu.Lock()
+	assert.Equal(t, "60", fake.settings["max_execution_time"])
+	fake.mu.Unlock()
+
+	err = conn.exec(ctx, "FAIL")
+	var exception *Exception
+	require.ErrorAs(t, err, &exception)
+	assert.Equal(t, int32(60), exception.Code)
+	assert.Equal(t, "Table default.x does not exist. (UNKNOWN_TABLE)", exception.Message)
+	assert.ErrorIs(t, err, ErrTableNotFound)
+	require.NoError(t, conn.exec(ctx, "CREATE TABLE t (id UInt64) ENGINE Memory"))
+}
+
+func TestGRPCBatch(t *testing.T) {
+	fake, addr := startGRPCServer(t)
+	ctx := context.Background()
+	conn, err := dialGRPC(ctx, addr, 2, (&Options{
+		Protocol:    GRPC,
+		Addr:        []string{addr},
+		Compression: &Compression{Method: CompressionGZIP},
+	}).setDefaults())
+	require.NoError(t, err)
+	defer conn.close()
+	batch, err := conn.prepareBatch(ctx, "INSERT INTO t", nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, batch.Append(uint64(1), "a"))
+	require.NoError(t, batch.Flush())
+	require.NoError(t, batch.Append(uint64(2), "b"))
+	require.NoError(t, batch.Append(uint64(3), "c"))
+	require.NoError(t, batch.Send())
+	fake.mu.Lock()
+	require.Len(t, fake.inserted, 2)
+	assert.Equal(t, 1, fake.inserted[0].Rows())
+	assert.Equal(t, 2, fake.inserted[1].Rows())
+	assert.Equal(t, []string{"id", "name"}, fake.inserted[1].ColumnsNames())
+	fake.mu.Unlock()
+
+	require.NoError(t, batch.(driver.ResettableBatch).Reset())
+	require.NoError(t, batch.Append(uint64(4), "d"))
+	require.NoError(t, batch.Send())
+	fake.mu.Lock()
+	assert.Len(t, fake.inserted, 3)
+	fake.mu.Unlock()
+}
+
+func TestGRPCUnsupportedCompression(t *testing.T) {
+	_, err := dialGRPC(context.Background(), "127.0.0.1:0", 1, (&Options{
+		Protocol:    GRPC,
+		Compression: &Compression{Method: CompressionLZ4},
+	}).setDefaults())
+	assert.Error(t, err)
+}
@@ -40,7 +40,7 @@ func (c *connect) handshake(database, username, password string) error {
 	{
 		c.buffer.PutByte(proto.ClientHello)
 		handshake := &proto.ClientHandshake{
-			ProtocolVersion: ClientTCPProtocolVersion,
+			ProtocolVersion: c.revision,
 			ClientName:      c.opt.ClientInfo.String(),
 			ClientVersion:   proto.Version{ClientVersionMajor, ClientVersionMinor, ClientVersionPatch}, //nolint:govet
 		}
@@ -55,7 +55,7 @@ func (c *connect) handshake(database, username, password string) error {
 		}
 	}
 	{
-		packet, err := c.reader.ReadByte()
+		packet, err := c.readPacket()
 		if err != nil {
 			return err
 		}
@@ -81,6 +81,7 @@ func (c *connect) handshake(database, username, password string) error {
 		c.revision = c.server.Revision
 		c.debugf("[handshake] downgrade client proto")
 	}
+	c.server.ProtocolRevision = c.revision
 	c.debugf("[handshake] <- %s", c.server)
 	return nil
 }
@@ -34,9 +34,10 @@ import (
 	"sync"
 	"time"
 
-	"github.com/ClickHouse/ch-go/compress"
 	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
 	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/timezone"
 	"github.com/andybalholm/brotli"
 	"github.com/pkg/errors"
 )
@@ -144,6 +145,19 @@ func dialHttp(ctx context.Context, addr string, num int, opt *Options) (*httpCon
 			return nil, errors.New("invalid interface type for http")
 		}
 	}
+	dialer := &net.Dialer{
+		Timeout:   opt.DialTimeout,
+		KeepAlive: opt.ConnMaxLifetime,
+	}
+	dialContext := dialer.DialContext
+	path, unix := unixSocket(addr)
+	if unix {
+		// the requests are sent to localhost, over the unix socket
+		addr = "localhost"
+		dialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", path)
+		}
+	}
 	u := &url.URL{
 		Scheme: opt.scheme,
 		Host:   addr,
@@ -155,13 +169,14 @@ func dialHttp(ctx context.Context, addr string, num int, opt *Options) (*httpCon
 		headers[k] = v
 	}
 
-	if opt.TLS == nil && len(opt.Auth.Username) > 0 {
+	// with Auth.Credentials the user is set on every request by prepareRequest
+	if opt.Auth.Credentials == nil && opt.TLS == nil && len(opt.Auth.Username) > 0 {
 		if len(opt.Auth.Password) > 0 {
 			u.User = url.UserPassword(opt.Auth.Username, opt.Auth.Password)
 		} else {
 			u.User = url.User(opt.Auth.Username)
 		}
-	} else if opt.TLS != nil && len(opt.Auth.Username) > 0 {
+	} else if opt.Auth.Credentials == nil && opt.TLS != nil && len(opt.Auth.Username) > 0 {
 		headers["X-ClickHouse-User"] = opt.Auth.Username
 		if len(opt.Auth.Password) > 0 {
 			headers["X-ClickHouse-Key"] = opt.Auth.Password
@@ -193,15 +208,21 @@ func dialHttp(ctx context.Context, addr string, num int, opt *Options) (*httpCon
 	query.Set("default_format", "Native")
 	u.RawQuery = query.Encode()
 
-	t := &http.Transport{
-		DialContext: (&net.Dialer{
-			Timeout:   opt.DialTimeout,
-			KeepAlive: opt.ConnMaxLifetime,
-		}).DialContext,
-		MaxIdleConns:          1,
-		IdleConnTimeout:       opt.ConnMaxLifetime,
-		ResponseHeaderTimeout: opt.ReadTimeout,
-		TLSClientConfig:       opt.TLS,
+	newTransport := func() *http.Transport {
+		return &http.Transport{
+			DialContext:           dialContext,
+			MaxIdleConns:          1,
+			IdleConnTimeout:       opt.ConnMaxLifetime,
+			ResponseHeaderTimeout: opt.ReadTimeout,
+			TLSClientConfig:       opt.TLS,
+			ForceAttemptHTTP2:     opt.Http2,
+			MaxConnsPerHost:       opt.HttpMaxConnsPerHost,
+		}
+	}
+	t, sharedTransport := newTransport(), false
+	if opt.httpTransport != nil && !unix {
+		// the connections share the sockets to all hosts, except for unix sockets which all use localhost
+		t, sharedTransport = opt.httpTransport.get(newTransport, opt.MaxIdleConns), true
 	}
 
 	conn := &httpConnect{
@@ -211,10 +232,17 @@ func dialHttp(ctx context.Context, addr string, num int, opt *Options) (*httpCon
 		url:             u,
 		buffer:          new(chproto.Buffer),
 		compression:     opt.Compression.Method,
-		blockCompressor: compress.NewWriter(),
+		level:           opt.Compression.Level,
+		blockCompressor: newBlockCompressor(),
 		compressionPool: compressionPool,
 		blockBufferSize: opt.BlockBufferSize,
 		headers:         headers,
+		auth:            opt.Auth,
+		conversion:      opt.ConversionPolicy,
+		fixedString:     opt.FixedString,
+		boolMapping:     opt.BoolMapping,
+		settings:        opt.Settings,
+		decorate:        opt.DecorateContext,
 	}
 	location, err := conn.readTimeZone(ctx)
 	if err != nil {
@@ -237,11 +265,20 @@ func dialHttp(ctx context.Context, addr string, num int, opt *Options) (*httpCon
 		url:             u,
 		buffer:          new(chproto.Buffer),
 		compression:     opt.Compression.Method,
-		blockCompressor: compress.NewWriter(),
+		level:           opt.Compression.Level,
+		blockCompressor: newBlockCompressor(),
 		compressionPool: compressionPool,
 		location:        location,
 		blockBufferSize: opt.BlockBufferSize,
 		headers:         headers,
+		auth:            opt.Auth,
+		conversion:      opt.ConversionPolicy,
+		fixedString:     opt.FixedString,
+		boolMapping:     opt.BoolMapping,
+		settings:        opt.Settings,
+		session:         newSessionSettings(opt),
+		sharedTransport: sharedTransport,
+		decorate:        opt.DecorateContext,
 	}, nil
 }
 
@@ -251,10 +288,21 @@ type httpConnect struct {
 	location        *time.Location
 	buffer          *chproto.Buffer
 	compression     CompressionMethod
-	blockCompressor *compress.Writer
+	level           int // level of compression, for the leveled block compression methods
+	blockCompressor *blockCompressor
 	compressionPool Pool[HTTPReaderWriter]
 	blockBufferSize uint8
 	headers         map[string]string
+	auth            Auth
+	conversion      column.ConversionPolicy
+	fixedString     column.FixedStringOptions
+	boolMapping     bool
+	settings        Settings
+	session         *sessionSettings
+	// sharedTransport is set when the transport of client is shared by the connections of the pool
+	sharedTransport bool
+	// decorate is applied to the context of the queries the connection issues itself, see Options.DecorateContext
+	decorate func(context.Context) context.Context
 }
 
 func (h *httpConnect) isBad() bool {
@@ -264,8 +312,25 @@ func (h *httpConnect) isBad() bool {
 	return false
 }
 
+// resetSession drops the settings of the SET statements of the connection, unless they are replayed.
+func (h *httpConnect) resetSession() {
+	h.session.reset()
+}
+
+func (h *httpConnect) serverVersion() (*ServerVersion, error) {
+	version, err := h.readVersion(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &ServerVersion{
+		Name:     "ClickHouse",
+		Version:  version,
+		Timezone: h.location,
+	}, nil
+}
+
 func (h *httpConnect) readTimeZone(ctx context.Context) (*time.Location, error) {
-	rows, err := h.query(ctx, func(*connect, error) {}, "SELECT timezone()")
+	rows, err := h.query(decorateContext(h.decorate, ctx), func(*connect, error) {}, "SELECT timezone()")
 	if err != nil {
 		return nil, err
 	}
@@ -273,7 +338,7 @@ func (h *httpConnect) readTimeZone(ctx context.Context) (*time.Location, error)
 	for rows.Next() {
 		var serverLocation string
 		rows.Scan(&serverLocation)
-		location, err := time.LoadLocation(serverLocation)
+		location, err := timezone.Load(serverLocation)
 		if err != nil {
 			return nil, err
 		}
@@ -283,7 +348,7 @@ func (h *httpConnect) readTimeZone(ctx context.Context) (*time.Location, error)
 }
 
 func (h *httpConnect) readVersion(ctx context.Context) (proto.Version, error) {
-	rows, err := h.query(ctx, func(*connect, error) {}, "SELECT version()")
+	rows, err := h.query(decorateContext(h.decorate, ctx), func(*connect, error) {}, "SELECT version()")
 	if err != nil {
 		return proto.Version{}, err
 	}
@@ -347,16 +412,27 @@ func createCompressionPool(compression *Compression) (Pool[HTTPReaderWriter], er
 	return pool, nil
 }
 
+// compressesBlocks reports whether the data blocks are sent and received in the native compression
+// framing, with the compress and decompress settings rather than a Content-Encoding. LZ4HC blocks are
+// decoded as LZ4, so the server answers LZ4HC requests with LZ4 blocks.
+func (h *httpConnect) compressesBlocks() bool {
+	switch h.compression {
+	case CompressionLZ4, CompressionLZ4HC, CompressionZSTD:
+		return true
+	}
+	return false
+}
+
 func (h *httpConnect) writeData(block *proto.Block) error {
 	// Saving offset of compressible data
 	start := len(h.buffer.Buf)
 	if err := block.Encode(h.buffer, 0); err != nil {
 		return err
 	}
-	if h.compression == CompressionLZ4 || h.compression == CompressionZSTD {
+	if h.compressesBlocks() {
 		// Performing compression. Supported and requires
 		data := h.buffer.Buf[start:]
-		if err := h.blockCompressor.Compress(compress.Method(h.compression), data); err != nil {
+		if err := h.blockCompressor.Compress(Compression{Method: h.compression, Level: h.level}, data); err != nil {
 			return errors.Wrap(err, "compress")
 		}
 		h.buffer.Buf = append(h.buffer.Buf[:start], h.blockCompressor.Data...)
@@ -371,8 +447,8 @@ func (h *httpConnect) readData(ctx context.Context, reader *chproto.Reader) (*pr
 		location = opts.userLocation
 	}
 
-	block := proto.Block{Timezone: location}
-	if h.compression == CompressionLZ4 || h.compression == CompressionZSTD {
+	block := proto.Block{Timezone: location, Conversion: h.conversion, FixedString: h.fixedString, BoolMapping: h.boolMapping}
+	if h.compressesBlocks() {
 		reader.EnableCompression()
 		defer reader.DisableCompression()
 	}
@@ -403,7 +479,7 @@ func (h *httpConnect) readRawResponse(response *http.Response) (body []byte, err
 	if body, err = rw.read(response); err != nil {
 		return nil, err
 	}
-	if h.compression == CompressionLZ4 || h.compression == CompressionZSTD {
+	if h.compressesBlocks() {
 		result := make([]byte, len(body))
 		reader := chproto.NewReader(bytes.NewReader(body))
 		reader.EnableCompression()
@@ -433,6 +509,15 @@ func (h *httpConnect) prepareRequest(ctx context.Context, reader io.Reader, opti
 		req.Header.Add(k, v)
 	}
 
+	if h.auth.Credentials != nil {
+		username, password, err := h.auth.credentials(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-ClickHouse-User", username)
+		req.Header.Set("X-ClickHouse-Key", password)
+	}
+
 	var query url.Values
 	if options != nil {
 		query = req.URL.Query()
@@ -442,6 +527,12 @@ func (h *httpConnect) prepareRequest(ctx context.Context, reader io.Reader, opti
 		if options.quotaKey != "" {
 			query.Set(quotaKeyParamName, options.quotaKey)
 		}
+		if options.database != "" {
+			query.Set("database", options.database)
+		}
+		h.session.each(func(key string, value interface{}) {
+			query.Set(key, fmt.Sprint(value))
+		})
 		for key, value := range options.settings {
 			// check that query doesn't change format
 			if key == "default_format" {
@@ -449,6 +540,9 @@ func (h *httpConnect) prepareRequest(ctx context.Context, reader io.Reader, opti
 			}
 			query.Set(key, fmt.Sprint(value))
 		}
+		if comment, ok := options.logComment(); ok {
+			query.Set("log_comment", comment)
+		}
 		for key, value := range options.parameters {
 			query.Set(fmt.Sprintf("param_%s", key), value)
 		}
@@ -474,7 +568,11 @@ func (h *httpConnect) executeRequest(req *http.Request) (*http.Response, error)
 			return nil, errors.Wrap(err, "clickhouse [execute]:: failed to read the response")
 		}
 
-		return nil, fmt.Errorf("clickhouse [execute]:: %d code: %s", resp.StatusCode, string(msg))
+		err = fmt.Errorf("clickhouse [execute]:: %d code: %s", resp.StatusCode, string(msg))
+		if exception := httpException(resp.Header, string(msg)); exception != nil {
+			return nil, memoryLimitError(&httpExecError{msg: err.Error(), exception: exception})
+		}
+		return nil, err
 	}
 	return resp, nil
 }
@@ -496,7 +594,9 @@ func (h *httpConnect) close() error {
 	if h.client == nil {
 		return nil
 	}
-	h.client.CloseIdleConnections()
+	if !h.sharedTransport {
+		h.client.CloseIdleConnections()
+	}
 	h.client = nil
 	return nil
 }
@@ -34,10 +34,33 @@ import (
 var httpInsertRe = regexp.MustCompile(`(?i)^INSERT INTO\s+\x60?([\w.^\(]+)\x60?\s*(\([^\)]*\))?`)
 
 // release is ignored, because http used by std with empty release function
-func (h *httpConnect) prepareBatch(ctx context.Context, query string, release func(*connect, error)) (driver.Batch, error) {
+func (h *httpConnect) prepareBatch(ctx context.Context, query string, release func(*connect, error), acquire func(context.Context) (*connect, error)) (driver.Batch, error) {
+	block := &proto.Block{Conversion: h.conversion, FixedString: h.fixedString, BoolMapping: h.boolMapping}
+	query, err := describeInsert(query, block, func(query string) (*rows, error) {
+		return h.query(ctx, release, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	options := queryOptions(ctx)
+	return &httpBatch{
+		ctx:        ctx,
+		conn:       h,
+		structMap:  &structMap{},
+		block:      block,
+		query:      query,
+		nulls:      batchNullStrategy(h.settings, &options),
+		converters: columnConverters(options.columnConverters, block.ColumnsNames()),
+	}, nil
+}
+
+// describeInsert adds the columns of the table of the INSERT query to block, describing the table with query,
+// and returns the INSERT of the data in the Native format of the interfaces without a native batch protocol.
+func describeInsert(query string, block *proto.Block, describe func(query string) (*rows, error)) (string, error) {
 	matches := httpInsertRe.FindStringSubmatch(query)
 	if len(matches) < 3 {
-		return nil, errors.New("cannot get table name from query")
+		return "", errors.New("cannot get table name from query")
 	}
 	tableName := matches[1]
 	var rColumns []string
@@ -45,18 +68,16 @@ func (h *httpConnect) prepareBatch(ctx context.Context, query string, release fu
 		colMatch := strings.TrimSuffix(strings.TrimPrefix(matches[2], "("), ")")
 		rColumns = strings.Split(colMatch, ",")
 		for i := range rColumns {
-			rColumns[i] = strings.TrimSpace(rColumns[i])
+			rColumns[i] = strings.Trim(strings.TrimSpace(rColumns[i]), "`")
 		}
 	}
 	query = "INSERT INTO " + tableName + " FORMAT Native"
 	queryTableSchema := "DESCRIBE TABLE " + tableName
-	r, err := h.query(ctx, release, queryTableSchema)
+	r, err := describe(queryTableSchema)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	block := &proto.Block{}
-
 	// get Table columns and types
 	columns := make(map[string]string)
 	var colNames []string
@@ -68,7 +89,7 @@ func (h *httpConnect) prepareBatch(ctx context.Context, query string, release fu
 		)
 
 		if err = r.Scan(&colName, &colType, &ignore, &ignore, &ignore, &ignore, &ignore); err != nil {
-			return nil, err
+			return "", err
 		}
 		colNames = append(colNames, colName)
 		columns[colName] = colType
@@ -78,7 +99,7 @@ func (h *httpConnect) prepareBatch(ctx context.Context, query string, release fu
 	case 0:
 		for _, colName := range colNames {
 			if err = block.AddColumn(colName, column.Type(columns[colName])); err != nil {
-				return nil, err
+				return "", err
 			}
 		}
 	default:
@@ -86,31 +107,27 @@ func (h *httpConnect) prepareBatch(ctx context.Context, query string, release fu
 		for _, colName := range rColumns {
 			if colType, ok := columns[colName]; ok {
 				if err = block.AddColumn(colName, column.Type(colType)); err != nil {
-					return nil, err
+					return "", err
 				}
 			} else {
-				return nil, fmt.Errorf("column %s is not present in the table %s", colName, tableName)
+				return "", fmt.Errorf("column %s is not present in the table %s", colName, tableName)
 			}
 		}
 	}
-
-	return &httpBatch{
-		ctx:       ctx,
-		conn:      h,
-		structMap: &structMap{},
-		block:     block,
-		query:     query,
-	}, nil
+	return query, nil
 }
 
 type httpBatch struct {
-	query     string
-	err       error
-	ctx       context.Context
-	conn      *httpConnect
-	structMap *structMap
-	sent      bool
-	block     *proto.Block
+	query      string
+	err        error
+	ctx        context.Context
+	conn       *httpConnect
+	structMap  *structMap
+	sent       bool
+	sendErr    error
+	block      *proto.Block
+	nulls      NullStrategy
+	converters []ColumnConverter
 }
 
 // Flush TODO: noop on http currently - requires streaming to be implemented
@@ -132,14 +149,18 @@ func (b *httpBatch) Append(v ...interface{}) error {
 	if b.sent {
 		return ErrBatchAlreadySent
 	}
-	if err := b.block.Append(v...); err != nil {
+	v, err := convertRow("batch.Append", b.converters, b.block.ColumnsNames(), v)
+	if err != nil {
+		return err
+	}
+	if err := b.block.Append(applyNullStrategy(b.block, b.nulls, v, nil)...); err != nil {
 		return err
 	}
 	return nil
 }
 
 func (b *httpBatch) AppendStruct(v interface{}) error {
-	values, err := b.structMap.Map("AppendStruct", b.block.ColumnsNames(), v, false)
+	values, err := b.structMap.MapColumns("AppendStruct", b.block.ColumnsNames(), v, false, queryOptions(b.ctx).columnMapping)
 	if err != nil {
 		return err
 	}
@@ -155,9 +176,15 @@ func (b *httpBatch) Column(idx int) driver.BatchColumn {
 			},
 		}
 	}
+	var convert ColumnConverter
+	if b.converters != nil {
+		convert = b.converters[idx]
+	}
 	return &batchColumn{
-		batch:  b,
-		column: b.block.Columns[idx],
+		batch:      b,
+		column:     b.block.Columns[idx],
+		conversion: b.block.Conversion,
+		convert:    convert,
 		release: func(err error) {
 			b.err = err
 		},
@@ -171,6 +198,7 @@ func (b *httpBatch) IsSent() bool {
 func (b *httpBatch) Send() (err error) {
 	defer func() {
 		b.sent = true
+		b.sendErr = err
 	}()
 	if b.sent {
 		return ErrBatchAlreadySent
@@ -178,7 +206,44 @@ func (b *httpBatch) Send() (err error) {
 	if b.err != nil {
 		return b.err
 	}
+	err = b.send()
+	observeWrite(b.ctx, b.query, err)
+	return err
+}
+
+// Retry re-sends the data of a batch whose Send failed. Each HTTP request uses a connection from the transport pool.
+func (b *httpBatch) Retry(ctx context.Context) (err error) {
+	if !b.sent {
+		return ErrBatchNotSent
+	}
+	if b.sendErr == nil {
+		return ErrBatchAlreadySent
+	}
+	if b.err != nil {
+		return b.err
+	}
+	defer func() {
+		b.sendErr = err
+	}()
+	b.ctx = ctx
+	err = b.send()
+	observeWrite(b.ctx, b.query, err)
+	return err
+}
+
+// Reset clears the appended rows so that the batch can be reused for another insert into the same table
+// without describing the table again.
+func (b *httpBatch) Reset() error {
+	b.block.Reset()
+	b.sent, b.sendErr, b.err = false, nil, nil
+	return nil
+}
+
+func (b *httpBatch) send() (err error) {
 	options := queryOptions(b.ctx)
+	if b.nulls == NullAsDefault {
+		options.settings = withNullAsDefault(options.settings)
+	}
 
 	headers := make(map[string]string)
 
@@ -191,7 +256,7 @@ func (b *httpBatch) Send() (err error) {
 	switch b.conn.compression {
 	case CompressionGZIP, CompressionDeflate, CompressionBrotli:
 		headers["Content-Encoding"] = b.conn.compression.String()
-	case CompressionZSTD, CompressionLZ4:
+	case CompressionZSTD, CompressionLZ4, CompressionLZ4HC:
 		options.settings["decompress"] = "1"
 	}
 
@@ -218,15 +283,21 @@ func (b *httpBatch) Send() (err error) {
 	for k, v := range b.conn.headers {
 		headers[k] = v
 	}
+	onProcess := options.onProcess()
 	res, err := b.conn.sendQuery(b.ctx, r, &options, headers)
 
 	if res != nil {
 		defer res.Body.Close()
 		// we don't care about result, so just discard it to reuse connection
 		_, _ = io.Copy(ioutil.Discard, res.Body)
+		reportSummary(onProcess, res)
+		onProcess.finish()
 	}
 
 	return err
 }
 
-var _ driver.Batch = (*httpBatch)(nil)
+var (
+	_ driver.RetryableBatch  = (*httpBatch)(nil)
+	_ driver.ResettableBatch = (*httpBatch)(nil)
+)
@@ -19,24 +19,65 @@ package clickhouse
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"strconv"
 	"strings"
 )
 
 func (h *httpConnect) exec(ctx context.Context, query string, args ...interface{}) error {
+	if len(args) == 0 {
+		if ok, err := h.session.set(ctx, query, func(ctx context.Context, query string) error { return h.exec(ctx, query) }); ok {
+			return err
+		}
+	}
 	options := queryOptions(ctx)
 	query, err := bindQueryOrAppendParameters(true, &options, query, h.location, args...)
 	if err != nil {
 		return err
 	}
 
+	onProcess := options.onProcess()
 	res, err := h.sendQuery(ctx, strings.NewReader(query), &options, h.headers)
 	if res != nil {
 		defer res.Body.Close()
 		// we don't care about result, so just discard it to reuse connection
 		_, _ = io.Copy(ioutil.Discard, res.Body)
+		reportSummary(onProcess, res)
+		onProcess.finish()
 	}
 
 	return err
 }
+
+// reportSummary reports the X-ClickHouse-Summary header of res as the progress of the statement.
+func reportSummary(onProcess *onProcess, res *http.Response) {
+	if progress := summaryProgress(res.Header.Get("X-ClickHouse-Summary")); progress != nil {
+		onProcess.progress(progress)
+	}
+}
+
+// summaryProgress converts the X-ClickHouse-Summary response header into a Progress so HTTP
+// queries report the same totals as the progress packets of the native protocol.
+func summaryProgress(header string) *Progress {
+	if len(header) == 0 {
+		return nil
+	}
+	var summary map[string]string
+	if err := json.Unmarshal([]byte(header), &summary); err != nil {
+		return nil
+	}
+	value := func(key string) uint64 {
+		v, _ := strconv.ParseUint(summary[key], 10, 64)
+		return v
+	}
+	return &Progress{
+		Rows:       value("read_rows"),
+		Bytes:      value("read_bytes"),
+		TotalRows:  value("total_rows_to_read"),
+		WroteRows:  value("written_rows"),
+		WroteBytes: value("written_bytes"),
+	}
+}
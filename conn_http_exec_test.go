@@ -0,0 +1,128 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummaryProgress(t *testing.T) {
+	assert.Nil(t, summaryProgress(""))
+	assert.Nil(t, summaryProgress("not json"))
+	progress := summaryProgress(`{"read_rows":"10","read_bytes":"80","written_rows":"10","written_bytes":"80","total_rows_to_read":"10"}`)
+	if assert.NotNil(t, progress) {
+		assert.Equal(t, uint64(10), progress.Rows)
+		assert.Equal(t, uint64(80), progress.Bytes)
+		assert.Equal(t, uint64(10), progress.TotalRows)
+		assert.Equal(t, uint64(10), progress.WroteRows)
+		assert.Equal(t, uint64(80), progress.WroteBytes)
+	}
+}
+
+func TestHTTPStatistics(t *testing.T) {
+	const delay = 20 * time.Millisecond
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var (
+			query = strings.TrimSpace(string(body))
+			block proto.Block
+			buf   chproto.Buffer
+		)
+		if q := r.URL.Query().Get("query"); q != "" {
+			query = q
+		}
+		switch {
+		case query == "SELECT version()":
+			block.AddColumn("version()", "String")
+			block.Append("23.8.1")
+		case query == "SELECT timezone()":
+			block.AddColumn("timezone()", "String")
+			block.Append("UTC")
+		case strings.HasPrefix(query, "DESCRIBE TABLE"):
+			for _, name := range []string{"name", "type", "default_type", "default_expression", "comment", "codec_expression", "ttl_expression"} {
+				block.AddColumn(name, "String")
+			}
+			block.Append("id", "UInt64", "", "", "", "", "")
+		case strings.HasPrefix(query, "SELECT"):
+			block.AddColumn("number", "UInt64")
+			for i := uint64(0); i < 3; i++ {
+				block.Append(i)
+			}
+			time.Sleep(delay)
+			w.Header().Set("X-ClickHouse-Summary", `{"read_rows":"3","read_bytes":"24","total_rows_to_read":"3"}`)
+		default:
+			time.Sleep(delay)
+			w.Header().Set("X-ClickHouse-Summary", `{"written_rows":"2","written_bytes":"16"}`)
+		}
+		if len(block.Columns) != 0 {
+			block.Encode(&buf, 0)
+		}
+		w.Write(buf.Buf)
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	conn, err := dialHttp(context.Background(), addr, 1, (&Options{Protocol: HTTP, Addr: []string{addr}}).setDefaults())
+	require.NoError(t, err)
+	defer conn.close()
+	var (
+		stats Statistics
+		ctx   = Context(context.Background(), WithStatistics(&stats))
+	)
+
+	t.Run("exec", func(t *testing.T) {
+		require.NoError(t, conn.exec(ctx, "INSERT INTO t SELECT 1"))
+		assert.Equal(t, uint64(2), stats.WrittenRows)
+		assert.Equal(t, uint64(16), stats.WrittenBytes)
+		assert.GreaterOrEqual(t, stats.Elapsed, delay)
+	})
+	t.Run("query", func(t *testing.T) {
+		rows, err := conn.query(ctx, func(*connect, error) {}, "SELECT number FROM t")
+		require.NoError(t, err)
+		for rows.Next() {
+		}
+		require.NoError(t, rows.Close())
+		assert.Equal(t, uint64(3), stats.ReadRows)
+		assert.Equal(t, uint64(24), stats.ReadBytes)
+		assert.Equal(t, uint64(3), stats.TotalRowsToRead)
+		assert.Zero(t, stats.WrittenRows)
+		assert.GreaterOrEqual(t, stats.Elapsed, delay)
+	})
+	t.Run("batch", func(t *testing.T) {
+		batch, err := conn.prepareBatch(ctx, "INSERT INTO t", func(*connect, error) {}, nil)
+		require.NoError(t, err)
+		require.NoError(t, batch.Append(uint64(1)))
+		require.NoError(t, batch.Append(uint64(2)))
+		require.NoError(t, batch.Send())
+		assert.Equal(t, uint64(2), stats.WrittenRows)
+		assert.Equal(t, uint64(16), stats.WrittenBytes)
+		assert.Zero(t, stats.ReadRows)
+		assert.GreaterOrEqual(t, stats.Elapsed, delay)
+	})
+}
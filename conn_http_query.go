@@ -37,7 +37,7 @@ func (h *httpConnect) query(ctx context.Context, release func(*connect, error),
 	}
 	headers := make(map[string]string)
 	switch h.compression {
-	case CompressionZSTD, CompressionLZ4:
+	case CompressionZSTD, CompressionLZ4, CompressionLZ4HC:
 		options.settings["compress"] = "1"
 	case CompressionGZIP, CompressionDeflate, CompressionBrotli:
 		// request encoding
@@ -48,11 +48,13 @@ func (h *httpConnect) query(ctx context.Context, release func(*connect, error),
 		headers[k] = v
 	}
 
+	onProcess := options.onProcess()
 	res, err := h.sendQuery(ctx, strings.NewReader(query), &options, headers)
 	if err != nil {
-		return nil, err
+		return nil, options.resultLimits.wrap(err, 0)
 	}
 	defer res.Body.Close()
+	reportSummary(onProcess, res)
 	// detect compression from http Content-Encoding header - note user will need to have set enable_http_compression
 	// for CH to respond with compressed data - we don't set this automatically as they might not have permissions
 	var body []byte
@@ -72,6 +74,7 @@ func (h *httpConnect) query(ctx context.Context, release func(*connect, error),
 
 	if len(body) == 0 {
 		// queries with no results can get an empty body
+		onProcess.finish()
 		go func() {
 			close(stream)
 			close(errCh)
@@ -112,6 +115,8 @@ func (h *httpConnect) query(ctx context.Context, release func(*connect, error),
 			case stream <- block:
 			}
 		}
+		// the blocks are read by the time Close returns, as with the end of stream of the native protocol
+		onProcess.finish()
 		close(stream)
 		close(errCh)
 	}()
@@ -122,5 +127,7 @@ func (h *httpConnect) query(ctx context.Context, release func(*connect, error),
 		errors:    errCh,
 		columns:   block.ColumnsNames(),
 		structMap: &structMap{},
+		mapping:   options.columnMapping,
+		limits:    options.resultLimits,
 	}, nil
 }
@@ -0,0 +1,134 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ClickHouse/ch-go/compress"
+	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPCompressionLZ4HC(t *testing.T) {
+	var (
+		inserted []uint64
+		methods  []byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			params = r.URL.Query()
+			query  = params.Get("query")
+			block  proto.Block
+			buf    chproto.Buffer
+		)
+		if query == "" {
+			body, _ := io.ReadAll(r.Body)
+			query = strings.TrimSpace(string(body))
+		}
+		switch {
+		case query == "SELECT version()":
+			block.AddColumn("version()", "String")
+			block.Append("23.8.1")
+		case query == "SELECT timezone()":
+			block.AddColumn("timezone()", "String")
+			block.Append("UTC")
+		case strings.HasPrefix(query, "DESCRIBE TABLE"):
+			for _, name := range []string{"name", "type", "default_type", "default_expression", "comment", "codec_expression", "ttl_expression"} {
+				block.AddColumn(name, "String")
+			}
+			block.Append("id", "UInt64", "", "", "", "", "")
+		case strings.HasPrefix(query, "INSERT"):
+			body, _ := io.ReadAll(r.Body)
+			if params.Get("decompress") != "1" || len(body) <= compressHeaderSize {
+				http.Error(w, "expected compressed data", http.StatusBadRequest)
+				return
+			}
+			methods = append(methods, body[compressChecksumSize])
+			reader := chproto.NewReader(bytes.NewReader(body))
+			reader.EnableCompression()
+			var data proto.Block
+			if err := data.Decode(reader, 0); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			for i := 0; i < data.Rows(); i++ {
+				inserted = append(inserted, data.Columns[0].Row(i, false).(uint64))
+			}
+			return
+		default:
+			block.AddColumn("number", "UInt64")
+			for i := uint64(0); i < 3; i++ {
+				block.Append(i)
+			}
+		}
+		block.Encode(&buf, 0)
+		if params.Get("compress") == "1" {
+			writer := compress.NewWriter()
+			if err := writer.Compress(compress.LZ4, buf.Buf); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			buf.Buf = writer.Data
+		}
+		w.Write(buf.Buf)
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	opt := (&Options{
+		Protocol:    HTTP,
+		Addr:        []string{addr},
+		Compression: &Compression{Method: CompressionLZ4HC, Level: 9},
+	}).setDefaults()
+	conn, err := dialHttp(context.Background(), addr, 1, opt)
+	require.NoError(t, err)
+	defer conn.close()
+
+	t.Run("query", func(t *testing.T) {
+		rows, err := conn.query(context.Background(), func(*connect, error) {}, "SELECT number FROM t")
+		require.NoError(t, err)
+		var numbers []uint64
+		for rows.Next() {
+			var n uint64
+			require.NoError(t, rows.Scan(&n))
+			numbers = append(numbers, n)
+		}
+		require.NoError(t, rows.Close())
+		assert.Equal(t, []uint64{0, 1, 2}, numbers)
+	})
+	t.Run("batch", func(t *testing.T) {
+		batch, err := conn.prepareBatch(context.Background(), "INSERT INTO t", func(*connect, error) {}, nil)
+		require.NoError(t, err)
+		for i := uint64(1); i <= 3; i++ {
+			require.NoError(t, batch.Append(i))
+		}
+		require.NoError(t, batch.Send())
+		assert.Equal(t, []uint64{1, 2, 3}, inserted)
+		// LZ4HC blocks are framed as LZ4
+		assert.Equal(t, []byte{byte(compress.LZ4)}, methods)
+	})
+}
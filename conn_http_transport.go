@@ -0,0 +1,40 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"net/http"
+	"sync"
+)
+
+// httpTransport is the transport shared by the HTTP connections of a pool, so that their requests are
+// coalesced over the same sockets, multiplexed as streams with HTTP/2.
+type httpTransport struct {
+	once      sync.Once
+	transport *http.Transport
+}
+
+// get returns the shared transport, created by fn on first use, keeping up to maxIdleConns idle sockets per host.
+func (t *httpTransport) get(fn func() *http.Transport, maxIdleConns int) *http.Transport {
+	t.once.Do(func() {
+		t.transport = fn()
+		t.transport.MaxIdleConns = 0
+		t.transport.MaxIdleConnsPerHost = maxIdleConns
+	})
+	return t.transport
+}
@@ -0,0 +1,92 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHttp2SharedTransport(t *testing.T) {
+	var (
+		sockets int32
+		mu      sync.Mutex
+		protos  []string
+	)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		protos = append(protos, r.Proto)
+		mu.Unlock()
+		var (
+			block proto.Block
+			buf   chproto.Buffer
+		)
+		block.AddColumn("timezone()", "String")
+		block.Append("UTC")
+		block.Encode(&buf, 0)
+		w.Write(buf.Buf)
+	}))
+	server.EnableHTTP2 = true
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&sockets, 1)
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "https://")
+	opt := (&Options{
+		Protocol: HTTP,
+		Addr:     []string{addr},
+		TLS:      &tls.Config{InsecureSkipVerify: true},
+		Http2:    true,
+	}).setDefaults()
+	var conns []*httpConnect
+	for i := 0; i < 3; i++ {
+		conn, err := dialHttp(context.Background(), addr, 2, opt)
+		require.NoError(t, err)
+		conns = append(conns, conn)
+	}
+	for _, conn := range conns {
+		assert.True(t, conn.sharedTransport)
+		assert.Same(t, conns[0].client.Transport, conn.client.Transport)
+	}
+	for _, conn := range conns {
+		require.NoError(t, conn.exec(context.Background(), "SELECT 1"))
+		require.NoError(t, conn.close())
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&sockets))
+	mu.Lock()
+	defer mu.Unlock()
+	for _, proto := range protos {
+		assert.Equal(t, "HTTP/2.0", proto)
+	}
+}
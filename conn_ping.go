@@ -47,7 +47,7 @@ func (c *connect) ping(ctx context.Context) (err error) {
 
 	var packet byte
 	for {
-		if packet, err = c.reader.ReadByte(); err != nil {
+		if packet, err = c.readPacket(); err != nil {
 			return err
 		}
 		switch packet {
@@ -19,17 +19,29 @@ package clickhouse
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
 	"io"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
 )
 
 type onProcess struct {
+	end           func()
 	data          func(*proto.Block)
 	logs          func([]Log)
 	progress      func(*Progress)
 	profileInfo   func(*ProfileInfo)
 	profileEvents func([]ProfileEvent)
+	// insertProgress is called by the batches, see WithInsertProgress
+	insertProgress func(*InsertProgress)
+}
+
+func (on *onProcess) finish() {
+	if on.end != nil {
+		on.end()
+	}
 }
 
 func (c *connect) firstBlock(ctx context.Context, on *onProcess) (*proto.Block, error) {
@@ -40,7 +52,7 @@ func (c *connect) firstBlock(ctx context.Context, on *onProcess) (*proto.Block,
 			return nil, ctx.Err()
 		default:
 		}
-		packet, err := c.reader.ReadByte()
+		packet, err := c.readPacket()
 		if err != nil {
 			return nil, err
 		}
@@ -49,6 +61,7 @@ func (c *connect) firstBlock(ctx context.Context, on *onProcess) (*proto.Block,
 			return c.readData(ctx, packet, true)
 		case proto.ServerEndOfStream:
 			c.debugf("[end of stream]")
+			on.finish()
 			return nil, io.EOF
 		default:
 			if err := c.handle(ctx, packet, on); err != nil {
@@ -67,7 +80,7 @@ func (c *connect) process(ctx context.Context, on *onProcess) error {
 		default:
 		}
 		c.rwLock.Lock()
-		packet, err := c.reader.ReadByte()
+		packet, err := c.readPacket()
 		c.rwLock.Unlock()
 		if err != nil {
 			return err
@@ -75,6 +88,7 @@ func (c *connect) process(ctx context.Context, on *onProcess) error {
 		switch packet {
 		case proto.ServerEndOfStream:
 			c.debugf("[end of stream]")
+			on.finish()
 			return nil
 		}
 		if err := c.handle(ctx, packet, on); err != nil {
@@ -131,6 +145,11 @@ func (c *connect) handle(ctx context.Context, packet byte, on *onProcess) error
 		c.debugf("[progress] %s", progress)
 		on.progress(progress)
 	default:
+		if c.opt.UnexpectedPackets == UnexpectedPacketSkip {
+			if skipped, err := c.skipPacket(packet); skipped {
+				return err
+			}
+		}
 		return &OpError{
 			Op:  "process",
 			Err: fmt.Errorf("unexpected packet %d", packet),
@@ -139,13 +158,93 @@ func (c *connect) handle(ctx context.Context, packet byte, on *onProcess) error
 	return nil
 }
 
+// skipPacket discards the payload of a packet the client does not handle.
+// It returns false if the packet layout is unknown and the stream cannot be recovered.
+func (c *connect) skipPacket(packet byte) (bool, error) {
+	switch packet {
+	case proto.ServerPong, proto.ServerReadTaskRequest, proto.ServerTreeReadTaskRequest:
+		// no payload
+	case proto.ServerPartUUIDs:
+		n, err := c.reader.UVarInt()
+		if err != nil {
+			return true, err
+		}
+		// UUIDs are 16 bytes each
+		if _, err := c.reader.ReadRaw(int(n) * 16); err != nil {
+			return true, err
+		}
+	default:
+		return false, nil
+	}
+	c.debugf("[process] skipped unexpected packet %d", packet)
+	return true, nil
+}
+
 func (c *connect) cancel() error {
 	c.debugf("[cancel]")
 	c.buffer.PutUVarInt(proto.ClientCancel)
 	wErr := c.flush()
-	// don't reuse a cancelled query as we don't drain the connection
+	if wErr == nil && c.opt.CancelDrainTimeout > 0 {
+		if err := c.drain(c.opt.CancelDrainTimeout); err == nil {
+			c.drained = true
+			return nil
+		} else {
+			c.debugf("[cancel] drain error: %v", err)
+		}
+	}
+	// don't reuse a cancelled query that could not be drained
 	if cErr := c.close(); cErr != nil {
 		return cErr
 	}
 	return wErr
 }
+
+// discardProcess returns the handlers of the packets of the queries the client issues itself.
+func discardProcess() *onProcess {
+	return &onProcess{
+		logs:          func([]Log) {},
+		progress:      func(*Progress) {},
+		profileInfo:   func(*ProfileInfo) {},
+		profileEvents: func([]ProfileEvent) {},
+	}
+}
+
+// drain discards the packets of a cancelled query until the server ends it, so the connection can be reused.
+func (c *connect) drain(timeout time.Duration) error {
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	defer c.conn.SetDeadline(time.Time{})
+	var (
+		ctx     = context.Background()
+		discard = discardProcess()
+	)
+	for {
+		packet, err := c.readPacket()
+		if err != nil {
+			return err
+		}
+		switch packet {
+		case proto.ServerEndOfStream:
+			c.debugf("[cancel] drained")
+			return nil
+		case proto.ServerException:
+			// an exception ends the query as well
+			if err := c.exception(); err != nil {
+				var exception *Exception
+				if errors.As(err, &exception) {
+					c.debugf("[cancel] drained with exception: %v", err)
+					return nil
+				}
+				return err
+			}
+			return nil
+		}
+		if err := c.handle(ctx, packet, discard); err != nil {
+			return err
+		}
+	}
+}
+
+// isDrainedCancel reports whether err is the cancellation of a query whose connection was drained and is reusable.
+func (c *connect) isDrainedCancel(err error) bool {
+	return c.drained && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,55 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleUnexpectedPackets(t *testing.T) {
+	var buf chproto.Buffer
+	buf.PutUVarInt(2)
+	buf.PutRaw(make([]byte, 32))
+	buf.PutByte(0xff)
+	newConn := func(policy UnexpectedPacketPolicy) *connect {
+		return &connect{
+			opt:    &Options{UnexpectedPackets: policy},
+			reader: chproto.NewReader(bytes.NewReader(buf.Buf)),
+			debugf: func(string, ...interface{}) {},
+		}
+	}
+	ctx := context.Background()
+
+	conn := newConn(UnexpectedPacketError)
+	assert.Error(t, conn.handle(ctx, proto.ServerPartUUIDs, &onProcess{}))
+
+	conn = newConn(UnexpectedPacketSkip)
+	require.NoError(t, conn.handle(ctx, proto.ServerPartUUIDs, &onProcess{}))
+	require.NoError(t, conn.handle(ctx, proto.ServerPong, &onProcess{}))
+	next, err := conn.reader.ReadByte()
+	require.NoError(t, err)
+	assert.Equal(t, byte(0xff), next)
+	assert.Error(t, conn.handle(ctx, 0xfe, &onProcess{}))
+}
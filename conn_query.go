@@ -40,12 +40,17 @@ func (c *connect) query(ctx context.Context, release func(*connect, error), quer
 
 	// set a read deadline - alternative to context.Read operation will fail if no data is received after deadline.
 	c.conn.SetReadDeadline(time.Now().Add(c.readTimeout))
-	defer c.conn.SetReadDeadline(time.Time{})
-	// context level deadlines override any read deadline
-	if deadline, ok := ctx.Deadline(); ok {
+	// context level deadlines override any read deadline, and also bound the reads of the rest of the result
+	deadline, hasDeadline := options.deadline(ctx)
+	if hasDeadline {
 		c.conn.SetDeadline(deadline)
-		defer c.conn.SetDeadline(time.Time{})
 	}
+	streaming := false
+	defer func() {
+		if !streaming || !hasDeadline {
+			c.conn.SetDeadline(time.Time{})
+		}
+	}()
 
 	if err = c.sendQuery(body, &options); err != nil {
 		release(c, err)
@@ -55,6 +60,7 @@ func (c *connect) query(ctx context.Context, release func(*connect, error), quer
 	init, err := c.firstBlock(ctx, onProcess)
 
 	if err != nil {
+		err = options.resultLimits.wrap(options.timeoutError(ctx, err), 0)
 		c.debugf("[query] first block error: %v", err)
 		release(c, err)
 		return nil, err
@@ -69,16 +75,33 @@ func (c *connect) query(ctx context.Context, release func(*connect, error), quer
 		stream = make(chan *proto.Block, bufferSize)
 	)
 
+	streaming = true
+	var spooled *spool
+	if options.spool != nil {
+		spooled = c.newSpool(*options.spool, c.newBlock(ctx))
+		go spooled.feed(stream)
+	}
 	go func() {
 		onProcess.data = func(b *proto.Block) {
 			stream <- b
 		}
+		if spooled != nil {
+			onProcess.data = spooled.push
+		}
 		err := c.process(ctx, onProcess)
+		if hasDeadline {
+			c.conn.SetDeadline(time.Time{})
+		}
 		if err != nil {
+			err = options.timeoutError(ctx, err)
 			c.debugf("[query] process error: %v", err)
 			errors <- err
 		}
-		close(stream)
+		if spooled != nil {
+			spooled.finish()
+		} else {
+			close(stream)
+		}
 		close(errors)
 		release(c, err)
 	}()
@@ -89,6 +112,9 @@ func (c *connect) query(ctx context.Context, release func(*connect, error), quer
 		errors:    errors,
 		columns:   init.ColumnsNames(),
 		structMap: c.structMap,
+		mapping:   options.columnMapping,
+		limits:    options.resultLimits,
+		spool:     spooled,
 	}, nil
 }
 
@@ -18,16 +18,30 @@
 package clickhouse
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
 )
 
 // Connection::sendQuery
 // https://github.com/ClickHouse/ClickHouse/blob/master/src/Client/Connection.cpp
 func (c *connect) sendQuery(body string, o *QueryOptions) error {
+	if err := c.useDatabase(o.database); err != nil {
+		return err
+	}
 	c.rwLock.Lock()
 	defer c.rwLock.Unlock()
 
-	c.debugf("[send query] compression=%q %s", c.compression, body)
+	c.blockCompression = c.compression
+	if o.compression != nil {
+		c.blockCompression = *o.compression
+	}
+	c.limiter = c.bandwidth
+	if o.bandwidthLimit > 0 {
+		c.limiter = newBandwidthLimiter(o.bandwidthLimit)
+	}
+	c.debugf("[send query] compression=%q %s", c.blockCompression.Method, body)
 	c.buffer.PutByte(proto.ClientQuery)
 	q := proto.Query{
 		ClientName:     c.opt.ClientInfo.String(),
@@ -36,11 +50,14 @@ func (c *connect) sendQuery(body string, o *QueryOptions) error {
 		Body:           body,
 		Span:           o.span,
 		QuotaKey:       o.quotaKey,
-		Compression:    c.compression != CompressionNone,
+		Compression:    c.blockCompression.Method != CompressionNone,
 		InitialAddress: c.conn.LocalAddr().String(),
 		Settings:       c.settings(o.settings),
 		Parameters:     parametersToProtoParameters(o.parameters),
 	}
+	if comment, ok := o.logComment(); ok {
+		q.Settings = append(q.Settings, proto.Setting{Key: "log_comment", Value: comment})
+	}
 	if err := q.Encode(c.buffer, c.revision); err != nil {
 		return err
 	}
@@ -55,6 +72,50 @@ func (c *connect) sendQuery(body string, o *QueryOptions) error {
 	return c.flush()
 }
 
+// useDatabase switches the current database of the connection to database, see WithDatabase, or back to
+// the database of the handshake if empty. The native protocol has no database per query: the switch
+// costs a round trip, only when the database of the queries of the connection changes.
+func (c *connect) useDatabase(database string) error {
+	if database == c.database {
+		return nil
+	}
+	if len(c.database) == 0 && len(c.opt.Auth.Database) == 0 && len(c.defaultDatabase) == 0 {
+		// the database of the user, resolved before leaving it
+		if err := c.sendQuery("SELECT currentDatabase()", &QueryOptions{}); err != nil {
+			return err
+		}
+		on := discardProcess()
+		on.data = func(block *proto.Block) {
+			if len(block.Columns) == 1 && block.Rows() == 1 {
+				c.defaultDatabase = fmt.Sprint(block.Columns[0].Row(0, false))
+			}
+		}
+		if err := c.process(context.Background(), on); err != nil {
+			return err
+		}
+	}
+	target := database
+	if len(target) == 0 {
+		target = c.opt.Auth.Database
+	}
+	if len(target) == 0 {
+		target = c.defaultDatabase
+	}
+	c.debugf("[use database] %s", target)
+	// the USE statement runs in the current database
+	previous := c.database
+	c.database = database
+	if err := c.sendQuery("USE "+quoteIdentifier(target), &QueryOptions{database: database}); err != nil {
+		c.database = previous
+		return err
+	}
+	if err := c.process(context.Background(), discardProcess()); err != nil {
+		c.database = previous
+		return err
+	}
+	return nil
+}
+
 func parametersToProtoParameters(parameters Parameters) (s proto.Parameters) {
 	for k, v := range parameters {
 		s = append(s, proto.Parameter{
@@ -0,0 +1,70 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingConn replays the bytes of a server and records the bytes written to it.
+type recordingConn struct {
+	replayConn
+	written bytes.Buffer
+}
+
+func (c *recordingConn) Write(p []byte) (int, error) {
+	c.written.Write(p)
+	return c.replayConn.Write(p)
+}
+
+func TestWithDatabase(t *testing.T) {
+	server := &recordingConn{replayConn: replayConn{data: fakeServer(
+		proto.ServerEndOfStream, // USE analytics
+		proto.ServerEndOfStream,
+		proto.ServerEndOfStream,
+		proto.ServerEndOfStream, // USE default
+		proto.ServerEndOfStream,
+	)}}
+	opt := (&Options{
+		Auth: Auth{Database: "default"},
+		DialContext: func(context.Context, string) (net.Conn, error) {
+			return server, nil
+		},
+	}).setDefaults()
+	conn, err := dial(context.Background(), "127.0.0.1:9000", 1, opt)
+	require.NoError(t, err)
+
+	analytics := Context(context.Background(), WithDatabase("analytics"))
+	require.NoError(t, conn.exec(analytics, "INSERT INTO events SELECT 1"))
+	assert.Equal(t, "analytics", conn.database)
+	require.NoError(t, conn.exec(analytics, "INSERT INTO events SELECT 2"))
+	require.NoError(t, conn.exec(context.Background(), "INSERT INTO events SELECT 3"))
+	assert.Empty(t, conn.database)
+
+	assert.Equal(t, 1, bytes.Count(server.written.Bytes(), []byte("USE analytics")))
+	assert.Equal(t, 1, bytes.Count(server.written.Bytes(), []byte("USE default")))
+	assert.Less(t, bytes.Index(server.written.Bytes(), []byte("USE analytics")), bytes.Index(server.written.Bytes(), []byte("SELECT 1")))
+	assert.Less(t, bytes.Index(server.written.Bytes(), []byte("USE default")), bytes.Index(server.written.Bytes(), []byte("SELECT 3")))
+}
@@ -0,0 +1,55 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"net"
+)
+
+// SocketOptions tunes the TCP connections of the default dialer, e.g. larger buffers for bulk
+// inserts over high-latency links.
+type SocketOptions struct {
+	// ReadBuffer and WriteBuffer are the socket receive and send buffer sizes in bytes, the kernel
+	// default if zero.
+	ReadBuffer  int
+	WriteBuffer int
+	// Nagle enables Nagle's algorithm, i.e. clears TCP_NODELAY which is set by default.
+	Nagle bool
+	// DSCP marks the packets with a Differentiated Services code point (0-63), e.g. 10 for AF11.
+	DSCP uint8
+}
+
+// apply sets the options of a connected socket.
+func (s SocketOptions) apply(conn *net.TCPConn) error {
+	if s.Nagle {
+		if err := conn.SetNoDelay(false); err != nil {
+			return err
+		}
+	}
+	if s.ReadBuffer > 0 {
+		if err := conn.SetReadBuffer(s.ReadBuffer); err != nil {
+			return err
+		}
+	}
+	if s.WriteBuffer > 0 {
+		if err := conn.SetWriteBuffer(s.WriteBuffer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd || solaris || illumos
+// +build linux darwin dragonfly freebsd netbsd openbsd solaris illumos
+
+package clickhouse
+
+import (
+	"strings"
+	"syscall"
+)
+
+// control marks the packets of a socket with DSCP before it connects.
+func (s SocketOptions) control(network, address string, c syscall.RawConn) error {
+	if s.DSCP == 0 || !strings.HasPrefix(network, "tcp") {
+		return nil
+	}
+	var (
+		tos    = int(s.DSCP) << 2
+		sysErr error
+	)
+	err := c.Control(func(fd uintptr) {
+		switch network {
+		case "tcp6":
+			sysErr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS, tos)
+		default:
+			sysErr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TOS, tos)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return sysErr
+}
@@ -0,0 +1,45 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialSocketOptions(t *testing.T) {
+	addr := listenLocal(t)
+	conn, err := dialNetwork(context.Background(), addr, &Options{
+		DialTimeout: time.Second,
+		Socket: SocketOptions{
+			ReadBuffer:  1 << 20,
+			WriteBuffer: 1 << 20,
+			Nagle:       true,
+			DSCP:        10,
+		},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	_, ok := conn.(*net.TCPConn)
+	assert.True(t, ok)
+}
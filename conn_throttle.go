@@ -0,0 +1,64 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import "time"
+
+// bandwidthLimiter paces writes to a number of bytes per second.
+type bandwidthLimiter struct {
+	rate  int
+	start time.Time
+	sent  int
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func newBandwidthLimiter(rate int) *bandwidthLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &bandwidthLimiter{
+		rate:  rate,
+		now:   time.Now,
+		sleep: time.Sleep,
+	}
+}
+
+// chunk is the largest write the limiter paces at once, a tenth of a second worth of bytes.
+func (l *bandwidthLimiter) chunk() int {
+	if chunk := l.rate / 10; chunk > 0 {
+		return chunk
+	}
+	return 1
+}
+
+// wait blocks until n more bytes may be written. Unused bandwidth is not saved up while idle.
+func (l *bandwidthLimiter) wait(n int) {
+	now := l.now()
+	if l.start.IsZero() || now.After(l.start.Add(l.duration(l.sent))) {
+		l.start, l.sent = now, 0
+	}
+	if d := l.start.Add(l.duration(l.sent)).Sub(now); d > 0 {
+		l.sleep(d)
+	}
+	l.sent += n
+}
+
+func (l *bandwidthLimiter) duration(bytes int) time.Duration {
+	return time.Duration(float64(bytes) / float64(l.rate) * float64(time.Second))
+}
@@ -0,0 +1,52 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBandwidthLimiter(t *testing.T) {
+	assert.Nil(t, newBandwidthLimiter(0))
+	var (
+		now     = time.Unix(0, 0)
+		slept   time.Duration
+		limiter = newBandwidthLimiter(1000)
+	)
+	limiter.now = func() time.Time { return now }
+	limiter.sleep = func(d time.Duration) {
+		slept += d
+		now = now.Add(d)
+	}
+	assert.Equal(t, 100, limiter.chunk())
+	for i := 0; i < 5; i++ {
+		limiter.wait(limiter.chunk())
+	}
+	// the first chunk is sent immediately, the next four wait 100ms each
+	assert.Equal(t, 400*time.Millisecond, slept)
+
+	// bandwidth is not saved up while idle
+	now = now.Add(time.Minute)
+	slept = 0
+	limiter.wait(100)
+	limiter.wait(100)
+	assert.Equal(t, 100*time.Millisecond, slept)
+}
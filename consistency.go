@@ -0,0 +1,117 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ConsistencyToken gives the reads of a client a consistent view of its own writes when reads and writes are
+// served by separate compute groups, the ReadAddr of Options or the read-only services of ClickHouse Cloud
+// compute-compute separation. Pass it to the writes and to the reads with WithConsistencyToken: for Window
+// after a successful write, the reads carrying the token are routed to the write pool, which took the write
+// and is warm, and run with select_sequential_consistency so that they see all the parts written before.
+// The token can be passed to another process with String and ParseConsistencyToken. It is safe for
+// concurrent use.
+type ConsistencyToken struct {
+	// Window is how long the reads are made consistent after a write. Default 1 minute.
+	Window  time.Duration
+	mu      sync.Mutex
+	written time.Time
+}
+
+// ParseConsistencyToken parses a token returned by ConsistencyToken.String.
+func ParseConsistencyToken(s string) (*ConsistencyToken, error) {
+	token := &ConsistencyToken{}
+	if len(s) == 0 {
+		return token, nil
+	}
+	written, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil, err
+	}
+	token.written = written
+	return token, nil
+}
+
+// String returns the time of the last write of the token, empty if none.
+func (t *ConsistencyToken) String() string {
+	written := t.Written()
+	if written.IsZero() {
+		return ""
+	}
+	return written.Format(time.RFC3339Nano)
+}
+
+// Written returns the time of the last successful write carrying the token.
+func (t *ConsistencyToken) Written() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.written
+}
+
+// fresh reports whether the reads carrying the token must be consistent.
+func (t *ConsistencyToken) fresh() bool {
+	if t == nil {
+		return false
+	}
+	window := t.Window
+	if window <= 0 {
+		window = time.Minute
+	}
+	written := t.Written()
+	return !written.IsZero() && time.Since(written) < window
+}
+
+func (t *ConsistencyToken) observe() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.written = time.Now()
+}
+
+// WithConsistencyToken makes the reads of the context consistent with its writes, see ConsistencyToken.
+func WithConsistencyToken(token *ConsistencyToken) QueryOption {
+	return func(o *QueryOptions) error {
+		o.consistency = token
+		return nil
+	}
+}
+
+// consistentRead returns the context of query, with select_sequential_consistency if it is a read carrying
+// a token with recent writes.
+func consistentRead(ctx context.Context, query string) context.Context {
+	options := queryOptions(ctx)
+	if !options.consistency.fresh() || StatementKind(query) != StatementRead {
+		return ctx
+	}
+	settings := make(Settings, len(options.settings)+1)
+	for k, v := range options.settings {
+		settings[k] = v
+	}
+	settings["select_sequential_consistency"] = 1
+	return Context(ctx, WithSettings(settings))
+}
+
+// observeWrite records a successful write carrying a token, see ConsistencyToken.
+func observeWrite(ctx context.Context, query string, err error) {
+	if token := queryOptions(ctx).consistency; token != nil && err == nil && StatementKind(query) != StatementRead {
+		token.observe()
+	}
+}
@@ -0,0 +1,84 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsistencyToken(t *testing.T) {
+	token := &ConsistencyToken{}
+	assert.False(t, token.fresh())
+	assert.Empty(t, token.String())
+
+	ctx := Context(context.Background(), WithConsistencyToken(token))
+	observeWrite(ctx, "INSERT INTO t VALUES", errors.New("failed"))
+	assert.False(t, token.fresh())
+	observeWrite(ctx, "SELECT 1", nil)
+	assert.False(t, token.fresh())
+	observeWrite(ctx, "INSERT INTO t VALUES", nil)
+	assert.True(t, token.fresh())
+
+	parsed, err := ParseConsistencyToken(token.String())
+	require.NoError(t, err)
+	assert.True(t, parsed.Written().Equal(token.Written()))
+	parsed.Window = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	assert.False(t, parsed.fresh())
+
+	_, err = ParseConsistencyToken("not a token")
+	assert.Error(t, err)
+	var none *ConsistencyToken
+	assert.False(t, none.fresh())
+}
+
+func TestConsistentRead(t *testing.T) {
+	token := &ConsistencyToken{}
+	ctx := Context(context.Background(), WithConsistencyToken(token), WithSettings(Settings{"max_threads": 1}))
+	assert.NotContains(t, queryOptions(consistentRead(ctx, "SELECT 1")).settings, "select_sequential_consistency")
+
+	observeWrite(ctx, "INSERT INTO t VALUES", nil)
+	settings := queryOptions(consistentRead(ctx, "SELECT 1")).settings
+	assert.Equal(t, 1, settings["select_sequential_consistency"])
+	assert.Equal(t, 1, settings["max_threads"])
+	assert.NotContains(t, queryOptions(ctx).settings, "select_sequential_consistency")
+	assert.NotContains(t, queryOptions(consistentRead(ctx, "INSERT INTO t VALUES")).settings, "select_sequential_consistency")
+}
+
+func TestConsistencyRouting(t *testing.T) {
+	conn, err := Open(&Options{
+		Addr:     []string{"writer:9000"},
+		ReadAddr: []string{"reader:9000"},
+	})
+	require.NoError(t, err)
+	var (
+		ch    = conn.(*clickhouse)
+		token = &ConsistencyToken{}
+		ctx   = Context(context.Background(), WithConsistencyToken(token))
+	)
+	assert.Same(t, ch.reader, ch.pool(ctx, "SELECT 1"))
+	observeWrite(ctx, "INSERT INTO t VALUES", nil)
+	assert.Same(t, ch, ch.pool(ctx, "SELECT 1"))
+	assert.Same(t, ch.reader, ch.pool(Context(ctx, WithConsistencyToken(token), WithRoute(RouteRead)), "SELECT 1"))
+}
@@ -19,6 +19,8 @@ package clickhouse
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2/ext"
@@ -43,17 +45,37 @@ type (
 		}
 		queryID  string
 		quotaKey string
+		database string
 		events   struct {
-			logs          func(*Log)
-			progress      func(*Progress)
-			profileInfo   func(*ProfileInfo)
-			profileEvents func([]ProfileEvent)
+			logs           func(*Log)
+			progress       func(*Progress)
+			profileInfo    func(*ProfileInfo)
+			profileEvents  func([]ProfileEvent)
+			insertProgress func(*InsertProgress)
 		}
-		settings        Settings
-		parameters      Parameters
-		external        []*ext.Table
-		blockBufferSize uint8
-		userLocation    *time.Location
+		statistics       *Statistics
+		columnMapping    *ColumnMapping
+		nullStrategy     NullStrategy
+		columnConverters map[string]ColumnConverter
+		metadata         Metadata
+		labels           Labels
+		tagSpan          bool
+		compression      *Compression
+		bandwidthLimit   int
+		queryTimeout     time.Duration
+		resultLimits     *ResultLimits
+		parallelReplicas *ParallelReplicasReport
+		sampling         *sampling
+		spool            *SpoolOptions
+		route            Route
+		consistency      *ConsistencyToken
+		settings         Settings
+		parameters       Parameters
+		external         []*ext.Table
+		blockBufferSize  uint8
+		userLocation     *time.Location
+		// err is the first error of the options passed to Context, returned by the statements run with it
+		err error
 	}
 )
 
@@ -71,6 +93,17 @@ func WithQueryID(queryID string) QueryOption {
 	}
 }
 
+// WithDatabase runs the query in database instead of the database of the connection, without qualifying
+// the tables of the query. HTTP and gRPC send it along with the query. The native protocol has no database
+// per query: the connection switches with a USE statement, a round trip taken only when the database of
+// the queries of the connection changes.
+func WithDatabase(database string) QueryOption {
+	return func(o *QueryOptions) error {
+		o.database = database
+		return nil
+	}
+}
+
 func WithBlockBufferSize(size uint8) QueryOption {
 	return func(o *QueryOptions) error {
 		o.blockBufferSize = size
@@ -113,6 +146,25 @@ func WithProgress(fn func(*Progress)) QueryOption {
 	}
 }
 
+// decorateContext applies decorate, see Options.DecorateContext, to ctx.
+func decorateContext(decorate func(context.Context) context.Context, ctx context.Context) context.Context {
+	if decorate == nil {
+		return ctx
+	}
+	return decorate(ctx)
+}
+
+// WithInsertProgress reports the progress of the batches prepared with the context over the native protocol:
+// fn is called after each block of rows is written to the server, with every Progress packet the server
+// acknowledges the written rows with, and once the server has completed the insert. The server reports
+// progress while the insert is being completed, after the last block was sent.
+func WithInsertProgress(fn func(*InsertProgress)) QueryOption {
+	return func(o *QueryOptions) error {
+		o.events.insertProgress = fn
+		return nil
+	}
+}
+
 func WithProfileInfo(fn func(*ProfileInfo)) QueryOption {
 	return func(o *QueryOptions) error {
 		o.events.profileInfo = fn
@@ -127,6 +179,74 @@ func WithProfileEvents(fn func([]ProfileEvent)) QueryOption {
 	}
 }
 
+// WithoutCompression sends and receives the data blocks of the query uncompressed over the native
+// protocol, regardless of the connection compression. It saves client CPU on fast links, for example
+// for inserts into columns whose codecs compress the data again on the server anyway.
+func WithoutCompression() QueryOption {
+	return func(o *QueryOptions) error {
+		o.compression = &Compression{Method: CompressionNone}
+		return nil
+	}
+}
+
+// WithCompression sets the native protocol compression of the data blocks of the query, overriding the
+// connection compression. level applies to CompressionLZ4HC (1-12) and CompressionZSTD (1-22), zero
+// meaning the default level.
+func WithCompression(method CompressionMethod, level int) QueryOption {
+	return func(o *QueryOptions) error {
+		c := Compression{Method: method, Level: level}
+		if err := nativeCompression(c); err != nil {
+			return err
+		}
+		o.compression = &c
+		return nil
+	}
+}
+
+// WithBandwidthLimit limits the data sent for the query, e.g. the blocks of a batch, to bytesPerSecond
+// over the native protocol, overriding the connection BandwidthLimit.
+func WithBandwidthLimit(bytesPerSecond int) QueryOption {
+	return func(o *QueryOptions) error {
+		o.bandwidthLimit = bytesPerSecond
+		return nil
+	}
+}
+
+// Route selects the endpoints of a query when Options.ReadAddr is configured.
+type Route uint8
+
+const (
+	// RouteAuto sends reads (see StatementKind) to ReadAddr and everything else to Addr.
+	RouteAuto Route = iota
+	RouteRead
+	RouteWrite
+)
+
+// WithRoute overrides the statement based routing between Addr and ReadAddr, e.g. to read
+// from the write endpoints right after an insert.
+func WithRoute(route Route) QueryOption {
+	return func(o *QueryOptions) error {
+		o.route = route
+		return nil
+	}
+}
+
+// WithStatistics collects the statistics of the query into stats.
+func WithStatistics(stats *Statistics) QueryOption {
+	return func(o *QueryOptions) error {
+		o.statistics = stats
+		return nil
+	}
+}
+
+// WithColumnMapping sets how columns are matched to struct fields by ScanStruct, Select and AppendStruct.
+func WithColumnMapping(mapping ColumnMapping) QueryOption {
+	return func(o *QueryOptions) error {
+		o.columnMapping = &mapping
+		return nil
+	}
+}
+
 func WithExternalTable(t ...*ext.Table) QueryOption {
 	return func(o *QueryOptions) error {
 		o.external = append(o.external, t...)
@@ -148,17 +268,33 @@ func WithUserLocation(location *time.Location) QueryOption {
 	}
 }
 
+// Context returns a copy of parent carrying the query options. An option which fails, e.g. WithCompression
+// with an unsupported method, is not applied and its error is returned by the statements run with the context.
 func Context(parent context.Context, options ...QueryOption) context.Context {
 	opt := queryOptions(parent)
 	for _, f := range options {
-		f(&opt)
+		if err := f(&opt); err != nil && opt.err == nil {
+			opt.err = fmt.Errorf("clickhouse: invalid query option: %w", err)
+		}
+	}
+	if opt.tagSpan {
+		opt.tagSpan = false
+		tagSpan(parent, opt.metadata)
 	}
 	return context.WithValue(parent, _contextOptionKey, opt)
 }
 
+// contextError returns the error of the query options of ctx, see Context.
+func contextError(ctx context.Context) error {
+	if o, ok := ctx.Value(_contextOptionKey).(QueryOptions); ok {
+		return o.err
+	}
+	return nil
+}
+
 func queryOptions(ctx context.Context) QueryOptions {
 	if o, ok := ctx.Value(_contextOptionKey).(QueryOptions); ok {
-		if deadline, ok := ctx.Deadline(); ok {
+		if deadline, ok := ctx.Deadline(); ok && o.queryTimeout == 0 {
 			if sec := time.Until(deadline).Seconds(); sec > 1 {
 				o.settings["max_execution_time"] = int(sec + 5)
 			}
@@ -171,8 +307,29 @@ func queryOptions(ctx context.Context) QueryOptions {
 }
 
 func (q *QueryOptions) onProcess() *onProcess {
+	var (
+		stats    = q.statistics
+		replicas = q.parallelReplicas
+		start    = time.Now()
+	)
+	if stats != nil {
+		stats.reset()
+	}
+	if replicas != nil {
+		replicas.reset()
+	}
 	return &onProcess{
+		end: func() {
+			if stats != nil {
+				stats.Elapsed = time.Since(start)
+			}
+		},
 		logs: func(logs []Log) {
+			if replicas != nil {
+				for _, l := range logs {
+					replicas.addHost(l.Hostname)
+				}
+			}
 			if q.events.logs != nil {
 				for _, l := range logs {
 					q.events.logs(&l)
@@ -180,19 +337,58 @@ func (q *QueryOptions) onProcess() *onProcess {
 			}
 		},
 		progress: func(p *Progress) {
+			if stats != nil {
+				stats.addProgress(p)
+			}
 			if q.events.progress != nil {
 				q.events.progress(p)
 			}
 		},
 		profileInfo: func(p *ProfileInfo) {
+			if stats != nil {
+				stats.addProfileInfo(p)
+			}
 			if q.events.profileInfo != nil {
 				q.events.profileInfo(p)
 			}
 		},
 		profileEvents: func(events []ProfileEvent) {
+			if replicas != nil {
+				replicas.addProfileEvents(events)
+			}
 			if q.events.profileEvents != nil {
 				q.events.profileEvents(events)
 			}
 		},
+		insertProgress: q.events.insertProgress,
+	}
+}
+
+// settingEnabled reports whether any of keys is set to a true value in the query settings, or
+// otherwise in the connection settings.
+func settingEnabled(conn, query Settings, keys ...string) bool {
+	for _, key := range keys {
+		value, ok := query[key]
+		if !ok {
+			value, ok = conn[key]
+		}
+		if !ok {
+			continue
+		}
+		switch v := value.(type) {
+		case bool:
+			if v {
+				return true
+			}
+		case int:
+			if v != 0 {
+				return true
+			}
+		case string:
+			if v == "1" || strings.EqualFold(v, "true") {
+				return true
+			}
+		}
 	}
+	return false
 }
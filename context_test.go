@@ -2,9 +2,17 @@ package clickhouse
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
+	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestContext(t *testing.T) {
@@ -33,3 +41,101 @@ func TestContext(t *testing.T) {
 		},
 	)
 }
+
+func TestContextOptionError(t *testing.T) {
+	ctx := Context(context.Background(), WithQueryID("a"), WithCompression(CompressionGZIP, 0))
+	ctx = Context(ctx, WithQuotaKey("b"))
+	require.ErrorContains(t, contextError(ctx), "unsupported compression method gzip")
+	assert.Nil(t, queryOptions(ctx).compression)
+
+	ch := &clickhouse{opt: (&Options{}).setDefaults()}
+	err := ch.intercept(ctx, &Operation{Kind: OperationExec, Query: "SELECT 1"}, func(context.Context, *Operation) error {
+		t.Fatal("the statement must not run")
+		return nil
+	})
+	assert.ErrorContains(t, err, "invalid query option")
+
+	std := &stdDriver{opt: (&Options{}).setDefaults(), debugf: func(string, ...interface{}) {}}
+	assert.ErrorContains(t, std.Exec(ctx, "SELECT 1"), "invalid query option")
+	_, err = std.QueryContext(ctx, "SELECT 1", nil)
+	assert.ErrorContains(t, err, "invalid query option")
+}
+
+func TestStatistics(t *testing.T) {
+	var stats Statistics
+	opts := queryOptions(Context(context.Background(), WithStatistics(&stats)))
+	on := opts.onProcess()
+	on.progress(&Progress{Rows: 10, Bytes: 80, TotalRows: 20, WroteRows: 1, WroteBytes: 8})
+	on.progress(&Progress{Rows: 10, Bytes: 80})
+	on.profileInfo(&ProfileInfo{Rows: 5, Bytes: 40, Blocks: 1})
+	on.finish()
+	assert.Equal(t, uint64(20), stats.ReadRows)
+	assert.Equal(t, uint64(160), stats.ReadBytes)
+	assert.Equal(t, uint64(20), stats.TotalRowsToRead)
+	assert.Equal(t, uint64(1), stats.WrittenRows)
+	assert.Equal(t, uint64(8), stats.WrittenBytes)
+	assert.Equal(t, uint64(5), stats.ResultRows)
+	assert.NotZero(t, stats.Elapsed)
+}
+
+func TestSettingEnabled(t *testing.T) {
+	const key = "input_format_null_as_default"
+	assert.False(t, settingEnabled(nil, nil, key))
+	assert.True(t, settingEnabled(Settings{key: 1}, nil, key))
+	assert.True(t, settingEnabled(nil, Settings{key: "true"}, key))
+	assert.False(t, settingEnabled(Settings{key: 1}, Settings{key: "0"}, key))
+	assert.True(t, settingEnabled(nil, Settings{"other": 1, key: true}, "other"))
+}
+
+func TestDecorateContext(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		queries = make(map[string]string)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		query := strings.TrimSpace(string(body))
+		mu.Lock()
+		queries[query] = r.URL.Query().Get("log_comment")
+		mu.Unlock()
+		var (
+			block proto.Block
+			buf   chproto.Buffer
+		)
+		switch query {
+		case "SELECT version()":
+			block.AddColumn("version()", "String")
+			block.Append("23.8.1")
+		default:
+			block.AddColumn("timezone()", "String")
+			block.Append("UTC")
+		}
+		block.Encode(&buf, 0)
+		w.Write(buf.Buf)
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	opt := (&Options{
+		Protocol: HTTP,
+		Addr:     []string{addr},
+		DecorateContext: func(ctx context.Context) context.Context {
+			return Context(ctx, WithSettings(Settings{"log_comment": "tenant-42"}))
+		},
+	}).setDefaults()
+	conn, err := dialHttp(context.Background(), addr, 1, opt)
+	require.NoError(t, err)
+	defer conn.close()
+	std := &stdDriver{opt: opt, conn: conn, debugf: func(string, ...interface{}) {}}
+	rows, err := std.Query(context.Background(), "SELECT timezone() AS tenant")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[string]string{
+		"SELECT timezone()":           "tenant-42",
+		"SELECT version()":            "tenant-42",
+		"SELECT timezone() AS tenant": "tenant-42",
+	}, queries)
+}
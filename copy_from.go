@@ -0,0 +1,111 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// CopyFromSource is the source of the rows of CopyFrom, as the interface of the same name of pgx, so
+// ingestion code written for PostgreSQL can be reused.
+type CopyFromSource interface {
+	// Next advances to the next row, returning false once there are no more rows or after an error.
+	Next() bool
+	// Values returns the values of the current row, in the order of the columns.
+	Values() ([]interface{}, error)
+	// Err returns the error, if any, that stopped Next.
+	Err() error
+}
+
+// CopyFromRows returns a CopyFromSource of rows.
+func CopyFromRows(rows [][]interface{}) CopyFromSource {
+	return CopyFromSlice(len(rows), func(i int) ([]interface{}, error) {
+		return rows[i], nil
+	})
+}
+
+// CopyFromSlice returns a CopyFromSource of length rows, the values of which are returned by next.
+func CopyFromSlice(length int, next func(int) ([]interface{}, error)) CopyFromSource {
+	return &copyFromSlice{next: next, idx: -1, len: length}
+}
+
+type copyFromSlice struct {
+	next func(int) ([]interface{}, error)
+	idx  int
+	len  int
+	err  error
+}
+
+func (s *copyFromSlice) Next() bool {
+	s.idx++
+	return s.idx < s.len
+}
+
+func (s *copyFromSlice) Values() ([]interface{}, error) {
+	values, err := s.next(s.idx)
+	if err != nil {
+		s.err = err
+	}
+	return values, err
+}
+
+func (s *copyFromSlice) Err() error {
+	return s.err
+}
+
+// CopyFrom inserts the rows of src into the columns of table with a single batch, returning the number
+// of rows inserted. Nothing is inserted if src, or appending one of its rows, fails.
+func CopyFrom(ctx context.Context, conn driver.Conn, table string, columns []string, src CopyFromSource) (int64, error) {
+	quoted := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quoted = append(quoted, quoteIdentifier(column))
+	}
+	query := "INSERT INTO " + table
+	if len(quoted) != 0 {
+		query += " (" + strings.Join(quoted, ", ") + ")"
+	}
+	batch, err := conn.PrepareBatch(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	var rows int64
+	for src.Next() {
+		values, err := src.Values()
+		if err != nil {
+			batch.Abort()
+			return 0, err
+		}
+		if err := batch.Append(values...); err != nil {
+			batch.Abort()
+			return 0, fmt.Errorf("clickhouse: copy row %d: %w", rows, err)
+		}
+		rows++
+	}
+	if err := src.Err(); err != nil {
+		batch.Abort()
+		return 0, err
+	}
+	if err := batch.Send(); err != nil {
+		return 0, err
+	}
+	return rows, nil
+}
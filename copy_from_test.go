@@ -0,0 +1,94 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// copyConn records the rows of the batches prepared on it.
+type copyConn struct {
+	driver.Conn
+	query string
+	batch *copyBatch
+}
+
+func (c *copyConn) PrepareBatch(ctx context.Context, query string) (driver.Batch, error) {
+	c.query, c.batch = query, &copyBatch{}
+	return c.batch, nil
+}
+
+type copyBatch struct {
+	driver.Batch
+	rows          [][]interface{}
+	sent, aborted bool
+}
+
+func (b *copyBatch) Append(v ...interface{}) error {
+	if len(v) != 2 {
+		return errors.New("expected 2 values")
+	}
+	b.rows = append(b.rows, v)
+	return nil
+}
+
+func (b *copyBatch) Send() error {
+	b.sent = true
+	return nil
+}
+
+func (b *copyBatch) Abort() error {
+	b.aborted = true
+	return nil
+}
+
+func TestCopyFrom(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		conn = &copyConn{}
+		rows = [][]interface{}{{uint64(1), "a"}, {uint64(2), "b"}}
+	)
+	n, err := CopyFrom(ctx, conn, "db.events", []string{"id", "user name"}, CopyFromRows(rows))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, "INSERT INTO db.events (id, `user name`)", conn.query)
+	assert.Equal(t, rows, conn.batch.rows)
+	assert.True(t, conn.batch.sent)
+
+	errSource := errors.New("source")
+	_, err = CopyFrom(ctx, conn, "events", nil, CopyFromSlice(3, func(i int) ([]interface{}, error) {
+		if i == 1 {
+			return nil, errSource
+		}
+		return []interface{}{uint64(i), "x"}, nil
+	}))
+	assert.ErrorIs(t, err, errSource)
+	assert.Equal(t, "INSERT INTO events", conn.query)
+	assert.True(t, conn.batch.aborted)
+	assert.False(t, conn.batch.sent)
+
+	_, err = CopyFrom(ctx, conn, "events", nil, CopyFromRows([][]interface{}{{uint64(1), "a"}, {uint64(2)}}))
+	assert.EqualError(t, err, "clickhouse: copy row 1: expected 2 values")
+	assert.True(t, conn.batch.aborted)
+}
@@ -0,0 +1,282 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// CopyTableSpec describes the copy of a table by CopyTable.
+type CopyTableSpec struct {
+	// Source is the table to copy, optionally qualified with its database.
+	Source string
+	// Destination is the table to copy into, which must exist. Default Source.
+	Destination string
+	// Where, if set, copies only the rows matching the condition.
+	Where string
+	// Parallelism is the number of partitions copied concurrently. Default 1.
+	Parallelism int
+	// Completed are the partitions, by partition_id, copied by a previous run, which are skipped to resume it.
+	Completed []string
+	// Overwrite drops each partition from the destination before it is copied, so that a partition left partially
+	// copied by a failed run is not duplicated on resume. Both tables must have the same partition key.
+	Overwrite bool
+	// SkipSchemaCheck skips the check that the columns of the source exist in the destination with the same types.
+	SkipSchemaCheck bool
+	// OnPartition, if set, is called after each copied partition, e.g. to persist the partitions for Completed.
+	// It is not called concurrently.
+	OnPartition func(progress CopyTableProgress)
+}
+
+// CopyTableProgress is the progress of CopyTable.
+type CopyTableProgress struct {
+	// Partition is the partition_id of the last copied partition, empty for tables without parts.
+	Partition string
+	// Partitions are the partitions copied so far, out of Total, not counting Completed ones.
+	Partitions int
+	Total      int
+	// Rows are the rows copied so far, as reported by the source over the native protocol.
+	Rows uint64
+	// Bytes are the bytes of the Native format relayed so far.
+	Bytes   int64
+	Elapsed time.Duration
+}
+
+// BytesPerSecond is the throughput of the copy so far.
+func (p CopyTableProgress) BytesPerSecond() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Bytes) / p.Elapsed.Seconds()
+}
+
+// CopyTable copies the rows of a table of src into a table of dst, e.g. on another cluster, relaying the blocks in
+// the Native format (see NativeRelay) partition by partition. The partitions are the active ones of the source
+// when the copy starts; a table without parts, e.g. of the Log engines, is copied as a single partition. Each
+// partition is inserted with a single INSERT, which only replaces the partition if Overwrite is set: an
+// interrupted copy is resumed with the partitions reported by OnPartition as Completed.
+func CopyTable(ctx context.Context, src, dst driver.Conn, spec CopyTableSpec) (CopyTableProgress, error) {
+	if len(spec.Destination) == 0 {
+		spec.Destination = spec.Source
+	}
+	if spec.Parallelism <= 0 {
+		spec.Parallelism = 1
+	}
+	if !spec.SkipSchemaCheck {
+		if err := checkCopySchema(ctx, src, dst, spec); err != nil {
+			return CopyTableProgress{}, err
+		}
+	}
+	partitions, err := copyPartitions(ctx, src, spec)
+	if err != nil {
+		return CopyTableProgress{}, err
+	}
+	var (
+		start    = time.Now()
+		progress = CopyTableProgress{Total: len(partitions)}
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+		queue    = make(chan string)
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for i := 0; i < spec.Parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for partition := range queue {
+				rows, bytes, err := copyPartition(ctx, src, dst, spec, partition)
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("clickhouse: copy partition %q of %s: %w", partition, spec.Source, err)
+					}
+					cancel()
+				} else {
+					progress.Partition = partition
+					progress.Partitions++
+					progress.Rows += rows
+					progress.Bytes += bytes
+					progress.Elapsed = time.Since(start)
+					if spec.OnPartition != nil {
+						spec.OnPartition(progress)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	func() {
+		defer close(queue)
+		for _, partition := range partitions {
+			select {
+			case queue <- partition:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	progress.Elapsed = time.Since(start)
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return progress, firstErr
+}
+
+// copyPartitions returns the partitions of the source still to copy.
+func copyPartitions(ctx context.Context, src driver.Conn, spec CopyTableSpec) ([]string, error) {
+	database, args := tableArgs(spec.Source)
+	rows, err := src.Query(ctx, `
+		SELECT DISTINCT partition_id FROM system.parts
+		WHERE database = `+database+` AND table = @table AND active
+		ORDER BY partition_id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var (
+		partitions []string
+		found      bool
+		completed  = make(map[string]bool, len(spec.Completed))
+	)
+	for _, partition := range spec.Completed {
+		completed[partition] = true
+	}
+	for rows.Next() {
+		var partition string
+		if err := rows.Scan(&partition); err != nil {
+			return nil, err
+		}
+		if found = true; !completed[partition] {
+			partitions = append(partitions, partition)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !found && !completed[""] {
+		// no parts: an empty MergeTree table, or an engine without parts
+		partitions = append(partitions, "")
+	}
+	return partitions, nil
+}
+
+// copyPartition relays the rows of partition, all of them if empty, from src to dst.
+func copyPartition(ctx context.Context, src, dst driver.Conn, spec CopyTableSpec, partition string) (uint64, int64, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if len(partition) != 0 {
+		conditions, args = append(conditions, "_partition_id = @partition"), append(args, Named("partition", partition))
+		if spec.Overwrite {
+			if err := dst.Exec(ctx, "ALTER TABLE "+spec.Destination+" DROP PARTITION ID @partition", args...); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+	if len(spec.Where) != 0 {
+		conditions = append(conditions, "("+spec.Where+")")
+	}
+	query := "SELECT * FROM " + spec.Source
+	if len(conditions) != 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	var (
+		stats  Statistics
+		r, w   = io.Pipe()
+		insert = make(chan error, 1)
+	)
+	go func() {
+		err := ImportNative(ctx, dst, spec.Destination, r)
+		// unblocks the export if the import failed
+		r.CloseWithError(err)
+		insert <- err
+	}()
+	n, err := ExportNative(Context(ctx, WithStatistics(&stats)), src, w, query, args...)
+	w.CloseWithError(err)
+	if insertErr := <-insert; err == nil {
+		err = insertErr
+	}
+	return stats.ResultRows, n, err
+}
+
+type copyColumn struct {
+	Name string `ch:"name"`
+	Type string `ch:"type"`
+}
+
+// checkCopySchema checks that the columns of the source exist in the destination with the same types.
+func checkCopySchema(ctx context.Context, src, dst driver.Conn, spec CopyTableSpec) error {
+	source, err := copyColumns(ctx, src, spec.Source)
+	if err != nil {
+		return err
+	}
+	destination, err := copyColumns(ctx, dst, spec.Destination)
+	if err != nil {
+		return err
+	}
+	if len(source) == 0 {
+		return fmt.Errorf("clickhouse: copy source %s not found", spec.Source)
+	}
+	if len(destination) == 0 {
+		return fmt.Errorf("clickhouse: copy destination %s not found", spec.Destination)
+	}
+	return compareCopyColumns(source, destination)
+}
+
+// copyColumns returns the columns of table which SELECT * returns.
+func copyColumns(ctx context.Context, conn driver.Conn, table string) (columns []copyColumn, err error) {
+	database, args := tableArgs(table)
+	err = conn.Select(ctx, &columns, `
+		SELECT name, type FROM system.columns
+		WHERE database = `+database+` AND table = @table AND default_kind NOT IN ('MATERIALIZED', 'ALIAS', 'EPHEMERAL')
+		ORDER BY position
+	`, args...)
+	return columns, err
+}
+
+func compareCopyColumns(source, destination []copyColumn) error {
+	types := make(map[string]string, len(destination))
+	for _, column := range destination {
+		types[column.Name] = column.Type
+	}
+	var mismatches []string
+	for _, column := range source {
+		switch typ, ok := types[column.Name]; {
+		case !ok:
+			mismatches = append(mismatches, fmt.Sprintf("%s is missing", column.Name))
+		case typ != column.Type:
+			mismatches = append(mismatches, fmt.Sprintf("%s is %s instead of %s", column.Name, typ, column.Type))
+		}
+	}
+	if len(mismatches) != 0 {
+		return fmt.Errorf("clickhouse: copy destination columns differ: %s", strings.Join(mismatches, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareCopyColumns(t *testing.T) {
+	source := []copyColumn{{Name: "id", Type: "UInt64"}, {Name: "name", Type: "String"}, {Name: "ts", Type: "DateTime"}}
+	assert.NoError(t, compareCopyColumns(source, append([]copyColumn{{Name: "extra", Type: "UInt8"}}, source...)))
+	assert.EqualError(t, compareCopyColumns(source, []copyColumn{{Name: "id", Type: "UInt32"}, {Name: "ts", Type: "DateTime"}}),
+		"clickhouse: copy destination columns differ: id is UInt32 instead of UInt64, name is missing")
+}
+
+func TestCopyTableProgress(t *testing.T) {
+	assert.Zero(t, CopyTableProgress{Bytes: 100}.BytesPerSecond())
+	assert.Equal(t, 50.0, CopyTableProgress{Bytes: 100, Elapsed: 2 * time.Second}.BytesPerSecond())
+}
@@ -0,0 +1,237 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ddl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// ErrMutationFailed is returned while waiting for a mutation the server failed to apply. The server keeps
+// retrying it, so the error may be transient.
+var ErrMutationFailed = errors.New("clickhouse [ddl]: mutation failed")
+
+// Projection is a table projection, e.g. Projection{Name: "by_user", Query: "SELECT * ORDER BY user_id"}.
+type Projection struct {
+	Name  string
+	Query string
+}
+
+func (p Projection) String() string {
+	return fmt.Sprintf("PROJECTION %s (%s)", Identifier(p.Name), p.Query)
+}
+
+// MaterializeOptions configures the materialization of an index or a projection for existing data.
+type MaterializeOptions struct {
+	// Partition restricts the materialization to a partition expression, e.g. "202301".
+	Partition string
+	// Wait waits until the mutations materializing the data are done.
+	Wait bool
+	// PollInterval is how often system.mutations is polled. Default 1 second.
+	PollInterval time.Duration
+	// OnProgress is called with the state of the mutations on every poll.
+	OnProgress func(MutationProgress)
+}
+
+// MutationProgress is the state of the mutations of an ALTER statement.
+type MutationProgress struct {
+	MutationIDs []string
+	// PartsToDo is the number of data parts left to mutate, PartsTotal the number when waiting started.
+	PartsToDo  int64
+	PartsTotal int64
+	Done       bool
+	// FailReason is the latest error of a mutation, if any.
+	FailReason string
+	Elapsed    time.Duration
+}
+
+// AddIndex adds a data skipping index to table, optionally qualified with its database. The index only
+// covers new data until it is materialized with MaterializeIndex.
+func AddIndex(ctx context.Context, conn driver.Conn, table string, index Index) error {
+	return conn.Exec(ctx, "ALTER TABLE "+table+" ADD "+index.String())
+}
+
+// DropIndex removes a data skipping index from table.
+func DropIndex(ctx context.Context, conn driver.Conn, table, name string) error {
+	return conn.Exec(ctx, "ALTER TABLE "+table+" DROP INDEX "+Identifier(name))
+}
+
+// MaterializeIndex builds an index for the existing data of table.
+func MaterializeIndex(ctx context.Context, conn driver.Conn, table, name string, opts MaterializeOptions) error {
+	return materialize(ctx, conn, table, "MATERIALIZE INDEX "+Identifier(name), opts)
+}
+
+// AddProjection adds a projection to table. It only covers new data until it is materialized with
+// MaterializeProjection.
+func AddProjection(ctx context.Context, conn driver.Conn, table string, projection Projection) error {
+	return conn.Exec(ctx, "ALTER TABLE "+table+" ADD "+projection.String())
+}
+
+// DropProjection removes a projection from table.
+func DropProjection(ctx context.Context, conn driver.Conn, table, name string) error {
+	return conn.Exec(ctx, "ALTER TABLE "+table+" DROP PROJECTION "+Identifier(name))
+}
+
+// MaterializeProjection builds a projection for the existing data of table.
+func MaterializeProjection(ctx context.Context, conn driver.Conn, table, name string, opts MaterializeOptions) error {
+	return materialize(ctx, conn, table, "MATERIALIZE PROJECTION "+Identifier(name), opts)
+}
+
+func materialize(ctx context.Context, conn driver.Conn, table, action string, opts MaterializeOptions) error {
+	query := "ALTER TABLE " + table + " " + action
+	if len(opts.Partition) != 0 {
+		query += " IN PARTITION " + opts.Partition
+	}
+	if !opts.Wait && opts.OnProgress == nil {
+		return conn.Exec(ctx, query)
+	}
+	before, err := mutationIDs(ctx, conn, table)
+	if err != nil {
+		return err
+	}
+	if err := conn.Exec(ctx, query); err != nil {
+		return err
+	}
+	after, err := mutationIDs(ctx, conn, table)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, id := range after {
+		if !contains(before, id) {
+			ids = append(ids, id)
+		}
+	}
+	return WaitMutations(ctx, conn, table, ids, opts)
+}
+
+// WaitMutations waits until the mutations of table with the given ids, as listed in system.mutations,
+// are done. Only PollInterval and OnProgress of opts are used.
+func WaitMutations(ctx context.Context, conn driver.Conn, table string, ids []string, opts MaterializeOptions) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	var (
+		start  = time.Now()
+		total  int64
+		ticker = time.NewTicker(opts.PollInterval)
+	)
+	defer ticker.Stop()
+	for {
+		progress, err := mutationProgress(ctx, conn, table, ids)
+		if err != nil {
+			return err
+		}
+		if progress.PartsToDo > total {
+			total = progress.PartsToDo
+		}
+		progress.PartsTotal, progress.Elapsed = total, time.Since(start)
+		if opts.OnProgress != nil {
+			opts.OnProgress(progress)
+		}
+		switch {
+		case progress.Done:
+			return nil
+		case len(progress.FailReason) != 0:
+			return fmt.Errorf("%w: %s", ErrMutationFailed, progress.FailReason)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// tableFilter returns the system table condition selecting table, optionally qualified with its database.
+func tableFilter(table string) (string, []interface{}) {
+	database, name := clickhouse.SplitTableName(table)
+	if len(database) != 0 {
+		return "database = @database AND table = @table", []interface{}{
+			clickhouse.Named("database", database),
+			clickhouse.Named("table", name),
+		}
+	}
+	return "database = currentDatabase() AND table = @table", []interface{}{clickhouse.Named("table", name)}
+}
+
+func mutationIDs(ctx context.Context, conn driver.Conn, table string) ([]string, error) {
+	filter, args := tableFilter(table)
+	rows, err := conn.Query(ctx, "SELECT mutation_id FROM system.mutations WHERE "+filter, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func mutationProgress(ctx context.Context, conn driver.Conn, table string, ids []string) (MutationProgress, error) {
+	var (
+		progress     = MutationProgress{MutationIDs: ids, Done: true}
+		filter, args = tableFilter(table)
+	)
+	rows, err := conn.Query(ctx, "SELECT parts_to_do, is_done, latest_fail_reason FROM system.mutations WHERE "+filter+" AND has(@ids, mutation_id)",
+		append(args, clickhouse.Named("ids", ids))...)
+	if err != nil {
+		return progress, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			partsToDo  int64
+			done       uint8
+			failReason string
+		)
+		if err := rows.Scan(&partsToDo, &done, &failReason); err != nil {
+			return progress, err
+		}
+		progress.PartsToDo += partsToDo
+		if done == 0 {
+			progress.Done = false
+			if len(failReason) != 0 {
+				progress.FailReason = failReason
+			}
+		}
+	}
+	return progress, rows.Err()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
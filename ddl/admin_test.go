@@ -0,0 +1,41 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ddl
+
+import (
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjection(t *testing.T) {
+	p := Projection{Name: "by_user", Query: "SELECT * ORDER BY user_id"}
+	assert.Equal(t, "PROJECTION `by_user` (SELECT * ORDER BY user_id)", p.String())
+}
+
+func TestTableFilter(t *testing.T) {
+	filter, args := tableFilter("`db`.`events`")
+	assert.Equal(t, "database = @database AND table = @table", filter)
+	assert.Equal(t, []interface{}{clickhouse.Named("database", "db"), clickhouse.Named("table", "events")}, args)
+	filter, args = tableFilter("events")
+	assert.Equal(t, "database = currentDatabase() AND table = @table", filter)
+	assert.Equal(t, []interface{}{clickhouse.Named("table", "events")}, args)
+	_, args = tableFilter("`db`.`events.v2`")
+	assert.Equal(t, []interface{}{clickhouse.Named("database", "db"), clickhouse.Named("table", "events.v2")}, args)
+}
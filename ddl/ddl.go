@@ -0,0 +1,276 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package ddl builds CREATE TABLE statements from typed definitions and reads the definition of
+// existing tables back, so schemas can be managed as code without string templating:
+//
+//	table := ddl.CreateTable{
+//		Database: "db",
+//		Name:     "events",
+//		Columns: []ddl.Column{
+//			{Name: "ts", Type: "DateTime", Codec: "Delta, ZSTD"},
+//			{Name: "user_id", Type: "UInt64"},
+//		},
+//		Engine:      ddl.Engine{Name: "MergeTree", Replicated: true},
+//		PartitionBy: "toYYYYMM(ts)",
+//		OrderBy:     []string{"user_id", "ts"},
+//	}
+//	err := conn.Exec(ctx, table.SQL())
+package ddl
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+var ErrInvalidTable = errors.New("clickhouse [ddl]: invalid table definition")
+
+const (
+	// DefaultZooKeeperPath and DefaultReplicaName are the arguments of replicated engines using the
+	// {shard} and {replica} macros of the server configuration.
+	DefaultZooKeeperPath = "/clickhouse/tables/{shard}/{database}/{table}"
+	DefaultReplicaName   = "{replica}"
+)
+
+// Column is a column definition.
+type Column struct {
+	Name string
+	Type string
+	// DefaultKind is DEFAULT, MATERIALIZED, ALIAS or EPHEMERAL, DEFAULT if empty while Default is set.
+	DefaultKind string
+	Default     string
+	// Codec is the compression codec without CODEC(...), e.g. "Delta, ZSTD(3)".
+	Codec   string
+	TTL     string
+	Comment string
+}
+
+func (c Column) String() string {
+	if len(c.Comment) != 0 {
+		return c.definition() + " COMMENT " + String(c.Comment)
+	}
+	return c.definition()
+}
+
+// definition renders the column without its comment, as used by ALTER TABLE MODIFY COLUMN.
+func (c Column) definition() string {
+	var b strings.Builder
+	b.WriteString(Identifier(c.Name))
+	b.WriteString(" ")
+	b.WriteString(c.Type)
+	if len(c.Default) != 0 {
+		fmt.Fprintf(&b, " %s %s", c.defaultKind(), c.Default)
+	}
+	if len(c.Codec) != 0 {
+		fmt.Fprintf(&b, " CODEC(%s)", c.Codec)
+	}
+	if len(c.TTL) != 0 {
+		fmt.Fprintf(&b, " TTL %s", c.TTL)
+	}
+	return b.String()
+}
+
+func (c Column) defaultKind() string {
+	if len(c.DefaultKind) == 0 && len(c.Default) != 0 {
+		return "DEFAULT"
+	}
+	return c.DefaultKind
+}
+
+// Index is a data skipping index.
+type Index struct {
+	Name       string
+	Expression string
+	// Type is the index type with its parameters, e.g. "minmax" or "bloom_filter(0.01)".
+	Type        string
+	Granularity uint64 // default 1
+}
+
+func (i Index) String() string {
+	granularity := i.Granularity
+	if granularity == 0 {
+		granularity = 1
+	}
+	return fmt.Sprintf("INDEX %s %s TYPE %s GRANULARITY %d", Identifier(i.Name), i.Expression, i.Type, granularity)
+}
+
+// Engine is a table engine, e.g. Engine{Name: "ReplacingMergeTree", Params: []string{"version"}}.
+type Engine struct {
+	Name   string
+	Params []string
+	// Replicated uses the Replicated variant of a MergeTree engine, with ZooKeeperPath and ReplicaName
+	// defaulting to DefaultZooKeeperPath and DefaultReplicaName.
+	Replicated    bool
+	ZooKeeperPath string
+	ReplicaName   string
+}
+
+// MergeTree reports whether the engine is of the MergeTree family, requiring ORDER BY.
+func (e Engine) MergeTree() bool {
+	return strings.HasSuffix(e.Name, "MergeTree")
+}
+
+func (e Engine) String() string {
+	var (
+		name   = e.Name
+		params = e.Params
+	)
+	if e.Replicated {
+		path, replica := e.ZooKeeperPath, e.ReplicaName
+		if len(path) == 0 {
+			path = DefaultZooKeeperPath
+		}
+		if len(replica) == 0 {
+			replica = DefaultReplicaName
+		}
+		name = "Replicated" + name
+		params = append([]string{String(path), String(replica)}, params...)
+	}
+	return name + "(" + strings.Join(params, ", ") + ")"
+}
+
+// CreateTable is a CREATE TABLE statement.
+type CreateTable struct {
+	Database    string
+	Name        string
+	IfNotExists bool
+	OnCluster   string
+	Columns     []Column
+	Indexes     []Index
+	Engine      Engine
+	PartitionBy string
+	// OrderBy is the sorting key, ORDER BY tuple() if empty for MergeTree engines.
+	OrderBy []string
+	// PrimaryKey is the primary key if it is a prefix of the sorting key rather than all of it.
+	PrimaryKey []string
+	SampleBy   string
+	TTL        string
+	Settings   clickhouse.Settings
+	Comment    string
+}
+
+// Table returns the, optionally database qualified, quoted name of the table.
+func (t *CreateTable) Table() string {
+	if len(t.Database) != 0 {
+		return Identifier(t.Database) + "." + Identifier(t.Name)
+	}
+	return Identifier(t.Name)
+}
+
+// Validate checks that the definition has a name, columns and an engine.
+func (t *CreateTable) Validate() error {
+	switch {
+	case len(t.Name) == 0:
+		return fmt.Errorf("%w: table name is empty", ErrInvalidTable)
+	case len(t.Columns) == 0:
+		return fmt.Errorf("%w: table %s has no columns", ErrInvalidTable, t.Name)
+	case len(t.Engine.Name) == 0:
+		return fmt.Errorf("%w: table %s has no engine", ErrInvalidTable, t.Name)
+	}
+	for _, c := range t.Columns {
+		if len(c.Name) == 0 || len(c.Type) == 0 {
+			return fmt.Errorf("%w: column %q of table %s has no name or type", ErrInvalidTable, c.Name, t.Name)
+		}
+	}
+	return nil
+}
+
+// SQL renders the statement. It does not validate the definition, see Validate.
+func (t *CreateTable) SQL() string {
+	var b strings.Builder
+	b.WriteString("CREATE TABLE ")
+	if t.IfNotExists {
+		b.WriteString("IF NOT EXISTS ")
+	}
+	b.WriteString(t.Table())
+	if len(t.OnCluster) != 0 {
+		fmt.Fprintf(&b, " ON CLUSTER %s", Identifier(t.OnCluster))
+	}
+	b.WriteString("\n(\n")
+	definitions := make([]string, 0, len(t.Columns)+len(t.Indexes))
+	for _, c := range t.Columns {
+		definitions = append(definitions, "    "+c.String())
+	}
+	for _, i := range t.Indexes {
+		definitions = append(definitions, "    "+i.String())
+	}
+	b.WriteString(strings.Join(definitions, ",\n"))
+	b.WriteString("\n)\nENGINE = ")
+	b.WriteString(t.Engine.String())
+	if len(t.PartitionBy) != 0 {
+		b.WriteString("\nPARTITION BY " + t.PartitionBy)
+	}
+	if len(t.OrderBy) != 0 {
+		b.WriteString("\nORDER BY " + key(t.OrderBy))
+	} else if t.Engine.MergeTree() {
+		b.WriteString("\nORDER BY tuple()")
+	}
+	if len(t.PrimaryKey) != 0 {
+		b.WriteString("\nPRIMARY KEY " + key(t.PrimaryKey))
+	}
+	if len(t.SampleBy) != 0 {
+		b.WriteString("\nSAMPLE BY " + t.SampleBy)
+	}
+	if len(t.TTL) != 0 {
+		b.WriteString("\nTTL " + t.TTL)
+	}
+	if len(t.Settings) != 0 {
+		b.WriteString("\nSETTINGS " + settings(t.Settings))
+	}
+	if len(t.Comment) != 0 {
+		b.WriteString("\nCOMMENT " + String(t.Comment))
+	}
+	return b.String()
+}
+
+func key(expressions []string) string {
+	if len(expressions) == 1 {
+		return expressions[0]
+	}
+	return "(" + strings.Join(expressions, ", ") + ")"
+}
+
+// settings renders settings sorted by name, quoting string values.
+func settings(s clickhouse.Settings) string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		value := fmt.Sprint(s[name])
+		if v, ok := s[name].(string); ok {
+			value = String(v)
+		}
+		names[i] = name + " = " + value
+	}
+	return strings.Join(names, ", ")
+}
+
+// Identifier quotes name with backquotes, see clickhouse.QuoteIdentifier.
+func Identifier(name string) string {
+	return clickhouse.QuoteIdentifier(name)
+}
+
+// String quotes v as a string literal.
+func String(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
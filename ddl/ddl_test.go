@@ -0,0 +1,94 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ddl
+
+import (
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTableSQL(t *testing.T) {
+	table := CreateTable{
+		Database:    "db",
+		Name:        "events",
+		IfNotExists: true,
+		OnCluster:   "main",
+		Columns: []Column{
+			{Name: "ts", Type: "DateTime", Codec: "Delta, ZSTD"},
+			{Name: "user_id", Type: "UInt64", Comment: "user's id"},
+			{Name: "day", Type: "Date", DefaultKind: "MATERIALIZED", Default: "toDate(ts)"},
+			{Name: "payload", Type: "String", Default: "''", TTL: "ts + toIntervalDay(7)"},
+		},
+		Indexes:     []Index{{Name: "payload_idx", Expression: "payload", Type: "bloom_filter(0.01)", Granularity: 4}},
+		Engine:      Engine{Name: "ReplacingMergeTree", Params: []string{"ts"}, Replicated: true},
+		PartitionBy: "toYYYYMM(ts)",
+		OrderBy:     []string{"user_id", "ts"},
+		PrimaryKey:  []string{"user_id"},
+		TTL:         "ts + toIntervalYear(1)",
+		Settings:    clickhouse.Settings{"storage_policy": "hot", "index_granularity": 8192},
+		Comment:     "events",
+	}
+	require.NoError(t, table.Validate())
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS `db`.`events` ON CLUSTER `main`\n"+
+		"(\n"+
+		"    `ts` DateTime CODEC(Delta, ZSTD),\n"+
+		"    `user_id` UInt64 COMMENT 'user\\'s id',\n"+
+		"    `day` Date MATERIALIZED toDate(ts),\n"+
+		"    `payload` String DEFAULT '' TTL ts + toIntervalDay(7),\n"+
+		"    INDEX `payload_idx` payload TYPE bloom_filter(0.01) GRANULARITY 4\n"+
+		")\n"+
+		"ENGINE = ReplicatedReplacingMergeTree('/clickhouse/tables/{shard}/{database}/{table}', '{replica}', ts)\n"+
+		"PARTITION BY toYYYYMM(ts)\n"+
+		"ORDER BY (user_id, ts)\n"+
+		"PRIMARY KEY user_id\n"+
+		"TTL ts + toIntervalYear(1)\n"+
+		"SETTINGS index_granularity = 8192, storage_policy = 'hot'\n"+
+		"COMMENT 'events'", table.SQL())
+
+	table = CreateTable{Name: "t", Columns: []Column{{Name: "x", Type: "UInt8"}}, Engine: Engine{Name: "MergeTree"}}
+	assert.Equal(t, "CREATE TABLE `t`\n(\n    `x` UInt8\n)\nENGINE = MergeTree()\nORDER BY tuple()", table.SQL())
+
+	assert.ErrorIs(t, (&CreateTable{Name: "t"}).Validate(), ErrInvalidTable)
+	assert.ErrorIs(t, (&CreateTable{Name: "t", Columns: []Column{{Name: "x"}}, Engine: Engine{Name: "Memory"}}).Validate(), ErrInvalidTable)
+}
+
+func TestParseEngine(t *testing.T) {
+	e, rest := parseEngine("ReplicatedReplacingMergeTree('/clickhouse/tables/{shard}/{database}/{table}', '{replica}', ver) PARTITION BY toYYYYMM(ts) ORDER BY (id, ts) TTL ts + toIntervalDay(1) SETTINGS index_granularity = 8192, storage_policy = 'hot'")
+	assert.Equal(t, Engine{Name: "ReplacingMergeTree", Params: []string{"ver"}, Replicated: true}, e)
+	clauses := splitClauses(rest)
+	assert.Equal(t, "toYYYYMM(ts)", clauses["PARTITION BY"])
+	assert.Equal(t, "(id, ts)", clauses["ORDER BY"])
+	assert.Equal(t, "ts + toIntervalDay(1)", clauses["TTL"])
+	assert.Equal(t, clickhouse.Settings{"index_granularity": int64(8192), "storage_policy": "hot"}, parseSettings(clauses["SETTINGS"]))
+
+	e, rest = parseEngine("Memory")
+	assert.Equal(t, Engine{Name: "Memory"}, e)
+	assert.Empty(t, rest)
+	e, _ = parseEngine("MergeTree ORDER BY id SETTINGS index_granularity = 8192")
+	assert.Equal(t, Engine{Name: "MergeTree"}, e)
+	e, _ = parseEngine("ReplicatedMergeTree('/tables/events', 'r1')")
+	assert.Equal(t, Engine{Name: "MergeTree", Replicated: true, ZooKeeperPath: "/tables/events", ReplicaName: "r1"}, e)
+}
+
+func TestSplitList(t *testing.T) {
+	assert.Equal(t, []string{"a", "toDate(b, 'UTC')", "[1, 2]", "'x, y'"}, splitList("a, toDate(b, 'UTC'), [1, 2], 'x, y'"))
+	assert.Nil(t, splitList(""))
+}
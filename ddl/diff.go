@@ -0,0 +1,319 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ddl
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrRecreateRequired is returned by SchemaDiff for changes no ALTER statement can apply, e.g. of the
+// engine or the partition key. The table has to be recreated and its data copied.
+var ErrRecreateRequired = errors.New("clickhouse [ddl]: table must be recreated")
+
+// Safety classifies the impact of a migration step.
+type Safety uint8
+
+const (
+	// Online steps only change metadata and apply instantly.
+	Online Safety = iota
+	// Mutation steps rewrite data parts in the background, e.g. a column type change.
+	Mutation
+	// Destructive steps delete data.
+	Destructive
+)
+
+func (s Safety) String() string {
+	switch s {
+	case Online:
+		return "online"
+	case Mutation:
+		return "mutation"
+	case Destructive:
+		return "destructive"
+	}
+	return ""
+}
+
+// Change is a step of a migration plan.
+type Change struct {
+	Statement string
+	Safety    Safety
+	// Description summarizes the change, e.g. "add column day".
+	Description string
+}
+
+// Plan is an ordered list of changes migrating a table.
+type Plan []Change
+
+// Statements returns the ALTER statements of the plan in order.
+func (p Plan) Statements() []string {
+	statements := make([]string, len(p))
+	for i, c := range p {
+		statements[i] = c.Statement
+	}
+	return statements
+}
+
+// Safety returns the highest safety class of the plan's changes.
+func (p Plan) Safety() Safety {
+	var safety Safety
+	for _, c := range p {
+		if c.Safety > safety {
+			safety = c.Safety
+		}
+	}
+	return safety
+}
+
+// SchemaDiff returns the ALTER statements migrating the table current, e.g. read by Introspect, to
+// desired. Indexes are dropped first and columns last, so that no step depends on a column about to be
+// removed. Expressions are compared as written, see Introspect. Changes of the engine, partition key,
+// primary key or sampling key, and of the sorting key other than appending new columns, return
+// ErrRecreateRequired.
+func SchemaDiff(current, desired *CreateTable) (Plan, error) {
+	var recreate []string
+	if !reflect.DeepEqual(current.Engine, desired.Engine) {
+		recreate = append(recreate, fmt.Sprintf("engine %s to %s", current.Engine, desired.Engine))
+	}
+	if current.PartitionBy != desired.PartitionBy {
+		recreate = append(recreate, "partition key")
+	}
+	if !equalKey(current.PrimaryKey, desired.PrimaryKey) {
+		recreate = append(recreate, "primary key")
+	}
+	if current.SampleBy != desired.SampleBy {
+		recreate = append(recreate, "sampling key")
+	}
+	var (
+		d = differ{
+			table: desired.Table(),
+		}
+		columns        = make(map[string]Column, len(current.Columns))
+		desiredColumns = make(map[string]bool, len(desired.Columns))
+	)
+	if len(desired.OnCluster) != 0 {
+		d.table += " ON CLUSTER " + Identifier(desired.OnCluster)
+	}
+	for _, c := range current.Columns {
+		columns[c.Name] = c
+	}
+	for _, c := range desired.Columns {
+		desiredColumns[c.Name] = true
+	}
+	if !equalKey(current.OrderBy, desired.OrderBy) {
+		if !appendsNewColumns(current.OrderBy, desired.OrderBy, columns, desiredColumns) {
+			recreate = append(recreate, "sorting key")
+		}
+	}
+	if len(recreate) != 0 {
+		return nil, fmt.Errorf("%w: %s of table %s changed", ErrRecreateRequired, strings.Join(recreate, ", "), desired.Name)
+	}
+
+	indexes := make(map[string]Index, len(desired.Indexes))
+	for _, i := range desired.Indexes {
+		indexes[i.Name] = i
+	}
+	for _, i := range current.Indexes {
+		if desired, found := indexes[i.Name]; !found || !equalIndex(i, desired) {
+			d.add(Online, "drop index "+i.Name, "DROP INDEX "+Identifier(i.Name))
+		}
+	}
+
+	// columns appended to the sorting key must be added by the same statement as MODIFY ORDER BY
+	var sortingKey []string
+	if !equalKey(current.OrderBy, desired.OrderBy) {
+		sortingKey = desired.OrderBy[len(current.OrderBy):]
+	}
+	var keyColumns []string
+	for n, c := range desired.Columns {
+		if _, found := columns[c.Name]; found {
+			continue
+		}
+		position := " FIRST"
+		if n != 0 {
+			position = " AFTER " + Identifier(desired.Columns[n-1].Name)
+		}
+		action := "ADD COLUMN " + c.String() + position
+		if inKey(sortingKey, c.Name) {
+			keyColumns = append(keyColumns, action)
+			continue
+		}
+		d.add(Online, "add column "+c.Name, action)
+	}
+	for _, c := range desired.Columns {
+		if cur, found := columns[c.Name]; found {
+			d.modifyColumn(cur, c)
+		}
+	}
+
+	if len(sortingKey) != 0 {
+		d.add(Online, "add columns "+strings.Join(sortingKey, ", ")+" to the sorting key",
+			strings.Join(append(keyColumns, "MODIFY ORDER BY "+key(desired.OrderBy)), ", "))
+	}
+
+	for _, i := range desired.Indexes {
+		var found bool
+		for _, cur := range current.Indexes {
+			if cur.Name == i.Name && equalIndex(cur, i) {
+				found = true
+			}
+		}
+		if !found {
+			d.add(Online, "add index "+i.Name, "ADD "+i.String())
+			d.add(Mutation, "build index "+i.Name+" for existing data", "MATERIALIZE INDEX "+Identifier(i.Name))
+		}
+	}
+
+	if current.TTL != desired.TTL {
+		if len(desired.TTL) == 0 {
+			d.add(Online, "remove table TTL", "REMOVE TTL")
+		} else {
+			d.add(Mutation, "modify table TTL", "MODIFY TTL "+desired.TTL)
+		}
+	}
+
+	var (
+		modified = make(map[string]interface{})
+		reset    []string
+	)
+	for name, value := range desired.Settings {
+		if cur, found := current.Settings[name]; !found || fmt.Sprint(cur) != fmt.Sprint(value) {
+			modified[name] = value
+		}
+	}
+	for name := range current.Settings {
+		if _, found := desired.Settings[name]; !found {
+			reset = append(reset, name)
+		}
+	}
+	if len(modified) != 0 {
+		d.add(Online, "modify settings", "MODIFY SETTING "+settings(modified))
+	}
+	if len(reset) != 0 {
+		sort.Strings(reset)
+		d.add(Online, "reset settings", "RESET SETTING "+strings.Join(reset, ", "))
+	}
+	if current.Comment != desired.Comment {
+		d.add(Online, "modify comment", "MODIFY COMMENT "+String(desired.Comment))
+	}
+
+	for _, c := range current.Columns {
+		if !desiredColumns[c.Name] {
+			d.add(Destructive, "drop column "+c.Name, "DROP COLUMN "+Identifier(c.Name))
+		}
+	}
+	return d.plan, nil
+}
+
+type differ struct {
+	table string
+	plan  Plan
+}
+
+func (d *differ) add(safety Safety, description, action string) {
+	d.plan = append(d.plan, Change{
+		Statement:   "ALTER TABLE " + d.table + " " + action,
+		Safety:      safety,
+		Description: description,
+	})
+}
+
+func (d *differ) modifyColumn(current, desired Column) {
+	var (
+		name   = Identifier(desired.Name)
+		column = name + " " + desired.Type
+	)
+	if current.Type != desired.Type {
+		d.add(Mutation, fmt.Sprintf("change type of column %s from %s to %s", desired.Name, current.Type, desired.Type), "MODIFY COLUMN "+desired.definition())
+	} else {
+		if current.defaultKind() != desired.defaultKind() || current.Default != desired.Default {
+			if len(desired.Default) == 0 {
+				d.add(Online, "remove default of column "+desired.Name, "MODIFY COLUMN "+name+" REMOVE "+current.defaultKind())
+			} else {
+				d.add(Online, "modify default of column "+desired.Name, fmt.Sprintf("MODIFY COLUMN %s %s %s", column, desired.defaultKind(), desired.Default))
+			}
+		}
+		if current.Codec != desired.Codec {
+			if len(desired.Codec) == 0 {
+				d.add(Online, "remove codec of column "+desired.Name, "MODIFY COLUMN "+name+" REMOVE CODEC")
+			} else {
+				d.add(Online, "modify codec of column "+desired.Name, fmt.Sprintf("MODIFY COLUMN %s CODEC(%s)", column, desired.Codec))
+			}
+		}
+		if current.TTL != desired.TTL {
+			if len(desired.TTL) == 0 {
+				d.add(Online, "remove TTL of column "+desired.Name, "MODIFY COLUMN "+name+" REMOVE TTL")
+			} else {
+				d.add(Mutation, "modify TTL of column "+desired.Name, fmt.Sprintf("MODIFY COLUMN %s TTL %s", column, desired.TTL))
+			}
+		}
+	}
+	if current.Comment != desired.Comment {
+		d.add(Online, "comment column "+desired.Name, fmt.Sprintf("COMMENT COLUMN %s %s", name, String(desired.Comment)))
+	}
+}
+
+func equalKey(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if strings.TrimSpace(a[i]) != strings.TrimSpace(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func inKey(key []string, column string) bool {
+	for _, expression := range key {
+		if strings.Trim(strings.TrimSpace(expression), "`") == column {
+			return true
+		}
+	}
+	return false
+}
+
+func equalIndex(a, b Index) bool {
+	granularity := func(i Index) uint64 {
+		if i.Granularity == 0 {
+			return 1
+		}
+		return i.Granularity
+	}
+	return a.Expression == b.Expression && a.Type == b.Type && granularity(a) == granularity(b)
+}
+
+// appendsNewColumns reports whether desired is current followed by columns added by the migration,
+// the only sorting key change MODIFY ORDER BY allows.
+func appendsNewColumns(current, desired []string, columns map[string]Column, desiredColumns map[string]bool) bool {
+	if len(desired) <= len(current) || !equalKey(current, desired[:len(current)]) {
+		return false
+	}
+	for _, expression := range desired[len(current):] {
+		name := strings.Trim(strings.TrimSpace(expression), "`")
+		if _, found := columns[name]; found || !desiredColumns[name] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ddl
+
+import (
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func diffTable() *CreateTable {
+	return &CreateTable{
+		Name: "events",
+		Columns: []Column{
+			{Name: "ts", Type: "DateTime"},
+			{Name: "id", Type: "UInt32"},
+			{Name: "payload", Type: "String", Codec: "ZSTD(1)"},
+			{Name: "legacy", Type: "String"},
+		},
+		Indexes:  []Index{{Name: "payload_idx", Expression: "payload", Type: "bloom_filter", Granularity: 1}},
+		Engine:   Engine{Name: "MergeTree"},
+		OrderBy:  []string{"id"},
+		Settings: clickhouse.Settings{"index_granularity": int64(8192), "merge_with_ttl_timeout": int64(3600)},
+	}
+}
+
+func TestSchemaDiff(t *testing.T) {
+	var (
+		current = diffTable()
+		desired = diffTable()
+	)
+	plan, err := SchemaDiff(current, desired)
+	require.NoError(t, err)
+	assert.Empty(t, plan)
+
+	desired.Columns = []Column{
+		{Name: "ts", Type: "DateTime"},
+		{Name: "id", Type: "UInt32"},
+		{Name: "region", Type: "String"},
+		{Name: "payload", Type: "String", Codec: "ZSTD(1)"},
+		{Name: "legacy", Type: "String"},
+	}
+	plan, err = SchemaDiff(current, desired)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ALTER TABLE `events` ADD COLUMN `region` String AFTER `id`"}, plan.Statements())
+	assert.Equal(t, Online, plan.Safety())
+
+	desired.Columns = []Column{
+		{Name: "ts", Type: "DateTime", Comment: "event time"},
+		{Name: "id", Type: "UInt64"},
+		{Name: "kind", Type: "LowCardinality(String)", Default: "'click'"},
+		{Name: "payload", Type: "String"},
+	}
+	desired.Indexes = []Index{{Name: "payload_idx", Expression: "payload", Type: "tokenbf_v1(512, 3, 0)", Granularity: 1}}
+	desired.OrderBy = []string{"id", "kind"}
+	desired.TTL = "ts + toIntervalDay(30)"
+	desired.Settings = clickhouse.Settings{"index_granularity": int64(8192), "min_bytes_for_wide_part": 0}
+	plan, err = SchemaDiff(current, desired)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"ALTER TABLE `events` DROP INDEX `payload_idx`",
+		"ALTER TABLE `events` COMMENT COLUMN `ts` 'event time'",
+		"ALTER TABLE `events` MODIFY COLUMN `id` UInt64",
+		"ALTER TABLE `events` MODIFY COLUMN `payload` REMOVE CODEC",
+		"ALTER TABLE `events` ADD COLUMN `kind` LowCardinality(String) DEFAULT 'click' AFTER `id`, MODIFY ORDER BY (id, kind)",
+		"ALTER TABLE `events` ADD INDEX `payload_idx` payload TYPE tokenbf_v1(512, 3, 0) GRANULARITY 1",
+		"ALTER TABLE `events` MATERIALIZE INDEX `payload_idx`",
+		"ALTER TABLE `events` MODIFY TTL ts + toIntervalDay(30)",
+		"ALTER TABLE `events` MODIFY SETTING min_bytes_for_wide_part = 0",
+		"ALTER TABLE `events` RESET SETTING merge_with_ttl_timeout",
+		"ALTER TABLE `events` DROP COLUMN `legacy`",
+	}, plan.Statements())
+	assert.Equal(t, Mutation, plan[2].Safety)
+	assert.Equal(t, Online, plan[3].Safety)
+	assert.Equal(t, Destructive, plan.Safety())
+	assert.Equal(t, "drop column legacy", plan[len(plan)-1].Description)
+}
+
+func TestSchemaDiffRecreate(t *testing.T) {
+	for name, change := range map[string]func(*CreateTable){
+		"engine":         func(t *CreateTable) { t.Engine = Engine{Name: "ReplacingMergeTree"} },
+		"partition":      func(t *CreateTable) { t.PartitionBy = "toYYYYMM(ts)" },
+		"order by":       func(t *CreateTable) { t.OrderBy = []string{"ts"} },
+		"order by added": func(t *CreateTable) { t.OrderBy = []string{"id", "ts"} },
+	} {
+		desired := diffTable()
+		change(desired)
+		_, err := SchemaDiff(diffTable(), desired)
+		assert.ErrorIs(t, err, ErrRecreateRequired, name)
+	}
+}
@@ -0,0 +1,252 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ddl
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// Introspect reads the definition of an existing table from the system tables, database defaulting to
+// the current database. Expressions are returned as formatted by the server, so a definition compares
+// equal to its introspection once it was written the way the server formats it.
+func Introspect(ctx context.Context, conn driver.Conn, database, table string) (*CreateTable, error) {
+	var (
+		t = CreateTable{
+			Database: database,
+			Name:     table,
+		}
+		engineFull, sortingKey, primaryKey string
+		query                              = `
+			SELECT database, engine_full, partition_key, sorting_key, primary_key, sampling_key, comment
+			FROM system.tables
+			WHERE database = currentDatabase() AND name = @table`
+		args = []interface{}{clickhouse.Named("table", table)}
+	)
+	if len(database) != 0 {
+		query = strings.Replace(query, "currentDatabase()", "@database", 1)
+		args = append(args, clickhouse.Named("database", database))
+	}
+	if err := conn.QueryRow(ctx, query, args...).Scan(
+		&t.Database, &engineFull, &t.PartitionBy, &sortingKey, &primaryKey, &t.SampleBy, &t.Comment,
+	); err != nil {
+		return nil, fmt.Errorf("clickhouse [ddl]: introspect table %s: %w", table, err)
+	}
+	t.Engine, engineFull = parseEngine(engineFull)
+	if len(sortingKey) != 0 {
+		t.OrderBy = splitList(sortingKey)
+	}
+	if len(primaryKey) != 0 && primaryKey != sortingKey {
+		t.PrimaryKey = splitList(primaryKey)
+	}
+	clauses := splitClauses(engineFull)
+	t.TTL = clauses["TTL"]
+	if s, ok := clauses["SETTINGS"]; ok {
+		t.Settings = parseSettings(s)
+		// the server lists the default index granularity for every MergeTree table
+		if t.Settings["index_granularity"] == int64(8192) {
+			delete(t.Settings, "index_granularity")
+		}
+		if len(t.Settings) == 0 {
+			t.Settings = nil
+		}
+	}
+	columns, err := clickhouse.DescribeTable(ctx, conn, t.Table())
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range columns {
+		t.Columns = append(t.Columns, Column{
+			Name:        c.Name,
+			Type:        c.Type,
+			DefaultKind: c.DefaultType,
+			Default:     c.DefaultExpression,
+			Codec:       strings.TrimSuffix(strings.TrimPrefix(c.CodecExpression, "CODEC("), ")"),
+			TTL:         c.TTLExpression,
+			Comment:     c.Comment,
+		})
+	}
+	rows, err := conn.Query(ctx, "SELECT name, type_full, expr, granularity FROM system.data_skipping_indices WHERE database = @database AND table = @table",
+		clickhouse.Named("database", t.Database), clickhouse.Named("table", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i Index
+		if err := rows.Scan(&i.Name, &i.Type, &i.Expression, &i.Granularity); err != nil {
+			return nil, err
+		}
+		t.Indexes = append(t.Indexes, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// parseEngine parses the engine at the start of engine_full, returning the clauses after it.
+func parseEngine(full string) (Engine, string) {
+	var (
+		e   Engine
+		end = strings.IndexAny(full, "( ")
+	)
+	if end == -1 {
+		end = len(full)
+	}
+	if e.Name = full[:end]; end < len(full) && full[end] == '(' {
+		open, depth, quoted := end, 0, false
+		for i := open; i < len(full); i++ {
+			switch c := full[i]; {
+			case c == '\\' && quoted:
+				i++
+			case c == '\'':
+				quoted = !quoted
+			case quoted:
+			case c == '(':
+				depth++
+			case c == ')':
+				if depth--; depth == 0 {
+					end = i + 1
+					e.Params = splitList(full[open+1 : i])
+					i = len(full)
+				}
+			}
+		}
+	}
+	if strings.HasPrefix(e.Name, "Replicated") && e.MergeTree() {
+		e.Name, e.Replicated = strings.TrimPrefix(e.Name, "Replicated"), true
+		if len(e.Params) >= 2 {
+			e.ZooKeeperPath, e.ReplicaName = unquote(e.Params[0]), unquote(e.Params[1])
+			e.Params = e.Params[2:]
+		}
+		if e.ZooKeeperPath == DefaultZooKeeperPath && e.ReplicaName == DefaultReplicaName {
+			e.ZooKeeperPath, e.ReplicaName = "", ""
+		}
+	}
+	if len(e.Params) == 0 {
+		e.Params = nil
+	}
+	return e, strings.TrimSpace(full[end:])
+}
+
+var clauseKeywords = []string{"PARTITION BY", "PRIMARY KEY", "ORDER BY", "SAMPLE BY", "TTL", "SETTINGS", "COMMENT"}
+
+// splitClauses splits the clauses following the engine in engine_full by their keyword.
+func splitClauses(s string) map[string]string {
+	var (
+		clauses = make(map[string]string)
+		keyword string
+		start   int
+		depth   int
+		quoted  bool
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && (i == 0 || s[i-1] == ' '):
+			for _, k := range clauseKeywords {
+				if strings.HasPrefix(s[i:], k+" ") {
+					if len(keyword) != 0 {
+						clauses[keyword] = strings.TrimSpace(s[start:i])
+					}
+					keyword, start = k, i+len(k)+1
+					i += len(k)
+					break
+				}
+			}
+		}
+	}
+	if len(keyword) != 0 {
+		clauses[keyword] = strings.TrimSpace(s[start:])
+	}
+	return clauses
+}
+
+// splitList splits a comma separated list of expressions, ignoring the commas of nested expressions
+// and string literals.
+func splitList(s string) []string {
+	var (
+		items  []string
+		start  int
+		depth  int
+		quoted bool
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if item := strings.TrimSpace(s[start:]); len(item) != 0 || len(items) != 0 {
+		items = append(items, item)
+	}
+	return items
+}
+
+func parseSettings(s string) clickhouse.Settings {
+	settings := make(clickhouse.Settings)
+	for _, item := range splitList(s) {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if strings.HasPrefix(value, "'") {
+			settings[name] = unquote(value)
+		} else if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			settings[name] = v
+		} else if v, err := strconv.ParseFloat(value, 64); err == nil {
+			settings[name] = v
+		} else {
+			settings[name] = value
+		}
+	}
+	return settings
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace(s[1 : len(s)-1])
+	}
+	return s
+}
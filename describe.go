@@ -0,0 +1,70 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// ColumnDescription is a column of a table as returned by DESCRIBE TABLE.
+type ColumnDescription struct {
+	Name              string
+	Type              string
+	DefaultType       string
+	DefaultExpression string
+	Comment           string
+	// CodecExpression is the column compression codec, e.g. "ZSTD(3)" or "Delta(8), LZ4". It is empty
+	// when the column uses the table default.
+	CodecExpression string
+	TTLExpression   string
+}
+
+// HasCodec reports whether the column declares its own compression codec.
+func (c ColumnDescription) HasCodec() bool {
+	return len(c.CodecExpression) != 0
+}
+
+// DescribeTable returns the columns of table, including their compression codecs.
+func DescribeTable(ctx context.Context, conn driver.Conn, table string) ([]ColumnDescription, error) {
+	rows, err := conn.Query(ctx, "DESCRIBE TABLE "+table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var columns []ColumnDescription
+	for rows.Next() {
+		var (
+			c    ColumnDescription
+			dest = []interface{}{&c.Name, &c.Type, &c.DefaultType, &c.DefaultExpression, &c.Comment, &c.CodecExpression, &c.TTLExpression}
+		)
+		// newer servers may return additional columns (e.g. with describe_include_subcolumns)
+		for i := len(dest); i < len(rows.Columns()); i++ {
+			dest = append(dest, new(interface{}))
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
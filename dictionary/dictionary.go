@@ -0,0 +1,278 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package dictionary looks up dictionary attributes with dictGet, caching the results on the client.
+// Cached attributes expire after Config.CacheTTL and are all dropped as soon as system.dictionaries
+// reports that the dictionary was reloaded:
+//
+//	users := dictionary.New(conn, "users_dict", dictionary.Config{CacheTTL: time.Minute})
+//	name, err := dictionary.Get[string](ctx, users, "name", uint64(42))
+package dictionary
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+var ErrNotFound = errors.New("clickhouse [dictionary]: dictionary not found")
+
+type Config struct {
+	// CacheTTL is how long looked up attributes are cached. Zero disables the cache.
+	CacheTTL time.Duration
+	// MaxEntries is the number of cached attributes, the least recently used being evicted. Default 10000.
+	MaxEntries int
+	// ReloadCheckInterval is how often system.dictionaries is checked for a reload of the dictionary,
+	// which invalidates the cache. Default 10 seconds, a negative value disables the check.
+	ReloadCheckInterval time.Duration
+}
+
+func (c Config) setDefaults() Config {
+	if c.MaxEntries <= 0 {
+		c.MaxEntries = 10000
+	}
+	if c.ReloadCheckInterval == 0 {
+		c.ReloadCheckInterval = 10 * time.Second
+	}
+	return c
+}
+
+// Status is the state of a dictionary in system.dictionaries.
+type Status struct {
+	Status                   string    `ch:"status"`
+	ElementCount             uint64    `ch:"element_count"`
+	LastSuccessfulUpdateTime time.Time `ch:"last_successful_update_time"`
+	LastException            string    `ch:"last_exception"`
+}
+
+// Dictionary looks up the attributes of a dictionary. It is safe for concurrent use.
+type Dictionary struct {
+	conn   driver.Conn
+	name   string
+	config Config
+
+	mu         sync.Mutex
+	lru        *list.List
+	entries    map[cacheKey]*list.Element
+	lastCheck  time.Time
+	lastUpdate time.Time
+}
+
+type cacheKey struct {
+	attribute string
+	key       string
+	typ       reflect.Type
+}
+
+type cacheEntry struct {
+	key     cacheKey
+	value   reflect.Value
+	expires time.Time
+}
+
+// New returns a Dictionary looking up the dictionary name, optionally qualified with its database.
+func New(conn driver.Conn, name string, config Config) *Dictionary {
+	return &Dictionary{
+		conn:    conn,
+		name:    name,
+		config:  config.setDefaults(),
+		lru:     list.New(),
+		entries: make(map[cacheKey]*list.Element),
+	}
+}
+
+// Get scans the attribute of key into dest, a pointer to a value of the attribute type. Keys of complex
+// key dictionaries are passed as clickhouse.GroupSet. Missing keys yield the default of the attribute.
+func (d *Dictionary) Get(ctx context.Context, attribute string, key interface{}, dest interface{}) error {
+	return d.get(ctx, "dictGet", attribute, key, nil, dest)
+}
+
+// GetOrDefault is Get with def returned for missing keys.
+func (d *Dictionary) GetOrDefault(ctx context.Context, attribute string, key, def interface{}, dest interface{}) error {
+	return d.get(ctx, "dictGetOrDefault", attribute, key, def, dest)
+}
+
+// Has reports whether the dictionary contains key.
+func (d *Dictionary) Has(ctx context.Context, key interface{}) (bool, error) {
+	var has uint8
+	if err := d.get(ctx, "dictHas", "", key, nil, &has); err != nil {
+		return false, err
+	}
+	return has == 1, nil
+}
+
+// Get returns the attribute of key as T, see Dictionary.Get.
+func Get[T any](ctx context.Context, d *Dictionary, attribute string, key interface{}) (T, error) {
+	var value T
+	err := d.Get(ctx, attribute, key, &value)
+	return value, err
+}
+
+// GetOrDefault returns the attribute of key as T or def for missing keys.
+func GetOrDefault[T any](ctx context.Context, d *Dictionary, attribute string, key interface{}, def T) (T, error) {
+	var value T
+	err := d.GetOrDefault(ctx, attribute, key, def, &value)
+	return value, err
+}
+
+func (d *Dictionary) get(ctx context.Context, fn, attribute string, key, def, dest interface{}) error {
+	target := reflect.ValueOf(dest)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("clickhouse [dictionary]: %s destination must be a non-nil pointer", fn)
+	}
+	if err := d.checkReload(ctx); err != nil {
+		return err
+	}
+	ck := cacheKey{
+		attribute: fn + ":" + attribute,
+		key:       fmt.Sprintf("%T:%v:%v", key, key, def),
+		typ:       target.Type(),
+	}
+	if value, ok := d.cached(ck); ok {
+		target.Elem().Set(value)
+		return nil
+	}
+	var (
+		query = fmt.Sprintf("SELECT %s(@dictionary, @attribute, @key)", fn)
+		args  = []interface{}{
+			clickhouse.Named("dictionary", d.name),
+			clickhouse.Named("attribute", attribute),
+			clickhouse.Named("key", key),
+		}
+	)
+	switch fn {
+	case "dictHas":
+		query, args = "SELECT dictHas(@dictionary, @key)", []interface{}{args[0], args[2]}
+	case "dictGetOrDefault":
+		query, args = "SELECT dictGetOrDefault(@dictionary, @attribute, @key, @default)", append(args, clickhouse.Named("default", def))
+	}
+	if err := d.conn.QueryRow(ctx, query, args...).Scan(dest); err != nil {
+		return err
+	}
+	d.store(ck, target.Elem())
+	return nil
+}
+
+func (d *Dictionary) cached(key cacheKey) (reflect.Value, bool) {
+	if d.config.CacheTTL <= 0 {
+		return reflect.Value{}, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e, found := d.entries[key]
+	if !found {
+		return reflect.Value{}, false
+	}
+	entry := e.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		d.lru.Remove(e)
+		delete(d.entries, key)
+		return reflect.Value{}, false
+	}
+	d.lru.MoveToFront(e)
+	return entry.value, true
+}
+
+func (d *Dictionary) store(key cacheKey, value reflect.Value) {
+	if d.config.CacheTTL <= 0 {
+		return
+	}
+	copied := reflect.New(value.Type()).Elem()
+	copied.Set(value)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entry := &cacheEntry{key: key, value: copied, expires: time.Now().Add(d.config.CacheTTL)}
+	if e, found := d.entries[key]; found {
+		e.Value = entry
+		d.lru.MoveToFront(e)
+		return
+	}
+	d.entries[key] = d.lru.PushFront(entry)
+	if d.lru.Len() > d.config.MaxEntries {
+		oldest := d.lru.Back()
+		d.lru.Remove(oldest)
+		delete(d.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// Invalidate drops all the cached attributes.
+func (d *Dictionary) Invalidate() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lru.Init()
+	d.entries = make(map[cacheKey]*list.Element)
+}
+
+// checkReload invalidates the cache if the dictionary was reloaded since the last check.
+func (d *Dictionary) checkReload(ctx context.Context) error {
+	if d.config.CacheTTL <= 0 || d.config.ReloadCheckInterval < 0 {
+		return nil
+	}
+	d.mu.Lock()
+	due := time.Since(d.lastCheck) >= d.config.ReloadCheckInterval
+	if due {
+		// other lookups keep using the cache while the status is queried
+		d.lastCheck = time.Now()
+	}
+	d.mu.Unlock()
+	if !due {
+		return nil
+	}
+	status, err := d.Status(ctx)
+	if err != nil {
+		d.mu.Lock()
+		d.lastCheck = time.Time{}
+		d.mu.Unlock()
+		return err
+	}
+	d.mu.Lock()
+	reloaded := !status.LastSuccessfulUpdateTime.Equal(d.lastUpdate)
+	d.lastUpdate = status.LastSuccessfulUpdateTime
+	d.mu.Unlock()
+	if reloaded {
+		d.Invalidate()
+	}
+	return nil
+}
+
+// Status returns the state of the dictionary from system.dictionaries.
+func (d *Dictionary) Status(ctx context.Context) (Status, error) {
+	var (
+		statuses []Status
+		query    = "SELECT toString(status) AS status, element_count, last_successful_update_time, last_exception FROM system.dictionaries WHERE name = @name AND database IN (currentDatabase(), '')"
+		args     = []interface{}{clickhouse.Named("name", d.name)}
+	)
+	if i := strings.LastIndex(d.name, "."); i != -1 {
+		query = strings.Replace(query, "IN (currentDatabase(), '')", "= @database", 1)
+		args = []interface{}{clickhouse.Named("name", d.name[i+1:]), clickhouse.Named("database", d.name[:i])}
+	}
+	if err := d.conn.Select(ctx, &statuses, query, args...); err != nil {
+		return Status{}, err
+	}
+	if len(statuses) == 0 {
+		return Status{}, fmt.Errorf("%w: %s", ErrNotFound, d.name)
+	}
+	return statuses[0], nil
+}
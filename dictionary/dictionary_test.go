@@ -0,0 +1,128 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dictionary
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRow struct {
+	driver.Row
+	value interface{}
+}
+
+func (r *fakeRow) Scan(dest ...interface{}) error {
+	reflect.ValueOf(dest[0]).Elem().Set(reflect.ValueOf(r.value))
+	return nil
+}
+
+type fakeConn struct {
+	driver.Conn
+	lookups  int
+	statuses int
+	updated  time.Time
+	values   map[uint64]string
+}
+
+func (c *fakeConn) QueryRow(ctx context.Context, query string, args ...interface{}) driver.Row {
+	c.lookups++
+	key := args[len(args)-1].(driver.NamedValue).Value.(uint64)
+	return &fakeRow{value: c.values[key]}
+}
+
+func (c *fakeConn) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	c.statuses++
+	*dest.(*[]Status) = []Status{{Status: "LOADED", LastSuccessfulUpdateTime: c.updated}}
+	return nil
+}
+
+func TestDictionaryCache(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		conn = &fakeConn{values: map[uint64]string{1: "one", 2: "two"}, updated: time.Unix(1, 0)}
+		d    = New(conn, "numbers", Config{CacheTTL: time.Hour, ReloadCheckInterval: time.Hour})
+	)
+	for i := 0; i < 3; i++ {
+		v, err := Get[string](ctx, d, "name", uint64(1))
+		require.NoError(t, err)
+		assert.Equal(t, "one", v)
+	}
+	assert.Equal(t, 1, conn.lookups)
+	assert.Equal(t, 1, conn.statuses)
+
+	v, err := Get[string](ctx, d, "name", uint64(2))
+	require.NoError(t, err)
+	assert.Equal(t, "two", v)
+	assert.Equal(t, 2, conn.lookups)
+
+	// a reload of the dictionary invalidates the cache
+	conn.values[1], conn.updated = "uno", time.Unix(2, 0)
+	d.lastCheck = time.Time{}
+	v, err = Get[string](ctx, d, "name", uint64(1))
+	require.NoError(t, err)
+	assert.Equal(t, "uno", v)
+	assert.Equal(t, 3, conn.lookups)
+
+	d.Invalidate()
+	_, err = Get[string](ctx, d, "name", uint64(1))
+	require.NoError(t, err)
+	assert.Equal(t, 4, conn.lookups)
+}
+
+func TestDictionaryCacheEviction(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		conn = &fakeConn{values: map[uint64]string{1: "one", 2: "two", 3: "three"}}
+		d    = New(conn, "numbers", Config{CacheTTL: time.Hour, MaxEntries: 2, ReloadCheckInterval: -1})
+	)
+	for _, key := range []uint64{1, 2, 3, 1} {
+		_, err := Get[string](ctx, d, "name", key)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 4, conn.lookups)
+	assert.Equal(t, 0, conn.statuses)
+
+	d = New(conn, "numbers", Config{ReloadCheckInterval: -1})
+	for i := 0; i < 2; i++ {
+		_, err := Get[string](ctx, d, "name", uint64(1))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 6, conn.lookups)
+	assert.Error(t, d.Get(ctx, "name", uint64(1), "not a pointer"))
+}
+
+type missingConn struct {
+	driver.Conn
+}
+
+func (c *missingConn) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return nil
+}
+
+func TestDictionaryStatusNotFound(t *testing.T) {
+	_, err := New(&missingConn{}, "db.missing", Config{}).Status(context.Background())
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
@@ -0,0 +1,88 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+)
+
+// ErrorCode is a server error code. errors.Is(err, code) reports whether err is, or wraps, an *Exception
+// with that code, for both the native and the HTTP interface and through database/sql.
+type ErrorCode = proto.ErrorCode
+
+const (
+	ErrTableNotFound              = proto.ErrCodeUnknownTable
+	ErrDatabaseNotFound           = proto.ErrCodeUnknownDatabase
+	ErrColumnNotFound             = proto.ErrCodeNoSuchColumnInTable
+	ErrTableAlreadyExists         = proto.ErrCodeTableAlreadyExists
+	ErrDatabaseAlreadyExists      = proto.ErrCodeDatabaseAlreadyExists
+	ErrSyntax                     = proto.ErrCodeSyntaxError
+	ErrUnknownIdentifier          = proto.ErrCodeUnknownIdentifier
+	ErrUnknownSetting             = proto.ErrCodeUnknownSetting
+	ErrTypeMismatch               = proto.ErrCodeTypeMismatch
+	ErrTimeoutExceeded            = proto.ErrCodeTimeoutExceeded
+	ErrMemoryLimitExceeded        = proto.ErrCodeMemoryLimitExceeded
+	ErrTooManySimultaneousQueries = proto.ErrCodeTooManySimultaneousQueries
+	ErrTooManyParts               = proto.ErrCodeTooManyParts
+	ErrTooManyRowsOrBytes         = proto.ErrCodeTooManyRowsOrBytes
+	ErrSamplingNotSupported       = proto.ErrCodeSamplingNotSupported
+	ErrQueryWasCancelled          = proto.ErrCodeQueryWasCancelled
+	ErrReadonly                   = proto.ErrCodeReadonly
+	ErrAccessDenied               = proto.ErrCodeAccessDenied
+	ErrAuthenticationFailed       = proto.ErrCodeAuthenticationFailed
+	ErrKeeperException            = proto.ErrCodeKeeperException
+)
+
+// httpExecError is a failed HTTP request. It keeps the message of the response while exposing the server
+// exception to errors.Is and errors.As.
+type httpExecError struct {
+	msg       string
+	exception *Exception
+}
+
+func (e *httpExecError) Error() string {
+	return e.msg
+}
+
+func (e *httpExecError) Unwrap() error {
+	return e.exception
+}
+
+var httpExceptionRe = regexp.MustCompile(`(?s)^Code: \d+\. ([\w:]+): (.*?)(?: \(version .*\))?\s*$`)
+
+// httpException builds the exception of a failed HTTP response from the X-ClickHouse-Exception-Code header
+// and the response body, e.g. "Code: 60. DB::Exception: Table default.x does not exist. (UNKNOWN_TABLE) (version 23.3.1.1)".
+func httpException(header http.Header, body string) *Exception {
+	code, err := strconv.ParseInt(header.Get("X-ClickHouse-Exception-Code"), 10, 32)
+	if err != nil {
+		return nil
+	}
+	exception := Exception{
+		Code:    int32(code),
+		Message: strings.TrimSpace(body),
+	}
+	if match := httpExceptionRe.FindStringSubmatch(exception.Message); match != nil {
+		exception.Name, exception.Message = match[1], match[2]
+	}
+	return &exception
+}
@@ -0,0 +1,54 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExceptionIs(t *testing.T) {
+	var err error = &Exception{Code: 60, Name: "DB::Exception", Message: "Table default.x does not exist. (UNKNOWN_TABLE)"}
+	wrapped := fmt.Errorf("query: %w", err)
+	assert.ErrorIs(t, wrapped, ErrTableNotFound)
+	assert.NotErrorIs(t, wrapped, ErrDatabaseNotFound)
+	var exception *Exception
+	require.ErrorAs(t, wrapped, &exception)
+	assert.Equal(t, "DB::Exception: Table default.x does not exist. (UNKNOWN_TABLE)", exception.DisplayText())
+	assert.Equal(t, "UNKNOWN_TABLE", ErrorCode(exception.Code).Name())
+	assert.Equal(t, "code: 60 (UNKNOWN_TABLE)", ErrTableNotFound.Error())
+}
+
+func TestHTTPException(t *testing.T) {
+	header := http.Header{}
+	assert.Nil(t, httpException(header, "not an exception"))
+	header.Set("X-ClickHouse-Exception-Code", "60")
+	exception := httpException(header, "Code: 60. DB::Exception: Table default.x does not exist. (UNKNOWN_TABLE) (version 23.3.1.2823 (official build))\n")
+	require.NotNil(t, exception)
+	assert.Equal(t, int32(60), exception.Code)
+	assert.Equal(t, "DB::Exception", exception.Name)
+	assert.Equal(t, "Table default.x does not exist. (UNKNOWN_TABLE)", exception.Message)
+
+	err := &httpExecError{msg: "clickhouse [execute]:: 404 code: ...", exception: exception}
+	assert.True(t, errors.Is(err, ErrTableNotFound))
+}
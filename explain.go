@@ -0,0 +1,179 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// ExplainMode is the kind of EXPLAIN statement, optionally followed by its settings,
+// e.g. ExplainPlan + " indexes = 1".
+type ExplainMode string
+
+const (
+	ExplainPlan     ExplainMode = "PLAN"
+	ExplainPipeline ExplainMode = "PIPELINE"
+	ExplainEstimate ExplainMode = "ESTIMATE"
+	ExplainAST      ExplainMode = "AST"
+	ExplainSyntax   ExplainMode = "SYNTAX"
+)
+
+func (m ExplainMode) kind() ExplainMode {
+	if fields := strings.Fields(string(m)); len(fields) != 0 {
+		return ExplainMode(strings.ToUpper(fields[0]))
+	}
+	return ExplainPlan
+}
+
+// ExplainNode is a line of the output of EXPLAIN PLAN, PIPELINE or AST, the lines indented below
+// it being its children. Detail lines, e.g. enabled by header = 1 or actions = 1, are children too.
+type ExplainNode struct {
+	// Name is the first word of the line, e.g. the step "ReadFromMergeTree" or the AST node "TableIdentifier".
+	Name string
+	// Text is the line without its indentation.
+	Text     string
+	Children []*ExplainNode
+}
+
+// Walk calls fn for the node and its descendants in depth-first order, depth being 0 for the node.
+// Returning false skips the children of a node.
+func (n *ExplainNode) Walk(fn func(node *ExplainNode, depth int) bool) {
+	n.walk(fn, 0)
+}
+
+func (n *ExplainNode) walk(fn func(*ExplainNode, int) bool, depth int) {
+	if !fn(n, depth) {
+		return
+	}
+	for _, child := range n.Children {
+		child.walk(fn, depth+1)
+	}
+}
+
+// TableEstimate is a row of EXPLAIN ESTIMATE, the data to be read from a table.
+type TableEstimate struct {
+	Database string `ch:"database"`
+	Table    string `ch:"table"`
+	Parts    uint64 `ch:"parts"`
+	Rows     uint64 `ch:"rows"`
+	Marks    uint64 `ch:"marks"`
+}
+
+type ExplainResult struct {
+	Mode ExplainMode
+	// Lines is the raw output of PLAN, PIPELINE, AST and SYNTAX.
+	Lines []string
+	// Nodes are the top level nodes of PLAN, PIPELINE and AST.
+	Nodes []*ExplainNode
+	// Estimates are the rows of ESTIMATE.
+	Estimates []TableEstimate
+}
+
+// Walk calls ExplainNode.Walk for every top level node.
+func (r *ExplainResult) Walk(fn func(node *ExplainNode, depth int) bool) {
+	for _, node := range r.Nodes {
+		node.Walk(fn)
+	}
+}
+
+// Find returns the nodes named name, e.g. Find("ReadFromMergeTree") for the table reads of a plan.
+func (r *ExplainResult) Find(name string) []*ExplainNode {
+	var nodes []*ExplainNode
+	r.Walk(func(node *ExplainNode, _ int) bool {
+		if node.Name == name {
+			nodes = append(nodes, node)
+		}
+		return true
+	})
+	return nodes
+}
+
+// Explain runs EXPLAIN mode on query and parses its output, for checking query plans programmatically.
+func Explain(ctx context.Context, conn driver.Conn, mode ExplainMode, query string, args ...interface{}) (*ExplainResult, error) {
+	if len(mode) == 0 {
+		mode = ExplainPlan
+	}
+	result := ExplainResult{Mode: mode}
+	rows, err := conn.Query(ctx, "EXPLAIN "+string(mode)+" "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if mode.kind() == ExplainEstimate {
+			var estimate TableEstimate
+			if err := rows.ScanStruct(&estimate); err != nil {
+				return nil, err
+			}
+			result.Estimates = append(result.Estimates, estimate)
+			continue
+		}
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		result.Lines = append(result.Lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	switch mode.kind() {
+	case ExplainEstimate, ExplainSyntax:
+	default:
+		result.Nodes = parseExplainTree(result.Lines)
+	}
+	return &result, nil
+}
+
+func parseExplainTree(lines []string) []*ExplainNode {
+	type level struct {
+		indent int
+		node   *ExplainNode
+	}
+	var (
+		roots []*ExplainNode
+		stack []level
+	)
+	for _, line := range lines {
+		text := strings.TrimLeft(line, " ")
+		if len(strings.TrimSpace(text)) == 0 {
+			continue
+		}
+		var (
+			indent = len(line) - len(text)
+			node   = &ExplainNode{
+				Name: strings.Fields(text)[0],
+				Text: strings.TrimRight(text, " "),
+			}
+		)
+		for len(stack) != 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, node)
+		} else {
+			parent := stack[len(stack)-1].node
+			parent.Children = append(parent.Children, node)
+		}
+		stack = append(stack, level{indent: indent, node: node})
+	}
+	return roots
+}
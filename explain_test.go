@@ -0,0 +1,76 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExplainTree(t *testing.T) {
+	result := ExplainResult{
+		Nodes: parseExplainTree([]string{
+			"Expression ((Projection + Before ORDER BY))",
+			"  Filter (WHERE)",
+			"    ReadFromMergeTree (default.events)",
+			"  ",
+			"Union",
+			"  ReadFromStorage (SystemOne)",
+			"  ReadFromStorage (SystemOne)",
+		}),
+	}
+	require.Len(t, result.Nodes, 2)
+	assert.Equal(t, "Expression", result.Nodes[0].Name)
+	assert.Equal(t, "Expression ((Projection + Before ORDER BY))", result.Nodes[0].Text)
+	require.Len(t, result.Nodes[0].Children, 1)
+	assert.Equal(t, "Filter", result.Nodes[0].Children[0].Name)
+	reads := result.Find("ReadFromMergeTree")
+	require.Len(t, reads, 1)
+	assert.Equal(t, "ReadFromMergeTree (default.events)", reads[0].Text)
+	assert.Len(t, result.Nodes[1].Children, 2)
+	var depths []int
+	result.Walk(func(node *ExplainNode, depth int) bool {
+		depths = append(depths, depth)
+		return node.Name != "Union"
+	})
+	assert.Equal(t, []int{0, 1, 2, 0}, depths)
+}
+
+func TestParseExplainAST(t *testing.T) {
+	nodes := parseExplainTree([]string{
+		"SelectWithUnionQuery (children 1)",
+		" ExpressionList (children 1)",
+		"  SelectQuery (children 2)",
+		"   ExpressionList (children 1)",
+		"    Literal UInt64_1",
+		"   TablesInSelectQuery (children 1)",
+	})
+	require.Len(t, nodes, 1)
+	query := nodes[0].Children[0].Children[0]
+	assert.Equal(t, "SelectQuery", query.Name)
+	require.Len(t, query.Children, 2)
+	assert.Equal(t, "TablesInSelectQuery", query.Children[1].Name)
+}
+
+func TestExplainModeKind(t *testing.T) {
+	assert.Equal(t, ExplainPlan, (ExplainPlan + " indexes = 1").kind())
+	assert.Equal(t, ExplainEstimate, ExplainMode("estimate").kind())
+	assert.Equal(t, ExplainPlan, ExplainMode("").kind())
+}
@@ -0,0 +1,265 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package flight serves ClickHouse query results as Arrow record batches over gRPC using the
+// Arrow Flight SQL protocol. Queries run on the driver.Conn given to New, so they share its
+// connection pool, and results are streamed in batches of Config.BatchSize rows.
+//
+//	conn, _ := clickhouse.Open(&clickhouse.Options{Addr: []string{"127.0.0.1:9000"}})
+//	flightServer, _ := flight.New(conn, flight.Config{Authorize: flight.ReadOnly})
+//	server := arrowflight.NewServerWithMiddleware(nil)
+//	server.RegisterFlightService(flightServer.Service())
+//	server.Init("0.0.0.0:32010")
+//	server.Serve()
+//
+// The server runs the SQL sent by its clients with the user of conn: any client able to reach it
+// can run any statement that user is allowed to. Tickets carry the query text and are not signed,
+// so a client can run a query with DoGet without asking for its FlightInfo first. Every query,
+// whether from a FlightInfo or schema request, a ticket or an update, is therefore passed to
+// Config.Authorize before it reaches ClickHouse. Connect with a user restricted to what the
+// clients may do, and authenticate the clients with the middleware and TLS of the arrowflight.Server.
+//
+// The package is a separate module so that the Arrow dependencies are not required by the driver.
+package flight
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	arrowflight "github.com/apache/arrow/go/v12/arrow/flight"
+	"github.com/apache/arrow/go/v12/arrow/flight/flightsql"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrUnsupportedType = errors.New("clickhouse [flight]: unsupported column type")
+	ErrNoAuthorizer    = errors.New("clickhouse [flight]: Config.Authorize is required")
+	ErrNotRead         = errors.New("clickhouse [flight]: only read statements are allowed")
+)
+
+// Authorizer decides whether a query sent by a client may run. A non-nil error is returned to the
+// client with the PermissionDenied code and the query is not sent to ClickHouse.
+type Authorizer func(ctx context.Context, query string) error
+
+// ReadOnly allows the statements classified as reads by clickhouse.StatementKind, e.g. SELECT or SHOW,
+// which also rejects every update.
+func ReadOnly(_ context.Context, query string) error {
+	if clickhouse.StatementKind(query) != clickhouse.StatementRead {
+		return ErrNotRead
+	}
+	return nil
+}
+
+// AllowAll allows every query. It is meant for servers whose clients are all trusted, as they can
+// run any statement the user of the connection is allowed to.
+func AllowAll(context.Context, string) error {
+	return nil
+}
+
+type Config struct {
+	// Authorize is called with every query before it runs, see Authorizer. Required.
+	Authorize Authorizer
+	// BatchSize is the maximum number of rows in a record batch. Default 4096.
+	BatchSize int
+	// Allocator is used for the record batches. Default memory.DefaultAllocator.
+	Allocator memory.Allocator
+}
+
+func (c Config) setDefaults() Config {
+	if c.BatchSize <= 0 {
+		c.BatchSize = 4096
+	}
+	if c.Allocator == nil {
+		c.Allocator = memory.DefaultAllocator
+	}
+	return c
+}
+
+// Server implements the statement commands of flightsql.Server on top of a driver.Conn.
+// Catalog, prepared statement and transaction commands are not implemented.
+type Server struct {
+	flightsql.BaseServer
+	conn   driver.Conn
+	config Config
+}
+
+// New returns a Server running the queries on conn. It returns ErrNoAuthorizer if config.Authorize is nil.
+func New(conn driver.Conn, config Config) (*Server, error) {
+	if config.Authorize == nil {
+		return nil, ErrNoAuthorizer
+	}
+	config = config.setDefaults()
+	s := &Server{conn: conn, config: config}
+	s.Alloc = config.Allocator
+	return s, nil
+}
+
+// Service returns the Flight service to register with an arrowflight.Server.
+func (s *Server) Service() arrowflight.FlightServer {
+	return flightsql.NewFlightServerWithAllocator(s, s.config.Allocator)
+}
+
+func (s *Server) GetFlightInfoStatement(ctx context.Context, cmd flightsql.StatementQuery, desc *arrowflight.FlightDescriptor) (*arrowflight.FlightInfo, error) {
+	if err := s.authorize(ctx, cmd.GetQuery()); err != nil {
+		return nil, err
+	}
+	ticket, err := flightsql.CreateStatementQueryTicket([]byte(cmd.GetQuery()))
+	if err != nil {
+		return nil, err
+	}
+	schema, err := s.schema(ctx, cmd.GetQuery())
+	if err != nil {
+		return nil, err
+	}
+	return &arrowflight.FlightInfo{
+		Schema:           arrowflight.SerializeSchema(schema, s.config.Allocator),
+		Endpoint:         []*arrowflight.FlightEndpoint{{Ticket: &arrowflight.Ticket{Ticket: ticket}}},
+		FlightDescriptor: desc,
+		TotalRecords:     -1,
+		TotalBytes:       -1,
+	}, nil
+}
+
+func (s *Server) GetSchemaStatement(ctx context.Context, cmd flightsql.StatementQuery, desc *arrowflight.FlightDescriptor) (*arrowflight.SchemaResult, error) {
+	if err := s.authorize(ctx, cmd.GetQuery()); err != nil {
+		return nil, err
+	}
+	schema, err := s.schema(ctx, cmd.GetQuery())
+	if err != nil {
+		return nil, err
+	}
+	return &arrowflight.SchemaResult{Schema: arrowflight.SerializeSchema(schema, s.config.Allocator)}, nil
+}
+
+// DoGetStatement runs the query and streams its result. The rows are read in a separate goroutine
+// which stops when ctx is cancelled.
+func (s *Server) DoGetStatement(ctx context.Context, ticket flightsql.StatementQueryTicket) (*arrow.Schema, <-chan arrowflight.StreamChunk, error) {
+	query := string(ticket.GetStatementHandle())
+	if err := s.authorize(ctx, query); err != nil {
+		return nil, nil, err
+	}
+	rows, err := s.conn.Query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	columns := rows.ColumnTypes()
+	fields := make([]arrow.Field, 0, len(columns))
+	for _, c := range columns {
+		field, err := Field(c.Name(), c.DatabaseTypeName())
+		if err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		fields = append(fields, field)
+	}
+	schema := arrow.NewSchema(fields, nil)
+	ch := make(chan arrowflight.StreamChunk)
+	go s.stream(ctx, rows, schema, ch)
+	return schema, ch, nil
+}
+
+// DoPutCommandStatementUpdate executes a statement. ClickHouse does not report affected rows, so the
+// returned count is always -1 (unknown).
+func (s *Server) DoPutCommandStatementUpdate(ctx context.Context, cmd flightsql.StatementUpdate) (int64, error) {
+	if err := s.authorize(ctx, cmd.GetQuery()); err != nil {
+		return 0, err
+	}
+	if err := s.conn.Exec(ctx, cmd.GetQuery()); err != nil {
+		return 0, err
+	}
+	return -1, nil
+}
+
+func (s *Server) authorize(ctx context.Context, query string) error {
+	if err := s.config.Authorize(ctx, query); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
+
+func (s *Server) schema(ctx context.Context, query string) (*arrow.Schema, error) {
+	columns, err := clickhouse.DescribeTable(ctx, s.conn, "("+query+")")
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]arrow.Field, 0, len(columns))
+	for _, c := range columns {
+		field, err := Field(c.Name, c.Type)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return arrow.NewSchema(fields, nil), nil
+}
+
+func (s *Server) stream(ctx context.Context, rows driver.Rows, schema *arrow.Schema, ch chan<- arrowflight.StreamChunk) {
+	defer close(ch)
+	defer rows.Close()
+	var (
+		builder = array.NewRecordBuilder(s.config.Allocator, schema)
+		dest    = make([]interface{}, len(schema.Fields()))
+		n       int
+	)
+	defer builder.Release()
+	for i, c := range rows.ColumnTypes() {
+		dest[i] = reflect.New(c.ScanType()).Interface()
+	}
+	send := func(chunk arrowflight.StreamChunk) bool {
+		select {
+		case ch <- chunk:
+			return true
+		case <-ctx.Done():
+			if chunk.Data != nil {
+				chunk.Data.Release()
+			}
+			return false
+		}
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			send(arrowflight.StreamChunk{Err: err})
+			return
+		}
+		for i, v := range dest {
+			if err := Append(builder.Field(i), reflect.ValueOf(v).Elem().Interface()); err != nil {
+				send(arrowflight.StreamChunk{Err: err})
+				return
+			}
+		}
+		if n++; n == s.config.BatchSize {
+			if !send(arrowflight.StreamChunk{Data: builder.NewRecord()}) {
+				return
+			}
+			n = 0
+		}
+	}
+	if err := rows.Err(); err != nil {
+		send(arrowflight.StreamChunk{Err: err})
+		return
+	}
+	if n != 0 {
+		send(arrowflight.StreamChunk{Data: builder.NewRecord()})
+	}
+}
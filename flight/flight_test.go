@@ -0,0 +1,94 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package flight
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errNoServer = errors.New("no server")
+
+type recordingConn struct {
+	driver.Conn
+	queries []string
+}
+
+func (c *recordingConn) Query(_ context.Context, query string, _ ...interface{}) (driver.Rows, error) {
+	c.queries = append(c.queries, query)
+	return nil, errNoServer
+}
+
+func (c *recordingConn) Exec(_ context.Context, query string, _ ...interface{}) error {
+	c.queries = append(c.queries, query)
+	return nil
+}
+
+type statement string
+
+func (s statement) GetQuery() string           { return string(s) }
+func (s statement) GetTransactionId() []byte   { return nil }
+func (s statement) GetStatementHandle() []byte { return []byte(s) }
+
+func TestAuthorize(t *testing.T) {
+	_, err := New(&recordingConn{}, Config{})
+	require.ErrorIs(t, err, ErrNoAuthorizer)
+
+	var (
+		ctx  = context.Background()
+		conn = &recordingConn{}
+	)
+	s, err := New(conn, Config{Authorize: ReadOnly})
+	require.NoError(t, err)
+
+	_, _, err = s.DoGetStatement(ctx, statement("DROP TABLE t"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = s.DoPutCommandStatementUpdate(ctx, statement("INSERT INTO t VALUES (1)"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = s.GetSchemaStatement(ctx, statement("ALTER TABLE t DELETE WHERE 1"), nil)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = s.GetFlightInfoStatement(ctx, statement("TRUNCATE TABLE t"), nil)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Empty(t, conn.queries)
+
+	_, _, err = s.DoGetStatement(ctx, statement("SELECT 1"))
+	assert.ErrorIs(t, err, errNoServer)
+	assert.Equal(t, []string{"SELECT 1"}, conn.queries)
+
+	conn.queries = nil
+	s, err = New(conn, Config{Authorize: func(_ context.Context, query string) error {
+		if query != "INSERT INTO t VALUES (1)" {
+			return errors.New("denied")
+		}
+		return nil
+	}})
+	require.NoError(t, err)
+	n, err := s.DoPutCommandStatementUpdate(ctx, statement("INSERT INTO t VALUES (1)"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), n)
+	_, err = s.DoPutCommandStatementUpdate(ctx, statement("DROP TABLE t"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, []string{"INSERT INTO t VALUES (1)"}, conn.queries)
+}
@@ -0,0 +1,356 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package flight
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/decimal128"
+	"github.com/apache/arrow/go/v12/arrow/decimal256"
+	"github.com/shopspring/decimal"
+)
+
+// Field returns the Arrow field for a ClickHouse column. Types without a direct Arrow
+// counterpart (e.g. UUID, Enum, IPv4, Tuple or the 128 and 256 bit integers) are served as strings.
+func Field(name, chType string) (arrow.Field, error) {
+	nullable := false
+	if inner, ok := unwrap(chType, "Nullable"); ok {
+		nullable, chType = true, inner
+	}
+	dt, err := DataType(chType)
+	if err != nil {
+		return arrow.Field{}, err
+	}
+	return arrow.Field{Name: name, Type: dt, Nullable: nullable}, nil
+}
+
+// DataType returns the Arrow type for a ClickHouse type.
+func DataType(chType string) (arrow.DataType, error) {
+	if inner, ok := unwrap(chType, "LowCardinality"); ok {
+		return DataType(inner)
+	}
+	if inner, ok := unwrap(chType, "Nullable"); ok {
+		return DataType(inner)
+	}
+	if inner, ok := unwrap(chType, "Array"); ok {
+		elem, err := DataType(inner)
+		if err != nil {
+			return nil, err
+		}
+		return arrow.ListOf(elem), nil
+	}
+	if inner, ok := unwrap(chType, "Map"); ok {
+		params := split(inner)
+		if len(params) != 2 {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, chType)
+		}
+		key, err := DataType(params[0])
+		if err != nil {
+			return nil, err
+		}
+		item, err := DataType(params[1])
+		if err != nil {
+			return nil, err
+		}
+		return arrow.MapOf(key, item), nil
+	}
+	switch chType {
+	case "Int8":
+		return arrow.PrimitiveTypes.Int8, nil
+	case "Int16":
+		return arrow.PrimitiveTypes.Int16, nil
+	case "Int32":
+		return arrow.PrimitiveTypes.Int32, nil
+	case "Int64":
+		return arrow.PrimitiveTypes.Int64, nil
+	case "UInt8":
+		return arrow.PrimitiveTypes.Uint8, nil
+	case "UInt16":
+		return arrow.PrimitiveTypes.Uint16, nil
+	case "UInt32":
+		return arrow.PrimitiveTypes.Uint32, nil
+	case "UInt64":
+		return arrow.PrimitiveTypes.Uint64, nil
+	case "Float32":
+		return arrow.PrimitiveTypes.Float32, nil
+	case "Float64":
+		return arrow.PrimitiveTypes.Float64, nil
+	case "Bool":
+		return arrow.FixedWidthTypes.Boolean, nil
+	case "Date", "Date32":
+		return arrow.FixedWidthTypes.Date32, nil
+	case "DateTime":
+		return &arrow.TimestampType{Unit: arrow.Second, TimeZone: "UTC"}, nil
+	}
+	if inner, ok := unwrap(chType, "DateTime"); ok {
+		return &arrow.TimestampType{Unit: arrow.Second, TimeZone: unquote(inner)}, nil
+	}
+	if inner, ok := unwrap(chType, "DateTime64"); ok {
+		params := split(inner)
+		precision, err := strconv.Atoi(params[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, chType)
+		}
+		timestamp := &arrow.TimestampType{Unit: arrow.Nanosecond, TimeZone: "UTC"}
+		switch {
+		case precision == 0:
+			timestamp.Unit = arrow.Second
+		case precision <= 3:
+			timestamp.Unit = arrow.Millisecond
+		case precision <= 6:
+			timestamp.Unit = arrow.Microsecond
+		}
+		if len(params) > 1 {
+			timestamp.TimeZone = unquote(params[1])
+		}
+		return timestamp, nil
+	}
+	if precision, scale, ok := decimalParams(chType); ok {
+		if precision > 38 {
+			return &arrow.Decimal256Type{Precision: int32(precision), Scale: int32(scale)}, nil
+		}
+		return &arrow.Decimal128Type{Precision: int32(precision), Scale: int32(scale)}, nil
+	}
+	return arrow.BinaryTypes.String, nil
+}
+
+// Append appends a value scanned from a ClickHouse column to b. Nil pointers are appended as nulls.
+func Append(b array.Builder, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			b.AppendNull()
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		b.AppendNull()
+		return nil
+	}
+	v = rv.Interface()
+	unsupported := func() error {
+		return fmt.Errorf("%w: %T as %s", ErrUnsupportedType, v, b.Type())
+	}
+	switch b := b.(type) {
+	case *array.Int8Builder:
+		if !isInt(rv) {
+			return unsupported()
+		}
+		b.Append(int8(rv.Int()))
+	case *array.Int16Builder:
+		if !isInt(rv) {
+			return unsupported()
+		}
+		b.Append(int16(rv.Int()))
+	case *array.Int32Builder:
+		if !isInt(rv) {
+			return unsupported()
+		}
+		b.Append(int32(rv.Int()))
+	case *array.Int64Builder:
+		if !isInt(rv) {
+			return unsupported()
+		}
+		b.Append(rv.Int())
+	case *array.Uint8Builder:
+		if !isUint(rv) {
+			return unsupported()
+		}
+		b.Append(uint8(rv.Uint()))
+	case *array.Uint16Builder:
+		if !isUint(rv) {
+			return unsupported()
+		}
+		b.Append(uint16(rv.Uint()))
+	case *array.Uint32Builder:
+		if !isUint(rv) {
+			return unsupported()
+		}
+		b.Append(uint32(rv.Uint()))
+	case *array.Uint64Builder:
+		if !isUint(rv) {
+			return unsupported()
+		}
+		b.Append(rv.Uint())
+	case *array.Float32Builder:
+		if rv.Kind() != reflect.Float32 && rv.Kind() != reflect.Float64 {
+			return unsupported()
+		}
+		b.Append(float32(rv.Float()))
+	case *array.Float64Builder:
+		if rv.Kind() != reflect.Float32 && rv.Kind() != reflect.Float64 {
+			return unsupported()
+		}
+		b.Append(rv.Float())
+	case *array.BooleanBuilder:
+		if rv.Kind() != reflect.Bool {
+			return unsupported()
+		}
+		b.Append(rv.Bool())
+	case *array.StringBuilder:
+		if rv.Kind() == reflect.String {
+			b.Append(rv.String())
+		} else {
+			b.Append(fmt.Sprint(v))
+		}
+	case *array.Date32Builder:
+		t, ok := v.(time.Time)
+		if !ok {
+			return unsupported()
+		}
+		b.Append(arrow.Date32FromTime(t))
+	case *array.TimestampBuilder:
+		t, ok := v.(time.Time)
+		if !ok {
+			return unsupported()
+		}
+		switch b.Type().(*arrow.TimestampType).Unit {
+		case arrow.Second:
+			b.Append(arrow.Timestamp(t.Unix()))
+		case arrow.Millisecond:
+			b.Append(arrow.Timestamp(t.UnixMilli()))
+		case arrow.Microsecond:
+			b.Append(arrow.Timestamp(t.UnixMicro()))
+		default:
+			b.Append(arrow.Timestamp(t.UnixNano()))
+		}
+	case *array.Decimal128Builder:
+		d, ok := v.(decimal.Decimal)
+		if !ok {
+			return unsupported()
+		}
+		b.Append(decimal128.FromBigInt(d.Shift(b.Type().(*arrow.Decimal128Type).Scale).BigInt()))
+	case *array.Decimal256Builder:
+		d, ok := v.(decimal.Decimal)
+		if !ok {
+			return unsupported()
+		}
+		b.Append(decimal256.FromBigInt(d.Shift(b.Type().(*arrow.Decimal256Type).Scale).BigInt()))
+	case *array.MapBuilder:
+		if rv.Kind() != reflect.Map {
+			return unsupported()
+		}
+		b.Append(true)
+		iter := rv.MapRange()
+		for iter.Next() {
+			if err := Append(b.KeyBuilder(), iter.Key().Interface()); err != nil {
+				return err
+			}
+			if err := Append(b.ItemBuilder(), iter.Value().Interface()); err != nil {
+				return err
+			}
+		}
+	case *array.ListBuilder:
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return unsupported()
+		}
+		b.Append(true)
+		for i := 0; i < rv.Len(); i++ {
+			if err := Append(b.ValueBuilder(), rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	default:
+		return unsupported()
+	}
+	return nil
+}
+
+func isInt(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isUint(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// unwrap returns the parameters of chType if it is name(...).
+func unwrap(chType, name string) (string, bool) {
+	if strings.HasPrefix(chType, name+"(") && strings.HasSuffix(chType, ")") {
+		return chType[len(name)+1 : len(chType)-1], true
+	}
+	return "", false
+}
+
+// split splits type parameters on the commas which are not nested in parentheses or quotes.
+func split(params string) []string {
+	var (
+		parts  []string
+		depth  int
+		quoted bool
+		start  int
+	)
+	for i := 0; i < len(params); i++ {
+		switch c := params[i]; {
+		case c == '\'' && (i == 0 || params[i-1] != '\\'):
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(params[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(params[start:]))
+}
+
+func unquote(s string) string {
+	return strings.Trim(strings.TrimSpace(s), "'")
+}
+
+func decimalParams(chType string) (precision, scale int, ok bool) {
+	for name, precision := range map[string]int{"Decimal32": 9, "Decimal64": 18, "Decimal128": 38, "Decimal256": 76} {
+		if inner, found := unwrap(chType, name); found {
+			scale, err := strconv.Atoi(strings.TrimSpace(inner))
+			return precision, scale, err == nil
+		}
+	}
+	inner, found := unwrap(chType, "Decimal")
+	if !found {
+		return 0, 0, false
+	}
+	params := split(inner)
+	precision, err := strconv.Atoi(params[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	if len(params) > 1 {
+		if scale, err = strconv.Atoi(params[1]); err != nil {
+			return 0, 0, false
+		}
+	}
+	return precision, scale, true
+}
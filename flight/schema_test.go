@@ -0,0 +1,88 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package flight
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestField(t *testing.T) {
+	for chType, expected := range map[string]arrow.Field{
+		"UInt64":                             {Name: "c", Type: arrow.PrimitiveTypes.Uint64},
+		"Nullable(Int32)":                    {Name: "c", Type: arrow.PrimitiveTypes.Int32, Nullable: true},
+		"LowCardinality(Nullable(String))":   {Name: "c", Type: arrow.BinaryTypes.String},
+		"Array(Nullable(Float64))":           {Name: "c", Type: arrow.ListOf(arrow.PrimitiveTypes.Float64)},
+		"Map(String, Array(UInt8))":          {Name: "c", Type: arrow.MapOf(arrow.BinaryTypes.String, arrow.ListOf(arrow.PrimitiveTypes.Uint8))},
+		"DateTime('Europe/Berlin')":          {Name: "c", Type: &arrow.TimestampType{Unit: arrow.Second, TimeZone: "Europe/Berlin"}},
+		"DateTime64(3)":                      {Name: "c", Type: &arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "UTC"}},
+		"DateTime64(9, 'Asia/Tokyo')":        {Name: "c", Type: &arrow.TimestampType{Unit: arrow.Nanosecond, TimeZone: "Asia/Tokyo"}},
+		"Date32":                             {Name: "c", Type: arrow.FixedWidthTypes.Date32},
+		"Decimal(18, 4)":                     {Name: "c", Type: &arrow.Decimal128Type{Precision: 18, Scale: 4}},
+		"Decimal256(10)":                     {Name: "c", Type: &arrow.Decimal256Type{Precision: 76, Scale: 10}},
+		"Enum8('a' = 1, 'b' = 2)":            {Name: "c", Type: arrow.BinaryTypes.String},
+		"Tuple(a String, b Nullable(UInt8))": {Name: "c", Type: arrow.BinaryTypes.String},
+		"UUID":                               {Name: "c", Type: arrow.BinaryTypes.String},
+	} {
+		field, err := Field("c", chType)
+		require.NoError(t, err, chType)
+		assert.True(t, arrow.TypeEqual(expected.Type, field.Type), chType)
+		assert.Equal(t, expected.Nullable, field.Nullable, chType)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	var (
+		str    = "a"
+		fields = []string{"Nullable(String)", "Array(UInt32)", "DateTime64(3)", "Decimal(9, 2)", "Date"}
+		schema = make([]arrow.Field, 0, len(fields))
+	)
+	for _, chType := range fields {
+		field, err := Field(chType, chType)
+		require.NoError(t, err)
+		schema = append(schema, field)
+	}
+	builder := array.NewRecordBuilder(memory.NewGoAllocator(), arrow.NewSchema(schema, nil))
+	defer builder.Release()
+	now := time.Date(2023, 4, 5, 6, 7, 8, 9000000, time.UTC)
+	for _, row := range [][]interface{}{
+		{&str, []uint32{1, 2}, now, decimal.RequireFromString("12.34"), now},
+		{(*string)(nil), []uint32{}, now, decimal.RequireFromString("-0.5"), now},
+	} {
+		for i, v := range row {
+			require.NoError(t, Append(builder.Field(i), v))
+		}
+	}
+	record := builder.NewRecord()
+	defer record.Release()
+	require.Equal(t, int64(2), record.NumRows())
+	assert.Equal(t, "a", record.Column(0).(*array.String).Value(0))
+	assert.True(t, record.Column(0).IsNull(1))
+	assert.Equal(t, `[[1 2] []]`, record.Column(1).String())
+	assert.Equal(t, arrow.Timestamp(now.UnixMilli()), record.Column(2).(*array.Timestamp).Value(0))
+	assert.Equal(t, uint64(1234), record.Column(3).(*array.Decimal128).Value(0).LowBits())
+	assert.Equal(t, arrow.Date32FromTime(now), record.Column(4).(*array.Date32).Value(1))
+	assert.ErrorIs(t, Append(builder.Field(2), "now"), ErrUnsupportedType)
+}
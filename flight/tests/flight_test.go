@@ -0,0 +1,74 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/flight"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	arrowflight "github.com/apache/arrow/go/v12/arrow/flight"
+	"github.com/apache/arrow/go/v12/arrow/flight/flightsql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestFlightServer(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	flightServer, err := flight.New(conn, flight.Config{BatchSize: 300, Authorize: flight.ReadOnly})
+	require.NoError(t, err)
+	server := arrowflight.NewServerWithMiddleware(nil)
+	server.RegisterFlightService(flightServer.Service())
+	require.NoError(t, server.Init("127.0.0.1:0"))
+	go server.Serve()
+	defer server.Shutdown()
+	client, err := flightsql.NewClient(server.Addr().String(), nil, nil, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer client.Close()
+	ctx := context.Background()
+	info, err := client.Execute(ctx, "SELECT number, toString(number) AS str, [number, number + 1] AS arr, if(number % 2 = 0, NULL, number) AS n FROM system.numbers LIMIT 1000")
+	require.NoError(t, err)
+	require.Len(t, info.Endpoint, 1)
+	reader, err := client.DoGet(ctx, info.Endpoint[0].Ticket)
+	require.NoError(t, err)
+	defer reader.Release()
+	assert.Equal(t, []string{"number", "str", "arr", "n"}, []string{
+		reader.Schema().Field(0).Name,
+		reader.Schema().Field(1).Name,
+		reader.Schema().Field(2).Name,
+		reader.Schema().Field(3).Name,
+	})
+	var rows, batches int64
+	for reader.Next() {
+		record := reader.Record()
+		if batches == 0 {
+			assert.Equal(t, uint64(0), record.Column(0).(*array.Uint64).Value(0))
+			assert.Equal(t, "1", record.Column(1).(*array.String).Value(1))
+			assert.True(t, record.Column(3).IsNull(0))
+		}
+		rows += record.NumRows()
+		batches++
+	}
+	require.NoError(t, reader.Err())
+	assert.Equal(t, int64(1000), rows)
+	assert.Equal(t, int64(4), batches)
+}
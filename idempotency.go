@@ -0,0 +1,114 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+const defaultIdempotencyTable = "clickhouse_go_idempotency_keys"
+
+// IdempotencyOptions configures the client side deduplication of inserts into tables without
+// server side deduplication, e.g. non-replicated MergeTree tables.
+type IdempotencyOptions struct {
+	// Table is the side table storing the idempotency keys, optionally qualified with its database.
+	// It is created if missing. Default clickhouse_go_idempotency_keys.
+	Table string
+	// Window is how long a key deduplicates inserts. Older keys are removed by the TTL of the side table. Default 24 hours.
+	Window time.Duration
+}
+
+// Idempotency deduplicates inserts by user provided idempotency keys stored in a side table, giving
+// retry safety where insert_deduplication_token is not available.
+//
+// The key is recorded after the insert succeeded, so an insert whose key could not be recorded is
+// repeated by a retry, and concurrent inserts with the same key are not deduplicated.
+type Idempotency struct {
+	conn   driver.Conn
+	table  string
+	window time.Duration
+}
+
+// NewIdempotency creates the side table of opts if it does not exist yet.
+func NewIdempotency(ctx context.Context, conn driver.Conn, opts IdempotencyOptions) (*Idempotency, error) {
+	if len(opts.Table) == 0 {
+		opts.Table = defaultIdempotencyTable
+	}
+	if opts.Window <= 0 {
+		opts.Window = 24 * time.Hour
+	}
+	i := &Idempotency{
+		conn:   conn,
+		table:  opts.Table,
+		window: opts.Window,
+	}
+	if err := conn.Exec(ctx, i.ddl()); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+func (i *Idempotency) ddl() string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		key String,
+		inserted_at DateTime
+	) Engine ReplacingMergeTree(inserted_at) ORDER BY key TTL inserted_at + INTERVAL %d SECOND`, i.table, i.seconds())
+}
+
+func (i *Idempotency) seconds() int64 {
+	if seconds := int64(i.window / time.Second); seconds > 0 {
+		return seconds
+	}
+	return 1
+}
+
+// Seen reports whether key was recorded within the window.
+func (i *Idempotency) Seen(ctx context.Context, key string) (bool, error) {
+	var count uint64
+	if err := i.conn.QueryRow(ctx, fmt.Sprintf(
+		"SELECT count() FROM %s WHERE key = @key AND inserted_at > now() - INTERVAL %d SECOND", i.table, i.seconds(),
+	), Named("key", key)).Scan(&count); err != nil {
+		return false, err
+	}
+	return count != 0, nil
+}
+
+// Record stores key, deduplicating later inserts with it until the window expired.
+func (i *Idempotency) Record(ctx context.Context, key string) error {
+	return i.conn.Exec(ctx, fmt.Sprintf("INSERT INTO %s (key, inserted_at) VALUES (@key, now())", i.table), Named("key", key))
+}
+
+// Insert calls insert unless key was recorded within the window, and records key once insert succeeded.
+// It reports whether insert was called.
+func (i *Idempotency) Insert(ctx context.Context, key string, insert func(context.Context) error) (bool, error) {
+	seen, err := i.Seen(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if seen {
+		return false, nil
+	}
+	if err := insert(ctx); err != nil {
+		return true, err
+	}
+	return true, i.Record(ctx, key)
+}
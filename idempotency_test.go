@@ -0,0 +1,34 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyDDL(t *testing.T) {
+	i := &Idempotency{table: "db.keys", window: 90 * time.Minute}
+	ddl := i.ddl()
+	assert.Contains(t, ddl, "CREATE TABLE IF NOT EXISTS db.keys (")
+	assert.Contains(t, ddl, "TTL inserted_at + INTERVAL 5400 SECOND")
+	i.window = time.Millisecond
+	assert.Equal(t, int64(1), i.seconds())
+}
@@ -0,0 +1,173 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// IdentifierValue is a bind argument written into the query as a quoted identifier, see Identifier.
+type IdentifierValue struct {
+	parts []string
+}
+
+// Identifier returns a bind argument for a database, table or column name, so that names chosen at runtime,
+// e.g. the table of a tenant, are quoted rather than concatenated into the query. Several parts make a
+// qualified name, Identifier("db", "events") being written as db.events:
+//
+//	conn.Query(ctx, "SELECT count() FROM @table WHERE day = @day",
+//		clickhouse.Named("table", clickhouse.Identifier("events_"+tenant)),
+//		clickhouse.Named("day", day),
+//	)
+//
+// With server side query parameters it is sent as the value of an {name:Identifier} parameter.
+func Identifier(parts ...string) IdentifierValue {
+	return IdentifierValue{parts: parts}
+}
+
+// Name returns the unquoted, dot separated, name.
+func (i IdentifierValue) Name() string {
+	return strings.Join(i.parts, ".")
+}
+
+// quote validates and quotes the parts of the identifier.
+func (i IdentifierValue) quote() (string, error) {
+	if len(i.parts) == 0 {
+		return "", fmt.Errorf("%w: identifier is empty", ErrInvalidIdentifier)
+	}
+	parts := make([]string, len(i.parts))
+	for n, part := range i.parts {
+		if err := validIdentifier(part); err != nil {
+			return "", err
+		}
+		parts[n] = quoteIdentifier(part)
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// QuoteIdentifier quotes name with backticks, escaping the backslashes and backticks it holds, for a
+// database, table or column name written into a statement.
+func QuoteIdentifier(name string) string {
+	return "`" + strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(name) + "`"
+}
+
+// SplitTableName splits a table name, optionally qualified with its database, into the unquoted names of
+// the database and the table. Either part may be quoted with backticks or double quotes, as by
+// QuoteIdentifier, and hold dots: `db`.`a.b` is the table a.b of the database db. The database is empty
+// for an unqualified name.
+func SplitTableName(name string) (database, table string) {
+	end := len(name)
+	if len(name) != 0 && (name[0] == '`' || name[0] == '"') {
+		end = quotedEnd(name)
+	} else if i := strings.IndexByte(name, '.'); i != -1 {
+		end = i
+	}
+	if end < len(name) && name[end] == '.' {
+		return unquoteIdentifier(name[:end]), unquoteIdentifier(name[end+1:])
+	}
+	return "", unquoteIdentifier(name)
+}
+
+// quotedEnd returns the index following the closing quote of the quoted identifier name starts with, or
+// the length of name if it is not closed.
+func quotedEnd(name string) int {
+	quote := name[0]
+	for i := 1; i < len(name); i++ {
+		switch name[i] {
+		case '\\':
+			i++
+		case quote:
+			if i+1 < len(name) && name[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(name)
+}
+
+// unquoteIdentifier returns the name of a quoted identifier, undoing the escapes of its backslashes and
+// quotes. Unquoted names are returned as they are.
+func unquoteIdentifier(name string) string {
+	if len(name) < 2 || (name[0] != '`' && name[0] != '"') || quotedEnd(name) != len(name) || name[len(name)-1] != name[0] {
+		return name
+	}
+	var (
+		quote   = name[0]
+		unquote strings.Builder
+	)
+	for i := 1; i < len(name)-1; i++ {
+		if name[i] == '\\' || name[i] == quote {
+			// an escaped character or a doubled quote
+			i++
+		}
+		unquote.WriteByte(name[i])
+	}
+	return unquote.String()
+}
+
+// validIdentifier rejects the names which cannot be written as a quoted identifier: empty names, invalid
+// UTF-8 and control characters such as NUL or new lines.
+func validIdentifier(name string) error {
+	switch {
+	case len(name) == 0:
+		return fmt.Errorf("%w: name is empty", ErrInvalidIdentifier)
+	case !utf8.ValidString(name):
+		return fmt.Errorf("%w: %q is not valid UTF-8", ErrInvalidIdentifier, name)
+	case strings.IndexFunc(name, unicode.IsControl) != -1:
+		return fmt.Errorf("%w: %q holds a control character", ErrInvalidIdentifier, name)
+	}
+	return nil
+}
+
+var templateRe = regexp.MustCompile(`\{([a-zA-Z_][0-9a-zA-Z_]*)\}`)
+
+// Template replaces the {name} placeholders of query with the quoted identifiers of the same name, for
+// statements which cannot take bind arguments in place of names such as DDL. Server side query
+// parameters, {name:Type}, are left as they are.
+//
+//	query, err := clickhouse.Template("ALTER TABLE {table} DROP PARTITION {partition:String}", map[string]clickhouse.IdentifierValue{
+//		"table": clickhouse.Identifier("events_" + tenant),
+//	})
+func Template(query string, identifiers map[string]IdentifierValue) (string, error) {
+	var err error
+	query = templateRe.ReplaceAllStringFunc(query, func(placeholder string) string {
+		if err != nil {
+			return placeholder
+		}
+		identifier, ok := identifiers[placeholder[1:len(placeholder)-1]]
+		if !ok {
+			err = fmt.Errorf("%w: have no identifier for %s", ErrInvalidIdentifier, placeholder)
+			return placeholder
+		}
+		var quoted string
+		if quoted, err = identifier.quote(); err != nil {
+			return placeholder
+		}
+		return quoted
+	})
+	if err != nil {
+		return "", err
+	}
+	return query, nil
+}
@@ -0,0 +1,96 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindIdentifier(t *testing.T) {
+	query, err := bind(time.Local, "SELECT count() FROM @table WHERE name = @name",
+		Named("table", Identifier("db", "events-1")),
+		Named("name", "events-1"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT count() FROM db.`events-1` WHERE name = 'events-1'", query)
+
+	query, err = bind(time.Local, "SELECT * FROM ? LIMIT ?", Identifier("x` UNION ALL SELECT 1 --"), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `x\\` UNION ALL SELECT 1 --` LIMIT 1", query)
+
+	for _, identifier := range []IdentifierValue{Identifier(), Identifier(""), Identifier("db", ""), Identifier("a\x00b"), Identifier("a\nb"), Identifier("\xff")} {
+		_, err := bind(time.Local, "SELECT * FROM $1", identifier)
+		assert.ErrorIs(t, err, ErrInvalidIdentifier, identifier.Name())
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, "`events`", QuoteIdentifier("events"))
+	assert.Equal(t, "`a\\` UNION ALL SELECT 1 --`", QuoteIdentifier("a` UNION ALL SELECT 1 --"))
+	assert.Equal(t, "`a\\\\`", QuoteIdentifier("a\\"))
+	assert.Equal(t, "`a\\\\\\``", QuoteIdentifier("a\\`"))
+}
+
+func TestSplitTableName(t *testing.T) {
+	for name, expected := range map[string][2]string{
+		"events":                  {"", "events"},
+		"`events`":                {"", "events"},
+		"db.events":               {"db", "events"},
+		"`db`.`events`":           {"db", "events"},
+		"`db`.`a.b`":              {"db", "a.b"},
+		"`d.b`.`a.b`":             {"d.b", "a.b"},
+		"db.`a.b`":                {"db", "a.b"},
+		`"d.b"."a.b"`:             {"d.b", "a.b"},
+		"`a.b`":                   {"", "a.b"},
+		"`d``b`.`a\\`.b`":         {"d`b", "a`.b"},
+		QuoteIdentifier("a\\`.b"): {"", "a\\`.b"},
+	} {
+		database, table := SplitTableName(name)
+		assert.Equal(t, expected, [2]string{database, table}, name)
+	}
+}
+
+func TestIdentifierQueryParameter(t *testing.T) {
+	var options QueryOptions
+	_, err := bindQueryOrAppendParameters(true, &options, "SELECT count() FROM {table:Identifier}", time.Local,
+		Named("table", Identifier("db", "events")),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, Parameters{"table": "db.events"}, options.parameters)
+}
+
+func TestTemplate(t *testing.T) {
+	query, err := Template("ALTER TABLE {table} DROP PARTITION {partition:String}", map[string]IdentifierValue{
+		"table": Identifier("events of tenant"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ALTER TABLE `events of tenant` DROP PARTITION {partition:String}", query)
+
+	_, err = Template("SELECT * FROM {table} JOIN {other} USING id", map[string]IdentifierValue{
+		"table": Identifier("events"),
+	})
+	assert.ErrorIs(t, err, ErrInvalidIdentifier)
+	_, err = Template("SELECT * FROM {table}", map[string]IdentifierValue{
+		"table": Identifier("events\x00"),
+	})
+	assert.ErrorIs(t, err, ErrInvalidIdentifier)
+}
@@ -0,0 +1,92 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var plainIdentifier = regexp.MustCompile("^[a-zA-Z_][0-9a-zA-Z_]*$")
+
+// quoteIdentifier quotes name with backticks unless it is a plain identifier.
+func quoteIdentifier(name string) string {
+	if plainIdentifier.MatchString(name) {
+		return name
+	}
+	return QuoteIdentifier(name)
+}
+
+// StructColumns returns the column names of the exported fields of the struct v (or pointer to it),
+// in field order, using the ch tag where present.
+func StructColumns(v interface{}) ([]string, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, &OpError{
+			Op:  "StructColumns",
+			Err: fmt.Errorf("expects a struct, got %T", v),
+		}
+	}
+	return structColumns(t), nil
+}
+
+func structColumns(t reflect.Type) (columns []string) {
+	for i := 0; i < t.NumField(); i++ {
+		var (
+			f    = t.Field(i)
+			name = f.Name
+		)
+		if tn := f.Tag.Get("ch"); len(tn) != 0 {
+			name = tn
+		}
+		switch {
+		case name == "-", len(f.PkgPath) != 0 && !f.Anonymous:
+			continue
+		}
+		switch {
+		case f.Anonymous:
+			if f.Type.Kind() != reflect.Ptr {
+				columns = append(columns, structColumns(f.Type)...)
+			}
+		default:
+			columns = append(columns, name)
+		}
+	}
+	return columns
+}
+
+// StructInsertQuery builds an INSERT statement for table listing only the columns present in the
+// struct v. Table columns which are not listed are filled by the server with their DEFAULT expressions.
+//
+//	batch, err := conn.PrepareBatch(ctx, clickhouse.StructInsertQuery("events", &Event{}))
+func StructInsertQuery(table string, v interface{}) (string, error) {
+	columns, err := StructColumns(v)
+	if err != nil {
+		return "", err
+	}
+	quoted := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quoted = append(quoted, quoteIdentifier(column))
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s)", table, strings.Join(quoted, ", ")), nil
+}
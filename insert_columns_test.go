@@ -0,0 +1,47 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructInsertQuery(t *testing.T) {
+	type Embed struct {
+		Source string `ch:"source"`
+	}
+	type Event struct {
+		ID      uint64 `ch:"id"`
+		Name    string
+		Ignored string `ch:"-"`
+		private string
+		Embed
+		Dashed string `ch:"user-agent"`
+	}
+	columns, err := StructColumns(&Event{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "Name", "source", "user-agent"}, columns)
+	query, err := StructInsertQuery("events", Event{})
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO events (id, Name, source, `user-agent`)", query)
+	_, err = StructInsertQuery("events", 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,66 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import "github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+
+// InsertProgress is the progress of a batch, see WithInsertProgress. The counters are those of the
+// current insert: they restart when the batch is Reset or retried.
+type InsertProgress struct {
+	Blocks       int    // blocks of rows written to the server
+	Rows         uint64 // rows written to the server
+	Bytes        uint64 // bytes written to the server, after compression
+	WrittenRows  uint64 // rows the server acknowledged in Progress packets
+	WrittenBytes uint64 // bytes the server acknowledged in Progress packets, uncompressed
+	Done         bool   // the server completed the insert
+}
+
+// observeProgress reports the Progress packets of the insert to WithInsertProgress.
+func (b *batch) observeProgress() {
+	b.progress = InsertProgress{}
+	if b.onProcess.insertProgress == nil {
+		return
+	}
+	progress := b.onProcess.progress
+	b.onProcess.progress = func(p *Progress) {
+		b.progress.WrittenRows += p.WroteRows
+		b.progress.WrittenBytes += p.WroteBytes
+		b.reportProgress()
+		progress(p)
+	}
+}
+
+// sendBlock writes a block of rows of the insert to the server.
+func (b *batch) sendBlock(block *proto.Block) error {
+	written := b.conn.written
+	if err := b.conn.sendData(block, ""); err != nil {
+		return err
+	}
+	b.progress.Blocks++
+	b.progress.Rows += uint64(block.Rows())
+	b.progress.Bytes += b.conn.written - written
+	b.reportProgress()
+	return nil
+}
+
+func (b *batch) reportProgress() {
+	if b.onProcess.insertProgress != nil {
+		progress := b.progress
+		b.onProcess.insertProgress(&progress)
+	}
+}
@@ -0,0 +1,177 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// InsertThrottleOptions configures an InsertThrottle.
+type InsertThrottleOptions struct {
+	// MinDelay is the delay after the first backpressure signal, doubled by every further one. Default 100ms.
+	MinDelay time.Duration
+	// MaxDelay caps the delay. Default 30 seconds.
+	MaxDelay time.Duration
+	// Pressure optionally reports the parts pressure of a table, 1 meaning that the server rejects inserts,
+	// e.g. monitoring.Pressure. It is polled at most once per PressureInterval (default 10 seconds) per table.
+	Pressure         func(ctx context.Context, table string) (float64, error)
+	PressureInterval time.Duration
+	// PressureThreshold is the pressure from which inserts are slowed down. Default 0.5.
+	PressureThreshold float64
+}
+
+// InsertThrottle slows down the batches of a table while the server signals too many parts: inserts
+// delayed by the server (the DelayedInserts profile event), inserts rejected with ErrTooManyParts and,
+// optionally, a high Pressure. Each signal doubles the delay applied before preparing the next batch of
+// the table, each batch sent without a signal halves it. It applies to native connections, see
+// Options.InsertThrottle, and is safe for concurrent use.
+type InsertThrottle struct {
+	opts   InsertThrottleOptions
+	mu     sync.Mutex
+	tables map[string]*throttleState
+}
+
+type throttleState struct {
+	delay    time.Duration
+	polledAt time.Time
+}
+
+func NewInsertThrottle(opts InsertThrottleOptions) *InsertThrottle {
+	if opts.MinDelay <= 0 {
+		opts.MinDelay = 100 * time.Millisecond
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 30 * time.Second
+	}
+	if opts.PressureInterval <= 0 {
+		opts.PressureInterval = 10 * time.Second
+	}
+	if opts.PressureThreshold <= 0 {
+		opts.PressureThreshold = 0.5
+	}
+	return &InsertThrottle{
+		opts:   opts,
+		tables: make(map[string]*throttleState),
+	}
+}
+
+// Delay returns the current delay of the batches of table.
+func (t *InsertThrottle) Delay(table string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if state, found := t.tables[table]; found {
+		return state.delay
+	}
+	return 0
+}
+
+func (t *InsertThrottle) state(table string) *throttleState {
+	state, found := t.tables[table]
+	if !found {
+		state = &throttleState{}
+		t.tables[table] = state
+	}
+	return state
+}
+
+// wait polls the pressure of the table of the insert query if due and sleeps for its delay.
+// A nil InsertThrottle does not wait.
+func (t *InsertThrottle) wait(ctx context.Context, query string) error {
+	if t == nil {
+		return nil
+	}
+	table := insertTable(query)
+	if len(table) == 0 {
+		return nil
+	}
+	if t.opts.Pressure != nil {
+		t.mu.Lock()
+		state := t.state(table)
+		due := time.Since(state.polledAt) >= t.opts.PressureInterval
+		if due {
+			state.polledAt = time.Now()
+		}
+		t.mu.Unlock()
+		if due {
+			// a failed poll is not a signal, the inserts report the server state as well
+			if pressure, err := t.opts.Pressure(ctx, table); err == nil && pressure >= t.opts.PressureThreshold {
+				t.observe(table, true)
+			}
+		}
+	}
+	delay := t.Delay(table)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// observe doubles the delay of table on a backpressure signal and halves it otherwise.
+func (t *InsertThrottle) observe(table string, signal bool) {
+	if t == nil || len(table) == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state := t.state(table)
+	switch {
+	case signal && state.delay < t.opts.MinDelay:
+		state.delay = t.opts.MinDelay
+	case signal:
+		if state.delay *= 2; state.delay > t.opts.MaxDelay {
+			state.delay = t.opts.MaxDelay
+		}
+	default:
+		if state.delay /= 2; state.delay < t.opts.MinDelay {
+			state.delay = 0
+		}
+	}
+}
+
+// backpressure reports whether the result of a batch is a too many parts signal.
+func backpressure(delayed bool, err error) bool {
+	return delayed || errors.Is(err, ErrTooManyParts)
+}
+
+// delayedInsert reports whether the server delayed the insert because of too many parts.
+func delayedInsert(events []ProfileEvent) bool {
+	for _, e := range events {
+		if e.Name == "DelayedInserts" && e.Value > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// insertTable returns the table of an INSERT statement.
+func insertTable(query string) string {
+	if tables := TablesReferenced(query); len(tables) != 0 {
+		return tables[0]
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertThrottleObserve(t *testing.T) {
+	throttle := NewInsertThrottle(InsertThrottleOptions{MinDelay: 100 * time.Millisecond, MaxDelay: time.Second})
+	for _, expected := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		throttle.observe("events", true)
+		assert.Equal(t, expected*time.Millisecond, throttle.Delay("events"))
+	}
+	assert.Zero(t, throttle.Delay("other"))
+	for _, expected := range []time.Duration{500, 250, 125, 0} {
+		throttle.observe("events", false)
+		assert.Equal(t, expected*time.Millisecond, throttle.Delay("events"))
+	}
+	var none *InsertThrottle
+	none.observe("events", true)
+	assert.NoError(t, none.wait(context.Background(), "INSERT INTO events"))
+}
+
+func TestInsertThrottleWait(t *testing.T) {
+	var polls []string
+	throttle := NewInsertThrottle(InsertThrottleOptions{
+		MinDelay:         50 * time.Millisecond,
+		PressureInterval: time.Hour,
+		Pressure: func(ctx context.Context, table string) (float64, error) {
+			polls = append(polls, table)
+			return 0.8, nil
+		},
+	})
+	start := time.Now()
+	require.NoError(t, throttle.wait(context.Background(), "INSERT INTO db.events (a, b) VALUES"))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	require.NoError(t, throttle.wait(context.Background(), "INSERT INTO db.events"))
+	assert.Equal(t, []string{"db.events"}, polls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, throttle.wait(ctx, "INSERT INTO db.events"), context.Canceled)
+}
+
+func TestInsertThrottleSignals(t *testing.T) {
+	assert.True(t, delayedInsert([]ProfileEvent{{Name: "InsertedRows", Value: 10}, {Name: "DelayedInserts", Value: 1}}))
+	assert.False(t, delayedInsert([]ProfileEvent{{Name: "DelayedInserts", Value: 0}}))
+	assert.True(t, backpressure(false, &Exception{Code: int32(ErrTooManyParts)}))
+	assert.False(t, backpressure(false, &Exception{Code: int32(ErrSyntax)}))
+	assert.True(t, backpressure(true, nil))
+}
@@ -0,0 +1,133 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+type OperationKind string
+
+const (
+	OperationQuery        OperationKind = "Query"
+	OperationQueryRow     OperationKind = "QueryRow"
+	OperationExec         OperationKind = "Exec"
+	OperationPrepareBatch OperationKind = "PrepareBatch"
+	OperationAsyncInsert  OperationKind = "AsyncInsert"
+	OperationPing         OperationKind = "Ping"
+	OperationExportNative OperationKind = "ExportNative"
+	OperationImportNative OperationKind = "ImportNative"
+)
+
+// Operation describes a call made on a Conn. Interceptors may change Query and Args before
+// calling the next handler; the result of the call (Rows, Row or Batch, depending on Kind)
+// is available once the next handler returns and may be replaced, e.g. with a wrapper. A Batch wrapper
+// should have an Unwrap() driver.Batch method returning the wrapped batch, which NewBatchWriter and
+// NewValidatingBatch need to reach the batch of the connection.
+type Operation struct {
+	Kind  OperationKind
+	Query string
+	Args  []interface{}
+
+	Rows  driver.Rows
+	Row   driver.Row
+	Batch driver.Batch
+}
+
+type (
+	Invoker     func(ctx context.Context, op *Operation) error
+	Interceptor func(ctx context.Context, op *Operation, next Invoker) error
+)
+
+// ChainInterceptors composes interceptors into a single one. The first interceptor is the outermost.
+func ChainInterceptors(interceptors ...Interceptor) Interceptor {
+	return func(ctx context.Context, op *Operation, next Invoker) error {
+		return chainInterceptors(interceptors, next)(ctx, op)
+	}
+}
+
+func chainInterceptors(interceptors []Interceptor, invoker Invoker) Invoker {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		var (
+			next        = invoker
+			interceptor = interceptors[i]
+		)
+		invoker = func(ctx context.Context, op *Operation) error {
+			return interceptor(ctx, op, next)
+		}
+	}
+	return invoker
+}
+
+func (ch *clickhouse) intercept(ctx context.Context, op *Operation, invoker Invoker) error {
+	ctx = decorateContext(ch.opt.DecorateContext, ctx)
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+	switch op.Kind {
+	case OperationQuery, OperationQueryRow:
+		ctx = consistentRead(ctx, op.Query)
+		invoker = ch.memoryFallback(ch.sample(invoker))
+	case OperationExportNative:
+		ctx = consistentRead(ctx, op.Query)
+	case OperationExec, OperationAsyncInsert, OperationImportNative:
+		next := invoker
+		invoker = func(ctx context.Context, op *Operation) error {
+			err := next(ctx, op)
+			observeWrite(ctx, op.Query, err)
+			return err
+		}
+	}
+	if ch.opt.ReadOnly || ch.opt.DisallowDDL {
+		// checked after the interceptors, which may rewrite the query
+		next := invoker
+		invoker = func(ctx context.Context, op *Operation) error {
+			if op.Kind != OperationPing {
+				if err := ch.opt.checkStatement(op.Query); err != nil {
+					return err
+				}
+			}
+			return next(ctx, op)
+		}
+	}
+	if len(ch.opt.Interceptors) == 0 {
+		return invoker(ctx, op)
+	}
+	return chainInterceptors(ch.opt.Interceptors, invoker)(ctx, op)
+}
+
+// batchWrapper is implemented by the batches wrapping the Batch of an operation, see Operation.
+type batchWrapper interface {
+	Unwrap() driver.Batch
+}
+
+// nativeBatch returns the native protocol batch wrapped by b, if any.
+func nativeBatch(b driver.Batch) (*batch, bool) {
+	for {
+		switch v := b.(type) {
+		case *batch:
+			return v, true
+		case batchWrapper:
+			b = v.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainInterceptors(t *testing.T) {
+	var calls []string
+	record := func(name string) Interceptor {
+		return func(ctx context.Context, op *Operation, next Invoker) error {
+			calls = append(calls, name+":before")
+			op.Query += " /* " + name + " */"
+			err := next(ctx, op)
+			calls = append(calls, name+":after")
+			return err
+		}
+	}
+	errInvoker := errors.New("invoker")
+	var query string
+	err := ChainInterceptors(record("a"), record("b"))(context.Background(), &Operation{Kind: OperationExec, Query: "SELECT 1"}, func(ctx context.Context, op *Operation) error {
+		query = op.Query
+		return errInvoker
+	})
+	assert.ErrorIs(t, err, errInvoker)
+	assert.Equal(t, "SELECT 1 /* a */ /* b */", query)
+	assert.Equal(t, []string{"a:before", "b:before", "b:after", "a:after"}, calls)
+}
+
+func TestNativeBatch(t *testing.T) {
+	nb := &batch{}
+	b, ok := nativeBatch(&slowBatch{Batch: &invalidatingBatch{Batch: nb}})
+	assert.True(t, ok)
+	assert.Same(t, nb, b)
+	_, ok = nativeBatch(&slowBatch{Batch: &sendBatch{}})
+	assert.False(t, ok)
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	ch := &clickhouse{opt: (&Options{
+		Interceptors: []Interceptor{
+			func(ctx context.Context, op *Operation, next Invoker) error {
+				return errors.New("denied " + string(op.Kind))
+			},
+		},
+	}).setDefaults()}
+	assert.EqualError(t, ch.Exec(context.Background(), "SELECT 1"), "denied Exec")
+	assert.EqualError(t, ch.QueryRow(context.Background(), "SELECT 1").Err(), "denied QueryRow")
+	_, err := ch.PrepareBatch(context.Background(), "INSERT INTO t")
+	assert.EqualError(t, err, "denied PrepareBatch")
+}
+
+func TestQueryCommentInterceptor(t *testing.T) {
+	interceptor := QueryCommentInterceptor(func(ctx context.Context, op *Operation) string {
+		return "service=api */ DROP"
+	})
+	var (
+		query    string
+		settings Settings
+	)
+	invoker := func(ctx context.Context, op *Operation) error {
+		query, settings = op.Query, queryOptions(ctx).settings
+		return nil
+	}
+	ctx := Context(context.Background(), WithSettings(Settings{"max_threads": 1}))
+	assert.NoError(t, interceptor(ctx, &Operation{Kind: OperationQuery, Query: "SELECT 1"}, invoker))
+	assert.Equal(t, "/* service=api * / DROP */ SELECT 1", query)
+	assert.NoError(t, interceptor(ctx, &Operation{Kind: OperationPrepareBatch, Query: "INSERT INTO t"}, invoker))
+	assert.Equal(t, "INSERT INTO t", query)
+	assert.Equal(t, "service=api */ DROP", settings["log_comment"])
+	assert.Equal(t, 1, settings["max_threads"])
+}
@@ -0,0 +1,91 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// InvalidationInterceptor calls fn with the tables changed by a statement once it succeeded, e.g. to
+// invalidate the entries of an application cache of query results read from them: on Exec of writes and
+// DDL, on AsyncInsert, and on Send of the batches of PrepareBatch. The tables are found with
+// TablesReferenced, so statements reading other tables, e.g. INSERT ... SELECT, report these as well.
+func InvalidationInterceptor(fn func(ctx context.Context, tables []string)) Interceptor {
+	return func(ctx context.Context, op *Operation, next Invoker) error {
+		if err := next(ctx, op); err != nil {
+			return err
+		}
+		invalidate := func() {
+			if tables := TablesReferenced(op.Query); len(tables) != 0 {
+				fn(ctx, tables)
+			}
+		}
+		switch op.Kind {
+		case OperationExec:
+			if StatementKind(op.Query) != StatementRead {
+				invalidate()
+			}
+		case OperationAsyncInsert:
+			invalidate()
+		case OperationPrepareBatch:
+			if op.Batch != nil {
+				op.Batch = &invalidatingBatch{Batch: op.Batch, invalidate: invalidate}
+			}
+		}
+		return nil
+	}
+}
+
+type invalidatingBatch struct {
+	driver.Batch
+	invalidate func()
+}
+
+func (b *invalidatingBatch) Send() error {
+	if err := b.Batch.Send(); err != nil {
+		return err
+	}
+	b.invalidate()
+	return nil
+}
+
+func (b *invalidatingBatch) Retry(ctx context.Context) error {
+	retryable, ok := b.Batch.(driver.RetryableBatch)
+	if !ok {
+		return ErrBatchRetryUnsupported
+	}
+	if err := retryable.Retry(ctx); err != nil {
+		return err
+	}
+	b.invalidate()
+	return nil
+}
+
+func (b *invalidatingBatch) Reset() error {
+	resettable, ok := b.Batch.(driver.ResettableBatch)
+	if !ok {
+		return ErrBatchResetUnsupported
+	}
+	return resettable.Reset()
+}
+
+func (b *invalidatingBatch) Unwrap() driver.Batch {
+	return b.Batch
+}
@@ -0,0 +1,92 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sendBatch struct {
+	driver.Batch
+	err error
+}
+
+func (b *sendBatch) Send() error { return b.err }
+
+type retryBatch struct {
+	sendBatch
+	retryErr error
+}
+
+func (b *retryBatch) Retry(context.Context) error { return b.retryErr }
+
+func TestInvalidationInterceptor(t *testing.T) {
+	var invalidated [][]string
+	interceptor := InvalidationInterceptor(func(ctx context.Context, tables []string) {
+		invalidated = append(invalidated, tables)
+	})
+	var (
+		ctx  = context.Background()
+		ok   = func(ctx context.Context, op *Operation) error { return nil }
+		fail = func(ctx context.Context, op *Operation) error { return errors.New("exec") }
+	)
+	require.NoError(t, interceptor(ctx, &Operation{Kind: OperationExec, Query: "SELECT * FROM events"}, ok))
+	require.NoError(t, interceptor(ctx, &Operation{Kind: OperationQuery, Query: "SELECT * FROM events"}, ok))
+	assert.Empty(t, invalidated, "reads do not invalidate")
+	assert.Error(t, interceptor(ctx, &Operation{Kind: OperationExec, Query: "TRUNCATE TABLE events"}, fail))
+	assert.Empty(t, invalidated, "failed statements do not invalidate")
+
+	require.NoError(t, interceptor(ctx, &Operation{Kind: OperationExec, Query: "ALTER TABLE db.events DELETE WHERE 1"}, ok))
+	require.NoError(t, interceptor(ctx, &Operation{Kind: OperationAsyncInsert, Query: "INSERT INTO logs VALUES (1)"}, ok))
+	assert.Equal(t, [][]string{{"db.events"}, {"logs"}}, invalidated)
+
+	batch := &sendBatch{}
+	op := &Operation{Kind: OperationPrepareBatch, Query: "INSERT INTO metrics"}
+	require.NoError(t, interceptor(ctx, op, func(ctx context.Context, op *Operation) error {
+		op.Batch = batch
+		return nil
+	}))
+	assert.Len(t, invalidated, 2, "a batch invalidates once sent")
+	batch.err = errors.New("send")
+	assert.Error(t, op.Batch.Send())
+	assert.Len(t, invalidated, 2)
+	batch.err = nil
+	require.NoError(t, op.Batch.Send())
+	assert.Equal(t, []string{"metrics"}, invalidated[2])
+	assert.ErrorIs(t, op.Batch.(driver.RetryableBatch).Retry(ctx), ErrBatchRetryUnsupported)
+	assert.Len(t, invalidated, 3)
+
+	retry := &retryBatch{retryErr: errors.New("retry")}
+	require.NoError(t, interceptor(ctx, op, func(ctx context.Context, op *Operation) error {
+		op.Batch = retry
+		return nil
+	}))
+	assert.Error(t, op.Batch.(driver.RetryableBatch).Retry(ctx))
+	assert.Len(t, invalidated, 3, "a failed retry does not invalidate")
+	retry.retryErr = nil
+	require.NoError(t, op.Batch.(driver.RetryableBatch).Retry(ctx))
+	assert.Equal(t, []string{"metrics"}, invalidated[3])
+	assert.Equal(t, retry, op.Batch.(batchWrapper).Unwrap())
+	assert.ErrorIs(t, op.Batch.(driver.ResettableBatch).Reset(), ErrBatchResetUnsupported)
+}
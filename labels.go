@@ -0,0 +1,45 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import "context"
+
+// Labels are user assigned names of the statements, e.g. the endpoint or report they serve, for metrics.
+type Labels map[string]string
+
+// WithLabel adds the label key with value to the statements of the context. The labels are passed to
+// Options.OnAcquire in AcquireInfo, to SlowQueryInterceptor, and to interceptors with QueryLabels.
+func WithLabel(key, value string) QueryOption {
+	return func(o *QueryOptions) error {
+		labels := make(Labels, len(o.labels)+1)
+		for k, v := range o.labels {
+			labels[k] = v
+		}
+		labels[key] = value
+		o.labels = labels
+		return nil
+	}
+}
+
+// QueryLabels returns the labels of the statements of ctx, set with WithLabel.
+func QueryLabels(ctx context.Context) Labels {
+	if o, ok := ctx.Value(_contextOptionKey).(QueryOptions); ok {
+		return o.labels
+	}
+	return nil
+}
@@ -296,6 +296,11 @@ func (col *Array) scanSlice(sliceType reflect.Type, row int, level int) (reflect
 				}
 			default:
 				v := col.values.Row(int(i), isPtr)
+				if v == nil && isNothing(col.values) {
+					// the elements of Array(Nullable(Nothing)), e.g. of SELECT [NULL], are all NULL
+					rSlice = reflect.Append(rSlice, reflect.Zero(rSlice.Type().Elem()))
+					continue
+				}
 				val := reflect.ValueOf(v)
 				if v == nil {
 					val = reflect.Zero(base)
@@ -99,15 +99,18 @@ func (col *Bool) Append(v interface{}) (nulls []uint8, err error) {
 	case []sql.NullBool:
 		nulls = make([]uint8, len(v))
 		for i := range v {
-			col.Append(v[i])
+			if !v[i].Valid {
+				nulls[i] = 1
+			}
+			col.AppendRow(v[i])
 		}
 	case []*sql.NullBool:
 		nulls = make([]uint8, len(v))
 		for i := range v {
-			if v[i] == nil {
+			if v[i] == nil || !v[i].Valid {
 				nulls[i] = 1
 			}
-			col.Append(v[i])
+			col.AppendRow(v[i])
 		}
 	default:
 		return nil, &ColumnConverterError{
@@ -134,8 +137,7 @@ func (col *Bool) AppendRow(v interface{}) error {
 			value = v.Bool
 		}
 	case *sql.NullBool:
-		switch v.Valid {
-		case true:
+		if v != nil && v.Valid {
 			value = v.Bool
 		}
 	case nil:
@@ -0,0 +1,146 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package column
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// MapBool returns col with its UInt8 and Int8 columns, also when wrapped in Nullable, scanned into and appended
+// from Go bool values: 0 is false and any other value is true. Values of the integer types are still accepted.
+func MapBool(col Interface) Interface {
+	switch c := col.(type) {
+	case *UInt8, *Int8:
+		return &boolMapping{Interface: col}
+	case *Nullable:
+		if _, ok := c.base.(*boolMapping); !ok {
+			if c.base = MapBool(c.base); c.base.ScanType() == scanTypeBool {
+				c.scanType = reflect.PtrTo(scanTypeBool)
+			}
+		}
+	}
+	return col
+}
+
+// boolMapping is an integer column read and written as bool.
+type boolMapping struct {
+	Interface
+}
+
+func (col *boolMapping) ScanType() reflect.Type {
+	return scanTypeBool
+}
+
+func (col *boolMapping) Row(i int, ptr bool) interface{} {
+	value := col.row(i)
+	if ptr {
+		return &value
+	}
+	return value
+}
+
+func (col *boolMapping) ScanRow(dest interface{}, row int) error {
+	switch d := dest.(type) {
+	case *bool:
+		*d = col.row(row)
+	case **bool:
+		*d = new(bool)
+		**d = col.row(row)
+	case *sql.NullBool:
+		return d.Scan(col.row(row))
+	case *interface{}:
+		*d = col.row(row)
+	default:
+		return col.Interface.ScanRow(dest, row)
+	}
+	return nil
+}
+
+func (col *boolMapping) Append(v interface{}) (nulls []uint8, err error) {
+	switch v := v.(type) {
+	case []bool:
+		values := make([]interface{}, len(v))
+		for i := range v {
+			values[i] = v[i]
+		}
+		return col.appendRows(values)
+	case []*bool:
+		values := make([]interface{}, len(v))
+		for i := range v {
+			values[i] = v[i]
+		}
+		return col.appendRows(values)
+	}
+	return col.Interface.Append(v)
+}
+
+func (col *boolMapping) AppendRow(v interface{}) error {
+	var value bool
+	switch v := v.(type) {
+	case bool:
+		value = v
+	case *bool:
+		if v != nil {
+			value = *v
+		}
+	case sql.NullBool:
+		value = v.Valid && v.Bool
+	case *sql.NullBool:
+		value = v != nil && v.Valid && v.Bool
+	default:
+		return col.Interface.AppendRow(v)
+	}
+	var n uint8
+	if value {
+		n = 1
+	}
+	switch c := col.Interface.(type) {
+	case *Int8:
+		c.col.Append(int8(n))
+	case *UInt8:
+		c.col.Append(n)
+	}
+	return nil
+}
+
+func (col *boolMapping) appendRows(v []interface{}) ([]uint8, error) {
+	nulls := make([]uint8, len(v))
+	for i := range v {
+		if p, ok := v[i].(*bool); ok && p == nil {
+			nulls[i] = 1
+		}
+		if err := col.AppendRow(v[i]); err != nil {
+			return nil, err
+		}
+	}
+	return nulls, nil
+}
+
+func (col *boolMapping) row(i int) bool {
+	switch c := col.Interface.(type) {
+	case *Int8:
+		return c.col.Row(i) != 0
+	case *UInt8:
+		return c.col.Row(i) != 0
+	}
+	panic(fmt.Sprintf("clickhouse: bool mapping of %s", col.Type()))
+}
+
+var _ Interface = (*boolMapping)(nil)
@@ -18,11 +18,14 @@
 package column
 
 import (
+	"errors"
 	"fmt"
 	"github.com/ClickHouse/ch-go/proto"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // column names which match this must be escaped - see https://clickhouse.com/docs/en/sql-reference/syntax/#identifiers
@@ -56,14 +59,69 @@ type ColumnConverterError struct {
 	Op       string
 	Hint     string
 	From, To string
+	// Column, ColumnType and Row locate the value when the error is returned for a block: the column name,
+	// its full type (To may be a nested element of it) and the index of the row in the block.
+	Column     string
+	ColumnType string
+	Row        int
+	// Value is a representation of the offending value, truncated to MaxErrorValueLength bytes.
+	Value string
 }
 
+// MaxErrorValueLength is the maximum length of ColumnConverterError.Value.
+const MaxErrorValueLength = 64
+
 func (e *ColumnConverterError) Error() string {
 	var hint string
 	if len(e.Hint) != 0 {
 		hint += ". " + e.Hint
 	}
-	return fmt.Sprintf("clickhouse [%s]: converting %s to %s is unsupported%s", e.Op, e.From, e.To, hint)
+	if len(e.Column) == 0 {
+		return fmt.Sprintf("clickhouse [%s]: converting %s to %s is unsupported%s", e.Op, e.From, e.To, hint)
+	}
+	return fmt.Sprintf("clickhouse [%s]: (%s %s) row %d: converting %s to %s is unsupported%s (value: %s)",
+		e.Op, e.Column, e.ColumnType, e.Row,
+		e.From, e.To,
+		hint, e.Value,
+	)
+}
+
+// Locate adds the column, row and value to err if it is a ColumnConverterError without them.
+func Locate(err error, col Interface, row int, v interface{}) error {
+	var cErr *ColumnConverterError
+	if errors.As(err, &cErr) && len(cErr.Column) == 0 {
+		cErr.Column = col.Name()
+		cErr.ColumnType = string(col.Type())
+		cErr.Row = row
+		cErr.Value = errorValue(v)
+	}
+	return err
+}
+
+// errorValue formats v for an error, quoting strings, dereferencing pointers and truncating the
+// result to MaxErrorValueLength bytes.
+func errorValue(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	var s string
+	switch {
+	case !rv.IsValid(), rv.Kind() == reflect.Ptr:
+		s = "<nil>"
+	case rv.Kind() == reflect.String:
+		s = strconv.Quote(rv.String())
+	default:
+		s = fmt.Sprintf("%v", rv.Interface())
+	}
+	if len(s) <= MaxErrorValueLength {
+		return s
+	}
+	end := MaxErrorValueLength
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return fmt.Sprintf("%s... (%d bytes)", s[:end], len(s))
 }
 
 type UnsupportedColumnTypeError struct {
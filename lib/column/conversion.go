@@ -0,0 +1,397 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package column
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ConversionPolicy controls the implicit conversions of values appended to and scanned from columns.
+type ConversionPolicy uint8
+
+const (
+	// ConversionLenient converts values which have a natural representation in the column type:
+	// strings to numbers and Decimals, floats and integers to Decimals (truncated to the column scale),
+	// any integer to DateTime and, when scanning, between numeric types, from strings and to strings.
+	// It is the default.
+	ConversionLenient ConversionPolicy = iota
+	// ConversionStrict only accepts the Go types of the column. Strings and integers are not converted
+	// to dates and Decimals with more digits than the column scale are rejected instead of truncated.
+	ConversionStrict
+)
+
+func (p ConversionPolicy) String() string {
+	switch p {
+	case ConversionLenient:
+		return "lenient"
+	case ConversionStrict:
+		return "strict"
+	}
+	return fmt.Sprintf("ConversionPolicy(%d)", uint8(p))
+}
+
+// ConvertAppend returns v as a value which col accepts under policy. Values col accepts natively
+// are returned unchanged.
+func ConvertAppend(col Interface, v interface{}, policy ConversionPolicy) (interface{}, error) {
+	t := baseType(col.Type())
+	if policy == ConversionStrict {
+		return v, checkStrict(col, t, v)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return v, nil
+		}
+		rv = rv.Elem()
+	}
+	var (
+		converted interface{}
+		err       error
+	)
+	switch name := t.name(); {
+	case strings.HasPrefix(name, "Decimal"):
+		switch rv.Kind() {
+		case reflect.String:
+			converted, err = decimal.NewFromString(rv.String())
+		case reflect.Float32, reflect.Float64:
+			converted = decimal.NewFromFloat(rv.Float())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			converted = decimal.New(rv.Int(), 0)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			converted = decimal.NewFromBigInt(new(big.Int).SetUint64(rv.Uint()), 0)
+		default:
+			return v, nil
+		}
+	case name == "DateTime" || name == "DateTime64":
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+			converted = rv.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if rv.Uint() > math.MaxInt64 {
+				err = strconv.ErrRange
+			}
+			converted = int64(rv.Uint())
+		default:
+			return v, nil
+		}
+	default:
+		scanType, numeric := numericTypes[name]
+		if !numeric || rv.Kind() != reflect.String {
+			return v, nil
+		}
+		converted, err = parseNumber(rv.String(), scanType)
+	}
+	if err != nil {
+		return v, &ColumnConverterError{
+			Op:   "AppendRow",
+			To:   string(col.Type()),
+			From: fmt.Sprintf("%T", v),
+			Hint: err.Error(),
+		}
+	}
+	return converted, nil
+}
+
+func checkStrict(col Interface, t Type, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	hint := "implicit conversions are disabled by ConversionStrict"
+	switch name := t.name(); {
+	case strings.HasPrefix(name, "Date"):
+		switch rv.Kind() {
+		case reflect.String, reflect.Int64:
+		default:
+			return nil
+		}
+	case strings.HasPrefix(name, "Decimal"):
+		d, ok := rv.Interface().(decimal.Decimal)
+		if !ok {
+			return nil
+		}
+		scale := decimalScale(t)
+		if d.Equal(d.Truncate(scale)) {
+			return nil
+		}
+		hint = fmt.Sprintf("%s has more than %d decimal places and would be truncated", d, scale)
+	default:
+		return nil
+	}
+	return &ColumnConverterError{
+		Op:   "AppendRow",
+		To:   string(col.Type()),
+		From: fmt.Sprintf("%T", v),
+		Hint: hint,
+	}
+}
+
+// ScanConvert stores value, a value of a column, in dest converting it leniently. It reports false
+// if there is no such conversion.
+func ScanConvert(value interface{}, dest interface{}) bool {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return false
+	}
+	target := dv.Elem()
+	src := reflect.ValueOf(value)
+	for src.Kind() == reflect.Ptr || src.Kind() == reflect.Interface {
+		if src.IsNil() {
+			src = reflect.Value{}
+			break
+		}
+		src = src.Elem()
+	}
+	if target.Kind() == reflect.Ptr {
+		if !src.IsValid() {
+			target.Set(reflect.Zero(target.Type()))
+			return true
+		}
+		elem := reflect.New(target.Type().Elem())
+		if !convertValue(src, elem.Elem()) {
+			return false
+		}
+		target.Set(elem)
+		return true
+	}
+	if !src.IsValid() {
+		return false
+	}
+	return convertValue(src, target)
+}
+
+func convertValue(src, target reflect.Value) bool {
+	switch target.Interface().(type) {
+	case time.Time:
+		if n, ok := integer(src); ok {
+			target.Set(reflect.ValueOf(time.Unix(n, 0)))
+			return true
+		}
+		return false
+	case decimal.Decimal:
+		var (
+			d   decimal.Decimal
+			err error
+		)
+		switch src.Kind() {
+		case reflect.String:
+			d, err = decimal.NewFromString(src.String())
+		case reflect.Float32, reflect.Float64:
+			d = decimal.NewFromFloat(src.Float())
+		default:
+			n, ok := integer(src)
+			if !ok {
+				return false
+			}
+			d = decimal.New(n, 0)
+		}
+		if err != nil {
+			return false
+		}
+		target.Set(reflect.ValueOf(d))
+		return true
+	}
+	switch target.Kind() {
+	case reflect.String:
+		switch v := src.Interface().(type) {
+		case time.Time:
+			target.SetString(v.Format("2006-01-02 15:04:05.999999999"))
+		case fmt.Stringer:
+			target.SetString(v.String())
+		default:
+			if src.Kind() == reflect.String {
+				target.SetString(src.String())
+				return true
+			}
+			target.SetString(fmt.Sprint(v))
+		}
+		return true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch src.Kind() {
+		case reflect.String:
+			var err error
+			if n, err = strconv.ParseInt(src.String(), 10, 64); err != nil {
+				return false
+			}
+		case reflect.Float32, reflect.Float64:
+			if f := src.Float(); f == math.Trunc(f) && f >= math.MinInt64 && f <= math.MaxInt64 {
+				n = int64(f)
+			} else {
+				return false
+			}
+		default:
+			var ok bool
+			if n, ok = integer(src); !ok {
+				return false
+			}
+		}
+		if target.OverflowInt(n) {
+			return false
+		}
+		target.SetInt(n)
+		return true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		switch src.Kind() {
+		case reflect.String:
+			var err error
+			if n, err = strconv.ParseUint(src.String(), 10, 64); err != nil {
+				return false
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = src.Uint()
+		case reflect.Float32, reflect.Float64:
+			if f := src.Float(); f == math.Trunc(f) && f >= 0 && f <= math.MaxUint64 {
+				n = uint64(f)
+			} else {
+				return false
+			}
+		default:
+			i, ok := integer(src)
+			if !ok || i < 0 {
+				return false
+			}
+			n = uint64(i)
+		}
+		if target.OverflowUint(n) {
+			return false
+		}
+		target.SetUint(n)
+		return true
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch src.Kind() {
+		case reflect.String:
+			var err error
+			if f, err = strconv.ParseFloat(src.String(), 64); err != nil {
+				return false
+			}
+		case reflect.Float32, reflect.Float64:
+			f = src.Float()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			f = float64(src.Uint())
+		default:
+			n, ok := integer(src)
+			if !ok {
+				d, ok := src.Interface().(decimal.Decimal)
+				if !ok {
+					return false
+				}
+				f, _ = d.Float64()
+				break
+			}
+			f = float64(n)
+		}
+		target.SetFloat(f)
+		return true
+	}
+	return false
+}
+
+// integer returns the value of a signed, or in range unsigned, integer.
+func integer(v reflect.Value) (int64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() <= math.MaxInt64 {
+			return int64(v.Uint()), true
+		}
+	}
+	return 0, false
+}
+
+var numericTypes = map[string]reflect.Type{
+	"Int8":    scanTypeInt8,
+	"Int16":   scanTypeInt16,
+	"Int32":   scanTypeInt32,
+	"Int64":   scanTypeInt64,
+	"UInt8":   scanTypeUInt8,
+	"UInt16":  scanTypeUInt16,
+	"UInt32":  scanTypeUInt32,
+	"UInt64":  scanTypeUInt64,
+	"Float32": scanTypeFloat32,
+	"Float64": scanTypeFloat64,
+}
+
+func parseNumber(s string, t reflect.Type) (interface{}, error) {
+	v := reflect.New(t).Elem()
+	s = strings.TrimSpace(s)
+	switch t.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, t.Bits())
+		if err != nil {
+			return nil, err
+		}
+		v.SetInt(n)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, t.Bits())
+		if err != nil {
+			return nil, err
+		}
+		v.SetUint(n)
+	default:
+		f, err := strconv.ParseFloat(s, t.Bits())
+		if err != nil {
+			return nil, err
+		}
+		v.SetFloat(f)
+	}
+	return v.Interface(), nil
+}
+
+// baseType strips Nullable and LowCardinality from t.
+func baseType(t Type) Type {
+	for {
+		switch s := string(t); {
+		case strings.HasPrefix(s, "Nullable("), strings.HasPrefix(s, "LowCardinality("):
+			t = Type(t.params())
+		default:
+			return t
+		}
+	}
+}
+
+// name returns t without its parameters.
+func (t Type) name() string {
+	if i := strings.IndexByte(string(t), '('); i != -1 {
+		return string(t[:i])
+	}
+	return string(t)
+}
+
+func decimalScale(t Type) int32 {
+	params := strings.Split(t.params(), ",")
+	scale, _ := strconv.Atoi(strings.TrimSpace(params[len(params)-1]))
+	if t.name() == "Decimal" && len(params) == 1 {
+		scale = 0
+	}
+	return int32(scale)
+}
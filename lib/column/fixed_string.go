@@ -23,13 +23,52 @@ import (
 	"fmt"
 	"github.com/ClickHouse/ch-go/proto"
 	"reflect"
+	"strings"
 
 	"github.com/ClickHouse/clickhouse-go/v2/lib/binary"
 )
 
+// FixedStringOptions controls how values are fitted to the length of FixedString columns. The zero value
+// pads shorter values with zero bytes and rejects longer values.
+type FixedStringOptions struct {
+	// Truncate cuts values longer than the column to its length instead of rejecting them.
+	Truncate bool
+	// NoPadding rejects values shorter than the column, including empty strings, instead of padding them.
+	NoPadding bool
+	// TrimNulls removes the trailing zero bytes of the padding from the values read as strings.
+	TrimNulls bool
+}
+
+// SetFixedStringOptions applies opts to the FixedString columns of col, including the columns nested in
+// Nullable, Array, LowCardinality, Map and Tuple.
+func SetFixedStringOptions(col Interface, opts FixedStringOptions) {
+	switch col := col.(type) {
+	case *FixedString:
+		col.opts = opts
+	case *Nullable:
+		SetFixedStringOptions(col.base, opts)
+	case *Array:
+		SetFixedStringOptions(col.values, opts)
+	case *LowCardinality:
+		SetFixedStringOptions(col.index, opts)
+	case *SimpleAggregateFunction:
+		SetFixedStringOptions(col.base, opts)
+	case *Nested:
+		SetFixedStringOptions(col.Interface, opts)
+	case *Map:
+		SetFixedStringOptions(col.keys, opts)
+		SetFixedStringOptions(col.values, opts)
+	case *Tuple:
+		for _, c := range col.columns {
+			SetFixedStringOptions(c, opts)
+		}
+	}
+}
+
 type FixedString struct {
 	name string
 	col  proto.ColFixedStr
+	opts FixedStringOptions
 }
 
 func (col *FixedString) Reset() {
@@ -60,7 +99,7 @@ func (col *FixedString) Rows() int {
 }
 
 func (col *FixedString) Row(i int, ptr bool) interface{} {
-	value := col.row(i)
+	value := col.str(i)
 	if ptr {
 		return &value
 	}
@@ -70,15 +109,15 @@ func (col *FixedString) Row(i int, ptr bool) interface{} {
 func (col *FixedString) ScanRow(dest interface{}, row int) error {
 	switch d := dest.(type) {
 	case *string:
-		*d = col.row(row)
+		*d = col.str(row)
 	case **string:
 		*d = new(string)
-		**d = col.row(row)
+		**d = col.str(row)
 	case encoding.BinaryUnmarshaler:
 		return d.UnmarshalBinary(col.rowBytes(row))
 	default:
 		if scan, ok := dest.(sql.Scanner); ok {
-			return scan.Scan(col.row(row))
+			return scan.Scan(col.str(row))
 		}
 		return &ColumnConverterError{
 			Op:   "ScanRow",
@@ -94,28 +133,25 @@ func (col *FixedString) Append(v interface{}) (nulls []uint8, err error) {
 	case []string:
 		nulls = make([]uint8, len(v))
 		for _, v := range v {
-			if v == "" {
-				col.col.Append(make([]byte, col.col.Size))
-			} else {
-				col.col.Append(binary.Str2Bytes(v, col.col.Size))
+			data, err := col.fit(v)
+			if err != nil {
+				return nil, err
 			}
+			col.col.Append(data)
 		}
 	case []*string:
 		nulls = make([]uint8, len(v))
 		for i, v := range v {
 			if v == nil {
 				nulls[i] = 1
-			}
-			switch {
-			case v == nil:
 				col.col.Append(make([]byte, col.col.Size))
-			default:
-				if *v == "" {
-					col.col.Append(make([]byte, col.col.Size))
-				} else {
-					col.col.Append(binary.Str2Bytes(*v, col.col.Size))
-				}
+				continue
 			}
+			data, err := col.fit(*v)
+			if err != nil {
+				return nil, err
+			}
+			col.col.Append(data)
 		}
 	case encoding.BinaryMarshaler:
 		data, err := v.MarshalBinary()
@@ -138,13 +174,13 @@ func (col *FixedString) AppendRow(v interface{}) (err error) {
 	data := make([]byte, col.col.Size)
 	switch v := v.(type) {
 	case string:
-		if v != "" {
-			data = binary.Str2Bytes(v, col.col.Size)
+		if data, err = col.fit(v); err != nil {
+			return err
 		}
 	case *string:
 		if v != nil {
-			if *v != "" {
-				data = binary.Str2Bytes(*v, col.col.Size)
+			if data, err = col.fit(*v); err != nil {
+				return err
 			}
 		}
 	case nil:
@@ -175,6 +211,35 @@ func (col *FixedString) Encode(buffer *proto.Buffer) {
 	col.col.EncodeColumn(buffer)
 }
 
+// fit returns the bytes of v fitted to the length of the column according to the options.
+func (col *FixedString) fit(v string) ([]byte, error) {
+	size := col.col.Size
+	switch {
+	case len(v) > size:
+		if !col.opts.Truncate {
+			return nil, &Error{
+				ColumnType: string(col.Type()),
+				Err:        fmt.Errorf("value of %d bytes exceeds the column length", len(v)),
+			}
+		}
+		v = v[:size]
+	case len(v) < size && col.opts.NoPadding:
+		return nil, &Error{
+			ColumnType: string(col.Type()),
+			Err:        fmt.Errorf("value of %d bytes is shorter than the column length", len(v)),
+		}
+	}
+	return binary.Str2Bytes(v, size), nil
+}
+
+// str returns row i as a string, without the padding if TrimNulls is set.
+func (col *FixedString) str(i int) string {
+	if col.opts.TrimNulls {
+		return strings.TrimRight(col.row(i), "\x00")
+	}
+	return col.row(i)
+}
+
 func (col *FixedString) row(i int) string {
 	v := col.col.Row(i)
 	return string(v)
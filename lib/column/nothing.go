@@ -18,11 +18,15 @@
 package column
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/ClickHouse/ch-go/proto"
 	"reflect"
 )
 
+var scanTypeAny = reflect.TypeOf((*interface{})(nil)).Elem()
+
 type Nothing struct {
 	name string
 	col  proto.ColNothing
@@ -37,12 +41,32 @@ func (col Nothing) Name() string {
 }
 
 func (Nothing) Type() Type                { return "Nothing" }
-func (Nothing) ScanType() reflect.Type    { return reflect.TypeOf((*interface{})(nil)) }
-func (Nothing) Rows() int                 { return 0 }
+func (Nothing) ScanType() reflect.Type    { return scanTypeAny }
+func (col *Nothing) Rows() int            { return col.col.Rows() }
 func (Nothing) Row(int, bool) interface{} { return nil }
-func (Nothing) ScanRow(interface{}, int) error {
+
+// ScanRow stores the zero value of dest, a pointer, as Nothing only has NULL values, e.g. of SELECT NULL.
+func (Nothing) ScanRow(dest interface{}, row int) error {
+	switch d := dest.(type) {
+	case *interface{}:
+		*d = nil
+	case sql.Scanner:
+		return d.Scan(nil)
+	default:
+		v := reflect.ValueOf(dest)
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			return &ColumnConverterError{
+				Op:   "ScanRow",
+				To:   fmt.Sprintf("%T", dest),
+				From: "Nothing",
+				Hint: "try using a pointer",
+			}
+		}
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
 	return nil
 }
+
 func (Nothing) Append(interface{}) ([]uint8, error) {
 	return nil, &Error{
 		ColumnType: "Nothing",
@@ -56,11 +80,20 @@ func (col Nothing) AppendRow(interface{}) error {
 	}
 }
 
-func (col Nothing) Decode(reader *proto.Reader, rows int) error {
+func (col *Nothing) Decode(reader *proto.Reader, rows int) error {
 	return col.col.DecodeColumn(reader, rows)
 }
 
 func (Nothing) Encode(buffer *proto.Buffer) {
 }
 
+// isNothing reports whether col is Nothing or Nullable(Nothing).
+func isNothing(col Interface) bool {
+	if n, ok := col.(*Nullable); ok {
+		col = n.base
+	}
+	_, ok := col.(*Nothing)
+	return ok
+}
+
 var _ Interface = (*Nothing)(nil)
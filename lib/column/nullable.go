@@ -93,6 +93,10 @@ func (col *Nullable) ScanRow(dest interface{}, row int) error {
 			if scan, ok := dest.(sql.Scanner); ok {
 				return scan.Scan(nil)
 			}
+			if _, ok := col.base.(*Nothing); ok {
+				// all the values of Nullable(Nothing), e.g. of SELECT NULL, are NULL, clear dest
+				return col.base.ScanRow(dest, row)
+			}
 			return nil
 		}
 	}
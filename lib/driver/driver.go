@@ -85,6 +85,18 @@ type (
 		Send() error
 		IsSent() bool
 	}
+	// RetryableBatch is implemented by the batches of the client, which keep their data when Send fails.
+	// Retry re-sends it on a new connection.
+	RetryableBatch interface {
+		Batch
+		Retry(ctx context.Context) error
+	}
+	// ResettableBatch is implemented by the batches of the client. Reset clears the appended rows so that
+	// the batch can be reused for another insert into the same table.
+	ResettableBatch interface {
+		Batch
+		Reset() error
+	}
 	BatchColumn interface {
 		Append(interface{}) error
                 AppendRow(interface{}) error
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/ClickHouse/ch-go/proto"
@@ -32,6 +33,12 @@ type Block struct {
 	Packet   byte
 	Columns  []column.Interface
 	Timezone *time.Location
+	// Conversion is the policy for the implicit conversions of appended and scanned values.
+	Conversion column.ConversionPolicy
+	// FixedString controls the fitting of values to the length of FixedString columns.
+	FixedString column.FixedStringOptions
+	// BoolMapping maps UInt8 and Int8 columns to Go bool values, see column.MapBool.
+	BoolMapping bool
 }
 
 func (b *Block) Rows() int {
@@ -42,7 +49,7 @@ func (b *Block) Rows() int {
 }
 
 func (b *Block) AddColumn(name string, ct column.Type) error {
-	column, err := ct.Column(name, b.Timezone)
+	column, err := b.newColumn(name, ct)
 	if err != nil {
 		return err
 	}
@@ -50,6 +57,20 @@ func (b *Block) AddColumn(name string, ct column.Type) error {
 	return nil
 }
 
+func (b *Block) newColumn(name string, ct column.Type) (column.Interface, error) {
+	c, err := ct.Column(name, b.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	if b.FixedString != (column.FixedStringOptions{}) {
+		column.SetFixedStringOptions(c, b.FixedString)
+	}
+	if b.BoolMapping {
+		c = column.MapBool(c)
+	}
+	return c, nil
+}
+
 func (b *Block) Append(v ...interface{}) (err error) {
 	columns := b.Columns
 	if len(columns) != len(v) {
@@ -58,11 +79,16 @@ func (b *Block) Append(v ...interface{}) (err error) {
 			Err: fmt.Errorf("clickhouse: expected %d arguments, got %d", len(columns), len(v)),
 		}
 	}
+	row := b.Rows()
 	for i, v := range v {
-		if err := b.Columns[i].AppendRow(v); err != nil {
+		converted, err := column.ConvertAppend(b.Columns[i], v, b.Conversion)
+		if err == nil {
+			err = b.Columns[i].AppendRow(converted)
+		}
+		if err != nil {
 			return &BlockError{
 				Op:         "AppendRow",
-				Err:        err,
+				Err:        column.Locate(err, b.Columns[i], row, v),
 				ColumnName: columns[i].Name(),
 			}
 		}
@@ -70,6 +96,38 @@ func (b *Block) Append(v ...interface{}) (err error) {
 	return nil
 }
 
+// PromoteNullable replaces the non-nullable column i with a Nullable column holding the same rows.
+// A NULL sent for such a column is replaced by the column default on the server when
+// input_format_null_as_default is enabled. Composite and already nullable columns are left unchanged.
+func (b *Block) PromoteNullable(i int) (bool, error) {
+	if i < 0 || i >= len(b.Columns) {
+		return false, nil
+	}
+	c := b.Columns[i]
+	switch t := string(c.Type()); {
+	case strings.HasPrefix(t, "Nullable("),
+		strings.HasPrefix(t, "Array("),
+		strings.HasPrefix(t, "Map("),
+		strings.HasPrefix(t, "Tuple("),
+		strings.HasPrefix(t, "Nested("),
+		strings.HasPrefix(t, "LowCardinality("),
+		strings.HasPrefix(t, "Object("),
+		strings.HasPrefix(t, "SimpleAggregateFunction("):
+		return false, nil
+	}
+	nullable, err := b.newColumn(c.Name(), column.Type("Nullable("+string(c.Type())+")"))
+	if err != nil {
+		return false, err
+	}
+	for row := 0; row < c.Rows(); row++ {
+		if err := nullable.AppendRow(c.Row(row, false)); err != nil {
+			return false, err
+		}
+	}
+	b.Columns[i] = nullable
+	return true, nil
+}
+
 func (b *Block) ColumnsNames() []string {
 	return b.names
 }
@@ -214,25 +272,37 @@ func (b *Block) Decode(reader *proto.Reader, revision uint64) (err error) {
 		if columnType, err = reader.Str(); err != nil {
 			return err
 		}
-		c, err := column.Type(columnType).Column(columnName, b.Timezone)
+		c, err := b.newColumn(columnName, column.Type(columnType))
 		if err != nil {
 			return err
 		}
 
+		var sparse bool
 		if revision >= DBMS_MIN_REVISION_WITH_CUSTOM_SERIALIZATION {
 			hasCustom, err := reader.Bool()
 			if err != nil {
 				return err
 			}
 			if hasCustom {
-				return &BlockError{
-					Op:  "Decode",
-					Err: errors.New(fmt.Sprintf("custom serialization for column %s. not supported", columnName)),
+				if sparse, err = decodeSerializationKind(reader, c); err != nil {
+					return &BlockError{
+						Op:         "Decode",
+						Err:        err,
+						ColumnName: columnName,
+					}
 				}
 			}
 		}
 
-		if numRows != 0 {
+		if numRows != 0 && sparse {
+			if c, err = b.decodeSparse(reader, c, int(numRows)); err != nil {
+				return &BlockError{
+					Op:         "Decode",
+					Err:        err,
+					ColumnName: columnName,
+				}
+			}
+		} else if numRows != 0 {
 			if serialize, ok := c.(column.CustomSerialization); ok {
 				if err := serialize.ReadStatePrefix(reader); err != nil {
 					return &BlockError{
@@ -301,8 +371,16 @@ func (e *BlockError) Error() string {
 	switch err := e.Err.(type) {
 	case *column.Error:
 		return fmt.Sprintf("clickhouse [%s]: (%s %s) %s", e.Op, e.ColumnName, err.ColumnType, err.Err)
+	case *column.ColumnConverterError:
+		if len(err.Column) != 0 {
+			return err.Error()
+		}
 	case *column.DateOverflowError:
 		return fmt.Sprintf("clickhouse: dateTime overflow. %s must be between %s and %s", e.ColumnName, err.Min.Format(err.Format), err.Max.Format(err.Format))
 	}
 	return fmt.Sprintf("clickhouse [%s]: %s %s", e.Op, e.ColumnName, e.Err)
 }
+
+func (e *BlockError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,97 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proto
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeNothing(t *testing.T) {
+	var buf proto.Buffer
+	encodeBlockInfo(&buf)
+	buf.PutUVarInt(4) // columns
+	buf.PutUVarInt(2) // rows
+	for _, c := range []struct {
+		name, typ string
+		data      func()
+	}{
+		{"nothing", "Nothing", func() { buf.PutRaw([]byte{0, 0}) }},
+		{"null", "Nullable(Nothing)", func() { buf.PutRaw([]byte{1, 1, 0, 0}) }},
+		{"empty", "Array(Nothing)", func() {
+			buf.PutUInt64(0)
+			buf.PutUInt64(0)
+		}},
+		{"nulls", "Array(Nullable(Nothing))", func() {
+			buf.PutUInt64(0)
+			buf.PutUInt64(2)
+			buf.PutRaw([]byte{1, 1, 0, 0})
+		}},
+	} {
+		buf.PutString(c.name)
+		buf.PutString(c.typ)
+		buf.PutBool(false)
+		c.data()
+	}
+	var block Block
+	require.NoError(t, block.Decode(proto.NewReader(&buf), DBMS_TCP_PROTOCOL_VERSION))
+	require.Equal(t, 2, block.Rows())
+	for i := range block.Columns {
+		assert.Equal(t, 2, block.Columns[i].Rows())
+	}
+
+	for _, col := range block.Columns[:2] {
+		var (
+			v     interface{} = "previous"
+			s                 = "previous"
+			p                 = &s
+			n     sql.NullString
+			dests = []interface{}{&v, &s, &p, &n}
+		)
+		for _, dest := range dests {
+			require.NoError(t, col.ScanRow(dest, 1), "%s into %T", col.Type(), dest)
+		}
+		assert.Nil(t, v)
+		assert.Empty(t, s)
+		assert.Nil(t, p)
+		assert.False(t, n.Valid)
+		assert.Nil(t, col.Row(0, false))
+		assert.Error(t, col.ScanRow(s, 0))
+	}
+
+	var (
+		empty []string
+		any   interface{}
+	)
+	require.NoError(t, block.Columns[2].ScanRow(&empty, 0))
+	assert.NotNil(t, empty)
+	assert.Empty(t, empty)
+	require.NoError(t, block.Columns[2].ScanRow(&any, 1))
+	assert.Equal(t, []interface{}{}, any)
+	var nulls []*string
+	require.NoError(t, block.Columns[3].ScanRow(&nulls, 0))
+	assert.Empty(t, nulls)
+	require.NoError(t, block.Columns[3].ScanRow(&nulls, 1))
+	assert.Equal(t, []*string{nil, nil}, nulls)
+	require.NoError(t, block.Columns[3].ScanRow(&any, 1))
+	assert.Equal(t, []*interface{}{nil, nil}, any)
+}
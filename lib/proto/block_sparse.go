@@ -0,0 +1,110 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proto
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+)
+
+// serialization kinds, see SerializationInfo in ClickHouse
+const (
+	serializationDefault = 0
+	serializationSparse  = 1
+)
+
+// sparseEndOfGranule marks the last group of default values in the offsets stream of a sparse column.
+const sparseEndOfGranule = 1 << 62
+
+// decodeSerializationKind reads the serialization kind sent for a column with custom serialization
+// and reports whether the column is sparse.
+func decodeSerializationKind(reader *proto.Reader, c column.Interface) (bool, error) {
+	if _, ok := c.(*column.Tuple); ok {
+		// tuple kinds are followed by the kinds of every element
+		return false, fmt.Errorf("custom serialization of tuple elements is not supported")
+	}
+	kind, err := reader.UInt8()
+	if err != nil {
+		return false, err
+	}
+	switch kind {
+	case serializationDefault:
+		return false, nil
+	case serializationSparse:
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown serialization kind %d", kind)
+}
+
+// decodeSparse reads a sparse column - the positions of non-default values followed by the values
+// themselves - and expands it to c, a column of the block, with rows values.
+func (b *Block) decodeSparse(reader *proto.Reader, c column.Interface, rows int) (column.Interface, error) {
+	if serialize, ok := c.(column.CustomSerialization); ok {
+		if err := serialize.ReadStatePrefix(reader); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		offsets []int
+		row     int
+	)
+	for {
+		group, err := reader.UVarInt()
+		if err != nil {
+			return nil, err
+		}
+		if group&sparseEndOfGranule != 0 {
+			row += int(group &^ sparseEndOfGranule)
+			break
+		}
+		row += int(group)
+		offsets = append(offsets, row)
+		row++
+	}
+	if row != rows || len(offsets) > rows {
+		return nil, fmt.Errorf("sparse offsets describe %d rows, expected %d", row, rows)
+	}
+	// built as c, e.g. with the bool mapping, so that its values are appended to c as they are
+	values, err := b.newColumn(c.Name(), c.Type())
+	if err != nil {
+		return nil, err
+	}
+	if len(offsets) != 0 {
+		if err := values.Decode(reader, len(offsets)); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		next  int
+		empty = reflect.Zero(c.ScanType()).Interface()
+	)
+	for i := 0; i < rows; i++ {
+		value := empty
+		if next < len(offsets) && offsets[next] == i {
+			value = values.Row(next, false)
+			next++
+		}
+		if err := c.AppendRow(value); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,85 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proto
+
+import (
+	"testing"
+
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeSparseColumn(t *testing.T) {
+	var buf proto.Buffer
+	encodeBlockInfo(&buf)
+	buf.PutUVarInt(2) // columns
+	buf.PutUVarInt(6) // rows
+	{
+		buf.PutString("sparse")
+		buf.PutString("UInt64")
+		buf.PutBool(true)
+		buf.PutUInt8(serializationSparse)
+		// non-default values at rows 1 and 4, followed by one trailing default
+		buf.PutUVarInt(1)
+		buf.PutUVarInt(2)
+		buf.PutUVarInt(1 | sparseEndOfGranule)
+		buf.PutUInt64(10)
+		buf.PutUInt64(40)
+	}
+	{
+		buf.PutString("dense")
+		buf.PutString("String")
+		buf.PutBool(false)
+		for _, v := range []string{"a", "b", "c", "d", "e", "f"} {
+			buf.PutString(v)
+		}
+	}
+	var block Block
+	require.NoError(t, block.Decode(proto.NewReader(&buf), DBMS_TCP_PROTOCOL_VERSION))
+	require.Equal(t, 6, block.Rows())
+	var values []uint64
+	for i := 0; i < block.Rows(); i++ {
+		values = append(values, block.Columns[0].Row(i, false).(uint64))
+	}
+	assert.Equal(t, []uint64{0, 10, 0, 0, 40, 0}, values)
+	assert.Equal(t, "f", block.Columns[1].Row(5, false))
+}
+
+func TestDecodeSparseBoolMapping(t *testing.T) {
+	var buf proto.Buffer
+	encodeBlockInfo(&buf)
+	buf.PutUVarInt(1) // columns
+	buf.PutUVarInt(4) // rows
+	buf.PutString("flag")
+	buf.PutString("UInt8")
+	buf.PutBool(true)
+	buf.PutUInt8(serializationSparse)
+	// a non-default value at row 2, followed by one trailing default
+	buf.PutUVarInt(2)
+	buf.PutUVarInt(1 | sparseEndOfGranule)
+	buf.PutUInt8(1)
+	block := Block{BoolMapping: true}
+	require.NoError(t, block.Decode(proto.NewReader(&buf), DBMS_TCP_PROTOCOL_VERSION))
+	require.Equal(t, 4, block.Rows())
+	var values []interface{}
+	for i := 0; i < block.Rows(); i++ {
+		values = append(values, block.Columns[0].Row(i, false))
+	}
+	assert.Equal(t, []interface{}{false, false, true, false}, values)
+}
@@ -0,0 +1,185 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proto
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockPromoteNullable(t *testing.T) {
+	var block Block
+	require.NoError(t, block.AddColumn("id", "UInt64"))
+	require.NoError(t, block.AddColumn("tags", "Array(String)"))
+	require.NoError(t, block.Append(uint64(1), []string{"a"}))
+	require.NoError(t, block.Append(uint64(2), []string{}))
+
+	promoted, err := block.PromoteNullable(0)
+	require.NoError(t, err)
+	assert.True(t, promoted)
+	assert.Equal(t, "Nullable(UInt64)", string(block.Columns[0].Type()))
+	assert.Equal(t, "id", block.Columns[0].Name())
+	require.Equal(t, 2, block.Rows())
+
+	promoted, err = block.PromoteNullable(0)
+	require.NoError(t, err)
+	assert.False(t, promoted)
+	promoted, err = block.PromoteNullable(1)
+	require.NoError(t, err)
+	assert.False(t, promoted)
+
+	require.NoError(t, block.Append(nil, []string{"b"}))
+	require.Equal(t, 3, block.Rows())
+	assert.Nil(t, block.Columns[0].Row(2, false))
+	v := block.Columns[0].Row(1, false).(*uint64)
+	assert.Equal(t, uint64(2), *v)
+}
+
+func TestBlockConversionPolicy(t *testing.T) {
+	lenient := Block{}
+	require.NoError(t, lenient.AddColumn("n", "Nullable(Int32)"))
+	require.NoError(t, lenient.AddColumn("price", "Decimal(9, 2)"))
+	require.NoError(t, lenient.AddColumn("at", "DateTime"))
+	require.NoError(t, lenient.Append("42", 1.255, int32(1700000000)))
+	assert.Equal(t, int32(42), *lenient.Columns[0].Row(0, false).(*int32))
+	assert.Equal(t, "1.25", lenient.Columns[1].Row(0, false).(decimal.Decimal).String())
+	assert.Equal(t, int64(1700000000), lenient.Columns[2].Row(0, false).(time.Time).Unix())
+	assert.Error(t, lenient.Append("4x2", 1.0, int32(0)))
+
+	strict := Block{Conversion: column.ConversionStrict}
+	require.NoError(t, strict.AddColumn("price", "Decimal(9, 2)"))
+	require.NoError(t, strict.AddColumn("at", "DateTime"))
+	require.NoError(t, strict.Append(decimal.RequireFromString("1.20"), time.Unix(1700000000, 0)))
+	var cErr *column.ColumnConverterError
+	assert.ErrorAs(t, strict.Append(decimal.RequireFromString("1.255"), time.Unix(0, 0)), &cErr)
+	assert.ErrorAs(t, strict.Append(decimal.Zero, "2023-01-01 00:00:00"), &cErr)
+	assert.ErrorAs(t, strict.Append(decimal.Zero, int64(1700000000)), &cErr)
+	assert.Contains(t, cErr.Hint, "ConversionStrict")
+}
+
+func TestBlockFixedString(t *testing.T) {
+	var padded Block
+	require.NoError(t, padded.AddColumn("code", "FixedString(3)"))
+	require.NoError(t, padded.Append("ab"))
+	require.NoError(t, padded.Append(""))
+	assert.Error(t, padded.Append("abcd"))
+	assert.Equal(t, "ab\x00", padded.Columns[0].Row(0, false))
+	var s string
+	require.NoError(t, padded.Columns[0].ScanRow(&s, 0))
+	assert.Equal(t, "ab\x00", s)
+
+	strict := Block{FixedString: column.FixedStringOptions{NoPadding: true}}
+	require.NoError(t, strict.AddColumn("code", "Nullable(FixedString(3))"))
+	require.NoError(t, strict.Append("abc"))
+	require.NoError(t, strict.Append(nil))
+	assert.Error(t, strict.Append("ab"))
+	assert.Error(t, strict.Append(""))
+
+	lenient := Block{FixedString: column.FixedStringOptions{Truncate: true, TrimNulls: true}}
+	require.NoError(t, lenient.AddColumn("codes", "Array(FixedString(3))"))
+	require.NoError(t, lenient.AddColumn("code", "FixedString(3)"))
+	require.NoError(t, lenient.AddColumn("lc", "LowCardinality(FixedString(3))"))
+	require.NoError(t, lenient.Append([]string{"abcd", "a"}, "xyzw", "long"))
+	assert.Equal(t, []string{"abc", "a"}, lenient.Columns[0].Row(0, false))
+	assert.Equal(t, "xyz", lenient.Columns[1].Row(0, false))
+	require.NoError(t, lenient.Append([]string{}, "x", "x"))
+	var p *string
+	require.NoError(t, lenient.Columns[1].ScanRow(&p, 1))
+	assert.Equal(t, "x", *p)
+	promoted, err := lenient.PromoteNullable(1)
+	require.NoError(t, err)
+	require.True(t, promoted)
+	require.NoError(t, lenient.Append([]string{}, "long", "x"))
+	assert.Equal(t, 3, lenient.Rows())
+}
+
+func TestBlockBoolMapping(t *testing.T) {
+	block := Block{BoolMapping: true}
+	require.NoError(t, block.AddColumn("flag", "UInt8"))
+	require.NoError(t, block.AddColumn("signed", "Int8"))
+	require.NoError(t, block.AddColumn("maybe", "Nullable(UInt8)"))
+	require.NoError(t, block.AddColumn("native", "Bool"))
+	require.NoError(t, block.Append(true, false, nil, true))
+	require.NoError(t, block.Append(uint8(7), int8(-1), true, sql.NullBool{}))
+	assert.Error(t, block.Append("yes", false, nil, true))
+
+	var b bool
+	require.NoError(t, block.Columns[0].ScanRow(&b, 0))
+	assert.True(t, b)
+	require.NoError(t, block.Columns[0].ScanRow(&b, 1))
+	assert.True(t, b)
+	var n uint8
+	require.NoError(t, block.Columns[0].ScanRow(&n, 1))
+	assert.Equal(t, uint8(7), n)
+	require.NoError(t, block.Columns[1].ScanRow(&b, 1))
+	assert.True(t, b)
+	var p *bool
+	require.NoError(t, block.Columns[2].ScanRow(&p, 1))
+	require.NotNil(t, p)
+	assert.True(t, *p)
+	assert.Nil(t, block.Columns[2].Row(0, false))
+	assert.Equal(t, reflect.TypeOf(p), block.Columns[2].ScanType())
+	assert.Equal(t, false, block.Columns[1].Row(0, false))
+	assert.Equal(t, "UInt8", string(block.Columns[0].Type()))
+
+	var plain Block
+	require.NoError(t, plain.AddColumn("flag", "UInt8"))
+	require.NoError(t, plain.AddColumn("native", "Nullable(Bool)"))
+	require.NoError(t, plain.Append(true, sql.NullBool{}))
+	assert.Error(t, plain.Columns[0].ScanRow(&b, 0))
+	assert.Equal(t, uint8(1), plain.Columns[0].Row(0, false))
+	assert.Nil(t, plain.Columns[1].Row(0, false))
+}
+
+func TestBlockAppendErrorContext(t *testing.T) {
+	var block Block
+	require.NoError(t, block.AddColumn("id", "UInt64"))
+	require.NoError(t, block.AddColumn("name", "String"))
+	for i := 0; i < 3; i++ {
+		require.NoError(t, block.Append(uint64(i), "ok"))
+	}
+	long := strings.Repeat("é", 100)
+	err := block.Append(uint64(3), []string{long})
+	var cErr *column.ColumnConverterError
+	require.ErrorAs(t, err, &cErr)
+	assert.Equal(t, "name", cErr.Column)
+	assert.Equal(t, "String", cErr.ColumnType)
+	assert.Equal(t, 3, cErr.Row)
+	assert.True(t, strings.HasSuffix(cErr.Value, "... (202 bytes)"), cErr.Value)
+	assert.LessOrEqual(t, len(cErr.Value), column.MaxErrorValueLength+len("... (202 bytes)"))
+	assert.True(t, utf8.ValidString(cErr.Value))
+	assert.Contains(t, err.Error(), "(name String) row 3: converting []string to String is unsupported")
+	assert.Contains(t, err.Error(), "(value: [éé")
+
+	var nested Block
+	require.NoError(t, nested.AddColumn("pairs", "Array(Tuple(String, UInt8))"))
+	err = nested.Append([][]interface{}{{"a", "b"}})
+	require.ErrorAs(t, err, &cErr)
+	assert.Equal(t, "UInt8", cErr.To)
+	assert.Equal(t, "Array(Tuple(String, UInt8))", cErr.ColumnType)
+	assert.Equal(t, 0, cErr.Row)
+}
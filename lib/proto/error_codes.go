@@ -0,0 +1,188 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proto
+
+import "fmt"
+
+// ErrorCode is a ClickHouse server error code, see src/Common/ErrorCodes.cpp. It is an error which
+// errors.Is matches against an *Exception with the same code.
+type ErrorCode int32
+
+const (
+	ErrCodeUnsupportedMethod                 ErrorCode = 1
+	ErrCodeUnsupportedParameter              ErrorCode = 2
+	ErrCodeUnexpectedEndOfFile               ErrorCode = 3
+	ErrCodeCannotParseText                   ErrorCode = 6
+	ErrCodeThereIsNoColumn                   ErrorCode = 8
+	ErrCodeNotFoundColumnInBlock             ErrorCode = 10
+	ErrCodeNoSuchColumnInTable               ErrorCode = 16
+	ErrCodeNumberOfColumnsDoesntMatch        ErrorCode = 20
+	ErrCodeCannotParseInputAssertionFailed   ErrorCode = 27
+	ErrCodeBadArguments                      ErrorCode = 36
+	ErrCodeCannotParseDatetime               ErrorCode = 41
+	ErrCodeNumberOfArgumentsDoesntMatch      ErrorCode = 42
+	ErrCodeIllegalTypeOfArgument             ErrorCode = 43
+	ErrCodeIllegalColumn                     ErrorCode = 44
+	ErrCodeUnknownFunction                   ErrorCode = 46
+	ErrCodeUnknownIdentifier                 ErrorCode = 47
+	ErrCodeNotImplemented                    ErrorCode = 48
+	ErrCodeLogicalError                      ErrorCode = 49
+	ErrCodeUnknownType                       ErrorCode = 50
+	ErrCodeTypeMismatch                      ErrorCode = 53
+	ErrCodeTableAlreadyExists                ErrorCode = 57
+	ErrCodeUnknownTable                      ErrorCode = 60
+	ErrCodeSyntaxError                       ErrorCode = 62
+	ErrCodeCannotConvertType                 ErrorCode = 70
+	ErrCodeUnknownFormat                     ErrorCode = 73
+	ErrCodeUnknownDatabase                   ErrorCode = 81
+	ErrCodeDatabaseAlreadyExists             ErrorCode = 82
+	ErrCodeFileDoesntExist                   ErrorCode = 107
+	ErrCodeUnknownSetting                    ErrorCode = 115
+	ErrCodeIncorrectData                     ErrorCode = 117
+	ErrCodeTooLargeStringSize                ErrorCode = 131
+	ErrCodeSamplingNotSupported              ErrorCode = 141
+	ErrCodeTooManyRows                       ErrorCode = 158
+	ErrCodeTimeoutExceeded                   ErrorCode = 159
+	ErrCodeTooSlow                           ErrorCode = 160
+	ErrCodeReadonly                          ErrorCode = 164
+	ErrCodeCyclicAliases                     ErrorCode = 174
+	ErrCodeIllegalAggregation                ErrorCode = 184
+	ErrCodeSizesOfArraysDontMatch            ErrorCode = 190
+	ErrCodeUnknownUser                       ErrorCode = 192
+	ErrCodeWrongPassword                     ErrorCode = 193
+	ErrCodeRequiredPassword                  ErrorCode = 194
+	ErrCodeTooManySimultaneousQueries        ErrorCode = 202
+	ErrCodeSocketTimeout                     ErrorCode = 209
+	ErrCodeNetworkError                      ErrorCode = 210
+	ErrCodeNotAnAggregate                    ErrorCode = 215
+	ErrCodeQueryWithSameIdIsAlreadyRunning   ErrorCode = 216
+	ErrCodeNoZookeeper                       ErrorCode = 225
+	ErrCodeAborted                           ErrorCode = 236
+	ErrCodeMemoryLimitExceeded               ErrorCode = 241
+	ErrCodeTableIsReadOnly                   ErrorCode = 242
+	ErrCodeNotEnoughSpace                    ErrorCode = 243
+	ErrCodeCorruptedData                     ErrorCode = 246
+	ErrCodeTooManyParts                      ErrorCode = 252
+	ErrCodeAllConnectionTriesFailed          ErrorCode = 279
+	ErrCodeTooFewLiveReplicas                ErrorCode = 285
+	ErrCodeUnsatisfiedQuorumForPreviousWrite ErrorCode = 286
+	ErrCodeTooDeepRecursion                  ErrorCode = 306
+	ErrCodeTooManyBytes                      ErrorCode = 307
+	ErrCodeUnknownStatusOfInsert             ErrorCode = 319
+	ErrCodeCannotInsertNullInOrdinaryColumn  ErrorCode = 349
+	ErrCodeNoCommonType                      ErrorCode = 386
+	ErrCodeQueryWasCancelled                 ErrorCode = 394
+	ErrCodeTooManyRowsOrBytes                ErrorCode = 396
+	ErrCodeDecimalOverflow                   ErrorCode = 407
+	ErrCodeDeadlockAvoided                   ErrorCode = 473
+	ErrCodeAccessDenied                      ErrorCode = 497
+	ErrCodeAuthenticationFailed              ErrorCode = 516
+	ErrCodeCannotAssignAlter                 ErrorCode = 517
+	ErrCodeKeeperException                   ErrorCode = 999
+	ErrCodePocoException                     ErrorCode = 1000
+	ErrCodeStdException                      ErrorCode = 1001
+	ErrCodeUnknownException                  ErrorCode = 1002
+)
+
+var errorCodeNames = map[ErrorCode]string{
+	ErrCodeUnsupportedMethod:                 "UNSUPPORTED_METHOD",
+	ErrCodeUnsupportedParameter:              "UNSUPPORTED_PARAMETER",
+	ErrCodeUnexpectedEndOfFile:               "UNEXPECTED_END_OF_FILE",
+	ErrCodeCannotParseText:                   "CANNOT_PARSE_TEXT",
+	ErrCodeThereIsNoColumn:                   "THERE_IS_NO_COLUMN",
+	ErrCodeNotFoundColumnInBlock:             "NOT_FOUND_COLUMN_IN_BLOCK",
+	ErrCodeNoSuchColumnInTable:               "NO_SUCH_COLUMN_IN_TABLE",
+	ErrCodeNumberOfColumnsDoesntMatch:        "NUMBER_OF_COLUMNS_DOESNT_MATCH",
+	ErrCodeCannotParseInputAssertionFailed:   "CANNOT_PARSE_INPUT_ASSERTION_FAILED",
+	ErrCodeBadArguments:                      "BAD_ARGUMENTS",
+	ErrCodeCannotParseDatetime:               "CANNOT_PARSE_DATETIME",
+	ErrCodeNumberOfArgumentsDoesntMatch:      "NUMBER_OF_ARGUMENTS_DOESNT_MATCH",
+	ErrCodeIllegalTypeOfArgument:             "ILLEGAL_TYPE_OF_ARGUMENT",
+	ErrCodeIllegalColumn:                     "ILLEGAL_COLUMN",
+	ErrCodeUnknownFunction:                   "UNKNOWN_FUNCTION",
+	ErrCodeUnknownIdentifier:                 "UNKNOWN_IDENTIFIER",
+	ErrCodeNotImplemented:                    "NOT_IMPLEMENTED",
+	ErrCodeLogicalError:                      "LOGICAL_ERROR",
+	ErrCodeUnknownType:                       "UNKNOWN_TYPE",
+	ErrCodeTypeMismatch:                      "TYPE_MISMATCH",
+	ErrCodeTableAlreadyExists:                "TABLE_ALREADY_EXISTS",
+	ErrCodeUnknownTable:                      "UNKNOWN_TABLE",
+	ErrCodeSyntaxError:                       "SYNTAX_ERROR",
+	ErrCodeCannotConvertType:                 "CANNOT_CONVERT_TYPE",
+	ErrCodeUnknownFormat:                     "UNKNOWN_FORMAT",
+	ErrCodeUnknownDatabase:                   "UNKNOWN_DATABASE",
+	ErrCodeDatabaseAlreadyExists:             "DATABASE_ALREADY_EXISTS",
+	ErrCodeFileDoesntExist:                   "FILE_DOESNT_EXIST",
+	ErrCodeUnknownSetting:                    "UNKNOWN_SETTING",
+	ErrCodeIncorrectData:                     "INCORRECT_DATA",
+	ErrCodeTooLargeStringSize:                "TOO_LARGE_STRING_SIZE",
+	ErrCodeSamplingNotSupported:              "SAMPLING_NOT_SUPPORTED",
+	ErrCodeTooManyRows:                       "TOO_MANY_ROWS",
+	ErrCodeTimeoutExceeded:                   "TIMEOUT_EXCEEDED",
+	ErrCodeTooSlow:                           "TOO_SLOW",
+	ErrCodeReadonly:                          "READONLY",
+	ErrCodeCyclicAliases:                     "CYCLIC_ALIASES",
+	ErrCodeIllegalAggregation:                "ILLEGAL_AGGREGATION",
+	ErrCodeSizesOfArraysDontMatch:            "SIZES_OF_ARRAYS_DONT_MATCH",
+	ErrCodeUnknownUser:                       "UNKNOWN_USER",
+	ErrCodeWrongPassword:                     "WRONG_PASSWORD",
+	ErrCodeRequiredPassword:                  "REQUIRED_PASSWORD",
+	ErrCodeTooManySimultaneousQueries:        "TOO_MANY_SIMULTANEOUS_QUERIES",
+	ErrCodeSocketTimeout:                     "SOCKET_TIMEOUT",
+	ErrCodeNetworkError:                      "NETWORK_ERROR",
+	ErrCodeNotAnAggregate:                    "NOT_AN_AGGREGATE",
+	ErrCodeQueryWithSameIdIsAlreadyRunning:   "QUERY_WITH_SAME_ID_IS_ALREADY_RUNNING",
+	ErrCodeNoZookeeper:                       "NO_ZOOKEEPER",
+	ErrCodeAborted:                           "ABORTED",
+	ErrCodeMemoryLimitExceeded:               "MEMORY_LIMIT_EXCEEDED",
+	ErrCodeTableIsReadOnly:                   "TABLE_IS_READ_ONLY",
+	ErrCodeNotEnoughSpace:                    "NOT_ENOUGH_SPACE",
+	ErrCodeCorruptedData:                     "CORRUPTED_DATA",
+	ErrCodeTooManyParts:                      "TOO_MANY_PARTS",
+	ErrCodeAllConnectionTriesFailed:          "ALL_CONNECTION_TRIES_FAILED",
+	ErrCodeTooFewLiveReplicas:                "TOO_FEW_LIVE_REPLICAS",
+	ErrCodeUnsatisfiedQuorumForPreviousWrite: "UNSATISFIED_QUORUM_FOR_PREVIOUS_WRITE",
+	ErrCodeTooDeepRecursion:                  "TOO_DEEP_RECURSION",
+	ErrCodeTooManyBytes:                      "TOO_MANY_BYTES",
+	ErrCodeUnknownStatusOfInsert:             "UNKNOWN_STATUS_OF_INSERT",
+	ErrCodeCannotInsertNullInOrdinaryColumn:  "CANNOT_INSERT_NULL_IN_ORDINARY_COLUMN",
+	ErrCodeNoCommonType:                      "NO_COMMON_TYPE",
+	ErrCodeQueryWasCancelled:                 "QUERY_WAS_CANCELLED",
+	ErrCodeTooManyRowsOrBytes:                "TOO_MANY_ROWS_OR_BYTES",
+	ErrCodeDecimalOverflow:                   "DECIMAL_OVERFLOW",
+	ErrCodeDeadlockAvoided:                   "DEADLOCK_AVOIDED",
+	ErrCodeAccessDenied:                      "ACCESS_DENIED",
+	ErrCodeAuthenticationFailed:              "AUTHENTICATION_FAILED",
+	ErrCodeCannotAssignAlter:                 "CANNOT_ASSIGN_ALTER",
+	ErrCodeKeeperException:                   "KEEPER_EXCEPTION",
+	ErrCodePocoException:                     "POCO_EXCEPTION",
+	ErrCodeStdException:                      "STD_EXCEPTION",
+	ErrCodeUnknownException:                  "UNKNOWN_EXCEPTION",
+}
+
+// Name is the server name of the code, e.g. UNKNOWN_TABLE, or empty if the code is not known to the client.
+func (c ErrorCode) Name() string {
+	return errorCodeNames[c]
+}
+
+func (c ErrorCode) Error() string {
+	if name := c.Name(); len(name) != 0 {
+		return fmt.Sprintf("code: %d (%s)", int32(c), name)
+	}
+	return fmt.Sprintf("code: %d", int32(c))
+}
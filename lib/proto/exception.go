@@ -29,14 +29,39 @@ type Exception struct {
 	Name       string
 	Message    string
 	StackTrace string
-	Nested     []Exception
-	nested     bool
+	// Nested are the exceptions which caused this one, outermost first. Each of them holds its own causes.
+	Nested []Exception
+	nested bool
 }
 
 func (e *Exception) Error() string {
 	return fmt.Sprintf("code: %d, message: %s", e.Code, e.Message)
 }
 
+// Chain returns e followed by the exceptions which caused it, outermost first.
+func (e *Exception) Chain() []*Exception {
+	chain := []*Exception{e}
+	for i := range e.Nested {
+		chain = append(chain, &e.Nested[i])
+	}
+	return chain
+}
+
+// Is reports whether target is the ErrorCode of the exception, so that errors.Is(err, ErrCodeUnknownTable)
+// matches an *Exception with the code 60.
+func (e *Exception) Is(target error) bool {
+	code, ok := target.(ErrorCode)
+	return ok && int32(code) == e.Code
+}
+
+// DisplayText is the message of the exception as shown by clickhouse-client, prefixed with its name.
+func (e *Exception) DisplayText() string {
+	if len(e.Name) == 0 {
+		return e.Message
+	}
+	return e.Name + ": " + e.Message
+}
+
 func (e *Exception) Decode(reader *proto.Reader) (err error) {
 	var exceptions []Exception
 	for {
@@ -48,18 +73,43 @@ func (e *Exception) Decode(reader *proto.Reader) (err error) {
 			break
 		}
 	}
+	// link every exception to its causes, so that each one unwraps to the next
+	for i := range exceptions {
+		if exceptions[i].nested {
+			exceptions[i].Nested = exceptions[i+1:]
+		}
+	}
 	if len(exceptions) != 0 {
 		e.Code = exceptions[0].Code
 		e.Name = exceptions[0].Name
 		e.Message = exceptions[0].Message
 		e.StackTrace = exceptions[0].StackTrace
-		if exceptions[0].nested {
-			e.Nested = exceptions[1:]
-		}
+		e.Nested = exceptions[0].Nested
 	}
 	return nil
 }
 
+// Unwrap returns the exception which caused e, the first of Nested, so that errors.Is and errors.As
+// inspect the whole chain sent by the server.
+func (e *Exception) Unwrap() error {
+	if len(e.Nested) == 0 {
+		return nil
+	}
+	cause := e.Nested[0]
+	if len(cause.Nested) == 0 {
+		cause.Nested = e.Nested[1:]
+	}
+	return &cause
+}
+
+// Cause returns the innermost exception of the chain, e itself if it has no nested exceptions.
+func (e *Exception) Cause() *Exception {
+	if len(e.Nested) == 0 {
+		return e
+	}
+	return &e.Nested[len(e.Nested)-1]
+}
+
 func (e *Exception) decode(reader *proto.Reader) (err error) {
 	if e.Code, err = reader.Int32(); err != nil {
 		return err
@@ -0,0 +1,64 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proto
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeNestedException(t *testing.T) {
+	var buf proto.Buffer
+	for i, ex := range []struct {
+		code    int32
+		message string
+	}{
+		{252, "Too many parts"},
+		{319, "Unknown status of insert"},
+		{999, "Coordination::Exception: Session expired"},
+	} {
+		buf.PutInt32(ex.code)
+		buf.PutString("DB::Exception")
+		buf.PutString("DB::Exception: " + ex.message)
+		buf.PutString("stack")
+		buf.PutBool(i != 2)
+	}
+	var e Exception
+	require.NoError(t, e.Decode(proto.NewReader(&buf)))
+	assert.Equal(t, int32(252), e.Code)
+	assert.Equal(t, "Too many parts", e.Message)
+	require.Len(t, e.Nested, 2)
+	assert.Equal(t, int32(999), e.Cause().Code)
+	assert.Len(t, e.Chain(), 3)
+
+	var err error = &e
+	assert.True(t, errors.Is(err, ErrCodeKeeperException))
+	assert.True(t, errors.Is(err, ErrCodeUnknownStatusOfInsert))
+	assert.False(t, errors.Is(err, ErrCodeUnknownTable))
+	cause := errors.Unwrap(err).(*Exception)
+	assert.Equal(t, int32(319), cause.Code)
+	assert.Equal(t, int32(999), cause.Cause().Code)
+	assert.Nil(t, errors.Unwrap(errors.Unwrap(cause)))
+
+	flat := &Exception{Code: 1, Nested: []Exception{{Code: 2}, {Code: 3}}}
+	assert.True(t, errors.Is(flat, ErrorCode(3)))
+}
@@ -52,6 +52,32 @@ type ServerHandshake struct {
 	Revision    uint64
 	Version     Version
 	Timezone    *time.Location
+	// ProtocolRevision is the revision negotiated with the client - the lower of the server and client revisions.
+	ProtocolRevision uint64
+}
+
+// Feature is a protocol capability, identified by the first revision which supports it.
+type Feature uint64
+
+const (
+	FeatureClientWriteInfo          Feature = DBMS_MIN_REVISION_WITH_CLIENT_WRITE_INFO
+	FeatureSettingsAsStrings        Feature = DBMS_MIN_REVISION_WITH_SETTINGS_SERIALIZED_AS_STRINGS
+	FeatureInterserverSecret        Feature = DBMS_MIN_REVISION_WITH_INTERSERVER_SECRET
+	FeatureOpenTelemetry            Feature = DBMS_MIN_REVISION_WITH_OPENTELEMETRY
+	FeatureIncrementalProfileEvents Feature = DBMS_MIN_PROTOCOL_VERSION_WITH_INCREMENTAL_PROFILE_EVENTS
+	FeatureParallelReplicas         Feature = DBMS_MIN_REVISION_WITH_PARALLEL_REPLICAS
+	FeatureCustomSerialization      Feature = DBMS_MIN_REVISION_WITH_CUSTOM_SERIALIZATION
+	FeatureQuotaKey                 Feature = DBMS_MIN_PROTOCOL_VERSION_WITH_QUOTA_KEY
+	FeatureParameters               Feature = DBMS_MIN_PROTOCOL_VERSION_WITH_PARAMETERS
+)
+
+// Supports reports whether feature is available with the negotiated protocol revision.
+func (srv *ServerHandshake) Supports(feature Feature) bool {
+	revision := srv.ProtocolRevision
+	if revision == 0 {
+		revision = srv.Revision
+	}
+	return revision >= uint64(feature)
 }
 
 type Version struct {
@@ -0,0 +1,95 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proto
+
+// ServerFeature is a server capability, identified by the first ClickHouse version which supports it.
+type ServerFeature struct {
+	Name  string
+	Since Version
+	// Setting is the setting enabling the feature, if it is experimental, e.g. allow_experimental_object_type.
+	Setting string
+}
+
+var (
+	ServerFeatureAsyncInsert       = ServerFeature{Name: "asynchronous inserts", Since: Version{21, 11, 0}}
+	ServerFeatureDate32            = ServerFeature{Name: "Date32 type", Since: Version{21, 9, 0}}
+	ServerFeatureBoolType          = ServerFeature{Name: "Bool type", Since: Version{21, 12, 0}}
+	ServerFeatureJSONType          = ServerFeature{Name: "JSON type", Since: Version{22, 3, 0}, Setting: "allow_experimental_object_type"}
+	ServerFeatureLightweightDelete = ServerFeature{Name: "lightweight DELETE", Since: Version{23, 3, 0}}
+	ServerFeatureVariantType       = ServerFeature{Name: "Variant type", Since: Version{24, 1, 0}, Setting: "allow_experimental_variant_type"}
+)
+
+// ServerFeatures is the matrix of the server features, in the order of their versions.
+var ServerFeatures = []ServerFeature{
+	ServerFeatureDate32,
+	ServerFeatureAsyncInsert,
+	ServerFeatureBoolType,
+	ServerFeatureJSONType,
+	ServerFeatureLightweightDelete,
+	ServerFeatureVariantType,
+}
+
+// Compare returns -1, 0 or +1 as v is lower than, equal to or greater than other.
+func (v Version) Compare(other Version) int {
+	for _, diff := range [][2]uint64{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		switch {
+		case diff[0] < diff[1]:
+			return -1
+		case diff[0] > diff[1]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// AtLeast reports whether v is other or a later version.
+func (v Version) AtLeast(other Version) bool {
+	return v.Compare(other) >= 0
+}
+
+// AtLeast reports whether the server is version major.minor.patch or later.
+func (srv *ServerHandshake) AtLeast(major, minor, patch uint64) bool {
+	return srv.Version.AtLeast(Version{Major: major, Minor: minor, Patch: patch})
+}
+
+// Has reports whether the version of the server supports feature. The experimental features further
+// require their Setting to be enabled.
+func (srv *ServerHandshake) Has(feature ServerFeature) bool {
+	return srv.Version.AtLeast(feature.Since)
+}
+
+// Features returns the features of ServerFeatures the version of the server supports.
+func (srv *ServerHandshake) Features() []ServerFeature {
+	var features []ServerFeature
+	for _, feature := range ServerFeatures {
+		if srv.Has(feature) {
+			features = append(features, feature)
+		}
+	}
+	return features
+}
+
+// SupportsLightweightDelete reports whether the server runs DELETE FROM statements, see ServerFeatureLightweightDelete.
+func (srv *ServerHandshake) SupportsLightweightDelete() bool {
+	return srv.Has(ServerFeatureLightweightDelete)
+}
+
+// SupportsJSONType reports whether the server has the Object('json') type, see ServerFeatureJSONType.
+func (srv *ServerHandshake) SupportsJSONType() bool {
+	return srv.Has(ServerFeatureJSONType)
+}
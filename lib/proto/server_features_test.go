@@ -0,0 +1,48 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerFeatures(t *testing.T) {
+	assert.Equal(t, 0, Version{23, 3, 1}.Compare(Version{23, 3, 1}))
+	assert.Equal(t, -1, Version{22, 12, 5}.Compare(Version{23, 1, 0}))
+	assert.Equal(t, 1, Version{23, 10, 0}.Compare(Version{23, 9, 7}))
+	assert.True(t, Version{23, 3, 0}.AtLeast(Version{23, 3, 0}))
+	assert.False(t, Version{23, 2, 9}.AtLeast(Version{23, 3, 0}))
+
+	server := ServerHandshake{Version: Version{22, 8, 4}}
+	assert.True(t, server.AtLeast(22, 8, 0))
+	assert.False(t, server.AtLeast(22, 8, 5))
+	assert.True(t, server.SupportsJSONType())
+	assert.False(t, server.SupportsLightweightDelete())
+	assert.Equal(t, []ServerFeature{
+		ServerFeatureDate32,
+		ServerFeatureAsyncInsert,
+		ServerFeatureBoolType,
+		ServerFeatureJSONType,
+	}, server.Features())
+
+	server.Version = Version{23, 3, 1}
+	assert.True(t, server.SupportsLightweightDelete())
+	assert.False(t, server.Has(ServerFeatureVariantType))
+}
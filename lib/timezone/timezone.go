@@ -22,13 +22,29 @@ import (
 	"time"
 )
 
+// Loader loads the location of a timezone name, see SetLoader.
+type Loader func(name string) (*time.Location, error)
+
 var cache = struct {
-	mutex sync.Mutex
-	items map[string]*time.Location
+	mutex  sync.Mutex
+	items  map[string]*time.Location
+	loader Loader
 }{
 	items: make(map[string]*time.Location),
 }
 
+// SetLoader sets the loader of the timezones missing from the local zoneinfo database, e.g. in scratch
+// containers, which otherwise fail the handshake and the queries of DateTime and DateTime64 columns.
+// It may return a location from another source or a substitute such as time.UTC. Building with the
+// clickhouse_tzdata tag (or importing time/tzdata) embeds the zoneinfo database in the binary instead.
+// A nil loader restores the default, which fails.
+func SetLoader(loader Loader) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.loader = loader
+	cache.items = make(map[string]*time.Location)
+}
+
 func Load(name string) (*time.Location, error) {
 	cache.mutex.Lock()
 	defer cache.mutex.Unlock()
@@ -37,7 +53,12 @@ func Load(name string) (*time.Location, error) {
 	}
 	tz, err := time.LoadLocation(name)
 	if err != nil {
-		return nil, err
+		if cache.loader == nil {
+			return nil, err
+		}
+		if tz, err = cache.loader(name); err != nil {
+			return nil, err
+		}
 	}
 	cache.items[name] = tz
 	return tz, nil
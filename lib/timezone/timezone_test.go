@@ -0,0 +1,58 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package timezone
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader(t *testing.T) {
+	defer SetLoader(nil)
+	const name = "Mars/Olympus_Mons"
+	_, err := Load(name)
+	require.Error(t, err)
+
+	var loaded []string
+	SetLoader(func(name string) (*time.Location, error) {
+		loaded = append(loaded, name)
+		if name == "Mars/Unknown" {
+			return nil, errors.New("unknown")
+		}
+		return time.FixedZone(name, 0), nil
+	})
+	for i := 0; i < 2; i++ {
+		tz, err := Load(name)
+		require.NoError(t, err)
+		assert.Equal(t, name, tz.String())
+	}
+	_, err = Load("Mars/Unknown")
+	assert.Error(t, err)
+	tz, err := Load("UTC")
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC.String(), tz.String())
+	assert.Equal(t, []string{name, "Mars/Unknown"}, loaded)
+
+	SetLoader(nil)
+	_, err = Load(name)
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+)
+
+// MemoryFallback re-runs the reads which exceeded the memory limit of the query once, with GROUP BY and ORDER BY
+// spilling to disk, see Options.MemoryFallback. Only the errors reported before the first block of the result
+// can be retried, as the rows received so far cannot be taken back.
+type MemoryFallback struct {
+	// Limits are the limits of the retry. Default MemoryLimitError.External: the limit the query exceeded,
+	// spilling past half of it.
+	Limits *MemoryLimits
+	// OnFallback, if set, is called before the retry, e.g. to log the downgrade or count it. The client logs it
+	// with Debugf as well.
+	OnFallback func(ctx context.Context, query string, err *MemoryLimitError)
+}
+
+// limits returns the limits to retry the query failed with err with, false if it is not to be retried.
+func (f *MemoryFallback) limits(query string, err error) (*MemoryLimitError, MemoryLimits, bool) {
+	var limitErr *MemoryLimitError
+	if !errors.As(err, &limitErr) || limitErr.Scope != "for query" || StatementKind(query) != StatementRead {
+		return nil, MemoryLimits{}, false
+	}
+	limits := limitErr.External()
+	if f.Limits != nil {
+		limits = *f.Limits
+	}
+	if limits.MaxBytesBeforeExternalGroupBy == 0 && limits.MaxBytesBeforeExternalSort == 0 {
+		return nil, MemoryLimits{}, false
+	}
+	return limitErr, limits, true
+}
+
+// memoryFallback wraps invoker so that it retries the reads which exceeded their memory limit, see MemoryFallback.
+func (ch *clickhouse) memoryFallback(invoker Invoker) Invoker {
+	fallback := ch.opt.MemoryFallback
+	if fallback == nil {
+		return invoker
+	}
+	return func(ctx context.Context, op *Operation) error {
+		err := invoker(ctx, op)
+		limitErr, limits, ok := fallback.limits(op.Query, err)
+		if !ok || ctx.Err() != nil {
+			return err
+		}
+		ch.debugf("[memory fallback] retrying with external group by at %d bytes and sort at %d bytes: %v",
+			limits.MaxBytesBeforeExternalGroupBy, limits.MaxBytesBeforeExternalSort, err)
+		if fallback.OnFallback != nil {
+			fallback.OnFallback(ctx, op.Query, limitErr)
+		}
+		op.Rows, op.Row = nil, nil
+		return invoker(Context(ctx, WithMemoryLimits(limits)), op)
+	}
+}
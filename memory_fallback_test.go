@@ -0,0 +1,86 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryFallback(t *testing.T) {
+	var (
+		fallbacks []string
+		settings  []Settings
+		debug     []string
+		ch        = &clickhouse{opt: &Options{
+			Debug:  true,
+			Debugf: func(format string, v ...interface{}) { debug = append(debug, format) },
+			MemoryFallback: &MemoryFallback{
+				OnFallback: func(ctx context.Context, query string, err *MemoryLimitError) {
+					fallbacks = append(fallbacks, query)
+				},
+			},
+		}}
+		oom = func(scope string) error {
+			return memoryLimitError(&Exception{
+				Code:    int32(ErrMemoryLimitExceeded),
+				Message: "Memory limit (" + scope + ") exceeded: would use 1.00 GiB (attempt to allocate chunk of 4194304 bytes), maximum: 1.00 GiB",
+			})
+		}
+		invoker = ch.memoryFallback(func(ctx context.Context, op *Operation) error {
+			options := queryOptions(ctx)
+			settings = append(settings, options.settings)
+			if _, ok := options.settings["max_bytes_before_external_group_by"]; ok {
+				return nil
+			}
+			switch op.Query {
+			case "SELECT user":
+				return oom("for user")
+			case "SELECT broken":
+				return errors.New("broken")
+			}
+			return oom("for query")
+		})
+		ctx = context.Background()
+	)
+	require.NoError(t, invoker(ctx, &Operation{Kind: OperationQuery, Query: "SELECT uniqExact(id) FROM hits GROUP BY url"}))
+	assert.Equal(t, []string{"SELECT uniqExact(id) FROM hits GROUP BY url"}, fallbacks)
+	require.Len(t, settings, 2)
+	assert.Equal(t, Settings{
+		"max_memory_usage":                   uint64(1 << 30),
+		"max_bytes_before_external_group_by": uint64(1 << 29),
+		"max_bytes_before_external_sort":     uint64(1 << 29),
+	}, settings[1])
+	assert.Len(t, debug, 1)
+
+	settings = nil
+	assert.True(t, errors.Is(invoker(ctx, &Operation{Kind: OperationQuery, Query: "SELECT user"}), ErrMemoryLimitExceeded))
+	assert.EqualError(t, invoker(ctx, &Operation{Kind: OperationQuery, Query: "SELECT broken"}), "broken")
+	assert.True(t, errors.Is(invoker(ctx, &Operation{Kind: OperationExec, Query: "INSERT INTO t SELECT * FROM hits"}), ErrMemoryLimitExceeded))
+	assert.Len(t, settings, 3)
+	assert.Len(t, fallbacks, 1)
+
+	ch.opt.MemoryFallback.Limits = &MemoryLimits{MaxBytesBeforeExternalSort: 100}
+	settings = nil
+	assert.True(t, errors.Is(invoker(ctx, &Operation{Kind: OperationQuery, Query: "SELECT * FROM hits ORDER BY ts"}), ErrMemoryLimitExceeded))
+	assert.Equal(t, Settings{"max_bytes_before_external_sort": uint64(100)}, settings[1])
+}
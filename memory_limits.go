@@ -0,0 +1,141 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MemoryLimits bounds the memory of a query, see WithMemoryLimits. Zero keeps the setting of the server.
+type MemoryLimits struct {
+	MaxMemoryUsage uint64 // max_memory_usage
+	// MaxBytesBeforeExternalGroupBy and MaxBytesBeforeExternalSort spill GROUP BY and ORDER BY to disk once
+	// they use as many bytes, at the cost of speed. ClickHouse recommends half of MaxMemoryUsage.
+	MaxBytesBeforeExternalGroupBy uint64 // max_bytes_before_external_group_by
+	MaxBytesBeforeExternalSort    uint64 // max_bytes_before_external_sort
+}
+
+// WithMemoryLimits sets max_memory_usage, max_bytes_before_external_group_by and max_bytes_before_external_sort
+// for the query.
+func WithMemoryLimits(limits MemoryLimits) QueryOption {
+	return func(o *QueryOptions) error {
+		settings := make(Settings, len(o.settings)+3)
+		for k, v := range o.settings {
+			settings[k] = v
+		}
+		for key, value := range map[string]uint64{
+			"max_memory_usage":                   limits.MaxMemoryUsage,
+			"max_bytes_before_external_group_by": limits.MaxBytesBeforeExternalGroupBy,
+			"max_bytes_before_external_sort":     limits.MaxBytesBeforeExternalSort,
+		} {
+			if value != 0 {
+				settings[key] = value
+			}
+		}
+		o.settings = settings
+		return nil
+	}
+}
+
+// MemoryLimitError is returned for a MEMORY_LIMIT_EXCEEDED server exception, over any protocol. It
+// matches ErrMemoryLimitExceeded, and errors.As finds the *Exception it wraps.
+type MemoryLimitError struct {
+	// Scope is the limit which was exceeded as reported by the server, e.g. "for query", "for user" or "total".
+	Scope string
+	// Peak is the memory usage in bytes the query would have reached, and Limit the maximum it exceeded. Both
+	// are parsed from the rounded sizes of the exception message and are zero if it could not be parsed.
+	Peak  uint64
+	Limit uint64
+	Err   error
+}
+
+func (e *MemoryLimitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *MemoryLimitError) Unwrap() error {
+	return e.Err
+}
+
+// External returns limits to retry the query with, which aggregate and sort on disk past half of the limit
+// it exceeded. Retrying makes sense for the "for query" scope only: the other ones depend on the other
+// queries of the user or the server.
+func (e *MemoryLimitError) External() MemoryLimits {
+	return MemoryLimits{
+		MaxMemoryUsage:                e.Limit,
+		MaxBytesBeforeExternalGroupBy: e.Limit / 2,
+		MaxBytesBeforeExternalSort:    e.Limit / 2,
+	}
+}
+
+var (
+	memoryLimitScopeRe = regexp.MustCompile(`Memory limit \(([^)]+)\) exceeded`)
+	memoryLimitPeakRe  = regexp.MustCompile(`would use ([\d.]+ ?[A-Za-z]+)`)
+	memoryLimitMaxRe   = regexp.MustCompile(`maximum: ([\d.]+ ?[A-Za-z]+)`)
+)
+
+// memoryLimitError wraps err into a *MemoryLimitError if it is a MEMORY_LIMIT_EXCEEDED exception.
+func memoryLimitError(err error) error {
+	var exception *Exception
+	if !errors.Is(err, ErrMemoryLimitExceeded) || !errors.As(err, &exception) {
+		return err
+	}
+	var limitErr *MemoryLimitError
+	if errors.As(err, &limitErr) {
+		return err
+	}
+	limitErr = &MemoryLimitError{Err: err}
+	if match := memoryLimitScopeRe.FindStringSubmatch(exception.Message); match != nil {
+		limitErr.Scope = match[1]
+	}
+	if match := memoryLimitPeakRe.FindStringSubmatch(exception.Message); match != nil {
+		limitErr.Peak, _ = parseReadableSize(match[1])
+	}
+	if match := memoryLimitMaxRe.FindStringSubmatch(exception.Message); match != nil {
+		limitErr.Limit, _ = parseReadableSize(match[1])
+	}
+	return limitErr
+}
+
+// parseReadableSize parses a size formatted by the server with formatReadableSizeWithBinarySuffix,
+// e.g. "9.31 GiB".
+func parseReadableSize(s string) (uint64, error) {
+	var (
+		i    = strings.IndexFunc(s, func(r rune) bool { return !(r >= '0' && r <= '9' || r == '.') })
+		unit string
+	)
+	if i == -1 {
+		i = len(s)
+	}
+	value, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, err
+	}
+	unit = strings.TrimSpace(s[i:])
+	for _, suffix := range []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"} {
+		if strings.EqualFold(unit, suffix) || (suffix == "B" && (unit == "" || unit == "bytes")) {
+			return uint64(value), nil
+		}
+		value *= 1024
+	}
+	return 0, fmt.Errorf("unknown size unit %q", unit)
+}
@@ -0,0 +1,90 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMemoryLimits(t *testing.T) {
+	ctx := Context(context.Background(), WithMemoryLimits(MemoryLimits{
+		MaxMemoryUsage:             10 << 30,
+		MaxBytesBeforeExternalSort: 5 << 30,
+	}))
+	assert.Equal(t, Settings{
+		"max_memory_usage":               uint64(10 << 30),
+		"max_bytes_before_external_sort": uint64(5 << 30),
+	}, queryOptions(ctx).settings)
+}
+
+func TestMemoryLimitError(t *testing.T) {
+	gib := float64(1 << 30)
+	exception := &Exception{
+		Code:    int32(ErrMemoryLimitExceeded),
+		Name:    "DB::Exception",
+		Message: "Memory limit (for query) exceeded: would use 9.32 GiB (attempt to allocate chunk of 4360448 bytes), maximum: 9.31 GiB.: While executing AggregatingTransform",
+	}
+	err := memoryLimitError(exception)
+	var limitErr *MemoryLimitError
+	require.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, "for query", limitErr.Scope)
+	assert.Equal(t, uint64(9.32*gib), limitErr.Peak)
+	assert.Equal(t, uint64(9.31*gib), limitErr.Limit)
+	assert.Equal(t, limitErr.Limit/2, limitErr.External().MaxBytesBeforeExternalGroupBy)
+	assert.True(t, errors.Is(err, ErrMemoryLimitExceeded))
+	assert.Equal(t, exception.Error(), err.Error())
+	var unwrapped *Exception
+	require.True(t, errors.As(err, &unwrapped))
+	assert.Same(t, exception, unwrapped)
+	assert.Same(t, err, memoryLimitError(err))
+
+	header := http.Header{"X-Clickhouse-Exception-Code": []string{"241"}}
+	httpErr := memoryLimitError(&httpExecError{
+		msg:       "clickhouse [execute]:: 500 code: Code: 241. DB::Exception: ...",
+		exception: httpException(header, "Code: 241. DB::Exception: Memory limit (total) exceeded: would use 12.60 GiB (attempt to allocate chunk of 4217748 bytes), current RSS 12.00 GiB, maximum: 12.60 GiB. (MEMORY_LIMIT_EXCEEDED) (version 24.3.1.1)"),
+	})
+	require.True(t, errors.As(httpErr, &limitErr))
+	assert.Equal(t, "total", limitErr.Scope)
+	assert.Equal(t, uint64(12.60*gib), limitErr.Limit)
+
+	other := &Exception{Code: int32(ErrTableNotFound), Message: "Table default.x does not exist"}
+	assert.Same(t, other, memoryLimitError(other))
+	assert.Nil(t, memoryLimitError(nil))
+}
+
+func TestParseReadableSize(t *testing.T) {
+	for s, expected := range map[string]uint64{
+		"512.00 B":  512,
+		"1.50 KiB":  1536,
+		"2.00 MiB":  2 << 20,
+		"1.00 TiB":  1 << 40,
+		"100 bytes": 100,
+	} {
+		size, err := parseReadableSize(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, expected, size, s)
+	}
+	_, err := parseReadableSize("1.00 GB")
+	assert.Error(t, err)
+}
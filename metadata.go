@@ -0,0 +1,114 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// MaxMetadataValueLength is the length in bytes the values of Metadata are truncated to.
+	MaxMetadataValueLength = 256
+	// MaxMetadataSize is the size in bytes of the log_comment Metadata is sent as. The entries past it, in
+	// the order of their keys, are left out.
+	MaxMetadataSize = 4096
+)
+
+// Metadata describes the origin of queries and inserts, e.g. the service and job, for audits of
+// system.query_log and system.part_log.
+type Metadata map[string]string
+
+// WithMetadata adds md to the metadata of the queries, or batches, of the context. The metadata is sent as
+// the log_comment setting, a JSON object, unless the query sets the setting itself. The recording span of the
+// context, if any, is tagged with the metadata as clickhouse.metadata.<key> attributes.
+func WithMetadata(md Metadata) QueryOption {
+	return func(o *QueryOptions) error {
+		metadata := make(Metadata, len(o.metadata)+len(md))
+		for k, v := range o.metadata {
+			metadata[k] = v
+		}
+		for k, v := range md {
+			metadata[k] = v
+		}
+		o.metadata, o.tagSpan = metadata, true
+		return nil
+	}
+}
+
+// logComment returns the log_comment of the metadata, if the query settings do not set it.
+func (o *QueryOptions) logComment() (string, bool) {
+	if len(o.metadata) == 0 {
+		return "", false
+	}
+	if _, ok := o.settings["log_comment"]; ok {
+		return "", false
+	}
+	return o.metadata.encode(), true
+}
+
+// encode returns md as a JSON object of at most MaxMetadataSize bytes.
+func (md Metadata) encode() string {
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	comment := []byte{'{'}
+	for _, k := range keys {
+		key, _ := json.Marshal(k)
+		value, _ := json.Marshal(truncateMetadata(md[k]))
+		if len(comment)+len(key)+len(value)+3 > MaxMetadataSize {
+			break
+		}
+		if len(comment) > 1 {
+			comment = append(comment, ',')
+		}
+		comment = append(append(append(comment, key...), ':'), value...)
+	}
+	return string(append(comment, '}'))
+}
+
+func truncateMetadata(v string) string {
+	if len(v) <= MaxMetadataValueLength {
+		return v
+	}
+	end := MaxMetadataValueLength
+	for end > 0 && !utf8.RuneStart(v[end]) {
+		end--
+	}
+	return v[:end]
+}
+
+// tagSpan sets the metadata as attributes of the recording span of ctx.
+func tagSpan(ctx context.Context, md Metadata) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	attributes := make([]attribute.KeyValue, 0, len(md))
+	for k, v := range md {
+		attributes = append(attributes, attribute.String("clickhouse.metadata."+k, truncateMetadata(v)))
+	}
+	span.SetAttributes(attributes...)
+}
@@ -0,0 +1,90 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordingSpan records the attributes set on it.
+type recordingSpan struct {
+	trace.Span
+	attributes map[attribute.Key]string
+}
+
+func (s *recordingSpan) IsRecording() bool { return true }
+
+func (s *recordingSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, kv := range kv {
+		s.attributes[kv.Key] = kv.Value.AsString()
+	}
+}
+
+func TestMetadataLogComment(t *testing.T) {
+	ctx := Context(context.Background(), WithMetadata(Metadata{"service": "ingest", "job": "daily"}))
+	ctx = Context(ctx, WithMetadata(Metadata{"job": "hourly"}))
+	options := queryOptions(ctx)
+	comment, ok := options.logComment()
+	require.True(t, ok)
+	assert.Equal(t, `{"job":"hourly","service":"ingest"}`, comment)
+
+	options = queryOptions(Context(ctx, WithSettings(Settings{"log_comment": "explicit"})))
+	_, ok = options.logComment()
+	assert.False(t, ok)
+	options = queryOptions(context.Background())
+	_, ok = options.logComment()
+	assert.False(t, ok)
+}
+
+func TestMetadataSizeGuards(t *testing.T) {
+	md := Metadata{
+		"a": strings.Repeat("é", MaxMetadataValueLength),
+		"b": `quoted "value"`,
+	}
+	for i := 0; i < 100; i++ {
+		md[strings.Repeat("k", 10)+string(rune('a'+i%26))+string(rune('a'+i/26))] = strings.Repeat("v", 100)
+	}
+	comment := md.encode()
+	assert.LessOrEqual(t, len(comment), MaxMetadataSize)
+	var decoded map[string]string
+	require.NoError(t, json.Unmarshal([]byte(comment), &decoded))
+	assert.Len(t, decoded["a"], MaxMetadataValueLength)
+	assert.Equal(t, `quoted "value"`, decoded["b"])
+	assert.Less(t, len(decoded), len(md))
+}
+
+func TestMetadataTagsSpan(t *testing.T) {
+	span := &recordingSpan{
+		Span:       trace.SpanFromContext(context.Background()),
+		attributes: make(map[attribute.Key]string),
+	}
+	ctx := trace.ContextWithSpan(context.Background(), span)
+	ctx = Context(ctx, WithMetadata(Metadata{"service": "ingest"}))
+	assert.Equal(t, map[attribute.Key]string{"clickhouse.metadata.service": "ingest"}, span.attributes)
+	delete(span.attributes, "clickhouse.metadata.service")
+	Context(ctx, WithQueryID("id"))
+	assert.Empty(t, span.attributes, "the span is only tagged by WithMetadata")
+}
@@ -0,0 +1,206 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package monitoring reads the state of MergeTree tables from system.parts and system.merges, e.g. for
+// ingestion services to slow down before the server rejects inserts with TOO_MANY_PARTS:
+//
+//	pressure, err := monitoring.Pressure(ctx, conn, "events")
+//	if err == nil && pressure > 0.5 {
+//		// insert bigger batches, less often
+//	}
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// TableParts summarizes the active data parts of a table.
+type TableParts struct {
+	Database string `ch:"database"`
+	Table    string `ch:"table"`
+	// ActiveParts is the number of active parts, MaxPartsInPartition the number in the partition with
+	// the most parts, which the server compares with parts_to_delay_insert and parts_to_throw_insert.
+	ActiveParts         uint64 `ch:"active_parts"`
+	Partitions          uint64 `ch:"partitions"`
+	MaxPartsInPartition uint64 `ch:"max_parts_in_partition"`
+	Rows                uint64 `ch:"rows"`
+	BytesOnDisk         uint64 `ch:"bytes_on_disk"`
+	CompressedBytes     uint64 `ch:"compressed_bytes"`
+	UncompressedBytes   uint64 `ch:"uncompressed_bytes"`
+}
+
+// Merge is a merge or a mutation running on a table.
+type Merge struct {
+	Database       string
+	Table          string
+	Elapsed        time.Duration
+	Progress       float64 // from 0 to 1
+	NumParts       uint64
+	ResultPartName string
+	IsMutation     bool
+	// TotalBytes is the compressed size of the merged parts.
+	TotalBytes     uint64
+	RowsRead       uint64
+	RowsWritten    uint64
+	MemoryUsage    uint64
+	MergeType      string
+	MergeAlgorithm string
+}
+
+const partsQuery = `
+	SELECT
+		  database
+		, table
+		, sum(parts) AS active_parts
+		, count() AS partitions
+		, max(parts) AS max_parts_in_partition
+		, sum(partition_rows) AS rows
+		, sum(partition_bytes_on_disk) AS bytes_on_disk
+		, sum(partition_compressed_bytes) AS compressed_bytes
+		, sum(partition_uncompressed_bytes) AS uncompressed_bytes
+	FROM (
+		SELECT
+			  database
+			, table
+			, count() AS parts
+			, sum(rows) AS partition_rows
+			, sum(bytes_on_disk) AS partition_bytes_on_disk
+			, sum(data_compressed_bytes) AS partition_compressed_bytes
+			, sum(data_uncompressed_bytes) AS partition_uncompressed_bytes
+		FROM system.parts
+		WHERE active AND %s
+		GROUP BY database, table, partition_id
+	)
+	GROUP BY database, table
+	ORDER BY database, table`
+
+// Parts returns the active parts of table, optionally qualified with its database. A table without
+// parts, e.g. an empty one, yields zero counts.
+func Parts(ctx context.Context, conn driver.Conn, table string) (TableParts, error) {
+	var (
+		parts        []TableParts
+		filter, args = tableFilter(table)
+	)
+	if err := conn.Select(ctx, &parts, fmt.Sprintf(partsQuery, filter), args...); err != nil {
+		return TableParts{}, err
+	}
+	if len(parts) == 0 {
+		database, name := clickhouse.SplitTableName(table)
+		return TableParts{Database: database, Table: name}, nil
+	}
+	return parts[0], nil
+}
+
+// DatabaseParts returns the active parts of the tables of database, the current database if empty,
+// ordered by table name. Tables without parts are omitted.
+func DatabaseParts(ctx context.Context, conn driver.Conn, database string) ([]TableParts, error) {
+	var (
+		parts  []TableParts
+		filter = "database = currentDatabase()"
+		args   []interface{}
+	)
+	if len(database) != 0 {
+		filter, args = "database = @database", []interface{}{clickhouse.Named("database", database)}
+	}
+	if err := conn.Select(ctx, &parts, fmt.Sprintf(partsQuery, filter), args...); err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+// Merges returns the merges and mutations running on table, optionally qualified with its database.
+func Merges(ctx context.Context, conn driver.Conn, table string) ([]Merge, error) {
+	filter, args := tableFilter(table)
+	rows, err := conn.Query(ctx, `
+		SELECT
+			  database
+			, table
+			, elapsed
+			, progress
+			, num_parts
+			, result_part_name
+			, is_mutation
+			, total_size_bytes_compressed
+			, rows_read
+			, rows_written
+			, memory_usage
+			, toString(merge_type)
+			, toString(merge_algorithm)
+		FROM system.merges
+		WHERE `+filter+`
+		ORDER BY elapsed DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var merges []Merge
+	for rows.Next() {
+		var (
+			m          Merge
+			elapsed    float64
+			isMutation uint8
+		)
+		if err := rows.Scan(
+			&m.Database, &m.Table, &elapsed, &m.Progress, &m.NumParts, &m.ResultPartName, &isMutation,
+			&m.TotalBytes, &m.RowsRead, &m.RowsWritten, &m.MemoryUsage, &m.MergeType, &m.MergeAlgorithm,
+		); err != nil {
+			return nil, err
+		}
+		m.Elapsed, m.IsMutation = time.Duration(elapsed*float64(time.Second)), isMutation == 1
+		merges = append(merges, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return merges, nil
+}
+
+// Pressure returns the parts count of the fullest partition of table relative to parts_to_throw_insert,
+// at which the server rejects inserts. Inserts are delayed from parts_to_delay_insert on, usually half
+// of it. Table level overrides of the settings are not taken into account.
+func Pressure(ctx context.Context, conn driver.Conn, table string) (float64, error) {
+	parts, err := Parts(ctx, conn, table)
+	if err != nil {
+		return 0, err
+	}
+	var limit string
+	if err := conn.QueryRow(ctx, "SELECT value FROM system.merge_tree_settings WHERE name = 'parts_to_throw_insert'").Scan(&limit); err != nil {
+		return 0, err
+	}
+	var throw uint64
+	if _, err := fmt.Sscan(limit, &throw); err != nil || throw == 0 {
+		return 0, fmt.Errorf("clickhouse [monitoring]: invalid parts_to_throw_insert %q", limit)
+	}
+	return float64(parts.MaxPartsInPartition) / float64(throw), nil
+}
+
+// tableFilter returns the system table condition selecting table, optionally qualified with its database.
+func tableFilter(table string) (string, []interface{}) {
+	database, name := clickhouse.SplitTableName(table)
+	if len(database) != 0 {
+		return "database = @database AND table = @table", []interface{}{
+			clickhouse.Named("database", database),
+			clickhouse.Named("table", name),
+		}
+	}
+	return "database = currentDatabase() AND table = @table", []interface{}{clickhouse.Named("table", name)}
+}
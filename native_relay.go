@@ -0,0 +1,276 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+)
+
+// NativeRelay copies results and inserts as streams in the Native format, e.g. to copy a table to another
+// cluster through a Go process. The stream is the one returned by the HTTP interface for FORMAT Native.
+//
+// Over HTTP the stream is passed through as it is, without being decoded. The native protocol has no such
+// format on the wire, so its blocks are decoded and encoded again, still without converting the values to
+// Go types. A connection returned by Open implements it, see ExportNative and ImportNative, and so does a
+// database/sql connection of the native or HTTP protocol - obtain it via Raw as NativeConn.
+type NativeRelay interface {
+	// ExportNative runs query and writes its result to w in the Native format, returning the number of bytes
+	// written. The totals of WITH TOTALS are left out.
+	ExportNative(ctx context.Context, w io.Writer, query string, args ...interface{}) (int64, error)
+	// ImportNative inserts the blocks of the Native format stream r into the columns of table named by it.
+	ImportNative(ctx context.Context, table string, r io.Reader) error
+}
+
+// ExportNative runs query on conn and writes its result to w in the Native format, see NativeRelay.
+func ExportNative(ctx context.Context, conn driver.Conn, w io.Writer, query string, args ...interface{}) (int64, error) {
+	relay, ok := conn.(NativeRelay)
+	if !ok {
+		return 0, fmt.Errorf("clickhouse: native relay is not supported by %T", conn)
+	}
+	return relay.ExportNative(ctx, w, query, args...)
+}
+
+// ImportNative inserts the blocks of the Native format stream r, e.g. written by ExportNative, into table on
+// conn, see NativeRelay.
+func ImportNative(ctx context.Context, conn driver.Conn, table string, r io.Reader) error {
+	relay, ok := conn.(NativeRelay)
+	if !ok {
+		return fmt.Errorf("clickhouse: native relay is not supported by %T", conn)
+	}
+	return relay.ImportNative(ctx, table, r)
+}
+
+func (ch *clickhouse) ExportNative(ctx context.Context, w io.Writer, query string, args ...interface{}) (n int64, err error) {
+	op := &Operation{Kind: OperationExportNative, Query: query, Args: args}
+	err = ch.intercept(ctx, op, func(ctx context.Context, op *Operation) error {
+		pool := ch.pool(ctx, op.Query)
+		conn, err := pool.acquire(ctx)
+		if err != nil {
+			return err
+		}
+		rows, err := conn.query(ctx, pool.release, op.Query, op.Args...)
+		if err != nil {
+			return err
+		}
+		n, err = writeNative(w, rows)
+		return err
+	})
+	return n, err
+}
+
+func (ch *clickhouse) ImportNative(ctx context.Context, table string, r io.Reader) error {
+	stream, err := readNative(table, r)
+	if err != nil || stream == nil {
+		return err
+	}
+	op := &Operation{Kind: OperationImportNative, Query: stream.query}
+	return ch.intercept(ctx, op, func(ctx context.Context, op *Operation) error {
+		if err := ch.opt.InsertThrottle.wait(ctx, op.Query); err != nil {
+			return err
+		}
+		conn, err := ch.acquire(ctx)
+		if err != nil {
+			return err
+		}
+		prepared, err := conn.prepareBatch(ctx, op.Query, ch.release, ch.acquire)
+		if err != nil {
+			return err
+		}
+		return stream.insert(prepared.(*batch))
+	})
+}
+
+func (std *stdDriver) ExportNative(ctx context.Context, w io.Writer, query string, args ...interface{}) (int64, error) {
+	ctx, err := std.context(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := std.opt.checkStatement(query); err != nil {
+		return 0, err
+	}
+	ctx = consistentRead(ctx, query)
+	switch conn := std.conn.(type) {
+	case *httpConnect:
+		return conn.exportNative(ctx, w, query, args...)
+	case *connect:
+		rows, err := conn.query(ctx, func(*connect, error) {}, query, args...)
+		if err != nil {
+			return 0, err
+		}
+		return writeNative(w, rows)
+	}
+	return 0, fmt.Errorf("clickhouse: native relay is not supported by the %s protocol", std.opt.Protocol)
+}
+
+func (std *stdDriver) ImportNative(ctx context.Context, table string, r io.Reader) (err error) {
+	ctx, err = std.context(ctx)
+	if err != nil {
+		return err
+	}
+	switch conn := std.conn.(type) {
+	case *httpConnect:
+		query := "INSERT INTO " + table + " FORMAT Native"
+		if err := std.opt.checkStatement(query); err != nil {
+			return err
+		}
+		defer func() {
+			observeWrite(ctx, query, err)
+		}()
+		return conn.importNative(ctx, query, r)
+	case *connect:
+		stream, err := readNative(table, r)
+		if err != nil || stream == nil {
+			return err
+		}
+		if err := std.opt.checkStatement(stream.query); err != nil {
+			return err
+		}
+		defer func() {
+			observeWrite(ctx, stream.query, err)
+		}()
+		prepared, err := conn.prepareBatch(ctx, stream.query, func(*connect, error) {}, nil)
+		if err != nil {
+			return err
+		}
+		return stream.insert(prepared.(*batch))
+	}
+	return fmt.Errorf("clickhouse: native relay is not supported by the %s protocol", std.opt.Protocol)
+}
+
+// exportNative copies the response of query, in the Native format set by default_format, to w.
+func (h *httpConnect) exportNative(ctx context.Context, w io.Writer, query string, args ...interface{}) (int64, error) {
+	options := queryOptions(ctx)
+	query, err := bindQueryOrAppendParameters(true, &options, query, h.location, args...)
+	if err != nil {
+		return 0, err
+	}
+	headers := make(map[string]string, len(h.headers))
+	for k, v := range h.headers {
+		headers[k] = v
+	}
+	res, err := h.sendQuery(ctx, strings.NewReader(query), &options, headers)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	return io.Copy(w, res.Body)
+}
+
+// importNative sends r as the data of query, an INSERT in the Native format.
+func (h *httpConnect) importNative(ctx context.Context, query string, r io.Reader) error {
+	options := queryOptions(ctx)
+	settings := make(Settings, len(options.settings)+1)
+	for k, v := range options.settings {
+		settings[k] = v
+	}
+	settings["query"] = query
+	options.settings = settings
+	headers := map[string]string{"Content-Type": "application/octet-stream"}
+	for k, v := range h.headers {
+		headers[k] = v
+	}
+	res, err := h.sendQuery(ctx, r, &options, headers)
+	if res != nil {
+		defer res.Body.Close()
+		_, _ = io.Copy(io.Discard, res.Body)
+	}
+	return err
+}
+
+// writeNative writes the blocks of rows to w in the Native format.
+func writeNative(w io.Writer, rows *rows) (n int64, err error) {
+	defer rows.Close()
+	var buffer chproto.Buffer
+	for rows.next() {
+		buffer.Reset()
+		if err := rows.block.Encode(&buffer, 0); err != nil {
+			return n, err
+		}
+		written, err := w.Write(buffer.Buf)
+		if n += int64(written); err != nil {
+			return n, err
+		}
+		// skip the rest of the block
+		rows.row = rows.block.Rows()
+	}
+	return n, rows.Err()
+}
+
+// nativeStream is a Native format stream to insert over the native protocol, see readNative.
+type nativeStream struct {
+	query  string
+	reader *chproto.Reader
+	first  *proto.Block
+}
+
+// readNative reads the first block of the Native format stream r, which names the columns of the INSERT into
+// table. It returns nil if the stream is empty.
+func readNative(table string, r io.Reader) (*nativeStream, error) {
+	stream := &nativeStream{reader: chproto.NewReader(bufio.NewReader(r))}
+	first, err := stream.next()
+	if err != nil || first == nil {
+		return nil, err
+	}
+	columns := make([]string, 0, len(first.Columns))
+	for _, name := range first.ColumnsNames() {
+		columns = append(columns, quoteIdentifier(name))
+	}
+	stream.query = "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ")"
+	stream.first = first
+	return stream, nil
+}
+
+// next decodes the next block of the stream, nil at its end.
+func (s *nativeStream) next() (*proto.Block, error) {
+	var block proto.Block
+	if err := block.Decode(s.reader, 0); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &block, nil
+}
+
+// insert sends the blocks of the stream with b, the columns of which are in the order of the stream.
+func (s *nativeStream) insert(b *batch) error {
+	for block := s.first; block != nil; {
+		if block.Rows() != 0 {
+			b.block = block
+			if err := b.Flush(); err != nil {
+				b.Abort()
+				return err
+			}
+		}
+		var err error
+		if block, err = s.next(); err != nil {
+			b.Abort()
+			return err
+		}
+	}
+	return b.Send()
+}
@@ -0,0 +1,146 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func relayBlock(t *testing.T, rows int) *proto.Block {
+	block := &proto.Block{}
+	require.NoError(t, block.AddColumn("n", "UInt64"))
+	require.NoError(t, block.AddColumn("s", "String"))
+	for i := 0; i < rows; i++ {
+		require.NoError(t, block.Append(uint64(i), strings.Repeat("x", i)))
+	}
+	return block
+}
+
+func TestNativeRelay(t *testing.T) {
+	var server chproto.Buffer
+	for _, rows := range []int{0, 2, 3} {
+		server.PutByte(proto.ServerData)
+		server.PutString("")
+		require.NoError(t, relayBlock(t, rows).Encode(&server, proto.DBMS_MIN_REVISION_WITH_QUOTA_KEY_IN_CLIENT_INFO))
+	}
+	server.PutByte(proto.ServerEndOfStream)
+	source, err := Open(&Options{
+		Addr: []string{"source:9000"},
+		DialContext: func(context.Context, string) (net.Conn, error) {
+			return &replayConn{data: fakeServer(server.Buf...)}, nil
+		},
+	})
+	require.NoError(t, err)
+
+	var exported bytes.Buffer
+	n, err := ExportNative(context.Background(), source, &exported, "SELECT n, s FROM t")
+	require.NoError(t, err)
+	assert.Equal(t, int64(exported.Len()), n)
+
+	stream, err := readNative("copy", bytes.NewReader(exported.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO copy (n, s)", stream.query)
+	var values []string
+	for block := stream.first; block != nil; block, err = stream.next() {
+		require.NoError(t, err)
+		for i := 0; i < block.Rows(); i++ {
+			values = append(values, block.Columns[1].Row(i, false).(string))
+		}
+	}
+	assert.Equal(t, []string{"", "x", "", "x", "xx"}, values)
+
+	server.Reset()
+	server.PutByte(proto.ServerData)
+	server.PutString("")
+	require.NoError(t, relayBlock(t, 0).Encode(&server, proto.DBMS_MIN_REVISION_WITH_QUOTA_KEY_IN_CLIENT_INFO))
+	server.PutByte(proto.ServerEndOfStream)
+	destination := &recordingConn{replayConn: replayConn{data: fakeServer(server.Buf...)}}
+	target, err := Open(&Options{
+		Addr: []string{"target:9000"},
+		DialContext: func(context.Context, string) (net.Conn, error) {
+			return destination, nil
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, ImportNative(context.Background(), target, "copy", bytes.NewReader(exported.Bytes())))
+	assert.Contains(t, destination.written.String(), "INSERT INTO copy (n, s) VALUES")
+	assert.Contains(t, destination.written.String(), "xx")
+
+	empty, err := readNative("copy", bytes.NewReader(nil))
+	require.NoError(t, err)
+	assert.Nil(t, empty)
+	assert.NoError(t, ImportNative(context.Background(), target, "copy", bytes.NewReader(nil)))
+}
+
+func TestNativeRelayHTTP(t *testing.T) {
+	var native chproto.Buffer
+	require.NoError(t, relayBlock(t, 3).Encode(&native, 0))
+	var imported []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var (
+			block proto.Block
+			buf   chproto.Buffer
+		)
+		switch query := r.URL.Query().Get("query"); {
+		case query == "INSERT INTO copy FORMAT Native":
+			imported = body
+			return
+		case string(body) == "SELECT version()":
+			block.AddColumn("version()", "String")
+			block.Append("23.8.1")
+		case string(body) == "SELECT timezone()":
+			block.AddColumn("timezone()", "String")
+			block.Append("UTC")
+		default:
+			w.Write(native.Buf)
+			return
+		}
+		block.Encode(&buf, 0)
+		w.Write(buf.Buf)
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	opt := (&Options{Protocol: HTTP, Addr: []string{addr}}).setDefaults()
+	conn, err := dialHttp(context.Background(), addr, 1, opt)
+	require.NoError(t, err)
+	defer conn.close()
+	var relay NativeRelay = &stdDriver{opt: opt, conn: conn, debugf: func(string, ...interface{}) {}}
+
+	var exported bytes.Buffer
+	n, err := relay.ExportNative(context.Background(), &exported, "SELECT n, s FROM t")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(native.Buf)), n)
+	assert.Equal(t, native.Buf, exported.Bytes())
+
+	require.NoError(t, relay.ImportNative(context.Background(), "copy", bytes.NewReader(exported.Bytes())))
+	assert.Equal(t, native.Buf, imported)
+}
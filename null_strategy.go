@@ -0,0 +1,130 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+)
+
+// NullStrategy selects what a batch does with nil appended to a column that is not Nullable.
+type NullStrategy uint8
+
+const (
+	// NullAsError rejects nil for non-Nullable columns. If input_format_null_as_default is enabled
+	// in the settings the column default is used instead, as with NullAsDefault.
+	NullAsError NullStrategy = iota
+	// NullAsZero replaces nil with the zero value of the column type: 0, "", the Unix epoch for dates
+	// or an empty Array or Map. Enum columns have no zero value and still reject nil.
+	NullAsZero
+	// NullAsDefault sends nil as NULL with input_format_null_as_default enabled, so the server fills
+	// in the column default, including DEFAULT expressions.
+	NullAsDefault
+)
+
+func (s NullStrategy) String() string {
+	switch s {
+	case NullAsError:
+		return "error"
+	case NullAsZero:
+		return "zero"
+	case NullAsDefault:
+		return "default"
+	}
+	return ""
+}
+
+// WithNullStrategy sets how a batch prepared with the context handles nil appended to non-Nullable columns.
+func WithNullStrategy(strategy NullStrategy) QueryOption {
+	return func(o *QueryOptions) error {
+		o.nullStrategy = strategy
+		return nil
+	}
+}
+
+// batchNullStrategy resolves the strategy of a batch from the query options and the settings.
+func batchNullStrategy(conn Settings, options *QueryOptions) NullStrategy {
+	if options.nullStrategy == NullAsError && settingEnabled(conn, options.settings, "input_format_null_as_default", "input_format_defaults_for_omitted_fields") {
+		return NullAsDefault
+	}
+	return options.nullStrategy
+}
+
+// withNullAsDefault returns a copy of settings enabling input_format_null_as_default.
+func withNullAsDefault(settings Settings) Settings {
+	copied := make(Settings, len(settings)+1)
+	for k, v := range settings {
+		copied[k] = v
+	}
+	copied["input_format_null_as_default"] = 1
+	return copied
+}
+
+// applyNullStrategy prepares the row v to be appended to block. Columns receiving nil are promoted to
+// Nullable for NullAsDefault, and nil is replaced by the zero value of the column for NullAsZero, in
+// which case a copy of v is returned.
+func applyNullStrategy(block *proto.Block, strategy NullStrategy, v []interface{}, promoted func(i int)) []interface{} {
+	if strategy == NullAsError {
+		return v
+	}
+	copied := false
+	for i, value := range v {
+		if i >= len(block.Columns) || !isNilValue(value) {
+			continue
+		}
+		switch strategy {
+		case NullAsDefault:
+			if ok, _ := block.PromoteNullable(i); ok && promoted != nil {
+				promoted(i)
+			}
+		case NullAsZero:
+			zero, ok := zeroValue(block.Columns[i])
+			if !ok {
+				continue
+			}
+			if !copied {
+				v, copied = append([]interface{}(nil), v...), true
+			}
+			v[i] = zero
+		}
+	}
+	return v
+}
+
+// zeroValue returns the value appended for nil by NullAsZero, or false if col accepts nil or has no zero value.
+func zeroValue(col column.Interface) (interface{}, bool) {
+	t := string(col.Type())
+	t = strings.TrimSuffix(strings.TrimPrefix(t, "LowCardinality("), ")")
+	switch {
+	case strings.HasPrefix(t, "Nullable("),
+		strings.HasPrefix(t, "Enum"),
+		strings.HasPrefix(t, "Tuple("),
+		strings.HasPrefix(t, "Nested("),
+		strings.HasPrefix(t, "Object("):
+		return nil, false
+	}
+	scanType := col.ScanType()
+	if scanType == reflect.TypeOf(time.Time{}) {
+		return time.Unix(0, 0).UTC(), true
+	}
+	return reflect.Zero(scanType).Interface(), true
+}
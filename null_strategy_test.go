@@ -0,0 +1,68 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyNullStrategy(t *testing.T) {
+	newBlock := func() *proto.Block {
+		block := &proto.Block{}
+		require.NoError(t, block.AddColumn("id", "UInt64"))
+		require.NoError(t, block.AddColumn("name", "LowCardinality(String)"))
+		require.NoError(t, block.AddColumn("created", "DateTime"))
+		require.NoError(t, block.AddColumn("tags", "Array(String)"))
+		require.NoError(t, block.AddColumn("note", "Nullable(String)"))
+		require.NoError(t, block.AddColumn("kind", "Enum8('a' = 1)"))
+		return block
+	}
+	var name *string
+	row := []interface{}{nil, name, nil, nil, nil, nil}
+
+	block := newBlock()
+	assert.Equal(t, row, applyNullStrategy(block, NullAsError, row, nil))
+
+	v := applyNullStrategy(block, NullAsZero, row, nil)
+	assert.Equal(t, []interface{}{uint64(0), "", time.Unix(0, 0).UTC(), []string(nil), nil, nil}, v)
+	assert.Nil(t, row[0], "the row is copied")
+
+	var promoted []int
+	applyNullStrategy(block, NullAsDefault, row, func(i int) {
+		promoted = append(promoted, i)
+	})
+	assert.Equal(t, []int{0, 2, 5}, promoted)
+	assert.Equal(t, "Nullable(UInt64)", string(block.Columns[0].Type()))
+}
+
+func TestBatchNullStrategy(t *testing.T) {
+	assert.Equal(t, NullAsError, batchNullStrategy(nil, &QueryOptions{}))
+	assert.Equal(t, NullAsZero, batchNullStrategy(nil, &QueryOptions{nullStrategy: NullAsZero}))
+	assert.Equal(t, NullAsDefault, batchNullStrategy(Settings{"input_format_null_as_default": 1}, &QueryOptions{}))
+	assert.Equal(t, NullAsZero, batchNullStrategy(Settings{"input_format_null_as_default": 1}, &QueryOptions{nullStrategy: NullAsZero}))
+
+	settings := Settings{"max_threads": 1}
+	enabled := withNullAsDefault(settings)
+	assert.Equal(t, Settings{"max_threads": 1, "input_format_null_as_default": 1}, enabled)
+	assert.Len(t, settings, 1)
+}
@@ -0,0 +1,154 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// OptimizeOptions describes an OPTIMIZE TABLE statement and how to wait for its merges.
+type OptimizeOptions struct {
+	// Table is the table to optimize, optionally qualified with its database.
+	Table string
+	// Partition is a partition expression, e.g. "202301" or "tuple('a', 1)".
+	Partition string
+	// PartitionID selects a partition by its system.parts partition_id instead of Partition.
+	PartitionID string
+	// Final merges all parts of the partitions, even if they are already merged into one part.
+	Final bool
+	// Deduplicate removes duplicate rows, comparing all columns or only DeduplicateBy if set.
+	Deduplicate   bool
+	DeduplicateBy []string
+	// Wait waits, after OPTIMIZE returned, until system.merges has no merges left for the table.
+	Wait bool
+	// PollInterval is how often system.merges is polled. Default 1 second.
+	PollInterval time.Duration
+	// OnProgress is called with the state of the merges of the table on every poll.
+	OnProgress func(MergeProgress)
+}
+
+// MergeProgress is the state of the running merges of a table.
+type MergeProgress struct {
+	// Merges is the number of running merges.
+	Merges uint64
+	// Progress is the average progress of the running merges, from 0 to 1.
+	Progress float64
+	// RowsRead is the number of rows read by the running merges.
+	RowsRead uint64
+	// Elapsed is the time since OptimizeTable was called.
+	Elapsed time.Duration
+}
+
+func (o OptimizeOptions) query() string {
+	var query strings.Builder
+	query.WriteString("OPTIMIZE TABLE ")
+	query.WriteString(o.Table)
+	switch {
+	case len(o.PartitionID) != 0:
+		id, _ := format(nil, Seconds, o.PartitionID)
+		query.WriteString(" PARTITION ID " + id)
+	case len(o.Partition) != 0:
+		query.WriteString(" PARTITION " + o.Partition)
+	}
+	if o.Final {
+		query.WriteString(" FINAL")
+	}
+	if o.Deduplicate || len(o.DeduplicateBy) != 0 {
+		query.WriteString(" DEDUPLICATE")
+		if len(o.DeduplicateBy) != 0 {
+			query.WriteString(" BY " + strings.Join(o.DeduplicateBy, ", "))
+		}
+	}
+	return query.String()
+}
+
+// OptimizeTable runs OPTIMIZE TABLE, reporting the merges of the table while it runs and optionally
+// waiting for them to finish. Polling system.merges uses a second connection of the pool.
+func OptimizeTable(ctx context.Context, conn driver.Conn, opts OptimizeOptions) error {
+	query := opts.query()
+	if !opts.Wait && opts.OnProgress == nil {
+		return conn.Exec(ctx, query)
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	var (
+		start  = time.Now()
+		done   = make(chan error, 1)
+		ticker = time.NewTicker(opts.PollInterval)
+	)
+	defer ticker.Stop()
+	go func() {
+		done <- conn.Exec(ctx, query)
+	}()
+	for running := true; ; {
+		select {
+		case err := <-done:
+			if err != nil {
+				return err
+			}
+			if !opts.Wait {
+				return nil
+			}
+			running, done = false, nil
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		progress, err := tableMerges(ctx, conn, opts.Table)
+		if err != nil {
+			return err
+		}
+		progress.Elapsed = time.Since(start)
+		if opts.OnProgress != nil {
+			opts.OnProgress(progress)
+		}
+		if !running && progress.Merges == 0 {
+			return nil
+		}
+	}
+}
+
+func tableMerges(ctx context.Context, conn driver.Conn, table string) (progress MergeProgress, err error) {
+	database, args := tableArgs(table)
+	err = conn.QueryRow(ctx, `
+		SELECT
+			  count()
+			, ifNotFinite(avg(progress), 0)
+			, sum(rows_read)
+		FROM system.merges WHERE database = `+database+` AND table = @table
+	`, args...).Scan(&progress.Merges, &progress.Progress, &progress.RowsRead)
+	return progress, err
+}
+
+// tableArgs returns the database expression and the named arguments of table, optionally qualified with its
+// database, for queries of system tables with the @database and @table placeholders.
+func tableArgs(table string) (database string, args []interface{}) {
+	database, name := SplitTableName(table)
+	if len(database) != 0 {
+		return "@database", []interface{}{
+			Named("database", database),
+			Named("table", name),
+		}
+	}
+	return "currentDatabase()", []interface{}{Named("table", name)}
+}
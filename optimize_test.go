@@ -0,0 +1,50 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptimizeQuery(t *testing.T) {
+	assert.Equal(t, "OPTIMIZE TABLE events", OptimizeOptions{Table: "events"}.query())
+	assert.Equal(t, "OPTIMIZE TABLE db.events PARTITION 202301 FINAL", OptimizeOptions{
+		Table:     "db.events",
+		Partition: "202301",
+		Final:     true,
+	}.query())
+	assert.Equal(t, "OPTIMIZE TABLE events PARTITION ID 'it\\'s' FINAL DEDUPLICATE BY id, ts", OptimizeOptions{
+		Table:         "events",
+		Partition:     "ignored",
+		PartitionID:   "it's",
+		Final:         true,
+		DeduplicateBy: []string{"id", "ts"},
+	}.query())
+	assert.Equal(t, "OPTIMIZE TABLE events DEDUPLICATE", OptimizeOptions{Table: "events", Deduplicate: true}.query())
+}
+
+func TestTableArgs(t *testing.T) {
+	database, args := tableArgs("`db`.`events.v2`")
+	assert.Equal(t, "@database", database)
+	assert.Equal(t, []interface{}{Named("database", "db"), Named("table", "events.v2")}, args)
+	database, args = tableArgs("events")
+	assert.Equal(t, "currentDatabase()", database)
+	assert.Equal(t, []interface{}{Named("table", "events")}, args)
+}
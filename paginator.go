@@ -0,0 +1,228 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// PaginationMode is how a Paginator moves from one page to the next.
+type PaginationMode uint8
+
+const (
+	// PaginateKeyset continues after the keys of the last row of the previous page. Every page costs the
+	// same, but the keys must identify a row uniquely, otherwise rows sharing the keys at a page boundary are skipped.
+	PaginateKeyset PaginationMode = iota
+	// PaginateOffset uses LIMIT/OFFSET. The server reads and skips all the rows before the page, so later
+	// pages get slower and rows inserted meanwhile shift the pages.
+	PaginateOffset
+)
+
+// Cursor is the position of a keyset Paginator: the key values of the last row returned.
+type Cursor []interface{}
+
+type PaginatorOptions struct {
+	// Keys are the result columns the pages are ordered by.
+	Keys       []string
+	Descending bool
+	PageSize   int // default 1000
+	Mode       PaginationMode
+	// After resumes keyset pagination after a cursor previously returned by Paginator.Cursor.
+	After Cursor
+	// Offset is the first row of PaginateOffset pagination.
+	Offset uint64
+}
+
+// Paginator reads the result of a query page by page, see NewPaginator.
+type Paginator struct {
+	conn      driver.Conn
+	opts      PaginatorOptions
+	query     string
+	args      []interface{}
+	cursor    Cursor
+	offset    uint64
+	done      bool
+	structMap *structMap
+}
+
+// NewPaginator wraps query as a subquery ordered by opts.Keys and limited to opts.PageSize rows per page.
+// The cursor values of PaginateKeyset are bound as query arguments in the placeholder style of args, so
+// queries with server side {name:Type} parameters are only supported by PaginateOffset.
+func NewPaginator(conn driver.Conn, opts PaginatorOptions, query string, args ...interface{}) (*Paginator, error) {
+	if len(opts.Keys) == 0 {
+		return nil, errors.New("clickhouse: paginator requires at least one key")
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = 1000
+	}
+	if opts.After != nil && len(opts.After) != len(opts.Keys) {
+		return nil, fmt.Errorf("clickhouse: paginator cursor has %d values for %d keys", len(opts.After), len(opts.Keys))
+	}
+	return &Paginator{
+		conn:      conn,
+		opts:      opts,
+		query:     strings.TrimRight(strings.TrimSpace(query), ";"),
+		args:      args,
+		cursor:    opts.After,
+		offset:    opts.Offset,
+		structMap: &structMap{},
+	}, nil
+}
+
+// NextPage reads the next page into dest, a pointer to a slice of structs as for Select. It returns false
+// once there are no more rows, dest then being empty.
+func (p *Paginator) NextPage(ctx context.Context, dest interface{}) (bool, error) {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Slice {
+		return false, &OpError{
+			Op:  "NextPage",
+			Err: errors.New("must pass a pointer to a slice to NextPage destination"),
+		}
+	}
+	slice := value.Elem()
+	if p.done {
+		slice.SetLen(0)
+		return false, nil
+	}
+	query, args := p.pageQuery()
+	if err := p.conn.Select(ctx, dest, query, args...); err != nil {
+		return false, err
+	}
+	n := slice.Len()
+	if n < p.opts.PageSize {
+		p.done = true
+	}
+	if n == 0 {
+		return false, nil
+	}
+	switch p.opts.Mode {
+	case PaginateOffset:
+		p.offset += uint64(n)
+	default:
+		last := slice.Index(n - 1)
+		if last.Kind() != reflect.Ptr {
+			last = last.Addr()
+		}
+		opts := queryOptions(ctx)
+		fields, err := p.structMap.MapColumns("NextPage", p.opts.Keys, last.Interface(), true, opts.columnMapping)
+		if err != nil {
+			return false, err
+		}
+		cursor := make(Cursor, len(fields))
+		for i, field := range fields {
+			cursor[i] = reflect.ValueOf(field).Elem().Interface()
+		}
+		p.cursor = cursor
+	}
+	return true, nil
+}
+
+// Cursor returns the position after the last page read, to resume with PaginatorOptions.After.
+// It is nil before the first page and for PaginateOffset.
+func (p *Paginator) Cursor() Cursor {
+	return p.cursor
+}
+
+// Offset returns the number of rows read so far by PaginateOffset, including PaginatorOptions.Offset.
+func (p *Paginator) Offset() uint64 {
+	return p.offset
+}
+
+// Done reports whether the last page was read.
+func (p *Paginator) Done() bool {
+	return p.done
+}
+
+// Count returns the exact number of rows of the query. It executes the whole query.
+func (p *Paginator) Count(ctx context.Context) (uint64, error) {
+	var count uint64
+	if err := p.conn.QueryRow(ctx, "SELECT count() FROM ("+p.query+")", p.args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Estimate returns the number of rows the server expects to read for the query, from EXPLAIN ESTIMATE.
+// It is cheap, but only an upper bound of the result size of queries filtering or aggregating the rows,
+// and zero for queries not reading MergeTree tables.
+func (p *Paginator) Estimate(ctx context.Context) (uint64, error) {
+	result, err := Explain(ctx, p.conn, ExplainEstimate, p.query, p.args...)
+	if err != nil {
+		return 0, err
+	}
+	var rows uint64
+	for _, estimate := range result.Estimates {
+		rows += estimate.Rows
+	}
+	return rows, nil
+}
+
+func (p *Paginator) pageQuery() (string, []interface{}) {
+	var (
+		b     strings.Builder
+		args  = p.args
+		order = "ASC"
+		op    = ">"
+	)
+	if p.opts.Descending {
+		order, op = "DESC", "<"
+	}
+	b.WriteString("SELECT * FROM (")
+	b.WriteString(p.query)
+	b.WriteString(")")
+	if p.opts.Mode == PaginateKeyset && p.cursor != nil {
+		placeholders := make([]string, len(p.cursor))
+		args = make([]interface{}, 0, len(p.args)+len(p.cursor))
+		args = append(args, p.args...)
+		for i, v := range p.cursor {
+			placeholders[i], v = p.placeholder(i, v)
+			args = append(args, v)
+		}
+		fmt.Fprintf(&b, " WHERE (%s) %s (%s)", strings.Join(p.opts.Keys, ", "), op, strings.Join(placeholders, ", "))
+	}
+	keys := make([]string, len(p.opts.Keys))
+	for i, key := range p.opts.Keys {
+		keys[i] = key + " " + order
+	}
+	fmt.Fprintf(&b, " ORDER BY %s LIMIT %d", strings.Join(keys, ", "), p.opts.PageSize)
+	if p.opts.Mode == PaginateOffset && p.offset != 0 {
+		fmt.Fprintf(&b, " OFFSET %d", p.offset)
+	}
+	return b.String(), args
+}
+
+// placeholder returns the placeholder of the i-th cursor value in the style of the query arguments.
+func (p *Paginator) placeholder(i int, v interface{}) (string, interface{}) {
+	for _, arg := range p.args {
+		switch arg.(type) {
+		case driver.NamedValue, driver.NamedDateValue:
+			name := fmt.Sprintf("paginator_key_%d", i)
+			return "@" + name, Named(name, v)
+		}
+	}
+	if len(bindTemplates.get(p.query).numeric) != 0 {
+		return fmt.Sprintf("$%d", len(p.args)+i+1), v
+	}
+	return "?", v
+}
@@ -0,0 +1,106 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pageRow struct {
+	ID   uint64 `ch:"id"`
+	Name string `ch:"name"`
+}
+
+// pageConn serves the ids 1..rows, after the cursor argument following the query argument.
+type pageConn struct {
+	driver.Conn
+	rows    uint64
+	size    uint64
+	queries []string
+}
+
+func (c *pageConn) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	c.queries = append(c.queries, query)
+	var after uint64
+	if len(args) > 1 {
+		after = args[len(args)-1].(uint64)
+	}
+	page := dest.(*[]pageRow)
+	*page = (*page)[:0]
+	for id := after + 1; id <= c.rows && uint64(len(*page)) < c.size; id++ {
+		*page = append(*page, pageRow{ID: id})
+	}
+	return nil
+}
+
+func TestPaginatorKeyset(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		conn = &pageConn{rows: 5, size: 2}
+	)
+	p, err := NewPaginator(conn, PaginatorOptions{Keys: []string{"id"}, PageSize: 2}, "SELECT id, name FROM t WHERE x = ?;", 1)
+	require.NoError(t, err)
+	var (
+		page []pageRow
+		ids  []uint64
+	)
+	for {
+		ok, err := p.NextPage(ctx, &page)
+		require.NoError(t, err)
+		if !ok {
+			break
+		}
+		for _, row := range page {
+			ids = append(ids, row.ID)
+		}
+	}
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5}, ids)
+	assert.True(t, p.Done())
+	assert.Equal(t, Cursor{uint64(5)}, p.Cursor())
+	require.Len(t, conn.queries, 3)
+	assert.Equal(t, "SELECT * FROM (SELECT id, name FROM t WHERE x = ?) ORDER BY id ASC LIMIT 2", conn.queries[0])
+	assert.Equal(t, "SELECT * FROM (SELECT id, name FROM t WHERE x = ?) WHERE (id) > (?) ORDER BY id ASC LIMIT 2", conn.queries[1])
+}
+
+func TestPaginatorPageQuery(t *testing.T) {
+	p, err := NewPaginator(nil, PaginatorOptions{Keys: []string{"a", "b"}, Descending: true, After: Cursor{1, "x"}}, "SELECT a, b FROM t WHERE c = @c", Named("c", 1))
+	require.NoError(t, err)
+	query, args := p.pageQuery()
+	assert.Equal(t, "SELECT * FROM (SELECT a, b FROM t WHERE c = @c) WHERE (a, b) < (@paginator_key_0, @paginator_key_1) ORDER BY a DESC, b DESC LIMIT 1000", query)
+	assert.Equal(t, []interface{}{Named("c", 1), Named("paginator_key_0", 1), Named("paginator_key_1", "x")}, args)
+
+	p, err = NewPaginator(nil, PaginatorOptions{Keys: []string{"a"}, After: Cursor{1}}, "SELECT a FROM t WHERE c = $1", 1)
+	require.NoError(t, err)
+	query, _ = p.pageQuery()
+	assert.Contains(t, query, "WHERE (a) > ($2)")
+
+	p, err = NewPaginator(nil, PaginatorOptions{Keys: []string{"a"}, Mode: PaginateOffset, Offset: 20, PageSize: 10}, "SELECT a FROM t")
+	require.NoError(t, err)
+	query, _ = p.pageQuery()
+	assert.Equal(t, "SELECT * FROM (SELECT a FROM t) ORDER BY a ASC LIMIT 10 OFFSET 20", query)
+
+	_, err = NewPaginator(nil, PaginatorOptions{}, "SELECT 1")
+	assert.Error(t, err)
+	_, err = NewPaginator(nil, PaginatorOptions{Keys: []string{"a"}, After: Cursor{1, 2}}, "SELECT 1")
+	assert.Error(t, err)
+}
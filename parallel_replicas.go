@@ -0,0 +1,98 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ParallelReplicas reads a query with several replicas of each shard, see WithParallelReplicas.
+type ParallelReplicas struct {
+	Max     uint64 // max_parallel_replicas, zero keeps the server default
+	Cluster string // cluster_for_parallel_replicas, empty keeps the server default
+	// Strict fails the query instead of falling back to a single replica when parallel reading is not
+	// possible (allow_experimental_parallel_reading_from_replicas = 2).
+	Strict bool
+	// Report, if set, collects the replicas which took part in the query.
+	Report *ParallelReplicasReport
+}
+
+// ParallelReplicasReport lists the replicas which took part in a query run with WithParallelReplicas.
+// The replicas are taken from the host names of the profile events and of the server logs, which are only
+// sent with send_logs_level, so it is only filled over the native protocol. It is complete once Exec,
+// Batch.Send or Rows.Close returns and must not be read concurrently with the query.
+type ParallelReplicasReport struct {
+	// Replicas are the host names of the replicas, sorted.
+	Replicas []string
+	// Used is the ParallelReplicasUsedCount profile event, the number of replicas the query was sent to.
+	Used int64
+}
+
+func (r *ParallelReplicasReport) reset() {
+	*r = ParallelReplicasReport{}
+}
+
+func (r *ParallelReplicasReport) addHost(host string) {
+	if len(host) == 0 {
+		return
+	}
+	i := sort.SearchStrings(r.Replicas, host)
+	if i < len(r.Replicas) && r.Replicas[i] == host {
+		return
+	}
+	r.Replicas = append(r.Replicas, "")
+	copy(r.Replicas[i+1:], r.Replicas[i:])
+	r.Replicas[i] = host
+}
+
+func (r *ParallelReplicasReport) addProfileEvents(events []ProfileEvent) {
+	for _, e := range events {
+		r.addHost(e.Hostname)
+		if e.Name == "ParallelReplicasUsedCount" {
+			r.Used += e.Value
+		}
+	}
+}
+
+// WithParallelReplicas sets allow_experimental_parallel_reading_from_replicas, max_parallel_replicas and
+// cluster_for_parallel_replicas for the query.
+func WithParallelReplicas(replicas ParallelReplicas) QueryOption {
+	return func(o *QueryOptions) error {
+		if replicas.Max == 1 {
+			return fmt.Errorf("parallel replicas need Max of at least 2, got 1")
+		}
+		settings := make(Settings, len(o.settings)+3)
+		for k, v := range o.settings {
+			settings[k] = v
+		}
+		mode := 1
+		if replicas.Strict {
+			mode = 2
+		}
+		settings["allow_experimental_parallel_reading_from_replicas"] = mode
+		if replicas.Max != 0 {
+			settings["max_parallel_replicas"] = replicas.Max
+		}
+		if len(replicas.Cluster) != 0 {
+			settings["cluster_for_parallel_replicas"] = replicas.Cluster
+		}
+		o.settings, o.parallelReplicas = settings, replicas.Report
+		return nil
+	}
+}
@@ -0,0 +1,59 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithParallelReplicas(t *testing.T) {
+	report := &ParallelReplicasReport{Used: 7}
+	ctx := Context(context.Background(), WithSettings(Settings{"max_threads": 2}), WithParallelReplicas(ParallelReplicas{
+		Max:     3,
+		Cluster: "default",
+		Strict:  true,
+		Report:  report,
+	}))
+	options := queryOptions(ctx)
+	assert.Equal(t, Settings{
+		"max_threads": 2,
+		"allow_experimental_parallel_reading_from_replicas": 2,
+		"max_parallel_replicas":                             uint64(3),
+		"cluster_for_parallel_replicas":                     "default",
+	}, options.settings)
+
+	on := options.onProcess()
+	assert.Zero(t, report.Used)
+	on.logs([]Log{{Hostname: "replica-2"}, {Hostname: ""}})
+	on.profileEvents([]ProfileEvent{
+		{Hostname: "replica-1", Name: "ParallelReplicasUsedCount", Value: 2},
+		{Hostname: "replica-2", Name: "SelectedRows", Value: 10},
+	})
+	assert.Equal(t, []string{"replica-1", "replica-2"}, report.Replicas)
+	assert.Equal(t, int64(2), report.Used)
+
+	options = queryOptions(Context(context.Background(), WithParallelReplicas(ParallelReplicas{})))
+	assert.Equal(t, Settings{"allow_experimental_parallel_reading_from_replicas": 1}, options.settings)
+
+	err := WithParallelReplicas(ParallelReplicas{Max: 1})(&QueryOptions{})
+	require.Error(t, err)
+}
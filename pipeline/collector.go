@@ -0,0 +1,192 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// CollectorConfig configures a Collector. Config is the template for the per table writers,
+// its Query is ignored and SpillDir, if set, gets a subdirectory per table.
+type CollectorConfig struct {
+	Config
+	// MaxQueuedRows bounds the number of rows held in memory across all tables. Default 100000.
+	MaxQueuedRows int
+}
+
+func (c CollectorConfig) setDefaults() CollectorConfig {
+	if c.MaxQueuedRows <= 0 {
+		c.MaxQueuedRows = 100000
+	}
+	return c
+}
+
+// Collector accumulates single rows written by many goroutines into coalesced batches, one Writer per table.
+// It is a client side replacement for Buffer tables: rows are flushed when a table reaches MaxRows or
+// FlushInterval elapses, and memory is bounded by MaxQueuedRows over all tables.
+//
+//	collector := pipeline.NewCollector(conn, pipeline.CollectorConfig{
+//		Config:        pipeline.Config{MaxRows: 5000, FlushInterval: time.Second, Block: true},
+//		MaxQueuedRows: 50000,
+//	})
+//	defer collector.Close(ctx)
+//	collector.Write(ctx, "events", uint64(1), "click", time.Now())
+type Collector struct {
+	conn    driver.Conn
+	config  CollectorConfig
+	slots   chan struct{}
+	mu      sync.Mutex
+	closed  bool
+	writers map[string]*Writer
+}
+
+func NewCollector(conn driver.Conn, config CollectorConfig) *Collector {
+	config = config.setDefaults()
+	return &Collector{
+		conn:    conn,
+		config:  config,
+		slots:   make(chan struct{}, config.MaxQueuedRows),
+		writers: make(map[string]*Writer),
+	}
+}
+
+// Write enqueues a row for table. When MaxQueuedRows rows are held it waits for space if Config.Block
+// is set and returns ErrQueueFull otherwise.
+func (c *Collector) Write(ctx context.Context, table string, row ...interface{}) error {
+	w, err := c.writer(ctx, table)
+	if err != nil {
+		return err
+	}
+	if !c.config.Block {
+		select {
+		case c.slots <- struct{}{}:
+		default:
+			return ErrQueueFull
+		}
+	} else {
+		select {
+		case c.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err := w.Write(ctx, row...); err != nil {
+		c.release(1)
+		return err
+	}
+	return nil
+}
+
+// Flush sends the rows queued for every table and returns the first error.
+func (c *Collector) Flush(ctx context.Context) error {
+	var first error
+	for _, w := range c.snapshot() {
+		if err := w.Flush(ctx); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Close stops accepting rows and closes every writer, sending what is still queued.
+func (c *Collector) Close(ctx context.Context) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.closed = true
+	c.mu.Unlock()
+	var first error
+	for _, w := range c.snapshot() {
+		if err := w.Close(ctx); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Stats returns the counters of every table written so far.
+func (c *Collector) Stats() map[string]Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := make(map[string]Stats, len(c.writers))
+	for table, w := range c.writers {
+		stats[table] = w.Stats()
+	}
+	return stats
+}
+
+// Queued returns the number of rows held in memory across all tables.
+func (c *Collector) Queued() int {
+	return len(c.slots)
+}
+
+func (c *Collector) writer(ctx context.Context, table string) (*Writer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrClosed
+	}
+	if w, found := c.writers[table]; found {
+		return w, nil
+	}
+	if len(table) == 0 {
+		return nil, fmt.Errorf("%w: table is empty", ErrInvalidConf)
+	}
+	config := c.config.Config
+	config.Query = "INSERT INTO " + table
+	if len(config.SpillDir) != 0 {
+		config.SpillDir = filepath.Join(config.SpillDir, url.PathEscape(table))
+	}
+	w, err := New(ctx, c.conn, config)
+	if err != nil {
+		return nil, err
+	}
+	w.release = c.release
+	c.writers[table] = w
+	return w, nil
+}
+
+func (c *Collector) release(n int) {
+	for i := 0; i < n; i++ {
+		<-c.slots
+	}
+}
+
+func (c *Collector) snapshot() []*Writer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tables := make([]string, 0, len(c.writers))
+	for table := range c.writers {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	writers := make([]*Writer, 0, len(tables))
+	for _, table := range tables {
+		writers = append(writers, c.writers[table])
+	}
+	return writers
+}
@@ -0,0 +1,123 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type collectorConn struct {
+	driver.Conn
+	mu      sync.Mutex
+	batches map[string][][][]interface{}
+	send    chan struct{}
+}
+
+func (c *collectorConn) PrepareBatch(ctx context.Context, query string) (driver.Batch, error) {
+	return &collectorBatch{conn: c, query: query}, nil
+}
+
+type collectorBatch struct {
+	driver.Batch
+	conn  *collectorConn
+	query string
+	rows  [][]interface{}
+}
+
+func (b *collectorBatch) Append(v ...interface{}) error {
+	if len(v) == 0 {
+		return errors.New("empty row")
+	}
+	b.rows = append(b.rows, v)
+	return nil
+}
+
+func (b *collectorBatch) Abort() error { return nil }
+
+func (b *collectorBatch) Send() error {
+	if b.conn.send != nil {
+		<-b.conn.send
+	}
+	b.conn.mu.Lock()
+	defer b.conn.mu.Unlock()
+	b.conn.batches[b.query] = append(b.conn.batches[b.query], b.rows)
+	return nil
+}
+
+func TestCollector(t *testing.T) {
+	conn := &collectorConn{batches: map[string][][][]interface{}{}}
+	collector := NewCollector(conn, CollectorConfig{
+		Config: Config{MaxRows: 100, FlushInterval: time.Hour, Block: true},
+	})
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				table := "a"
+				if j%5 == 0 {
+					table = "b"
+				}
+				require.NoError(t, collector.Write(ctx, table, i, j))
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, collector.Flush(ctx))
+	stats := collector.Stats()
+	assert.Equal(t, Stats{Accepted: 200, Sent: 200, Batches: 2}, stats["a"])
+	assert.Equal(t, Stats{Accepted: 50, Sent: 50, Batches: 1}, stats["b"])
+	assert.Len(t, conn.batches["INSERT INTO a"], 2)
+	assert.Len(t, conn.batches["INSERT INTO b"], 1)
+	assert.Equal(t, 0, collector.Queued())
+	require.NoError(t, collector.Write(ctx, "b"))
+	require.NoError(t, collector.Close(ctx))
+	assert.Equal(t, uint64(1), collector.Stats()["b"].DeadLetters)
+	assert.ErrorIs(t, collector.Write(ctx, "a", 1, 1), ErrClosed)
+}
+
+func TestCollectorMaxQueuedRows(t *testing.T) {
+	conn := &collectorConn{batches: map[string][][][]interface{}{}, send: make(chan struct{})}
+	collector := NewCollector(conn, CollectorConfig{
+		Config:        Config{MaxRows: 2, FlushInterval: time.Hour},
+		MaxQueuedRows: 3,
+	})
+	ctx := context.Background()
+	require.NoError(t, collector.Write(ctx, "a", 1))
+	require.NoError(t, collector.Write(ctx, "a", 2))
+	require.NoError(t, collector.Write(ctx, "b", 3))
+	assert.ErrorIs(t, collector.Write(ctx, "b", 4), ErrQueueFull)
+	assert.Equal(t, 3, collector.Queued())
+	conn.send <- struct{}{}
+	require.Eventually(t, func() bool { return collector.Queued() == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, collector.Write(ctx, "b", 4))
+	close(conn.send)
+	require.NoError(t, collector.Close(ctx))
+	assert.Equal(t, 0, collector.Queued())
+	assert.Equal(t, uint64(2), collector.Stats()["b"].Sent)
+}
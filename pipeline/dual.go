@@ -0,0 +1,402 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/google/uuid"
+)
+
+// Target identifies one side of a DualWriter.
+type Target string
+
+const (
+	Primary   Target = "primary"
+	Secondary Target = "secondary"
+)
+
+type DualConfig struct {
+	// QueueSize is the number of failed batches each target holds for retry. Default 100.
+	QueueSize int
+	// MaxRetries is the number of times a queued batch is retried before it is dropped. Default 10,
+	// a negative value retries until the writer is closed.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, doubled on each attempt up to MaxBackoff. Default 1 second.
+	RetryBackoff time.Duration
+	// MaxBackoff caps the delay between retries. Default 1 minute.
+	MaxBackoff time.Duration
+	// OnDrop receives batches which a target will never receive: the queue was full, the retries
+	// were exhausted, the rows were rejected by the column converters or Close gave up on them.
+	OnDrop func(target Target, query string, rows [][]interface{}, err error)
+}
+
+func (c DualConfig) setDefaults() DualConfig {
+	if c.QueueSize <= 0 {
+		c.QueueSize = 100
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = 10
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = time.Minute
+	}
+	if c.MaxBackoff < c.RetryBackoff {
+		c.MaxBackoff = c.RetryBackoff
+	}
+	return c
+}
+
+// DualWriter mirrors every batch to a primary and a secondary cluster, for migrations where the
+// application writes to the old and the new cluster until the new one has caught up. Each cluster has
+// its own retry queue, so an outage of one side delays neither the other side nor the caller, and
+// every batch carries an insert_deduplication_token so retries of an insert which was in fact
+// committed are dropped by the server.
+//
+//	writer := pipeline.NewDualWriter(oldCluster, newCluster, pipeline.DualConfig{})
+//	defer writer.Close(ctx)
+//	err := writer.Write(ctx, "INSERT INTO events", rows)
+//	drift := writer.Stats().Drift
+type DualWriter struct {
+	config  DualConfig
+	targets [2]*dualTarget
+	mu      sync.RWMutex
+	closed  bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+type dualTarget struct {
+	name   Target
+	conn   driver.Conn
+	stats  *dualCounters
+	wake   chan struct{}
+	mu     sync.Mutex
+	queue  []*dualBatch
+	oldest time.Time
+}
+
+// dualCounters is allocated separately so the 64-bit fields are aligned for atomic access on 32-bit platforms.
+type dualCounters struct {
+	batches, rows, retries, failures, droppedBatches, droppedRows uint64
+}
+
+type dualBatch struct {
+	query    string
+	rows     [][]interface{}
+	token    string
+	queued   time.Time
+	attempts int
+}
+
+// TargetStats is a snapshot of the counters of one side of a DualWriter.
+type TargetStats struct {
+	// Batches and Rows count what the cluster has committed.
+	Batches uint64
+	Rows    uint64
+	// Retries is the number of inserts attempted from the retry queue.
+	Retries uint64
+	// Failures is the number of inserts which returned an error.
+	Failures uint64
+	// QueuedBatches and QueuedRows are waiting in the retry queue, the oldest of them for Lag.
+	QueuedBatches uint64
+	QueuedRows    uint64
+	Lag           time.Duration
+	// DroppedBatches and DroppedRows were passed to OnDrop.
+	DroppedBatches uint64
+	DroppedRows    uint64
+}
+
+// DualStats is a snapshot of the counters of a DualWriter.
+type DualStats struct {
+	Primary   TargetStats
+	Secondary TargetStats
+	// Drift is the number of rows committed by the primary but not (yet) by the secondary.
+	// It is negative if the secondary is ahead.
+	Drift int64
+}
+
+func NewDualWriter(primary, secondary driver.Conn, config DualConfig) *DualWriter {
+	w := &DualWriter{
+		config: config.setDefaults(),
+		stop:   make(chan struct{}),
+	}
+	for i, t := range []struct {
+		name Target
+		conn driver.Conn
+	}{{Primary, primary}, {Secondary, secondary}} {
+		w.targets[i] = &dualTarget{
+			name:  t.name,
+			conn:  t.conn,
+			stats: &dualCounters{},
+			wake:  make(chan struct{}, 1),
+		}
+		w.wg.Add(1)
+		go w.retry(w.targets[i])
+	}
+	return w
+}
+
+// Write inserts rows into both clusters concurrently. A cluster which fails the insert, or which still
+// has batches waiting for retry, gets the batch appended to its retry queue. Write only returns an error
+// if a target could not take the batch at all (full queue, rejected rows); the rows must not be
+// modified afterwards as they are retained until every target has them.
+func (w *DualWriter) Write(ctx context.Context, query string, rows [][]interface{}) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return ErrClosed
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	var (
+		wg   sync.WaitGroup
+		errs [2]error
+	)
+	for i, target := range w.targets {
+		b := &dualBatch{query: query, rows: rows, token: uuid.NewString()}
+		wg.Add(1)
+		go func(i int, target *dualTarget) {
+			defer wg.Done()
+			errs[i] = w.write(ctx, target, b)
+		}(i, target)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *DualWriter) write(ctx context.Context, target *dualTarget, b *dualBatch) error {
+	target.mu.Lock()
+	behind := len(target.queue) != 0
+	target.mu.Unlock()
+	if !behind {
+		err := w.send(ctx, target, b)
+		switch {
+		case err == nil:
+			return nil
+		case isRejected(err):
+			return w.drop(target, b, err)
+		}
+	}
+	return w.enqueue(target, b)
+}
+
+func (w *DualWriter) enqueue(target *dualTarget, b *dualBatch) error {
+	target.mu.Lock()
+	if len(target.queue) >= w.config.QueueSize {
+		target.mu.Unlock()
+		return w.drop(target, b, ErrQueueFull)
+	}
+	b.queued = time.Now()
+	if len(target.queue) == 0 {
+		target.oldest = b.queued
+	}
+	target.queue = append(target.queue, b)
+	target.mu.Unlock()
+	select {
+	case target.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (w *DualWriter) drop(target *dualTarget, b *dualBatch, err error) error {
+	atomic.AddUint64(&target.stats.droppedBatches, 1)
+	atomic.AddUint64(&target.stats.droppedRows, uint64(len(b.rows)))
+	if w.config.OnDrop != nil {
+		w.config.OnDrop(target.name, b.query, b.rows, err)
+	}
+	return fmt.Errorf("%s: %w", target.name, err)
+}
+
+// rejectedError marks rows the column converters refused, retrying them cannot succeed.
+type rejectedError struct {
+	err error
+}
+
+func (e *rejectedError) Error() string { return e.err.Error() }
+func (e *rejectedError) Unwrap() error { return e.err }
+
+func isRejected(err error) bool {
+	_, ok := err.(*rejectedError)
+	return ok
+}
+
+func (w *DualWriter) send(ctx context.Context, target *dualTarget, b *dualBatch) (err error) {
+	defer func() {
+		if err != nil {
+			atomic.AddUint64(&target.stats.failures, 1)
+			return
+		}
+		atomic.AddUint64(&target.stats.batches, 1)
+		atomic.AddUint64(&target.stats.rows, uint64(len(b.rows)))
+	}()
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+		"insert_deduplication_token": b.token,
+	}))
+	batch, err := target.conn.PrepareBatch(ctx, b.query)
+	if err != nil {
+		return err
+	}
+	for _, row := range b.rows {
+		if err := batch.Append(row...); err != nil {
+			batch.Abort()
+			return &rejectedError{err: err}
+		}
+	}
+	return batch.Send()
+}
+
+// retry drains the retry queue of target in order, one batch at a time.
+func (w *DualWriter) retry(target *dualTarget) {
+	defer w.wg.Done()
+	backoff := w.config.RetryBackoff
+	for {
+		target.mu.Lock()
+		var b *dualBatch
+		if len(target.queue) != 0 {
+			b = target.queue[0]
+		}
+		target.mu.Unlock()
+		if b == nil {
+			select {
+			case <-target.wake:
+				continue
+			case <-w.stop:
+				return
+			}
+		}
+		b.attempts++
+		atomic.AddUint64(&target.stats.retries, 1)
+		err := w.send(context.Background(), target, b)
+		exhausted := w.config.MaxRetries >= 0 && b.attempts >= w.config.MaxRetries
+		if err == nil || isRejected(err) || exhausted {
+			target.mu.Lock()
+			if target.queue = target.queue[1:]; len(target.queue) != 0 {
+				target.oldest = target.queue[0].queued
+			}
+			target.mu.Unlock()
+			if err != nil {
+				w.drop(target, b, err)
+			}
+			backoff = w.config.RetryBackoff
+			continue
+		}
+		select {
+		case <-time.After(backoff):
+		case <-w.stop:
+			return
+		}
+		if backoff *= 2; backoff > w.config.MaxBackoff {
+			backoff = w.config.MaxBackoff
+		}
+	}
+}
+
+// Stats returns the current counters of both targets.
+func (w *DualWriter) Stats() DualStats {
+	stats := DualStats{
+		Primary:   w.targets[0].snapshot(),
+		Secondary: w.targets[1].snapshot(),
+	}
+	stats.Drift = int64(stats.Primary.Rows) - int64(stats.Secondary.Rows)
+	return stats
+}
+
+func (t *dualTarget) snapshot() TargetStats {
+	stats := TargetStats{
+		Batches:        atomic.LoadUint64(&t.stats.batches),
+		Rows:           atomic.LoadUint64(&t.stats.rows),
+		Retries:        atomic.LoadUint64(&t.stats.retries),
+		Failures:       atomic.LoadUint64(&t.stats.failures),
+		DroppedBatches: atomic.LoadUint64(&t.stats.droppedBatches),
+		DroppedRows:    atomic.LoadUint64(&t.stats.droppedRows),
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats.QueuedBatches = uint64(len(t.queue))
+	for _, b := range t.queue {
+		stats.QueuedRows += uint64(len(b.rows))
+	}
+	if len(t.queue) != 0 {
+		stats.Lag = time.Since(t.oldest)
+	}
+	return stats
+}
+
+// Close stops accepting batches and waits for both retry queues to drain. If ctx is cancelled first
+// the batches still queued are passed to OnDrop with the context error.
+func (w *DualWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrClosed
+	}
+	w.closed = true
+	w.mu.Unlock()
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for !w.drained() {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			close(w.stop)
+			w.wg.Wait()
+			for _, target := range w.targets {
+				target.mu.Lock()
+				queue := target.queue
+				target.queue = nil
+				target.mu.Unlock()
+				for _, b := range queue {
+					w.drop(target, b, ctx.Err())
+				}
+			}
+			return ctx.Err()
+		}
+	}
+	close(w.stop)
+	w.wg.Wait()
+	return nil
+}
+
+func (w *DualWriter) drained() bool {
+	for _, target := range w.targets {
+		target.mu.Lock()
+		n := len(target.queue)
+		target.mu.Unlock()
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
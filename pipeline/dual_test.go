@@ -0,0 +1,141 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type dualConn struct {
+	driver.Conn
+	mu   sync.Mutex
+	down bool
+	rows int
+}
+
+func (c *dualConn) PrepareBatch(ctx context.Context, query string) (driver.Batch, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.down {
+		return nil, errors.New("connection refused")
+	}
+	return &dualConnBatch{conn: c}, nil
+}
+
+func (c *dualConn) setDown(down bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.down = down
+}
+
+type dualConnBatch struct {
+	driver.Batch
+	conn *dualConn
+	rows int
+}
+
+func (b *dualConnBatch) Append(v ...interface{}) error {
+	if len(v) == 0 {
+		return errors.New("empty row")
+	}
+	b.rows++
+	return nil
+}
+
+func (b *dualConnBatch) Abort() error { return nil }
+
+func (b *dualConnBatch) Send() error {
+	b.conn.mu.Lock()
+	defer b.conn.mu.Unlock()
+	b.conn.rows += b.rows
+	return nil
+}
+
+func TestDualWriter(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		primary   = &dualConn{}
+		secondary = &dualConn{down: true}
+		writer    = NewDualWriter(primary, secondary, DualConfig{
+			RetryBackoff: time.Millisecond,
+			MaxRetries:   -1,
+		})
+		rows = [][]interface{}{{1, "a"}, {2, "b"}}
+	)
+	require.NoError(t, writer.Write(ctx, "INSERT INTO events", rows))
+	require.NoError(t, writer.Write(ctx, "INSERT INTO events", rows[:1]))
+	stats := writer.Stats()
+	assert.Equal(t, uint64(3), stats.Primary.Rows)
+	assert.Equal(t, uint64(2), stats.Secondary.QueuedBatches)
+	assert.Equal(t, uint64(3), stats.Secondary.QueuedRows)
+	assert.Equal(t, int64(3), stats.Drift)
+
+	secondary.setDown(false)
+	require.Eventually(t, func() bool { return writer.Stats().Drift == 0 }, time.Second, time.Millisecond)
+	stats = writer.Stats()
+	assert.Equal(t, uint64(2), stats.Secondary.Batches)
+	assert.Equal(t, uint64(0), stats.Secondary.QueuedBatches)
+	assert.Equal(t, 3, secondary.rows)
+
+	require.NoError(t, writer.Close(ctx))
+	assert.ErrorIs(t, writer.Write(ctx, "INSERT INTO events", rows), ErrClosed)
+}
+
+func TestDualWriterDrop(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		primary   = &dualConn{}
+		secondary = &dualConn{down: true}
+		mu        sync.Mutex
+		dropped   = map[Target][]error{}
+		writer    = NewDualWriter(primary, secondary, DualConfig{
+			QueueSize:    1,
+			RetryBackoff: time.Hour,
+			OnDrop: func(target Target, query string, rows [][]interface{}, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				dropped[target] = append(dropped[target], err)
+			},
+		})
+	)
+	require.NoError(t, writer.Write(ctx, "INSERT INTO events", [][]interface{}{{1}}))
+	require.Eventually(t, func() bool { return writer.Stats().Secondary.Retries == 1 }, time.Second, time.Millisecond)
+	assert.ErrorIs(t, writer.Write(ctx, "INSERT INTO events", [][]interface{}{{2}}), ErrQueueFull)
+	assert.Error(t, writer.Write(ctx, "INSERT INTO events", [][]interface{}{{}}))
+
+	closeCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, writer.Close(closeCtx), context.DeadlineExceeded)
+	stats := writer.Stats()
+	assert.Equal(t, uint64(2), stats.Primary.Batches)
+	assert.Equal(t, uint64(1), stats.Primary.DroppedBatches)
+	assert.Equal(t, uint64(3), stats.Secondary.DroppedBatches)
+	require.Len(t, dropped[Primary], 1)
+	assert.EqualError(t, dropped[Primary][0], "empty row")
+	require.Len(t, dropped[Secondary], 3)
+	assert.ErrorIs(t, dropped[Secondary][0], ErrQueueFull)
+	assert.ErrorIs(t, dropped[Secondary][2], context.DeadlineExceeded)
+}
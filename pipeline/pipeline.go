@@ -0,0 +1,426 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package pipeline provides a buffered, at-least-once ingestion writer on top of a driver.Conn.
+// Rows are collected into batches which are sent when they reach MaxRows or when FlushInterval elapses.
+// Failed sends are retried with the same insert_deduplication_token, rows rejected by the column
+// converters are handed to a dead letter callback, and batches which could not be delivered are
+// optionally spilled to disk and replayed when the next Writer starts.
+package pipeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+var (
+	ErrClosed      = errors.New("clickhouse [pipeline]: writer is closed")
+	ErrQueueFull   = errors.New("clickhouse [pipeline]: queue is full")
+	ErrInvalidConf = errors.New("clickhouse [pipeline]: invalid configuration")
+)
+
+type Config struct {
+	// Query is the INSERT statement used to prepare every batch.
+	Query string
+	// MaxRows sends the current batch once it holds this many rows. Default 10000.
+	MaxRows int
+	// FlushInterval sends a non-empty batch after this duration. Default 1 second.
+	FlushInterval time.Duration
+	// QueueSize is the capacity of the in-memory row queue. Default 4 * MaxRows.
+	QueueSize int
+	// Block makes Write wait for queue space rather than returning ErrQueueFull.
+	Block bool
+	// MaxRetries is the number of times a failed send is retried. Default 3, a negative value disables retries.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, doubled on each attempt. Default 100ms.
+	RetryBackoff time.Duration
+	// Deduplicate attaches an insert_deduplication_token derived from the batch content to every insert
+	// so that retries of an already committed block are dropped by the server.
+	Deduplicate bool
+	// SpillDir, if set, is where undeliverable batches are persisted. They are replayed by New.
+	SpillDir string
+	// OnDeadLetter receives rows that cannot be appended to a batch.
+	OnDeadLetter func(row []interface{}, err error)
+	// OnError receives batches which could not be delivered (after retries and spilling), and the
+	// errors of spill files which cannot be decoded with nil rows. Such files are renamed with a
+	// .corrupt extension instead of being replayed.
+	OnError func(rows [][]interface{}, err error)
+}
+
+func (c Config) setDefaults() Config {
+	if c.MaxRows <= 0 {
+		c.MaxRows = 10000
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = time.Second
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 4 * c.MaxRows
+	}
+	if c.MaxRetries < 0 {
+		c.MaxRetries = 0
+	} else if c.MaxRetries == 0 {
+		c.MaxRetries = 3
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = 100 * time.Millisecond
+	}
+	return c
+}
+
+type Writer struct {
+	conn   driver.Conn
+	config Config
+	queue  chan []interface{}
+	flush  chan chan error
+	done   chan struct{}
+	// ctx is the context of the inserts, cancelled when Close gives up waiting for them
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.RWMutex
+	closed bool
+	spill  *spill
+	stats  *counters
+	// release is called with the number of accepted rows that left the writer (sent, failed or dead lettered).
+	release func(n int)
+}
+
+// Stats is a snapshot of the row counters of a Writer.
+type Stats struct {
+	// Accepted is the number of rows enqueued by Write.
+	Accepted uint64
+	// Queued is the number of accepted rows which are waiting to be sent.
+	Queued uint64
+	// Sent is the number of rows committed by the server.
+	Sent uint64
+	// Batches is the number of batches committed by the server.
+	Batches uint64
+	// Failed is the number of rows in batches which could not be delivered (spilled or passed to OnError).
+	Failed uint64
+	// DeadLetters is the number of rows passed to OnDeadLetter.
+	DeadLetters uint64
+}
+
+// counters is allocated separately so the 64-bit fields are aligned for atomic access on 32-bit platforms.
+type counters struct {
+	accepted, sent, batches, failed, deadLetters uint64
+}
+
+// New starts a Writer. Any batches spilled by a previous Writer using the same SpillDir are replayed first.
+func New(ctx context.Context, conn driver.Conn, config Config) (*Writer, error) {
+	if len(config.Query) == 0 {
+		return nil, fmt.Errorf("%w: query is empty", ErrInvalidConf)
+	}
+	config = config.setDefaults()
+	insertCtx, cancel := context.WithCancel(context.Background())
+	w := &Writer{
+		ctx:    insertCtx,
+		cancel: cancel,
+		conn:   conn,
+		config: config,
+		queue:  make(chan []interface{}, config.QueueSize),
+		flush:  make(chan chan error),
+		done:   make(chan struct{}),
+		stats:  &counters{},
+	}
+	if len(config.SpillDir) != 0 {
+		w.spill = &spill{dir: config.SpillDir}
+		if err := w.replay(ctx); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+	go w.run()
+	return w, nil
+}
+
+// Write enqueues a row. It returns ErrQueueFull if the queue is full and Config.Block is false.
+func (w *Writer) Write(ctx context.Context, row ...interface{}) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return ErrClosed
+	}
+	if !w.config.Block {
+		select {
+		case w.queue <- row:
+			atomic.AddUint64(&w.stats.accepted, 1)
+			return nil
+		default:
+			return ErrQueueFull
+		}
+	}
+	select {
+	case w.queue <- row:
+		atomic.AddUint64(&w.stats.accepted, 1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the current row counters.
+func (w *Writer) Stats() Stats {
+	stats := Stats{
+		Accepted:    atomic.LoadUint64(&w.stats.accepted),
+		Sent:        atomic.LoadUint64(&w.stats.sent),
+		Batches:     atomic.LoadUint64(&w.stats.batches),
+		Failed:      atomic.LoadUint64(&w.stats.failed),
+		DeadLetters: atomic.LoadUint64(&w.stats.deadLetters),
+	}
+	if done := stats.Sent + stats.Failed + stats.DeadLetters; stats.Accepted > done {
+		stats.Queued = stats.Accepted - done
+	}
+	return stats
+}
+
+// Flush sends all rows queued before the call and waits for the result.
+func (w *Writer) Flush(ctx context.Context) error {
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return ErrClosed
+	}
+	result := make(chan error, 1)
+	select {
+	case w.flush <- result:
+	case <-ctx.Done():
+		w.mu.RUnlock()
+		return ctx.Err()
+	}
+	w.mu.RUnlock()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting rows, sends everything still queued and waits until done or ctx is cancelled.
+// If ctx is cancelled first the inserts in flight are cancelled and no more retries are made: the batches
+// still queued are spilled or passed to OnError before Close returns.
+func (w *Writer) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrClosed
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+	select {
+	case <-w.done:
+		w.cancel()
+		return nil
+	case <-ctx.Done():
+		w.cancel()
+		<-w.done
+		return ctx.Err()
+	}
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+	var (
+		rows   = make([][]interface{}, 0, w.config.MaxRows)
+		ticker = time.NewTicker(w.config.FlushInterval)
+	)
+	defer ticker.Stop()
+	send := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		err := w.deliver(rows)
+		rows = make([][]interface{}, 0, w.config.MaxRows)
+		return err
+	}
+	for {
+		select {
+		case row, ok := <-w.queue:
+			if !ok {
+				send()
+				return
+			}
+			if rows = append(rows, row); len(rows) >= w.config.MaxRows {
+				send()
+			}
+		case result := <-w.flush:
+		drain:
+			for {
+				select {
+				case row, ok := <-w.queue:
+					if !ok {
+						break drain
+					}
+					if rows = append(rows, row); len(rows) >= w.config.MaxRows {
+						send()
+					}
+				default:
+					break drain
+				}
+			}
+			result <- send()
+		case <-ticker.C:
+			send()
+		}
+	}
+}
+
+// deliver sends rows, retrying on failure, and spills them to disk if every attempt fails.
+func (w *Writer) deliver(rows [][]interface{}) (err error) {
+	accepted := len(rows)
+	defer func() {
+		switch {
+		case err == nil && len(rows) != 0:
+			atomic.AddUint64(&w.stats.sent, uint64(len(rows)))
+			atomic.AddUint64(&w.stats.batches, 1)
+		case err != nil:
+			atomic.AddUint64(&w.stats.failed, uint64(len(rows)))
+		}
+		atomic.AddUint64(&w.stats.deadLetters, uint64(accepted-len(rows)))
+		if w.release != nil {
+			w.release(accepted)
+		}
+	}()
+	// the token is computed once: rows removed as dead letters never reached the server, and a replay
+	// of the spilled rows must carry the token of the attempts made before
+	var token string
+	if w.config.Deduplicate {
+		token = DeduplicationToken(rows)
+	}
+	backoff := w.config.RetryBackoff
+	for attempt := 0; attempt <= w.config.MaxRetries; attempt++ {
+		if attempt != 0 {
+			select {
+			case <-time.After(backoff):
+			case <-w.ctx.Done():
+			}
+			backoff *= 2
+		}
+		if err = w.ctx.Err(); err != nil {
+			break
+		}
+		if rows, err = w.send(insertContext(w.ctx, token), rows); err == nil {
+			return nil
+		}
+	}
+	if w.spill != nil {
+		if sErr := w.spill.write(spillRecord{Token: token, Rows: rows}); sErr == nil {
+			return err
+		} else {
+			err = fmt.Errorf("%w (spill: %s)", err, sErr)
+		}
+	}
+	if w.config.OnError != nil {
+		w.config.OnError(rows, err)
+	}
+	return err
+}
+
+// send inserts rows as a single batch. Rows which fail to append are removed, passed to
+// OnDeadLetter, and the batch is rebuilt from the remaining rows. The returned slice holds the
+// rows that are still pending.
+func (w *Writer) send(ctx context.Context, rows [][]interface{}) ([][]interface{}, error) {
+prepare:
+	if len(rows) == 0 {
+		return rows, nil
+	}
+	batch, err := w.conn.PrepareBatch(ctx, w.config.Query)
+	if err != nil {
+		return rows, err
+	}
+	for i, row := range rows {
+		if err := batch.Append(row...); err != nil {
+			batch.Abort()
+			if w.config.OnDeadLetter != nil {
+				w.config.OnDeadLetter(row, err)
+			}
+			rows = append(rows[:i:i], rows[i+1:]...)
+			goto prepare
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return rows, err
+	}
+	return rows, nil
+}
+
+func (w *Writer) replay(ctx context.Context) error {
+	files, err := w.spill.list()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		record, err := w.spill.read(file)
+		if err != nil {
+			var corrupt *errCorruptSpill
+			if !errors.As(err, &corrupt) {
+				return err
+			}
+			if err := w.spill.quarantine(file); err != nil {
+				return err
+			}
+			if w.config.OnError != nil {
+				w.config.OnError(nil, corrupt)
+			}
+			continue
+		}
+		if w.config.Deduplicate && len(record.Token) == 0 {
+			record.Token = DeduplicationToken(record.Rows)
+		}
+		if _, err := w.send(insertContext(ctx, record.Token), record.Rows); err != nil {
+			return err
+		}
+		if err := w.spill.remove(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertContext attaches the insert_deduplication_token of a batch, if any, to ctx.
+var insertContext = func(ctx context.Context, token string) context.Context {
+	if len(token) == 0 {
+		return ctx
+	}
+	return clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+		"insert_deduplication_token": token,
+	}))
+}
+
+// DeduplicationToken returns a stable token for the content of rows.
+func DeduplicationToken(rows [][]interface{}) string {
+	h := sha256.New()
+	for _, row := range rows {
+		for _, v := range row {
+			fmt.Fprintf(h, "%v\x00", v)
+		}
+		h.Write([]byte{'\n'})
+	}
+	return strconv.Itoa(len(rows)) + "-" + hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,271 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpill(t *testing.T) {
+	s := &spill{dir: t.TempDir()}
+	rows := [][]interface{}{
+		{uint64(1), "a", []string{"x"}},
+		{uint64(2), "b", []string{"y", "z"}},
+	}
+	require.NoError(t, s.write(spillRecord{Token: "token", Rows: rows}))
+	require.NoError(t, s.write(spillRecord{Rows: rows[:1]}))
+	files, err := s.list()
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	read, err := s.read(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, spillRecord{Token: "token", Rows: rows}, read)
+	require.NoError(t, s.remove(files[0]))
+	files, err = s.list()
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestSpillTypes(t *testing.T) {
+	s := &spill{dir: t.TempDir()}
+	rows := [][]interface{}{{
+		time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		decimal.RequireFromString("12.345"),
+		uuid.MustParse("8a6d3e2c-5ad1-4b7e-9f3e-6a1c2d3e4f50"),
+		big.NewInt(42),
+		map[string]string{"k": "v"},
+		map[string]interface{}{"k": "v"},
+		[]interface{}{uint8(1), "a"},
+	}}
+	require.NoError(t, s.write(spillRecord{Rows: rows}))
+	files, err := s.list()
+	require.NoError(t, err)
+	read, err := s.read(files[0])
+	require.NoError(t, err)
+	require.Len(t, read.Rows, 1)
+	assert.True(t, rows[0][0].(time.Time).Equal(read.Rows[0][0].(time.Time)))
+	assert.True(t, rows[0][1].(decimal.Decimal).Equal(read.Rows[0][1].(decimal.Decimal)))
+	assert.Equal(t, rows[0][2:], read.Rows[0][2:])
+}
+
+// flakyConn fails the first failures sends, rejects rows holding "bad" and records the committed batches.
+type flakyConn struct {
+	driver.Conn
+	mu       sync.Mutex
+	failures int
+	attempts int
+	batches  [][][]interface{}
+}
+
+func (c *flakyConn) PrepareBatch(ctx context.Context, query string) (driver.Batch, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &flakyBatch{conn: c}, nil
+}
+
+type flakyBatch struct {
+	driver.Batch
+	conn *flakyConn
+	rows [][]interface{}
+}
+
+func (b *flakyBatch) Append(v ...interface{}) error {
+	if len(v) != 0 && v[0] == "bad" {
+		return errors.New("bad row")
+	}
+	b.rows = append(b.rows, v)
+	return nil
+}
+
+func (b *flakyBatch) Abort() error { return nil }
+
+func (b *flakyBatch) Send() error {
+	b.conn.mu.Lock()
+	defer b.conn.mu.Unlock()
+	if b.conn.attempts++; b.conn.attempts <= b.conn.failures {
+		return errors.New("connection refused")
+	}
+	b.conn.batches = append(b.conn.batches, b.rows)
+	return nil
+}
+
+// recordTokens records the deduplication tokens of the inserts for the duration of the test.
+func recordTokens(t *testing.T) func() []string {
+	var (
+		mu     sync.Mutex
+		tokens []string
+		orig   = insertContext
+	)
+	insertContext = func(ctx context.Context, token string) context.Context {
+		mu.Lock()
+		defer mu.Unlock()
+		tokens = append(tokens, token)
+		return orig(ctx, token)
+	}
+	t.Cleanup(func() { insertContext = orig })
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), tokens...)
+	}
+}
+
+func TestWriterRetry(t *testing.T) {
+	tokens := recordTokens(t)
+	var (
+		ctx         = context.Background()
+		conn        = &flakyConn{failures: 2}
+		deadLetters [][]interface{}
+	)
+	w, err := New(ctx, conn, Config{
+		Query:         "INSERT INTO t",
+		MaxRows:       10,
+		FlushInterval: time.Hour,
+		RetryBackoff:  time.Millisecond,
+		Deduplicate:   true,
+		OnDeadLetter: func(row []interface{}, err error) {
+			deadLetters = append(deadLetters, row)
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Write(ctx, "a", 1))
+	require.NoError(t, w.Write(ctx, "bad", 2))
+	require.NoError(t, w.Write(ctx, "c", 3))
+	require.NoError(t, w.Flush(ctx))
+	assert.Equal(t, [][][]interface{}{{{"a", 1}, {"c", 3}}}, conn.batches)
+	assert.Equal(t, [][]interface{}{{"bad", 2}}, deadLetters)
+	assert.Equal(t, Stats{Accepted: 3, Sent: 2, Batches: 1, DeadLetters: 1}, w.Stats())
+	// every attempt carries the token of the batch as written, also after the dead letter was removed
+	token := DeduplicationToken([][]interface{}{{"a", 1}, {"bad", 2}, {"c", 3}})
+	assert.Equal(t, []string{token, token, token}, tokens())
+	require.NoError(t, w.Close(ctx))
+}
+
+func TestWriterSpillReplay(t *testing.T) {
+	tokens := recordTokens(t)
+	var (
+		ctx    = context.Background()
+		dir    = t.TempDir()
+		failed [][]interface{}
+		errs   []error
+		config = Config{
+			Query:         "INSERT INTO t",
+			FlushInterval: time.Hour,
+			MaxRetries:    -1,
+			Deduplicate:   true,
+			SpillDir:      dir,
+			OnError: func(rows [][]interface{}, err error) {
+				failed, errs = append(failed, rows...), append(errs, err)
+			},
+		}
+	)
+	w, err := New(ctx, &flakyConn{failures: 1}, config)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(ctx, "a", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+	assert.Error(t, w.Flush(ctx))
+	require.NoError(t, w.Close(ctx))
+	assert.Empty(t, errs)
+	assert.Equal(t, uint64(1), w.Stats().Failed)
+	files, err := filepath.Glob(filepath.Join(dir, "*"+spillExt))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	// a corrupt file is quarantined rather than failing New
+	corrupt := filepath.Join(dir, "0-000000"+spillExt)
+	require.NoError(t, os.WriteFile(corrupt, []byte("not gob"), 0o644))
+
+	conn := &flakyConn{}
+	w, err = New(ctx, conn, config)
+	require.NoError(t, err)
+	require.NoError(t, w.Close(ctx))
+	require.Len(t, conn.batches, 1)
+	assert.Equal(t, "a", conn.batches[0][0][0])
+	recorded := tokens()
+	require.Len(t, recorded, 2)
+	assert.Equal(t, recorded[0], recorded[1], "the replay carries the token of the first attempt")
+	assert.Empty(t, failed)
+	require.Len(t, errs, 1)
+	var spillErr *errCorruptSpill
+	assert.ErrorAs(t, errs[0], &spillErr)
+	files, err = filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{corrupt + corruptExt}, files)
+}
+
+func TestWriterCloseCancelsRetries(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		failed int
+	)
+	w, err := New(ctx, &flakyConn{failures: 1000}, Config{
+		Query:         "INSERT INTO t",
+		FlushInterval: time.Millisecond,
+		MaxRetries:    10,
+		RetryBackoff:  time.Hour,
+		OnError: func(rows [][]interface{}, err error) {
+			failed += len(rows)
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Write(ctx, "a"))
+	require.Eventually(t, func() bool {
+		w.conn.(*flakyConn).mu.Lock()
+		defer w.conn.(*flakyConn).mu.Unlock()
+		return w.conn.(*flakyConn).attempts == 1
+	}, time.Second, time.Millisecond)
+	closeCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.ErrorIs(t, w.Close(closeCtx), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, uint64(1), w.Stats().Failed)
+}
+
+func TestDeduplicationToken(t *testing.T) {
+	a := [][]interface{}{{uint64(1), "a"}, {uint64(2), "b"}}
+	b := [][]interface{}{{uint64(1), "a"}, {uint64(2), "b"}}
+	c := [][]interface{}{{uint64(1), "a"}, {uint64(2), "c"}}
+	assert.Equal(t, DeduplicationToken(a), DeduplicationToken(b))
+	assert.NotEqual(t, DeduplicationToken(a), DeduplicationToken(c))
+}
+
+func TestConfigDefaults(t *testing.T) {
+	c := Config{}.setDefaults()
+	assert.Equal(t, 10000, c.MaxRows)
+	assert.Equal(t, 40000, c.QueueSize)
+	assert.Equal(t, 3, c.MaxRetries)
+}
+
+func TestDeduplicationTokenFor(t *testing.T) {
+	assert.Equal(t, "events-3-100-199", DeduplicationTokenFor("events", 3, 100, 199))
+}
@@ -0,0 +1,161 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// Message identifies the position of a consumed record in a partitioned log such as a Kafka topic.
+type Message struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+}
+
+type SinkConfig struct {
+	// Query is the INSERT statement used for every block.
+	Query string
+	// BlockSize is the number of offsets covered by one insert. Blocks always start at an offset
+	// which is a multiple of BlockSize, so a consumer restarting from any committed offset rebuilds
+	// exactly the same blocks and the server drops the ones it has already seen. Default 10000.
+	BlockSize int64
+	// Commit is called with the next offset to consume once a block has been inserted.
+	Commit func(ctx context.Context, topic string, partition int32, offset int64) error
+}
+
+// ExactlyOnceSink inserts records into a Replicated*MergeTree table with an insert_deduplication_token
+// derived from (topic, partition, offset range). Combined with committing offsets only after an insert
+// succeeds this gives effectively exactly-once delivery.
+type ExactlyOnceSink struct {
+	conn   driver.Conn
+	config SinkConfig
+	mu     sync.Mutex
+	parts  map[sinkPartition]*sinkBlock
+}
+
+type sinkPartition struct {
+	topic     string
+	partition int32
+}
+
+type sinkBlock struct {
+	first, last int64
+	rows        [][]interface{}
+}
+
+func NewExactlyOnceSink(conn driver.Conn, config SinkConfig) (*ExactlyOnceSink, error) {
+	if len(config.Query) == 0 {
+		return nil, fmt.Errorf("%w: query is empty", ErrInvalidConf)
+	}
+	if config.BlockSize <= 0 {
+		config.BlockSize = 10000
+	}
+	return &ExactlyOnceSink{
+		conn:   conn,
+		config: config,
+		parts:  make(map[sinkPartition]*sinkBlock),
+	}, nil
+}
+
+// DeduplicationTokenFor returns the token used for the block covering offsets first to last inclusive.
+func DeduplicationTokenFor(topic string, partition int32, first, last int64) string {
+	return fmt.Sprintf("%s-%d-%d-%d", topic, partition, first, last)
+}
+
+// Add buffers a row for msg. When msg closes an aligned block the block is inserted and committed.
+func (s *ExactlyOnceSink) Add(ctx context.Context, msg Message, row ...interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var (
+		key   = sinkPartition{topic: msg.Topic, partition: msg.Partition}
+		block = s.parts[key]
+		start = msg.Offset - msg.Offset%s.config.BlockSize
+	)
+	if block != nil && msg.Offset <= block.last {
+		// the log was rewound, e.g. redelivered from the committed offset after a failed insert - rebuild the block
+		block = nil
+	}
+	if block != nil && block.first < start {
+		// the log skipped past the end of the previous block (e.g. compaction) - close it as is
+		if err := s.send(ctx, key, block); err != nil {
+			return err
+		}
+		block = nil
+	}
+	if block == nil {
+		block = &sinkBlock{first: msg.Offset}
+		s.parts[key] = block
+	}
+	block.last, block.rows = msg.Offset, append(block.rows, row)
+	if (msg.Offset+1)%s.config.BlockSize == 0 {
+		return s.send(ctx, key, block)
+	}
+	return nil
+}
+
+// Flush inserts and commits every partially filled block. Partial blocks are only replayed with
+// the same token if the consumer restarts before more records arrive, so Flush is intended for
+// shutdown and partition revocation.
+func (s *ExactlyOnceSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, block := range s.parts {
+		if err := s.send(ctx, key, block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Revoke drops the buffered rows of a partition without inserting them.
+func (s *ExactlyOnceSink) Revoke(topic string, partition int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.parts, sinkPartition{topic: topic, partition: partition})
+}
+
+func (s *ExactlyOnceSink) send(ctx context.Context, key sinkPartition, block *sinkBlock) error {
+	insertCtx := clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+		"insert_deduplication_token": DeduplicationTokenFor(key.topic, key.partition, block.first, block.last),
+		"max_insert_block_size":      len(block.rows),
+	}))
+	batch, err := s.conn.PrepareBatch(insertCtx, s.config.Query)
+	if err != nil {
+		return err
+	}
+	for _, row := range block.rows {
+		if err := batch.Append(row...); err != nil {
+			batch.Abort()
+			return err
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return err
+	}
+	delete(s.parts, key)
+	if s.config.Commit != nil {
+		return s.config.Commit(ctx, key.topic, key.partition, block.last+1)
+	}
+	return nil
+}
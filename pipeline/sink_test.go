@@ -0,0 +1,82 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExactlyOnceSink(t *testing.T) {
+	type commit struct {
+		offset  int64
+		batches int
+	}
+	var (
+		ctx     = context.Background()
+		conn    = &flakyConn{}
+		commits []commit
+	)
+	sink, err := NewExactlyOnceSink(conn, SinkConfig{
+		Query:     "INSERT INTO t",
+		BlockSize: 3,
+		Commit: func(ctx context.Context, topic string, partition int32, offset int64) error {
+			// the offset is committed only once the block is inserted
+			commits = append(commits, commit{offset: offset, batches: len(conn.batches)})
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	add := func(offset int64) error {
+		return sink.Add(ctx, Message{Topic: "events", Partition: 0, Offset: offset}, offset)
+	}
+	for offset := int64(0); offset < 2; offset++ {
+		require.NoError(t, add(offset))
+	}
+	assert.Empty(t, conn.batches)
+	assert.Empty(t, commits)
+	require.NoError(t, add(2))
+	assert.Equal(t, [][][]interface{}{{{int64(0)}, {int64(1)}, {int64(2)}}}, conn.batches)
+	assert.Equal(t, []commit{{offset: 3, batches: 1}}, commits)
+
+	// a failed insert commits nothing, the redelivered records rebuild the same block
+	conn.failures, conn.attempts = 1, 0
+	require.NoError(t, add(3))
+	require.NoError(t, add(4))
+	assert.Error(t, add(5))
+	assert.Equal(t, []commit{{offset: 3, batches: 1}}, commits)
+	for offset := int64(3); offset < 6; offset++ {
+		require.NoError(t, add(offset))
+	}
+	require.Len(t, conn.batches, 2)
+	assert.Equal(t, [][]interface{}{{int64(3)}, {int64(4)}, {int64(5)}}, conn.batches[1])
+	assert.Equal(t, []commit{{offset: 3, batches: 1}, {offset: 6, batches: 2}}, commits)
+
+	// Flush inserts and commits the partial block, Revoke drops it
+	require.NoError(t, add(6))
+	require.NoError(t, sink.Flush(ctx))
+	assert.Equal(t, commit{offset: 7, batches: 3}, commits[len(commits)-1])
+	require.NoError(t, add(7))
+	sink.Revoke("events", 0)
+	require.NoError(t, sink.Flush(ctx))
+	assert.Len(t, conn.batches, 3)
+	assert.Len(t, commits, 3)
+}
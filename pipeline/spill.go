@@ -0,0 +1,138 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pipeline
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func init() {
+	// the values of the rows are interfaces, gob needs the concrete types which are not Go builtins
+	for _, v := range []interface{}{
+		time.Time{},
+		decimal.Decimal{},
+		uuid.UUID{},
+		new(big.Int),
+		net.IP{},
+		[]interface{}{},
+		[]time.Time{},
+		map[string]string{},
+		map[string]interface{}{},
+		map[string]uint64{},
+		map[string]int64{},
+		map[string]float64{},
+	} {
+		gob.Register(v)
+	}
+}
+
+// spill persists undeliverable batches using encoding/gob. Values of types other than the Go
+// builtins and the types registered above must be registered with gob.Register before they can be spilled.
+type spill struct {
+	dir string
+	seq uint64
+}
+
+// spillRecord is a spilled batch. The token is kept so a replay is deduplicated against the attempts
+// which were made before the batch was spilled.
+type spillRecord struct {
+	Token string
+	Rows  [][]interface{}
+}
+
+const (
+	spillExt   = ".chspill"
+	corruptExt = ".corrupt"
+)
+
+func (s *spill) write(record spillRecord) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	var (
+		name = fmt.Sprintf("%d-%06d%s", time.Now().UnixNano(), atomic.AddUint64(&s.seq, 1), spillExt)
+		tmp  = filepath.Join(s.dir, name+".tmp")
+	)
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(record); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, name))
+}
+
+func (s *spill) list() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*"+spillExt))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// errCorruptSpill is returned by read for files which cannot be decoded.
+type errCorruptSpill struct {
+	file string
+	err  error
+}
+
+func (e *errCorruptSpill) Error() string {
+	return fmt.Sprintf("clickhouse [pipeline]: decode spill file %s: %s", e.file, e.err)
+}
+
+func (e *errCorruptSpill) Unwrap() error { return e.err }
+
+func (s *spill) read(file string) (record spillRecord, err error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return record, err
+	}
+	defer f.Close()
+	if err := gob.NewDecoder(f).Decode(&record); err != nil {
+		return record, &errCorruptSpill{file: file, err: err}
+	}
+	return record, nil
+}
+
+func (s *spill) remove(file string) error {
+	return os.Remove(file)
+}
+
+// quarantine renames a file which cannot be decoded so it is no longer replayed but kept for inspection.
+func (s *spill) quarantine(file string) error {
+	return os.Rename(file, file+corruptExt)
+}
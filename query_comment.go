@@ -0,0 +1,59 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"strings"
+)
+
+var commentEscaper = strings.NewReplacer("*/", "* /", "/*", "/ *")
+
+// QueryCommentInterceptor tags queries with the comment returned by fn, e.g. a request ID or the
+// calling service. Query, QueryRow and Exec statements are prefixed with a /* comment */.
+// Inserts are parsed by the client so for PrepareBatch and AsyncInsert the comment is sent
+// as the log_comment setting instead. Both appear in system.query_log.
+// An empty comment leaves the operation unchanged.
+func QueryCommentInterceptor(fn func(ctx context.Context, op *Operation) string) Interceptor {
+	return func(ctx context.Context, op *Operation, next Invoker) error {
+		comment := fn(ctx, op)
+		if len(comment) == 0 {
+			return next(ctx, op)
+		}
+		switch op.Kind {
+		case OperationQuery, OperationQueryRow, OperationExec:
+			op.Query = "/* " + commentEscaper.Replace(comment) + " */ " + op.Query
+		case OperationPrepareBatch, OperationAsyncInsert:
+			ctx = withSetting(ctx, "log_comment", comment)
+		}
+		return next(ctx, op)
+	}
+}
+
+// withSetting returns a context with key added to the query settings already carried by ctx.
+func withSetting(ctx context.Context, key string, value interface{}) context.Context {
+	var (
+		current  = queryOptions(ctx).settings
+		settings = make(Settings, len(current)+1)
+	)
+	for k, v := range current {
+		settings[k] = v
+	}
+	settings[key] = value
+	return Context(ctx, WithSettings(settings))
+}
@@ -0,0 +1,153 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+var ErrQueryLogNotFound = errors.New("clickhouse: query not found in system.query_log")
+
+// QueryLogEntry is the system.query_log entry written when a query finished or failed.
+type QueryLogEntry struct {
+	QueryID       string
+	Type          string // QueryFinish, ExceptionBeforeStart or ExceptionWhileProcessing
+	EventTime     time.Time
+	Duration      time.Duration
+	Query         string
+	ReadRows      uint64
+	ReadBytes     uint64
+	WrittenRows   uint64
+	WrittenBytes  uint64
+	ResultRows    uint64
+	ResultBytes   uint64
+	MemoryUsage   uint64
+	ExceptionCode int32
+	Exception     string
+	StackTrace    string
+}
+
+// Failed reports whether the query ended with an exception.
+func (e *QueryLogEntry) Failed() bool {
+	return e.ExceptionCode != 0
+}
+
+type QueryLogOptions struct {
+	// FlushLogs runs SYSTEM FLUSH LOGS before the first lookup, which requires the SYSTEM FLUSH LOGS grant.
+	FlushLogs bool
+	// Retries is the number of lookups repeated while the entry has not been flushed yet. Default 10.
+	Retries int
+	// RetryInterval is the delay between lookups. Default 500ms.
+	RetryInterval time.Duration
+}
+
+// QueryLog returns the system.query_log entry of a completed query, waiting for the log to be
+// flushed. It returns ErrQueryLogNotFound if the entry did not appear in time.
+func QueryLog(ctx context.Context, conn driver.Conn, queryID string, opts QueryLogOptions) (*QueryLogEntry, error) {
+	if opts.Retries <= 0 {
+		opts.Retries = 10
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = 500 * time.Millisecond
+	}
+	if opts.FlushLogs {
+		if err := conn.Exec(ctx, "SYSTEM FLUSH LOGS"); err != nil {
+			return nil, err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		entry, err := queryLog(ctx, conn, queryID)
+		switch {
+		case err == nil:
+			return entry, nil
+		case !errors.Is(err, ErrQueryLogNotFound):
+			return nil, err
+		case attempt == opts.Retries:
+			return nil, err
+		}
+		select {
+		case <-time.After(opts.RetryInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func queryLog(ctx context.Context, conn driver.Conn, queryID string) (*QueryLogEntry, error) {
+	rows, err := conn.Query(ctx, `
+		SELECT
+			  query_id
+			, toString(type) AS type
+			, event_time
+			, query_duration_ms
+			, query
+			, read_rows
+			, read_bytes
+			, written_rows
+			, written_bytes
+			, result_rows
+			, result_bytes
+			, memory_usage
+			, exception_code
+			, exception
+			, stack_trace
+		FROM system.query_log
+		WHERE query_id = @query_id AND type != 'QueryStart'
+		ORDER BY event_time DESC
+		LIMIT 1
+	`, Named("query_id", queryID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrQueryLogNotFound
+	}
+	var (
+		entry    QueryLogEntry
+		duration uint64
+	)
+	if err := rows.Scan(
+		&entry.QueryID,
+		&entry.Type,
+		&entry.EventTime,
+		&duration,
+		&entry.Query,
+		&entry.ReadRows,
+		&entry.ReadBytes,
+		&entry.WrittenRows,
+		&entry.WrittenBytes,
+		&entry.ResultRows,
+		&entry.ResultBytes,
+		&entry.MemoryUsage,
+		&entry.ExceptionCode,
+		&entry.Exception,
+		&entry.StackTrace,
+	); err != nil {
+		return nil, err
+	}
+	entry.Duration = time.Duration(duration) * time.Millisecond
+	return &entry, nil
+}
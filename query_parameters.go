@@ -40,12 +40,19 @@ func bindQueryOrAppendParameters(paramsProtocolSupport bool, options *QueryOptio
 	// parameter values will be loaded from `args ...interface{}` for compatibility
 	if paramsProtocolSupport &&
 		len(args) > 0 &&
-		hasQueryParamsRe.MatchString(query) {
+		bindTemplates.get(query).queryParams {
 		options.parameters = make(Parameters, len(args))
 		for _, a := range args {
 			if p, ok := a.(driver.NamedValue); ok {
-				if str, ok := p.Value.(string); ok {
-					options.parameters[p.Name] = str
+				switch v := p.Value.(type) {
+				case string:
+					options.parameters[p.Name] = v
+					continue
+				case IdentifierValue:
+					if _, err := v.quote(); err != nil {
+						return "", err
+					}
+					options.parameters[p.Name] = v.Name()
 					continue
 				}
 			}
@@ -0,0 +1,133 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// PipelineOptions configures a Pipeline.
+type PipelineOptions struct {
+	// MaxConcurrency bounds the number of queries of a Pipeline in flight. Zero runs all queries at once.
+	MaxConcurrency int
+}
+
+// Pipeline queues independent small queries, e.g. the panels of a dashboard, and runs them as one burst.
+//
+// The native protocol runs a single query at a time per connection - the server rejects packets other than a cancel
+// while it executes a query - so the queries are not written to one connection ahead of their results. Run executes
+// them concurrently over the connections of conn instead, which for the HTTP protocol are the keep-alive connections
+// of its transport, so a burst takes about as long as its slowest query rather than the sum of the round trips.
+//
+//	p := clickhouse.NewPipeline(conn, clickhouse.PipelineOptions{MaxConcurrency: 4})
+//	p.Select(&users, "SELECT name, age FROM users LIMIT 10")
+//	p.Query("SELECT count() FROM events", func(rows driver.Rows) error { ... })
+//	err := p.Run(ctx)
+type Pipeline struct {
+	conn    driver.Conn
+	opts    PipelineOptions
+	queries []pipelineQuery
+}
+
+type pipelineQuery struct {
+	query string
+	args  []interface{}
+	fn    func(driver.Rows) error
+}
+
+// NewPipeline returns an empty Pipeline running its queries on conn.
+func NewPipeline(conn driver.Conn, opts PipelineOptions) *Pipeline {
+	return &Pipeline{
+		conn: conn,
+		opts: opts,
+	}
+}
+
+// Query queues a query whose rows are passed to fn. fn is called from the goroutine running the query and the rows are
+// closed once it returns.
+func (p *Pipeline) Query(query string, fn func(rows driver.Rows) error, args ...interface{}) {
+	p.queries = append(p.queries, pipelineQuery{
+		query: query,
+		args:  args,
+		fn:    fn,
+	})
+}
+
+// Select queues a query whose rows are scanned into dest, a pointer to a slice of structs, see Conn.Select.
+func (p *Pipeline) Select(dest interface{}, query string, args ...interface{}) {
+	p.Query(query, func(rows driver.Rows) error {
+		return selectRows(dest, func() (driver.Rows, error) {
+			return rows, nil
+		})
+	}, args...)
+}
+
+// Len returns the number of queued queries.
+func (p *Pipeline) Len() int {
+	return len(p.queries)
+}
+
+// Run executes the queued queries and empties the queue. Every query runs even if another one fails,
+// and the error of the first failed query in the order they were queued is returned.
+func (p *Pipeline) Run(ctx context.Context) error {
+	queries := p.queries
+	p.queries = nil
+	concurrency := p.opts.MaxConcurrency
+	if concurrency <= 0 || concurrency > len(queries) {
+		concurrency = len(queries)
+	}
+	var (
+		wg    sync.WaitGroup
+		errs  = make([]error, len(queries))
+		slots = make(chan struct{}, concurrency)
+	)
+	for i := range queries {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			errs[i] = queries[i].run(ctx, p.conn)
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("clickhouse [pipeline]: query %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (q pipelineQuery) run(ctx context.Context, conn driver.Conn) error {
+	rows, err := conn.Query(ctx, q.query, q.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if err := q.fn(rows); err != nil {
+		return err
+	}
+	return rows.Err()
+}
@@ -0,0 +1,108 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pipelineConn struct {
+	driver.Conn
+	mu       sync.Mutex
+	inFlight int
+	max      int
+}
+
+func (c *pipelineConn) Query(ctx context.Context, query string, args ...interface{}) (driver.Rows, error) {
+	if strings.HasPrefix(query, "FAIL") {
+		return nil, errors.New(query)
+	}
+	c.mu.Lock()
+	if c.inFlight++; c.inFlight > c.max {
+		c.max = c.inFlight
+	}
+	c.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	n, _ := strconv.Atoi(query)
+	return &pipelineRows{n: n}, nil
+}
+
+type pipelineRows struct {
+	driver.Rows
+	n, row int
+}
+
+func (r *pipelineRows) Next() bool {
+	r.row++
+	return r.row <= r.n
+}
+
+func (r *pipelineRows) ScanStruct(dest interface{}) error {
+	dest.(*struct{ V int }).V = r.row
+	return nil
+}
+
+func (r *pipelineRows) Close() error { return nil }
+func (r *pipelineRows) Err() error   { return nil }
+
+func TestPipeline(t *testing.T) {
+	conn := &pipelineConn{}
+	p := NewPipeline(conn, PipelineOptions{MaxConcurrency: 2})
+	dest := make([][]struct{ V int }, 5)
+	for i := range dest {
+		p.Select(&dest[i], strconv.Itoa(i))
+	}
+	assert.Equal(t, 5, p.Len())
+	require.NoError(t, p.Run(context.Background()))
+	assert.Equal(t, 0, p.Len())
+	assert.Equal(t, 2, conn.max)
+	for i := range dest {
+		assert.Len(t, dest[i], i)
+	}
+
+	var ran []string
+	var mu sync.Mutex
+	p = NewPipeline(&pipelineConn{}, PipelineOptions{})
+	p.Query("FAIL 1", nil)
+	for _, query := range []string{"1", "2"} {
+		query := query
+		p.Query(query, func(rows driver.Rows) error {
+			mu.Lock()
+			ran = append(ran, query)
+			mu.Unlock()
+			return nil
+		})
+	}
+	p.Query("FAIL 2", nil)
+	err := p.Run(context.Background())
+	assert.EqualError(t, err, "clickhouse [pipeline]: query 0: FAIL 1")
+	assert.ElementsMatch(t, []string{"1", "2"}, ran)
+}
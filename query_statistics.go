@@ -0,0 +1,93 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"fmt"
+	"time"
+)
+
+// Statistics are the server reported totals of a single statement, collected with WithStatistics.
+// They are complete once Exec, Batch.Send or Rows.Close returns and must not be read concurrently
+// with the statement.
+type Statistics struct {
+	ReadRows        uint64
+	ReadBytes       uint64
+	TotalRowsToRead uint64
+	WrittenRows     uint64
+	WrittenBytes    uint64
+	// ResultRows and ResultBytes describe the result set sent to the client (from profile info).
+	ResultRows   uint64
+	ResultBytes  uint64
+	ResultBlocks uint64
+	// Elapsed is the time from sending the statement until the end of stream was received.
+	Elapsed time.Duration
+	// Acquire describes how the pool connection of the statement was acquired. It is not set for database/sql.
+	Acquire AcquireInfo
+}
+
+// AcquireInfo describes the acquisition of a pool connection. A long Wait points to an exhausted
+// pool, which often causes tail latency rather than the server.
+type AcquireInfo struct {
+	// Wait is the time spent waiting for a free connection, including dialing a new one.
+	Wait time.Duration
+	// Addr is the address of the connection, one of Options.Addr or Options.ReadAddr.
+	Addr   string
+	ConnID int
+	// Dialed is set if a new connection was dialed rather than an idle one reused.
+	Dialed bool
+	Err    error
+	// Labels are the labels of the statement, see WithLabel.
+	Labels Labels
+	// Local is set for connections to loopback addresses and unix sockets, and Compression is the compression
+	// of the connection, CompressionNone for local connections with Compression.SkipLocal. Together they tell
+	// the CPU and latency cost of compression apart on local and remote connections.
+	Local       bool
+	Compression CompressionMethod
+}
+
+// reset clears the totals before a statement is sent, keeping Acquire which is set right before.
+func (s *Statistics) reset() {
+	*s = Statistics{Acquire: s.Acquire}
+}
+
+func (s *Statistics) addProgress(p *Progress) {
+	s.ReadRows += p.Rows
+	s.ReadBytes += p.Bytes
+	s.TotalRowsToRead += p.TotalRows
+	s.WrittenRows += p.WroteRows
+	s.WrittenBytes += p.WroteBytes
+}
+
+func (s *Statistics) addProfileInfo(p *ProfileInfo) {
+	s.ResultRows += p.Rows
+	s.ResultBytes += p.Bytes
+	s.ResultBlocks += p.Blocks
+}
+
+func (s *Statistics) String() string {
+	return fmt.Sprintf("read rows=%d, read bytes=%d, written rows=%d, written bytes=%d, result rows=%d, elapsed=%s, acquire wait=%s",
+		s.ReadRows,
+		s.ReadBytes,
+		s.WrittenRows,
+		s.WrittenBytes,
+		s.ResultRows,
+		s.Elapsed,
+		s.Acquire.Wait,
+	)
+}
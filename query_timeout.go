@@ -0,0 +1,104 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"time"
+)
+
+var ErrQueryTimeout = errors.New("clickhouse: query timeout exceeded")
+
+// queryTimeoutGrace is how much longer the client waits than the server max_execution_time, so that
+// the server reports the timeout whenever it is able to.
+const queryTimeoutGrace = time.Second
+
+// QueryTimeoutError is returned when the timeout set by WithQueryTimeout expired. It matches ErrQueryTimeout.
+type QueryTimeoutError struct {
+	Timeout time.Duration
+	// Server is true when the server stopped the query on max_execution_time and false when the client
+	// read deadline expired first, e.g. because the server or the network did not respond.
+	Server bool
+	Err    error
+}
+
+func (e *QueryTimeoutError) Error() string {
+	side := "client"
+	if e.Server {
+		side = "server"
+	}
+	return fmt.Sprintf("clickhouse: query timeout of %s exceeded on the %s: %v", e.Timeout, side, e.Err)
+}
+
+func (e *QueryTimeoutError) Is(target error) bool {
+	return target == ErrQueryTimeout
+}
+
+func (e *QueryTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// WithQueryTimeout limits the execution of the query to timeout. It sets max_execution_time (rounded up
+// to whole seconds) on the server and, over the native protocol, a client deadline slightly later than
+// it. Expiry on either side is reported as a *QueryTimeoutError.
+func WithQueryTimeout(timeout time.Duration) QueryOption {
+	return func(o *QueryOptions) error {
+		if timeout <= 0 {
+			return fmt.Errorf("query timeout must be positive, got %s", timeout)
+		}
+		settings := make(Settings, len(o.settings)+1)
+		for k, v := range o.settings {
+			settings[k] = v
+		}
+		settings["max_execution_time"] = int(math.Ceil(timeout.Seconds()))
+		o.settings, o.queryTimeout = settings, timeout
+		return nil
+	}
+}
+
+// deadline is the earlier of the ctx deadline and the client deadline of the query timeout.
+func (o *QueryOptions) deadline(ctx context.Context) (time.Time, bool) {
+	deadline, ok := ctx.Deadline()
+	if o.queryTimeout > 0 {
+		if timeout := time.Now().Add(o.queryTimeout + queryTimeoutGrace); !ok || timeout.Before(deadline) {
+			return timeout, true
+		}
+	}
+	return deadline, ok
+}
+
+// timeoutError wraps err into a *QueryTimeoutError if it was caused by the query timeout.
+func (o *QueryOptions) timeoutError(ctx context.Context, err error) error {
+	if err == nil || o.queryTimeout == 0 {
+		return err
+	}
+	if errors.Is(err, ErrTimeoutExceeded) {
+		return &QueryTimeoutError{Timeout: o.queryTimeout, Server: true, Err: err}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() && ctx.Err() == nil {
+		if deadline, ok := ctx.Deadline(); !ok || time.Now().Before(deadline) {
+			return &QueryTimeoutError{Timeout: o.queryTimeout, Err: err}
+		}
+	}
+	return err
+}
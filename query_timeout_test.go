@@ -0,0 +1,75 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithQueryTimeout(t *testing.T) {
+	ctx := Context(context.Background(), WithSettings(Settings{"max_threads": 1}), WithQueryTimeout(1500*time.Millisecond))
+	opts := queryOptions(ctx)
+	assert.Equal(t, 2, opts.settings["max_execution_time"])
+	assert.Equal(t, 1, opts.settings["max_threads"])
+
+	deadline, ok := opts.deadline(ctx)
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(2500*time.Millisecond), deadline, 100*time.Millisecond)
+
+	// an earlier ctx deadline wins and does not override max_execution_time
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	opts = queryOptions(ctx)
+	assert.Equal(t, 2, opts.settings["max_execution_time"])
+	deadline, _ = opts.deadline(ctx)
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+
+	var o QueryOptions
+	assert.Error(t, WithQueryTimeout(0)(&o))
+}
+
+func TestQueryTimeoutError(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		opts = queryOptions(Context(ctx, WithQueryTimeout(time.Second)))
+	)
+	err := opts.timeoutError(ctx, &Exception{Code: int32(ErrTimeoutExceeded)})
+	var timeoutErr *QueryTimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.True(t, timeoutErr.Server)
+	assert.ErrorIs(t, err, ErrQueryTimeout)
+	assert.ErrorIs(t, err, ErrTimeoutExceeded)
+
+	err = opts.timeoutError(ctx, fmt.Errorf("read: %w", &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}))
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.False(t, timeoutErr.Server)
+
+	other := errors.New("other")
+	assert.Equal(t, other, opts.timeoutError(ctx, other))
+	plain := queryOptions(ctx)
+	assert.Equal(t, other, plain.timeoutError(ctx, other))
+}
@@ -0,0 +1,95 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrResultLimitExceeded = errors.New("clickhouse: result limit exceeded")
+
+// ResultLimits bounds the result of a query, see WithResultLimits.
+type ResultLimits struct {
+	MaxRows  uint64 // max_result_rows, zero means unlimited
+	MaxBytes uint64 // max_result_bytes (uncompressed), zero means unlimited
+	// KeepPartial sets result_overflow_mode to 'break': instead of failing the whole query the rows up to
+	// the limit are returned and the overflow is reported by Rows.Err once they were read.
+	KeepPartial bool
+}
+
+// ResultLimitError is returned when the result of a query exceeded the limits set by WithResultLimits.
+// It matches ErrResultLimitExceeded.
+type ResultLimitError struct {
+	Limits ResultLimits
+	// Rows is the number of rows returned before the limit was hit.
+	Rows uint64
+	// Err is the server exception, nil when the result was truncated on the client in KeepPartial mode.
+	Err error
+}
+
+func (e *ResultLimitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("clickhouse: result limit of %d rows exceeded, result truncated", e.Limits.MaxRows)
+	}
+	return fmt.Sprintf("clickhouse: result limit exceeded after %d rows: %v", e.Rows, e.Err)
+}
+
+func (e *ResultLimitError) Is(target error) bool {
+	return target == ErrResultLimitExceeded
+}
+
+func (e *ResultLimitError) Unwrap() error {
+	return e.Err
+}
+
+// WithResultLimits sets max_result_rows, max_result_bytes and result_overflow_mode for the query. An
+// overflow is reported as a *ResultLimitError. With KeepPartial the server stops at a block boundary,
+// so the client truncates the rows to MaxRows; an exceeded MaxBytes is not detectable in that mode and
+// simply ends the result early.
+func WithResultLimits(limits ResultLimits) QueryOption {
+	return func(o *QueryOptions) error {
+		if limits.MaxRows == 0 && limits.MaxBytes == 0 {
+			return fmt.Errorf("result limits must set MaxRows or MaxBytes")
+		}
+		settings := make(Settings, len(o.settings)+3)
+		for k, v := range o.settings {
+			settings[k] = v
+		}
+		mode := "throw"
+		if limits.KeepPartial {
+			mode = "break"
+		}
+		settings["max_result_rows"] = limits.MaxRows
+		settings["max_result_bytes"] = limits.MaxBytes
+		settings["result_overflow_mode"] = mode
+		o.settings, o.resultLimits = settings, &limits
+		return nil
+	}
+}
+
+// wrap wraps err into a *ResultLimitError if the server reported a result overflow. It is a no-op on nil limits.
+func (l *ResultLimits) wrap(err error, rows uint64) error {
+	if err == nil || l == nil || errors.Is(err, ErrResultLimitExceeded) {
+		return err
+	}
+	if errors.Is(err, ErrTooManyRowsOrBytes) {
+		return &ResultLimitError{Limits: *l, Rows: rows, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithResultLimits(t *testing.T) {
+	ctx := Context(context.Background(), WithSettings(Settings{"max_threads": 1}), WithResultLimits(ResultLimits{MaxRows: 10, KeepPartial: true}))
+	opts := queryOptions(ctx)
+	assert.Equal(t, uint64(10), opts.settings["max_result_rows"])
+	assert.Equal(t, uint64(0), opts.settings["max_result_bytes"])
+	assert.Equal(t, "break", opts.settings["result_overflow_mode"])
+	assert.Equal(t, 1, opts.settings["max_threads"])
+
+	opts = queryOptions(Context(context.Background(), WithResultLimits(ResultLimits{MaxBytes: 1 << 20})))
+	assert.Equal(t, "throw", opts.settings["result_overflow_mode"])
+
+	var o QueryOptions
+	assert.Error(t, WithResultLimits(ResultLimits{})(&o))
+}
+
+func TestResultLimitError(t *testing.T) {
+	limits := &ResultLimits{MaxRows: 5}
+	err := limits.wrap(&Exception{Code: int32(ErrTooManyRowsOrBytes)}, 3)
+	var limitErr *ResultLimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, uint64(3), limitErr.Rows)
+	assert.ErrorIs(t, err, ErrResultLimitExceeded)
+	assert.ErrorIs(t, err, ErrTooManyRowsOrBytes)
+	// wrapping is idempotent
+	assert.Equal(t, err, limits.wrap(err, 4))
+
+	other := &Exception{Code: int32(ErrSyntax)}
+	assert.Equal(t, error(other), limits.wrap(other, 0))
+	var none *ResultLimits
+	assert.Equal(t, error(other), none.wrap(other, 0))
+}
+
+func testLimitRows(t *testing.T, limits *ResultLimits, blocks ...int) *rows {
+	var (
+		stream = make(chan *proto.Block, len(blocks))
+		errCh  = make(chan error)
+		first  *proto.Block
+	)
+	for i, n := range blocks {
+		block := &proto.Block{}
+		require.NoError(t, block.AddColumn("n", "UInt64"))
+		for j := 0; j < n; j++ {
+			require.NoError(t, block.Append(uint64(j)))
+		}
+		if i == 0 {
+			first = block
+			continue
+		}
+		stream <- block
+	}
+	close(stream)
+	close(errCh)
+	return &rows{block: first, stream: stream, errors: errCh, columns: first.ColumnsNames(), structMap: &structMap{}, limits: limits}
+}
+
+func TestRowsKeepPartial(t *testing.T) {
+	r := testLimitRows(t, &ResultLimits{MaxRows: 5, KeepPartial: true}, 3, 3)
+	var count int
+	for r.Next() {
+		count++
+	}
+	assert.Equal(t, 5, count)
+	var limitErr *ResultLimitError
+	require.ErrorAs(t, r.Err(), &limitErr)
+	assert.Equal(t, uint64(5), limitErr.Rows)
+	assert.ErrorIs(t, r.Err(), ErrResultLimitExceeded)
+	assert.False(t, r.Next())
+
+	// a result within the limit is not an error
+	r = testLimitRows(t, &ResultLimits{MaxRows: 6, KeepPartial: true}, 3, 3)
+	count = 0
+	for r.Next() {
+		count++
+	}
+	assert.Equal(t, 6, count)
+	assert.NoError(t, r.Err())
+}
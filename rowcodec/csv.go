@@ -0,0 +1,146 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rowcodec
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+type CSVOptions struct {
+	// Header writes, or expects, a first record with the column names (CSVWithNames).
+	Header bool
+	// Delimiter is the field delimiter. Default ','.
+	Delimiter rune
+}
+
+func (o CSVOptions) delimiter() rune {
+	if o.Delimiter == 0 {
+		return ','
+	}
+	return o.Delimiter
+}
+
+// WriteCSV writes rows to w as CSV and returns the number of rows written. Composite values are written
+// in the ClickHouse text representation, e.g. "[1,2]" or "{'a':1}", and NULL as \N. rows is not closed.
+func WriteCSV(w io.Writer, rows driver.Rows, opts CSVOptions) (int, error) {
+	var (
+		columns = Columns(rows)
+		types   = make([]*columnType, 0, len(columns))
+		record  = make([]string, len(columns))
+		dest    = scanDest(rows)
+		writer  = csv.NewWriter(w)
+		buf     []byte
+		n       int
+	)
+	writer.Comma = opts.delimiter()
+	for i, c := range columns {
+		types = append(types, parseType(c.Type))
+		record[i] = c.Name
+	}
+	if opts.Header {
+		if err := writer.Write(record); err != nil {
+			return n, err
+		}
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return n, err
+		}
+		for i, v := range dest {
+			var err error
+			if buf, err = appendText(buf[:0], types[i], reflect.ValueOf(v), false); err != nil {
+				return n, fmt.Errorf("column %s: %w", columns[i].Name, err)
+			}
+			record[i] = string(buf)
+		}
+		if err := writer.Write(record); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, err
+	}
+	writer.Flush()
+	return n, writer.Error()
+}
+
+// AppendCSV parses CSV from r and appends every record to batch, whose columns are described by columns.
+// With CSVOptions.Header the first record names the columns, which may be in any order but must all be
+// present. It returns the number of rows appended.
+func AppendCSV(batch driver.Batch, columns []Column, r io.Reader, opts CSVOptions) (int, error) {
+	var (
+		reader = csv.NewReader(r)
+		types  = make([]*columnType, 0, len(columns))
+		order  = make([]int, len(columns))
+		row    = make([]interface{}, len(columns))
+		n      int
+	)
+	reader.Comma = opts.delimiter()
+	reader.FieldsPerRecord = len(columns)
+	reader.ReuseRecord = true
+	for i, c := range columns {
+		types = append(types, parseType(c.Type))
+		order[i] = i
+	}
+	if opts.Header {
+		header, err := reader.Read()
+		if err != nil {
+			return n, err
+		}
+		index := make(map[string]int, len(columns))
+		for i, c := range columns {
+			index[c.Name] = i
+		}
+		for i, name := range header {
+			column, found := index[name]
+			if !found {
+				return n, fmt.Errorf("%w: unknown column %s", ErrInvalidInput, name)
+			}
+			order[i] = column
+			delete(index, name)
+		}
+	}
+	for {
+		record, err := reader.Read()
+		switch {
+		case errors.Is(err, io.EOF):
+			return n, nil
+		case err != nil:
+			return n, err
+		}
+		for i, field := range record {
+			column := order[i]
+			v, err := parseText(types[column], field)
+			if err != nil {
+				return n, fmt.Errorf("row %d column %s: %w", n, columns[column].Name, err)
+			}
+			row[column] = v.Interface()
+		}
+		if err := batch.Append(row...); err != nil {
+			return n, fmt.Errorf("row %d: %w", n, err)
+		}
+		n++
+	}
+}
@@ -0,0 +1,350 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rowcodec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/shopspring/decimal"
+)
+
+type JSONOptions struct {
+	// Quote64BitIntegers writes integers of 64 bits or more as strings, like the server's
+	// output_format_json_quote_64bit_integers setting (enabled by default on the server).
+	Quote64BitIntegers bool
+}
+
+// WriteJSONEachRow writes rows to w as one JSON object per line and returns the number of rows written.
+// Arrays and unnamed Tuples are written as JSON arrays, Maps and named Tuples as objects, dates as strings
+// and NaN or infinite floats as null. rows is not closed.
+func WriteJSONEachRow(w io.Writer, rows driver.Rows, opts JSONOptions) (int, error) {
+	var (
+		columns = Columns(rows)
+		types   = make([]*columnType, 0, len(columns))
+		names   = make([][]byte, 0, len(columns))
+		dest    = scanDest(rows)
+		writer  = bufio.NewWriter(w)
+		buf     []byte
+		n       int
+	)
+	for _, c := range columns {
+		types = append(types, parseType(c.Type))
+		names = append(names, appendJSONString(nil, c.Name))
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return n, err
+		}
+		buf = append(buf[:0], '{')
+		for i, v := range dest {
+			if i != 0 {
+				buf = append(buf, ',')
+			}
+			buf = append(append(buf, names[i]...), ':')
+			var err error
+			if buf, err = appendJSON(buf, types[i], reflect.ValueOf(v), opts); err != nil {
+				return n, fmt.Errorf("column %s: %w", columns[i].Name, err)
+			}
+		}
+		if _, err := writer.Write(append(buf, '}', '\n')); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, err
+	}
+	return n, writer.Flush()
+}
+
+func appendJSON(buf []byte, t *columnType, v reflect.Value, opts JSONOptions) ([]byte, error) {
+	v, ok := indirect(v)
+	if !ok {
+		return append(buf, "null"...), nil
+	}
+	var err error
+	switch t.name {
+	case "Nullable":
+		return appendJSON(buf, t.elems[0], v, opts)
+	case "Array":
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, unexpected(t, v)
+		}
+		buf = append(buf, '[')
+		for i := 0; i < v.Len(); i++ {
+			if i != 0 {
+				buf = append(buf, ',')
+			}
+			if buf, err = appendJSON(buf, t.elems[0], v.Index(i), opts); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, ']'), nil
+	case "Map":
+		if v.Kind() != reflect.Map {
+			return nil, unexpected(t, v)
+		}
+		entries := make([][2][]byte, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := appendText(nil, t.elems[0], iter.Key(), false)
+			if err != nil {
+				return nil, err
+			}
+			value, err := appendJSON(nil, t.elems[1], iter.Value(), opts)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, [2][]byte{key, value})
+		}
+		sort.Slice(entries, func(i, j int) bool { return string(entries[i][0]) < string(entries[j][0]) })
+		buf = append(buf, '{')
+		for i, entry := range entries {
+			if i != 0 {
+				buf = append(buf, ',')
+			}
+			buf = append(append(appendJSONString(buf, string(entry[0])), ':'), entry[1]...)
+		}
+		return append(buf, '}'), nil
+	case "Tuple":
+		elems, err := tupleElems(t, v)
+		if err != nil {
+			return nil, err
+		}
+		open, close := byte('['), byte(']')
+		if t.names != nil {
+			open, close = '{', '}'
+		}
+		buf = append(buf, open)
+		for i, elem := range elems {
+			if i != 0 {
+				buf = append(buf, ',')
+			}
+			if t.names != nil {
+				buf = append(appendJSONString(buf, t.names[i]), ':')
+			}
+			if buf, err = appendJSON(buf, t.elems[i], elem, opts); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, close), nil
+	case "Date", "Date32", "DateTime", "DateTime64":
+		tm, ok := v.Interface().(time.Time)
+		if !ok {
+			return nil, unexpected(t, v)
+		}
+		return appendJSONString(buf, tm.Format(t.layout())), nil
+	}
+	switch {
+	case t.isDecimal():
+		d, ok := v.Interface().(decimal.Decimal)
+		if !ok {
+			return nil, unexpected(t, v)
+		}
+		return append(buf, d.StringFixed(t.scale())...), nil
+	case t.numeric():
+		quote := opts.Quote64BitIntegers && t.wide()
+		if quote {
+			buf = append(buf, '"')
+		}
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			buf = strconv.AppendInt(buf, v.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			buf = strconv.AppendUint(buf, v.Uint(), 10)
+		case reflect.Float32, reflect.Float64:
+			if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+				return append(buf, "null"...), nil
+			}
+			buf = strconv.AppendFloat(buf, v.Float(), 'g', -1, v.Type().Bits())
+		case reflect.Bool:
+			buf = strconv.AppendBool(buf, v.Bool())
+		default:
+			n, ok := v.Interface().(*big.Int)
+			if !ok {
+				return nil, unexpected(t, v)
+			}
+			buf = append(buf, n.String()...)
+		}
+		if quote {
+			buf = append(buf, '"')
+		}
+		return buf, nil
+	}
+	return appendJSONString(buf, toString(v)), nil
+}
+
+func appendJSONString(buf []byte, s string) []byte {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return append(buf, bytes.TrimSuffix(b.Bytes(), []byte{'\n'})...)
+}
+
+// AppendJSONEachRow parses one JSON object per line from r and appends it to batch, whose columns are
+// described by columns. Keys which are not columns are an error, missing keys are appended as NULL or
+// the zero value of the column. Integers and dates may be given as numbers or strings. It returns the
+// number of rows appended.
+func AppendJSONEachRow(batch driver.Batch, columns []Column, r io.Reader) (int, error) {
+	var (
+		decoder = json.NewDecoder(r)
+		types   = make([]*columnType, 0, len(columns))
+		index   = make(map[string]int, len(columns))
+		row     = make([]interface{}, len(columns))
+		n       int
+	)
+	decoder.UseNumber()
+	for i, c := range columns {
+		types = append(types, parseType(c.Type))
+		index[c.Name] = i
+	}
+	for {
+		var object map[string]interface{}
+		switch err := decoder.Decode(&object); {
+		case errors.Is(err, io.EOF):
+			return n, nil
+		case err != nil:
+			return n, fmt.Errorf("row %d: %w", n, err)
+		}
+		for name := range object {
+			if _, found := index[name]; !found {
+				return n, fmt.Errorf("%w: row %d: unknown column %s", ErrInvalidInput, n, name)
+			}
+		}
+		for i, c := range columns {
+			v, err := fromJSON(types[i], object[c.Name])
+			if err != nil {
+				return n, fmt.Errorf("row %d column %s: %w", n, c.Name, err)
+			}
+			row[i] = v.Interface()
+		}
+		if err := batch.Append(row...); err != nil {
+			return n, fmt.Errorf("row %d: %w", n, err)
+		}
+		n++
+	}
+}
+
+// fromJSON converts a value decoded with json.Decoder.UseNumber to the type of the column.
+func fromJSON(t *columnType, value interface{}) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(t.goType()), nil
+	}
+	invalid := func() (reflect.Value, error) {
+		return reflect.Value{}, fmt.Errorf("%w: %v is not a valid %s", ErrInvalidInput, value, t.name)
+	}
+	switch t.name {
+	case "Nullable":
+		elem, err := fromJSON(t.elems[0], value)
+		if err != nil {
+			return elem, err
+		}
+		v := reflect.New(elem.Type())
+		v.Elem().Set(elem)
+		return v, nil
+	case "Array":
+		values, ok := value.([]interface{})
+		if !ok {
+			return invalid()
+		}
+		v := reflect.MakeSlice(t.goType(), 0, len(values))
+		for _, value := range values {
+			elem, err := fromJSON(t.elems[0], value)
+			if err != nil {
+				return elem, err
+			}
+			v = reflect.Append(v, elem)
+		}
+		return v, nil
+	case "Map":
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return invalid()
+		}
+		v := reflect.MakeMapWithSize(t.goType(), len(values))
+		for key, value := range values {
+			k, err := parseScalar(t.elems[0], key)
+			if err != nil {
+				return k, err
+			}
+			elem, err := fromJSON(t.elems[1], value)
+			if err != nil {
+				return elem, err
+			}
+			v.SetMapIndex(k, elem)
+		}
+		return v, nil
+	case "Tuple":
+		var values []interface{}
+		switch value := value.(type) {
+		case []interface{}:
+			values = value
+		case map[string]interface{}:
+			if t.names == nil {
+				return invalid()
+			}
+			for _, name := range t.names {
+				values = append(values, value[name])
+			}
+		}
+		if len(values) != len(t.elems) {
+			return invalid()
+		}
+		elems := make([]interface{}, 0, len(values))
+		for i, value := range values {
+			elem, err := fromJSON(t.elems[i], value)
+			if err != nil {
+				return elem, err
+			}
+			elems = append(elems, elem.Interface())
+		}
+		if t.names != nil {
+			named := make(map[string]interface{}, len(elems))
+			for i, name := range t.names {
+				named[name] = elems[i]
+			}
+			return reflect.ValueOf(named), nil
+		}
+		return reflect.ValueOf(elems), nil
+	}
+	switch value := value.(type) {
+	case json.Number:
+		return parseScalar(t, value.String())
+	case string:
+		return parseScalar(t, value)
+	case bool:
+		if t.name != "Bool" {
+			return invalid()
+		}
+		return reflect.ValueOf(value), nil
+	}
+	return invalid()
+}
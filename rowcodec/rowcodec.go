@@ -0,0 +1,297 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package rowcodec converts query results to and from the CSV and JSONEachRow text formats using the
+// same representation as the ClickHouse server, so that exports can be loaded by clickhouse-client and
+// files produced by the server can be appended to a batch.
+//
+//	rows, err := conn.Query(ctx, "SELECT * FROM events")
+//	...
+//	n, err := rowcodec.WriteCSV(w, rows, rowcodec.CSVOptions{Header: true})
+//
+//	columns, err := rowcodec.TableColumns(ctx, conn, "events")
+//	batch, err := conn.PrepareBatch(ctx, "INSERT INTO events")
+//	n, err := rowcodec.AppendJSONEachRow(batch, columns, r)
+//	err = batch.Send()
+package rowcodec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/shopspring/decimal"
+)
+
+var ErrInvalidInput = errors.New("clickhouse [rowcodec]: invalid input")
+
+// Column is a column name and its ClickHouse type, e.g. "Array(Nullable(String))".
+type Column struct {
+	Name string
+	Type string
+}
+
+// Columns returns the columns of a result.
+func Columns(rows driver.Rows) []Column {
+	columns := make([]Column, 0, len(rows.ColumnTypes()))
+	for _, c := range rows.ColumnTypes() {
+		columns = append(columns, Column{Name: c.Name(), Type: c.DatabaseTypeName()})
+	}
+	return columns
+}
+
+// TableColumns returns the columns of table which are sent by an "INSERT INTO table" batch,
+// i.e. all columns except MATERIALIZED and ALIAS ones.
+func TableColumns(ctx context.Context, conn driver.Conn, table string) ([]Column, error) {
+	described, err := clickhouse.DescribeTable(ctx, conn, table)
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]Column, 0, len(described))
+	for _, c := range described {
+		switch c.DefaultType {
+		case "MATERIALIZED", "ALIAS":
+			continue
+		}
+		columns = append(columns, Column{Name: c.Name, Type: c.Type})
+	}
+	return columns, nil
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	decimalType = reflect.TypeOf(decimal.Decimal{})
+	bigIntType  = reflect.TypeOf(&big.Int{})
+	stringer    = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// columnType is a parsed ClickHouse type. LowCardinality and SimpleAggregateFunction are
+// transparent and are removed while parsing.
+type columnType struct {
+	name   string
+	params []string
+	elems  []*columnType
+	// names of the elements of a named Tuple
+	names []string
+}
+
+func parseType(chType string) *columnType {
+	chType = strings.TrimSpace(chType)
+	open := strings.IndexByte(chType, '(')
+	if open == -1 || !strings.HasSuffix(chType, ")") {
+		return &columnType{name: chType}
+	}
+	t := &columnType{
+		name:   chType[:open],
+		params: split(chType[open+1 : len(chType)-1]),
+	}
+	switch t.name {
+	case "LowCardinality":
+		return parseType(t.params[0])
+	case "SimpleAggregateFunction":
+		return parseType(t.params[len(t.params)-1])
+	case "Nullable", "Array", "Map":
+		for _, param := range t.params {
+			t.elems = append(t.elems, parseType(param))
+		}
+	case "Tuple":
+		for _, param := range t.params {
+			name, elem := "", param
+			if strings.HasPrefix(param, "`") {
+				if end := strings.IndexByte(param[1:], '`'); end != -1 {
+					name, elem = param[1:end+1], param[end+2:]
+				}
+			} else if space := strings.IndexByte(param, ' '); space != -1 {
+				if open := strings.IndexByte(param, '('); open == -1 || space < open {
+					name, elem = param[:space], param[space+1:]
+				}
+			}
+			if len(name) != 0 {
+				t.names = append(t.names, name)
+			}
+			t.elems = append(t.elems, parseType(elem))
+		}
+		if len(t.names) != len(t.elems) {
+			t.names = nil
+		}
+	}
+	return t
+}
+
+// goType is the type of the values built for the column by the parsers.
+func (t *columnType) goType() reflect.Type {
+	switch t.name {
+	case "Nullable":
+		return reflect.PtrTo(t.elems[0].goType())
+	case "Array":
+		return reflect.SliceOf(t.elems[0].goType())
+	case "Map":
+		return reflect.MapOf(t.elems[0].goType(), t.elems[1].goType())
+	case "Tuple":
+		if t.names != nil {
+			return reflect.TypeOf(map[string]interface{}{})
+		}
+		return reflect.TypeOf([]interface{}{})
+	case "Int8":
+		return reflect.TypeOf(int8(0))
+	case "Int16":
+		return reflect.TypeOf(int16(0))
+	case "Int32":
+		return reflect.TypeOf(int32(0))
+	case "Int64":
+		return reflect.TypeOf(int64(0))
+	case "UInt8":
+		return reflect.TypeOf(uint8(0))
+	case "UInt16":
+		return reflect.TypeOf(uint16(0))
+	case "UInt32":
+		return reflect.TypeOf(uint32(0))
+	case "UInt64":
+		return reflect.TypeOf(uint64(0))
+	case "Float32":
+		return reflect.TypeOf(float32(0))
+	case "Float64":
+		return reflect.TypeOf(float64(0))
+	case "Bool":
+		return reflect.TypeOf(false)
+	case "Int128", "Int256", "UInt128", "UInt256":
+		return bigIntType
+	case "Date", "Date32", "DateTime", "DateTime64":
+		return timeType
+	}
+	if t.isDecimal() {
+		return decimalType
+	}
+	return reflect.TypeOf("")
+}
+
+func (t *columnType) isDecimal() bool {
+	return strings.HasPrefix(t.name, "Decimal")
+}
+
+// numeric reports whether values of t are written without quotes inside composite values.
+func (t *columnType) numeric() bool {
+	switch t.name {
+	case "Int8", "Int16", "Int32", "Int64", "Int128", "Int256",
+		"UInt8", "UInt16", "UInt32", "UInt64", "UInt128", "UInt256",
+		"Float32", "Float64", "Bool":
+		return true
+	}
+	return t.isDecimal()
+}
+
+// wide reports whether t is an integer of 64 bits or more, which ClickHouse quotes in JSON by default.
+func (t *columnType) wide() bool {
+	switch t.name {
+	case "Int64", "UInt64", "Int128", "Int256", "UInt128", "UInt256":
+		return true
+	}
+	return false
+}
+
+func (t *columnType) scale() int32 {
+	var param string
+	switch {
+	case t.name == "Decimal" && len(t.params) == 2:
+		param = t.params[1]
+	case t.name != "Decimal" && len(t.params) == 1:
+		param = t.params[0]
+	}
+	scale, _ := strconv.Atoi(param)
+	return int32(scale)
+}
+
+func (t *columnType) location() *time.Location {
+	var param string
+	switch {
+	case t.name == "DateTime" && len(t.params) == 1:
+		param = t.params[0]
+	case t.name == "DateTime64" && len(t.params) == 2:
+		param = t.params[1]
+	}
+	if loc, err := time.LoadLocation(strings.Trim(param, "'")); err == nil && len(param) != 0 {
+		return loc
+	}
+	return time.UTC
+}
+
+func (t *columnType) layout() string {
+	switch t.name {
+	case "Date", "Date32":
+		return "2006-01-02"
+	case "DateTime64":
+		if precision, _ := strconv.Atoi(t.params[0]); precision > 0 {
+			return "2006-01-02 15:04:05." + strings.Repeat("0", precision)
+		}
+	}
+	return "2006-01-02 15:04:05"
+}
+
+// split splits type parameters on the commas which are not nested in parentheses or quotes.
+func split(params string) []string {
+	var (
+		parts  []string
+		depth  int
+		quoted bool
+		start  int
+	)
+	for i := 0; i < len(params); i++ {
+		switch c := params[i]; {
+		case c == '\'' && (i == 0 || params[i-1] != '\\'):
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(params[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(params[start:]))
+}
+
+// scanDest returns pointers to scan a row of rows into.
+func scanDest(rows driver.Rows) []interface{} {
+	dest := make([]interface{}, 0, len(rows.ColumnTypes()))
+	for _, c := range rows.ColumnTypes() {
+		dest = append(dest, reflect.New(c.ScanType()).Interface())
+	}
+	return dest
+}
+
+// indirect dereferences pointers and interfaces. It returns false for nil.
+func indirect(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v, false
+		}
+		if v.Type() == bigIntType {
+			return v, true
+		}
+		v = v.Elem()
+	}
+	return v, v.IsValid()
+}
@@ -0,0 +1,189 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rowcodec
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeColumn struct {
+	name, chType string
+	scanType     reflect.Type
+}
+
+func (c fakeColumn) Name() string             { return c.name }
+func (c fakeColumn) Nullable() bool           { return strings.HasPrefix(c.chType, "Nullable") }
+func (c fakeColumn) ScanType() reflect.Type   { return c.scanType }
+func (c fakeColumn) DatabaseTypeName() string { return c.chType }
+
+type fakeRows struct {
+	driver.Rows
+	columns []driver.ColumnType
+	values  [][]interface{}
+	pos     int
+}
+
+func (r *fakeRows) ColumnTypes() []driver.ColumnType { return r.columns }
+func (r *fakeRows) Err() error                       { return nil }
+func (r *fakeRows) Next() bool {
+	r.pos++
+	return r.pos <= len(r.values)
+}
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	for i, v := range r.values[r.pos-1] {
+		target := reflect.ValueOf(dest[i]).Elem()
+		if v == nil {
+			target.Set(reflect.Zero(target.Type()))
+			continue
+		}
+		target.Set(reflect.ValueOf(v))
+	}
+	return nil
+}
+
+type fakeBatch struct {
+	driver.Batch
+	rows [][]interface{}
+}
+
+func (b *fakeBatch) Append(v ...interface{}) error {
+	b.rows = append(b.rows, append([]interface{}(nil), v...))
+	return nil
+}
+
+var (
+	str  = "x'y"
+	when = time.Date(2023, 1, 2, 3, 4, 5, 123000000, time.UTC)
+)
+
+func testRows() *fakeRows {
+	return &fakeRows{
+		columns: []driver.ColumnType{
+			fakeColumn{"id", "UInt64", reflect.TypeOf(uint64(0))},
+			fakeColumn{"name", "Nullable(String)", reflect.TypeOf((*string)(nil))},
+			fakeColumn{"tags", "Array(Nullable(String))", reflect.TypeOf([]*string{})},
+			fakeColumn{"attrs", "Map(String, Array(UInt8))", reflect.TypeOf(map[string][]uint8{})},
+			fakeColumn{"point", "Tuple(x Float64, label LowCardinality(String))", reflect.TypeOf(map[string]interface{}{})},
+			fakeColumn{"at", "DateTime64(3)", reflect.TypeOf(time.Time{})},
+			fakeColumn{"price", "Decimal(9, 2)", reflect.TypeOf(decimal.Decimal{})},
+		},
+		values: [][]interface{}{
+			{uint64(1), &str, []*string{&str, nil}, map[string][]uint8{"b": {2}, "a": {1}}, map[string]interface{}{"x": 1.5, "label": "p"}, when, decimal.RequireFromString("1.5")},
+			{uint64(2), (*string)(nil), []*string{}, map[string][]uint8{}, map[string]interface{}{"x": 0.0, "label": "a,\"b\""}, when, decimal.RequireFromString("-3")},
+		},
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var out bytes.Buffer
+	n, err := WriteCSV(&out, testRows(), CSVOptions{Header: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, strings.Join([]string{
+		"id,name,tags,attrs,point,at,price",
+		`1,x'y,"['x\'y',NULL]","{'a':[1],'b':[2]}","(1.5,'p')",2023-01-02 03:04:05.123,1.50`,
+		`2,\N,[],{},"(0,'a,""b""')",2023-01-02 03:04:05.123,-3.00`,
+	}, "\n")+"\n", out.String())
+}
+
+func TestWriteJSONEachRow(t *testing.T) {
+	var out bytes.Buffer
+	n, err := WriteJSONEachRow(&out, testRows(), JSONOptions{Quote64BitIntegers: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, strings.Join([]string{
+		`{"id":"1","name":"x'y","tags":["x'y",null],"attrs":{"a":[1],"b":[2]},"point":{"x":1.5,"label":"p"},"at":"2023-01-02 03:04:05.123","price":1.50}`,
+		`{"id":"2","name":null,"tags":[],"attrs":{},"point":{"x":0,"label":"a,\"b\""},"at":"2023-01-02 03:04:05.123","price":-3.00}`,
+	}, "\n")+"\n", out.String())
+}
+
+func TestRoundTrip(t *testing.T) {
+	columns := Columns(testRows())
+	expected := [][]interface{}{
+		{uint64(1), &str, []*string{&str, nil}, map[string][]uint8{"b": {2}, "a": {1}}, map[string]interface{}{"x": 1.5, "label": "p"}, when, decimal.RequireFromString("1.5")},
+		{uint64(2), (*string)(nil), []*string{}, map[string][]uint8{}, map[string]interface{}{"x": 0.0, "label": "a,\"b\""}, when, decimal.RequireFromString("-3")},
+	}
+	assertRows := func(t *testing.T, rows [][]interface{}) {
+		require.Len(t, rows, len(expected))
+		for i, row := range rows {
+			for j, v := range row {
+				if d, ok := v.(decimal.Decimal); ok {
+					assert.True(t, d.Equal(expected[i][j].(decimal.Decimal)))
+					continue
+				}
+				assert.Equal(t, expected[i][j], v, columns[j].Name)
+			}
+		}
+	}
+	t.Run("CSV", func(t *testing.T) {
+		var out bytes.Buffer
+		_, err := WriteCSV(&out, testRows(), CSVOptions{Header: true, Delimiter: '\t'})
+		require.NoError(t, err)
+		batch := &fakeBatch{}
+		n, err := AppendCSV(batch, columns, &out, CSVOptions{Header: true, Delimiter: '\t'})
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assertRows(t, batch.rows)
+	})
+	t.Run("JSONEachRow", func(t *testing.T) {
+		var out bytes.Buffer
+		_, err := WriteJSONEachRow(&out, testRows(), JSONOptions{Quote64BitIntegers: true})
+		require.NoError(t, err)
+		batch := &fakeBatch{}
+		n, err := AppendJSONEachRow(batch, columns, &out)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assertRows(t, batch.rows)
+	})
+}
+
+func TestAppendErrors(t *testing.T) {
+	columns := []Column{{"id", "UInt8"}, {"tags", "Array(String)"}}
+	_, err := AppendCSV(&fakeBatch{}, columns, strings.NewReader("1,['a'\n"), CSVOptions{})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = AppendCSV(&fakeBatch{}, columns, strings.NewReader("256,[]\n"), CSVOptions{})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = AppendCSV(&fakeBatch{}, columns, strings.NewReader("id,other\n1,[]\n"), CSVOptions{Header: true})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = AppendJSONEachRow(&fakeBatch{}, columns, strings.NewReader(`{"id":1,"other":2}`))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	batch := &fakeBatch{}
+	n, err := AppendJSONEachRow(batch, columns, strings.NewReader(`{"id":"7"}`+"\n"+`{"tags":["a"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, [][]interface{}{{uint8(7), []string(nil)}, {uint8(0), []string{"a"}}}, batch.rows)
+}
+
+func TestParseType(t *testing.T) {
+	typ := parseType("Tuple(a Nullable(DateTime64(3, 'UTC')), `b c` Map(String, UInt64))")
+	assert.Equal(t, "Tuple", typ.name)
+	assert.Equal(t, []string{"a", "b c"}, typ.names)
+	assert.Equal(t, "Nullable", typ.elems[0].name)
+	assert.Equal(t, "DateTime64", typ.elems[0].elems[0].name)
+	assert.Equal(t, reflect.TypeOf(map[string]uint64{}), typ.elems[1].goType())
+	assert.Equal(t, "String", parseType("LowCardinality(String)").name)
+}
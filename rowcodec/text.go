@@ -0,0 +1,493 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rowcodec
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// appendText appends the text representation of v. Inside composite values (nested) strings and
+// dates are quoted and escaped and NULL is written as NULL, at the top level they are written as is
+// and NULL is \N.
+func appendText(buf []byte, t *columnType, v reflect.Value, nested bool) ([]byte, error) {
+	v, ok := indirect(v)
+	if !ok {
+		if nested {
+			return append(buf, "NULL"...), nil
+		}
+		return append(buf, `\N`...), nil
+	}
+	switch t.name {
+	case "Nullable":
+		return appendText(buf, t.elems[0], v, nested)
+	case "Array":
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, unexpected(t, v)
+		}
+		buf = append(buf, '[')
+		for i := 0; i < v.Len(); i++ {
+			if i != 0 {
+				buf = append(buf, ',')
+			}
+			var err error
+			if buf, err = appendText(buf, t.elems[0], v.Index(i), true); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, ']'), nil
+	case "Map":
+		if v.Kind() != reflect.Map {
+			return nil, unexpected(t, v)
+		}
+		entries := make([][2][]byte, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := appendText(nil, t.elems[0], iter.Key(), true)
+			if err != nil {
+				return nil, err
+			}
+			value, err := appendText(nil, t.elems[1], iter.Value(), true)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, [2][]byte{key, value})
+		}
+		sort.Slice(entries, func(i, j int) bool { return string(entries[i][0]) < string(entries[j][0]) })
+		buf = append(buf, '{')
+		for i, entry := range entries {
+			if i != 0 {
+				buf = append(buf, ',')
+			}
+			buf = append(append(append(buf, entry[0]...), ':'), entry[1]...)
+		}
+		return append(buf, '}'), nil
+	case "Tuple":
+		elems, err := tupleElems(t, v)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, '(')
+		for i, elem := range elems {
+			if i != 0 {
+				buf = append(buf, ',')
+			}
+			if buf, err = appendText(buf, t.elems[i], elem, true); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, ')'), nil
+	case "Date", "Date32", "DateTime", "DateTime64":
+		tm, ok := v.Interface().(time.Time)
+		if !ok {
+			return nil, unexpected(t, v)
+		}
+		return appendString(buf, tm.Format(t.layout()), nested), nil
+	}
+	switch {
+	case t.isDecimal():
+		d, ok := v.Interface().(decimal.Decimal)
+		if !ok {
+			return nil, unexpected(t, v)
+		}
+		return append(buf, d.StringFixed(t.scale())...), nil
+	case t.numeric():
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return strconv.AppendInt(buf, v.Int(), 10), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return strconv.AppendUint(buf, v.Uint(), 10), nil
+		case reflect.Float32, reflect.Float64:
+			return appendFloat(buf, v.Float(), v.Type().Bits()), nil
+		case reflect.Bool:
+			return strconv.AppendBool(buf, v.Bool()), nil
+		}
+		if n, ok := v.Interface().(*big.Int); ok {
+			return append(buf, n.String()...), nil
+		}
+		return nil, unexpected(t, v)
+	}
+	return appendString(buf, toString(v), nested), nil
+}
+
+func appendFloat(buf []byte, f float64, bits int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(buf, "nan"...)
+	case math.IsInf(f, 1):
+		return append(buf, "inf"...)
+	case math.IsInf(f, -1):
+		return append(buf, "-inf"...)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bits)
+}
+
+func toString(v reflect.Value) string {
+	switch {
+	case v.Kind() == reflect.String:
+		return v.String()
+	case v.Type().Implements(stringer):
+		return v.Interface().(fmt.Stringer).String()
+	case reflect.PtrTo(v.Type()).Implements(stringer) && v.CanAddr():
+		return v.Addr().Interface().(fmt.Stringer).String()
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return string(v.Bytes())
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// appendString appends s, quoted and escaped if nested.
+func appendString(buf []byte, s string, nested bool) []byte {
+	if !nested {
+		return append(buf, s...)
+	}
+	buf = append(buf, '\'')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'', '\\':
+			buf = append(buf, '\\', c)
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case 0:
+			buf = append(buf, '\\', '0')
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return append(buf, '\'')
+}
+
+// tupleElems returns the elements of a Tuple value, which is a slice, a map keyed by the element
+// names or a struct with one field per element.
+func tupleElems(t *columnType, v reflect.Value) ([]reflect.Value, error) {
+	elems := make([]reflect.Value, 0, len(t.elems))
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Len() != len(t.elems) {
+			return nil, unexpected(t, v)
+		}
+		for i := 0; i < v.Len(); i++ {
+			elems = append(elems, v.Index(i))
+		}
+	case reflect.Map:
+		if t.names == nil || v.Type().Key().Kind() != reflect.String {
+			return nil, unexpected(t, v)
+		}
+		for _, name := range t.names {
+			elems = append(elems, v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key())))
+		}
+	case reflect.Struct:
+		if v.NumField() != len(t.elems) {
+			return nil, unexpected(t, v)
+		}
+		for i := 0; i < v.NumField(); i++ {
+			elems = append(elems, v.Field(i))
+		}
+	default:
+		return nil, unexpected(t, v)
+	}
+	return elems, nil
+}
+
+func unexpected(t *columnType, v reflect.Value) error {
+	return fmt.Errorf("%w: cannot represent %s as %s", ErrInvalidInput, v.Type(), t.name)
+}
+
+// parseScalar parses the unquoted text of a non composite value.
+func parseScalar(t *columnType, s string) (reflect.Value, error) {
+	var (
+		typ = t.goType()
+		v   = reflect.New(typ).Elem()
+		err error
+	)
+	switch {
+	case t.name == "Nullable":
+		if s == `\N` || s == "NULL" {
+			return v, nil
+		}
+		elem, err := parseScalar(t.elems[0], s)
+		if err != nil {
+			return v, err
+		}
+		v.Set(reflect.New(typ.Elem()))
+		v.Elem().Set(elem)
+		return v, nil
+	case typ == timeType:
+		var tm time.Time
+		if tm, err = parseTime(t, s); err == nil {
+			v.Set(reflect.ValueOf(tm))
+		}
+	case typ == decimalType:
+		var d decimal.Decimal
+		if d, err = decimal.NewFromString(s); err == nil {
+			v.Set(reflect.ValueOf(d))
+		}
+	case typ == bigIntType:
+		n, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			err = strconv.ErrSyntax
+		}
+		v.Set(reflect.ValueOf(n))
+	default:
+		switch typ.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			var n int64
+			if n, err = strconv.ParseInt(s, 10, typ.Bits()); err == nil {
+				v.SetInt(n)
+			}
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			var n uint64
+			if n, err = strconv.ParseUint(s, 10, typ.Bits()); err == nil {
+				v.SetUint(n)
+			}
+		case reflect.Float32, reflect.Float64:
+			var f float64
+			if f, err = strconv.ParseFloat(s, typ.Bits()); err == nil {
+				v.SetFloat(f)
+			}
+		case reflect.Bool:
+			switch s {
+			case "true", "1":
+				v.SetBool(true)
+			case "false", "0":
+			default:
+				err = strconv.ErrSyntax
+			}
+		case reflect.String:
+			v.SetString(s)
+		}
+	}
+	if err != nil {
+		return v, fmt.Errorf("%w: %q is not a valid %s", ErrInvalidInput, s, t.name)
+	}
+	return v, nil
+}
+
+func parseTime(t *columnType, s string) (time.Time, error) {
+	loc := t.location()
+	for _, layout := range []string{t.layout(), "2006-01-02 15:04:05.999999999", "2006-01-02", time.RFC3339Nano} {
+		if tm, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return tm, nil
+		}
+	}
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0).In(loc), nil
+	}
+	return time.Time{}, strconv.ErrSyntax
+}
+
+// parseText parses the top level text of a value as written by appendText.
+func parseText(t *columnType, s string) (reflect.Value, error) {
+	inner := t
+	if t.name == "Nullable" {
+		if s == `\N` {
+			return reflect.Zero(t.goType()), nil
+		}
+		inner = t.elems[0]
+	}
+	switch inner.name {
+	case "Array", "Map", "Tuple":
+		p := &textParser{s: s}
+		v, err := p.value(t)
+		if err != nil {
+			return v, err
+		}
+		if p.skipSpace(); p.pos != len(p.s) {
+			return v, p.errorf("unexpected %q", p.s[p.pos:])
+		}
+		return v, nil
+	}
+	return parseScalar(t, s)
+}
+
+// textParser parses the representation of composite values, e.g. [1,2] or {'a':(1,NULL)}.
+type textParser struct {
+	s   string
+	pos int
+}
+
+func (p *textParser) value(t *columnType) (reflect.Value, error) {
+	p.skipSpace()
+	switch t.name {
+	case "Nullable":
+		if strings.HasPrefix(p.s[p.pos:], "NULL") {
+			p.pos += len("NULL")
+			return reflect.Zero(t.goType()), nil
+		}
+		elem, err := p.value(t.elems[0])
+		if err != nil {
+			return elem, err
+		}
+		v := reflect.New(elem.Type())
+		v.Elem().Set(elem)
+		return v, nil
+	case "Array":
+		v := reflect.MakeSlice(t.goType(), 0, 0)
+		err := p.list('[', ']', func(int) error {
+			elem, err := p.value(t.elems[0])
+			if err == nil {
+				v = reflect.Append(v, elem)
+			}
+			return err
+		})
+		return v, err
+	case "Map":
+		v := reflect.MakeMap(t.goType())
+		err := p.list('{', '}', func(int) error {
+			key, err := p.value(t.elems[0])
+			if err != nil {
+				return err
+			}
+			if err := p.expect(':'); err != nil {
+				return err
+			}
+			value, err := p.value(t.elems[1])
+			if err != nil {
+				return err
+			}
+			v.SetMapIndex(key, value)
+			return nil
+		})
+		return v, err
+	case "Tuple":
+		elems := make([]interface{}, 0, len(t.elems))
+		err := p.list('(', ')', func(i int) error {
+			if i >= len(t.elems) {
+				return p.errorf("too many tuple elements")
+			}
+			elem, err := p.value(t.elems[i])
+			if err == nil {
+				elems = append(elems, elem.Interface())
+			}
+			return err
+		})
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if len(elems) != len(t.elems) {
+			return reflect.Value{}, p.errorf("expected %d tuple elements", len(t.elems))
+		}
+		if t.names != nil {
+			named := make(map[string]interface{}, len(elems))
+			for i, name := range t.names {
+				named[name] = elems[i]
+			}
+			return reflect.ValueOf(named), nil
+		}
+		return reflect.ValueOf(elems), nil
+	}
+	if p.pos < len(p.s) && p.s[p.pos] == '\'' {
+		s, err := p.quoted()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return parseScalar(t, s)
+	}
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune(",:]})", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	return parseScalar(t, strings.TrimSpace(p.s[start:p.pos]))
+}
+
+// list parses open elem (, elem)* close calling elem for every element.
+func (p *textParser) list(open, close byte, elem func(i int) error) error {
+	if err := p.expect(open); err != nil {
+		return err
+	}
+	if p.skipSpace(); p.pos < len(p.s) && p.s[p.pos] == close {
+		p.pos++
+		return nil
+	}
+	for i := 0; ; i++ {
+		if err := elem(i); err != nil {
+			return err
+		}
+		if p.skipSpace(); p.pos < len(p.s) && p.s[p.pos] == close {
+			p.pos++
+			return nil
+		}
+		if err := p.expect(','); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *textParser) quoted() (string, error) {
+	var b strings.Builder
+	for p.pos++; p.pos < len(p.s); p.pos++ {
+		switch c := p.s[p.pos]; c {
+		case '\'':
+			p.pos++
+			return b.String(), nil
+		case '\\':
+			if p.pos++; p.pos == len(p.s) {
+				return "", p.errorf("unterminated string")
+			}
+			switch c := p.s[p.pos]; c {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '0':
+				b.WriteByte(0)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			default:
+				b.WriteByte(c)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *textParser) expect(c byte) error {
+	if p.skipSpace(); p.pos == len(p.s) || p.s[p.pos] != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *textParser) skipSpace() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *textParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s at offset %d of %q", ErrInvalidInput, fmt.Sprintf(format, args...), p.pos, p.s)
+}
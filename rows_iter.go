@@ -0,0 +1,66 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build go1.23
+
+package clickhouse
+
+import (
+	"iter"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// All returns an iterator over rows for use with range-over-func. Rows are closed when the
+// iteration ends or the loop breaks; check rows.Err() afterwards.
+//
+//	for row := range clickhouse.All(rows) {
+//		row.Scan(&col1, &col2)
+//	}
+func All(rows driver.Rows) iter.Seq[driver.Rows] {
+	return func(yield func(driver.Rows) bool) {
+		defer rows.Close()
+		for rows.Next() {
+			if !yield(rows) {
+				return
+			}
+		}
+	}
+}
+
+// AllStructs returns an iterator which scans every row into a T using ScanStruct. A scan error
+// or the final rows.Err() is yielded with the zero value of T and ends the iteration.
+func AllStructs[T any](rows driver.Rows) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		defer rows.Close()
+		for rows.Next() {
+			var v T
+			if err := rows.ScanStruct(&v); err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build go1.23
+
+package clickhouse
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+type iterTestRows struct {
+	driver.Rows
+	values []uint64
+	row    int
+	err    error
+	// scanErr is returned by ScanStruct for the row holding the value failing, after it was scanned
+	scanErr error
+	failing uint64
+	closed  bool
+}
+
+func (r *iterTestRows) Next() bool {
+	if r.row >= len(r.values) {
+		return false
+	}
+	r.row++
+	return true
+}
+
+func (r *iterTestRows) Scan(dest ...interface{}) error {
+	*dest[0].(*uint64) = r.values[r.row-1]
+	return nil
+}
+
+func (r *iterTestRows) ScanStruct(dest interface{}) error {
+	dest.(*struct{ V uint64 }).V = r.values[r.row-1]
+	if r.scanErr != nil && r.values[r.row-1] == r.failing {
+		return r.scanErr
+	}
+	return nil
+}
+
+func (r *iterTestRows) Err() error { return r.err }
+
+func (r *iterTestRows) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestRowsIter(t *testing.T) {
+	rows := &iterTestRows{values: []uint64{1, 2, 3}}
+	var values []uint64
+	for row := range All(rows) {
+		var v uint64
+		assert.NoError(t, row.Scan(&v))
+		if values = append(values, v); v == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []uint64{1, 2}, values)
+	assert.True(t, rows.closed)
+
+	rows = &iterTestRows{values: []uint64{1, 2, 3}, err: errors.New("stream error")}
+	values = values[:0]
+	var err error
+	for v, e := range AllStructs[struct{ V uint64 }](rows) {
+		if e != nil {
+			err = e
+			break
+		}
+		values = append(values, v.V)
+	}
+	assert.Equal(t, []uint64{1, 2, 3}, values)
+	assert.EqualError(t, err, "stream error")
+	assert.True(t, rows.closed)
+
+	rows = &iterTestRows{values: []uint64{1, 2, 3}, scanErr: errors.New("scan error"), failing: 2}
+	values, err = values[:0], nil
+	for v, e := range AllStructs[struct{ V uint64 }](rows) {
+		if e != nil {
+			assert.Zero(t, v, "the partially scanned value is not yielded")
+			err = e
+			continue
+		}
+		values = append(values, v.V)
+	}
+	assert.Equal(t, []uint64{1}, values)
+	assert.EqualError(t, err, "scan error")
+	assert.True(t, rows.closed)
+}
@@ -0,0 +1,221 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type sampling struct {
+	ratio, offset float64
+}
+
+// WithSampling reads about ratio of the rows of the tables of a read query, starting at offset, for approximate
+// analytics. The tables are read with a SAMPLE ratio OFFSET offset clause, added after each table the query
+// reads unless it samples the table itself. Tables without a sampling key reject the clause; the query is then
+// run again filtering the rows with rand() through the additional_table_filters setting, which is
+// remembered for the tables of the query. Unlike SAMPLE, the filter picks other rows on each run and does
+// not scale aggregates such as count with _sample_factor. Both ratio and offset are in [0, 1]: the statements
+// run with a context set up with other values fail, see Context.
+func WithSampling(ratio, offset float64) QueryOption {
+	return func(o *QueryOptions) error {
+		if !(ratio > 0 && ratio <= 1) || !(offset >= 0 && offset < 1) || ratio+offset > 1 {
+			return fmt.Errorf("invalid sampling ratio %v with offset %v", ratio, offset)
+		}
+		o.sampling = &sampling{ratio: ratio, offset: offset}
+		return nil
+	}
+}
+
+// clause returns the SAMPLE clause of the sampling, with a leading space.
+func (s *sampling) clause() string {
+	clause := " SAMPLE " + strconv.FormatFloat(s.ratio, 'f', -1, 64)
+	if s.offset != 0 {
+		clause += " OFFSET " + strconv.FormatFloat(s.offset, 'f', -1, 64)
+	}
+	return clause
+}
+
+// filter returns the rand() filter of the sampling: rand() is a uniform UInt32, shifted by the offset so
+// that a single comparison selects the range [offset, offset+ratio).
+func (s *sampling) filter() string {
+	const scale = 1 << 32
+	var (
+		from  = uint64(s.offset * scale)
+		width = uint64(math.Round(s.ratio * scale))
+	)
+	if from == 0 {
+		return fmt.Sprintf("rand() < %d", width)
+	}
+	return fmt.Sprintf("(rand() + %d) %% %d < %d", scale-from, uint64(scale), width)
+}
+
+// sampleQuery adds the SAMPLE clause of s after the tables read by query.
+func sampleQuery(query string, s *sampling) string {
+	var (
+		tokens  = lexStatement(query)
+		ctes    = make(map[string]bool)
+		inserts []int
+		calls   []bool
+	)
+	for i := 0; i+2 < len(tokens); i++ {
+		if tokens[i].isName() && tokens[i+1].is("AS") && tokens[i+2].text == "(" {
+			ctes[tokens[i].text] = true
+		}
+	}
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case "(":
+			calls = append(calls, i > 0 && tokens[i-1].isName() && !parenKeywords[strings.ToUpper(tokens[i-1].text)])
+			continue
+		case ")":
+			if len(calls) != 0 {
+				calls = calls[:len(calls)-1]
+			}
+			continue
+		}
+		if !(tokens[i].is("FROM") || tokens[i].is("JOIN")) || (len(calls) != 0 && calls[len(calls)-1]) {
+			continue
+		}
+		for next := i + 1; ; {
+			name, end := tableName(tokens, next, true)
+			if len(name) == 0 {
+				break
+			}
+			// the clause goes after the alias and FINAL: FROM t AS a FINAL SAMPLE 0.1
+			last := end - 1
+			switch {
+			case end+1 < len(tokens) && tokens[end].is("AS") && tokens[end+1].isName():
+				last = end + 1
+			case end < len(tokens) && tokens[end].isName() && !sampleClauseKeywords[strings.ToUpper(tokens[end].text)]:
+				last = end
+			}
+			if last+1 < len(tokens) && tokens[last+1].is("FINAL") {
+				last++
+			}
+			if !ctes[name] && !(last+1 < len(tokens) && tokens[last+1].is("SAMPLE")) {
+				inserts = append(inserts, tokens[last].end)
+			}
+			// FROM a, b
+			end = last + 1
+			if !tokens[i].is("FROM") || end >= len(tokens) || tokens[end].text != "," {
+				break
+			}
+			next = end + 1
+		}
+	}
+	if len(inserts) == 0 {
+		return query
+	}
+	var (
+		clause = s.clause()
+		out    strings.Builder
+		from   int
+	)
+	for _, pos := range inserts {
+		out.WriteString(query[from:pos])
+		out.WriteString(clause)
+		from = pos
+	}
+	out.WriteString(query[from:])
+	return out.String()
+}
+
+// sampleClauseKeywords may follow a table in FROM or JOIN; they are not aliases.
+var sampleClauseKeywords = map[string]bool{
+	"FINAL": true, "SAMPLE": true, "WHERE": true, "PREWHERE": true, "GROUP": true, "ORDER": true, "LIMIT": true,
+	"HAVING": true, "SETTINGS": true, "FORMAT": true, "UNION": true, "EXCEPT": true, "INTERSECT": true,
+	"WINDOW": true, "QUALIFY": true, "JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true,
+	"CROSS": true, "ANY": true, "ALL": true, "ASOF": true, "SEMI": true, "ANTI": true, "GLOBAL": true,
+	"ARRAY": true, "PASTE": true, "ON": true, "USING": true, "INTO": true, "OFFSET": true,
+}
+
+// sampleFilters returns the additional_table_filters setting selecting the rows of s from tables.
+func sampleFilters(tables []string, s *sampling) string {
+	var (
+		filter  = s.filter()
+		entries = make([]string, 0, len(tables))
+	)
+	for _, table := range tables {
+		entries = append(entries, fmt.Sprintf("'%s': '%s'", strings.ReplaceAll(table, "'", "\\'"), filter))
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+// samplingSupport remembers the sets of tables without a sampling key, see WithSampling.
+type samplingSupport struct {
+	mu          sync.Mutex
+	unsupported map[string]bool
+}
+
+func (s *samplingSupport) supported(tables []string) bool {
+	if s == nil {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.unsupported[strings.Join(tables, ",")]
+}
+
+func (s *samplingSupport) markUnsupported(tables []string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.unsupported == nil {
+		s.unsupported = make(map[string]bool)
+	}
+	s.unsupported[strings.Join(tables, ",")] = true
+}
+
+// sample wraps invoker so that it runs the read queries of a context set up with WithSampling sampled.
+func (ch *clickhouse) sample(invoker Invoker) Invoker {
+	return func(ctx context.Context, op *Operation) error {
+		s := queryOptions(ctx).sampling
+		if s == nil || StatementKind(op.Query) != StatementRead {
+			return invoker(ctx, op)
+		}
+		tables := TablesReferenced(op.Query)
+		if len(tables) == 0 {
+			return invoker(ctx, op)
+		}
+		if ch.sampling.supported(tables) {
+			query := op.Query
+			op.Query = sampleQuery(query, s)
+			err := invoker(ctx, op)
+			if !errors.Is(err, ErrSamplingNotSupported) {
+				return err
+			}
+			ch.sampling.markUnsupported(tables)
+			op.Query, op.Rows, op.Row = query, nil, nil
+		}
+		settings := make(Settings, len(queryOptions(ctx).settings)+1)
+		for k, v := range queryOptions(ctx).settings {
+			settings[k] = v
+		}
+		settings["additional_table_filters"] = sampleFilters(tables, s)
+		return invoker(Context(ctx, WithSettings(settings)), op)
+	}
+}
@@ -0,0 +1,94 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleQuery(t *testing.T) {
+	s := &sampling{ratio: 0.1, offset: 0.5}
+	for query, expected := range map[string]string{
+		"SELECT count() FROM hits":                                 "SELECT count() FROM hits SAMPLE 0.1 OFFSET 0.5",
+		"SELECT * FROM db.hits AS h FINAL WHERE x = 1":             "SELECT * FROM db.hits AS h FINAL SAMPLE 0.1 OFFSET 0.5 WHERE x = 1",
+		"SELECT * FROM hits h LEFT JOIN `visits` v ON h.id = v.id": "SELECT * FROM hits h SAMPLE 0.1 OFFSET 0.5 LEFT JOIN `visits` v SAMPLE 0.1 OFFSET 0.5 ON h.id = v.id",
+		"SELECT * FROM a, b":                                       "SELECT * FROM a SAMPLE 0.1 OFFSET 0.5, b SAMPLE 0.1 OFFSET 0.5",
+		"SELECT * FROM hits SAMPLE 0.5":                            "SELECT * FROM hits SAMPLE 0.5",
+		"SELECT * FROM numbers(10)":                                "SELECT * FROM numbers(10)",
+		"WITH t AS (SELECT 1 FROM hits) SELECT * FROM t":           "WITH t AS (SELECT 1 FROM hits SAMPLE 0.1 OFFSET 0.5) SELECT * FROM t",
+		"SELECT * FROM (SELECT * FROM hits) GROUP BY x":            "SELECT * FROM (SELECT * FROM hits SAMPLE 0.1 OFFSET 0.5) GROUP BY x",
+		"SELECT extract(day FROM d) FROM hits -- FROM comment":     "SELECT extract(day FROM d) FROM hits SAMPLE 0.1 OFFSET 0.5 -- FROM comment",
+	} {
+		assert.Equal(t, expected, sampleQuery(query, s), query)
+	}
+	assert.Equal(t, "SELECT * FROM hits SAMPLE 0.25", sampleQuery("SELECT * FROM hits", &sampling{ratio: 0.25}))
+}
+
+func TestSampleFilters(t *testing.T) {
+	assert.Equal(t, "{'hits': 'rand() < 1073741824'}", sampleFilters([]string{"hits"}, &sampling{ratio: 0.25}))
+	assert.Equal(t, "{'db.hits': '(rand() + 2147483648) % 4294967296 < 429496730', 'v': '(rand() + 2147483648) % 4294967296 < 429496730'}",
+		sampleFilters([]string{"db.hits", "v"}, &sampling{ratio: 0.1, offset: 0.5}))
+}
+
+func TestWithSampling(t *testing.T) {
+	for _, invalid := range [][2]float64{{0, 0}, {1.5, 0}, {0.5, 1}, {0.6, 0.6}, {0.1, -0.1}} {
+		assert.Error(t, WithSampling(invalid[0], invalid[1])(&QueryOptions{}), invalid)
+	}
+	err := (&clickhouse{opt: (&Options{}).setDefaults()}).intercept(Context(context.Background(), WithSampling(1.5, 0)),
+		&Operation{Kind: OperationQuery, Query: "SELECT count() FROM hits"},
+		func(context.Context, *Operation) error {
+			t.Fatal("the query must not run unsampled")
+			return nil
+		},
+	)
+	assert.ErrorContains(t, err, "invalid sampling ratio 1.5")
+	var (
+		ch      = &clickhouse{sampling: &samplingSupport{}}
+		queries []string
+		filters []interface{}
+		invoker = ch.sample(func(ctx context.Context, op *Operation) error {
+			queries = append(queries, op.Query)
+			filters = append(filters, queryOptions(ctx).settings["additional_table_filters"])
+			if strings.Contains(op.Query, "SAMPLE") && strings.Contains(op.Query, "events") {
+				return &Exception{Code: int32(proto.ErrCodeSamplingNotSupported)}
+			}
+			return nil
+		})
+		ctx = Context(context.Background(), WithSampling(0.25, 0))
+	)
+	require.NoError(t, invoker(ctx, &Operation{Kind: OperationQuery, Query: "SELECT count() FROM hits"}))
+	assert.Equal(t, []string{"SELECT count() FROM hits SAMPLE 0.25"}, queries)
+	assert.Equal(t, []interface{}{nil}, filters)
+
+	queries, filters = nil, nil
+	require.NoError(t, invoker(ctx, &Operation{Kind: OperationQuery, Query: "SELECT count() FROM events"}))
+	require.NoError(t, invoker(ctx, &Operation{Kind: OperationQueryRow, Query: "SELECT count() FROM events"}))
+	assert.Equal(t, []string{"SELECT count() FROM events SAMPLE 0.25", "SELECT count() FROM events", "SELECT count() FROM events"}, queries)
+	assert.Equal(t, []interface{}{nil, "{'events': 'rand() < 1073741824'}", "{'events': 'rand() < 1073741824'}"}, filters)
+
+	queries = nil
+	require.NoError(t, invoker(context.Background(), &Operation{Kind: OperationQuery, Query: "SELECT * FROM events"}))
+	require.NoError(t, invoker(ctx, &Operation{Kind: OperationExec, Query: "INSERT INTO events SELECT * FROM hits"}))
+	assert.Equal(t, []string{"SELECT * FROM events", "INSERT INTO events SELECT * FROM hits"}, queries)
+}
@@ -23,10 +23,19 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
 )
 
 func (ch *clickhouse) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return selectRows(dest, func() (driver.Rows, error) {
+		return ch.Query(ctx, query, args...)
+	})
+}
+
+// selectRows appends the rows of query to dest, a pointer to a slice of structs.
+func selectRows(dest interface{}, query func() (driver.Rows, error)) error {
 	value := reflect.ValueOf(dest)
 	if value.Kind() != reflect.Ptr {
 		return &OpError{
@@ -51,7 +60,7 @@ func (ch *clickhouse) Select(ctx context.Context, dest interface{}, query string
 	}
 	var (
 		base      = direct.Type().Elem()
-		rows, err = ch.Query(ctx, query, args...)
+		rows, err = query()
 	)
 	if err != nil {
 		return err
@@ -79,9 +88,16 @@ func scan(block *proto.Block, row int, dest ...interface{}) error {
 		}
 	}
 	for i, d := range dest {
+		if d == skipColumn {
+			continue
+		}
 		if err := columns[i].ScanRow(d, row-1); err != nil {
+			var cErr *column.ColumnConverterError
+			if block.Conversion == column.ConversionLenient && errors.As(err, &cErr) && column.ScanConvert(columns[i].Row(row-1, false), d) {
+				continue
+			}
 			return &OpError{
-				Err:        err,
+				Err:        column.Locate(err, columns[i], row-1, columns[i].Row(row-1, false)),
 				ColumnName: block.ColumnsNames()[i],
 			}
 		}
@@ -0,0 +1,61 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanConversionPolicy(t *testing.T) {
+	block := &proto.Block{}
+	require.NoError(t, block.AddColumn("n", "UInt64"))
+	require.NoError(t, block.AddColumn("s", "String"))
+	require.NoError(t, block.AddColumn("m", "Nullable(Int16)"))
+	require.NoError(t, block.Append(uint64(300), "12", nil))
+
+	var (
+		n int
+		s int64
+		m *int
+	)
+	require.NoError(t, scan(block, 1, &n, &s, &m))
+	assert.Equal(t, 300, n)
+	assert.Equal(t, int64(12), s)
+	assert.Nil(t, m)
+	var small uint8
+	assert.Error(t, scan(block, 1, &small, &s, &m))
+	var str string
+	require.NoError(t, scan(block, 1, &str, &s, &m))
+	assert.Equal(t, "300", str)
+
+	block.Conversion = column.ConversionStrict
+	var cErr *column.ColumnConverterError
+	require.ErrorAs(t, scan(block, 1, &n, &s, &m), &cErr)
+	assert.Equal(t, "n", cErr.Column)
+	assert.Equal(t, 0, cErr.Row)
+	assert.Equal(t, "300", cErr.Value)
+	var exact uint64
+	var text string
+	var nullable *int16
+	require.NoError(t, scan(block, 1, &exact, &text, &nullable))
+}
@@ -0,0 +1,137 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// Priorities of server log entries, see Log.Priority.
+const (
+	LogFatal int8 = iota + 1
+	LogCritical
+	LogError
+	LogWarning
+	LogNotice
+	LogInformation
+	LogDebug
+	LogTrace
+)
+
+type TailLogsOptions struct {
+	// Priority is the least severe priority delivered, e.g. LogWarning for warnings, errors and fatal entries. Default LogInformation.
+	Priority int8
+	// QueryID only delivers the entries of a query.
+	QueryID string
+	// Since is the time of the first entry delivered. Default the time TailServerLogs is called.
+	Since time.Time
+	// PollInterval is how often system.text_log is polled. Default 1 second.
+	PollInterval time.Duration
+	// BufferSize is the capacity of the entry channel.
+	BufferSize int
+}
+
+// TailServerLogs polls system.text_log and sends its new entries to the returned channel, in order of
+// their time. Both channels are closed once ctx is cancelled or a poll failed; the error channel
+// receives at most one error, not counting the cancellation. Entries only appear once the server
+// flushed the log, every 7.5 seconds by default, and the text_log section of the server
+// configuration must be enabled.
+func TailServerLogs(ctx context.Context, conn driver.Conn, opts TailLogsOptions) (<-chan Log, <-chan error) {
+	if opts.Priority == 0 {
+		opts.Priority = LogInformation
+	}
+	if opts.Since.IsZero() {
+		opts.Since = time.Now()
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	var (
+		out  = make(chan Log, opts.BufferSize)
+		errs = make(chan error, 1)
+	)
+	go func() {
+		defer close(errs)
+		defer close(out)
+		ticker := time.NewTicker(opts.PollInterval)
+		defer ticker.Stop()
+		for since := opts.Since; ; {
+			logs, err := textLog(ctx, conn, since, opts)
+			if err != nil {
+				if ctx.Err() == nil {
+					errs <- err
+				}
+				return
+			}
+			for _, log := range logs {
+				select {
+				case out <- log:
+					since = log.Time
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, errs
+}
+
+func textLog(ctx context.Context, conn driver.Conn, since time.Time, opts TailLogsOptions) ([]Log, error) {
+	query := `
+		SELECT
+			  event_time_microseconds
+			, microseconds
+			, hostName()
+			, query_id
+			, thread_id
+			, CAST(level, 'Int8') AS priority
+			, logger_name
+			, message
+		FROM system.text_log
+		WHERE event_date >= toDate(@since) AND event_time_microseconds > @since AND priority <= @priority`
+	args := []interface{}{
+		DateNamed("since", since, MicroSeconds),
+		Named("priority", opts.Priority),
+	}
+	if len(opts.QueryID) != 0 {
+		query += " AND query_id = @query_id"
+		args = append(args, Named("query_id", opts.QueryID))
+	}
+	rows, err := conn.Query(ctx, query+" ORDER BY event_time_microseconds", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var logs []Log
+	for rows.Next() {
+		var log Log
+		if err := rows.Scan(&log.Time, &log.TimeMicro, &log.Hostname, &log.QueryID, &log.ThreadID, &log.Priority, &log.Source, &log.Text); err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+	return logs, rows.Err()
+}
@@ -0,0 +1,96 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+)
+
+// shutdownTracker records the hosts whose server signaled a shutdown, so that the pooled connections
+// dialed to them before are recycled instead of failing the next queries during rolling restarts.
+type shutdownTracker struct {
+	mu    sync.Mutex
+	hosts map[string]time.Time
+}
+
+// mark records that the server at addr is shutting down.
+func (t *shutdownTracker) mark(addr string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.hosts == nil {
+		t.hosts = make(map[string]time.Time)
+	}
+	t.hosts[addr] = time.Now()
+}
+
+// stale reports whether a connection to addr established at connectedAt predates a shutdown of its server.
+func (t *shutdownTracker) stale(addr string, connectedAt time.Time) bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	at, found := t.hosts[addr]
+	return found && !connectedAt.After(at)
+}
+
+// isServerShutdown reports whether err signals that the server of a connection is shutting down or
+// restarting: an exception about the shutdown, or the connection closed or reset by the server.
+func isServerShutdown(err error) bool {
+	var exception *Exception
+	if errors.As(err, &exception) {
+		return exception.Code == int32(proto.ErrCodeAborted) || strings.Contains(strings.ToLower(exception.Message), "shutdown")
+	}
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// serverShutdown recycles the idle connections to addr after its server signaled a shutdown with err. The
+// connections in use are recycled when they are released, or by the check of acquire.
+func (ch *clickhouse) serverShutdown(addr string, err error) {
+	ch.shutdowns.mark(addr)
+	for i := len(ch.idle); i > 0; i-- {
+		select {
+		case conn := <-ch.idle:
+			if ch.shutdowns.stale(conn.addr, conn.connectedAt) {
+				conn.debugf("[shutdown] recycling idle connection [%d] after %v", conn.id, err)
+				conn.close()
+				continue
+			}
+			select {
+			case ch.idle <- conn:
+			default:
+				conn.close()
+			}
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsServerShutdown(t *testing.T) {
+	assert.True(t, isServerShutdown(&Exception{Code: int32(proto.ErrCodeAborted), Message: "Query was aborted"}))
+	assert.True(t, isServerShutdown(fmt.Errorf("query: %w", &Exception{Code: 1000, Message: "Server shutdown is in progress"})))
+	assert.True(t, isServerShutdown(fmt.Errorf("read: %w", io.EOF)))
+	assert.True(t, isServerShutdown(&net.OpError{Op: "read", Err: syscall.ECONNRESET}))
+	assert.False(t, isServerShutdown(&Exception{Code: int32(proto.ErrCodeUnknownTable), Message: "Table default.x does not exist"}))
+	assert.False(t, isServerShutdown(context.Canceled))
+}
+
+func TestServerShutdownRecyclesConnections(t *testing.T) {
+	conn, err := Open(&Options{
+		Addr: []string{"a:9000"},
+		DialContext: func(ctx context.Context, addr string) (net.Conn, error) {
+			return &replayConn{data: fakeServer()}, nil
+		},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	ch := conn.(*clickhouse)
+	_, err = Warmup(context.Background(), conn, 3, WarmupOptions{})
+	require.NoError(t, err)
+	require.Len(t, ch.idle, 3)
+
+	c, err := ch.acquire(context.Background())
+	require.NoError(t, err)
+	other, err := ch.acquire(context.Background())
+	require.NoError(t, err)
+	require.Len(t, ch.idle, 1)
+	ch.release(c, &Exception{Code: int32(proto.ErrCodeAborted), Message: "Server shutdown is called"})
+	assert.True(t, c.isClosed())
+	assert.Len(t, ch.idle, 0)
+
+	// a connection in use when the shutdown was signaled is recycled once released
+	ch.release(other, nil)
+	assert.True(t, other.isClosed())
+	assert.Len(t, ch.idle, 0)
+
+	// the connections dialed after the shutdown are pooled
+	fresh, err := ch.acquire(context.Background())
+	require.NoError(t, err)
+	ch.release(fresh, nil)
+	assert.False(t, fresh.isClosed())
+	assert.Len(t, ch.idle, 1)
+}
+
+func TestStdServerShutdown(t *testing.T) {
+	std := &stdDriver{conn: &httpConnect{client: &http.Client{}}, debugf: func(string, ...interface{}) {}}
+	std.recycle(&Exception{Code: int32(proto.ErrCodeUnknownTable)})
+	require.NoError(t, std.ResetSession(context.Background()))
+	std.recycle(&Exception{Code: int32(proto.ErrCodeAborted), Message: "Server shutdown is called"})
+	assert.ErrorIs(t, std.ResetSession(context.Background()), driver.ErrBadConn)
+}
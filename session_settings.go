@@ -0,0 +1,181 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SessionSettingsPolicy is what the client does with the settings of SET statements run with Exec, which the
+// server otherwise keeps for the later statements of the connection, whatever requests they serve.
+type SessionSettingsPolicy uint8
+
+const (
+	// SessionSettingsServer sends SET statements to the server, the settings staying with the connection
+	// once it is back in the pool.
+	SessionSettingsServer SessionSettingsPolicy = iota
+	// SessionSettingsReset keeps the settings of SET statements in the client, and sends them with the later
+	// statements of the connection until it is released, e.g. at the end of a database/sql Conn or Tx.
+	// The connections of Conn are released after every statement, so there SET only validates the settings.
+	SessionSettingsReset
+	// SessionSettingsReplay keeps the settings of SET statements in the client, and sends them with the
+	// statements of every connection of the pool, as if they were added to Options.Settings. With
+	// database/sql the pool must be opened with OpenDB or Connector rather than sql.Open to be shared.
+	SessionSettingsReplay
+)
+
+// sessionSettings are the settings of the SET statements of a connection, or of a pool, tracked by the client.
+type sessionSettings struct {
+	mu       sync.Mutex
+	settings Settings
+	// shared settings are replayed on the connections of a pool and never reset
+	shared bool
+}
+
+func newSessionSettings(opt *Options) *sessionSettings {
+	switch opt.SessionSettings {
+	case SessionSettingsReset:
+		return &sessionSettings{settings: make(Settings)}
+	case SessionSettingsReplay:
+		return opt.replay
+	}
+	return nil
+}
+
+// set tracks the settings of query, if it is a SET statement, first validating them with a query run by exec.
+// It reports whether query was handled.
+func (s *sessionSettings) set(ctx context.Context, query string, exec func(context.Context, string) error) (bool, error) {
+	if s == nil {
+		return false, nil
+	}
+	settings, ok := parseSetStatement(query)
+	if !ok {
+		return false, nil
+	}
+	var (
+		current = queryOptions(ctx).settings
+		merged  = make(Settings, len(current)+len(settings))
+	)
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k, v := range settings {
+		merged[k] = v
+	}
+	if err := exec(Context(ctx, WithSettings(merged)), "SELECT 1"); err != nil {
+		return true, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range settings {
+		s.settings[k] = v
+	}
+	return true, nil
+}
+
+// each calls fn with the tracked settings.
+func (s *sessionSettings) each(fn func(key string, value interface{})) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range s.settings {
+		fn(k, v)
+	}
+}
+
+// reset drops the settings of a connection when it is released.
+func (s *sessionSettings) reset() {
+	if s == nil || s.shared {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = make(Settings)
+}
+
+// parseSetStatement returns the settings of query, a SET statement of name = value assignments. Other
+// SET statements, e.g. SET ROLE, are not reported.
+func parseSetStatement(query string) (Settings, bool) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	if len(query) < 4 || !strings.EqualFold(query[:3], "SET") || !strings.ContainsAny(query[3:4], " \t\r\n") {
+		return nil, false
+	}
+	settings := make(Settings)
+	for _, assignment := range splitAssignments(query[4:]) {
+		i := strings.IndexByte(assignment, '=')
+		if i == -1 {
+			return nil, false
+		}
+		name := strings.TrimSpace(assignment[:i])
+		if !plainIdentifier.MatchString(name) {
+			return nil, false
+		}
+		value, ok := settingValue(strings.TrimSpace(assignment[i+1:]))
+		if !ok {
+			return nil, false
+		}
+		settings[name] = value
+	}
+	return settings, len(settings) != 0
+}
+
+// splitAssignments splits s at the commas outside of string literals.
+func splitAssignments(s string) (assignments []string) {
+	var (
+		start  int
+		quoted bool
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '\'':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			assignments, start = append(assignments, s[start:i]), i+1
+		}
+	}
+	return append(assignments, s[start:])
+}
+
+// settingValue returns the value of a literal: a string, an integer, a boolean or any other word.
+func settingValue(literal string) (interface{}, bool) {
+	switch {
+	case len(literal) == 0:
+		return nil, false
+	case literal[0] == '\'':
+		if len(literal) < 2 || literal[len(literal)-1] != '\'' {
+			return nil, false
+		}
+		return strings.NewReplacer(`\\`, `\`, `\'`, `'`, `''`, `'`).Replace(literal[1 : len(literal)-1]), true
+	case strings.EqualFold(literal, "true"), strings.EqualFold(literal, "false"):
+		return strings.EqualFold(literal, "true"), true
+	}
+	if n, err := strconv.Atoi(literal); err == nil {
+		return n, true
+	}
+	if strings.ContainsAny(literal, " \t\r\n'") {
+		return nil, false
+	}
+	return literal, true
+}
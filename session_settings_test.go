@@ -0,0 +1,98 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSetStatement(t *testing.T) {
+	settings, ok := parseSetStatement("SET max_threads = 2, log_comment = 'it''s, \\'quoted\\'', use_uncompressed_cache=true, load_balancing = in_order;")
+	require.True(t, ok)
+	assert.Equal(t, Settings{
+		"max_threads":            2,
+		"log_comment":            "it's, 'quoted'",
+		"use_uncompressed_cache": true,
+		"load_balancing":         "in_order",
+	}, settings)
+	for _, query := range []string{
+		"SELECT 1",
+		"SET ROLE admin",
+		"SET DEFAULT ROLE admin TO user",
+		"SETTINGS max_threads = 1",
+		"SET max_threads = ",
+		"SET `max threads` = 1",
+		"SET log_comment = 'unterminated",
+		"SET max_threads = 1 + 1",
+	} {
+		_, ok := parseSetStatement(query)
+		assert.False(t, ok, query)
+	}
+}
+
+func TestSessionSettings(t *testing.T) {
+	var (
+		ctx      = Context(context.Background(), WithSettings(Settings{"max_threads": 1}))
+		executed []Settings
+		exec     = func(ctx context.Context, query string) error {
+			assert.Equal(t, "SELECT 1", query)
+			executed = append(executed, queryOptions(ctx).settings)
+			return nil
+		}
+		session = newSessionSettings(&Options{SessionSettings: SessionSettingsReset})
+		c       = &connect{opt: &Options{Settings: Settings{"readonly": 2}}, session: session}
+	)
+	ok, err := session.set(ctx, "SELECT 2", exec)
+	assert.False(t, ok)
+	assert.NoError(t, err)
+	ok, err = session.set(ctx, "set max_execution_time = 10", exec)
+	assert.True(t, ok)
+	require.NoError(t, err)
+	assert.Equal(t, []Settings{{"max_threads": 1, "max_execution_time": 10}}, executed)
+	assert.Len(t, c.settings(Settings{"max_threads": 1}), 3)
+
+	errInvalid := errors.New("unknown setting")
+	ok, err = session.set(ctx, "SET unknown = 1", func(context.Context, string) error { return errInvalid })
+	assert.True(t, ok)
+	assert.ErrorIs(t, err, errInvalid)
+	assert.Len(t, c.settings(nil), 2, "invalid settings are not kept")
+
+	c.resetSession()
+	assert.Len(t, c.settings(nil), 1)
+
+	var nop *sessionSettings
+	ok, err = nop.set(ctx, "SET max_threads = 1", exec)
+	assert.False(t, ok, "SET statements are sent to the server by default")
+	assert.NoError(t, err)
+	assert.Nil(t, newSessionSettings(&Options{}))
+
+	opt := (&Options{SessionSettings: SessionSettingsReplay}).setDefaults()
+	replay := newSessionSettings(opt)
+	require.NotNil(t, replay)
+	assert.Same(t, replay, newSessionSettings(opt), "the settings are shared by the connections of a pool")
+	_, err = replay.set(ctx, "SET max_threads = 4", exec)
+	require.NoError(t, err)
+	replay.reset()
+	c.session = replay
+	assert.Len(t, c.settings(nil), 2, "replayed settings are not reset")
+}
@@ -0,0 +1,140 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// SlowQuery describes a statement that took longer than the threshold of SlowQueryInterceptor.
+type SlowQuery struct {
+	Kind   OperationKind
+	Query  string
+	Labels Labels
+	// Elapsed is the time from the call until the rows are closed for Query, the row is scanned for
+	// QueryRow, the batch is sent for PrepareBatch, or the call returns otherwise.
+	Elapsed time.Duration
+	Err     error
+}
+
+// SlowQueryInterceptor calls fn with the statements taking threshold or longer, e.g. to log them with
+// their labels, see WithLabel.
+func SlowQueryInterceptor(threshold time.Duration, fn func(ctx context.Context, q SlowQuery)) Interceptor {
+	return func(ctx context.Context, op *Operation, next Invoker) error {
+		var (
+			start = time.Now()
+			once  sync.Once
+		)
+		report := func(err error) {
+			once.Do(func() {
+				if elapsed := time.Since(start); elapsed >= threshold {
+					fn(ctx, SlowQuery{
+						Kind:    op.Kind,
+						Query:   op.Query,
+						Labels:  QueryLabels(ctx),
+						Elapsed: elapsed,
+						Err:     err,
+					})
+				}
+			})
+		}
+		if err := next(ctx, op); err != nil {
+			report(err)
+			return err
+		}
+		switch {
+		case op.Rows != nil:
+			op.Rows = &slowRows{Rows: op.Rows, report: report}
+		case op.Row != nil:
+			op.Row = &slowRow{Row: op.Row, report: report}
+		case op.Batch != nil:
+			op.Batch = &slowBatch{Batch: op.Batch, report: report}
+		default:
+			report(nil)
+		}
+		return nil
+	}
+}
+
+type slowRows struct {
+	driver.Rows
+	report func(error)
+}
+
+func (r *slowRows) Close() error {
+	err := r.Rows.Close()
+	if err == nil {
+		err = r.Rows.Err()
+	}
+	r.report(err)
+	return err
+}
+
+type slowRow struct {
+	driver.Row
+	report func(error)
+}
+
+func (r *slowRow) Scan(dest ...interface{}) error {
+	err := r.Row.Scan(dest...)
+	r.report(err)
+	return err
+}
+
+func (r *slowRow) ScanStruct(dest interface{}) error {
+	err := r.Row.ScanStruct(dest)
+	r.report(err)
+	return err
+}
+
+type slowBatch struct {
+	driver.Batch
+	report func(error)
+}
+
+func (b *slowBatch) Send() error {
+	err := b.Batch.Send()
+	b.report(err)
+	return err
+}
+
+// Retry is not reported, the statement was reported by Send.
+func (b *slowBatch) Retry(ctx context.Context) error {
+	retryable, ok := b.Batch.(driver.RetryableBatch)
+	if !ok {
+		return ErrBatchRetryUnsupported
+	}
+	return retryable.Retry(ctx)
+}
+
+// Reset is not reported, the statement was reported by Send.
+func (b *slowBatch) Reset() error {
+	resettable, ok := b.Batch.(driver.ResettableBatch)
+	if !ok {
+		return ErrBatchResetUnsupported
+	}
+	return resettable.Reset()
+}
+
+func (b *slowBatch) Unwrap() driver.Batch {
+	return b.Batch
+}
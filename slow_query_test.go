@@ -0,0 +1,83 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sleepRows struct {
+	driver.Rows
+	sleep time.Duration
+}
+
+func (r *sleepRows) Close() error {
+	time.Sleep(r.sleep)
+	return nil
+}
+
+func (r *sleepRows) Err() error { return nil }
+
+func TestWithLabel(t *testing.T) {
+	ctx := Context(context.Background(), WithLabel("report", "daily_revenue"))
+	ctx = Context(ctx, WithLabel("endpoint", "/revenue"), WithQueryID("id"))
+	assert.Equal(t, Labels{"report": "daily_revenue", "endpoint": "/revenue"}, QueryLabels(ctx))
+	assert.Nil(t, QueryLabels(context.Background()))
+}
+
+func TestSlowQueryInterceptor(t *testing.T) {
+	var slow []SlowQuery
+	interceptor := SlowQueryInterceptor(20*time.Millisecond, func(ctx context.Context, q SlowQuery) {
+		slow = append(slow, q)
+	})
+	ctx := Context(context.Background(), WithLabel("report", "daily_revenue"))
+
+	op := &Operation{Kind: OperationQuery, Query: "SELECT 1"}
+	require.NoError(t, interceptor(ctx, op, func(ctx context.Context, op *Operation) error {
+		op.Rows = &sleepRows{sleep: 30 * time.Millisecond}
+		return nil
+	}))
+	assert.Empty(t, slow, "a query is measured until its rows are closed")
+	require.NoError(t, op.Rows.Close())
+	require.NoError(t, op.Rows.Close())
+	require.Len(t, slow, 1)
+	assert.Equal(t, OperationQuery, slow[0].Kind)
+	assert.Equal(t, "SELECT 1", slow[0].Query)
+	assert.Equal(t, Labels{"report": "daily_revenue"}, slow[0].Labels)
+	assert.GreaterOrEqual(t, slow[0].Elapsed, 20*time.Millisecond)
+
+	require.NoError(t, interceptor(ctx, &Operation{Kind: OperationExec, Query: "SELECT 2"}, func(ctx context.Context, op *Operation) error {
+		return nil
+	}))
+	assert.Len(t, slow, 1, "fast statements are not reported")
+
+	errExec := errors.New("exec")
+	assert.ErrorIs(t, interceptor(ctx, &Operation{Kind: OperationExec, Query: "SELECT 3"}, func(ctx context.Context, op *Operation) error {
+		time.Sleep(30 * time.Millisecond)
+		return errExec
+	}), errExec)
+	require.Len(t, slow, 2)
+	assert.ErrorIs(t, slow[1].Err, errExec)
+}
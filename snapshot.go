@@ -0,0 +1,198 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// SnapshotOptions configures the queries of ReadSnapshot.
+type SnapshotOptions struct {
+	// MaxReplicaDelay, if set, is the max_replica_delay_for_distributed_queries setting of the queries: the
+	// replicas of Distributed tables lagging behind more are skipped rather than fallen back to.
+	MaxReplicaDelay time.Duration
+	// Settings are added to the settings of the queries, e.g. to pin the replicas otherwise.
+	Settings Settings
+}
+
+func (o SnapshotOptions) settings() Settings {
+	// in_order reads the shards of Distributed tables from the same replicas in every query
+	settings := Settings{"load_balancing": "in_order"}
+	if o.MaxReplicaDelay > 0 {
+		delay := int(o.MaxReplicaDelay / time.Second)
+		if delay < 1 {
+			delay = 1
+		}
+		settings["max_replica_delay_for_distributed_queries"] = delay
+		settings["fallback_to_stale_replicas_for_distributed_queries"] = 0
+	}
+	for k, v := range o.Settings {
+		settings[k] = v
+	}
+	return settings
+}
+
+// Snapshot runs the queries of ReadSnapshot one after another on the same connection. It is not safe for
+// concurrent use, and a query closes the rows of the previous one, reading what is left of them.
+type Snapshot struct {
+	ch       *clickhouse
+	conn     *connect
+	settings Settings
+	time     time.Time
+	rows     *rows
+	// done is closed once the connection is released by the last query
+	done   chan struct{}
+	err    error
+	closed bool
+}
+
+// ReadSnapshot calls fn with a Snapshot whose queries are all read from the same server, giving dashboards
+// fanning out several queries a consistent view: Distributed tables are read from the same replicas, and
+// Time is the time of the server at the start of the snapshot, to bound the queries to the same data with.
+// conn must have been returned by Open, and s is not used once fn returns. A failed query fails the remaining queries of the snapshot, as the
+// connection is not reused after errors.
+func ReadSnapshot(ctx context.Context, conn driver.Conn, opts SnapshotOptions, fn func(s *Snapshot) error) error {
+	ch, ok := conn.(*clickhouse)
+	if !ok {
+		return fmt.Errorf("clickhouse: snapshot requires a connection of Open, not %T", conn)
+	}
+	pool := ch.pool(ctx, "SELECT")
+	c, err := pool.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	s := &Snapshot{
+		ch:       ch,
+		conn:     c,
+		settings: opts.settings(),
+	}
+	defer func() {
+		s.wait()
+		s.closed = true
+		pool.release(c, s.err)
+	}()
+	if err := s.QueryRow(ctx, "SELECT now64(9)").Scan(&s.time); err != nil {
+		return err
+	}
+	return fn(s)
+}
+
+// Addr returns the address of the server the snapshot reads from.
+func (s *Snapshot) Addr() string {
+	return s.conn.addr
+}
+
+// Time returns the time of the server at the start of the snapshot.
+func (s *Snapshot) Time() time.Time {
+	return s.time
+}
+
+func (s *Snapshot) Query(ctx context.Context, query string, args ...interface{}) (driver.Rows, error) {
+	op := &Operation{Kind: OperationQuery, Query: query, Args: args}
+	err := s.ch.intercept(s.context(ctx), op, func(ctx context.Context, op *Operation) error {
+		rows, err := s.query(ctx, op.Query, op.Args...)
+		if err != nil {
+			return err
+		}
+		op.Rows = rows
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return op.Rows, nil
+}
+
+func (s *Snapshot) QueryRow(ctx context.Context, query string, args ...interface{}) driver.Row {
+	op := &Operation{Kind: OperationQueryRow, Query: query, Args: args}
+	err := s.ch.intercept(s.context(ctx), op, func(ctx context.Context, op *Operation) error {
+		rows, err := s.query(ctx, op.Query, op.Args...)
+		if err != nil {
+			return err
+		}
+		op.Row = &row{rows: rows}
+		return nil
+	})
+	if op.Row == nil || (err != nil && op.Row.Err() == nil) {
+		return &row{
+			err: err,
+		}
+	}
+	return op.Row
+}
+
+func (s *Snapshot) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return selectRows(dest, func() (driver.Rows, error) {
+		return s.Query(ctx, query, args...)
+	})
+}
+
+func (s *Snapshot) query(ctx context.Context, query string, args ...interface{}) (*rows, error) {
+	if s.closed {
+		return nil, errSnapshotClosed
+	}
+	s.wait()
+	if s.err != nil {
+		return nil, fmt.Errorf("clickhouse: snapshot connection failed: %w", s.err)
+	}
+	done := make(chan struct{})
+	s.done = done
+	rows, err := s.conn.query(ctx, func(_ *connect, err error) {
+		s.err = err
+		close(done)
+	}, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	s.rows = rows
+	return rows, nil
+}
+
+// wait closes the rows of the last query, and waits for the release of the connection.
+func (s *Snapshot) wait() {
+	if s.rows != nil {
+		s.rows.Close()
+		s.rows = nil
+	}
+	if s.done != nil {
+		<-s.done
+		s.done = nil
+	}
+}
+
+// context returns ctx with the settings of the snapshot, under the settings already carried by ctx.
+func (s *Snapshot) context(ctx context.Context) context.Context {
+	var (
+		current  = queryOptions(ctx).settings
+		settings = make(Settings, len(s.settings)+len(current))
+	)
+	for k, v := range s.settings {
+		settings[k] = v
+	}
+	for k, v := range current {
+		settings[k] = v
+	}
+	return Context(ctx, WithSettings(settings))
+}
+
+var errSnapshotClosed = errors.New("clickhouse: snapshot is closed")
@@ -0,0 +1,58 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotSettings(t *testing.T) {
+	assert.Equal(t, Settings{"load_balancing": "in_order"}, SnapshotOptions{}.settings())
+	assert.Equal(t, Settings{
+		"load_balancing": "nearest_hostname",
+		"max_replica_delay_for_distributed_queries":          1,
+		"fallback_to_stale_replicas_for_distributed_queries": 0,
+	}, SnapshotOptions{
+		MaxReplicaDelay: 500 * time.Millisecond,
+		Settings:        Settings{"load_balancing": "nearest_hostname"},
+	}.settings())
+
+	s := &Snapshot{settings: SnapshotOptions{MaxReplicaDelay: time.Minute}.settings()}
+	ctx := s.context(Context(context.Background(), WithSettings(Settings{"max_replica_delay_for_distributed_queries": 5})))
+	settings := queryOptions(ctx).settings
+	assert.Equal(t, 5, settings["max_replica_delay_for_distributed_queries"])
+	assert.Equal(t, "in_order", settings["load_balancing"])
+}
+
+func TestReadSnapshotRequiresOpen(t *testing.T) {
+	err := ReadSnapshot(context.Background(), &pageConn{}, SnapshotOptions{}, func(*Snapshot) error {
+		t.Fatal("unexpected snapshot")
+		return nil
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "connection of Open")
+
+	closed := &Snapshot{ch: &clickhouse{opt: &Options{}}, closed: true}
+	_, err = closed.Query(context.Background(), "SELECT 1")
+	assert.ErrorIs(t, err, errSnapshotClosed)
+}
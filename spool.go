@@ -0,0 +1,222 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"bytes"
+	"os"
+	"sync"
+
+	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+)
+
+// SpoolOptions configures the spooling of a result to disk, see WithSpool.
+type SpoolOptions struct {
+	// Dir is the directory of the temporary file of the spooled blocks. Default os.TempDir.
+	Dir string
+	// MemoryBlocks is the number of received blocks kept in memory, past which they are spooled. Default 4.
+	MemoryBlocks int
+}
+
+// WithSpool spools the blocks of a result which are received faster than they are read to a temporary file,
+// rather than leaving them on the server: the query finishes and its connection is released as soon as the
+// server is done, while at most MemoryBlocks blocks are held in memory. The rows read the blocks back in
+// order, and the file is removed once all of them were read or the rows are closed. Blocks which fail to be
+// written are kept in memory. Native protocol only.
+func WithSpool(opts SpoolOptions) QueryOption {
+	return func(o *QueryOptions) error {
+		if opts.MemoryBlocks <= 0 {
+			opts.MemoryBlocks = 4
+		}
+		o.spool = &opts
+		return nil
+	}
+}
+
+// spoolEntry is a block of the spool, in memory or written to the file at offset.
+type spoolEntry struct {
+	block        *proto.Block
+	packet       byte
+	offset, size int64
+}
+
+// spool queues the blocks received by a query for its rows, see WithSpool.
+type spool struct {
+	opts     SpoolOptions
+	revision uint64
+	template proto.Block // the empty block the spooled blocks are decoded into
+	debugf   func(format string, v ...interface{})
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    []spoolEntry
+	inMemory int
+	file     *os.File
+	size     int64
+	buffer   chproto.Buffer
+	done     bool // no more blocks are pushed
+	closed   bool // the rows are closed or read, the blocks are discarded
+	err      error
+}
+
+func (c *connect) newSpool(opts SpoolOptions, template proto.Block) *spool {
+	s := &spool{
+		opts:     opts,
+		revision: c.revision,
+		template: template,
+		debugf:   c.debugf,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// push queues a block received from the server.
+func (s *spool) push(block *proto.Block) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	defer s.cond.Signal()
+	if s.inMemory < s.opts.MemoryBlocks {
+		s.queue, s.inMemory = append(s.queue, spoolEntry{block: block}), s.inMemory+1
+		return
+	}
+	entry, err := s.write(block)
+	if err != nil {
+		s.debugf("[spool] keeping the block in memory: %v", err)
+		s.queue, s.inMemory = append(s.queue, spoolEntry{block: block}), s.inMemory+1
+		return
+	}
+	s.queue = append(s.queue, entry)
+}
+
+// write appends block to the file, creating it if needed.
+func (s *spool) write(block *proto.Block) (spoolEntry, error) {
+	if s.file == nil {
+		file, err := os.CreateTemp(s.opts.Dir, "clickhouse-spool-*")
+		if err != nil {
+			return spoolEntry{}, err
+		}
+		s.file = file
+	}
+	s.buffer.Reset()
+	if err := block.Encode(&s.buffer, s.revision); err != nil {
+		return spoolEntry{}, err
+	}
+	if _, err := s.file.WriteAt(s.buffer.Buf, s.size); err != nil {
+		return spoolEntry{}, err
+	}
+	entry := spoolEntry{packet: block.Packet, offset: s.size, size: int64(len(s.buffer.Buf))}
+	s.size += entry.size
+	return entry, nil
+}
+
+// finish reports that all the blocks were pushed.
+func (s *spool) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done = true
+	s.cond.Signal()
+}
+
+// failure returns the error reading the spooled blocks back, if any.
+func (s *spool) failure() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// discard drops the queued blocks once the rows are closed.
+func (s *spool) discard() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed, s.queue = true, nil
+	s.cond.Signal()
+}
+
+// next returns the next block, nil once all were read or the spool was discarded.
+func (s *spool) next() (*proto.Block, error) {
+	s.mu.Lock()
+	for len(s.queue) == 0 && !s.done && !s.closed {
+		s.cond.Wait()
+	}
+	if len(s.queue) == 0 || s.closed {
+		s.mu.Unlock()
+		return nil, nil
+	}
+	entry := s.queue[0]
+	s.queue = s.queue[1:]
+	if entry.block != nil {
+		s.inMemory--
+		s.mu.Unlock()
+		return entry.block, nil
+	}
+	data := make([]byte, entry.size)
+	_, err := s.file.ReadAt(data, entry.offset)
+	if err == nil && len(s.queue) == 0 {
+		// the file was read, write the next blocks from its start
+		if err = s.file.Truncate(0); err == nil {
+			s.size = 0
+		}
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	block := s.template
+	if err := block.Decode(chproto.NewReader(bytes.NewReader(data)), s.revision); err != nil {
+		return nil, err
+	}
+	block.Packet = entry.packet
+	return &block, nil
+}
+
+// feed sends the blocks of the spool to stream and closes it, removing the file.
+func (s *spool) feed(stream chan<- *proto.Block) {
+	defer func() {
+		s.mu.Lock()
+		s.closed, s.queue = true, nil
+		if s.file != nil {
+			s.file.Close()
+			os.Remove(s.file.Name())
+		}
+		s.mu.Unlock()
+		close(stream)
+	}()
+	for {
+		block, err := s.next()
+		if err != nil {
+			s.mu.Lock()
+			s.err = err
+			s.mu.Unlock()
+			return
+		}
+		if block == nil {
+			return
+		}
+		stream <- block
+	}
+}
@@ -0,0 +1,143 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+
+	chproto "github.com/ClickHouse/ch-go/proto"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func spoolBlock(t *testing.T, values ...uint64) *proto.Block {
+	block := &proto.Block{Packet: proto.ServerData}
+	require.NoError(t, block.AddColumn("n", "UInt64"))
+	for _, v := range values {
+		require.NoError(t, block.Append(v))
+	}
+	return block
+}
+
+func TestSpool(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		conn   = &connect{revision: proto.DBMS_TCP_PROTOCOL_VERSION, debugf: func(string, ...interface{}) {}}
+		s      = conn.newSpool(SpoolOptions{Dir: dir, MemoryBlocks: 1}, proto.Block{})
+		stream = make(chan *proto.Block)
+	)
+	for i := uint64(0); i < 4; i++ {
+		s.push(spoolBlock(t, i, i*10))
+	}
+	assert.Equal(t, 1, s.inMemory)
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	go s.feed(stream)
+	var values []uint64
+	read := func() {
+		block := <-stream
+		require.NotNil(t, block)
+		assert.Equal(t, byte(proto.ServerData), block.Packet)
+		for i := 0; i < block.Rows(); i++ {
+			values = append(values, block.Columns[0].Row(i, false).(uint64))
+		}
+	}
+	for i := 0; i < 4; i++ {
+		read()
+	}
+	// the file was read, the next block is written from its start
+	s.push(spoolBlock(t, 4))
+	s.push(spoolBlock(t, 5))
+	s.finish()
+	read()
+	read()
+	_, ok := <-stream
+	assert.False(t, ok)
+	assert.Equal(t, []uint64{0, 0, 1, 10, 2, 20, 3, 30, 4, 5}, values)
+	assert.NoError(t, s.failure())
+	files, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestSpoolDiscard(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		conn   = &connect{revision: proto.DBMS_TCP_PROTOCOL_VERSION, debugf: func(string, ...interface{}) {}}
+		s      = conn.newSpool(SpoolOptions{Dir: dir, MemoryBlocks: 1}, proto.Block{})
+		stream = make(chan *proto.Block)
+	)
+	s.push(spoolBlock(t, 1))
+	s.push(spoolBlock(t, 2))
+	go s.feed(stream)
+	<-stream
+	s.discard()
+	s.push(spoolBlock(t, 3))
+	for range stream {
+	}
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestQueryWithSpool(t *testing.T) {
+	var server chproto.Buffer
+	for i := uint64(0); i < 5; i++ {
+		server.PutByte(proto.ServerData)
+		server.PutString("")
+		require.NoError(t, spoolBlock(t, i).Encode(&server, proto.DBMS_MIN_REVISION_WITH_QUOTA_KEY_IN_CLIENT_INFO))
+	}
+	server.PutByte(proto.ServerEndOfStream)
+
+	opt := &Options{
+		DialContext: func(context.Context, string) (net.Conn, error) {
+			return &replayConn{data: fakeServer(server.Buf...)}, nil
+		},
+	}
+	opt.setDefaults()
+	conn, err := dial(context.Background(), "127.0.0.1:9000", 1, opt)
+	require.NoError(t, err)
+
+	var (
+		dir      = t.TempDir()
+		released = make(chan error, 1)
+		ctx      = Context(context.Background(), WithSpool(SpoolOptions{Dir: dir, MemoryBlocks: 1}))
+	)
+	rows, err := conn.query(ctx, func(_ *connect, err error) { released <- err }, "SELECT n FROM t")
+	require.NoError(t, err)
+	// the connection is released before the rows are read
+	require.NoError(t, <-released)
+
+	var values []uint64
+	for rows.Next() {
+		var n uint64
+		require.NoError(t, rows.Scan(&n))
+		values = append(values, n)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []uint64{0, 1, 2, 3, 4}, values)
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
@@ -0,0 +1,283 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var errStatementUnknown = errors.New("clickhouse: cannot classify statement")
+
+// StatementType is the class of a statement as reported by StatementKind.
+type StatementType uint8
+
+const (
+	// StatementRead does not change data or schema, e.g. SELECT, SHOW, DESCRIBE or EXPLAIN.
+	StatementRead StatementType = iota
+	// StatementWrite changes data, e.g. INSERT, DELETE, OPTIMIZE, and any statement which is neither a read nor DDL.
+	StatementWrite
+	// StatementDDL changes schema or grants, e.g. CREATE, ALTER, DROP or GRANT.
+	StatementDDL
+	// StatementUnknown is returned for a query without a leading keyword.
+	StatementUnknown
+)
+
+func (t StatementType) String() string {
+	switch t {
+	case StatementRead:
+		return "read"
+	case StatementWrite:
+		return "write"
+	case StatementDDL:
+		return "ddl"
+	}
+	return "unknown"
+}
+
+var statementKeywords = map[string]StatementType{
+	"SELECT":   StatementRead,
+	"WITH":     StatementRead,
+	"SHOW":     StatementRead,
+	"DESCRIBE": StatementRead,
+	"DESC":     StatementRead,
+	"EXPLAIN":  StatementRead,
+	"WATCH":    StatementRead,
+	"EXISTS":   StatementRead,
+	"CHECK":    StatementRead,
+	"SET":      StatementRead,
+	"USE":      StatementRead,
+	"CREATE":   StatementDDL,
+	"ALTER":    StatementDDL,
+	"DROP":     StatementDDL,
+	"RENAME":   StatementDDL,
+	"TRUNCATE": StatementDDL,
+	"ATTACH":   StatementDDL,
+	"DETACH":   StatementDDL,
+	"EXCHANGE": StatementDDL,
+	"UNDROP":   StatementDDL,
+	"GRANT":    StatementDDL,
+	"REVOKE":   StatementDDL,
+}
+
+// StatementKind classifies query by its first keyword, ignoring comments and leading parentheses.
+// It is a lightweight check meant for routing and metrics, not a SQL parser.
+func StatementKind(query string) StatementType {
+	kind, err := classifyStatement(query)
+	if err != nil {
+		return StatementUnknown
+	}
+	return kind
+}
+
+func classifyStatement(query string) (StatementType, error) {
+	for _, t := range lexStatement(query) {
+		switch {
+		case t.text == "(":
+			continue
+		case t.kind != tokenWord:
+			return StatementUnknown, errStatementUnknown
+		}
+		if kind, found := statementKeywords[strings.ToUpper(t.text)]; found {
+			return kind, nil
+		}
+		return StatementWrite, nil
+	}
+	return StatementUnknown, errStatementUnknown
+}
+
+// TablesReferenced returns the tables named by query, e.g. after FROM, JOIN, INTO or TABLE, as
+// "table" or "database.table" in order of appearance. Subqueries, table functions and common table
+// expressions are not reported. Like StatementKind, it is a heuristic and not a SQL parser.
+func TablesReferenced(query string) []string {
+	var (
+		tokens = lexStatement(query)
+		ctes   = make(map[string]bool)
+		seen   = make(map[string]bool)
+		tables []string
+		calls  []bool // for each open parenthesis, whether it is a function call
+	)
+	for i := 0; i+2 < len(tokens); i++ {
+		if tokens[i].isName() && tokens[i+1].is("AS") && tokens[i+2].text == "(" {
+			ctes[tokens[i].text] = true
+		}
+	}
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch t.text {
+		case "(":
+			calls = append(calls, i > 0 && tokens[i-1].isName() && !parenKeywords[strings.ToUpper(tokens[i-1].text)])
+			continue
+		case ")":
+			if len(calls) != 0 {
+				calls = calls[:len(calls)-1]
+			}
+			continue
+		}
+		if t.kind != tokenWord || (len(calls) != 0 && calls[len(calls)-1]) {
+			continue
+		}
+		keyword := strings.ToUpper(t.text)
+		switch keyword {
+		case "FROM", "JOIN", "INTO", "TABLE", "VIEW", "DICTIONARY":
+		case "DESCRIBE", "DESC", "TRUNCATE":
+			if i != 0 {
+				continue
+			}
+		default:
+			continue
+		}
+		for next := i + 1; ; {
+			name, end := tableName(tokens, next, keyword == "FROM" || keyword == "JOIN")
+			if len(name) != 0 && !ctes[name] && !seen[name] {
+				seen[name], tables = true, append(tables, name)
+			}
+			// FROM a, b
+			if keyword != "FROM" || end >= len(tokens) || tokens[end].text != "," || len(name) == 0 {
+				break
+			}
+			next = end + 1
+		}
+	}
+	return tables
+}
+
+// parenKeywords may precede a parenthesis which is not a function call.
+var parenKeywords = map[string]bool{
+	"IN": true, "FROM": true, "JOIN": true, "AS": true, "EXISTS": true, "AND": true, "OR": true,
+	"NOT": true, "ON": true, "WHERE": true, "HAVING": true, "SELECT": true, "UNION": true, "ALL": true,
+	"DISTINCT": true, "VALUES": true, "USING": true, "WITH": true, "INTO": true, "TABLE": true,
+}
+
+// tableName reads the table name starting at tokens[i], returning it and the index of the token after it.
+func tableName(tokens []token, i int, skipFunctions bool) (string, int) {
+	for i < len(tokens) && tokens[i].kind == tokenWord {
+		switch strings.ToUpper(tokens[i].text) {
+		case "TABLE", "IF", "NOT", "EXISTS", "TEMPORARY", "OR", "REPLACE":
+			i++
+			continue
+		case "FUNCTION", "SELECT", "WITH", "VALUES", "FORMAT":
+			return "", i
+		}
+		break
+	}
+	if i >= len(tokens) || !tokens[i].isName() {
+		return "", i
+	}
+	name := tokens[i].text
+	i++
+	if i+1 < len(tokens) && tokens[i].text == "." && tokens[i+1].isName() {
+		name, i = name+"."+tokens[i+1].text, i+2
+	}
+	if skipFunctions && i < len(tokens) && tokens[i].text == "(" {
+		return "", i
+	}
+	return name, i
+}
+
+type tokenKind uint8
+
+const (
+	tokenWord tokenKind = iota
+	tokenQuoted
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// pos and end are the byte offsets of the token in the query
+	pos, end int
+}
+
+func (t token) isName() bool {
+	return t.kind == tokenWord || t.kind == tokenQuoted
+}
+
+func (t token) is(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+// lexStatement splits query into words, quoted identifiers and punctuation, dropping comments,
+// string literals and numbers.
+func lexStatement(query string) (tokens []token) {
+	isWord := func(r byte) bool {
+		return r == '_' || r == '$' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= 0x80
+	}
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case strings.HasPrefix(query[i:], "--") || c == '#':
+			end := strings.IndexByte(query[i:], '\n')
+			if end == -1 {
+				return tokens
+			}
+			i += end + 1
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end == -1 {
+				return tokens
+			}
+			i += end + 4
+		case c == '\'' || c == '`' || c == '"':
+			var (
+				text strings.Builder
+				j    = i + 1
+			)
+			for ; j < len(query); j++ {
+				if query[j] == '\\' && j+1 < len(query) {
+					j++
+					text.WriteByte(query[j])
+					continue
+				}
+				if query[j] == c {
+					if j+1 < len(query) && query[j+1] == c {
+						j++
+						text.WriteByte(c)
+						continue
+					}
+					break
+				}
+				text.WriteByte(query[j])
+			}
+			if c != '\'' {
+				tokens = append(tokens, token{kind: tokenQuoted, text: text.String(), pos: i, end: j + 1})
+			}
+			i = j + 1
+		case c >= '0' && c <= '9':
+			for i < len(query) && (isWord(query[i]) || query[i] == '.') {
+				i++
+			}
+		case isWord(c):
+			start := i
+			for i < len(query) && isWord(query[i]) {
+				i++
+			}
+			if word := query[start:i]; unicode.IsLetter([]rune(word)[0]) || word[0] == '_' {
+				tokens = append(tokens, token{kind: tokenWord, text: word, pos: start, end: i})
+			}
+		default:
+			tokens = append(tokens, token{kind: tokenPunct, text: query[i : i+1], pos: i, end: i + 1})
+			i++
+		}
+	}
+	return tokens
+}
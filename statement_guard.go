@@ -0,0 +1,47 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"errors"
+)
+
+var (
+	ErrReadOnly      = errors.New("clickhouse: only read statements are allowed on a read-only client")
+	ErrDDLNotAllowed = errors.New("clickhouse: DDL statements are not allowed on this client")
+)
+
+// checkStatement rejects query if the client is ReadOnly or DisallowDDL and the statement is not allowed.
+func (o *Options) checkStatement(query string) error {
+	if o == nil || (!o.ReadOnly && !o.DisallowDDL) {
+		return nil
+	}
+	kind, err := classifyStatement(query)
+	switch {
+	case err != nil && o.ReadOnly:
+		return ErrReadOnly
+	case err != nil:
+		// leave statements which cannot be classified to the server
+		return nil
+	case o.ReadOnly && kind != StatementRead:
+		return ErrReadOnly
+	case o.DisallowDDL && kind == StatementDDL:
+		return ErrDDLNotAllowed
+	}
+	return nil
+}
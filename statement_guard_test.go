@@ -0,0 +1,69 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckStatement(t *testing.T) {
+	var none *Options
+	assert.NoError(t, none.checkStatement("DROP TABLE t"))
+
+	readOnly := &Options{ReadOnly: true}
+	assert.NoError(t, readOnly.checkStatement("SELECT 1"))
+	assert.ErrorIs(t, readOnly.checkStatement("INSERT INTO t VALUES"), ErrReadOnly)
+	assert.ErrorIs(t, readOnly.checkStatement("DROP TABLE t"), ErrReadOnly)
+	assert.ErrorIs(t, readOnly.checkStatement(""), ErrReadOnly)
+	assert.Equal(t, 2, readOnly.setDefaults().Settings["readonly"])
+
+	noDDL := &Options{DisallowDDL: true}
+	assert.NoError(t, noDDL.checkStatement("INSERT INTO t VALUES"))
+	assert.ErrorIs(t, noDDL.checkStatement("TRUNCATE TABLE t"), ErrDDLNotAllowed)
+	assert.NoError(t, noDDL.checkStatement(""))
+}
+
+func TestStdStatementGuardOrder(t *testing.T) {
+	var (
+		// as with intercept, the error of an invalid query option comes before the statement guard
+		std   = &stdDriver{opt: (&Options{ReadOnly: true}).setDefaults()}
+		ctx   = Context(context.Background(), WithSampling(1.5, 0))
+		query = "INSERT INTO t VALUES"
+		errs  []error
+	)
+	_, err := std.ExecContext(ctx, query, nil)
+	errs = append(errs, err)
+	_, err = std.QueryContext(ctx, query, nil)
+	errs = append(errs, err)
+	_, err = std.PrepareContext(ctx, query)
+	errs = append(errs, err)
+	_, err = std.PrepareBatch(ctx, query)
+	errs = append(errs, err)
+	_, err = std.Query(ctx, query)
+	errs = append(errs, err)
+	errs = append(errs, std.Exec(ctx, query))
+	_, err = std.ExportNative(ctx, io.Discard, query)
+	errs = append(errs, err)
+	for _, err := range errs {
+		assert.ErrorContains(t, err, "invalid sampling ratio 1.5")
+	}
+}
@@ -0,0 +1,82 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyStatement(t *testing.T) {
+	for query, expected := range map[string]StatementType{
+		"SELECT 1":                                  StatementRead,
+		"  (select 1) UNION ALL (SELECT 2)":         StatementRead,
+		"WITH 1 AS x SELECT x":                      StatementRead,
+		"WATCH lv LIMIT 1":                          StatementRead,
+		"/* comment */ -- line\n  DESCRIBE TABLE t": StatementRead,
+		"INSERT INTO t VALUES":                      StatementWrite,
+		"OPTIMIZE TABLE t FINAL":                    StatementWrite,
+		"DELETE FROM t WHERE 1":                     StatementWrite,
+		"SYSTEM FLUSH LOGS":                         StatementWrite,
+		"create table t (x UInt8) Engine Memory":    StatementDDL,
+		"ALTER TABLE t DELETE WHERE 1":              StatementDDL,
+		"# comment\nDROP TABLE t":                   StatementDDL,
+	} {
+		kind, err := classifyStatement(query)
+		if assert.NoError(t, err, query) {
+			assert.Equal(t, expected, kind, query)
+		}
+	}
+	_, err := classifyStatement("/* unterminated SELECT 1")
+	assert.Error(t, err)
+}
+
+func TestStatementKind(t *testing.T) {
+	assert.Equal(t, StatementRead, StatementKind("SELECT 1"))
+	assert.Equal(t, StatementDDL, StatementKind("DROP TABLE t"))
+	assert.Equal(t, StatementWrite, StatementKind("INSERT INTO t VALUES"))
+	assert.Equal(t, StatementUnknown, StatementKind(""))
+	assert.Equal(t, StatementUnknown, StatementKind("'string'"))
+	assert.Equal(t, "ddl", StatementDDL.String())
+}
+
+func TestTablesReferenced(t *testing.T) {
+	for query, expected := range map[string][]string{
+		"SELECT 1": nil,
+		"SELECT * FROM events WHERE x = 'FROM fake'":                                 {"events"},
+		"SELECT * FROM db.events AS e JOIN `other db`.`users` u ON e.id = u.id":      {"db.events", "other db.users"},
+		"SELECT * FROM a, b LEFT JOIN c USING id":                                    {"a", "b", "c"},
+		"SELECT * FROM (SELECT * FROM inner_table) WHERE id IN (SELECT id FROM ids)": {"inner_table", "ids"},
+		"SELECT * FROM numbers(10)":                                                  nil,
+		"SELECT extract(DAY FROM d), trim(BOTH ' ' FROM s) FROM dates":               {"dates"},
+		"WITH recent AS (SELECT * FROM events) SELECT * FROM recent JOIN users ON 1": {"events", "users"},
+		"INSERT INTO TABLE db.t (a, b) VALUES (1, 2)":                                {"db.t"},
+		"INSERT INTO t SELECT * FROM src":                                            {"t", "src"},
+		"INSERT INTO FUNCTION remote('host', db.t) VALUES":                           nil,
+		"CREATE TABLE IF NOT EXISTS t (x UInt8) Engine Memory":                       {"t"},
+		"DROP TABLE IF EXISTS t":                                                     {"t"},
+		"/* comment FROM x */ OPTIMIZE TABLE t FINAL":                                {"t"},
+		"DESCRIBE events":                                         {"events"},
+		"SELECT x FROM t ORDER BY x DESC LIMIT 1":                 {"t"},
+		"CREATE MATERIALIZED VIEW mv TO dst AS SELECT * FROM src": {"mv", "src"},
+		"SELECT * FROM t1 JOIN t1 ON 1":                           {"t1"},
+	} {
+		assert.Equal(t, expected, TablesReferenced(query), query)
+	}
+}
@@ -0,0 +1,157 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+)
+
+// The types below are database/sql Scanner and Valuer adapters for complex column types. They allow
+// ORMs such as GORM and sqlx, which require struct fields to implement sql.Scanner, to read and write
+// Array, Map, Tuple and Nullable columns through the database/sql interface.
+type (
+	// Array maps an Array(T) column.
+	Array[T any] []T
+	// Map maps a Map(K, V) column.
+	Map[K comparable, V any] map[K]V
+	// Tuple maps an unnamed Tuple column.
+	Tuple []interface{}
+	// Nullable maps a Nullable(T) column. Valid is false for NULL.
+	Nullable[T any] struct {
+		V     T
+		Valid bool
+	}
+)
+
+// stdValue is implemented by the adapters so that batch inserts can unwrap them into the
+// plain Go types expected by the column encoders.
+type stdValue interface {
+	driver.Valuer
+	stdValue()
+}
+
+func (Array[T]) stdValue()    {}
+func (Map[K, V]) stdValue()   {}
+func (Tuple) stdValue()       {}
+func (Nullable[T]) stdValue() {}
+
+func (a Array[T]) Value() (driver.Value, error) {
+	return []T(a), nil
+}
+
+func (a *Array[T]) Scan(src interface{}) error {
+	if isNilValue(src) {
+		*a = nil
+		return nil
+	}
+	var v []T
+	if err := assignScan(&v, src); err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+func (m Map[K, V]) Value() (driver.Value, error) {
+	return map[K]V(m), nil
+}
+
+func (m *Map[K, V]) Scan(src interface{}) error {
+	if isNilValue(src) {
+		*m = nil
+		return nil
+	}
+	var v map[K]V
+	if err := assignScan(&v, src); err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+func (t Tuple) Value() (driver.Value, error) {
+	return []interface{}(t), nil
+}
+
+func (t *Tuple) Scan(src interface{}) error {
+	if isNilValue(src) {
+		*t = nil
+		return nil
+	}
+	var v []interface{}
+	if err := assignScan(&v, src); err != nil {
+		return err
+	}
+	*t = v
+	return nil
+}
+
+func (n Nullable[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.V, nil
+}
+
+func (n *Nullable[T]) Scan(src interface{}) error {
+	var zero T
+	if isNilValue(src) {
+		n.V, n.Valid = zero, false
+		return nil
+	}
+	if err := assignScan(&n.V, src); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+func isNilValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func assignScan(dest interface{}, src interface{}) error {
+	var (
+		dv = reflect.ValueOf(dest).Elem()
+		sv = reflect.Indirect(reflect.ValueOf(src))
+	)
+	switch {
+	case sv.Type().AssignableTo(dv.Type()):
+		dv.Set(sv)
+	case sv.Type().ConvertibleTo(dv.Type()):
+		dv.Set(sv.Convert(dv.Type()))
+	default:
+		return &column.ColumnConverterError{
+			Op:   "Scan",
+			From: fmt.Sprintf("%T", src),
+			To:   fmt.Sprintf("%T", dest),
+		}
+	}
+	return nil
+}
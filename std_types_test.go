@@ -0,0 +1,66 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdTypes(t *testing.T) {
+	t.Run("array", func(t *testing.T) {
+		var a Array[string]
+		require.NoError(t, a.Scan([]string{"a", "b"}))
+		assert.Equal(t, Array[string]{"a", "b"}, a)
+		v, err := a.Value()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, v)
+		require.NoError(t, a.Scan(nil))
+		assert.Nil(t, a)
+		assert.Error(t, a.Scan([]int{1}))
+	})
+	t.Run("map", func(t *testing.T) {
+		var m Map[string, uint64]
+		require.NoError(t, m.Scan(map[string]uint64{"a": 1}))
+		assert.Equal(t, Map[string, uint64]{"a": 1}, m)
+		v, err := m.Value()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]uint64{"a": 1}, v)
+	})
+	t.Run("tuple", func(t *testing.T) {
+		var tuple Tuple
+		require.NoError(t, tuple.Scan([]interface{}{"a", uint8(1)}))
+		assert.Equal(t, Tuple{"a", uint8(1)}, tuple)
+	})
+	t.Run("nullable", func(t *testing.T) {
+		var n Nullable[int64]
+		value := int64(42)
+		require.NoError(t, n.Scan(&value))
+		assert.Equal(t, Nullable[int64]{V: 42, Valid: true}, n)
+		var null *int64
+		require.NoError(t, n.Scan(null))
+		assert.False(t, n.Valid)
+		v, err := n.Value()
+		require.NoError(t, err)
+		assert.Nil(t, v)
+		require.NoError(t, n.Scan(int32(7)))
+		assert.Equal(t, int64(7), n.V)
+	})
+}
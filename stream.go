@@ -0,0 +1,62 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// StreamStruct executes query and decodes every row into a T (using ScanStruct) which is sent to
+// the returned channel. Both channels are closed once the rows are exhausted; the error channel
+// receives at most one error. Cancelling ctx stops the stream. The caller must drain the row
+// channel or cancel ctx to release the connection.
+func StreamStruct[T any](ctx context.Context, conn driver.Conn, bufferSize int, query string, args ...interface{}) (<-chan T, <-chan error) {
+	var (
+		out  = make(chan T, bufferSize)
+		errs = make(chan error, 1)
+	)
+	go func() {
+		defer close(errs)
+		defer close(out)
+		rows, err := conn.Query(ctx, query, args...)
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var v T
+			if err := rows.ScanStruct(&v); err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			errs <- err
+		}
+	}()
+	return out, errs
+}
@@ -28,6 +28,12 @@ type structMap struct {
 }
 
 func (m *structMap) Map(op string, columns []string, s interface{}, ptr bool) ([]interface{}, error) {
+	return m.MapColumns(op, columns, s, ptr, nil)
+}
+
+// MapColumns is Map with column names translated by mapping. When scanning (ptr is true) and
+// mapping.IgnoreUnknownColumns is set, columns without a destination field are skipped.
+func (m *structMap) MapColumns(op string, columns []string, s interface{}, ptr bool, mapping *ColumnMapping) ([]interface{}, error) {
 	v := reflect.ValueOf(s)
 	if v.Kind() != reflect.Ptr {
 		return nil, &OpError{
@@ -65,7 +71,14 @@ func (m *structMap) Map(op string, columns []string, s interface{}, ptr bool) ([
 		m.cache.Store(t, index)
 	}
 	for _, name := range columns {
+		if field, ok := mapping.field(name); ok {
+			name = field
+		}
 		idx, found := index[name]
+		if !found && ptr && mapping != nil && mapping.IgnoreUnknownColumns {
+			values = append(values, skipColumn)
+			continue
+		}
 		if !found {
 			return nil, &OpError{
 				Op:  op,
@@ -82,6 +95,27 @@ func (m *structMap) Map(op string, columns []string, s interface{}, ptr bool) ([
 	return values, nil
 }
 
+// ColumnMapping controls how result and insert columns are matched to struct fields.
+type ColumnMapping struct {
+	// Fields maps a column name to the name of the destination struct field (or its ch tag).
+	Fields map[string]string
+	// IgnoreUnknownColumns skips result columns which have no destination field instead of failing ScanStruct.
+	IgnoreUnknownColumns bool
+}
+
+func (m *ColumnMapping) field(column string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	field, ok := m.Fields[column]
+	return field, ok
+}
+
+type discard struct{}
+
+// skipColumn is a Scan destination which discards the value of its column.
+var skipColumn = &discard{}
+
 func structIdx(t reflect.Type) map[string][]int {
 	fields := make(map[string][]int)
 	for i := 0; i < t.NumField(); i++ {
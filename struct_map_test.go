@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestStructIdx(t *testing.T) {
@@ -78,6 +79,31 @@ func TestMapper(t *testing.T) {
 	t.Log(values, err)
 }
 
+func TestStructMapColumnMapping(t *testing.T) {
+	type Example struct {
+		ID   uint64 `ch:"id"`
+		Name string
+	}
+	var (
+		mapper  = structMap{}
+		example = Example{ID: 1, Name: "a"}
+		mapping = &ColumnMapping{
+			Fields: map[string]string{"user_name": "Name"},
+		}
+	)
+	values, err := mapper.MapColumns("AppendStruct", []string{"id", "user_name"}, &example, false, mapping)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{uint64(1), "a"}, values)
+
+	_, err = mapper.MapColumns("ScanStruct", []string{"id", "extra"}, &example, true, mapping)
+	assert.Error(t, err)
+
+	mapping.IgnoreUnknownColumns = true
+	values, err = mapper.MapColumns("ScanStruct", []string{"id", "extra", "user_name"}, &example, true, mapping)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{&example.ID, skipColumn, &example.Name}, values)
+}
+
 func BenchmarkStructMap(b *testing.B) {
 	type Embed2 struct {
 		Col6 uint8
@@ -0,0 +1,57 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquireInfo(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	var (
+		mu    sync.Mutex
+		infos []clickhouse.AcquireInfo
+	)
+	options := clientOptionsFromEnv(env, nil)
+	options.OnAcquire = func(ctx context.Context, info clickhouse.AcquireInfo) {
+		mu.Lock()
+		defer mu.Unlock()
+		infos = append(infos, info)
+	}
+	conn, err := GetConnectionWithOptions(&options)
+	require.NoError(t, err)
+	var (
+		stats clickhouse.Statistics
+		ctx   = clickhouse.Context(context.Background(), clickhouse.WithStatistics(&stats))
+	)
+	require.NoError(t, conn.Exec(ctx, "SELECT 1"))
+	assert.Equal(t, options.Addr[0], stats.Acquire.Addr)
+	assert.NoError(t, stats.Acquire.Err)
+	assert.False(t, stats.Acquire.Dialed, "the connection dialed by GetConnectionWithOptions is reused")
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotEmpty(t, infos)
+	assert.Equal(t, stats.Acquire, infos[len(infos)-1])
+}
@@ -0,0 +1,108 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/backfill"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfill(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	for _, table := range []string{"test_backfill_src", "test_backfill_dst"} {
+		require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS "+table))
+		require.NoError(t, conn.Exec(ctx, `
+			CREATE TABLE `+table+` (
+				  ts DateTime('UTC')
+				, value UInt64
+			) Engine MergeTree() PARTITION BY toYYYYMM(ts) ORDER BY ts
+		`))
+		defer conn.Exec(ctx, "DROP TABLE "+table)
+	}
+	require.NoError(t, conn.Exec(ctx, `
+		INSERT INTO test_backfill_src
+		SELECT toDateTime('2023-01-01 00:00:00', 'UTC') + INTERVAL number HOUR, number FROM numbers(24 * 90)
+	`))
+	var progress []backfill.Progress
+	results, err := backfill.Run(ctx, conn, backfill.Config{
+		Query: "INSERT INTO test_backfill_dst SELECT * FROM test_backfill_src WHERE ts >= @from AND ts < @to",
+		From:  time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:    time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC),
+		OnProgress: func(p backfill.Progress) {
+			progress = append(progress, p)
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	var total uint64
+	for _, result := range results {
+		total += result.WrittenRows
+	}
+	assert.Equal(t, uint64(24*90), total)
+	assert.Equal(t, backfill.StateDone, progress[len(progress)-1].State)
+	var count uint64
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count() FROM test_backfill_dst").Scan(&count))
+	assert.Equal(t, uint64(24*90), count)
+}
+
+func TestBackfillView(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	defer func() {
+		conn.Exec(ctx, "DROP VIEW IF EXISTS test_backfill_mv")
+		conn.Exec(ctx, "DROP TABLE IF EXISTS test_backfill_view_dst")
+		conn.Exec(ctx, "DROP TABLE IF EXISTS test_backfill_view_src")
+	}()
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_backfill_view_src (
+			  ts DateTime('UTC')
+			, value UInt64
+		) Engine MergeTree() PARTITION BY toStartOfHour(ts) ORDER BY ts
+	`))
+	require.NoError(t, conn.Exec(ctx, "INSERT INTO test_backfill_view_src SELECT now() - INTERVAL (number + 1) MINUTE, number FROM numbers(120)"))
+	var (
+		now = time.Now().UTC().Truncate(time.Second)
+		to  = now.Add(2 * time.Second)
+	)
+	results, err := backfill.RunView(ctx, conn, backfill.View{
+		Name:       "test_backfill_mv",
+		Target:     "test_backfill_view_dst",
+		TargetDDL:  "CREATE TABLE IF NOT EXISTS test_backfill_view_dst (ts DateTime('UTC'), doubled UInt64) Engine MergeTree ORDER BY ts",
+		Source:     "test_backfill_view_src",
+		Select:     "SELECT ts, value * 2 AS doubled FROM {source}",
+		TimeColumn: "ts",
+	}, backfill.Config{
+		From:  now.Add(-3 * time.Hour),
+		To:    to,
+		Split: backfill.Every(time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Len(t, results, 4)
+	require.NoError(t, conn.Exec(ctx, "INSERT INTO test_backfill_view_src VALUES (now(), 1000)"))
+	var count uint64
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count() FROM test_backfill_view_dst").Scan(&count))
+	assert.Equal(t, uint64(121), count)
+}
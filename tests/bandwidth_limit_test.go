@@ -0,0 +1,55 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchBandwidthLimit(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_bandwidth_limit"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_bandwidth_limit (
+			  Col1 UInt64
+		) Engine MergeTree() ORDER BY tuple()
+	`))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_bandwidth_limit")
+	}()
+	batch, err := conn.PrepareBatch(clickhouse.Context(ctx, clickhouse.WithBandwidthLimit(8*1024)), "INSERT INTO test_bandwidth_limit")
+	require.NoError(t, err)
+	for i := 0; i < 2048; i++ {
+		require.NoError(t, batch.Append(uint64(i)))
+	}
+	start := time.Now()
+	require.NoError(t, batch.Send())
+	// 16KiB of data at 8KiB/s
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	var count uint64
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count() FROM test_bandwidth_limit").Scan(&count))
+	assert.Equal(t, uint64(2048), count)
+}
@@ -0,0 +1,60 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchReset(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_batch_reset"))
+	require.NoError(t, conn.Exec(ctx, "CREATE TABLE test_batch_reset (Col1 UInt64, Col2 String) Engine MergeTree() ORDER BY tuple()"))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_batch_reset")
+	}()
+	batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_batch_reset")
+	require.NoError(t, err)
+	for cycle := 0; cycle < 3; cycle++ {
+		for i := 0; i < 10; i++ {
+			require.NoError(t, batch.Append(uint64(cycle*10+i), "value"))
+		}
+		require.NoError(t, batch.Send())
+		assert.ErrorIs(t, batch.Append(uint64(0), "value"), clickhouse.ErrBatchAlreadySent)
+		require.NoError(t, batch.(driver.ResettableBatch).Reset())
+	}
+	require.NoError(t, batch.Column(0).Append([]uint64{30, 31}))
+	require.NoError(t, batch.Column(1).Append([]string{"a", "b"}))
+	require.NoError(t, batch.Flush())
+	require.NoError(t, batch.Send())
+	var (
+		count uint64
+		sum   uint64
+	)
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count(), sum(Col1) FROM test_batch_reset").Scan(&count, &sum))
+	assert.Equal(t, uint64(32), count)
+	assert.Equal(t, uint64(31*32/2), sum)
+}
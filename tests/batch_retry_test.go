@@ -0,0 +1,110 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchRetry(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, &clickhouse.Compression{
+		Method: clickhouse.CompressionLZ4,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_batch_retry"))
+	require.NoError(t, conn.Exec(ctx, "CREATE TABLE test_batch_retry (Col1 UInt64) Engine MergeTree() ORDER BY tuple()"))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_batch_retry")
+	}()
+	batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_batch_retry")
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, batch.Append(uint64(i)))
+	}
+	retryable, ok := batch.(driver.RetryableBatch)
+	require.True(t, ok)
+	assert.ErrorIs(t, retryable.Retry(ctx), clickhouse.ErrBatchNotSent)
+	require.NoError(t, batch.Send())
+	assert.ErrorIs(t, retryable.Retry(ctx), clickhouse.ErrBatchAlreadySent)
+	var count uint64
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count() FROM test_batch_retry").Scan(&count))
+	assert.Equal(t, uint64(10), count)
+}
+
+// failingConn fails its writes once fail is set, breaking the connection in the middle of an insert.
+type failingConn struct {
+	net.Conn
+	fail *int32
+}
+
+func (c *failingConn) Write(b []byte) (int, error) {
+	if atomic.LoadInt32(c.fail) != 0 {
+		c.Conn.Close()
+		return 0, errors.New("connection broken by the test")
+	}
+	return c.Conn.Write(b)
+}
+
+func TestBatchRetryAfterFailedSend(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	var fail int32
+	options := clientOptionsFromEnv(env, nil)
+	options.DialContext = func(ctx context.Context, addr string) (net.Conn, error) {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		return &failingConn{Conn: conn, fail: &fail}, nil
+	}
+	if options.TLS != nil {
+		t.Skip("custom dial does not use TLS")
+	}
+	conn, err := GetConnectionWithOptions(&options)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_batch_retry_failed"))
+	require.NoError(t, conn.Exec(ctx, "CREATE TABLE test_batch_retry_failed (Col1 UInt64) Engine MergeTree() ORDER BY tuple()"))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_batch_retry_failed")
+	}()
+	batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_batch_retry_failed")
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, batch.Append(uint64(i)))
+	}
+	atomic.StoreInt32(&fail, 1)
+	require.Error(t, batch.Send())
+	atomic.StoreInt32(&fail, 0)
+
+	require.NoError(t, batch.(driver.RetryableBatch).Retry(ctx))
+	var count uint64
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count() FROM test_batch_retry_failed").Scan(&count))
+	assert.Equal(t, uint64(10), count)
+}
@@ -0,0 +1,119 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchWriter(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, &clickhouse.Compression{
+		Method: clickhouse.CompressionLZ4,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_batch_writer"))
+	require.NoError(t, conn.Exec(ctx, "CREATE TABLE test_batch_writer (Col1 UInt64, Col2 String) Engine MergeTree() ORDER BY tuple()"))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_batch_writer")
+	}()
+	writer, err := clickhouse.NewBatchWriter(ctx, conn, "INSERT INTO test_batch_writer", 4)
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				assert.NoError(t, writer.Append(uint64(g*1000+i), RandAsciiString(5)))
+				if i == 500 && g == 0 {
+					assert.NoError(t, writer.Flush())
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	require.NoError(t, writer.Send())
+	assert.True(t, writer.IsSent())
+	assert.ErrorIs(t, writer.Append(uint64(0), ""), clickhouse.ErrBatchAlreadySent)
+	var count, distinct uint64
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count(), uniqExact(Col1) FROM test_batch_writer").Scan(&count, &distinct))
+	assert.Equal(t, uint64(8000), count)
+	assert.Equal(t, uint64(8000), distinct)
+}
+
+func TestBatchWriterInterceptors(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	var (
+		mu          sync.Mutex
+		invalidated []string
+		sent        int
+		options     = clientOptionsFromEnv(env, nil)
+	)
+	options.Interceptors = []clickhouse.Interceptor{
+		clickhouse.SlowQueryInterceptor(0, func(_ context.Context, q clickhouse.SlowQuery) {
+			if q.Kind == clickhouse.OperationPrepareBatch {
+				mu.Lock()
+				defer mu.Unlock()
+				sent++
+			}
+		}),
+		clickhouse.InvalidationInterceptor(func(_ context.Context, tables []string) {
+			mu.Lock()
+			defer mu.Unlock()
+			invalidated = append(invalidated, tables...)
+		}),
+	}
+	conn, err := GetConnectionWithOptions(&options)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_batch_writer_interceptors"))
+	require.NoError(t, conn.Exec(ctx, "CREATE TABLE test_batch_writer_interceptors (Col1 UInt64) Engine MergeTree() ORDER BY tuple()"))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_batch_writer_interceptors")
+	}()
+	invalidated = nil
+
+	writer, err := clickhouse.NewBatchWriter(ctx, conn, "INSERT INTO test_batch_writer_interceptors", 2)
+	require.NoError(t, err)
+	require.NoError(t, writer.Append(uint64(1)))
+	require.NoError(t, writer.Send())
+	assert.Equal(t, []string{"test_batch_writer_interceptors"}, invalidated, "the writer is sent through the interceptors")
+
+	batch, err := clickhouse.NewValidatingBatch(ctx, conn, "INSERT INTO test_batch_writer_interceptors", clickhouse.ValidationOptions{})
+	require.NoError(t, err)
+	require.NoError(t, batch.Append(uint64(2)))
+	require.NoError(t, batch.Append("two"))
+	report, err := batch.Send()
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Appended)
+	assert.Len(t, report.Rejected, 1)
+	assert.Len(t, invalidated, 2)
+	assert.Equal(t, 2, sent)
+
+	var count uint64
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count() FROM test_batch_writer_interceptors").Scan(&count))
+	assert.Equal(t, uint64(2), count)
+}
@@ -195,3 +195,58 @@ func TestBoolFlush(t *testing.T) {
 		i += 1
 	}
 }
+
+func TestBoolMapping(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	options := clientOptionsFromEnv(env, nil)
+	options.BoolMapping = true
+	conn, err := GetConnectionWithOptions(&options)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_bool_mapping"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_bool_mapping (
+			  id     UInt64
+			, flag   UInt8
+			, signed Int8
+			, maybe  Nullable(UInt8)
+			, native Bool
+		) Engine MergeTree() ORDER BY id
+	`))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_bool_mapping")
+	}()
+	batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_bool_mapping")
+	require.NoError(t, err)
+	require.NoError(t, batch.Append(uint64(1), true, false, nil, true))
+	require.NoError(t, batch.Append(uint64(2), uint8(2), int8(-1), true, false))
+	require.NoError(t, batch.Send())
+
+	rows, err := conn.Query(ctx, "SELECT flag, signed, maybe, native FROM test_bool_mapping ORDER BY id")
+	require.NoError(t, err)
+	var (
+		flags, signed, native []bool
+		maybe                 []*bool
+	)
+	for rows.Next() {
+		var (
+			f, s, n bool
+			m       *bool
+		)
+		require.NoError(t, rows.Scan(&f, &s, &m, &n))
+		flags, signed, maybe, native = append(flags, f), append(signed, s), append(maybe, m), append(native, n)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []bool{true, true}, flags)
+	assert.Equal(t, []bool{false, true}, signed)
+	require.Len(t, maybe, 2)
+	assert.Nil(t, maybe[0])
+	require.NotNil(t, maybe[1])
+	assert.True(t, *maybe[1])
+	assert.Equal(t, []bool{true, false}, native)
+
+	var raw uint8
+	require.NoError(t, conn.QueryRow(ctx, "SELECT flag FROM test_bool_mapping WHERE id = 2").Scan(&raw))
+	assert.Equal(t, uint8(2), raw)
+}
@@ -0,0 +1,62 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelDrain(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	var dialCount int
+	options := clientOptionsFromEnv(env, nil)
+	options.MaxOpenConns, options.MaxIdleConns = 1, 1
+	options.CancelDrainTimeout = 10 * time.Second
+	options.DialContext = func(ctx context.Context, addr string) (net.Conn, error) {
+		dialCount++
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", addr)
+	}
+	if options.TLS != nil {
+		t.Skip("custom dial does not use TLS")
+	}
+	conn, err := GetConnectionWithOptions(&options)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Ping(ctx))
+	dialed := dialCount
+	queryCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		cancel()
+	}()
+	rows, err := conn.Query(queryCtx, "SELECT number FROM system.numbers")
+	require.NoError(t, err)
+	for rows.Next() {
+	}
+	assert.ErrorIs(t, rows.Err(), context.Canceled)
+	require.NoError(t, conn.Ping(ctx))
+	assert.Equal(t, dialed, dialCount)
+}
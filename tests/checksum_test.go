@@ -0,0 +1,55 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkipChecksumVerification(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	for _, skip := range []bool{false, true} {
+		options := clientOptionsFromEnv(env, clickhouse.Settings{})
+		options.Compression = &clickhouse.Compression{Method: clickhouse.CompressionZSTD}
+		options.SkipChecksumVerification = skip
+		options.OnCorruptedBlock = func(err *clickhouse.CorruptedBlockError, block []byte) {
+			t.Errorf("unexpected corrupted block: %s", err)
+		}
+		conn, err := GetConnectionWithOptions(&options)
+		require.NoError(t, err)
+		var count, sum uint64
+		require.NoError(t, conn.QueryRow(context.Background(), "SELECT count(), sum(number) FROM (SELECT number FROM system.numbers LIMIT 1000000)").Scan(&count, &sum))
+		assert.Equal(t, uint64(1000000), count)
+		assert.Equal(t, uint64(999999*1000000/2), sum)
+		rows, err := conn.Query(context.Background(), "SELECT toString(number) FROM system.numbers LIMIT 200000")
+		require.NoError(t, err)
+		var n int
+		for rows.Next() {
+			n++
+		}
+		require.NoError(t, rows.Err())
+		assert.Equal(t, 200000, n)
+		conn.Close()
+	}
+}
@@ -0,0 +1,80 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/chgen"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//go:generate go run ../chgen/cmd/chgen -schema resources/chgen_schema.sql -package tests -out chgen_models_test.go
+
+func TestChgen(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	schema, err := os.ReadFile("resources/chgen_schema.sql")
+	require.NoError(t, err)
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_chgen_events"))
+	require.NoError(t, conn.Exec(ctx, string(schema)))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_chgen_events")
+	}()
+
+	introspected, err := chgen.Introspect(ctx, conn, "test_chgen_events")
+	require.NoError(t, err)
+	parsed, err := chgen.ParseSchema(string(schema))
+	require.NoError(t, err)
+	require.Len(t, parsed, 1)
+	assert.Equal(t, parsed[0], introspected)
+
+	note := "first"
+	created := time.Date(2023, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	rows := []TestChgenEventsRow{
+		{ID: 1, UserID: 10, Name: "a", Note: &note, Tags: []string{"x", "y"}, Attrs: map[string]uint64{"k": 1}, Amount: decimal.New(12345, -2), CreatedAt: created},
+		{ID: 2, UserID: 20, Name: "b", Tags: []string{}, Attrs: map[string]uint64{}, Amount: decimal.Zero, CreatedAt: created},
+	}
+	require.NoError(t, InsertTestChgenEventsRows(ctx, conn, rows))
+
+	batch, err := conn.PrepareBatch(ctx, TestChgenEventsInsert)
+	require.NoError(t, err)
+	row := TestChgenEventsRow{ID: 3, Tags: []string{}, Attrs: map[string]uint64{}, CreatedAt: created}
+	require.NoError(t, row.Append(batch))
+	require.NoError(t, batch.Send())
+	rows = append(rows, row)
+
+	selected, err := SelectTestChgenEventsRows(ctx, conn, "ORDER BY id")
+	require.NoError(t, err)
+	require.Len(t, selected, 3)
+	for i := range rows {
+		assert.Equal(t, rows[i].ID, selected[i].ID)
+		assert.Equal(t, rows[i].Note, selected[i].Note)
+		assert.Equal(t, rows[i].Tags, selected[i].Tags)
+		assert.Equal(t, rows[i].Attrs, selected[i].Attrs)
+		assert.True(t, rows[i].Amount.Equal(selected[i].Amount))
+		assert.Equal(t, rows[i].CreatedAt, selected[i].CreatedAt.UTC())
+	}
+	assert.Equal(t, "a", selected[0].Name)
+}
@@ -0,0 +1,61 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipelineCollector(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_pipeline_collector"))
+	require.NoError(t, conn.Exec(ctx, "CREATE TABLE test_pipeline_collector (id UInt64, name String) Engine MergeTree ORDER BY id"))
+	defer conn.Exec(ctx, "DROP TABLE test_pipeline_collector")
+	collector := pipeline.NewCollector(conn, pipeline.CollectorConfig{
+		Config:        pipeline.Config{MaxRows: 1000, FlushInterval: 100 * time.Millisecond, Block: true},
+		MaxQueuedRows: 2000,
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				require.NoError(t, collector.Write(ctx, "test_pipeline_collector", uint64(i*500+j), "row"))
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, collector.Close(ctx))
+	var count uint64
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count() FROM test_pipeline_collector").Scan(&count))
+	assert.Equal(t, uint64(5000), count)
+	stats := collector.Stats()["test_pipeline_collector"]
+	assert.Equal(t, uint64(5000), stats.Sent)
+	assert.Equal(t, uint64(0), stats.Queued)
+	assert.LessOrEqual(t, stats.Batches, uint64(50))
+}
@@ -0,0 +1,84 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type money struct {
+	cents    int64
+	currency string
+}
+
+func TestBatchColumnConverters(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_column_converters"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_column_converters (
+			  id       UInt64
+			, amount   Decimal(18, 2)
+			, currency FixedString(3)
+		) Engine MergeTree() ORDER BY id
+	`))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_column_converters")
+	}()
+	ctx = clickhouse.Context(ctx, clickhouse.WithColumnConverters(map[string]clickhouse.ColumnConverter{
+		"amount": func(v interface{}) (interface{}, error) {
+			m, ok := v.(money)
+			if !ok {
+				return nil, fmt.Errorf("unexpected %T", v)
+			}
+			return decimal.New(m.cents, -2), nil
+		},
+		"currency": func(v interface{}) (interface{}, error) {
+			if m, ok := v.(money); ok {
+				return m.currency, nil
+			}
+			return v, nil
+		},
+	}))
+	batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_column_converters")
+	require.NoError(t, err)
+	for i, m := range []money{{cents: 1050, currency: "EUR"}, {cents: -1, currency: "USD"}} {
+		require.NoError(t, batch.Append(uint64(i), m, m))
+	}
+	assert.Error(t, batch.Append(uint64(2), 1.5, "EUR"))
+	require.NoError(t, batch.Send())
+
+	rows, err := conn.Query(ctx, "SELECT toString(amount), currency FROM test_column_converters ORDER BY id")
+	require.NoError(t, err)
+	var result []string
+	for rows.Next() {
+		var amount, currency string
+		require.NoError(t, rows.Scan(&amount, &currency))
+		result = append(result, amount+" "+currency)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"10.50 EUR", "-0.01 USD"}, result)
+}
@@ -0,0 +1,47 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnMapping(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := clickhouse.Context(context.Background(), clickhouse.WithColumnMapping(clickhouse.ColumnMapping{
+		Fields:               map[string]string{"user_name": "Name"},
+		IgnoreUnknownColumns: true,
+	}))
+	var result []struct {
+		ID   uint64 `ch:"id"`
+		Name string
+		Note string
+	}
+	require.NoError(t, conn.Select(ctx, &result, "SELECT number AS id, toString(number) AS user_name, now() AS extra FROM system.numbers LIMIT 3"))
+	require.Len(t, result, 3)
+	assert.Equal(t, uint64(2), result[2].ID)
+	assert.Equal(t, "2", result[2].Name)
+	assert.Empty(t, result[2].Note)
+	require.Error(t, conn.Select(context.Background(), &result, "SELECT number AS id, now() AS extra FROM system.numbers LIMIT 3"))
+}
@@ -16,6 +16,43 @@ func TestLZ4Compression(t *testing.T) {
 	CompressionTest(t, clickhouse.CompressionLZ4)
 }
 
+func TestLZ4HCCompression(t *testing.T) {
+	CompressionTest(t, clickhouse.CompressionLZ4HC)
+}
+
+func TestQueryCompression(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, &clickhouse.Compression{
+		Method: clickhouse.CompressionLZ4,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_query_compression"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_query_compression (
+			  Col1 String
+		) Engine MergeTree() ORDER BY tuple()
+	`))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE IF EXISTS test_query_compression")
+	}()
+	for _, option := range []clickhouse.QueryOption{
+		clickhouse.WithCompression(clickhouse.CompressionLZ4HC, 12),
+		clickhouse.WithCompression(clickhouse.CompressionZSTD, 9),
+		clickhouse.WithoutCompression(),
+	} {
+		batch, err := conn.PrepareBatch(clickhouse.Context(ctx, option), "INSERT INTO test_query_compression")
+		require.NoError(t, err)
+		for i := 0; i < 100; i++ {
+			require.NoError(t, batch.Append("compressed value"))
+		}
+		require.NoError(t, batch.Send())
+	}
+	var count uint64
+	require.NoError(t, conn.QueryRow(clickhouse.Context(ctx, clickhouse.WithCompression(clickhouse.CompressionZSTD, 3)),
+		"SELECT count() FROM test_query_compression WHERE Col1 = 'compressed value'").Scan(&count))
+	assert.Equal(t, uint64(300), count)
+}
+
 func TestNoCompression(t *testing.T) {
 	CompressionTest(t, clickhouse.CompressionNone)
 }
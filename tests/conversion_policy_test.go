@@ -0,0 +1,66 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversionPolicy(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	ctx := context.Background()
+	for _, policy := range []clickhouse.ConversionPolicy{clickhouse.ConversionLenient, clickhouse.ConversionStrict} {
+		t.Run(policy.String(), func(t *testing.T) {
+			options := clientOptionsFromEnv(env, nil)
+			options.ConversionPolicy = policy
+			conn, err := GetConnectionWithOptions(&options)
+			require.NoError(t, err)
+			require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_conversion_policy"))
+			require.NoError(t, conn.Exec(ctx, "CREATE TABLE test_conversion_policy (n UInt32, price Decimal(9, 2), at DateTime) Engine MergeTree ORDER BY n"))
+			defer conn.Exec(ctx, "DROP TABLE test_conversion_policy")
+
+			batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_conversion_policy")
+			require.NoError(t, err)
+			err = batch.Append("7", decimal.RequireFromString("1.255"), "2023-01-02 03:04:05")
+			if policy == clickhouse.ConversionStrict {
+				var cErr *column.ColumnConverterError
+				assert.ErrorAs(t, err, &cErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, batch.Send())
+			var (
+				n     int
+				price float64
+				at    string
+			)
+			require.NoError(t, conn.QueryRow(ctx, "SELECT n, price, at FROM test_conversion_policy").Scan(&n, &price, &at))
+			assert.Equal(t, 7, n)
+			assert.Equal(t, 1.25, price)
+			assert.Contains(t, at, "2023-01-02")
+		})
+	}
+}
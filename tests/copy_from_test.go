@@ -0,0 +1,56 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyFrom(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_copy_from"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_copy_from (
+			  id   UInt64
+			, name String
+			, tag  String DEFAULT 'none'
+		) Engine MergeTree() ORDER BY id
+	`))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_copy_from")
+	}()
+	n, err := clickhouse.CopyFrom(ctx, conn, "test_copy_from", []string{"id", "name"}, clickhouse.CopyFromSlice(100, func(i int) ([]interface{}, error) {
+		return []interface{}{uint64(i), "name"}, nil
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), n)
+	var (
+		count uint64
+		tag   string
+	)
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count(), any(tag) FROM test_copy_from").Scan(&count, &tag))
+	assert.Equal(t, uint64(100), count)
+	assert.Equal(t, "none", tag)
+}
@@ -0,0 +1,83 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyTable(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	for _, table := range []string{"test_copy_table_source", "test_copy_table_destination"} {
+		require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS "+table))
+		require.NoError(t, conn.Exec(ctx, fmt.Sprintf(`
+			CREATE TABLE %s (
+				  Day  Date
+				, ID   UInt64
+				, Name String
+			) Engine MergeTree() PARTITION BY Day ORDER BY ID
+		`, table)))
+		defer conn.Exec(ctx, "DROP TABLE "+table)
+	}
+	require.NoError(t, conn.Exec(ctx, `
+		INSERT INTO test_copy_table_source SELECT toDate('2024-01-01') + number % 3, number, toString(number) FROM numbers(1000)
+	`))
+
+	var completed []string
+	progress, err := clickhouse.CopyTable(ctx, conn, conn, clickhouse.CopyTableSpec{
+		Source:      "test_copy_table_source",
+		Destination: "test_copy_table_destination",
+		Parallelism: 2,
+		Overwrite:   true,
+		OnPartition: func(progress clickhouse.CopyTableProgress) {
+			completed = append(completed, progress.Partition)
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, progress.Partitions)
+	assert.Equal(t, uint64(1000), progress.Rows)
+	assert.Len(t, completed, 3)
+
+	// resuming with a partition left copies the remaining one again, overwriting it
+	progress, err = clickhouse.CopyTable(ctx, conn, conn, clickhouse.CopyTableSpec{
+		Source:      "test_copy_table_source",
+		Destination: "test_copy_table_destination",
+		Completed:   completed[:2],
+		Overwrite:   true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.Partitions)
+	var count uint64
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count() FROM test_copy_table_destination").Scan(&count))
+	assert.Equal(t, uint64(1000), count)
+
+	require.NoError(t, conn.Exec(ctx, "ALTER TABLE test_copy_table_destination MODIFY COLUMN Name Nullable(String)"))
+	_, err = clickhouse.CopyTable(ctx, conn, conn, clickhouse.CopyTableSpec{
+		Source:      "test_copy_table_source",
+		Destination: "test_copy_table_destination",
+	})
+	assert.ErrorContains(t, err, "Name is Nullable(String) instead of String")
+}
@@ -0,0 +1,65 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// HTTP connections are only available through database/sql
+func TestCredentialsProvider(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	for _, protocol := range []clickhouse.Protocol{clickhouse.Native, clickhouse.HTTP} {
+		t.Run(protocol.String(), func(t *testing.T) {
+			var calls int32
+			options := clientOptionsFromEnv(env, nil)
+			if protocol == clickhouse.HTTP {
+				options.Protocol = protocol
+				options.Addr = []string{fmt.Sprintf("%s:%d", env.Host, env.HttpPort)}
+				options.TLS = nil
+				options.Compression = nil
+			}
+			options.Auth.Password = ""
+			options.Auth.Credentials = func(ctx context.Context) (string, string, error) {
+				atomic.AddInt32(&calls, 1)
+				return env.Username, env.Password, nil
+			}
+			var user string
+			if protocol == clickhouse.HTTP {
+				db := clickhouse.OpenDB(&options)
+				defer db.Close()
+				require.NoError(t, db.QueryRow("SELECT currentUser()").Scan(&user))
+			} else {
+				conn, err := clickhouse.Open(&options)
+				require.NoError(t, err)
+				defer conn.Close()
+				require.NoError(t, conn.QueryRow(context.Background(), "SELECT currentUser()").Scan(&user))
+			}
+			assert.Equal(t, env.Username, user)
+			assert.NotZero(t, atomic.LoadInt32(&calls))
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/ddl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDDLBuilder(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	table := ddl.CreateTable{
+		Name: "test_ddl_builder",
+		Columns: []ddl.Column{
+			{Name: "ts", Type: "DateTime", Codec: "Delta(4), ZSTD(1)"},
+			{Name: "id", Type: "UInt64", Comment: "identifier"},
+			{Name: "day", Type: "Date", DefaultKind: "MATERIALIZED", Default: "toDate(ts)"},
+		},
+		Indexes:     []ddl.Index{{Name: "id_idx", Expression: "id", Type: "minmax", Granularity: 2}},
+		Engine:      ddl.Engine{Name: "ReplacingMergeTree", Params: []string{"ts"}},
+		PartitionBy: "toYYYYMM(ts)",
+		OrderBy:     []string{"id", "ts"},
+		PrimaryKey:  []string{"id"},
+		TTL:         "ts + toIntervalYear(1)",
+		Settings:    clickhouse.Settings{"index_granularity": 4096},
+	}
+	require.NoError(t, table.Validate())
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_ddl_builder"))
+	require.NoError(t, conn.Exec(ctx, table.SQL()))
+	defer conn.Exec(ctx, "DROP TABLE test_ddl_builder")
+
+	current, err := ddl.Introspect(ctx, conn, "", "test_ddl_builder")
+	require.NoError(t, err)
+	assert.Equal(t, table.Engine, current.Engine)
+	assert.Equal(t, table.PartitionBy, current.PartitionBy)
+	assert.Equal(t, table.OrderBy, current.OrderBy)
+	assert.Equal(t, table.PrimaryKey, current.PrimaryKey)
+	assert.Equal(t, table.TTL, current.TTL)
+	assert.Equal(t, int64(4096), current.Settings["index_granularity"])
+	assert.Equal(t, table.Indexes, current.Indexes)
+	require.Len(t, current.Columns, 3)
+	assert.Equal(t, table.Columns[0], current.Columns[0])
+	assert.Equal(t, table.Columns[1], current.Columns[1])
+	assert.Equal(t, table.Columns[2], current.Columns[2])
+}
+
+func TestDDLSchemaDiff(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	table := ddl.CreateTable{
+		Name: "test_ddl_schema_diff",
+		Columns: []ddl.Column{
+			{Name: "id", Type: "UInt32"},
+			{Name: "legacy", Type: "String"},
+		},
+		Engine:  ddl.Engine{Name: "MergeTree"},
+		OrderBy: []string{"id"},
+	}
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_ddl_schema_diff"))
+	require.NoError(t, conn.Exec(ctx, table.SQL()))
+	defer conn.Exec(ctx, "DROP TABLE test_ddl_schema_diff")
+
+	desired := table
+	desired.Columns = []ddl.Column{
+		{Name: "id", Type: "UInt64"},
+		{Name: "kind", Type: "String", Default: "'click'"},
+	}
+	desired.OrderBy = []string{"id", "kind"}
+	desired.Indexes = []ddl.Index{{Name: "kind_idx", Expression: "kind", Type: "set(100)", Granularity: 1}}
+	current, err := ddl.Introspect(ctx, conn, "", "test_ddl_schema_diff")
+	require.NoError(t, err)
+	plan, err := ddl.SchemaDiff(current, &desired)
+	require.NoError(t, err)
+	assert.Equal(t, ddl.Destructive, plan.Safety())
+	for _, statement := range plan.Statements() {
+		require.NoError(t, conn.Exec(ctx, statement), statement)
+	}
+	current, err = ddl.Introspect(ctx, conn, "", "test_ddl_schema_diff")
+	require.NoError(t, err)
+	plan, err = ddl.SchemaDiff(current, &desired)
+	require.NoError(t, err)
+	assert.Empty(t, plan)
+}
+
+func TestDDLMaterialize(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_ddl_materialize"))
+	require.NoError(t, conn.Exec(ctx, "CREATE TABLE test_ddl_materialize (id UInt64, user_id UInt64) Engine MergeTree ORDER BY id"))
+	defer conn.Exec(ctx, "DROP TABLE test_ddl_materialize")
+	require.NoError(t, conn.Exec(ctx, "INSERT INTO test_ddl_materialize SELECT number, number % 10 FROM numbers(10000)"))
+
+	require.NoError(t, ddl.AddIndex(ctx, conn, "test_ddl_materialize", ddl.Index{Name: "user_idx", Expression: "user_id", Type: "set(10)"}))
+	var progress []ddl.MutationProgress
+	require.NoError(t, ddl.MaterializeIndex(ctx, conn, "test_ddl_materialize", "user_idx", ddl.MaterializeOptions{
+		Wait:         true,
+		PollInterval: 100 * time.Millisecond,
+		OnProgress: func(p ddl.MutationProgress) {
+			progress = append(progress, p)
+		},
+	}))
+	require.NotEmpty(t, progress)
+	assert.True(t, progress[len(progress)-1].Done)
+	assert.Len(t, progress[0].MutationIDs, 1)
+
+	require.NoError(t, ddl.AddProjection(ctx, conn, "test_ddl_materialize", ddl.Projection{Name: "by_user", Query: "SELECT * ORDER BY user_id"}))
+	require.NoError(t, ddl.MaterializeProjection(ctx, conn, "test_ddl_materialize", "by_user", ddl.MaterializeOptions{Wait: true, PollInterval: 100 * time.Millisecond}))
+	require.NoError(t, ddl.DropProjection(ctx, conn, "test_ddl_materialize", "by_user"))
+	require.NoError(t, ddl.DropIndex(ctx, conn, "test_ddl_materialize", "user_idx"))
+}
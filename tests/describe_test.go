@@ -0,0 +1,63 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeTableCodecs(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, &clickhouse.Compression{
+		Method: clickhouse.CompressionLZ4,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_describe_codecs"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_describe_codecs (
+			  id UInt64 CODEC(Delta, ZSTD(3))
+			, name String
+		) Engine MergeTree() ORDER BY id
+	`))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_describe_codecs")
+	}()
+	columns, err := clickhouse.DescribeTable(ctx, conn, "test_describe_codecs")
+	require.NoError(t, err)
+	require.Len(t, columns, 2)
+	assert.Equal(t, "id", columns[0].Name)
+	assert.True(t, columns[0].HasCodec())
+	assert.Contains(t, columns[0].CodecExpression, "ZSTD(3)")
+	assert.False(t, columns[1].HasCodec())
+
+	insertCtx := clickhouse.Context(ctx, clickhouse.WithoutCompression())
+	batch, err := conn.PrepareBatch(insertCtx, "INSERT INTO test_describe_codecs")
+	require.NoError(t, err)
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, batch.Append(uint64(i), "name"))
+	}
+	require.NoError(t, batch.Send())
+	var count uint64
+	require.NoError(t, conn.QueryRow(insertCtx, "SELECT count() FROM test_describe_codecs").Scan(&count))
+	assert.Equal(t, uint64(1000), count)
+}
@@ -0,0 +1,39 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialFamily(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	for _, family := range []clickhouse.DialFamily{clickhouse.DialPreferIPv4, clickhouse.DialPreferIPv6} {
+		options := clientOptionsFromEnv(env, nil)
+		options.DialFamily = family
+		conn, err := GetConnectionWithOptions(&options)
+		require.NoError(t, err)
+		require.NoError(t, conn.Ping(context.Background()))
+		require.NoError(t, conn.Close())
+	}
+}
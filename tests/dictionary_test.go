@@ -0,0 +1,64 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/dictionary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDictionary(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	defer func() {
+		conn.Exec(ctx, "DROP DICTIONARY IF EXISTS test_dictionary_dict")
+		conn.Exec(ctx, "DROP TABLE IF EXISTS test_dictionary_source")
+	}()
+	require.NoError(t, conn.Exec(ctx, "CREATE TABLE test_dictionary_source (id UInt64, name String) Engine MergeTree ORDER BY id"))
+	require.NoError(t, conn.Exec(ctx, "INSERT INTO test_dictionary_source VALUES (1, 'one'), (2, 'two')"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE DICTIONARY test_dictionary_dict (id UInt64, name String DEFAULT '')
+		PRIMARY KEY id
+		SOURCE(CLICKHOUSE(TABLE 'test_dictionary_source'))
+		LIFETIME(0)
+		LAYOUT(FLAT())
+	`))
+	d := dictionary.New(conn, "test_dictionary_dict", dictionary.Config{CacheTTL: time.Minute})
+	name, err := dictionary.Get[string](ctx, d, "name", uint64(1))
+	require.NoError(t, err)
+	assert.Equal(t, "one", name)
+	name, err = dictionary.GetOrDefault[string](ctx, d, "name", uint64(3), "none")
+	require.NoError(t, err)
+	assert.Equal(t, "none", name)
+	has, err := d.Has(ctx, uint64(2))
+	require.NoError(t, err)
+	assert.True(t, has)
+	status, err := d.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "LOADED", status.Status)
+	assert.Equal(t, uint64(2), status.ElementCount)
+
+	_, err = dictionary.New(conn, "test_dictionary_missing", dictionary.Config{}).Status(ctx)
+	assert.ErrorIs(t, err, dictionary.ErrNotFound)
+}
@@ -0,0 +1,41 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCodes(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	err = conn.Exec(ctx, "SELECT * FROM test_error_codes_missing_table")
+	assert.True(t, errors.Is(err, clickhouse.ErrTableNotFound))
+	err = conn.Exec(ctx, "SELEKT 1")
+	assert.True(t, errors.Is(err, clickhouse.ErrSyntax))
+	var exception *clickhouse.Exception
+	require.True(t, errors.As(err, &exception))
+	assert.Equal(t, "SYNTAX_ERROR", clickhouse.ErrorCode(exception.Code).Name())
+}
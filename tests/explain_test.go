@@ -0,0 +1,59 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_explain"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_explain (
+			  id UInt64
+		) Engine MergeTree() ORDER BY id
+	`))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE IF EXISTS test_explain")
+	}()
+	require.NoError(t, conn.Exec(ctx, "INSERT INTO test_explain SELECT number FROM numbers(1000)"))
+	plan, err := clickhouse.Explain(ctx, conn, clickhouse.ExplainPlan, "SELECT id FROM test_explain WHERE id > ?", 10)
+	require.NoError(t, err)
+	assert.NotEmpty(t, plan.Lines)
+	assert.NotEmpty(t, plan.Find("ReadFromMergeTree"))
+	ast, err := clickhouse.Explain(ctx, conn, clickhouse.ExplainAST, "SELECT 1")
+	require.NoError(t, err)
+	require.Len(t, ast.Nodes, 1)
+	assert.Equal(t, "SelectWithUnionQuery", ast.Nodes[0].Name)
+	pipeline, err := clickhouse.Explain(ctx, conn, clickhouse.ExplainPipeline, "SELECT id FROM test_explain")
+	require.NoError(t, err)
+	assert.NotEmpty(t, pipeline.Nodes)
+	estimate, err := clickhouse.Explain(ctx, conn, clickhouse.ExplainEstimate, "SELECT id FROM test_explain")
+	require.NoError(t, err)
+	require.Len(t, estimate.Estimates, 1)
+	assert.Equal(t, "test_explain", estimate.Estimates[0].Table)
+	assert.Equal(t, uint64(1000), estimate.Estimates[0].Rows)
+}
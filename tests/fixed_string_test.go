@@ -362,3 +362,59 @@ func TestFixedStringFlush(t *testing.T) {
 	}
 	require.Equal(t, 1000, i)
 }
+
+func TestFixedStringOptions(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	options := clientOptionsFromEnv(env, nil)
+	options.FixedString = clickhouse.FixedStringOptions{Truncate: true, TrimNulls: true}
+	conn, err := GetConnectionWithOptions(&options)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_fixed_string_options"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_fixed_string_options (
+			  id   UInt64
+			, code FixedString(4)
+			, tags Array(Nullable(FixedString(4)))
+		) Engine MergeTree() ORDER BY id
+	`))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_fixed_string_options")
+	}()
+	batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_fixed_string_options")
+	require.NoError(t, err)
+	long, short := "abcdef", "ab"
+	require.NoError(t, batch.Append(uint64(1), long, []*string{&long, nil, &short}))
+	require.NoError(t, batch.Append(uint64(2), short, []*string{}))
+	require.NoError(t, batch.Send())
+
+	rows, err := conn.Query(ctx, "SELECT code, length(code), tags FROM test_fixed_string_options ORDER BY id")
+	require.NoError(t, err)
+	var (
+		codes []string
+		tags  [][]*string
+	)
+	for rows.Next() {
+		var (
+			code   string
+			length uint64
+			tag    []*string
+		)
+		require.NoError(t, rows.Scan(&code, &length, &tag))
+		assert.Equal(t, uint64(4), length)
+		codes, tags = append(codes, code), append(tags, tag)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"abcd", "ab"}, codes)
+	require.Len(t, tags[0], 3)
+	assert.Equal(t, "abcd", *tags[0][0])
+	assert.Nil(t, tags[0][1])
+	assert.Equal(t, "ab", *tags[0][2])
+
+	strict, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	batch, err = strict.PrepareBatch(ctx, "INSERT INTO test_fixed_string_options")
+	require.NoError(t, err)
+	assert.Error(t, batch.Append(uint64(3), long, []*string{}))
+}
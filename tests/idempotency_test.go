@@ -0,0 +1,76 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotentInsert(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_idempotent_insert"))
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_idempotency_keys"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_idempotent_insert (
+			  id UInt64
+		) Engine MergeTree() ORDER BY id
+	`))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE IF EXISTS test_idempotent_insert")
+		conn.Exec(ctx, "DROP TABLE IF EXISTS test_idempotency_keys")
+	}()
+	idempotency, err := clickhouse.NewIdempotency(ctx, conn, clickhouse.IdempotencyOptions{
+		Table: "test_idempotency_keys",
+	})
+	require.NoError(t, err)
+	insert := func(ctx context.Context) error {
+		batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_idempotent_insert")
+		if err != nil {
+			return err
+		}
+		for i := 0; i < 10; i++ {
+			if err := batch.Append(uint64(i)); err != nil {
+				return err
+			}
+		}
+		return batch.Send()
+	}
+	failed := errors.New("insert failed")
+	inserted, err := idempotency.Insert(ctx, "batch-1", func(context.Context) error { return failed })
+	assert.True(t, inserted)
+	assert.ErrorIs(t, err, failed)
+	for attempt := 0; attempt < 3; attempt++ {
+		inserted, err := idempotency.Insert(ctx, "batch-1", insert)
+		require.NoError(t, err)
+		assert.Equal(t, attempt == 0, inserted)
+	}
+	inserted, err = idempotency.Insert(ctx, "batch-2", insert)
+	require.NoError(t, err)
+	assert.True(t, inserted)
+	var count uint64
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count() FROM test_idempotent_insert").Scan(&count))
+	assert.Equal(t, uint64(20), count)
+}
@@ -0,0 +1,58 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertProgress(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_insert_progress"))
+	require.NoError(t, conn.Exec(ctx, "CREATE TABLE test_insert_progress (Col1 UInt64) Engine MergeTree() ORDER BY tuple()"))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_insert_progress")
+	}()
+	var reported []clickhouse.InsertProgress
+	ctx = clickhouse.Context(ctx, clickhouse.WithInsertProgress(func(p *clickhouse.InsertProgress) {
+		reported = append(reported, *p)
+	}))
+	batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_insert_progress")
+	require.NoError(t, err)
+	for block := 0; block < 5; block++ {
+		for i := 0; i < 1000; i++ {
+			require.NoError(t, batch.Append(uint64(block*1000+i)))
+		}
+		require.NoError(t, batch.Flush())
+	}
+	require.NoError(t, batch.Send())
+	require.NotEmpty(t, reported)
+	last := reported[len(reported)-1]
+	assert.True(t, last.Done)
+	assert.Equal(t, 5, last.Blocks)
+	assert.Equal(t, uint64(5000), last.Rows)
+	assert.NotZero(t, last.Bytes)
+	assert.Equal(t, uint64(5000), last.WrittenRows)
+}
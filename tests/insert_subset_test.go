@@ -0,0 +1,62 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertColumnSubset(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_insert_subset"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_insert_subset (
+			  id UInt64
+			, name String
+			, created DateTime DEFAULT toDateTime('2020-01-01 00:00:00', 'UTC')
+			, name_length UInt64 DEFAULT length(name)
+		) Engine MergeTree() ORDER BY id
+	`))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_insert_subset")
+	}()
+	type row struct {
+		ID   uint64 `ch:"id"`
+		Name string `ch:"name"`
+	}
+	query, err := clickhouse.StructInsertQuery("test_insert_subset", &row{})
+	require.NoError(t, err)
+	batch, err := conn.PrepareBatch(ctx, query)
+	require.NoError(t, err)
+	require.NoError(t, batch.AppendStruct(&row{ID: 1, Name: "abc"}))
+	require.NoError(t, batch.Send())
+	var (
+		created    uint32
+		nameLength uint64
+	)
+	require.NoError(t, conn.QueryRow(ctx, "SELECT toUnixTimestamp(created), name_length FROM test_insert_subset").Scan(&created, &nameLength))
+	assert.Equal(t, uint32(1577836800), created)
+	assert.Equal(t, uint64(3), nameLength)
+}
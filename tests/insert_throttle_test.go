@@ -0,0 +1,53 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertThrottle(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	throttle := clickhouse.NewInsertThrottle(clickhouse.InsertThrottleOptions{MinDelay: 10 * time.Millisecond})
+	options := clientOptionsFromEnv(env, nil)
+	options.InsertThrottle = throttle
+	conn, err := GetConnectionWithOptions(&options)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_insert_throttle"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_insert_throttle (v UInt64) Engine MergeTree ORDER BY v
+		SETTINGS parts_to_delay_insert = 2, parts_to_throw_insert = 1000, max_delay_to_insert = 1
+	`))
+	defer conn.Exec(ctx, "DROP TABLE test_insert_throttle")
+	require.NoError(t, conn.Exec(ctx, "SYSTEM STOP MERGES test_insert_throttle"))
+	for i := 0; i < 5; i++ {
+		batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_insert_throttle")
+		require.NoError(t, err)
+		require.NoError(t, batch.Append(uint64(i)))
+		require.NoError(t, batch.Send())
+	}
+	assert.Greater(t, throttle.Delay("test_insert_throttle"), time.Duration(0))
+}
@@ -0,0 +1,55 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxOpenConnsPerHost(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	for _, policy := range []clickhouse.HostLimitPolicy{clickhouse.HostLimitSpill, clickhouse.HostLimitQueue} {
+		options := clientOptionsFromEnv(env, nil)
+		options.MaxOpenConnsPerHost = 1
+		options.HostLimit = policy
+		options.DialTimeout = time.Second
+		conn, err := GetConnectionWithOptions(&options)
+		require.NoError(t, err)
+		ctx := context.Background()
+		rows, err := conn.Query(ctx, "SELECT number FROM system.numbers LIMIT 10")
+		require.NoError(t, err)
+		err = conn.Exec(ctx, "SELECT 1")
+		switch policy {
+		case clickhouse.HostLimitSpill:
+			assert.True(t, errors.Is(err, clickhouse.ErrMaxOpenConnsPerHost), err)
+		case clickhouse.HostLimitQueue:
+			assert.True(t, errors.Is(err, clickhouse.ErrAcquireConnTimeout), err)
+		}
+		require.NoError(t, rows.Close())
+		assert.NoError(t, conn.Exec(ctx, "SELECT 1"))
+		require.NoError(t, conn.Close())
+	}
+}
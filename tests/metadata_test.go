@@ -0,0 +1,53 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataLogComment(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_metadata"))
+	require.NoError(t, conn.Exec(ctx, "CREATE TABLE test_metadata (id UInt64) Engine MergeTree() ORDER BY id"))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_metadata")
+	}()
+	queryID := fmt.Sprintf("test-metadata-%d", time.Now().UnixNano())
+	batch, err := conn.PrepareBatch(clickhouse.Context(ctx,
+		clickhouse.WithQueryID(queryID),
+		clickhouse.WithMetadata(clickhouse.Metadata{"service": "ingest", "job": "daily"}),
+	), "INSERT INTO test_metadata")
+	require.NoError(t, err)
+	require.NoError(t, batch.Append(uint64(1)))
+	require.NoError(t, batch.Send())
+
+	require.NoError(t, conn.Exec(ctx, "SYSTEM FLUSH LOGS"))
+	var comment string
+	require.NoError(t, conn.QueryRow(ctx, "SELECT log_comment FROM system.query_log WHERE query_id = ? AND type = 'QueryFinish'", queryID).Scan(&comment))
+	assert.Equal(t, `{"job":"daily","service":"ingest"}`, comment)
+}
@@ -0,0 +1,70 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/monitoring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonitoring(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_monitoring"))
+	require.NoError(t, conn.Exec(ctx, "CREATE TABLE test_monitoring (p UInt8, v UInt64) Engine MergeTree PARTITION BY p ORDER BY v"))
+	defer conn.Exec(ctx, "DROP TABLE test_monitoring")
+	require.NoError(t, conn.Exec(ctx, "SYSTEM STOP MERGES test_monitoring"))
+
+	parts, err := monitoring.Parts(ctx, conn, "test_monitoring")
+	require.NoError(t, err)
+	assert.Equal(t, "test_monitoring", parts.Table)
+	assert.Zero(t, parts.ActiveParts)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, conn.Exec(ctx, "INSERT INTO test_monitoring SELECT number % 2, number FROM numbers(10)"))
+	}
+	parts, err = monitoring.Parts(ctx, conn, "test_monitoring")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), parts.ActiveParts)
+	assert.Equal(t, uint64(2), parts.Partitions)
+	assert.Equal(t, uint64(3), parts.MaxPartsInPartition)
+	assert.Equal(t, uint64(30), parts.Rows)
+	assert.NotZero(t, parts.BytesOnDisk)
+
+	all, err := monitoring.DatabaseParts(ctx, conn, "")
+	require.NoError(t, err)
+	var found bool
+	for _, p := range all {
+		found = found || p.Table == "test_monitoring"
+	}
+	assert.True(t, found)
+
+	merges, err := monitoring.Merges(ctx, conn, "test_monitoring")
+	require.NoError(t, err)
+	assert.Empty(t, merges)
+
+	pressure, err := monitoring.Pressure(ctx, conn, "test_monitoring")
+	require.NoError(t, err)
+	assert.Greater(t, pressure, 0.0)
+	assert.Less(t, pressure, 1.0)
+}
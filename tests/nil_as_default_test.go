@@ -0,0 +1,71 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchNilAsDefault(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := clickhouse.Context(context.Background(), clickhouse.WithSettings(clickhouse.Settings{
+		"input_format_null_as_default": 1,
+	}))
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_nil_as_default"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_nil_as_default (
+			  id UInt64
+			, name String DEFAULT 'unknown'
+			, score Int32 DEFAULT 42
+		) Engine MergeTree() ORDER BY id
+	`))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_nil_as_default")
+	}()
+	batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_nil_as_default")
+	require.NoError(t, err)
+	require.NoError(t, batch.Append(uint64(1), "abc", int32(1)))
+	require.NoError(t, batch.Append(uint64(2), nil, nil))
+	var name *string
+	require.NoError(t, batch.Append(uint64(3), name, int32(3)))
+	require.NoError(t, batch.Send())
+
+	rows, err := conn.Query(ctx, "SELECT name, score FROM test_nil_as_default ORDER BY id")
+	require.NoError(t, err)
+	var (
+		names  []string
+		scores []int32
+	)
+	for rows.Next() {
+		var (
+			name  string
+			score int32
+		)
+		require.NoError(t, rows.Scan(&name, &score))
+		names, scores = append(names, name), append(scores, score)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"abc", "unknown", "unknown"}, names)
+	assert.Equal(t, []int32{1, 42, 3}, scores)
+}
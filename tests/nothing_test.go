@@ -45,3 +45,25 @@ func TestNothing(t *testing.T) {
 	require.NoError(t, rows.Err())
 	assert.Equal(t, 10, count)
 }
+
+func TestNothingScan(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	var (
+		v     interface{} = "previous"
+		s                 = "previous"
+		p                 = &s
+		empty []string
+		nulls []*string
+		any   interface{}
+	)
+	require.NoError(t, conn.QueryRow(ctx, "SELECT NULL, NULL, NULL, [], [NULL, NULL], []").Scan(&v, &s, &p, &empty, &nulls, &any))
+	assert.Nil(t, v)
+	assert.Empty(t, s)
+	assert.Nil(t, p)
+	assert.NotNil(t, empty)
+	assert.Empty(t, empty)
+	assert.Equal(t, []*string{nil, nil}, nulls)
+	assert.Equal(t, []interface{}{}, any)
+}
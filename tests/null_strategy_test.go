@@ -0,0 +1,114 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchNullStrategy(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_null_strategy"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_null_strategy (
+			  id      UInt64
+			, name    String DEFAULT 'unknown'
+			, score   Int32 DEFAULT 42
+			, created DateTime
+		) Engine MergeTree() ORDER BY id
+	`))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_null_strategy")
+	}()
+
+	t.Run("error", func(t *testing.T) {
+		batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_null_strategy")
+		require.NoError(t, err)
+		assert.Error(t, batch.Append(uint64(1), nil, int32(1), time.Now()))
+	})
+	for _, strategy := range []clickhouse.NullStrategy{clickhouse.NullAsZero, clickhouse.NullAsDefault} {
+		ctx := clickhouse.Context(ctx, clickhouse.WithNullStrategy(strategy))
+		batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_null_strategy")
+		require.NoError(t, err)
+		require.NoError(t, batch.Append(uint64(strategy), nil, nil, nil))
+		require.NoError(t, batch.Send())
+	}
+
+	rows, err := conn.Query(ctx, "SELECT name, score, created FROM test_null_strategy ORDER BY id")
+	require.NoError(t, err)
+	var (
+		names   []string
+		scores  []int32
+		created []int64
+	)
+	for rows.Next() {
+		var (
+			name  string
+			score int32
+			at    time.Time
+		)
+		require.NoError(t, rows.Scan(&name, &score, &at))
+		names, scores, created = append(names, name), append(scores, score), append(created, at.Unix())
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"", "unknown"}, names)
+	assert.Equal(t, []int32{0, 42}, scores)
+	assert.Equal(t, []int64{0, 0}, created)
+}
+
+func TestBatchWriterNullStrategy(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_batch_writer_null_strategy"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_batch_writer_null_strategy (
+			  id    UInt64
+			, name  String DEFAULT 'unknown'
+		) Engine MergeTree() ORDER BY id
+	`))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_batch_writer_null_strategy")
+	}()
+	writer, err := clickhouse.NewBatchWriter(clickhouse.Context(ctx, clickhouse.WithNullStrategy(clickhouse.NullAsDefault)), conn, "INSERT INTO test_batch_writer_null_strategy", 2)
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, writer.Append(uint64(i), nil))
+	}
+	require.NoError(t, writer.Append(uint64(4), "set"))
+	require.NoError(t, writer.Send())
+
+	rows, err := conn.Query(ctx, "SELECT name FROM test_batch_writer_null_strategy ORDER BY id")
+	require.NoError(t, err)
+	var names []string
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"unknown", "unknown", "unknown", "unknown", "set"}, names)
+}
@@ -0,0 +1,63 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptimizeTable(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_optimize"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_optimize (
+			  id UInt64
+		) Engine MergeTree() ORDER BY id
+	`))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_optimize")
+	}()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, conn.Exec(ctx, "INSERT INTO test_optimize SELECT number % 10 FROM numbers(100)"))
+	}
+	var polls int
+	require.NoError(t, clickhouse.OptimizeTable(ctx, conn, clickhouse.OptimizeOptions{
+		Table:        "test_optimize",
+		Final:        true,
+		Deduplicate:  true,
+		Wait:         true,
+		PollInterval: 100 * time.Millisecond,
+		OnProgress: func(p clickhouse.MergeProgress) {
+			polls++
+		},
+	}))
+	assert.NotZero(t, polls)
+	var parts, rows uint64
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count() FROM system.parts WHERE database = currentDatabase() AND table = 'test_optimize' AND active").Scan(&parts))
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count() FROM test_optimize").Scan(&rows))
+	assert.Equal(t, uint64(1), parts)
+	assert.Equal(t, uint64(10), rows)
+}
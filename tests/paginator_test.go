@@ -0,0 +1,65 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaginator(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	type row struct {
+		N uint64 `ch:"n"`
+	}
+	for _, mode := range []clickhouse.PaginationMode{clickhouse.PaginateKeyset, clickhouse.PaginateOffset} {
+		p, err := clickhouse.NewPaginator(conn, clickhouse.PaginatorOptions{Keys: []string{"n"}, PageSize: 30, Mode: mode},
+			"SELECT number AS n FROM system.numbers WHERE number % ? = 0 LIMIT 100", 2)
+		require.NoError(t, err)
+		count, err := p.Count(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(100), count)
+		var (
+			page  []row
+			seen  []uint64
+			pages int
+		)
+		for {
+			ok, err := p.NextPage(ctx, &page)
+			require.NoError(t, err)
+			if !ok {
+				break
+			}
+			pages++
+			for _, r := range page {
+				seen = append(seen, r.N)
+			}
+		}
+		assert.Equal(t, 4, pages)
+		require.Len(t, seen, 100)
+		for i, n := range seen {
+			assert.Equal(t, uint64(i*2), n)
+		}
+	}
+}
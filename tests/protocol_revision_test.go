@@ -0,0 +1,45 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtocolRevisionPinning(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	options := clientOptionsFromEnv(env, nil)
+	options.ProtocolRevision = proto.DBMS_MIN_PROTOCOL_VERSION_WITH_INCREMENTAL_PROFILE_EVENTS
+	conn, err := GetConnectionWithOptions(&options)
+	require.NoError(t, err)
+	require.NoError(t, conn.Ping(context.Background()))
+	version, err := conn.ServerVersion()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(proto.DBMS_MIN_PROTOCOL_VERSION_WITH_INCREMENTAL_PROFILE_EVENTS), version.ProtocolRevision)
+	assert.True(t, version.Supports(proto.FeatureIncrementalProfileEvents))
+	assert.False(t, version.Supports(proto.FeatureParameters))
+	var n uint64
+	require.NoError(t, conn.QueryRow(context.Background(), "SELECT toUInt64(?)", 42).Scan(&n))
+	assert.Equal(t, uint64(42), n)
+}
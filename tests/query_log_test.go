@@ -0,0 +1,53 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLog(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	queryID := uuid.NewString()
+	require.NoError(t, conn.Exec(clickhouse.Context(ctx, clickhouse.WithQueryID(queryID)), "SELECT * FROM numbers(1000) FORMAT Null"))
+	entry, err := clickhouse.QueryLog(ctx, conn, queryID, clickhouse.QueryLogOptions{FlushLogs: true})
+	require.NoError(t, err)
+	assert.Equal(t, queryID, entry.QueryID)
+	assert.Equal(t, "QueryFinish", entry.Type)
+	assert.Equal(t, uint64(1000), entry.ReadRows)
+	assert.NotZero(t, entry.MemoryUsage)
+	assert.False(t, entry.Failed())
+
+	failedID := uuid.NewString()
+	require.Error(t, conn.Exec(clickhouse.Context(ctx, clickhouse.WithQueryID(failedID)), "SELECT throwIf(1)"))
+	entry, err = clickhouse.QueryLog(ctx, conn, failedID, clickhouse.QueryLogOptions{FlushLogs: true})
+	require.NoError(t, err)
+	assert.True(t, entry.Failed())
+	assert.NotEmpty(t, entry.Exception)
+
+	_, err = clickhouse.QueryLog(ctx, conn, uuid.NewString(), clickhouse.QueryLogOptions{Retries: 1})
+	assert.ErrorIs(t, err, clickhouse.ErrQueryLogNotFound)
+}
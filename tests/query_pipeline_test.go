@@ -0,0 +1,55 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryPipeline(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	var (
+		numbers []struct {
+			N uint64 `ch:"number"`
+		}
+		count uint64
+	)
+	p := clickhouse.NewPipeline(conn, clickhouse.PipelineOptions{MaxConcurrency: 2})
+	p.Select(&numbers, "SELECT number FROM system.numbers LIMIT 3")
+	p.Query("SELECT count() FROM numbers(10)", func(rows driver.Rows) error {
+		for rows.Next() {
+			if err := rows.Scan(&count); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	p.Query("SELECT toUInt64($1)", func(rows driver.Rows) error { return nil }, "x")
+	err = p.Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "query 2")
+	assert.Len(t, numbers, 3)
+	assert.Equal(t, uint64(10), count)
+}
@@ -0,0 +1,48 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadWriteSplitting(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	options := clientOptionsFromEnv(env, nil)
+	// only the read endpoints are reachable
+	options.ReadAddr = options.Addr
+	options.Addr = []string{"127.0.0.1:1"}
+	options.DialTimeout = time.Second
+	conn, err := clickhouse.Open(&options)
+	require.NoError(t, err)
+	ctx := context.Background()
+	var one uint8
+	require.NoError(t, conn.QueryRow(ctx, "SELECT 1").Scan(&one))
+	assert.Equal(t, uint8(1), one)
+	err = conn.Exec(ctx, "CREATE TABLE IF NOT EXISTS test_read_write_splitting (x UInt8) Engine Memory")
+	require.Error(t, err)
+	err = conn.QueryRow(clickhouse.Context(ctx, clickhouse.WithRoute(clickhouse.RouteWrite)), "SELECT 1").Scan(&one)
+	require.Error(t, err)
+}
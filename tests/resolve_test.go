@@ -0,0 +1,41 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveTTL(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	options := clientOptionsFromEnv(env, nil)
+	options.ResolveTTL = time.Minute
+	conn, err := GetConnectionWithOptions(&options)
+	require.NoError(t, err)
+	var stats clickhouse.Statistics
+	ctx := clickhouse.Context(context.Background(), clickhouse.WithStatistics(&stats))
+	require.NoError(t, conn.Exec(ctx, "SELECT 1"))
+	assert.NotEmpty(t, stats.Acquire.Addr)
+}
@@ -0,0 +1,11 @@
+CREATE TABLE test_chgen_events (
+    id         UInt64,
+    user_id    UInt32 CODEC(Delta, ZSTD),
+    name       LowCardinality(String) DEFAULT 'unknown',
+    note       Nullable(String),
+    tags       Array(String),
+    attrs      Map(String, UInt64),
+    amount     Decimal(18, 4),
+    created_at DateTime64(3, 'UTC'),
+    day        Date MATERIALIZED toDate(created_at)
+) ENGINE = MergeTree ORDER BY id;
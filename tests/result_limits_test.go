@@ -0,0 +1,54 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultLimits(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := clickhouse.Context(context.Background(), clickhouse.WithResultLimits(clickhouse.ResultLimits{MaxRows: 100}))
+	rows, err := conn.Query(ctx, "SELECT number FROM system.numbers LIMIT 1000000")
+	if err == nil {
+		for rows.Next() {
+		}
+		err = rows.Err()
+	}
+	var limitErr *clickhouse.ResultLimitError
+	require.True(t, errors.As(err, &limitErr), err)
+	assert.ErrorIs(t, err, clickhouse.ErrTooManyRowsOrBytes)
+
+	ctx = clickhouse.Context(context.Background(), clickhouse.WithSettings(clickhouse.Settings{"max_block_size": 10}),
+		clickhouse.WithResultLimits(clickhouse.ResultLimits{MaxRows: 25, KeepPartial: true}))
+	rows, err = conn.Query(ctx, "SELECT number FROM system.numbers LIMIT 1000000")
+	require.NoError(t, err)
+	var count int
+	for rows.Next() {
+		count++
+	}
+	assert.Equal(t, 25, count)
+	assert.ErrorIs(t, rows.Err(), clickhouse.ErrResultLimitExceeded)
+}
@@ -0,0 +1,85 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/rowcodec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowCodec(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_row_codec"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_row_codec (
+			  id    UInt64
+			, name  Nullable(String)
+			, tags  Array(String)
+			, attrs Map(String, UInt32)
+			, at    DateTime64(3, 'UTC')
+			, upper String MATERIALIZED upper(name)
+		) Engine MergeTree ORDER BY id
+	`))
+	defer conn.Exec(ctx, "DROP TABLE test_row_codec")
+	columns, err := rowcodec.TableColumns(ctx, conn, "test_row_codec")
+	require.NoError(t, err)
+	require.Len(t, columns, 5)
+	const input = "at,id,name,tags,attrs\n" +
+		`2023-01-02 03:04:05.123,1,"a,b","['x','y\'z']",{'k':1}` + "\n" +
+		`2023-01-02 03:04:05.000,2,\N,[],{}` + "\n"
+	batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_row_codec")
+	require.NoError(t, err)
+	n, err := rowcodec.AppendCSV(batch, columns, strings.NewReader(input), rowcodec.CSVOptions{Header: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	require.NoError(t, batch.Send())
+
+	rows, err := conn.Query(ctx, "SELECT id, name, tags, attrs, at FROM test_row_codec ORDER BY id")
+	require.NoError(t, err)
+	var out bytes.Buffer
+	n, err = rowcodec.WriteJSONEachRow(&out, rows, rowcodec.JSONOptions{})
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+	assert.Equal(t, 2, n)
+	assert.Equal(t, `{"id":1,"name":"a,b","tags":["x","y'z"],"attrs":{"k":1},"at":"2023-01-02 03:04:05.123"}`+"\n"+
+		`{"id":2,"name":null,"tags":[],"attrs":{},"at":"2023-01-02 03:04:05.000"}`+"\n", out.String())
+
+	require.NoError(t, conn.Exec(ctx, "TRUNCATE TABLE test_row_codec"))
+	batch, err = conn.PrepareBatch(ctx, "INSERT INTO test_row_codec")
+	require.NoError(t, err)
+	n, err = rowcodec.AppendJSONEachRow(batch, columns, &out)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	require.NoError(t, batch.Send())
+	rows, err = conn.Query(ctx, "SELECT id, name, tags, attrs, at FROM test_row_codec ORDER BY id")
+	require.NoError(t, err)
+	defer rows.Close()
+	var csv bytes.Buffer
+	_, err = rowcodec.WriteCSV(&csv, rows, rowcodec.CSVOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, `1,"a,b","['x','y\'z']",{'k':1},2023-01-02 03:04:05.123`+"\n"+
+		`2,\N,[],{},2023-01-02 03:04:05.000`+"\n", csv.String())
+}
@@ -0,0 +1,59 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailServerLogs(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	var exists uint8
+	require.NoError(t, conn.QueryRow(ctx, "EXISTS TABLE system.text_log").Scan(&exists))
+	if exists == 0 {
+		t.Skip("system.text_log is not enabled")
+	}
+	const queryID = "test-tail-server-logs"
+	require.NoError(t, conn.Exec(clickhouse.Context(ctx, clickhouse.WithQueryID(queryID)), "SELECT 1"))
+	require.NoError(t, conn.Exec(ctx, "SYSTEM FLUSH LOGS"))
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	logs, errs := clickhouse.TailServerLogs(ctx, conn, clickhouse.TailLogsOptions{
+		Priority:     clickhouse.LogTrace,
+		QueryID:      queryID,
+		Since:        time.Now().Add(-time.Minute),
+		PollInterval: 100 * time.Millisecond,
+	})
+	log, ok := <-logs
+	require.True(t, ok, "no log entry received")
+	assert.Equal(t, queryID, log.QueryID)
+	assert.NotEmpty(t, log.Text)
+	assert.LessOrEqual(t, log.Priority, clickhouse.LogTrace)
+	cancel()
+	for range logs {
+	}
+	assert.NoError(t, <-errs)
+}
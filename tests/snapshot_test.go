@@ -0,0 +1,68 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSnapshot(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	before := conn.Stats().Open
+	err = clickhouse.ReadSnapshot(ctx, conn, clickhouse.SnapshotOptions{MaxReplicaDelay: time.Minute}, func(s *clickhouse.Snapshot) error {
+		assert.False(t, s.Time().IsZero())
+		assert.NotEmpty(t, s.Addr())
+		var balancing string
+		if err := s.QueryRow(ctx, "SELECT getSetting('load_balancing')").Scan(&balancing); err != nil {
+			return err
+		}
+		assert.Equal(t, "in_order", balancing)
+		// the rows of the previous query are closed by the next one
+		rows, err := s.Query(ctx, "SELECT number FROM system.numbers LIMIT 100000")
+		if err != nil {
+			return err
+		}
+		require.True(t, rows.Next())
+		var numbers []struct {
+			Number uint64 `ch:"number"`
+		}
+		if err := s.Select(ctx, &numbers, "SELECT number FROM system.numbers WHERE number < ? LIMIT 10", 3); err != nil {
+			return err
+		}
+		assert.Len(t, numbers, 3)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, before, conn.Stats().Open)
+
+	var leaked *clickhouse.Snapshot
+	require.NoError(t, clickhouse.ReadSnapshot(ctx, conn, clickhouse.SnapshotOptions{}, func(s *clickhouse.Snapshot) error {
+		leaked = s
+		return nil
+	}))
+	_, err = leaked.Query(ctx, "SELECT 1")
+	assert.Error(t, err)
+}
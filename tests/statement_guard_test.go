@@ -0,0 +1,60 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyClient(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	options := clientOptionsFromEnv(env, nil)
+	options.ReadOnly = true
+	conn, err := GetConnectionWithOptions(&options)
+	require.NoError(t, err)
+	ctx := context.Background()
+	var one uint8
+	require.NoError(t, conn.QueryRow(clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+		"max_threads": 1,
+	})), "SELECT 1").Scan(&one))
+	assert.Equal(t, uint8(1), one)
+	assert.ErrorIs(t, conn.Exec(ctx, "CREATE TABLE test_read_only (x UInt8) Engine Memory"), clickhouse.ErrReadOnly)
+	_, err = conn.PrepareBatch(ctx, "INSERT INTO test_read_only")
+	assert.ErrorIs(t, err, clickhouse.ErrReadOnly)
+	var readonly string
+	require.NoError(t, conn.QueryRow(ctx, "SELECT getSetting('readonly')").Scan(&readonly))
+	assert.Equal(t, "2", readonly)
+}
+
+func TestDisallowDDLClient(t *testing.T) {
+	env, err := GetNativeTestEnvironment()
+	require.NoError(t, err)
+	options := clientOptionsFromEnv(env, nil)
+	options.DisallowDDL = true
+	conn, err := GetConnectionWithOptions(&options)
+	require.NoError(t, err)
+	err = conn.Exec(context.Background(), "DROP TABLE IF EXISTS test_disallow_ddl")
+	assert.True(t, errors.Is(err, clickhouse.ErrDDLNotAllowed))
+}
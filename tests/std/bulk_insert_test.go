@@ -0,0 +1,87 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package std
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdBulkInsert(t *testing.T) {
+	conn, err := GetStdOpenDBConnection(clickhouse.Native, nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	_, err = conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS test_std_bulk (Col1 UInt64, Col2 Array(String)) Engine MergeTree() ORDER BY tuple()")
+	require.NoError(t, err)
+	defer func() {
+		conn.Exec("DROP TABLE test_std_bulk")
+	}()
+	rows := clickhouse.BulkRows{
+		{uint64(1), []string{"a"}},
+		{uint64(2), []string{"b", "c"}},
+		{uint64(3), []string{}},
+	}
+	result, err := conn.ExecContext(ctx, "INSERT INTO test_std_bulk", rows)
+	require.NoError(t, err)
+	affected, err := result.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), affected)
+
+	scope, err := conn.Begin()
+	require.NoError(t, err)
+	stmt, err := scope.Prepare("INSERT INTO test_std_bulk")
+	require.NoError(t, err)
+	_, err = stmt.Exec(rows)
+	require.NoError(t, err)
+	require.NoError(t, scope.Commit())
+
+	var count uint64
+	require.NoError(t, conn.QueryRow("SELECT count() FROM test_std_bulk").Scan(&count))
+	assert.Equal(t, uint64(6), count)
+}
+
+func TestStdBulkInsertAppendError(t *testing.T) {
+	conn, err := GetStdOpenDBConnection(clickhouse.Native, nil, nil, nil)
+	require.NoError(t, err)
+	// a single connection, so the query after the failed insert runs on the connection of the insert
+	conn.SetMaxOpenConns(1)
+	conn.SetMaxIdleConns(1)
+	ctx := context.Background()
+	_, err = conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS test_std_bulk_error (Col1 UInt64) Engine MergeTree() ORDER BY tuple()")
+	require.NoError(t, err)
+	defer func() {
+		conn.Exec("DROP TABLE test_std_bulk_error")
+	}()
+	_, err = conn.ExecContext(ctx, "INSERT INTO test_std_bulk_error", clickhouse.BulkRows{
+		{uint64(1)},
+		{"not a number"},
+	})
+	require.Error(t, err)
+
+	var count uint64
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT count() FROM test_std_bulk_error").Scan(&count))
+	assert.Equal(t, uint64(0), count)
+	_, err = conn.ExecContext(ctx, "INSERT INTO test_std_bulk_error", clickhouse.BulkRows{{uint64(1)}})
+	require.NoError(t, err)
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT count() FROM test_std_bulk_error").Scan(&count))
+	assert.Equal(t, uint64(1), count)
+}
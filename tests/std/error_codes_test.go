@@ -0,0 +1,44 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package std
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdErrorCodes(t *testing.T) {
+	for name, protocol := range map[string]clickhouse.Protocol{"Http": clickhouse.HTTP, "Native": clickhouse.Native} {
+		t.Run(fmt.Sprintf("%s Protocol", name), func(t *testing.T) {
+			conn, err := GetStdOpenDBConnection(protocol, nil, nil, nil)
+			require.NoError(t, err)
+			_, err = conn.Exec("SELECT * FROM test_error_codes_missing_table")
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, clickhouse.ErrTableNotFound))
+			var exception *clickhouse.Exception
+			require.True(t, errors.As(err, &exception))
+			assert.Equal(t, int32(60), exception.Code)
+			assert.Contains(t, exception.DisplayText(), "test_error_codes_missing_table")
+		})
+	}
+}
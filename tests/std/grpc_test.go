@@ -0,0 +1,58 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package std
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdGRPC(t *testing.T) {
+	env, err := GetStdTestEnvironment()
+	require.NoError(t, err)
+	conn, err := GetConnectionFromDSN(fmt.Sprintf("grpc://%s:%d?username=%s&password=%s&compress=gzip", env.Host, env.GrpcPort, env.Username, env.Password))
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.Ping())
+	_, err = conn.Exec("DROP TABLE IF EXISTS test_std_grpc")
+	require.NoError(t, err)
+	_, err = conn.Exec("CREATE TABLE test_std_grpc (Col1 UInt64, Col2 String) Engine MergeTree() ORDER BY tuple()")
+	require.NoError(t, err)
+	defer conn.Exec("DROP TABLE test_std_grpc")
+	scope, err := conn.Begin()
+	require.NoError(t, err)
+	batch, err := scope.Prepare("INSERT INTO test_std_grpc")
+	require.NoError(t, err)
+	for i := 0; i < 1000; i++ {
+		_, err := batch.Exec(uint64(i), fmt.Sprint(i))
+		require.NoError(t, err)
+	}
+	require.NoError(t, scope.Commit())
+	var (
+		count uint64
+		last  string
+	)
+	require.NoError(t, conn.QueryRow("SELECT count(), max(Col1)::String FROM test_std_grpc").Scan(&count, &last))
+	assert.Equal(t, uint64(1000), count)
+	assert.Equal(t, "999", last)
+	_, err = conn.Query("SELECT * FROM test_std_grpc_missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,54 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package std
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdNativeConn(t *testing.T) {
+	db, err := GetStdOpenDBConnection(clickhouse.Native, nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.ExecContext(ctx, "CREATE TEMPORARY TABLE test_std_native_conn (Col1 UInt64) Engine Memory")
+	require.NoError(t, err)
+	require.NoError(t, conn.Raw(func(driverConn interface{}) error {
+		native, ok := driverConn.(clickhouse.NativeConn)
+		require.True(t, ok)
+		version, err := native.ServerVersion()
+		require.NoError(t, err)
+		assert.NotZero(t, version.Version.Major)
+		batch, err := native.PrepareBatch(ctx, "INSERT INTO test_std_native_conn")
+		require.NoError(t, err)
+		for i := 0; i < 10; i++ {
+			require.NoError(t, batch.Append(uint64(i)))
+		}
+		return batch.Send()
+	}))
+	var count uint64
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT count() FROM test_std_native_conn").Scan(&count))
+	assert.Equal(t, uint64(10), count)
+}
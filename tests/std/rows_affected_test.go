@@ -0,0 +1,50 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package std
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdRowsAffected(t *testing.T) {
+	for name, protocol := range map[string]clickhouse.Protocol{"Native": clickhouse.Native, "Http": clickhouse.HTTP} {
+		t.Run(fmt.Sprintf("%s Protocol", name), func(t *testing.T) {
+			conn, err := GetStdOpenDBConnection(protocol, nil, nil, nil)
+			require.NoError(t, err)
+			ctx := context.Background()
+			_, err = conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS test_std_rows_affected (Col1 UInt64) Engine MergeTree() ORDER BY tuple()")
+			require.NoError(t, err)
+			defer func() {
+				conn.Exec("DROP TABLE test_std_rows_affected")
+			}()
+			result, err := conn.ExecContext(ctx, "INSERT INTO test_std_rows_affected SELECT number FROM system.numbers LIMIT 100")
+			require.NoError(t, err)
+			affected, err := result.RowsAffected()
+			require.NoError(t, err)
+			assert.Equal(t, int64(100), affected)
+			_, err = result.LastInsertId()
+			assert.Error(t, err)
+		})
+	}
+}
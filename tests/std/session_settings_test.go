@@ -0,0 +1,59 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package std
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	clickhouse_tests "github.com/ClickHouse/clickhouse-go/v2/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdSessionSettingsReset(t *testing.T) {
+	dsns := map[string]clickhouse.Protocol{"Native": clickhouse.Native, "Http": clickhouse.HTTP}
+	useSSL, err := strconv.ParseBool(clickhouse_tests.GetEnv("CLICKHOUSE_USE_SSL", "false"))
+	require.NoError(t, err)
+	for name, protocol := range dsns {
+		t.Run(fmt.Sprintf("%s Protocol", name), func(t *testing.T) {
+			db, err := GetStdDSNConnection(protocol, useSSL, url.Values{"session_settings": []string{"reset"}})
+			require.NoError(t, err)
+			db.SetMaxOpenConns(1)
+			ctx := context.Background()
+			conn, err := db.Conn(ctx)
+			require.NoError(t, err)
+			_, err = conn.ExecContext(ctx, "SET max_threads = 3")
+			require.NoError(t, err)
+			var threads string
+			require.NoError(t, conn.QueryRowContext(ctx, "SELECT getSetting('max_threads')").Scan(&threads))
+			assert.Equal(t, "3", threads)
+			_, err = conn.ExecContext(ctx, "SET unknown_setting_name = 1")
+			assert.Error(t, err)
+			require.NoError(t, conn.Close())
+
+			// the single connection of the pool is reused without the settings
+			require.NoError(t, db.QueryRowContext(ctx, "SELECT getSetting('max_threads')").Scan(&threads))
+			assert.NotEqual(t, "3", threads)
+		})
+	}
+}
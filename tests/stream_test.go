@@ -0,0 +1,51 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamStruct(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	type result struct {
+		Number uint64 `ch:"number"`
+	}
+	rows, errs := clickhouse.StreamStruct[result](context.Background(), conn, 10, "SELECT number FROM system.numbers LIMIT 1000")
+	var expected uint64
+	for row := range rows {
+		assert.Equal(t, expected, row.Number)
+		expected++
+	}
+	require.NoError(t, <-errs)
+	assert.Equal(t, uint64(1000), expected)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rows, errs = clickhouse.StreamStruct[result](ctx, conn, 0, "SELECT number FROM system.numbers LIMIT 1000000")
+	<-rows
+	cancel()
+	for range rows {
+	}
+	assert.ErrorIs(t, <-errs, context.Canceled)
+}
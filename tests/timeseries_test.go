@@ -0,0 +1,65 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeSeriesAppender(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	schema := timeseries.Schema{Table: "test_timeseries", LabelColumns: []string{"metric", "host"}}
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_timeseries"))
+	create := schema.CreateTable()
+	require.NoError(t, conn.Exec(ctx, create.SQL()))
+	defer conn.Exec(ctx, "DROP TABLE test_timeseries")
+
+	appender, err := timeseries.NewAppender(conn, schema)
+	require.NoError(t, err)
+	start := time.Now().Truncate(time.Millisecond)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, appender.Append(timeseries.Point{
+			Time:   start.Add(time.Duration(i) * time.Second),
+			Labels: map[string]string{"metric": "cpu_usage", "host": "web-1", "core": "0"},
+			Value:  float64(i),
+		}))
+	}
+	require.NoError(t, appender.Flush(ctx))
+
+	var (
+		count uint64
+		sum   float64
+		core  string
+		first time.Time
+	)
+	require.NoError(t, conn.QueryRow(ctx, `
+		SELECT count(), sum(value), any(labels['core']), min(timestamp) FROM test_timeseries WHERE metric = 'cpu_usage' AND host = 'web-1'
+	`).Scan(&count, &sum, &core, &first))
+	assert.Equal(t, uint64(100), count)
+	assert.Equal(t, float64(4950), sum)
+	assert.Equal(t, "0", core)
+	assert.True(t, start.Equal(first))
+}
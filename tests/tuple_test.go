@@ -318,7 +318,7 @@ func TestUnNamedTupleWithMap(t *testing.T) {
 	var col1 map[string]interface{}
 	err = conn.QueryRow(ctx, "SELECT * FROM test_tuple").Scan(&col1)
 	require.Error(t, err)
-	require.Equal(t, "clickhouse [ScanRow]: (Col1) converting Tuple(String, Int64) to map[string]interface {} is unsupported. cannot use maps for unnamed tuples, use slice", err.Error())
+	require.Equal(t, "clickhouse [ScanRow]: (Col1 Tuple(String, Int64)) row 0: converting Tuple(String, Int64) to map[string]interface {} is unsupported. cannot use maps for unnamed tuples, use slice (value: [A 42])", err.Error())
 }
 
 func TestColumnarTuple(t *testing.T) {
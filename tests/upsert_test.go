@@ -0,0 +1,68 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsert(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_upsert"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_upsert (
+			  id      UInt64
+			, name    String
+			, version UInt64
+		) Engine ReplacingMergeTree(version) ORDER BY id
+	`))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_upsert")
+	}()
+	type user struct {
+		ID   uint64 `ch:"id"`
+		Name string `ch:"name"`
+	}
+	opts := clickhouse.UpsertOptions{
+		Table:         "test_upsert",
+		VersionColumn: "version",
+		Mode:          clickhouse.UpsertOptimize,
+		CheckEngine:   true,
+	}
+	require.NoError(t, clickhouse.Upsert(ctx, conn, opts, []user{{1, "a"}, {2, "b"}}))
+	require.NoError(t, clickhouse.Upsert(ctx, conn, opts, []user{{1, "c"}}))
+	var users []user
+	require.NoError(t, conn.Select(ctx, &users, "SELECT id, name FROM test_upsert ORDER BY id"))
+	assert.Equal(t, []user{{1, "c"}, {2, "b"}}, users)
+
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_upsert_plain"))
+	require.NoError(t, conn.Exec(ctx, "CREATE TABLE test_upsert_plain (id UInt64, name String) Engine MergeTree() ORDER BY id"))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE test_upsert_plain")
+	}()
+	err = clickhouse.Upsert(ctx, conn, clickhouse.UpsertOptions{Table: "test_upsert_plain", CheckEngine: true}, []user{{1, "a"}})
+	assert.True(t, errors.Is(err, clickhouse.ErrUpsertEngine))
+}
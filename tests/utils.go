@@ -57,6 +57,7 @@ type ClickHouseTestEnvironment struct {
 	HttpPort    int
 	SslPort     int
 	HttpsPort   int
+	GrpcPort    int
 	Host        string
 	Username    string
 	Password    string
@@ -143,7 +144,7 @@ func CreateClickHouseTestEnvironment(testSet string) (ClickHouseTestEnvironment,
 	req := testcontainers.ContainerRequest{
 		Image:        fmt.Sprintf("clickhouse/clickhouse-server:%s", GetClickHouseTestVersion()),
 		Name:         fmt.Sprintf("clickhouse-go-%s-%d", strings.ToLower(testSet), time.Now().UnixNano()),
-		ExposedPorts: []string{"9000/tcp", "8123/tcp", "9440/tcp", "8443/tcp"},
+		ExposedPorts: []string{"9000/tcp", "8123/tcp", "9440/tcp", "8443/tcp", "9100/tcp"},
 		WaitingFor: wait.ForAll(
 			wait.ForLog("Ready for connections").WithStartupTimeout(time.Second*time.Duration(120)),
 			wait.ForSQL("9000/tcp", "clickhouse", func(port nat.Port) string {
@@ -172,12 +173,14 @@ func CreateClickHouseTestEnvironment(testSet string) (ClickHouseTestEnvironment,
 	hp, _ := clickhouseContainer.MappedPort(ctx, "8123")
 	sslPort, _ := clickhouseContainer.MappedPort(ctx, "9440")
 	hps, _ := clickhouseContainer.MappedPort(ctx, "8443")
+	grpcPort, _ := clickhouseContainer.MappedPort(ctx, "9100")
 	ip, _ := clickhouseContainer.ContainerIP(ctx)
 	testEnv := ClickHouseTestEnvironment{
 		Port:      p.Int(),
 		HttpPort:  hp.Int(),
 		SslPort:   sslPort.Int(),
 		HttpsPort: hps.Int(),
+		GrpcPort:  grpcPort.Int(),
 		Host:      "127.0.0.1",
 		// we set this explicitly - note its also set in the /etc/clickhouse-server/users.d/admin.xml
 		Username:    "default",
@@ -234,11 +237,16 @@ func GetExternalTestEnvironment(testSet string) (ClickHouseTestEnvironment, erro
 	if err != nil {
 		return ClickHouseTestEnvironment{}, nil
 	}
+	grpcPort, err := strconv.Atoi(GetEnv("CLICKHOUSE_GRPC_PORT", "9100"))
+	if err != nil {
+		return ClickHouseTestEnvironment{}, nil
+	}
 	env := ClickHouseTestEnvironment{
 		Port:      port,
 		HttpPort:  httpPort,
 		SslPort:   sslPort,
 		HttpsPort: httpsPort,
+		GrpcPort:  grpcPort,
 		Username:  GetEnv("CLICKHOUSE_USERNAME", "default"),
 		Password:  GetEnv("CLICKHOUSE_PASSWORD", ""),
 		Host:      GetEnv("CLICKHOUSE_HOST", "localhost"),
@@ -0,0 +1,53 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatingBatch(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_validating_batch"))
+	require.NoError(t, conn.Exec(ctx, "CREATE TABLE test_validating_batch (id UInt64, name String) Engine MergeTree ORDER BY id"))
+	defer conn.Exec(ctx, "DROP TABLE test_validating_batch")
+	batch, err := clickhouse.NewValidatingBatch(ctx, conn, "INSERT INTO test_validating_batch", clickhouse.ValidationOptions{})
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		var name interface{} = "ok"
+		if i%10 == 0 {
+			name = []int{i}
+		}
+		require.NoError(t, batch.Append(uint64(i), name))
+	}
+	report, err := batch.Send()
+	require.NoError(t, err)
+	assert.Equal(t, 90, report.Appended)
+	require.Len(t, report.Rejected, 10)
+	assert.Equal(t, 10, report.Rejected[1].Index)
+	var count uint64
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count() FROM test_validating_batch").Scan(&count))
+	assert.Equal(t, uint64(90), count)
+}
@@ -0,0 +1,67 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchLiveView(t *testing.T) {
+	conn, err := GetNativeConnection(clickhouse.Settings{
+		"allow_experimental_live_view": 1,
+	}, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_watch_view"))
+	require.NoError(t, conn.Exec(ctx, "DROP TABLE IF EXISTS test_watch"))
+	require.NoError(t, conn.Exec(ctx, `
+		CREATE TABLE test_watch (
+			  id UInt64
+		) Engine MergeTree() ORDER BY id
+	`))
+	defer func() {
+		conn.Exec(ctx, "DROP TABLE IF EXISTS test_watch_view")
+		conn.Exec(ctx, "DROP TABLE IF EXISTS test_watch")
+	}()
+	if err := conn.Exec(ctx, "CREATE LIVE VIEW test_watch_view AS SELECT count() FROM test_watch"); err != nil {
+		t.Skip(fmt.Errorf("live views are not supported: %w", err))
+	}
+	sub, err := clickhouse.Watch(ctx, conn, clickhouse.WatchOptions{}, "WATCH test_watch_view LIMIT 1")
+	require.NoError(t, err)
+	defer sub.Close()
+	var counts []uint64
+	for sub.Next() {
+		var (
+			count   uint64
+			version uint64
+		)
+		require.NoError(t, sub.Scan(&count, &version))
+		if counts = append(counts, count); len(counts) == 1 {
+			require.NoError(t, conn.Exec(ctx, "INSERT INTO test_watch VALUES (1), (2)"))
+		}
+	}
+	require.NoError(t, sub.Err())
+	assert.Equal(t, []uint64{0, 2}, counts)
+	assert.Equal(t, []string{"count()", "_version"}, sub.Columns())
+}
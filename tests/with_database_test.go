@@ -0,0 +1,50 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDatabase(t *testing.T) {
+	conn, err := GetNativeConnection(nil, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, conn.Exec(ctx, "CREATE DATABASE IF NOT EXISTS test_with_database"))
+	defer conn.Exec(ctx, "DROP DATABASE test_with_database")
+	require.NoError(t, conn.Exec(ctx, "CREATE TABLE IF NOT EXISTS test_with_database.events (ID UInt64) Engine MergeTree() ORDER BY tuple()"))
+
+	analytics := clickhouse.Context(ctx, clickhouse.WithDatabase("test_with_database"))
+	require.NoError(t, conn.Exec(analytics, "INSERT INTO events VALUES (1), (2)"))
+	batch, err := conn.PrepareBatch(analytics, "INSERT INTO events")
+	require.NoError(t, err)
+	require.NoError(t, batch.Append(uint64(3)))
+	require.NoError(t, batch.Send())
+	var count uint64
+	require.NoError(t, conn.QueryRow(analytics, "SELECT count() FROM events").Scan(&count))
+	assert.Equal(t, uint64(3), count)
+
+	var database string
+	require.NoError(t, conn.QueryRow(ctx, "SELECT currentDatabase()").Scan(&database))
+	assert.NotEqual(t, "test_with_database", database)
+}
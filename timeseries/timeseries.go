@@ -0,0 +1,278 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package timeseries ingests (timestamp, labels, value) points, the common shape of metrics and other
+// observability data, into a table of a fixed layout: a DateTime64 timestamp, LowCardinality(String)
+// columns for the labels which are queried the most, a Map for the other labels and a Float64 value.
+// Points are buffered column by column and label strings are interned, so a batch of points sharing
+// the same label sets holds every distinct string once:
+//
+//	schema := timeseries.Schema{Table: "metrics", LabelColumns: []string{"metric", "host"}}
+//	create := schema.CreateTable()
+//	err := conn.Exec(ctx, create.SQL())
+//	appender, err := timeseries.NewAppender(conn, schema)
+//	err = appender.Append(timeseries.Point{
+//		Time:   time.Now(),
+//		Labels: map[string]string{"metric": "cpu_usage", "host": "web-1", "core": "0"},
+//		Value:  0.42,
+//	})
+//	err = appender.Flush(ctx)
+package timeseries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/ddl"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+var ErrInvalidSchema = errors.New("clickhouse [timeseries]: invalid schema")
+
+// maxInterned bounds the interned label strings of an Appender, the table is dropped when it is reached.
+const maxInterned = 1 << 16
+
+// Point is a single sample.
+type Point struct {
+	Time   time.Time
+	Labels map[string]string
+	Value  float64
+}
+
+// Schema describes the table points are inserted into.
+type Schema struct {
+	Database string
+	Table    string
+	// TimeColumn is the DateTime64(Precision) column of the timestamps. Default "timestamp".
+	TimeColumn string
+	// Precision is the precision of TimeColumn, 1 to 9. Default 3 (milliseconds).
+	Precision int
+	// ValueColumn is the Float64 column of the values. Default "value".
+	ValueColumn string
+	// LabelColumns are the labels stored in LowCardinality(String) columns of the same name, which make
+	// up the sorting key of the table. Points without one of them store an empty string.
+	LabelColumns []string
+	// LabelsColumn is the Map(LowCardinality(String), LowCardinality(String)) column of the labels which
+	// are not LabelColumns. Default "labels".
+	LabelsColumn string
+}
+
+func (s Schema) setDefaults() Schema {
+	if len(s.TimeColumn) == 0 {
+		s.TimeColumn = "timestamp"
+	}
+	if s.Precision == 0 {
+		s.Precision = 3
+	}
+	if len(s.ValueColumn) == 0 {
+		s.ValueColumn = "value"
+	}
+	if len(s.LabelsColumn) == 0 {
+		s.LabelsColumn = "labels"
+	}
+	return s
+}
+
+// Validate checks that the table is named, the precision is valid and the columns are distinct.
+func (s Schema) Validate() error {
+	s = s.setDefaults()
+	if len(s.Table) == 0 {
+		return fmt.Errorf("%w: table name is empty", ErrInvalidSchema)
+	}
+	if s.Precision < 1 || s.Precision > 9 {
+		return fmt.Errorf("%w: precision %d is not between 1 and 9", ErrInvalidSchema, s.Precision)
+	}
+	seen := make(map[string]struct{})
+	for _, column := range s.columns() {
+		if len(column) == 0 {
+			return fmt.Errorf("%w: label column name is empty", ErrInvalidSchema)
+		}
+		if _, ok := seen[column]; ok {
+			return fmt.Errorf("%w: column %s is used twice", ErrInvalidSchema, column)
+		}
+		seen[column] = struct{}{}
+	}
+	return nil
+}
+
+// columns are the names of the columns in insert order.
+func (s Schema) columns() []string {
+	return append(append([]string{s.TimeColumn}, s.LabelColumns...), s.LabelsColumn, s.ValueColumn)
+}
+
+// CreateTable returns the definition of a MergeTree table for the schema, partitioned by day, sorted by the
+// label columns and the timestamp, and with codecs suited to timestamps and gauges. It can be adjusted, e.g.
+// with a TTL or a replicated engine, before it is created.
+func (s Schema) CreateTable() ddl.CreateTable {
+	s = s.setDefaults()
+	table := ddl.CreateTable{
+		Database:    s.Database,
+		Name:        s.Table,
+		IfNotExists: true,
+		Columns: []ddl.Column{
+			{Name: s.TimeColumn, Type: fmt.Sprintf("DateTime64(%d)", s.Precision), Codec: "DoubleDelta, ZSTD"},
+		},
+		Engine:      ddl.Engine{Name: "MergeTree"},
+		PartitionBy: "toDate(" + ddl.Identifier(s.TimeColumn) + ")",
+	}
+	for _, label := range s.LabelColumns {
+		table.Columns = append(table.Columns, ddl.Column{Name: label, Type: "LowCardinality(String)"})
+		table.OrderBy = append(table.OrderBy, ddl.Identifier(label))
+	}
+	table.Columns = append(table.Columns,
+		ddl.Column{Name: s.LabelsColumn, Type: "Map(LowCardinality(String), LowCardinality(String))"},
+		ddl.Column{Name: s.ValueColumn, Type: "Float64", Codec: "Gorilla, ZSTD"},
+	)
+	table.OrderBy = append(table.OrderBy, ddl.Identifier(s.TimeColumn))
+	return table
+}
+
+// Insert returns the INSERT statement of the schema.
+func (s Schema) Insert() string {
+	s = s.setDefaults()
+	columns := s.columns()
+	for i, column := range columns {
+		columns[i] = ddl.Identifier(column)
+	}
+	table := ddl.Identifier(s.Table)
+	if len(s.Database) != 0 {
+		table = ddl.Identifier(s.Database) + "." + table
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s)", table, strings.Join(columns, ", "))
+}
+
+// Appender buffers points and inserts them, column by column, on Flush. It is safe for concurrent use.
+type Appender struct {
+	conn     driver.Conn
+	schema   Schema
+	query    string
+	position map[string]int // index of the label columns
+	mu       sync.Mutex
+	interned map[string]string
+	times    []time.Time
+	columns  [][]string
+	labels   []map[string]string
+	values   []float64
+}
+
+func NewAppender(conn driver.Conn, schema Schema) (*Appender, error) {
+	if err := schema.Validate(); err != nil {
+		return nil, err
+	}
+	schema = schema.setDefaults()
+	a := &Appender{
+		conn:     conn,
+		schema:   schema,
+		query:    schema.Insert(),
+		position: make(map[string]int, len(schema.LabelColumns)),
+		interned: make(map[string]string),
+		columns:  make([][]string, len(schema.LabelColumns)),
+	}
+	for i, label := range schema.LabelColumns {
+		a.position[label] = i
+	}
+	return a, nil
+}
+
+// Append buffers points until the next Flush. The label maps of the points are not retained.
+func (a *Appender) Append(points ...Point) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, p := range points {
+		var (
+			row   = len(a.times)
+			other map[string]string
+		)
+		for i := range a.columns {
+			a.columns[i] = append(a.columns[i], "")
+		}
+		for k, v := range p.Labels {
+			if i, ok := a.position[k]; ok {
+				a.columns[i][row] = a.intern(v)
+				continue
+			}
+			if other == nil {
+				other = make(map[string]string, len(p.Labels))
+			}
+			other[a.intern(k)] = a.intern(v)
+		}
+		if other == nil {
+			other = map[string]string{}
+		}
+		a.times = append(a.times, p.Time)
+		a.labels = append(a.labels, other)
+		a.values = append(a.values, p.Value)
+	}
+	return nil
+}
+
+// intern returns the buffered copy of s, so repeated label names and values share their memory.
+func (a *Appender) intern(s string) string {
+	if v, ok := a.interned[s]; ok {
+		return v
+	}
+	if len(a.interned) >= maxInterned {
+		a.interned = make(map[string]string)
+	}
+	a.interned[s] = s
+	return s
+}
+
+// Len returns the number of buffered points.
+func (a *Appender) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.times)
+}
+
+// Flush inserts the buffered points as one batch. The points are kept if the insert fails, so Flush can be
+// retried.
+func (a *Appender) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.times) == 0 {
+		return nil
+	}
+	batch, err := a.conn.PrepareBatch(ctx, a.query)
+	if err != nil {
+		return err
+	}
+	columns := make([]interface{}, 0, len(a.columns)+3)
+	columns = append(columns, a.times)
+	for _, column := range a.columns {
+		columns = append(columns, column)
+	}
+	columns = append(columns, a.labels, a.values)
+	for i, column := range columns {
+		if err := batch.Column(i).Append(column); err != nil {
+			batch.Abort()
+			return fmt.Errorf("clickhouse [timeseries]: column %s: %w", a.schema.columns()[i], err)
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return err
+	}
+	a.times, a.labels, a.values = a.times[:0], a.labels[:0], a.values[:0]
+	for i := range a.columns {
+		a.columns[i] = a.columns[i][:0]
+	}
+	return nil
+}
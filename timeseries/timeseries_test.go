@@ -0,0 +1,114 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package timeseries
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeConn struct {
+	driver.Conn
+	query   string
+	columns []interface{}
+	fail    error
+}
+
+func (c *fakeConn) PrepareBatch(ctx context.Context, query string) (driver.Batch, error) {
+	c.query, c.columns = query, nil
+	return &fakeBatch{conn: c}, nil
+}
+
+type fakeBatch struct {
+	driver.Batch
+	conn *fakeConn
+}
+
+func (b *fakeBatch) Column(i int) driver.BatchColumn { return &fakeColumn{batch: b, index: i} }
+func (b *fakeBatch) Abort() error                    { return nil }
+func (b *fakeBatch) Send() error                     { return b.conn.fail }
+
+type fakeColumn struct {
+	driver.BatchColumn
+	batch *fakeBatch
+	index int
+}
+
+func (c *fakeColumn) Append(v interface{}) error {
+	c.batch.conn.columns = append(c.batch.conn.columns, v)
+	return nil
+}
+
+func TestSchema(t *testing.T) {
+	schema := Schema{Database: "db", Table: "metrics", LabelColumns: []string{"metric", "host"}}
+	require.NoError(t, schema.Validate())
+	assert.Equal(t, "INSERT INTO `db`.`metrics` (`timestamp`, `metric`, `host`, `labels`, `value`)", schema.Insert())
+	create := schema.CreateTable()
+	assert.Equal(t, strings.Join([]string{
+		"CREATE TABLE IF NOT EXISTS `db`.`metrics`",
+		"(",
+		"    `timestamp` DateTime64(3) CODEC(DoubleDelta, ZSTD),",
+		"    `metric` LowCardinality(String),",
+		"    `host` LowCardinality(String),",
+		"    `labels` Map(LowCardinality(String), LowCardinality(String)),",
+		"    `value` Float64 CODEC(Gorilla, ZSTD)",
+		")",
+		"ENGINE = MergeTree()",
+		"PARTITION BY toDate(`timestamp`)",
+		"ORDER BY (`metric`, `host`, `timestamp`)",
+	}, "\n"), create.SQL())
+
+	assert.ErrorIs(t, Schema{}.Validate(), ErrInvalidSchema)
+	assert.ErrorIs(t, Schema{Table: "metrics", Precision: 10}.Validate(), ErrInvalidSchema)
+	assert.ErrorIs(t, Schema{Table: "metrics", LabelColumns: []string{"value"}}.Validate(), ErrInvalidSchema)
+}
+
+func TestAppender(t *testing.T) {
+	conn := &fakeConn{}
+	appender, err := NewAppender(conn, Schema{Table: "metrics", LabelColumns: []string{"metric"}})
+	require.NoError(t, err)
+	now := time.Now()
+	require.NoError(t, appender.Append(
+		Point{Time: now, Labels: map[string]string{"metric": "cpu", "core": "0"}, Value: 1},
+		Point{Time: now, Labels: map[string]string{"core": "1"}, Value: 2},
+	))
+	assert.Equal(t, 2, appender.Len())
+
+	conn.fail = errors.New("connection refused")
+	assert.Error(t, appender.Flush(context.Background()))
+	assert.Equal(t, 2, appender.Len())
+
+	conn.fail = nil
+	require.NoError(t, appender.Flush(context.Background()))
+	assert.Equal(t, "INSERT INTO `metrics` (`timestamp`, `metric`, `labels`, `value`)", conn.query)
+	assert.Equal(t, []interface{}{
+		[]time.Time{now, now},
+		[]string{"cpu", ""},
+		[]map[string]string{{"core": "0"}, {"core": "1"}},
+		[]float64{1, 2},
+	}, conn.columns)
+	assert.Equal(t, 0, appender.Len())
+	assert.Len(t, appender.interned, 4)
+}
@@ -0,0 +1,165 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// UpsertMode is how Upsert removes the rows replaced by the inserted ones.
+type UpsertMode int
+
+const (
+	// UpsertFinal leaves the replaced rows to the background merges of the table. Until they are merged,
+	// the table must be read with FINAL, e.g. SELECT * FROM table FINAL, to only see the latest rows.
+	UpsertFinal UpsertMode = iota
+	// UpsertOptimize runs OPTIMIZE TABLE ... FINAL after the insert, merging the parts of the table, or of
+	// UpsertOptions.Partition, at once. It is expensive on large tables and meant for small or rare upserts.
+	UpsertOptimize
+	// UpsertOptimizeDeduplicate is UpsertOptimize also removing the rows duplicated in all columns.
+	UpsertOptimizeDeduplicate
+)
+
+// UpsertOptions describes the ReplacingMergeTree table of Upsert.
+type UpsertOptions struct {
+	// Table is the table, optionally qualified with its database, with a ReplacingMergeTree engine: rows with
+	// the same sorting key replace each other, the one with the highest version being kept.
+	Table string
+	// VersionColumn is the version column of the engine, ReplacingMergeTree(VersionColumn). Without it, the
+	// last inserted row is kept, which is not deterministic for rows inserted concurrently.
+	VersionColumn string
+	// Version returns the version of the rows which leave VersionColumn zero, or do not have the column.
+	// Default uint64(time.Now().UnixNano()), for UInt64 versions; DateTime64 versions can use time.Now().
+	Version func() interface{}
+	// Mode is how the replaced rows are removed.
+	Mode UpsertMode
+	// Partition is the partition expression OPTIMIZE is restricted to by UpsertOptimize.
+	Partition string
+	// CheckEngine verifies that Table is a ReplacingMergeTree before inserting, as the rows of other
+	// engines are never replaced.
+	CheckEngine bool
+}
+
+// ErrUpsertEngine is returned by Upsert with CheckEngine if the table is not a ReplacingMergeTree.
+var ErrUpsertEngine = errors.New("clickhouse: upsert requires a ReplacingMergeTree table")
+
+// Upsert inserts rows, structs or pointers to structs mapped to columns as by AppendStruct, so they replace
+// the rows of the table with the same sorting key. ClickHouse has no UPDATE of single rows: the replaced
+// rows are only removed by merges, as configured with opts.Mode.
+func Upsert[T any](ctx context.Context, conn driver.Conn, opts UpsertOptions, rows []T) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if opts.CheckEngine {
+		if err := checkUpsertEngine(ctx, conn, opts.Table); err != nil {
+			return err
+		}
+	}
+	if opts.Version == nil {
+		opts.Version = func() interface{} {
+			return uint64(time.Now().UnixNano())
+		}
+	}
+	var zero T
+	columns, err := StructColumns(zero)
+	if err != nil {
+		return err
+	}
+	version := -1
+	for i, column := range columns {
+		if column == opts.VersionColumn {
+			version = i
+		}
+	}
+	insert := columns
+	if version == -1 && len(opts.VersionColumn) != 0 {
+		version, insert = len(columns), append(columns[:len(columns):len(columns)], opts.VersionColumn)
+	}
+	quoted := make([]string, 0, len(insert))
+	for _, column := range insert {
+		quoted = append(quoted, quoteIdentifier(column))
+	}
+	batch, err := conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s (%s)", opts.Table, strings.Join(quoted, ", ")))
+	if err != nil {
+		return err
+	}
+	var (
+		m = &structMap{}
+		v = opts.Version()
+	)
+	for i := range rows {
+		var row interface{} = &rows[i]
+		if reflect.ValueOf(rows[i]).Kind() == reflect.Ptr {
+			row = rows[i]
+		}
+		values, err := m.Map("Upsert", columns, row, false)
+		if err != nil {
+			batch.Abort()
+			return err
+		}
+		switch {
+		case version == len(values):
+			values = append(values, v)
+		case version != -1 && isZero(values[version]):
+			values[version] = v
+		}
+		if err := batch.Append(values...); err != nil {
+			batch.Abort()
+			return err
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return err
+	}
+	switch opts.Mode {
+	case UpsertOptimize, UpsertOptimizeDeduplicate:
+		return OptimizeTable(ctx, conn, OptimizeOptions{
+			Table:       opts.Table,
+			Partition:   opts.Partition,
+			Final:       true,
+			Deduplicate: opts.Mode == UpsertOptimizeDeduplicate,
+		})
+	}
+	return nil
+}
+
+func isZero(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return !rv.IsValid() || rv.IsZero()
+}
+
+func checkUpsertEngine(ctx context.Context, conn driver.Conn, table string) error {
+	var (
+		database, args = tableArgs(table)
+		engine         string
+	)
+	if err := conn.QueryRow(ctx, "SELECT engine FROM system.tables WHERE database = "+database+" AND name = @table", args...).Scan(&engine); err != nil {
+		return err
+	}
+	if !strings.HasSuffix(engine, "ReplacingMergeTree") {
+		return fmt.Errorf("%w, %s is %s", ErrUpsertEngine, table, engine)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type upsertRow struct {
+	ID      uint64 `ch:"id"`
+	Name    string `ch:"name"`
+	Version uint64 `ch:"version"`
+}
+
+// upsertConn records the statements and the rows of the batches run on it.
+type upsertConn struct {
+	driver.Conn
+	statements []string
+	rows       [][]interface{}
+}
+
+func (c *upsertConn) PrepareBatch(ctx context.Context, query string) (driver.Batch, error) {
+	c.statements = append(c.statements, query)
+	return &upsertBatch{conn: c}, nil
+}
+
+func (c *upsertConn) Exec(ctx context.Context, query string, args ...interface{}) error {
+	c.statements = append(c.statements, query)
+	return nil
+}
+
+type upsertBatch struct {
+	driver.Batch
+	conn *upsertConn
+}
+
+func (b *upsertBatch) Append(v ...interface{}) error {
+	b.conn.rows = append(b.conn.rows, v)
+	return nil
+}
+
+func (b *upsertBatch) Send() error { return nil }
+
+func TestUpsert(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		conn = &upsertConn{}
+		opts = UpsertOptions{
+			Table:         "db.users",
+			VersionColumn: "version",
+			Version:       func() interface{} { return uint64(42) },
+		}
+	)
+	require.NoError(t, Upsert(ctx, conn, opts, []upsertRow{{ID: 1, Name: "a"}, {ID: 2, Name: "b", Version: 7}}))
+	assert.Equal(t, []string{"INSERT INTO db.users (id, name, version)"}, conn.statements)
+	assert.Equal(t, [][]interface{}{{uint64(1), "a", uint64(42)}, {uint64(2), "b", uint64(7)}}, conn.rows)
+
+	type partial struct {
+		ID   uint64 `ch:"id"`
+		Name string `ch:"name"`
+	}
+	conn = &upsertConn{}
+	opts.Mode, opts.Partition = UpsertOptimizeDeduplicate, "202301"
+	require.NoError(t, Upsert(ctx, conn, opts, []*partial{{ID: 1, Name: "a"}}))
+	assert.Equal(t, []string{
+		"INSERT INTO db.users (id, name, version)",
+		"OPTIMIZE TABLE db.users PARTITION 202301 FINAL DEDUPLICATE",
+	}, conn.statements)
+	assert.Equal(t, [][]interface{}{{uint64(1), "a", uint64(42)}}, conn.rows)
+
+	conn = &upsertConn{}
+	require.NoError(t, Upsert(ctx, conn, UpsertOptions{Table: "users"}, []partial{{ID: 1}}))
+	assert.Equal(t, []string{"INSERT INTO users (id, name)"}, conn.statements)
+	require.NoError(t, Upsert(ctx, conn, UpsertOptions{Table: "users"}, []partial{}))
+	assert.Len(t, conn.statements, 1, "nothing to upsert")
+}
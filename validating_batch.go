@@ -0,0 +1,176 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+)
+
+var ErrTooManyRejected = errors.New("clickhouse [validating batch]: too many rejected rows")
+
+type ValidationOptions struct {
+	// MaxRejected makes Append return ErrTooManyRejected once more rows than this were rejected. Zero means no limit.
+	MaxRejected int
+}
+
+// RejectedRow is a row which could not be appended to a ValidatingBatch.
+type RejectedRow struct {
+	// Index is the position of the row among all rows passed to Append.
+	Index  int
+	Values []interface{}
+	Err    error
+}
+
+// BatchReport summarises the rows of a ValidatingBatch.
+type BatchReport struct {
+	Appended int
+	Rejected []RejectedRow
+}
+
+// ValidatingBatch is a batch which rejects rows that fail to convert instead of becoming invalid.
+// Every row is first appended to a single row block, so a bad value never leaves the batch columns
+// misaligned, and Send inserts the remaining rows and reports the rejected ones.
+type ValidatingBatch struct {
+	opts     ValidationOptions
+	batch    *batch
+	prepared driver.Batch // returned by PrepareBatch: batch or its wrapper, e.g. of an interceptor
+	staging  *proto.Block
+	mapping  *ColumnMapping
+	rows     int
+	rejected []RejectedRow
+}
+
+// NewValidatingBatch prepares an insert on conn. Only native protocol connections opened with Open are supported.
+func NewValidatingBatch(ctx context.Context, conn driver.Conn, query string, opts ValidationOptions) (*ValidatingBatch, error) {
+	ch, ok := conn.(*clickhouse)
+	if !ok {
+		return nil, ErrBatchWriterUnsupported
+	}
+	b, err := ch.PrepareBatch(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	nb, ok := nativeBatch(b)
+	if !ok {
+		b.Abort()
+		return nil, ErrBatchWriterUnsupported
+	}
+	staging := &proto.Block{Timezone: nb.block.Timezone, Conversion: nb.block.Conversion, FixedString: nb.block.FixedString, BoolMapping: nb.block.BoolMapping}
+	for _, col := range nb.block.Columns {
+		if err := staging.AddColumn(col.Name(), col.Type()); err != nil {
+			b.Abort()
+			return nil, err
+		}
+	}
+	return &ValidatingBatch{
+		opts:     opts,
+		batch:    nb,
+		prepared: b,
+		staging:  staging,
+		mapping:  queryOptions(ctx).columnMapping,
+	}, nil
+}
+
+// Append appends a row. A row which fails to convert is recorded as rejected and nil is returned,
+// the error is only returned for a sent or failed batch or when ValidationOptions.MaxRejected is exceeded.
+func (b *ValidatingBatch) Append(v ...interface{}) error {
+	if b.batch.sent {
+		return ErrBatchAlreadySent
+	}
+	if b.batch.err != nil {
+		return b.batch.err
+	}
+	index := b.rows
+	b.rows++
+	converted, err := convertRow("batch.Append", b.batch.converters, b.batch.block.ColumnsNames(), v)
+	if err == nil {
+		err = b.validate(converted)
+	}
+	if err != nil {
+		return b.reject(index, v, err)
+	}
+	return b.batch.appendRow(converted)
+}
+
+// AppendStruct appends the fields of the struct v, see Append.
+func (b *ValidatingBatch) AppendStruct(v interface{}) error {
+	values, err := b.batch.conn.structMap.MapColumns("AppendStruct", b.batch.block.ColumnsNames(), v, false, b.mapping)
+	if err != nil {
+		index := b.rows
+		b.rows++
+		return b.reject(index, []interface{}{v}, err)
+	}
+	return b.Append(values...)
+}
+
+func (b *ValidatingBatch) validate(v []interface{}) error {
+	b.staging.Reset()
+	return b.staging.Append(applyNullStrategy(b.staging, b.batch.nulls, v, nil)...)
+}
+
+func (b *ValidatingBatch) reject(index int, v []interface{}, err error) error {
+	b.rejected = append(b.rejected, RejectedRow{
+		Index:  index,
+		Values: append([]interface{}(nil), v...),
+		Err:    err,
+	})
+	if b.opts.MaxRejected > 0 && len(b.rejected) > b.opts.MaxRejected {
+		return fmt.Errorf("%w: %d (last: %s)", ErrTooManyRejected, len(b.rejected), err)
+	}
+	return nil
+}
+
+// Rejected returns the rows rejected so far.
+func (b *ValidatingBatch) Rejected() []RejectedRow {
+	return b.rejected
+}
+
+// Send inserts the appended rows and returns the report of appended and rejected rows.
+func (b *ValidatingBatch) Send() (BatchReport, error) {
+	report := BatchReport{
+		Appended: b.rows - len(b.rejected),
+		Rejected: b.rejected,
+	}
+	return report, b.prepared.Send()
+}
+
+func (b *ValidatingBatch) Flush() error {
+	return b.prepared.Flush()
+}
+
+func (b *ValidatingBatch) Abort() error {
+	return b.prepared.Abort()
+}
+
+// Reset clears the appended and rejected rows so that the batch can be reused, see driver.ResettableBatch.
+func (b *ValidatingBatch) Reset() error {
+	if err := b.batch.Reset(); err != nil {
+		return err
+	}
+	b.rows, b.rejected = 0, nil
+	return nil
+}
+
+func (b *ValidatingBatch) IsSent() bool {
+	return b.batch.IsSent()
+}
@@ -0,0 +1,52 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatingBatchAppend(t *testing.T) {
+	newBlock := func() *proto.Block {
+		block := &proto.Block{}
+		require.NoError(t, block.AddColumn("id", "UInt64"))
+		require.NoError(t, block.AddColumn("tags", "Array(String)"))
+		return block
+	}
+	b := &ValidatingBatch{
+		opts:    ValidationOptions{MaxRejected: 2},
+		batch:   &batch{block: newBlock()},
+		staging: newBlock(),
+	}
+	require.NoError(t, b.Append(uint64(1), []string{"a"}))
+	require.NoError(t, b.Append(uint64(2), "not an array"))
+	require.NoError(t, b.Append(uint64(3), []string{}))
+	require.NoError(t, b.Append("x", []string{}))
+	assert.Equal(t, 2, b.batch.block.Rows())
+	require.Len(t, b.Rejected(), 2)
+	assert.Equal(t, 1, b.Rejected()[0].Index)
+	assert.Equal(t, []interface{}{uint64(2), "not an array"}, b.Rejected()[0].Values)
+	assert.Error(t, b.Rejected()[0].Err)
+	assert.Equal(t, 3, b.Rejected()[1].Index)
+	assert.ErrorIs(t, b.Append(uint64(5)), ErrTooManyRejected)
+	assert.Equal(t, 2, b.batch.block.Rows())
+}
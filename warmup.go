@@ -0,0 +1,199 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// WarmupOptions configures Warmup.
+type WarmupOptions struct {
+	// Ping pings every connection after its handshake, so that a connection is only pooled once the server
+	// has answered a request.
+	Ping bool
+	// Concurrency is the number of connections established at once. Default 4.
+	Concurrency int
+	// OnProgress, if set, is called after every connection attempt, one call at a time.
+	OnProgress func(progress WarmupProgress)
+}
+
+// WarmupProgress is the progress of a Warmup, see WarmupOptions.OnProgress.
+type WarmupProgress struct {
+	Addr      string // the address of the attempt
+	Err       error  // the error of the attempt, if it failed
+	Connected int    // the connections established so far
+	Failed    int    // the failed attempts so far
+	Total     int    // the connections to establish
+}
+
+// WarmupHost is the outcome of a Warmup for a host address.
+type WarmupHost struct {
+	Addr      string
+	Connected int   // connections established and pooled
+	Failed    int   // failed attempts, dials or pings
+	Err       error // the last error
+}
+
+// Warmup establishes n idle connections in the pool of conn, which must have been returned by Open, so that
+// the first queries after a start do not pay for the dials and handshakes. The connections are dialed with
+// the DialStrategy of the options, so they spread over the hosts like the connections of the queries, and
+// n is capped at the free idle slots of the pool (MaxIdleConns). With ReadAddr, the read pool is warmed up
+// as well. Warmup returns the outcome per host address, and an error if fewer connections than requested
+// could be established.
+func Warmup(ctx context.Context, conn driver.Conn, n int, opts WarmupOptions) ([]WarmupHost, error) {
+	ch, ok := conn.(*clickhouse)
+	if !ok {
+		return nil, fmt.Errorf("clickhouse [warmup]: requires a connection of Open, not %T", conn)
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	w := &warmup{
+		opts:  opts,
+		hosts: make(map[string]*WarmupHost),
+	}
+	pools := []*clickhouse{ch}
+	if ch.reader != nil {
+		pools = append(pools, ch.reader)
+	}
+	for _, pool := range pools {
+		if free := cap(pool.idle) - len(pool.idle); n > free {
+			w.total += free
+		} else {
+			w.total += n
+		}
+	}
+	for _, pool := range pools {
+		w.run(ctx, pool, n)
+	}
+	hosts := make([]WarmupHost, 0, len(w.hosts))
+	for _, host := range w.hosts {
+		hosts = append(hosts, *host)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].Addr < hosts[j].Addr
+	})
+	if w.connected < w.total {
+		if w.err == nil {
+			w.err = ctx.Err()
+		}
+		return hosts, fmt.Errorf("clickhouse [warmup]: %d of %d connections established: %w", w.connected, w.total, w.err)
+	}
+	return hosts, nil
+}
+
+type warmup struct {
+	opts      WarmupOptions
+	mu        sync.Mutex
+	hosts     map[string]*WarmupHost
+	connected int
+	failed    int
+	total     int
+	err       error
+}
+
+func (w *warmup) run(ctx context.Context, ch *clickhouse, n int) {
+	if free := cap(ch.idle) - len(ch.idle); n > free {
+		n = free
+	}
+	var (
+		wg    sync.WaitGroup
+		slots = make(chan struct{}, w.opts.Concurrency)
+	)
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case slots <- struct{}{}:
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			w.connect(ctx, ch)
+		}()
+	}
+	wg.Wait()
+}
+
+// connect dials a connection and adds it to the idle connections of the pool.
+func (w *warmup) connect(ctx context.Context, ch *clickhouse) {
+	conn, err := ch.dialObserved(ctx, func(addr string, err error) {
+		if err != nil {
+			w.observe(addr, err)
+		}
+	})
+	if err != nil {
+		w.mu.Lock()
+		w.err = err
+		w.mu.Unlock()
+		return
+	}
+	// the connection is idle rather than in use
+	ch.hosts.release(conn.addr)
+	if w.opts.Ping {
+		if err := conn.ping(ctx); err != nil {
+			conn.close()
+			w.observe(conn.addr, err)
+			return
+		}
+	}
+	select {
+	case ch.idle <- conn:
+		w.observe(conn.addr, nil)
+	default:
+		conn.close()
+		w.observe(conn.addr, fmt.Errorf("clickhouse [warmup]: the pool has no idle slot left"))
+	}
+}
+
+func (w *warmup) observe(addr string, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	host, found := w.hosts[addr]
+	if !found {
+		host = &WarmupHost{Addr: addr}
+		w.hosts[addr] = host
+	}
+	if err != nil {
+		host.Failed++
+		host.Err = err
+		w.failed++
+		w.err = err
+	} else {
+		host.Connected++
+		w.connected++
+	}
+	if w.opts.OnProgress != nil {
+		w.opts.OnProgress(WarmupProgress{
+			Addr:      addr,
+			Err:       err,
+			Connected: w.connected,
+			Failed:    w.failed,
+			Total:     w.total,
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmup(t *testing.T) {
+	conn, err := Open(&Options{
+		Addr:             []string{"a:9000", "b:9000"},
+		ConnOpenStrategy: ConnOpenRoundRobin,
+		MaxIdleConns:     3,
+		DialContext: func(ctx context.Context, addr string) (net.Conn, error) {
+			if addr == "b:9000" {
+				return nil, errors.New("connection refused")
+			}
+			return &replayConn{data: fakeServer(proto.ServerPong)}, nil
+		},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var progress []WarmupProgress
+	hosts, err := Warmup(context.Background(), conn, 5, WarmupOptions{
+		Ping: true,
+		OnProgress: func(p WarmupProgress) {
+			progress = append(progress, p)
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, hosts, 2)
+	assert.Equal(t, "a:9000", hosts[0].Addr)
+	assert.Equal(t, 3, hosts[0].Connected)
+	assert.Zero(t, hosts[0].Failed)
+	assert.Equal(t, "b:9000", hosts[1].Addr)
+	assert.Zero(t, hosts[1].Connected)
+	assert.NotZero(t, hosts[1].Failed)
+	assert.Error(t, hosts[1].Err)
+	last := progress[len(progress)-1]
+	assert.Equal(t, 3, last.Connected)
+	assert.Equal(t, 3, last.Total)
+	assert.Equal(t, 3, len(conn.(*clickhouse).idle))
+
+	// the pool is full
+	hosts, err = Warmup(context.Background(), conn, 1, WarmupOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, hosts)
+}
+
+func TestWarmupFailure(t *testing.T) {
+	conn, err := Open(&Options{
+		Addr: []string{"a:9000"},
+		DialContext: func(ctx context.Context, addr string) (net.Conn, error) {
+			return nil, errors.New("connection refused")
+		},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	hosts, err := Warmup(context.Background(), conn, 2, WarmupOptions{})
+	assert.ErrorContains(t, err, "0 of 2 connections established: connection refused")
+	require.Len(t, hosts, 1)
+	assert.Equal(t, 2, hosts[0].Failed)
+}
@@ -0,0 +1,165 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// WatchOptions configures how a Subscription resubscribes after its stream broke.
+type WatchOptions struct {
+	// MaxRetries is the number of consecutive failed resubscriptions before Next gives up. Default 3, a
+	// negative value disables resubscribing. The count is reset once a row was delivered.
+	MaxRetries int
+	// RetryInterval is the delay before resubscribing. Default 1 second.
+	RetryInterval time.Duration
+	// OnResubscribe is called with the error which ended the stream before resubscribing.
+	OnResubscribe func(err error)
+}
+
+func (o *WatchOptions) setDefaults() {
+	if o.MaxRetries < 0 {
+		o.MaxRetries = 0
+	} else if o.MaxRetries == 0 {
+		o.MaxRetries = 3
+	}
+	if o.RetryInterval <= 0 {
+		o.RetryInterval = time.Second
+	}
+}
+
+// Subscription reads the rows of a long-lived streaming query, e.g. WATCH on a live or window view,
+// as the server sends them. If the connection breaks, the query is sent again on a new connection of
+// the pool. WATCH starts with the current result of the view, so rows already read may be delivered
+// again after a resubscription; their _version column tells them apart.
+type Subscription struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	conn    driver.Conn
+	query   string
+	args    []interface{}
+	opts    WatchOptions
+	rows    driver.Rows
+	columns []string
+	err     error
+}
+
+// Watch sends query, typically WATCH, and returns a Subscription delivering its rows until ctx is
+// cancelled, the server ends the stream (e.g. WATCH ... LIMIT 1) or Close is called. An error of the
+// first attempt is returned as is.
+func Watch(ctx context.Context, conn driver.Conn, opts WatchOptions, query string, args ...interface{}) (*Subscription, error) {
+	opts.setDefaults()
+	ctx, cancel := context.WithCancel(ctx)
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &Subscription{
+		ctx:     ctx,
+		cancel:  cancel,
+		conn:    conn,
+		query:   query,
+		args:    args,
+		opts:    opts,
+		rows:    rows,
+		columns: rows.Columns(),
+	}, nil
+}
+
+// Next prepares the next row for Scan, blocking until the server sends it. It returns false once the
+// stream ended, see Err.
+func (s *Subscription) Next() bool {
+	failures := 0
+	for s.rows != nil {
+		if s.rows.Next() {
+			return true
+		}
+		err := s.rows.Err()
+		s.rows.Close()
+		s.rows = nil
+		switch {
+		case s.ctx.Err() != nil:
+			s.err = s.ctx.Err()
+		case err != nil:
+			s.err = s.resubscribe(err, &failures)
+		}
+	}
+	return false
+}
+
+func (s *Subscription) resubscribe(err error, failures *int) error {
+	for {
+		var exception *Exception
+		if errors.As(err, &exception) || *failures >= s.opts.MaxRetries {
+			return err
+		}
+		*failures++
+		if s.opts.OnResubscribe != nil {
+			s.opts.OnResubscribe(err)
+		}
+		select {
+		case <-time.After(s.opts.RetryInterval):
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+		var rows driver.Rows
+		if rows, err = s.conn.Query(s.ctx, s.query, s.args...); err == nil {
+			s.rows = rows
+			return nil
+		}
+	}
+}
+
+func (s *Subscription) Scan(dest ...interface{}) error {
+	if s.rows == nil {
+		return io.EOF
+	}
+	return s.rows.Scan(dest...)
+}
+
+func (s *Subscription) ScanStruct(dest interface{}) error {
+	if s.rows == nil {
+		return io.EOF
+	}
+	return s.rows.ScanStruct(dest)
+}
+
+func (s *Subscription) Columns() []string {
+	return s.columns
+}
+
+// Err returns the error which ended the subscription, nil if the server ended the stream.
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+// Close stops the query and releases its connection.
+func (s *Subscription) Close() error {
+	s.cancel()
+	if s.rows != nil {
+		s.rows.Close()
+		s.rows = nil
+	}
+	return nil
+}
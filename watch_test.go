@@ -0,0 +1,119 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type watchRows struct {
+	driver.Rows
+	values []int
+	err    error
+}
+
+func (r *watchRows) Next() bool {
+	if len(r.values) == 0 {
+		return false
+	}
+	r.values = r.values[1:]
+	return true
+}
+
+func (r *watchRows) Columns() []string { return []string{"value"} }
+func (r *watchRows) Err() error        { return r.err }
+func (r *watchRows) Close() error      { return nil }
+
+type watchConn struct {
+	driver.Conn
+	streams []*watchRows
+	queries int
+}
+
+func (c *watchConn) Query(ctx context.Context, query string, args ...interface{}) (driver.Rows, error) {
+	c.queries++
+	if len(c.streams) == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	rows := c.streams[0]
+	c.streams = c.streams[1:]
+	return rows, nil
+}
+
+func TestSubscriptionResubscribe(t *testing.T) {
+	var (
+		broken = errors.New("connection reset")
+		conn   = &watchConn{streams: []*watchRows{
+			{values: []int{1, 2}, err: broken},
+			{values: []int{3}},
+		}}
+		resubscribed []error
+	)
+	sub, err := Watch(context.Background(), conn, WatchOptions{
+		RetryInterval: time.Millisecond,
+		OnResubscribe: func(err error) {
+			resubscribed = append(resubscribed, err)
+		},
+	}, "WATCH lv")
+	require.NoError(t, err)
+	var rows int
+	for sub.Next() {
+		rows++
+	}
+	require.NoError(t, sub.Err())
+	assert.Equal(t, 3, rows)
+	assert.Equal(t, []error{broken}, resubscribed)
+	assert.Equal(t, []string{"value"}, sub.Columns())
+	assert.ErrorIs(t, sub.Scan(), io.EOF)
+	assert.NoError(t, sub.Close())
+}
+
+func TestSubscriptionMaxRetries(t *testing.T) {
+	conn := &watchConn{streams: []*watchRows{
+		{values: []int{1}, err: io.EOF},
+	}}
+	sub, err := Watch(context.Background(), conn, WatchOptions{
+		MaxRetries:    2,
+		RetryInterval: time.Millisecond,
+	}, "WATCH lv")
+	require.NoError(t, err)
+	require.True(t, sub.Next())
+	require.False(t, sub.Next())
+	assert.ErrorIs(t, sub.Err(), io.ErrUnexpectedEOF)
+	assert.Equal(t, 3, conn.queries)
+}
+
+func TestSubscriptionServerException(t *testing.T) {
+	conn := &watchConn{streams: []*watchRows{
+		{err: &Exception{Code: 60, Message: "table does not exist"}},
+	}}
+	sub, err := Watch(context.Background(), conn, WatchOptions{}, "WATCH lv")
+	require.NoError(t, err)
+	require.False(t, sub.Next())
+	var exception *Exception
+	assert.ErrorAs(t, sub.Err(), &exception)
+	assert.Equal(t, 1, conn.queries)
+}